@@ -40,7 +40,7 @@ var AssetCommand = cli.Command{
 			Name:        "transfer",
 			Usage:       "Transfer onx or oxg to another account",
 			ArgsUsage:   " ",
-			Description: "Transfer onx or oxg to another account. If from address does not specified, using default account",
+			Description: "Transfer onx or oxg to another account. If from address does not specified, using default account. Using --batch to transfer to many accounts in one transaction",
 			Flags: []cli.Flag{
 				utils.RPCPortFlag,
 				utils.TransactionGasPriceFlag,
@@ -49,6 +49,7 @@ var AssetCommand = cli.Command{
 				utils.TransactionFromFlag,
 				utils.TransactionToFlag,
 				utils.TransactionAmountFlag,
+				utils.TransactionBatchFlag,
 				utils.ForceSendTxFlag,
 				utils.WalletFileFlag,
 			},
@@ -136,6 +137,9 @@ var AssetCommand = cli.Command{
 
 func transfer(ctx *cli.Context) error {
 	SetRpcPort(ctx)
+	if ctx.IsSet(utils.GetFlagName(utils.TransactionBatchFlag)) {
+		return transferBatch(ctx)
+	}
 	if !ctx.IsSet(utils.GetFlagName(utils.TransactionToFlag)) ||
 		!ctx.IsSet(utils.GetFlagName(utils.TransactionFromFlag)) ||
 		!ctx.IsSet(utils.GetFlagName(utils.TransactionAmountFlag)) {
@@ -221,6 +225,92 @@ func transfer(ctx *cli.Context) error {
 	return nil
 }
 
+func transferBatch(ctx *cli.Context) error {
+	if !ctx.IsSet(utils.GetFlagName(utils.TransactionFromFlag)) {
+		PrintErrorMsg("Missing %s argument.", utils.TransactionFromFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	if ctx.IsSet(utils.GetFlagName(utils.TransactionToFlag)) || ctx.IsSet(utils.GetFlagName(utils.TransactionAmountFlag)) {
+		PrintErrorMsg("%s cannot be used with %s or %s argument.", utils.TransactionBatchFlag.Name, utils.TransactionToFlag.Name, utils.TransactionAmountFlag.Name)
+		return nil
+	}
+
+	asset := strings.ToLower(ctx.String(utils.GetFlagName(utils.TransactionAssetFlag)))
+	if asset == "" {
+		asset = utils.ASSET_ONX
+	}
+	fromAddr, err := cmdcom.ParseAddress(ctx.String(utils.TransactionFromFlag.Name), ctx)
+	if err != nil {
+		return err
+	}
+	entries, err := utils.ReadBatchTransferFile(asset, ctx.String(utils.GetFlagName(utils.TransactionBatchFlag)))
+	if err != nil {
+		return fmt.Errorf("read batch file error:%s", err)
+	}
+	total := uint64(0)
+	for _, entry := range entries {
+		entry.To, err = cmdcom.ParseAddress(entry.To, ctx)
+		if err != nil {
+			return err
+		}
+		total += entry.Amount
+		if total < entry.Amount {
+			return fmt.Errorf("total amount overflow")
+		}
+	}
+	err = utils.CheckAssetAmount(asset, total)
+	if err != nil {
+		return err
+	}
+
+	force := ctx.Bool(utils.GetFlagName(utils.ForceSendTxFlag))
+	if !force {
+		balance, err := utils.GetAccountBalance(fromAddr, asset)
+		if err != nil {
+			return err
+		}
+		if balance < total {
+			PrintErrorMsg("Account:%s balance not enough.", fromAddr)
+			PrintInfoMsg("\nTip:")
+			PrintInfoMsg("  If you want to send transaction compulsively, please using %s flag.", utils.GetFlagName(utils.ForceSendTxFlag))
+			return nil
+		}
+	}
+
+	gasPrice := ctx.Uint64(utils.TransactionGasPriceFlag.Name)
+	gasLimit := ctx.Uint64(utils.TransactionGasLimitFlag.Name)
+
+	networkId, err := utils.GetNetworkId()
+	if err != nil {
+		return err
+	}
+	if networkId == config.NETWORK_ID_SOLO_NET {
+		gasPrice = 0
+	}
+
+	signer, err := cmdcom.GetAccount(ctx, fromAddr)
+	if err != nil {
+		return err
+	}
+	txHash, err := utils.TransferMulti(gasPrice, gasLimit, signer, asset, entries)
+	if err != nil {
+		return fmt.Errorf("transfer error:%s", err)
+	}
+	totalStr := utils.FormatOnx(total)
+	if asset == utils.ASSET_OXG {
+		totalStr = utils.FormatOxg(total)
+	}
+	PrintInfoMsg("Transfer %s", strings.ToUpper(asset))
+	PrintInfoMsg("  From:%s", fromAddr)
+	PrintInfoMsg("  Receivers:%d", len(entries))
+	PrintInfoMsg("  Total amount:%s", totalStr)
+	PrintInfoMsg("  TxHash:%s", txHash)
+	PrintInfoMsg("\nTip:")
+	PrintInfoMsg("  Using './onyxchain info status %s' to query transaction status.", txHash)
+	return nil
+}
+
 func getBalance(ctx *cli.Context) error {
 	SetRpcPort(ctx)
 	if ctx.NArg() < 1 {
@@ -35,7 +35,10 @@ func SetOnyxChainConfig(ctx *cli.Context) (*config.OnyxChainConfig, error) {
 	if err != nil {
 		return nil, fmt.Errorf("setGenesis error:%s", err)
 	}
-	setCommonConfig(ctx, cfg.Common)
+	err = setCommonConfig(ctx, cfg.Common)
+	if err != nil {
+		return nil, fmt.Errorf("setCommonConfig error:%s", err)
+	}
 	setConsensusConfig(ctx, cfg.Consensus)
 	setP2PNodeConfig(ctx, cfg.P2PNode)
 	setRpcConfig(ctx, cfg.Rpc)
@@ -50,6 +53,9 @@ func SetOnyxChainConfig(ctx *cli.Context) (*config.OnyxChainConfig, error) {
 		cfg.P2PNode.NetworkMagic = config.GetNetworkMagic(cfg.P2PNode.NetworkId)
 		cfg.Common.GasPrice = 0
 	}
+	if cfg.Common.LightNode && cfg.Consensus.EnableConsensus {
+		return nil, fmt.Errorf("lightnode cannot work with consensus")
+	}
 	if cfg.P2PNode.NetworkId == config.NETWORK_ID_MAIN_NET ||
 		cfg.P2PNode.NetworkId == config.NETWORK_ID_POLARIS_NET {
 		defNetworkId, err := cfg.GetDefaultNetworkId()
@@ -108,6 +114,13 @@ func setGenesis(ctx *cli.Context, cfg *config.OnyxChainConfig) error {
 		if cfg.Genesis.DBFT.GenBlockTime <= 0 {
 			cfg.Genesis.DBFT.GenBlockTime = config.DEFAULT_GEN_BLOCK_TIME
 		}
+	case config.CONSENSUS_TYPE_SBFT:
+		if len(cfg.Genesis.SBFT.Bookkeepers) < config.SBFT_MIN_NODE_NUM {
+			return fmt.Errorf("SBFT consensus at least need %d bookkeepers in config", config.SBFT_MIN_NODE_NUM)
+		}
+		if cfg.Genesis.SBFT.GenBlockTime <= 0 {
+			cfg.Genesis.SBFT.GenBlockTime = config.DEFAULT_GEN_BLOCK_TIME
+		}
 	case config.CONSENSUS_TYPE_VBFT:
 		err = governance.CheckVBFTConfig(cfg.Genesis.VBFT)
 		if err != nil {
@@ -123,12 +136,29 @@ func setGenesis(ctx *cli.Context, cfg *config.OnyxChainConfig) error {
 	return nil
 }
 
-func setCommonConfig(ctx *cli.Context, cfg *config.CommonConfig) {
+func setCommonConfig(ctx *cli.Context, cfg *config.CommonConfig) error {
 	cfg.LogLevel = ctx.Uint(utils.GetFlagName(utils.LogLevelFlag))
 	cfg.EnableEventLog = !ctx.Bool(utils.GetFlagName(utils.DisableEventLogFlag))
+	cfg.EnableEventIndex = ctx.Bool(utils.GetFlagName(utils.EventIndexFlag))
+	if cfg.EnableEventIndex && !cfg.EnableEventLog {
+		return fmt.Errorf("event-index cannot work with disable-event-log")
+	}
+	cfg.EnableArchiveMode = ctx.Bool(utils.GetFlagName(utils.ArchiveModeFlag))
 	cfg.GasLimit = ctx.Uint64(utils.GetFlagName(utils.GasLimitFlag))
 	cfg.GasPrice = ctx.Uint64(utils.GetFlagName(utils.GasPriceFlag))
+	cfg.MaxTxInPool = ctx.Uint(utils.GetFlagName(utils.MaxTxInPoolFlag))
+	cfg.MaxTxPerAccount = ctx.Uint(utils.GetFlagName(utils.MaxTxPerAccountFlag))
 	cfg.DataDir = ctx.String(utils.GetFlagName(utils.DataDirFlag))
+	pruneBlocks := ctx.Uint(utils.GetFlagName(utils.PruneBlocksFlag))
+	if pruneBlocks != 0 && pruneBlocks < config.MIN_PRUNE_BLOCKS {
+		return fmt.Errorf("prune-blocks should be 0 or at least %d", config.MIN_PRUNE_BLOCKS)
+	}
+	cfg.PruneBlocks = uint32(pruneBlocks)
+	cfg.LightNode = ctx.Bool(utils.GetFlagName(utils.LightNodeFlag))
+	if cfg.LightNode && (cfg.EnableArchiveMode || cfg.PruneBlocks != 0) {
+		return fmt.Errorf("lightnode cannot work with archive or prune-blocks")
+	}
+	return nil
 }
 
 func setConsensusConfig(ctx *cli.Context, cfg *config.ConsensusConfig) {
@@ -148,6 +178,8 @@ func setP2PNodeConfig(ctx *cli.Context, cfg *config.P2PNodeConfig) {
 	cfg.MaxConnInBound = ctx.Uint(utils.GetFlagName(utils.MaxConnInBoundFlag))
 	cfg.MaxConnOutBound = ctx.Uint(utils.GetFlagName(utils.MaxConnOutBoundFlag))
 	cfg.MaxConnInBoundForSingleIP = ctx.Uint(utils.GetFlagName(utils.MaxConnInBoundForSingleIPFlag))
+	cfg.EnableDHT = ctx.Bool(utils.GetFlagName(utils.DHTFlag))
+	cfg.SecureTransport = ctx.Bool(utils.GetFlagName(utils.SecureTransportFlag))
 
 	rsvfile := ctx.String(utils.GetFlagName(utils.ReservedPeersFileFlag))
 	if cfg.ReservedPeersOnly {
@@ -176,6 +208,7 @@ func setRpcConfig(ctx *cli.Context, cfg *config.RpcConfig) {
 	cfg.HttpLocalPort = ctx.Uint(utils.GetFlagName(utils.RPCLocalProtFlag))
 	cfg.HttpCertPath = ctx.String(utils.GetFlagName(utils.CertFileFlag))
 	cfg.HttpKeyPath = ctx.String(utils.GetFlagName(utils.KeyFileFlag))
+	cfg.EnableTrace = ctx.Bool(utils.GetFlagName(utils.RPCTraceEnableFlag))
 }
 
 func setRestfulConfig(ctx *cli.Context, cfg *config.RestfulConfig) {
@@ -19,25 +19,31 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	cmdcom "github.com/OnyxPay/OnyxChain/cmd/common"
 	"github.com/OnyxPay/OnyxChain/cmd/utils"
 	"github.com/OnyxPay/OnyxChain/common"
 	"github.com/OnyxPay/OnyxChain/common/config"
+	"github.com/OnyxPay/OnyxChain/core/payload"
 	httpcom "github.com/OnyxPay/OnyxChain/http/base/common"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/wasmvm"
 	"github.com/urfave/cli"
 	"io/ioutil"
 	"strings"
 )
 
+var wasmMagic = []byte{0x00, 0x61, 0x73, 0x6d}
+
 var (
 	ContractCommand = cli.Command{
 		Name:        "contract",
 		Action:      cli.ShowSubcommandHelp,
 		Usage:       "Deploy or invoke smart contract",
 		ArgsUsage:   " ",
-		Description: `Smart contract operations support the deployment of NeoVM smart contract, and the pre-execution and execution of NeoVM smart contract.`,
+		Description: `Smart contract operations support the deployment of NeoVM and WASM smart contract, and the pre-execution and execution of NeoVM and WASM smart contract.`,
 		Subcommands: []cli.Command{
 			{
 				Action:    deployContract,
@@ -49,6 +55,7 @@ var (
 					utils.TransactionGasPriceFlag,
 					utils.TransactionGasLimitFlag,
 					utils.ContractStorageFlag,
+					utils.ContractVmTypeFlag,
 					utils.ContractCodeFileFlag,
 					utils.ContractNameFlag,
 					utils.ContractVersionFlag,
@@ -75,6 +82,10 @@ var (
   Note that if string contain some special char like :,[,] and so one, please use '/' char to escape. 
   For example: string:did/:ed1e25c9dccae0c694ee892231407afa20b76008
 
+  WASM contract
+     Use --vmtype wasm to invoke WASM contract, the first parameter is the method name.
+     For example: string:transfer,string:foo,int:100
+
   Return type
      When invoke contract with --prepare flag, you need specifies return type by --return flag, to decode the return value.
      Return type support bytearray(encoded to hex string), string, integer, boolean. 
@@ -86,10 +97,12 @@ var (
 					utils.TransactionGasPriceFlag,
 					utils.TransactionGasLimitFlag,
 					utils.ContractAddrFlag,
+					utils.ContractVmTypeFlag,
 					utils.ContractParamsFlag,
 					utils.ContractVersionFlag,
 					utils.ContractPrepareInvokeFlag,
 					utils.ContractReturnTypeFlag,
+					utils.ContractTraceFlag,
 					utils.WalletFileFlag,
 					utils.AccountAddressFlag,
 				},
@@ -123,6 +136,10 @@ func deployContract(ctx *cli.Context) error {
 	}
 
 	store := ctx.Bool(utils.GetFlagName(utils.ContractStorageFlag))
+	vmType, err := utils.ParseVmType(ctx.String(utils.GetFlagName(utils.ContractVmTypeFlag)))
+	if err != nil {
+		return err
+	}
 	codeFile := ctx.String(utils.GetFlagName(utils.ContractCodeFileFlag))
 	if "" == codeFile {
 		return fmt.Errorf("please specific code file")
@@ -131,13 +148,17 @@ func deployContract(ctx *cli.Context) error {
 	if err != nil {
 		return fmt.Errorf("read code:%s error:%s", codeFile, err)
 	}
+	code := strings.TrimSpace(string(codeStr))
+	//wasm code file can be the binary module
+	if vmType == payload.WASMVM_TYPE && bytes.HasPrefix(codeStr, wasmMagic) {
+		code = hex.EncodeToString(codeStr)
+	}
 
 	name := ctx.String(utils.GetFlagName(utils.ContractNameFlag))
 	version := ctx.String(utils.GetFlagName(utils.ContractVersionFlag))
 	author := ctx.String(utils.GetFlagName(utils.ContractAuthorFlag))
 	email := ctx.String(utils.GetFlagName(utils.ContractEmailFlag))
 	desc := ctx.String(utils.GetFlagName(utils.ContractDescFlag))
-	code := strings.TrimSpace(string(codeStr))
 	gasPrice := ctx.Uint64(utils.GetFlagName(utils.TransactionGasPriceFlag))
 	gasLimit := ctx.Uint64(utils.GetFlagName(utils.TransactionGasLimitFlag))
 	networkId, err := utils.GetNetworkId()
@@ -151,7 +172,7 @@ func deployContract(ctx *cli.Context) error {
 	cversion := fmt.Sprintf("%s", version)
 
 	if ctx.IsSet(utils.GetFlagName(utils.ContractPrepareDeployFlag)) {
-		preResult, err := utils.PrepareDeployContract(store, code, name, cversion, author, email, desc)
+		preResult, err := utils.PrepareDeployContract(store, vmType, code, name, cversion, author, email, desc)
 		if err != nil {
 			return fmt.Errorf("PrepareDeployContract error:%s", err)
 		}
@@ -168,7 +189,7 @@ func deployContract(ctx *cli.Context) error {
 		return fmt.Errorf("get signer account error:%s", err)
 	}
 
-	txHash, err := utils.DeployContract(gasPrice, gasLimit, signer, store, code, name, cversion, author, email, desc)
+	txHash, err := utils.DeployContract(gasPrice, gasLimit, signer, store, vmType, code, name, cversion, author, email, desc)
 	if err != nil {
 		return fmt.Errorf("DeployContract error:%s", err)
 	}
@@ -292,11 +313,21 @@ func invokeContract(ctx *cli.Context) error {
 	if err != nil {
 		return fmt.Errorf("parseParams error:%s", err)
 	}
+	vmType, err := utils.ParseVmType(ctx.String(utils.GetFlagName(utils.ContractVmTypeFlag)))
+	if err != nil {
+		return err
+	}
+	if vmType == payload.WASMVM_TYPE {
+		return invokeWasmContract(ctx, contractAddr, params)
+	}
 
 	paramData, _ := json.Marshal(params)
 	PrintInfoMsg("Invoke:%x Params:%s", contractAddr[:], paramData)
 
 	if ctx.IsSet(utils.GetFlagName(utils.ContractPrepareInvokeFlag)) {
+		if ctx.IsSet(utils.GetFlagName(utils.ContractTraceFlag)) {
+			return traceInvokeContract(ctx, contractAddr, params)
+		}
 		preResult, err := utils.PrepareInvokeNeoVMContract(contractAddr, params)
 		if err != nil {
 			return fmt.Errorf("PrepareInvokeNeoVMSmartContact error:%s", err)
@@ -350,3 +381,91 @@ func invokeContract(ctx *cli.Context) error {
 	PrintInfoMsg("  Using './onyxchain info status %s' to query transaction status.", txHash)
 	return nil
 }
+
+func traceInvokeContract(ctx *cli.Context, contractAddr common.Address, params []interface{}) error {
+	traceResult, err := utils.TraceInvokeNeoVMContract(contractAddr, params)
+	if err != nil {
+		return fmt.Errorf("TraceInvokeNeoVMContract error:%s", err)
+	}
+	for _, step := range traceResult.Steps {
+		PrintInfoMsg("%s %5d %-16s gas:%d used:%d stack:%v %s", step.Contract, step.Pc, step.Op, step.GasCost,
+			step.GasUsed, step.Stack, step.Syscall)
+	}
+	if traceResult.Truncated {
+		PrintInfoMsg("...(steps truncated)")
+	}
+	if traceResult.State == 0 {
+		return fmt.Errorf("contract invoke failed:%s", traceResult.Error)
+	}
+	PrintInfoMsg("Contract invoke successfully")
+	PrintInfoMsg("  Gas limit:%d", traceResult.Gas)
+
+	rawReturnTypes := ctx.String(utils.GetFlagName(utils.ContractReturnTypeFlag))
+	if rawReturnTypes == "" {
+		PrintInfoMsg("  Return:%s (raw value)", traceResult.Result)
+		return nil
+	}
+	values, err := utils.ParseReturnValue(traceResult.Result, rawReturnTypes)
+	if err != nil {
+		return fmt.Errorf("parseReturnValue values:%+v types:%s error:%s", values, rawReturnTypes, err)
+	}
+	switch len(values) {
+	case 0:
+		PrintInfoMsg("  Return: nil")
+	case 1:
+		PrintInfoMsg("  Return:%+v", values[0])
+	default:
+		PrintInfoMsg("  Return:%+v", values)
+	}
+	return nil
+}
+
+func invokeWasmContract(ctx *cli.Context, contractAddr common.Address, params []interface{}) error {
+	if len(params) == 0 {
+		return fmt.Errorf("missing method name of wasm contract")
+	}
+	method, ok := params[0].(string)
+	if !ok {
+		return fmt.Errorf("method name of wasm contract should be string")
+	}
+	args := params[1:]
+	paramData, _ := json.Marshal(args)
+	PrintInfoMsg("Invoke:%x Method:%s Params:%s", contractAddr[:], method, paramData)
+
+	if ctx.IsSet(utils.GetFlagName(utils.ContractPrepareInvokeFlag)) {
+		preResult, err := utils.PrepareInvokeWasmVMContract(contractAddr, method, wasmvm.Json, args)
+		if err != nil {
+			return fmt.Errorf("PrepareInvokeWasmVMContract error:%s", err)
+		}
+		if preResult.State == 0 {
+			return fmt.Errorf("contract invoke failed")
+		}
+		PrintInfoMsg("Contract invoke successfully")
+		PrintInfoMsg("  Gas limit:%d", preResult.Gas)
+		PrintInfoMsg("  Return:%s (raw value)", preResult.Result)
+		return nil
+	}
+	signer, err := cmdcom.GetAccount(ctx)
+	if err != nil {
+		return fmt.Errorf("get signer account error:%s", err)
+	}
+	gasPrice := ctx.Uint64(utils.GetFlagName(utils.TransactionGasPriceFlag))
+	gasLimit := ctx.Uint64(utils.GetFlagName(utils.TransactionGasLimitFlag))
+	networkId, err := utils.GetNetworkId()
+	if err != nil {
+		return err
+	}
+	if networkId == config.NETWORK_ID_SOLO_NET {
+		gasPrice = 0
+	}
+
+	txHash, err := utils.InvokeWasmVMContract(gasPrice, gasLimit, signer, contractAddr, method, wasmvm.Json, args)
+	if err != nil {
+		return fmt.Errorf("invoke WASM contract error:%s", err)
+	}
+
+	PrintInfoMsg("  TxHash:%s", txHash)
+	PrintInfoMsg("\nTips:")
+	PrintInfoMsg("  Using './onyxchain info status %s' to query transaction status.", txHash)
+	return nil
+}
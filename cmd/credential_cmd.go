@@ -0,0 +1,273 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	cmdcom "github.com/OnyxPay/OnyxChain/cmd/common"
+	"github.com/OnyxPay/OnyxChain/cmd/utils"
+	"github.com/urfave/cli"
+)
+
+var CredentialCommand = cli.Command{
+	Name:        "credential",
+	Usage:       "Issue, revoke and verify credentials of onx id",
+	Description: "Credential commands issue json credentials signed by the key of the issuer's onx id and commit the hash on-chain, revoke them, and verify a credential offline against its on-chain status.",
+	Subcommands: []cli.Command{
+		{
+			Action:      credentialIssue,
+			Name:        "issue",
+			Usage:       "Issue a credential and commit it on-chain",
+			ArgsUsage:   " ",
+			Description: "Issue a credential of the claims about the subject. The signer account should be the key of --keyindex of the issuer, the credential is written to the file of --credential or printed.",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+				utils.TransactionGasPriceFlag,
+				utils.TransactionGasLimitFlag,
+				utils.CredentialIssuerFlag,
+				utils.CredentialSubjectFlag,
+				utils.CredentialClaimsFlag,
+				utils.CredentialExpiryFlag,
+				utils.CredentialKeyIndexFlag,
+				utils.CredentialFileFlag,
+				utils.WalletFileFlag,
+				utils.AccountAddressFlag,
+			},
+		},
+		{
+			Action:      credentialRevoke,
+			Name:        "revoke",
+			Usage:       "Revoke a credential on-chain",
+			ArgsUsage:   "[<hash>]",
+			Description: "Revoke the credential of --credential file or of the hash issued by --issuer, the revoker is the issuer or the subject. The signer account should be the key of --keyindex of the revoker.",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+				utils.TransactionGasPriceFlag,
+				utils.TransactionGasLimitFlag,
+				utils.CredentialFileFlag,
+				utils.CredentialIssuerFlag,
+				utils.CredentialRevokerFlag,
+				utils.CredentialKeyIndexFlag,
+				utils.WalletFileFlag,
+				utils.AccountAddressFlag,
+			},
+		},
+		{
+			Action:      credentialVerify,
+			Name:        "verify",
+			Usage:       "Verify a credential",
+			ArgsUsage:   " ",
+			Description: "Verify the proof of the credential by the public key of the issuer, and check the on-chain status of the credential.",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+				utils.CredentialFileFlag,
+			},
+		},
+		{
+			Action:    credentialStatus,
+			Name:      "status",
+			Usage:     "Show the on-chain status of a credential",
+			ArgsUsage: "<hash>",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+				utils.CredentialIssuerFlag,
+			},
+		},
+	},
+}
+
+func credentialIssue(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	if !ctx.IsSet(utils.GetFlagName(utils.CredentialIssuerFlag)) || !ctx.IsSet(utils.GetFlagName(utils.CredentialSubjectFlag)) {
+		PrintErrorMsg("Missing %s or %s argument.", utils.CredentialIssuerFlag.Name, utils.CredentialSubjectFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	claims := make(map[string]interface{})
+	if rawClaims := ctx.String(utils.GetFlagName(utils.CredentialClaimsFlag)); rawClaims != "" {
+		decoder := json.NewDecoder(bytes.NewReader([]byte(rawClaims)))
+		decoder.UseNumber()
+		if err := decoder.Decode(&claims); err != nil {
+			return fmt.Errorf("invalid claims:%s", err)
+		}
+	}
+	c, err := utils.NewCredential(ctx.String(utils.GetFlagName(utils.CredentialIssuerFlag)),
+		ctx.String(utils.GetFlagName(utils.CredentialSubjectFlag)), claims, ctx.Uint64(utils.GetFlagName(utils.CredentialExpiryFlag)))
+	if err != nil {
+		return err
+	}
+	signer, err := cmdcom.GetAccount(ctx)
+	if err != nil {
+		return fmt.Errorf("get signer account error:%s", err)
+	}
+	if err := utils.SignCredential(signer, c, ctx.Uint64(utils.GetFlagName(utils.CredentialKeyIndexFlag))); err != nil {
+		return err
+	}
+	gasPrice, gasLimit, err := getTxGas(ctx)
+	if err != nil {
+		return err
+	}
+	mutable, err := utils.CredentialCommitTx(gasPrice, gasLimit, c)
+	if err != nil {
+		return err
+	}
+	txHash, err := utils.InvokeSmartContract(signer, mutable)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal credential error:%s", err)
+	}
+	hash, err := c.Hash()
+	if err != nil {
+		return err
+	}
+	PrintInfoMsg("Issue credential:")
+	PrintInfoMsg("  Hash:%x", hash)
+	PrintInfoMsg("  TxHash:%s", txHash)
+	if file := ctx.String(utils.GetFlagName(utils.CredentialFileFlag)); file != "" {
+		if err := ioutil.WriteFile(file, data, 0644); err != nil {
+			return fmt.Errorf("write credential file error:%s", err)
+		}
+		PrintInfoMsg("  Credential:%s", file)
+	} else {
+		PrintInfoMsg("%s", data)
+	}
+	PrintInfoMsg("\nTip:")
+	PrintInfoMsg("  Using './onyxchain info status %s' to query transaction status.", txHash)
+	return nil
+}
+
+func credentialRevoke(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	if !ctx.IsSet(utils.GetFlagName(utils.CredentialRevokerFlag)) {
+		PrintErrorMsg("Missing %s argument.", utils.CredentialRevokerFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	var hash []byte
+	var err error
+	issuer := ctx.String(utils.GetFlagName(utils.CredentialIssuerFlag))
+	if ctx.IsSet(utils.GetFlagName(utils.CredentialFileFlag)) {
+		c, err := readCredential(ctx)
+		if err != nil {
+			return err
+		}
+		hash, err = c.Hash()
+		if err != nil {
+			return err
+		}
+		issuer = c.Issuer
+	} else if ctx.NArg() > 0 && issuer != "" {
+		hash, err = hex.DecodeString(ctx.Args().First())
+		if err != nil {
+			return fmt.Errorf("invalid hash:%s", err)
+		}
+	} else {
+		PrintErrorMsg("Missing %s or hash and %s argument.", utils.CredentialFileFlag.Name, utils.CredentialIssuerFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	signer, err := cmdcom.GetAccount(ctx)
+	if err != nil {
+		return fmt.Errorf("get signer account error:%s", err)
+	}
+	gasPrice, gasLimit, err := getTxGas(ctx)
+	if err != nil {
+		return err
+	}
+	mutable, err := utils.CredentialRevokeTx(gasPrice, gasLimit, issuer, hash, ctx.String(utils.GetFlagName(utils.CredentialRevokerFlag)),
+		ctx.Uint64(utils.GetFlagName(utils.CredentialKeyIndexFlag)))
+	if err != nil {
+		return err
+	}
+	txHash, err := utils.InvokeSmartContract(signer, mutable)
+	if err != nil {
+		return err
+	}
+	PrintInfoMsg("Revoke credential:")
+	PrintInfoMsg("  Hash:%x", hash)
+	PrintInfoMsg("  TxHash:%s", txHash)
+	PrintInfoMsg("\nTip:")
+	PrintInfoMsg("  Using './onyxchain info status %s' to query transaction status.", txHash)
+	return nil
+}
+
+func credentialVerify(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	if !ctx.IsSet(utils.GetFlagName(utils.CredentialFileFlag)) {
+		PrintErrorMsg("Missing %s argument.", utils.CredentialFileFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	c, err := readCredential(ctx)
+	if err != nil {
+		return err
+	}
+	hash, err := c.Hash()
+	if err != nil {
+		return err
+	}
+	status, err := utils.VerifyCredential(c)
+	if err != nil {
+		return fmt.Errorf("verify credential error:%s", err)
+	}
+	PrintInfoMsg("Credential:%x", hash)
+	PrintInfoMsg("  Issuer:%s", c.Issuer)
+	PrintInfoMsg("  Subject:%s", c.Subject())
+	PrintInfoMsg("  Signature:verified")
+	PrintInfoMsg("  Status:%s", utils.CredentialStatusString(status))
+	return nil
+}
+
+func credentialStatus(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	if ctx.NArg() < 1 || !ctx.IsSet(utils.GetFlagName(utils.CredentialIssuerFlag)) {
+		PrintErrorMsg("Missing hash or %s argument.", utils.CredentialIssuerFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	hash, err := hex.DecodeString(ctx.Args().First())
+	if err != nil {
+		return fmt.Errorf("invalid hash:%s", err)
+	}
+	issuer := ctx.String(utils.GetFlagName(utils.CredentialIssuerFlag))
+	status, err := utils.GetCredentialStatus(issuer, hash)
+	if err != nil {
+		return err
+	}
+	PrintInfoMsg("Credential:%x", hash)
+	PrintInfoMsg("  Issuer:%s", issuer)
+	PrintInfoMsg("  Status:%s", utils.CredentialStatusString(status))
+	return nil
+}
+
+func readCredential(ctx *cli.Context) (*utils.Credential, error) {
+	data, err := ioutil.ReadFile(ctx.String(utils.GetFlagName(utils.CredentialFileFlag)))
+	if err != nil {
+		return nil, fmt.Errorf("read credential file error:%s", err)
+	}
+	return utils.ParseCredential(data)
+}
@@ -47,6 +47,9 @@ var ImportCommand = cli.Command{
 		utils.ConfigFlag,
 		utils.NetworkIdFlag,
 		utils.DisableEventLogFlag,
+		utils.EventIndexFlag,
+		utils.ArchiveModeFlag,
+		utils.PruneBlocksFlag,
 	},
 	Description: "Note that import cmd doesn't support testmode",
 }
@@ -0,0 +1,374 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	cmdcom "github.com/OnyxPay/OnyxChain/cmd/common"
+	"github.com/OnyxPay/OnyxChain/cmd/utils"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/config"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/multisig"
+	nutils "github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	"github.com/urfave/cli"
+)
+
+var MultisigCommand = cli.Command{
+	Name:        "multisig",
+	Usage:       "Handle on-chain multisig wallets",
+	Description: "Multisig wallet commands create a wallet of owners, submit, confirm and revoke proposals on-chain. A proposal is executed once confirmed by the threshold of owners.",
+	Subcommands: []cli.Command{
+		{
+			Action:      multisigCreate,
+			Name:        "create",
+			Usage:       "Create a multisig wallet",
+			ArgsUsage:   " ",
+			Description: "Create a multisig wallet of the owners, the signer account is the creator.",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+				utils.TransactionGasPriceFlag,
+				utils.TransactionGasLimitFlag,
+				utils.MultisigOwnersFlag,
+				utils.MultisigThresholdFlag,
+				utils.WalletFileFlag,
+				utils.AccountAddressFlag,
+			},
+		},
+		{
+			Action:      multisigSubmit,
+			Name:        "submit",
+			Usage:       "Submit a proposal invoking a contract by the multisig wallet",
+			ArgsUsage:   " ",
+			Description: "Submit a proposal invoking a native contract with --method, or a neovm contract whose params include the method. The signer account is the proposer and confirms the proposal.",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+				utils.TransactionGasPriceFlag,
+				utils.TransactionGasLimitFlag,
+				utils.MultisigWalletFlag,
+				utils.ContractAddrFlag,
+				utils.MultisigMethodFlag,
+				utils.ContractParamsFlag,
+				utils.WalletFileFlag,
+				utils.AccountAddressFlag,
+			},
+		},
+		{
+			Action:      multisigChangeOwners,
+			Name:        "changeowners",
+			Usage:       "Submit a proposal changing the owners of the multisig wallet",
+			ArgsUsage:   " ",
+			Description: "Submit a proposal replacing the owners and threshold of the multisig wallet.",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+				utils.TransactionGasPriceFlag,
+				utils.TransactionGasLimitFlag,
+				utils.MultisigWalletFlag,
+				utils.MultisigOwnersFlag,
+				utils.MultisigThresholdFlag,
+				utils.WalletFileFlag,
+				utils.AccountAddressFlag,
+			},
+		},
+		{
+			Action:    multisigConfirm,
+			Name:      "confirm",
+			Usage:     "Confirm a proposal of the multisig wallet",
+			ArgsUsage: " ",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+				utils.TransactionGasPriceFlag,
+				utils.TransactionGasLimitFlag,
+				utils.MultisigWalletFlag,
+				utils.MultisigProposalIdFlag,
+				utils.WalletFileFlag,
+				utils.AccountAddressFlag,
+			},
+		},
+		{
+			Action:    multisigRevoke,
+			Name:      "revoke",
+			Usage:     "Revoke the confirmation of a proposal of the multisig wallet",
+			ArgsUsage: " ",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+				utils.TransactionGasPriceFlag,
+				utils.TransactionGasLimitFlag,
+				utils.MultisigWalletFlag,
+				utils.MultisigProposalIdFlag,
+				utils.WalletFileFlag,
+				utils.AccountAddressFlag,
+			},
+		},
+		{
+			Action:    multisigShowWallet,
+			Name:      "wallet",
+			Usage:     "Show the owners and threshold of the multisig wallet",
+			ArgsUsage: "<address>",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+			},
+		},
+		{
+			Action:    multisigShowProposal,
+			Name:      "proposal",
+			Usage:     "Show a proposal of the multisig wallet",
+			ArgsUsage: " ",
+			Flags: []cli.Flag{
+				utils.RPCPortFlag,
+				utils.MultisigWalletFlag,
+				utils.MultisigProposalIdFlag,
+			},
+		},
+	},
+}
+
+func multisigCreate(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	if !ctx.IsSet(utils.GetFlagName(utils.MultisigOwnersFlag)) || !ctx.IsSet(utils.GetFlagName(utils.MultisigThresholdFlag)) {
+		PrintErrorMsg("Missing %s or %s argument.", utils.MultisigOwnersFlag.Name, utils.MultisigThresholdFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	owners, err := parseMultisigOwners(ctx)
+	if err != nil {
+		return err
+	}
+	signer, err := cmdcom.GetAccount(ctx)
+	if err != nil {
+		return fmt.Errorf("get signer account error:%s", err)
+	}
+	gasPrice, gasLimit, err := getTxGas(ctx)
+	if err != nil {
+		return err
+	}
+	threshold := uint32(ctx.Uint(utils.GetFlagName(utils.MultisigThresholdFlag)))
+	mutable, err := utils.MultisigCreateWalletTx(gasPrice, gasLimit, signer.Address.ToBase58(), owners, threshold)
+	if err != nil {
+		return err
+	}
+	txHash, err := utils.InvokeSmartContract(signer, mutable)
+	if err != nil {
+		return err
+	}
+	PrintInfoMsg("Create multisig wallet:")
+	PrintInfoMsg("  Owners:%s", strings.Join(owners, ","))
+	PrintInfoMsg("  Threshold:%d", threshold)
+	PrintInfoMsg("  TxHash:%s", txHash)
+	PrintInfoMsg("\nTip:")
+	PrintInfoMsg("  Using './onyxchain info status %s' to query the address of the wallet in the notify.", txHash)
+	return nil
+}
+
+func multisigSubmit(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	if !ctx.IsSet(utils.GetFlagName(utils.MultisigWalletFlag)) || !ctx.IsSet(utils.GetFlagName(utils.ContractAddrFlag)) {
+		PrintErrorMsg("Missing %s or %s argument.", utils.MultisigWalletFlag.Name, utils.ContractAddrFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	target, err := common.AddressFromHexString(ctx.String(utils.GetFlagName(utils.ContractAddrFlag)))
+	if err != nil {
+		return fmt.Errorf("invalid contract address error:%s", err)
+	}
+	params, err := utils.ParseParams(ctx.String(utils.GetFlagName(utils.ContractParamsFlag)))
+	if err != nil {
+		return fmt.Errorf("parseParams error:%s", err)
+	}
+	method := ctx.String(utils.GetFlagName(utils.MultisigMethodFlag))
+	args, err := utils.BuildMultisigArgs(method, params)
+	if err != nil {
+		return fmt.Errorf("build args error:%s", err)
+	}
+	return submitMultisigProposal(ctx, target, method, args)
+}
+
+func multisigChangeOwners(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	if !ctx.IsSet(utils.GetFlagName(utils.MultisigWalletFlag)) || !ctx.IsSet(utils.GetFlagName(utils.MultisigOwnersFlag)) ||
+		!ctx.IsSet(utils.GetFlagName(utils.MultisigThresholdFlag)) {
+		PrintErrorMsg("Missing %s, %s or %s argument.", utils.MultisigWalletFlag.Name, utils.MultisigOwnersFlag.Name,
+			utils.MultisigThresholdFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	owners, err := parseMultisigOwners(ctx)
+	if err != nil {
+		return err
+	}
+	threshold := uint32(ctx.Uint(utils.GetFlagName(utils.MultisigThresholdFlag)))
+	args, err := utils.BuildChangeOwnersArgs(ctx.String(utils.GetFlagName(utils.MultisigWalletFlag)), owners, threshold)
+	if err != nil {
+		return err
+	}
+	return submitMultisigProposal(ctx, nutils.MultisigContractAddress, multisig.CHANGE_OWNERS_NAME, args)
+}
+
+func submitMultisigProposal(ctx *cli.Context, target common.Address, method string, args []byte) error {
+	signer, err := cmdcom.GetAccount(ctx)
+	if err != nil {
+		return fmt.Errorf("get signer account error:%s", err)
+	}
+	gasPrice, gasLimit, err := getTxGas(ctx)
+	if err != nil {
+		return err
+	}
+	wallet := ctx.String(utils.GetFlagName(utils.MultisigWalletFlag))
+	mutable, err := utils.MultisigSubmitTx(gasPrice, gasLimit, wallet, signer.Address.ToBase58(), target, method, args)
+	if err != nil {
+		return err
+	}
+	txHash, err := utils.InvokeSmartContract(signer, mutable)
+	if err != nil {
+		return err
+	}
+	PrintInfoMsg("Submit proposal:")
+	PrintInfoMsg("  Wallet:%s", wallet)
+	PrintInfoMsg("  Contract:%s", target.ToHexString())
+	PrintInfoMsg("  Method:%s", method)
+	PrintInfoMsg("  TxHash:%s", txHash)
+	PrintInfoMsg("\nTip:")
+	PrintInfoMsg("  Using './onyxchain info status %s' to query the proposal id in the notify.", txHash)
+	return nil
+}
+
+func multisigConfirm(ctx *cli.Context) error {
+	return confirmMultisigProposal(ctx, utils.MultisigConfirmTx, "Confirm")
+}
+
+func multisigRevoke(ctx *cli.Context) error {
+	return confirmMultisigProposal(ctx, utils.MultisigRevokeTx, "Revoke")
+}
+
+func confirmMultisigProposal(ctx *cli.Context, newTx func(gasPrice, gasLimit uint64, wallet, owner string,
+	id uint64) (*types.MutableTransaction, error), action string) error {
+	SetRpcPort(ctx)
+	if !ctx.IsSet(utils.GetFlagName(utils.MultisigWalletFlag)) || !ctx.IsSet(utils.GetFlagName(utils.MultisigProposalIdFlag)) {
+		PrintErrorMsg("Missing %s or %s argument.", utils.MultisigWalletFlag.Name, utils.MultisigProposalIdFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	signer, err := cmdcom.GetAccount(ctx)
+	if err != nil {
+		return fmt.Errorf("get signer account error:%s", err)
+	}
+	gasPrice, gasLimit, err := getTxGas(ctx)
+	if err != nil {
+		return err
+	}
+	wallet := ctx.String(utils.GetFlagName(utils.MultisigWalletFlag))
+	id := ctx.Uint64(utils.GetFlagName(utils.MultisigProposalIdFlag))
+	mutable, err := newTx(gasPrice, gasLimit, wallet, signer.Address.ToBase58(), id)
+	if err != nil {
+		return err
+	}
+	txHash, err := utils.InvokeSmartContract(signer, mutable)
+	if err != nil {
+		return err
+	}
+	PrintInfoMsg("%s proposal:", action)
+	PrintInfoMsg("  Wallet:%s", wallet)
+	PrintInfoMsg("  Id:%d", id)
+	PrintInfoMsg("  TxHash:%s", txHash)
+	PrintInfoMsg("\nTip:")
+	PrintInfoMsg("  Using './onyxchain info status %s' to query transaction status.", txHash)
+	return nil
+}
+
+func multisigShowWallet(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	if ctx.NArg() < 1 {
+		PrintErrorMsg("Missing address argument.")
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	wallet, err := utils.GetMultisigWallet(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	PrintInfoMsg("Multisig wallet:%s", wallet.Address.ToBase58())
+	for i, owner := range wallet.Owners {
+		PrintInfoMsg("  Owner %d:%s", i+1, owner.ToBase58())
+	}
+	PrintInfoMsg("  Threshold:%d", wallet.Threshold)
+	PrintInfoMsg("  Proposals:%d", wallet.NextId)
+	return nil
+}
+
+func multisigShowProposal(ctx *cli.Context) error {
+	SetRpcPort(ctx)
+	if !ctx.IsSet(utils.GetFlagName(utils.MultisigWalletFlag)) || !ctx.IsSet(utils.GetFlagName(utils.MultisigProposalIdFlag)) {
+		PrintErrorMsg("Missing %s or %s argument.", utils.MultisigWalletFlag.Name, utils.MultisigProposalIdFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	proposal, err := utils.GetMultisigProposal(ctx.String(utils.GetFlagName(utils.MultisigWalletFlag)),
+		ctx.Uint64(utils.GetFlagName(utils.MultisigProposalIdFlag)))
+	if err != nil {
+		return err
+	}
+	status := "pending"
+	if proposal.Status == multisig.STATUS_EXECUTED {
+		status = "executed"
+	}
+	PrintInfoMsg("Proposal %d of wallet:%s", proposal.Id, proposal.Wallet.ToBase58())
+	PrintInfoMsg("  Proposer:%s", proposal.Proposer.ToBase58())
+	PrintInfoMsg("  Contract:%s", proposal.Target.ToHexString())
+	PrintInfoMsg("  Method:%s", proposal.Method)
+	PrintInfoMsg("  Args:%x", proposal.Args)
+	PrintInfoMsg("  Height:%d", proposal.Height)
+	PrintInfoMsg("  Status:%s", status)
+	for _, addr := range proposal.Confirmations {
+		PrintInfoMsg("  Confirmed by:%s", addr.ToBase58())
+	}
+	return nil
+}
+
+//parseMultisigOwners return the owners in base58, the owner can be address, label or index of the wallet file
+func parseMultisigOwners(ctx *cli.Context) ([]string, error) {
+	owners := make([]string, 0)
+	for _, owner := range strings.Split(ctx.String(utils.GetFlagName(utils.MultisigOwnersFlag)), ",") {
+		owner = strings.TrimSpace(owner)
+		if owner == "" {
+			continue
+		}
+		addr, err := cmdcom.ParseAddress(owner, ctx)
+		if err != nil {
+			return nil, err
+		}
+		owners = append(owners, addr)
+	}
+	return owners, nil
+}
+
+//getTxGas return the gas price and gas limit of the flags, the gas price is 0 on solo net
+func getTxGas(ctx *cli.Context) (uint64, uint64, error) {
+	gasPrice := ctx.Uint64(utils.TransactionGasPriceFlag.Name)
+	gasLimit := ctx.Uint64(utils.TransactionGasLimitFlag.Name)
+	networkId, err := utils.GetNetworkId()
+	if err != nil {
+		return 0, 0, err
+	}
+	if networkId == config.NETWORK_ID_SOLO_NET {
+		gasPrice = 0
+	}
+	return gasPrice, gasLimit, nil
+}
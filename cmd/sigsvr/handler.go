@@ -30,4 +30,13 @@ func init() {
 	DefCliRpcSvr.RegHandler("signeovminvoketx", handlers.SigNeoVMInvokeTx)
 	DefCliRpcSvr.RegHandler("signeovminvokeabitx", handlers.SigNeoVMInvokeAbiTx)
 	DefCliRpcSvr.RegHandler("signativeinvoketx", handlers.SigNativeInvokeTx)
+	DefCliRpcSvr.RegHandler("sightlclocktx", handlers.SigHtlcLockTx)
+	DefCliRpcSvr.RegHandler("sightlcclaimtx", handlers.SigHtlcClaimTx)
+	DefCliRpcSvr.RegHandler("sightlcrefundtx", handlers.SigHtlcRefundTx)
+	DefCliRpcSvr.RegHandler("sigmultisigcreatetx", handlers.SigMultisigCreateTx)
+	DefCliRpcSvr.RegHandler("sigmultisigsubmittx", handlers.SigMultisigSubmitTx)
+	DefCliRpcSvr.RegHandler("sigmultisigconfirmtx", handlers.SigMultisigConfirmTx)
+	DefCliRpcSvr.RegHandler("sigmultisigrevoketx", handlers.SigMultisigRevokeTx)
+	DefCliRpcSvr.RegHandler("sigcredentialissue", handlers.SigCredentialIssue)
+	DefCliRpcSvr.RegHandler("sigcredentialrevoketx", handlers.SigCredentialRevokeTx)
 }
@@ -0,0 +1,155 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package handlers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	clisvrcom "github.com/OnyxPay/OnyxChain/cmd/sigsvr/common"
+	cliutil "github.com/OnyxPay/OnyxChain/cmd/utils"
+	"github.com/OnyxPay/OnyxChain/common/log"
+)
+
+type SigCredentialIssueReq struct {
+	GasPrice uint64          `json:"gas_price"`
+	GasLimit uint64          `json:"gas_limit"`
+	Issuer   string          `json:"issuer"`
+	Subject  string          `json:"subject"`
+	Claims   json.RawMessage `json:"claims"`
+	Expiry   uint64          `json:"expiry"`
+	KeyIndex uint64          `json:"key_index"`
+	Payer    string          `json:"payer"`
+}
+
+type SigCredentialIssueRsp struct {
+	Credential *cliutil.Credential `json:"credential"`
+	Hash       string              `json:"hash"`
+	SignedTx   string              `json:"signed_tx"`
+}
+
+type SigCredentialRevokeTxReq struct {
+	GasPrice uint64 `json:"gas_price"`
+	GasLimit uint64 `json:"gas_limit"`
+	Hash     string `json:"hash"`
+	Issuer   string `json:"issuer"`
+	Revoker  string `json:"revoker"`
+	KeyIndex uint64 `json:"key_index"`
+	Payer    string `json:"payer"`
+}
+
+type SigCredentialTxRsp struct {
+	SignedTx string `json:"signed_tx"`
+}
+
+//SigCredentialIssue sign the credential by the account of request, which is the key of key_index of the issuer,
+//and sign the transaction committing the credential
+func SigCredentialIssue(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigCredentialIssueReq{}
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	claims := make(map[string]interface{})
+	if len(rawReq.Claims) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(rawReq.Claims))
+		decoder.UseNumber()
+		if err := decoder.Decode(&claims); err != nil {
+			resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+			resp.ErrorInfo = "claims should be json object"
+			return
+		}
+	}
+	if rawReq.KeyIndex == 0 {
+		rawReq.KeyIndex = 1
+	}
+	credential, err := cliutil.NewCredential(rawReq.Issuer, rawReq.Subject, claims, rawReq.Expiry)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = err.Error()
+		return
+	}
+	signer, err := req.GetAccount()
+	if err != nil {
+		log.Infof("Cli Qid:%s SigCredentialIssue GetAccount:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_ACCOUNT_UNLOCK
+		return
+	}
+	if signer == nil {
+		resp.ErrorCode = clisvrcom.CLIERR_ACCOUNT_UNLOCK
+		return
+	}
+	err = cliutil.SignCredential(signer, credential, rawReq.KeyIndex)
+	if err != nil {
+		log.Infof("Cli Qid:%s SigCredentialIssue SignCredential error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		return
+	}
+	hash, err := credential.Hash()
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		return
+	}
+	mutable, err := cliutil.CredentialCommitTx(rawReq.GasPrice, rawReq.GasLimit, credential)
+	if err != nil {
+		log.Infof("Cli Qid:%s SigCredentialIssue CredentialCommitTx error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	signedTx, ok := signMutableTx(req, resp, mutable, rawReq.Payer)
+	if !ok {
+		return
+	}
+	resp.Result = &SigCredentialIssueRsp{
+		Credential: credential,
+		Hash:       hex.EncodeToString(hash),
+		SignedTx:   signedTx,
+	}
+}
+
+func SigCredentialRevokeTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigCredentialRevokeTxReq{}
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	hash, err := hex.DecodeString(rawReq.Hash)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "hash should be hex string"
+		return
+	}
+	if rawReq.KeyIndex == 0 {
+		rawReq.KeyIndex = 1
+	}
+	mutable, err := cliutil.CredentialRevokeTx(rawReq.GasPrice, rawReq.GasLimit, rawReq.Issuer, hash, rawReq.Revoker, rawReq.KeyIndex)
+	if err != nil {
+		log.Infof("Cli Qid:%s SigCredentialRevokeTx CredentialRevokeTx error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	signedTx, ok := signMutableTx(req, resp, mutable, rawReq.Payer)
+	if !ok {
+		return
+	}
+	resp.Result = &SigCredentialTxRsp{
+		SignedTx: signedTx,
+	}
+}
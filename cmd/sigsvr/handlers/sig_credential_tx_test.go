@@ -0,0 +1,93 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/OnyxPay/OnyxChain/account"
+	clisvrcom "github.com/OnyxPay/OnyxChain/cmd/sigsvr/common"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"testing"
+)
+
+func TestSigCredentialTx(t *testing.T) {
+	defAcc, err := testWallet.GetDefaultAccount(pwd)
+	if err != nil {
+		t.Errorf("GetDefaultAccount error:%s", err)
+		return
+	}
+	issuer, _ := account.GenerateID()
+	subject, _ := account.GenerateID()
+	issueReq := &SigCredentialIssueReq{
+		Issuer:  issuer,
+		Subject: subject,
+		Claims:  json.RawMessage(`{"name":"alice","age":30}`),
+	}
+	data, err := json.Marshal(issueReq)
+	if err != nil {
+		t.Errorf("json.Marshal SigCredentialIssueReq error:%s", err)
+		return
+	}
+	req := &clisvrcom.CliRpcRequest{
+		Qid:     "t",
+		Params:  data,
+		Account: defAcc.Address.ToBase58(),
+		Pwd:     string(pwd),
+	}
+	rsp := &clisvrcom.CliRpcResponse{}
+	SigCredentialIssue(req, rsp)
+	if rsp.ErrorCode != 0 {
+		t.Errorf("SigCredentialIssue failed. ErrorCode:%d ErrorInfo:%s", rsp.ErrorCode, rsp.ErrorInfo)
+		return
+	}
+	issueRsp := rsp.Result.(*SigCredentialIssueRsp)
+	if issueRsp.Credential.Proof == nil || issueRsp.Credential.Proof.VerificationMethod != issuer+"#keys-1" {
+		t.Errorf("SigCredentialIssue invalid proof:%v", issueRsp.Credential.Proof)
+		return
+	}
+
+	revokeReq := &SigCredentialRevokeTxReq{
+		Hash:    issueRsp.Hash,
+		Issuer:  issuer,
+		Revoker: subject,
+	}
+	data, err = json.Marshal(revokeReq)
+	if err != nil {
+		t.Errorf("json.Marshal SigCredentialRevokeTxReq error:%s", err)
+		return
+	}
+	req.Params = data
+	rsp = &clisvrcom.CliRpcResponse{}
+	SigCredentialRevokeTx(req, rsp)
+	if rsp.ErrorCode != 0 {
+		t.Errorf("SigCredentialRevokeTx failed. ErrorCode:%d ErrorInfo:%s", rsp.ErrorCode, rsp.ErrorInfo)
+		return
+	}
+	for _, signedTx := range []string{issueRsp.SignedTx, rsp.Result.(*SigCredentialTxRsp).SignedTx} {
+		raw, err := hex.DecodeString(signedTx)
+		if err != nil {
+			t.Errorf("hex.DecodeString signed tx error:%s", err)
+			return
+		}
+		if _, err := types.TransactionFromRawBytes(raw); err != nil {
+			t.Errorf("TransactionFromRawBytes error:%s", err)
+			return
+		}
+	}
+}
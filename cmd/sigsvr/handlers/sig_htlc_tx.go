@@ -0,0 +1,205 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	clisvrcom "github.com/OnyxPay/OnyxChain/cmd/sigsvr/common"
+	cliutil "github.com/OnyxPay/OnyxChain/cmd/utils"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/log"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"strconv"
+)
+
+type SigHtlcLockTxReq struct {
+	GasPrice uint64 `json:"gas_price"`
+	GasLimit uint64 `json:"gas_limit"`
+	Asset    string `json:"asset"`
+	Sender   string `json:"sender"`
+	Receiver string `json:"receiver"`
+	Amount   string `json:"amount"`
+	HashType string `json:"hash_type"`
+	HashLock string `json:"hash_lock"`
+	Timeout  uint32 `json:"timeout"`
+	Payer    string `json:"payer"`
+}
+
+type SigHtlcClaimTxReq struct {
+	GasPrice uint64 `json:"gas_price"`
+	GasLimit uint64 `json:"gas_limit"`
+	Sender   string `json:"sender"`
+	HashLock string `json:"hash_lock"`
+	Preimage string `json:"preimage"`
+	Payer    string `json:"payer"`
+}
+
+type SigHtlcRefundTxReq struct {
+	GasPrice uint64 `json:"gas_price"`
+	GasLimit uint64 `json:"gas_limit"`
+	Sender   string `json:"sender"`
+	HashLock string `json:"hash_lock"`
+	Payer    string `json:"payer"`
+}
+
+type SigHtlcTxRsp struct {
+	SignedTx string `json:"signed_tx"`
+}
+
+func SigHtlcLockTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigHtlcLockTxReq{}
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	amount, err := strconv.ParseUint(rawReq.Amount, 10, 64)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "amount should be string type"
+		return
+	}
+	hashType, err := cliutil.ParseHashType(rawReq.HashType)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = err.Error()
+		return
+	}
+	hashLock, err := hex.DecodeString(rawReq.HashLock)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "hash_lock should be hex string"
+		return
+	}
+	mutable, err := cliutil.HtlcLockTx(rawReq.GasPrice, rawReq.GasLimit, rawReq.Asset, rawReq.Sender, rawReq.Receiver,
+		amount, hashType, hashLock, rawReq.Timeout)
+	if err != nil {
+		log.Infof("Cli Qid:%s SigHtlcLockTx HtlcLockTx error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	sigHtlcTx(req, resp, mutable, rawReq.Payer)
+}
+
+func SigHtlcClaimTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigHtlcClaimTxReq{}
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	hashLock, err := hex.DecodeString(rawReq.HashLock)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "hash_lock should be hex string"
+		return
+	}
+	preimage, err := hex.DecodeString(rawReq.Preimage)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "preimage should be hex string"
+		return
+	}
+	mutable, err := cliutil.HtlcClaimTx(rawReq.GasPrice, rawReq.GasLimit, rawReq.Sender, hashLock, preimage)
+	if err != nil {
+		log.Infof("Cli Qid:%s SigHtlcClaimTx HtlcClaimTx error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	sigHtlcTx(req, resp, mutable, rawReq.Payer)
+}
+
+func SigHtlcRefundTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigHtlcRefundTxReq{}
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	hashLock, err := hex.DecodeString(rawReq.HashLock)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "hash_lock should be hex string"
+		return
+	}
+	mutable, err := cliutil.HtlcRefundTx(rawReq.GasPrice, rawReq.GasLimit, rawReq.Sender, hashLock)
+	if err != nil {
+		log.Infof("Cli Qid:%s SigHtlcRefundTx HtlcRefundTx error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	sigHtlcTx(req, resp, mutable, rawReq.Payer)
+}
+
+//sigHtlcTx set the payer and sign the htlc transaction by the account of request
+func sigHtlcTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse, mutable *types.MutableTransaction, payer string) {
+	signedTx, ok := signMutableTx(req, resp, mutable, payer)
+	if !ok {
+		return
+	}
+	resp.Result = &SigHtlcTxRsp{
+		SignedTx: signedTx,
+	}
+}
+
+//signMutableTx set the payer and sign the transaction by the account of request, return the hex of the signed transaction.
+//The error code of resp is set if failed
+func signMutableTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse, mutable *types.MutableTransaction, payer string) (string, bool) {
+	if payer != "" {
+		payerAddress, err := common.AddressFromBase58(payer)
+		if err != nil {
+			log.Infof("Cli Qid:%s %s AddressFromBase58 error:%s", req.Qid, req.Method, err)
+			resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+			return "", false
+		}
+		mutable.Payer = payerAddress
+	}
+
+	signer, err := req.GetAccount()
+	if err != nil {
+		log.Infof("Cli Qid:%s %s GetAccount:%s", req.Qid, req.Method, err)
+		resp.ErrorCode = clisvrcom.CLIERR_ACCOUNT_UNLOCK
+		return "", false
+	}
+	if signer == nil {
+		resp.ErrorCode = clisvrcom.CLIERR_ACCOUNT_UNLOCK
+		return "", false
+	}
+	err = cliutil.SignTransaction(signer, mutable)
+	if err != nil {
+		log.Infof("Cli Qid:%s %s SignTransaction error:%s", req.Qid, req.Method, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		return "", false
+	}
+	tx, err := mutable.IntoImmutable()
+	if err != nil {
+		log.Infof("Cli Qid:%s %s tx IntoInmmutable error:%s", req.Qid, req.Method, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		return "", false
+	}
+	sink := common.ZeroCopySink{}
+	err = tx.Serialization(&sink)
+	if err != nil {
+		log.Infof("Cli Qid:%s %s tx Serialize error:%s", req.Qid, req.Method, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		return "", false
+	}
+	return hex.EncodeToString(sink.Bytes()), true
+}
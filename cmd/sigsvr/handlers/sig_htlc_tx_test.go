@@ -0,0 +1,95 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/OnyxPay/OnyxChain/account"
+	clisvrcom "github.com/OnyxPay/OnyxChain/cmd/sigsvr/common"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"testing"
+)
+
+func TestSigHtlcTx(t *testing.T) {
+	acc := account.NewAccount("")
+	defAcc, err := testWallet.GetDefaultAccount(pwd)
+	if err != nil {
+		t.Errorf("GetDefaultAccount error:%s", err)
+		return
+	}
+	preimage := []byte("secret")
+	hashLock := sha256.Sum256(preimage)
+	lockReq := &SigHtlcLockTxReq{
+		Asset:    "onyx",
+		Sender:   defAcc.Address.ToBase58(),
+		Receiver: acc.Address.ToBase58(),
+		Amount:   "10",
+		HashType: "sha256",
+		HashLock: hex.EncodeToString(hashLock[:]),
+		Timeout:  1000,
+	}
+	claimReq := &SigHtlcClaimTxReq{
+		Sender:   defAcc.Address.ToBase58(),
+		HashLock: hex.EncodeToString(hashLock[:]),
+		Preimage: hex.EncodeToString(preimage),
+	}
+	refundReq := &SigHtlcRefundTxReq{
+		Sender:   defAcc.Address.ToBase58(),
+		HashLock: hex.EncodeToString(hashLock[:]),
+	}
+	handlers := []func(*clisvrcom.CliRpcRequest, *clisvrcom.CliRpcResponse){SigHtlcLockTx, SigHtlcClaimTx, SigHtlcRefundTx}
+	for i, sigReq := range []interface{}{lockReq, claimReq, refundReq} {
+		data, err := json.Marshal(sigReq)
+		if err != nil {
+			t.Errorf("json.Marshal htlc request error:%s", err)
+			return
+		}
+		req := &clisvrcom.CliRpcRequest{
+			Qid:     "t",
+			Params:  data,
+			Account: defAcc.Address.ToBase58(),
+			Pwd:     string(pwd),
+		}
+		rsp := &clisvrcom.CliRpcResponse{}
+		handlers[i](req, rsp)
+		if rsp.ErrorCode != 0 {
+			t.Errorf("SigHtlcTx %d failed. ErrorCode:%d", i, rsp.ErrorCode)
+			return
+		}
+		signedTx, err := hex.DecodeString(rsp.Result.(*SigHtlcTxRsp).SignedTx)
+		if err != nil {
+			t.Errorf("hex.DecodeString signed tx error:%s", err)
+			return
+		}
+		if _, err := types.TransactionFromRawBytes(signedTx); err != nil {
+			t.Errorf("TransactionFromRawBytes error:%s", err)
+			return
+		}
+	}
+
+	lockReq.HashLock = "not hex"
+	data, _ := json.Marshal(lockReq)
+	rsp := &clisvrcom.CliRpcResponse{}
+	SigHtlcLockTx(&clisvrcom.CliRpcRequest{Qid: "t", Params: data, Account: defAcc.Address.ToBase58(), Pwd: string(pwd)}, rsp)
+	if rsp.ErrorCode != clisvrcom.CLIERR_INVALID_PARAMS {
+		t.Errorf("SigHtlcLockTx should fail with invalid hash lock")
+	}
+}
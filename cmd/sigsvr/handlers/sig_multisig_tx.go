@@ -0,0 +1,157 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	clisvrcom "github.com/OnyxPay/OnyxChain/cmd/sigsvr/common"
+	cliutil "github.com/OnyxPay/OnyxChain/cmd/utils"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/log"
+	"github.com/OnyxPay/OnyxChain/core/types"
+)
+
+type SigMultisigCreateTxReq struct {
+	GasPrice  uint64   `json:"gas_price"`
+	GasLimit  uint64   `json:"gas_limit"`
+	Creator   string   `json:"creator"`
+	Owners    []string `json:"owners"`
+	Threshold uint32   `json:"threshold"`
+	Payer     string   `json:"payer"`
+}
+
+type SigMultisigSubmitTxReq struct {
+	GasPrice uint64        `json:"gas_price"`
+	GasLimit uint64        `json:"gas_limit"`
+	Wallet   string        `json:"wallet"`
+	Proposer string        `json:"proposer"`
+	Address  string        `json:"address"`
+	Method   string        `json:"method"`
+	Params   []interface{} `json:"params"`
+	Payer    string        `json:"payer"`
+}
+
+type SigMultisigConfirmTxReq struct {
+	GasPrice uint64 `json:"gas_price"`
+	GasLimit uint64 `json:"gas_limit"`
+	Wallet   string `json:"wallet"`
+	Owner    string `json:"owner"`
+	Id       uint64 `json:"id"`
+	Payer    string `json:"payer"`
+}
+
+type SigMultisigTxRsp struct {
+	SignedTx string `json:"signed_tx"`
+}
+
+func SigMultisigCreateTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigMultisigCreateTxReq{}
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	mutable, err := cliutil.MultisigCreateWalletTx(rawReq.GasPrice, rawReq.GasLimit, rawReq.Creator, rawReq.Owners, rawReq.Threshold)
+	if err != nil {
+		log.Infof("Cli Qid:%s SigMultisigCreateTx MultisigCreateWalletTx error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	sigMultisigTx(req, resp, mutable, rawReq.Payer)
+}
+
+//SigMultisigSubmitTx sign the transaction submitting a proposal, the params are in the same format as signeovminvoketx.
+//For a native contract the method should be set, for a neovm contract the method is the first of the params
+func SigMultisigSubmitTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigMultisigSubmitTxReq{}
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	target, err := common.AddressFromHexString(rawReq.Address)
+	if err != nil {
+		log.Infof("Cli Qid:%s SigMultisigSubmitTx AddressFromHexString:%s error:%s", req.Qid, rawReq.Address, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	params, err := cliutil.ParseNeoVMInvokeParams(rawReq.Params)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = fmt.Sprintf("ParseNeoVMInvokeParams error:%s", err)
+		return
+	}
+	args, err := cliutil.BuildMultisigArgs(rawReq.Method, params)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = fmt.Sprintf("BuildMultisigArgs error:%s", err)
+		return
+	}
+	mutable, err := cliutil.MultisigSubmitTx(rawReq.GasPrice, rawReq.GasLimit, rawReq.Wallet, rawReq.Proposer, target,
+		rawReq.Method, args)
+	if err != nil {
+		log.Infof("Cli Qid:%s SigMultisigSubmitTx MultisigSubmitTx error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	sigMultisigTx(req, resp, mutable, rawReq.Payer)
+}
+
+func SigMultisigConfirmTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigMultisigConfirmTxReq{}
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	mutable, err := cliutil.MultisigConfirmTx(rawReq.GasPrice, rawReq.GasLimit, rawReq.Wallet, rawReq.Owner, rawReq.Id)
+	if err != nil {
+		log.Infof("Cli Qid:%s SigMultisigConfirmTx MultisigConfirmTx error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	sigMultisigTx(req, resp, mutable, rawReq.Payer)
+}
+
+func SigMultisigRevokeTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigMultisigConfirmTxReq{}
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	mutable, err := cliutil.MultisigRevokeTx(rawReq.GasPrice, rawReq.GasLimit, rawReq.Wallet, rawReq.Owner, rawReq.Id)
+	if err != nil {
+		log.Infof("Cli Qid:%s SigMultisigRevokeTx MultisigRevokeTx error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	sigMultisigTx(req, resp, mutable, rawReq.Payer)
+}
+
+//sigMultisigTx set the payer and sign the multisig transaction by the account of request
+func sigMultisigTx(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse, mutable *types.MutableTransaction, payer string) {
+	signedTx, ok := signMutableTx(req, resp, mutable, payer)
+	if !ok {
+		return
+	}
+	resp.Result = &SigMultisigTxRsp{
+		SignedTx: signedTx,
+	}
+}
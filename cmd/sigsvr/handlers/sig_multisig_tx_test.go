@@ -0,0 +1,101 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/OnyxPay/OnyxChain/account"
+	clisvrcom "github.com/OnyxPay/OnyxChain/cmd/sigsvr/common"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	"testing"
+)
+
+func TestSigMultisigTx(t *testing.T) {
+	acc := account.NewAccount("")
+	defAcc, err := testWallet.GetDefaultAccount(pwd)
+	if err != nil {
+		t.Errorf("GetDefaultAccount error:%s", err)
+		return
+	}
+	wallet := account.NewAccount("")
+	owners := []string{defAcc.Address.ToBase58(), acc.Address.ToBase58()}
+	createReq := &SigMultisigCreateTxReq{
+		Creator:   defAcc.Address.ToBase58(),
+		Owners:    owners,
+		Threshold: 2,
+	}
+	submitReq := &SigMultisigSubmitTxReq{
+		Wallet:   wallet.Address.ToBase58(),
+		Proposer: defAcc.Address.ToBase58(),
+		Address:  utils.OnxContractAddress.ToHexString(),
+		Method:   "transfer",
+		Params: []interface{}{
+			map[string]interface{}{
+				"type": "array",
+				"value": []interface{}{
+					map[string]interface{}{"type": "int", "value": "1"},
+					map[string]interface{}{
+						"type": "array",
+						"value": []interface{}{
+							map[string]interface{}{"type": "bytearray", "value": hex.EncodeToString(wallet.Address[:])},
+							map[string]interface{}{"type": "bytearray", "value": hex.EncodeToString(acc.Address[:])},
+							map[string]interface{}{"type": "int", "value": "10"},
+						},
+					},
+				},
+			},
+		},
+	}
+	confirmReq := &SigMultisigConfirmTxReq{
+		Wallet: wallet.Address.ToBase58(),
+		Owner:  defAcc.Address.ToBase58(),
+		Id:     0,
+	}
+	handlers := []func(*clisvrcom.CliRpcRequest, *clisvrcom.CliRpcResponse){SigMultisigCreateTx, SigMultisigSubmitTx,
+		SigMultisigConfirmTx, SigMultisigRevokeTx}
+	for i, sigReq := range []interface{}{createReq, submitReq, confirmReq, confirmReq} {
+		data, err := json.Marshal(sigReq)
+		if err != nil {
+			t.Errorf("json.Marshal multisig request error:%s", err)
+			return
+		}
+		req := &clisvrcom.CliRpcRequest{
+			Qid:     "t",
+			Params:  data,
+			Account: defAcc.Address.ToBase58(),
+			Pwd:     string(pwd),
+		}
+		rsp := &clisvrcom.CliRpcResponse{}
+		handlers[i](req, rsp)
+		if rsp.ErrorCode != 0 {
+			t.Errorf("SigMultisigTx %d failed. ErrorCode:%d ErrorInfo:%s", i, rsp.ErrorCode, rsp.ErrorInfo)
+			return
+		}
+		signedTx, err := hex.DecodeString(rsp.Result.(*SigMultisigTxRsp).SignedTx)
+		if err != nil {
+			t.Errorf("hex.DecodeString signed tx error:%s", err)
+			return
+		}
+		if _, err := types.TransactionFromRawBytes(signedTx); err != nil {
+			t.Errorf("TransactionFromRawBytes error:%s", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,208 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"os"
+
+	"github.com/OnyxPay/OnyxChain-crypto/keypair"
+	"github.com/OnyxPay/OnyxChain/cmd/utils"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/config"
+	"github.com/OnyxPay/OnyxChain/common/log"
+	"github.com/OnyxPay/OnyxChain/core/genesis"
+	"github.com/OnyxPay/OnyxChain/core/ledger"
+	"github.com/OnyxPay/OnyxChain/core/store"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/urfave/cli"
+)
+
+var SnapshotCommand = cli.Command{
+	Name:        "snapshot",
+	Usage:       "Export or import state snapshot for fast sync",
+	Description: "A snapshot holds the block headers and the state at the current block of a ledger. A new node imports the snapshot instead of replaying all blocks, then syncs later blocks from network. Later blocks do not verify the imported state, so the snapshot is checked against the block hash, state merkle root and state hash printed by the export, which the operator must get from a trusted source.",
+	Subcommands: []cli.Command{
+		{
+			Action:      exportSnapshot,
+			Name:        "export",
+			Usage:       "Export state snapshot of the ledger to a file",
+			ArgsUsage:   " ",
+			Description: "Export the ledger at current block to the file of --snapshot-file. The ledger is opened from the data dir, so the node should be stopped.",
+			Flags: []cli.Flag{
+				utils.SnapshotFileFlag,
+				utils.DataDirFlag,
+				utils.ConfigFlag,
+				utils.NetworkIdFlag,
+			},
+		},
+		{
+			Action:      importSnapshot,
+			Name:        "import",
+			Usage:       "Initialize an empty ledger from a state snapshot file",
+			ArgsUsage:   " ",
+			Description: "Import the snapshot of --snapshot-file to an empty data dir. Headers of the snapshot are verified from the genesis block of the network, and the last block hash, state merkle root and state hash must match the trusted values of --snapshot-block-hash, --snapshot-state-root and --snapshot-state-hash. The node synchronizes later blocks normally when started.",
+			Flags: []cli.Flag{
+				utils.SnapshotFileFlag,
+				utils.SnapshotBlockHashFlag,
+				utils.SnapshotStateRootFlag,
+				utils.SnapshotStateHashFlag,
+				utils.DataDirFlag,
+				utils.ConfigFlag,
+				utils.NetworkIdFlag,
+				utils.DisableEventLogFlag,
+				utils.EventIndexFlag,
+				utils.ArchiveModeFlag,
+				utils.PruneBlocksFlag,
+			},
+		},
+	},
+}
+
+func exportSnapshot(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	snapshotFile := ctx.String(utils.GetFlagName(utils.SnapshotFileFlag))
+	if snapshotFile == "" {
+		PrintErrorMsg("Missing %s argument.", utils.SnapshotFileFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	bookKeepers, genesisBlock, err := openSnapshotLedger(ctx)
+	if err != nil {
+		return err
+	}
+	defer ledger.DefLedger.Close()
+	err = ledger.DefLedger.Init(bookKeepers, genesisBlock)
+	if err != nil {
+		return fmt.Errorf("init ledger error:%s", err)
+	}
+
+	ofile, err := os.OpenFile(snapshotFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("OpenFile error:%s", err)
+	}
+	defer ofile.Close()
+	fWriter := bufio.NewWriter(ofile)
+	zWriter := gzip.NewWriter(fWriter)
+
+	PrintInfoMsg("Start export snapshot.")
+	checkpoint, err := ledger.DefLedger.ExportSnapshot(zWriter)
+	if err != nil {
+		return fmt.Errorf("ExportSnapshot error:%s", err)
+	}
+	err = zWriter.Close()
+	if err != nil {
+		return fmt.Errorf("compress snapshot error:%s", err)
+	}
+	err = fWriter.Flush()
+	if err != nil {
+		return fmt.Errorf("write snapshot error:%s", err)
+	}
+
+	PrintInfoMsg("Export snapshot completed, block height:%d.", ledger.DefLedger.GetCurrentBlockHeight())
+	PrintInfoMsg("  --%s=%s", utils.SnapshotBlockHashFlag.Name, checkpoint.BlockHash.ToHexString())
+	PrintInfoMsg("  --%s=%s", utils.SnapshotStateRootFlag.Name, checkpoint.StateRoot.ToHexString())
+	PrintInfoMsg("  --%s=%s", utils.SnapshotStateHashFlag.Name, checkpoint.StateHash.ToHexString())
+	return nil
+}
+
+func importSnapshot(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	snapshotFile := ctx.String(utils.GetFlagName(utils.SnapshotFileFlag))
+	if snapshotFile == "" {
+		PrintErrorMsg("Missing %s argument.", utils.SnapshotFileFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	checkpoint := &store.SnapshotCheckpoint{}
+	for _, item := range []struct {
+		flag cli.StringFlag
+		hash *common.Uint256
+	}{
+		{utils.SnapshotBlockHashFlag, &checkpoint.BlockHash},
+		{utils.SnapshotStateRootFlag, &checkpoint.StateRoot},
+		{utils.SnapshotStateHashFlag, &checkpoint.StateHash},
+	} {
+		value := ctx.String(utils.GetFlagName(item.flag))
+		if value == "" {
+			PrintErrorMsg("Missing %s argument.", item.flag.Name)
+			cli.ShowSubcommandHelp(ctx)
+			return nil
+		}
+		hash, err := common.Uint256FromHexString(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s argument:%s", item.flag.Name, err)
+		}
+		*item.hash = hash
+	}
+	bookKeepers, genesisBlock, err := openSnapshotLedger(ctx)
+	if err != nil {
+		return err
+	}
+	defer ledger.DefLedger.Close()
+
+	ifile, err := os.OpenFile(snapshotFile, os.O_RDONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("OpenFile error:%s", err)
+	}
+	defer ifile.Close()
+	zReader, err := gzip.NewReader(bufio.NewReader(ifile))
+	if err != nil {
+		return fmt.Errorf("snapshot file decompress error:%s", err)
+	}
+
+	PrintInfoMsg("Start import snapshot.")
+	err = ledger.DefLedger.ImportSnapshot(zReader, genesisBlock, checkpoint)
+	if err != nil {
+		return err
+	}
+	err = ledger.DefLedger.Init(bookKeepers, genesisBlock)
+	if err != nil {
+		return fmt.Errorf("init ledger error:%s", err)
+	}
+	PrintInfoMsg("Import snapshot completed, current block height:%d.", ledger.DefLedger.GetCurrentBlockHeight())
+	return nil
+}
+
+func openSnapshotLedger(ctx *cli.Context) ([]keypair.PublicKey, *types.Block, error) {
+	cfg, err := SetOnyxChainConfig(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("SetOnyxChainConfig error:%s", err)
+	}
+	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+
+	stateHashHeight := config.GetStateHashCheckHeight(cfg.P2PNode.NetworkId)
+	ledger.DefLedger, err = ledger.NewLedger(dbDir, stateHashHeight)
+	if err != nil {
+		return nil, nil, fmt.Errorf("NewLedger error:%s", err)
+	}
+	bookKeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return nil, nil, fmt.Errorf("GetBookkeepers error:%s", err)
+	}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookKeepers, config.DefConfig.Genesis)
+	if err != nil {
+		return nil, nil, fmt.Errorf("BuildGenesisBlock error %s", err)
+	}
+	return bookKeepers, genesisBlock, nil
+}
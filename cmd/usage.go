@@ -100,6 +100,10 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ConfigFlag,
 			utils.LogLevelFlag,
 			utils.DisableEventLogFlag,
+			utils.EventIndexFlag,
+			utils.ArchiveModeFlag,
+			utils.PruneBlocksFlag,
+			utils.LightNodeFlag,
 			utils.DataDirFlag,
 		},
 	},
@@ -138,6 +142,8 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.GasPriceFlag,
 			utils.GasLimitFlag,
+			utils.MaxTxInPoolFlag,
+			utils.MaxTxPerAccountFlag,
 			utils.TxpoolPreExecDisableFlag,
 			utils.DisableSyncVerifyTxFlag,
 			utils.DisableBroadcastNetTxFlag,
@@ -156,6 +162,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MaxConnInBoundFlag,
 			utils.MaxConnOutBoundFlag,
 			utils.MaxConnInBoundForSingleIPFlag,
+			utils.DHTFlag,
+			utils.SecureTransportFlag,
 		},
 	},
 	{
@@ -163,8 +171,10 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.RPCDisabledFlag,
 			utils.RPCPortFlag,
+			utils.RPCTraceEnableFlag,
 			utils.RPCLocalEnableFlag,
 			utils.RPCLocalProtFlag,
+			utils.NativeAbiPathFlag,
 		},
 	},
 	{
@@ -204,6 +214,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ContractPrepareInvokeFlag,
 			utils.ContractParamsFlag,
 			utils.ContractReturnTypeFlag,
+			utils.ContractTraceFlag,
 		},
 	},
 	{
@@ -215,6 +226,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TransactionFromFlag,
 			utils.TransactionToFlag,
 			utils.TransactionAmountFlag,
+			utils.TransactionBatchFlag,
 			utils.TransactionHashFlag,
 			utils.TransferFromSenderFlag,
 			utils.ApproveAssetFlag,
@@ -230,6 +242,28 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.WithdrawOXGAmountFlag,
 		},
 	},
+	{
+		Name: "MULTISIG",
+		Flags: []cli.Flag{
+			utils.MultisigWalletFlag,
+			utils.MultisigOwnersFlag,
+			utils.MultisigThresholdFlag,
+			utils.MultisigMethodFlag,
+			utils.MultisigProposalIdFlag,
+		},
+	},
+	{
+		Name: "CREDENTIAL",
+		Flags: []cli.Flag{
+			utils.CredentialIssuerFlag,
+			utils.CredentialSubjectFlag,
+			utils.CredentialClaimsFlag,
+			utils.CredentialExpiryFlag,
+			utils.CredentialKeyIndexFlag,
+			utils.CredentialFileFlag,
+			utils.CredentialRevokerFlag,
+		},
+	},
 	{
 		Name: "Approve",
 		Flags: []cli.Flag{
@@ -253,6 +287,15 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ImportEndHeightFlag,
 		},
 	},
+	{
+		Name: "SNAPSHOT",
+		Flags: []cli.Flag{
+			utils.SnapshotFileFlag,
+			utils.SnapshotBlockHashFlag,
+			utils.SnapshotStateRootFlag,
+			utils.SnapshotStateHashFlag,
+		},
+	},
 	{
 		Name: "MISC",
 	},
@@ -0,0 +1,353 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OnyxPay/OnyxChain-crypto/keypair"
+	"github.com/OnyxPay/OnyxChain/account"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/serialization"
+	"github.com/OnyxPay/OnyxChain/core/signature"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	cutils "github.com/OnyxPay/OnyxChain/core/utils"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/credential"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+)
+
+const VERSION_CONTRACT_CREDENTIAL = byte(0)
+
+const (
+	CREDENTIAL_CONTEXT = "https://www.w3.org/2018/credentials/v1"
+	CREDENTIAL_TYPE    = "VerifiableCredential"
+)
+
+//Credential is a W3C verifiable credential issued by an onx id, the hash of the credential without proof is committed on-chain
+type Credential struct {
+	Context           []string               `json:"@context"`
+	Type              []string               `json:"type"`
+	Issuer            string                 `json:"issuer"`
+	IssuanceDate      string                 `json:"issuanceDate"`
+	ExpirationDate    string                 `json:"expirationDate,omitempty"`
+	CredentialSubject map[string]interface{} `json:"credentialSubject"`
+	Proof             *CredentialProof       `json:"proof,omitempty"`
+}
+
+type CredentialProof struct {
+	Type               string `json:"type"`
+	Created            string `json:"created"`
+	VerificationMethod string `json:"verificationMethod"`
+	SignatureValue     string `json:"signatureValue"`
+}
+
+//NewCredential return a credential of the claims about the subject, expiry is a unix timestamp and 0 means never expire
+func NewCredential(issuer, subject string, claims map[string]interface{}, expiry uint64) (*Credential, error) {
+	if !account.VerifyID(issuer) {
+		return nil, fmt.Errorf("invalid issuer ID:%s", issuer)
+	}
+	if !account.VerifyID(subject) {
+		return nil, fmt.Errorf("invalid subject ID:%s", subject)
+	}
+	credentialSubject := make(map[string]interface{}, len(claims)+1)
+	for k, v := range claims {
+		credentialSubject[k] = v
+	}
+	credentialSubject["id"] = subject
+	c := &Credential{
+		Context:           []string{CREDENTIAL_CONTEXT},
+		Type:              []string{CREDENTIAL_TYPE},
+		Issuer:            issuer,
+		IssuanceDate:      time.Now().UTC().Format(time.RFC3339),
+		CredentialSubject: credentialSubject,
+	}
+	if expiry != 0 {
+		c.ExpirationDate = time.Unix(int64(expiry), 0).UTC().Format(time.RFC3339)
+	}
+	return c, nil
+}
+
+//ParseCredential parse the json of credential, the numbers of claims are kept as they are to reproduce the hash
+func ParseCredential(data []byte) (*Credential, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	c := new(Credential)
+	if err := decoder.Decode(c); err != nil {
+		return nil, fmt.Errorf("parse credential error:%s", err)
+	}
+	return c, nil
+}
+
+//Subject return the onx id of the credential subject
+func (this *Credential) Subject() string {
+	subject, _ := this.CredentialSubject["id"].(string)
+	return subject
+}
+
+//Expiry return the unix timestamp of the expiration date, 0 if not set
+func (this *Credential) Expiry() (uint64, error) {
+	if this.ExpirationDate == "" {
+		return 0, nil
+	}
+	t, err := time.Parse(time.RFC3339, this.ExpirationDate)
+	if err != nil {
+		return 0, fmt.Errorf("invalid expiration date:%s", this.ExpirationDate)
+	}
+	return uint64(t.Unix()), nil
+}
+
+//Hash return the sha256 hash of the json of credential without proof
+func (this *Credential) Hash() ([]byte, error) {
+	c := *this
+	c.Proof = nil
+	data, err := json.Marshal(&c)
+	if err != nil {
+		return nil, fmt.Errorf("marshal credential error:%s", err)
+	}
+	hash := sha256.Sum256(data)
+	return hash[:], nil
+}
+
+//KeyIndex return the index of the issuer's key in the verification method of proof
+func (this *Credential) KeyIndex() (uint64, error) {
+	if this.Proof == nil {
+		return 0, fmt.Errorf("credential has no proof")
+	}
+	prefix := this.Issuer + "#keys-"
+	if !strings.HasPrefix(this.Proof.VerificationMethod, prefix) {
+		return 0, fmt.Errorf("verification method %s is not a key of the issuer", this.Proof.VerificationMethod)
+	}
+	index, err := strconv.ParseUint(strings.TrimPrefix(this.Proof.VerificationMethod, prefix), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid verification method %s", this.Proof.VerificationMethod)
+	}
+	return index, nil
+}
+
+//SignCredential sign the hash of credential by the signer, which is the key of keyIndex of the issuer
+func SignCredential(signer *account.Account, c *Credential, keyIndex uint64) error {
+	hash, err := c.Hash()
+	if err != nil {
+		return err
+	}
+	sig, err := signature.Sign(signer, hash)
+	if err != nil {
+		return fmt.Errorf("sign credential error:%s", err)
+	}
+	c.Proof = &CredentialProof{
+		Type:               signer.SigScheme.Name(),
+		Created:            time.Now().UTC().Format(time.RFC3339),
+		VerificationMethod: fmt.Sprintf("%s#keys-%d", c.Issuer, keyIndex),
+		SignatureValue:     hex.EncodeToString(sig),
+	}
+	return nil
+}
+
+//VerifyCredentialSignature verify the proof of credential by the public key of the issuer
+func VerifyCredentialSignature(c *Credential, pubKey keypair.PublicKey) error {
+	if c.Proof == nil {
+		return fmt.Errorf("credential has no proof")
+	}
+	hash, err := c.Hash()
+	if err != nil {
+		return err
+	}
+	sig, err := hex.DecodeString(c.Proof.SignatureValue)
+	if err != nil {
+		return fmt.Errorf("invalid signature value:%s", err)
+	}
+	return signature.Verify(pubKey, hash, sig)
+}
+
+//VerifyCredential verify the proof of credential by the issuer's key on-chain, and return the on-chain status of the credential.
+//The credential committed on-chain should be issued by the issuer about the subject of c
+func VerifyCredential(c *Credential) (byte, error) {
+	keyIndex, err := c.KeyIndex()
+	if err != nil {
+		return credential.STATUS_UNKNOWN, err
+	}
+	pubKey, err := GetOnxIDPublicKey(c.Issuer, uint32(keyIndex))
+	if err != nil {
+		return credential.STATUS_UNKNOWN, err
+	}
+	if err := VerifyCredentialSignature(c, pubKey); err != nil {
+		return credential.STATUS_UNKNOWN, err
+	}
+	hash, err := c.Hash()
+	if err != nil {
+		return credential.STATUS_UNKNOWN, err
+	}
+	status, err := GetCredentialStatus(c.Issuer, hash)
+	if err != nil || status == credential.STATUS_UNKNOWN {
+		return status, err
+	}
+	committed, err := GetCredential(c.Issuer, hash)
+	if err != nil {
+		return credential.STATUS_UNKNOWN, err
+	}
+	if string(committed.Issuer) != c.Issuer || string(committed.Subject) != c.Subject() {
+		return credential.STATUS_UNKNOWN, fmt.Errorf("committed credential of issuer:%s subject:%s mismatch",
+			committed.Issuer, committed.Subject)
+	}
+	return status, nil
+}
+
+//CredentialStatusString return the readable status of credential
+func CredentialStatusString(status byte) string {
+	switch status {
+	case credential.STATUS_VALID:
+		return "valid"
+	case credential.STATUS_REVOKED:
+		return "revoked"
+	case credential.STATUS_EXPIRED:
+		return "expired"
+	}
+	return "unknown"
+}
+
+//CredentialCommitTx return the transaction committing the hash of the signed credential
+func CredentialCommitTx(gasPrice, gasLimit uint64, c *Credential) (*types.MutableTransaction, error) {
+	keyIndex, err := c.KeyIndex()
+	if err != nil {
+		return nil, err
+	}
+	expiry, err := c.Expiry()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := c.Hash()
+	if err != nil {
+		return nil, err
+	}
+	param := &credential.CommitParam{
+		Hash:     hash,
+		Issuer:   []byte(c.Issuer),
+		Subject:  []byte(c.Subject()),
+		Expiry:   expiry,
+		KeyIndex: keyIndex,
+	}
+	return newCredentialInvokeTx(gasPrice, gasLimit, credential.COMMIT_NAME, []interface{}{param})
+}
+
+//CredentialRevokeTx return the transaction revoking the credential of hash issued by the issuer, the revoker is the issuer or the subject
+func CredentialRevokeTx(gasPrice, gasLimit uint64, issuer string, hash []byte, revoker string, keyIndex uint64) (*types.MutableTransaction, error) {
+	if !account.VerifyID(issuer) {
+		return nil, fmt.Errorf("invalid issuer ID:%s", issuer)
+	}
+	if !account.VerifyID(revoker) {
+		return nil, fmt.Errorf("invalid revoker ID:%s", revoker)
+	}
+	param := &credential.RevokeParam{
+		Hash:     hash,
+		Issuer:   []byte(issuer),
+		Revoker:  []byte(revoker),
+		KeyIndex: keyIndex,
+	}
+	return newCredentialInvokeTx(gasPrice, gasLimit, credential.REVOKE_NAME, []interface{}{param})
+}
+
+func newCredentialInvokeTx(gasPrice, gasLimit uint64, method string, params []interface{}) (*types.MutableTransaction, error) {
+	invokeCode, err := cutils.BuildNativeInvokeCode(utils.CredentialContractAddress, VERSION_CONTRACT_CREDENTIAL, method, params)
+	if err != nil {
+		return nil, fmt.Errorf("build invoke code error:%s", err)
+	}
+	return NewInvokeTransaction(gasPrice, gasLimit, invokeCode), nil
+}
+
+//GetCredentialStatus return the status of the credential of hash issued by the issuer by pre-executing getStatus
+func GetCredentialStatus(issuer string, hash []byte) (byte, error) {
+	param := &credential.CredentialIdParam{Hash: hash, Issuer: []byte(issuer)}
+	preResult, err := PrepareInvokeNativeContract(utils.CredentialContractAddress, VERSION_CONTRACT_CREDENTIAL,
+		credential.GET_STATUS_NAME, []interface{}{param})
+	if err != nil {
+		return credential.STATUS_UNKNOWN, err
+	}
+	data, err := preExecResultBytes(preResult.State, preResult.Result)
+	if err != nil {
+		return credential.STATUS_UNKNOWN, err
+	}
+	if len(data) != 1 {
+		return credential.STATUS_UNKNOWN, fmt.Errorf("invalid status:%x", data)
+	}
+	return data[0], nil
+}
+
+//GetCredential return the committed credential of hash issued by the issuer by pre-executing getCredential
+func GetCredential(issuer string, hash []byte) (*credential.Credential, error) {
+	param := &credential.CredentialIdParam{Hash: hash, Issuer: []byte(issuer)}
+	preResult, err := PrepareInvokeNativeContract(utils.CredentialContractAddress, VERSION_CONTRACT_CREDENTIAL,
+		credential.GET_CREDENTIAL_NAME, []interface{}{param})
+	if err != nil {
+		return nil, err
+	}
+	data, err := preExecResultBytes(preResult.State, preResult.Result)
+	if err != nil {
+		return nil, err
+	}
+	committed := new(credential.Credential)
+	if err := committed.Deserialization(common.NewZeroCopySource(data)); err != nil {
+		return nil, fmt.Errorf("deserialize credential error:%s", err)
+	}
+	return committed, nil
+}
+
+//GetOnxIDPublicKey return the public key of keyIndex of the onx id by pre-executing getPublicKeys, revoked keys are not returned
+func GetOnxIDPublicKey(id string, keyIndex uint32) (keypair.PublicKey, error) {
+	preResult, err := PrepareInvokeNativeContract(utils.OnxIDContractAddress, 0, "getPublicKeys", []interface{}{[]byte(id)})
+	if err != nil {
+		return nil, err
+	}
+	data, err := preExecResultBytes(preResult.State, preResult.Result)
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBuffer(data)
+	for buf.Len() > 0 {
+		index, err := serialization.ReadUint32(buf)
+		if err != nil {
+			return nil, fmt.Errorf("read key index error:%s", err)
+		}
+		key, err := serialization.ReadVarBytes(buf)
+		if err != nil {
+			return nil, fmt.Errorf("read public key error:%s", err)
+		}
+		if index == keyIndex {
+			return keypair.DeserializePublicKey(key)
+		}
+	}
+	return nil, fmt.Errorf("key %d of %s not found", keyIndex, id)
+}
+
+func preExecResultBytes(state byte, result interface{}) ([]byte, error) {
+	if state == 0 {
+		return nil, fmt.Errorf("prepare invoke failed")
+	}
+	str, ok := result.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid result:%v", result)
+	}
+	return hex.DecodeString(str)
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/OnyxPay/OnyxChain/account"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredential(t *testing.T) {
+	issuer, _ := account.GenerateID()
+	subject, _ := account.GenerateID()
+	acc := account.NewAccount("")
+	claims := map[string]interface{}{"name": "alice", "age": json.Number("30")}
+	_, err := NewCredential("did:else:x", subject, claims, 0)
+	assert.NotNil(t, err)
+	c, err := NewCredential(issuer, subject, claims, 1600000000)
+	assert.Nil(t, err)
+	assert.Equal(t, subject, c.Subject())
+	expiry, err := c.Expiry()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1600000000), expiry)
+
+	assert.Nil(t, SignCredential(acc, c, 2))
+	keyIndex, err := c.KeyIndex()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), keyIndex)
+	assert.Nil(t, VerifyCredentialSignature(c, acc.PublicKey))
+	assert.NotNil(t, VerifyCredentialSignature(c, account.NewAccount("").PublicKey))
+
+	// the hash is kept after the credential is transferred as json
+	data, err := json.Marshal(c)
+	assert.Nil(t, err)
+	parsed, err := ParseCredential(data)
+	assert.Nil(t, err)
+	hash, _ := c.Hash()
+	parsedHash, _ := parsed.Hash()
+	assert.Equal(t, hash, parsedHash)
+	assert.Nil(t, VerifyCredentialSignature(parsed, acc.PublicKey))
+
+	parsed.CredentialSubject["name"] = "bob"
+	assert.NotNil(t, VerifyCredentialSignature(parsed, acc.PublicKey))
+}
@@ -28,6 +28,7 @@ import (
 
 const (
 	DEFAULT_EXPORT_FILE   = "./OnxBlocks.dat"
+	DEFAULT_SNAPSHOT_FILE = "./OnxSnapshot.dat"
 	DEFAULT_ABI_PATH      = "./abi"
 	DEFAULT_EXPORT_HEIGHT = 0
 	DEFAULT_WALLET_PATH   = "./wallet_data"
@@ -48,6 +49,23 @@ var (
 		Name:  "disable-event-log",
 		Usage: "Discard event log output by smart contract execution",
 	}
+	EventIndexFlag = cli.BoolFlag{
+		Name:  "event-index",
+		Usage: "Index transactions by contract address and transfer address of event log, for blocks saved since enabled",
+	}
+	ArchiveModeFlag = cli.BoolFlag{
+		Name:  "archive",
+		Usage: "Keep state history of every block to serve storage and balance queries at a past height",
+	}
+	PruneBlocksFlag = cli.UintFlag{
+		Name:  "prune-blocks",
+		Usage: "Keep transactions, events and state history of only the last `<number>` blocks, 0 to keep all. Block headers are always kept",
+		Value: 0,
+	}
+	LightNodeFlag = cli.BoolFlag{
+		Name:  "lightnode",
+		Usage: "Run as light node which only keeps block headers, and verifies transaction and storage proofs from peers",
+	}
 	WalletFileFlag = cli.StringFlag{
 		Name:  "wallet,w",
 		Value: config.DEFAULT_WALLET_FILE_NAME,
@@ -99,6 +117,16 @@ var (
 		Usage: "Min gas price `<value>` of transaction to be accepted by tx pool.",
 		Value: config.DEFAULT_GAS_PRICE,
 	}
+	MaxTxInPoolFlag = cli.UintFlag{
+		Name:  "max-tx-in-pool",
+		Usage: "Max transaction `<number>` in tx pool, the lowest gas price one is evicted when full",
+		Value: config.DEFAULT_MAX_TX_IN_POOL,
+	}
+	MaxTxPerAccountFlag = cli.UintFlag{
+		Name:  "max-tx-per-account",
+		Usage: "Max transaction `<number>` of a payer in tx pool",
+		Value: config.DEFAULT_MAX_TX_PER_ACCOUNT,
+	}
 
 	//Test Mode setting
 	EnableTestModeFlag = cli.BoolFlag{
@@ -160,6 +188,14 @@ var (
 		Usage: "Max connection `<number>` in bound for single ip",
 		Value: config.DEFAULT_MAX_CONN_IN_BOUND_FOR_SINGLE_IP,
 	}
+	DHTFlag = cli.BoolFlag{
+		Name:  "dht",
+		Usage: "Discover peers by kademlia DHT through the UDP port of --nodeport, seed nodes are used as boot nodes.",
+	}
+	SecureTransportFlag = cli.BoolFlag{
+		Name:  "secure-transport",
+		Usage: "Encrypt and authenticate P2P connections with the wallet account as node key. Peer id is derived from the public key, and vBFT consensus peers are checked against the governance peer pool.",
+	}
 	// RPC settings
 	RPCDisabledFlag = cli.BoolFlag{
 		Name:  "disable-rpc",
@@ -170,6 +206,15 @@ var (
 		Usage: "Json rpc server listening port `<number>`",
 		Value: config.DEFAULT_RPC_PORT,
 	}
+	NativeAbiPathFlag = cli.StringFlag{
+		Name:  "native-abi",
+		Usage: "Native contract abi `<path>`, used to type params and results of contract call pre-execution",
+		Value: config.DEFAULT_NATIVE_ABI_PATH,
+	}
+	RPCTraceEnableFlag = cli.BoolFlag{
+		Name:  "enable-rpc-trace",
+		Usage: "Enable tracetransaction and traced preexecute of rpc server, which record neovm execution steps in memory",
+	}
 	RPCLocalEnableFlag = cli.BoolFlag{
 		Name:  "localrpc",
 		Usage: "Enable local rpc server",
@@ -293,6 +338,11 @@ var (
 		Name:  "needstore",
 		Usage: "Is need use storage in contract",
 	}
+	ContractVmTypeFlag = cli.StringFlag{
+		Name:  "vmtype",
+		Usage: "Specifies contract vm `<type>`. neovm or wasm",
+		Value: VM_TYPE_NEOVM,
+	}
 	ContractCodeFileFlag = cli.StringFlag{
 		Name:  "code",
 		Usage: "File path of contract code `<path>`",
@@ -336,6 +386,10 @@ var (
 		Name:  "return",
 		Usage: "Return `<type>` of contract. bytearray(hexstring), string, integer, boolean",
 	}
+	ContractTraceFlag = cli.BoolFlag{
+		Name:  "trace",
+		Usage: "Print the neovm execution steps of prepare invoke contract, the node should run with --enable-rpc-trace",
+	}
 
 	//information cmd settings
 	BlockHashInfoFlag = cli.StringFlag{
@@ -365,6 +419,10 @@ var (
 		Name:  "amount",
 		Usage: "Transfer `<amount>`. Float number",
 	}
+	TransactionBatchFlag = cli.StringFlag{
+		Name:  "batch",
+		Usage: "Transfer to the receivers in the csv `<file>`, each line is to,amount[,memo]",
+	}
 	TransactionHashFlag = cli.StringFlag{
 		Name:  "hash",
 		Usage: "Transaction `<hash>`",
@@ -439,6 +497,59 @@ var (
 		Usage: "Force to send transaction",
 	}
 
+	//Multisig setting
+	MultisigWalletFlag = cli.StringFlag{
+		Name:  "multisig",
+		Usage: "Multisig wallet `<address>`",
+	}
+	MultisigOwnersFlag = cli.StringFlag{
+		Name:  "owners",
+		Usage: "Owner `<addresses>` of the multisig wallet, split by ','",
+	}
+	MultisigThresholdFlag = cli.UintFlag{
+		Name:  "threshold",
+		Usage: "Number of confirmations `<number>` to execute a proposal",
+	}
+	MultisigMethodFlag = cli.StringFlag{
+		Name:  "method",
+		Usage: "Method `<name>` of the native contract to invoke. Empty for neovm contract, the method is in the params",
+	}
+	MultisigProposalIdFlag = cli.Uint64Flag{
+		Name:  "id",
+		Usage: "Proposal id `<number>` of the multisig wallet",
+	}
+
+	//Credential setting
+	CredentialIssuerFlag = cli.StringFlag{
+		Name:  "issuer",
+		Usage: "Onx ID `<id>` of the credential issuer",
+	}
+	CredentialSubjectFlag = cli.StringFlag{
+		Name:  "subject",
+		Usage: "Onx ID `<id>` of the credential subject",
+	}
+	CredentialClaimsFlag = cli.StringFlag{
+		Name:  "claims",
+		Usage: "Claims `<json>` about the subject, e.g. '{\"name\":\"alice\"}'",
+	}
+	CredentialExpiryFlag = cli.Uint64Flag{
+		Name:  "expiry",
+		Usage: "Expiry unix `<timestamp>` of the credential, 0 means never expire",
+	}
+	CredentialKeyIndexFlag = cli.Uint64Flag{
+		Name:  "keyindex",
+		Usage: "Index `<number>` of the onx id's key to sign",
+		Value: 1,
+	}
+	CredentialFileFlag = cli.StringFlag{
+		Name:  "credential",
+		Usage: "Credential json `<file>`",
+	}
+	CredentialRevokerFlag = cli.StringFlag{
+		Name:  "revoker",
+		Usage: "Onx ID `<id>` of the revoker, which is the issuer or the subject",
+	}
+
 	//Cli setting
 	CliAddressFlag = cli.StringFlag{
 		Name:  "cliaddress",
@@ -483,6 +594,25 @@ var (
 		Value: "m",
 	}
 
+	//Snapshot setting
+	SnapshotFileFlag = cli.StringFlag{
+		Name:  "snapshot-file",
+		Usage: "State snapshot `<file>` path",
+		Value: DEFAULT_SNAPSHOT_FILE,
+	}
+	SnapshotBlockHashFlag = cli.StringFlag{
+		Name:  "snapshot-block-hash",
+		Usage: "Trusted `<hash>` of the last block in snapshot",
+	}
+	SnapshotStateRootFlag = cli.StringFlag{
+		Name:  "snapshot-state-root",
+		Usage: "Trusted state merkle `<root>` of the last block in snapshot",
+	}
+	SnapshotStateHashFlag = cli.StringFlag{
+		Name:  "snapshot-state-hash",
+		Usage: "Trusted `<hash>` of all the state entries in snapshot",
+	}
+
 	//PreExecute switcher
 	TxpoolPreExecDisableFlag = cli.BoolFlag{
 		Name:  "disable-tx-pool-pre-exec",
@@ -0,0 +1,117 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	cutils "github.com/OnyxPay/OnyxChain/core/utils"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/htlc"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+)
+
+const (
+	VERSION_CONTRACT_HTLC = byte(0)
+
+	HASH_TYPE_SHA256  = "sha256"
+	HASH_TYPE_HASH160 = "hash160"
+)
+
+//ParseHashType return the hash type of htlc by name, sha256 is used if the name is empty
+func ParseHashType(name string) (byte, error) {
+	switch strings.ToLower(name) {
+	case "", HASH_TYPE_SHA256:
+		return htlc.HASH_SHA256, nil
+	case HASH_TYPE_HASH160:
+		return htlc.HASH_HASH160, nil
+	}
+	return 0, fmt.Errorf("unsupport hash type:%s", name)
+}
+
+//HtlcLockTx return the transaction locking the asset of sender for receiver under the hash lock.
+//Asset is onyx, oxg or the symbol of an asset of the token registry
+func HtlcLockTx(gasPrice, gasLimit uint64, asset, sender, receiver string, amount uint64, hashType byte,
+	hashLock []byte, timeout uint32) (*types.MutableTransaction, error) {
+	senderAddr, err := common.AddressFromBase58(sender)
+	if err != nil {
+		return nil, fmt.Errorf("sender address:%s invalid:%s", sender, err)
+	}
+	receiverAddr, err := common.AddressFromBase58(receiver)
+	if err != nil {
+		return nil, fmt.Errorf("receiver address:%s invalid:%s", receiver, err)
+	}
+	param := &htlc.LockParam{
+		Sender:   senderAddr,
+		Receiver: receiverAddr,
+		Amount:   amount,
+		HashType: hashType,
+		HashLock: hashLock,
+		Timeout:  timeout,
+	}
+	switch asset {
+	case ASSET_ONX:
+		param.Asset = utils.OnxContractAddress
+	case ASSET_OXG:
+		param.Asset = utils.OxgContractAddress
+	case "":
+		return nil, fmt.Errorf("asset should not be empty")
+	default:
+		param.Asset = utils.TokenContractAddress
+		param.Symbol = asset
+	}
+	return newHtlcInvokeTx(gasPrice, gasLimit, htlc.LOCK_NAME, []interface{}{param})
+}
+
+//HtlcClaimTx return the transaction claiming the lock of sender with the preimage
+func HtlcClaimTx(gasPrice, gasLimit uint64, sender string, hashLock, preimage []byte) (*types.MutableTransaction, error) {
+	senderAddr, err := common.AddressFromBase58(sender)
+	if err != nil {
+		return nil, fmt.Errorf("sender address:%s invalid:%s", sender, err)
+	}
+	param := &htlc.ClaimParam{
+		Sender:   senderAddr,
+		HashLock: hashLock,
+		Preimage: preimage,
+	}
+	return newHtlcInvokeTx(gasPrice, gasLimit, htlc.CLAIM_NAME, []interface{}{param})
+}
+
+//HtlcRefundTx return the transaction refunding the lock of sender after timeout
+func HtlcRefundTx(gasPrice, gasLimit uint64, sender string, hashLock []byte) (*types.MutableTransaction, error) {
+	senderAddr, err := common.AddressFromBase58(sender)
+	if err != nil {
+		return nil, fmt.Errorf("sender address:%s invalid:%s", sender, err)
+	}
+	param := &htlc.LockIdParam{
+		Sender:   senderAddr,
+		HashLock: hashLock,
+	}
+	return newHtlcInvokeTx(gasPrice, gasLimit, htlc.REFUND_NAME, []interface{}{param})
+}
+
+func newHtlcInvokeTx(gasPrice, gasLimit uint64, method string, params []interface{}) (*types.MutableTransaction, error) {
+	invokeCode, err := cutils.BuildNativeInvokeCode(utils.HtlcContractAddress, VERSION_CONTRACT_HTLC, method, params)
+	if err != nil {
+		return nil, fmt.Errorf("build invoke code error:%s", err)
+	}
+	return NewInvokeTransaction(gasPrice, gasLimit, invokeCode), nil
+}
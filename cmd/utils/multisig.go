@@ -0,0 +1,253 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	cutils "github.com/OnyxPay/OnyxChain/core/utils"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/multisig"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/neovm"
+	vmtypes "github.com/OnyxPay/OnyxChain/vm/neovm/types"
+)
+
+const VERSION_CONTRACT_MULTISIG = byte(0)
+
+//BuildMultisigArgs return the args of a proposal from the params parsed by ParseParams.
+//For a native contract(method is not empty) the params are serialized as the input of the method,
+//in which an array is serialized as a struct without the count of the elements.
+//For a neovm contract the params are serialized as a stack item array pushed to the contract
+func BuildMultisigArgs(method string, params []interface{}) ([]byte, error) {
+	isNative := method != ""
+	items := make([]vmtypes.StackItems, 0, len(params))
+	for _, param := range params {
+		item, err := buildMultisigStackItem(param, isNative)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if !isNative {
+		return neovm.SerializeStackItem(vmtypes.NewArray(items))
+	}
+	buf := new(bytes.Buffer)
+	for _, item := range items {
+		if err := neovm.BuildParamToNative(buf, item); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func buildMultisigStackItem(param interface{}, isNative bool) (vmtypes.StackItems, error) {
+	switch v := param.(type) {
+	case bool:
+		return vmtypes.NewBoolean(v), nil
+	case int64:
+		return vmtypes.NewInteger(big.NewInt(v)), nil
+	case uint64:
+		return vmtypes.NewInteger(new(big.Int).SetUint64(v)), nil
+	case string:
+		return vmtypes.NewByteArray([]byte(v)), nil
+	case []byte:
+		return vmtypes.NewByteArray(v), nil
+	case common.Address:
+		return vmtypes.NewByteArray(v[:]), nil
+	case []interface{}:
+		items := make([]vmtypes.StackItems, 0, len(v))
+		for _, p := range v {
+			item, err := buildMultisigStackItem(p, isNative)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		if isNative {
+			return vmtypes.NewStruct(items), nil
+		}
+		return vmtypes.NewArray(items), nil
+	}
+	return nil, fmt.Errorf("unsupported param:%v", param)
+}
+
+func parseAddresses(addrs []string) ([]common.Address, error) {
+	addresses := make([]common.Address, 0, len(addrs))
+	for _, str := range addrs {
+		addr, err := common.AddressFromBase58(str)
+		if err != nil {
+			return nil, fmt.Errorf("address:%s invalid:%s", str, err)
+		}
+		addresses = append(addresses, addr)
+	}
+	return addresses, nil
+}
+
+//BuildChangeOwnersArgs return the args of a proposal changing the owners and threshold of the wallet
+func BuildChangeOwnersArgs(wallet string, owners []string, threshold uint32) ([]byte, error) {
+	walletAddr, err := common.AddressFromBase58(wallet)
+	if err != nil {
+		return nil, fmt.Errorf("wallet address:%s invalid:%s", wallet, err)
+	}
+	ownerAddrs, err := parseAddresses(owners)
+	if err != nil {
+		return nil, err
+	}
+	param := &multisig.ChangeOwnersParam{
+		Wallet:    walletAddr,
+		Owners:    ownerAddrs,
+		Threshold: threshold,
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	return sink.Bytes(), nil
+}
+
+//MultisigCreateWalletTx return the transaction creating a wallet of the owners
+func MultisigCreateWalletTx(gasPrice, gasLimit uint64, creator string, owners []string, threshold uint32) (*types.MutableTransaction, error) {
+	creatorAddr, err := common.AddressFromBase58(creator)
+	if err != nil {
+		return nil, fmt.Errorf("creator address:%s invalid:%s", creator, err)
+	}
+	ownerAddrs, err := parseAddresses(owners)
+	if err != nil {
+		return nil, err
+	}
+	param := &multisig.CreateWalletParam{
+		Creator:   creatorAddr,
+		Owners:    ownerAddrs,
+		Threshold: threshold,
+	}
+	return newMultisigInvokeTx(gasPrice, gasLimit, multisig.CREATE_WALLET_NAME, []interface{}{param})
+}
+
+//MultisigSubmitTx return the transaction submitting a proposal invoking the method of the target contract
+//with the args built by BuildMultisigArgs or BuildChangeOwnersArgs
+func MultisigSubmitTx(gasPrice, gasLimit uint64, wallet, proposer string, target common.Address, method string,
+	args []byte) (*types.MutableTransaction, error) {
+	walletAddr, err := common.AddressFromBase58(wallet)
+	if err != nil {
+		return nil, fmt.Errorf("wallet address:%s invalid:%s", wallet, err)
+	}
+	proposerAddr, err := common.AddressFromBase58(proposer)
+	if err != nil {
+		return nil, fmt.Errorf("proposer address:%s invalid:%s", proposer, err)
+	}
+	param := &multisig.SubmitParam{
+		Wallet:   walletAddr,
+		Proposer: proposerAddr,
+		Target:   target,
+		Method:   method,
+		Args:     args,
+	}
+	return newMultisigInvokeTx(gasPrice, gasLimit, multisig.SUBMIT_NAME, []interface{}{param})
+}
+
+//MultisigConfirmTx return the transaction confirming the proposal by the owner
+func MultisigConfirmTx(gasPrice, gasLimit uint64, wallet, owner string, id uint64) (*types.MutableTransaction, error) {
+	return newMultisigConfirmTx(gasPrice, gasLimit, multisig.CONFIRM_NAME, wallet, owner, id)
+}
+
+//MultisigRevokeTx return the transaction revoking the confirmation of the proposal by the owner
+func MultisigRevokeTx(gasPrice, gasLimit uint64, wallet, owner string, id uint64) (*types.MutableTransaction, error) {
+	return newMultisigConfirmTx(gasPrice, gasLimit, multisig.REVOKE_NAME, wallet, owner, id)
+}
+
+func newMultisigConfirmTx(gasPrice, gasLimit uint64, method, wallet, owner string, id uint64) (*types.MutableTransaction, error) {
+	walletAddr, err := common.AddressFromBase58(wallet)
+	if err != nil {
+		return nil, fmt.Errorf("wallet address:%s invalid:%s", wallet, err)
+	}
+	ownerAddr, err := common.AddressFromBase58(owner)
+	if err != nil {
+		return nil, fmt.Errorf("owner address:%s invalid:%s", owner, err)
+	}
+	param := &multisig.ConfirmParam{
+		Wallet: walletAddr,
+		Id:     id,
+		Owner:  ownerAddr,
+	}
+	return newMultisigInvokeTx(gasPrice, gasLimit, method, []interface{}{param})
+}
+
+func newMultisigInvokeTx(gasPrice, gasLimit uint64, method string, params []interface{}) (*types.MutableTransaction, error) {
+	invokeCode, err := cutils.BuildNativeInvokeCode(utils.MultisigContractAddress, VERSION_CONTRACT_MULTISIG, method, params)
+	if err != nil {
+		return nil, fmt.Errorf("build invoke code error:%s", err)
+	}
+	return NewInvokeTransaction(gasPrice, gasLimit, invokeCode), nil
+}
+
+//GetMultisigWallet return the wallet by pre-executing getWallet
+func GetMultisigWallet(wallet string) (*multisig.Wallet, error) {
+	walletAddr, err := common.AddressFromBase58(wallet)
+	if err != nil {
+		return nil, fmt.Errorf("wallet address:%s invalid:%s", wallet, err)
+	}
+	data, err := prepareInvokeMultisig(multisig.GET_WALLET_NAME, []interface{}{walletAddr[:]})
+	if err != nil {
+		return nil, err
+	}
+	w := new(multisig.Wallet)
+	if err := w.Deserialization(common.NewZeroCopySource(data)); err != nil {
+		return nil, fmt.Errorf("deserialize wallet error:%s", err)
+	}
+	return w, nil
+}
+
+//GetMultisigProposal return the proposal of the wallet by pre-executing getProposal
+func GetMultisigProposal(wallet string, id uint64) (*multisig.Proposal, error) {
+	walletAddr, err := common.AddressFromBase58(wallet)
+	if err != nil {
+		return nil, fmt.Errorf("wallet address:%s invalid:%s", wallet, err)
+	}
+	param := &multisig.ProposalParam{
+		Wallet: walletAddr,
+		Id:     id,
+	}
+	data, err := prepareInvokeMultisig(multisig.GET_PROPOSAL_NAME, []interface{}{param})
+	if err != nil {
+		return nil, err
+	}
+	proposal := new(multisig.Proposal)
+	if err := proposal.Deserialization(common.NewZeroCopySource(data)); err != nil {
+		return nil, fmt.Errorf("deserialize proposal error:%s", err)
+	}
+	return proposal, nil
+}
+
+func prepareInvokeMultisig(method string, params []interface{}) ([]byte, error) {
+	preResult, err := PrepareInvokeNativeContract(utils.MultisigContractAddress, VERSION_CONTRACT_MULTISIG, method, params)
+	if err != nil {
+		return nil, err
+	}
+	if preResult.State == 0 {
+		return nil, fmt.Errorf("prepare invoke %s failed", method)
+	}
+	str, ok := preResult.Result.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid result of %s:%v", method, preResult.Result)
+	}
+	return hex.DecodeString(str)
+}
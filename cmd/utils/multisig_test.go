@@ -0,0 +1,51 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/onx"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/neovm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildMultisigArgs(t *testing.T) {
+	from, to := common.Address{1}, common.Address{2}
+	params, err := ParseParams("[int:1,[bytearray:" + common.ToHexString(from[:]) + ",bytearray:" +
+		common.ToHexString(to[:]) + ",int:100]]")
+	assert.Nil(t, err)
+	args, err := BuildMultisigArgs(onx.TRANSFER_NAME, params)
+	assert.Nil(t, err)
+	transfers := new(onx.Transfers)
+	assert.Nil(t, transfers.Deserialization(common.NewZeroCopySource(args)))
+	assert.Equal(t, []onx.State{{From: from, To: to, Value: 100}}, transfers.States)
+
+	args, err = BuildMultisigArgs("", []interface{}{"transfer", []interface{}{from[:], int64(100)}})
+	assert.Nil(t, err)
+	item, err := neovm.DeserializeStackItem(bytes.NewReader(args))
+	assert.Nil(t, err)
+	items, err := item.GetArray()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(items))
+	method, _ := items[0].GetByteArray()
+	assert.Equal(t, "transfer", string(method))
+}
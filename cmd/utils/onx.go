@@ -49,12 +49,16 @@ import (
 )
 
 const (
-	VERSION_TRANSACTION    = byte(0)
-	VERSION_CONTRACT_ONX   = byte(0)
-	VERSION_CONTRACT_OXG   = byte(0)
-	CONTRACT_TRANSFER      = "transfer"
-	CONTRACT_TRANSFER_FROM = "transferFrom"
-	CONTRACT_APPROVE       = "approve"
+	VERSION_TRANSACTION     = byte(0)
+	VERSION_CONTRACT_ONX    = byte(0)
+	VERSION_CONTRACT_OXG    = byte(0)
+	CONTRACT_TRANSFER       = "transfer"
+	CONTRACT_TRANSFER_FROM  = "transferFrom"
+	CONTRACT_APPROVE        = "approve"
+	CONTRACT_TRANSFER_MULTI = "transferMulti"
+
+	VM_TYPE_NEOVM = "neovm"
+	VM_TYPE_WASM  = "wasm"
 
 	ASSET_ONX = "onyx"
 	ASSET_OXG = "oxg"
@@ -136,6 +140,27 @@ func Transfer(gasPrice, gasLimit uint64, signer *account.Account, asset, from, t
 	return txHash, nil
 }
 
+//TransferMulti transfer onx or oxg from the signer to the receivers in one transaction
+func TransferMulti(gasPrice, gasLimit uint64, signer *account.Account, asset string, entries []*BatchTransferEntry) (string, error) {
+	mutable, err := TransferMultiTx(gasPrice, gasLimit, asset, signer.Address.ToBase58(), entries)
+	if err != nil {
+		return "", err
+	}
+	err = SignTransaction(signer, mutable)
+	if err != nil {
+		return "", fmt.Errorf("SignTransaction error:%s", err)
+	}
+	tx, err := mutable.IntoImmutable()
+	if err != nil {
+		return "", fmt.Errorf("convert immutable transaction error:%s", err)
+	}
+	txHash, err := SendRawTransaction(tx)
+	if err != nil {
+		return "", fmt.Errorf("SendTransaction error:%s", err)
+	}
+	return txHash, nil
+}
+
 func TransferFrom(gasPrice, gasLimit uint64, signer *account.Account, asset, sender, from, to string, amount uint64) (string, error) {
 	mutable, err := TransferFromTx(gasPrice, gasLimit, asset, sender, from, to, amount)
 	if err != nil {
@@ -246,6 +271,43 @@ func TransferTx(gasPrice, gasLimit uint64, asset, from, to string, amount uint64
 	return mutableTx, nil
 }
 
+func TransferMultiTx(gasPrice, gasLimit uint64, asset, from string, entries []*BatchTransferEntry) (*types.MutableTransaction, error) {
+	fromAddr, err := common.AddressFromBase58(from)
+	if err != nil {
+		return nil, fmt.Errorf("from address:%s invalid:%s", from, err)
+	}
+	param := &onx.TransferMulti{From: fromAddr}
+	for _, entry := range entries {
+		toAddr, err := common.AddressFromBase58(entry.To)
+		if err != nil {
+			return nil, fmt.Errorf("to address:%s invalid:%s", entry.To, err)
+		}
+		param.Entries = append(param.Entries, onx.TransferEntry{
+			To:    toAddr,
+			Value: entry.Amount,
+			Memo:  entry.Memo,
+		})
+	}
+	var version byte
+	var contractAddr common.Address
+	switch strings.ToLower(asset) {
+	case ASSET_ONX:
+		version = VERSION_CONTRACT_ONX
+		contractAddr = utils.OnxContractAddress
+	case ASSET_OXG:
+		version = VERSION_CONTRACT_OXG
+		contractAddr = utils.OxgContractAddress
+	default:
+		return nil, fmt.Errorf("unsupport asset:%s", asset)
+	}
+	invokeCode, err := cutils.BuildNativeInvokeCode(contractAddr, version, CONTRACT_TRANSFER_MULTI, []interface{}{param})
+	if err != nil {
+		return nil, fmt.Errorf("build invoke code error:%s", err)
+	}
+	mutableTx := NewInvokeTransaction(gasPrice, gasLimit, invokeCode)
+	return mutableTx, nil
+}
+
 func TransferFromTx(gasPrice, gasLimit uint64, asset, sender, from, to string, amount uint64) (*types.MutableTransaction, error) {
 	senderAddr, err := common.AddressFromBase58(sender)
 	if err != nil {
@@ -597,6 +659,7 @@ func DeployContract(
 	gasLimit uint64,
 	signer *account.Account,
 	needStorage bool,
+	vmType payload.VmType,
 	code,
 	cname,
 	cversion,
@@ -608,7 +671,7 @@ func DeployContract(
 	if err != nil {
 		return "", fmt.Errorf("hex.DecodeString error:%s", err)
 	}
-	mutable := NewDeployCodeTransaction(gasPrice, gasLimit, c, needStorage, cname, cversion, cauthor, cemail, cdesc)
+	mutable := NewDeployCodeTransaction(gasPrice, gasLimit, c, needStorage, vmType, cname, cversion, cauthor, cemail, cdesc)
 
 	err = SignTransaction(signer, mutable)
 	if err != nil {
@@ -627,6 +690,7 @@ func DeployContract(
 
 func PrepareDeployContract(
 	needStorage bool,
+	vmType payload.VmType,
 	code,
 	cname,
 	cversion,
@@ -637,7 +701,7 @@ func PrepareDeployContract(
 	if err != nil {
 		return nil, fmt.Errorf("hex.DecodeString error:%s", err)
 	}
-	mutable := NewDeployCodeTransaction(0, 0, c, needStorage, cname, cversion, cauthor, cemail, cdesc)
+	mutable := NewDeployCodeTransaction(0, 0, c, needStorage, vmType, cname, cversion, cauthor, cemail, cdesc)
 	tx, _ := mutable.IntoImmutable()
 	var buffer bytes.Buffer
 	err = tx.Serialize(&buffer)
@@ -667,18 +731,16 @@ func InvokeNativeContract(
 //Invoke wasm smart contract
 //methodName is wasm contract action name
 //paramType  is Json or Raw format
-//version should be greater than 0 (0 is reserved for test)
 func InvokeWasmVMContract(
 	gasPrice,
 	gasLimit uint64,
 	siger *account.Account,
-	cversion byte, //version of contract
 	contractAddress common.Address,
 	method string,
 	paramType wasmvm.ParamType,
 	params []interface{}) (string, error) {
 
-	invokeCode, err := BuildWasmVMInvokeCode(contractAddress, method, paramType, cversion, params)
+	invokeCode, err := BuildWasmVMInvokeCode(contractAddress, method, paramType, params)
 	if err != nil {
 		return "", err
 	}
@@ -689,6 +751,19 @@ func InvokeWasmVMContract(
 	return InvokeSmartContract(siger, tx)
 }
 
+//PrepareInvokeWasmVMContract pre-execute wasm smart contract without commit to ledger
+func PrepareInvokeWasmVMContract(
+	contractAddress common.Address,
+	method string,
+	paramType wasmvm.ParamType,
+	params []interface{}) (*cstates.PreExecResult, error) {
+	invokeCode, err := BuildWasmVMInvokeCode(contractAddress, method, paramType, params)
+	if err != nil {
+		return nil, err
+	}
+	return PrepareInvokeCodeNeoVMContract(invokeCode)
+}
+
 //Invoke neo vm smart contract. if isPreExec is true, the invoke will not really execute
 func InvokeNeoVMContract(
 	gasPrice,
@@ -743,6 +818,39 @@ func PrepareInvokeNeoVMContract(
 	return PrepareSendRawTransaction(txData)
 }
 
+//TraceInvokeNeoVMContract pre-execute the neovm contract invoke and return the execution steps
+func TraceInvokeNeoVMContract(
+	contractAddress common.Address,
+	params []interface{},
+) (*rpccommon.TraceResult, error) {
+	mutable, err := httpcom.NewNeovmInvokeTransaction(0, 0, contractAddress, params)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := mutable.IntoImmutable()
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	err = tx.Serialize(&buffer)
+	if err != nil {
+		return nil, fmt.Errorf("tx serialize error:%s", err)
+	}
+	txData := hex.EncodeToString(buffer.Bytes())
+	data, onxErr := sendRpcRequest("preexecute", []interface{}{txData, 1})
+	if onxErr != nil {
+		return nil, onxErr.Error
+	}
+	traceResult := &rpccommon.TraceResult{}
+	err = json.Unmarshal(data, traceResult)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal TraceResult:%s error:%s", data, err)
+	}
+	return traceResult, nil
+}
+
 func PrepareInvokeCodeNeoVMContract(code []byte) (*cstates.PreExecResult, error) {
 	mutable, err := httpcom.NewSmartContractTransaction(0, 0, code)
 	if err != nil {
@@ -784,12 +892,13 @@ func PrepareInvokeNativeContract(
 }
 
 //NewDeployCodeTransaction return a smart contract deploy transaction instance
-func NewDeployCodeTransaction(gasPrice, gasLimit uint64, code []byte, needStorage bool,
+func NewDeployCodeTransaction(gasPrice, gasLimit uint64, code []byte, needStorage bool, vmType payload.VmType,
 	cname, cversion, cauthor, cemail, cdesc string) *types.MutableTransaction {
 
 	deployPayload := &payload.DeployCode{
 		Code:        code,
 		NeedStorage: needStorage,
+		VmType:      vmType,
 		Name:        cname,
 		Version:     cversion,
 		Author:      cauthor,
@@ -808,6 +917,18 @@ func NewDeployCodeTransaction(gasPrice, gasLimit uint64, code []byte, needStorag
 	return tx
 }
 
+//ParseVmType return the vm type of deploy code by name
+func ParseVmType(vmType string) (payload.VmType, error) {
+	switch strings.ToLower(vmType) {
+	case "", VM_TYPE_NEOVM:
+		return payload.NEOVM_TYPE, nil
+	case VM_TYPE_WASM:
+		return payload.WASMVM_TYPE, nil
+	default:
+		return 0, fmt.Errorf("unsupport vm type:%s", vmType)
+	}
+}
+
 //for wasm vm
 //build param bytes for wasm contract
 func buildWasmContractParam(params []interface{}, paramType wasmvm.ParamType) ([]byte, error) {
@@ -887,48 +1008,32 @@ func buildWasmContractParam(params []interface{}, paramType wasmvm.ParamType) ([
 	}
 }
 
-//BuildWasmVMInvokeCode return wasn vm invoke code
-func BuildWasmVMInvokeCode(smartcodeAddress common.Address, methodName string, paramType wasmvm.ParamType, version byte, params []interface{}) ([]byte, error) {
-	contract := &cstates.ContractInvokeParam{}
-	contract.Address = smartcodeAddress
-	contract.Method = methodName
-	contract.Version = version
-
+//BuildWasmVMInvokeCode return wasm vm invoke code
+//the code push args and method to stack and appcall the wasm contract
+func BuildWasmVMInvokeCode(smartcodeAddress common.Address, methodName string, paramType wasmvm.ParamType, params []interface{}) ([]byte, error) {
 	argbytes, err := buildWasmContractParam(params, paramType)
-
 	if err != nil {
 		return nil, fmt.Errorf("build wasm contract param failed:%s", err)
 	}
-	contract.Args = argbytes
-	bf := bytes.NewBuffer(nil)
-	contract.Serialize(bf)
-	return bf.Bytes(), nil
+	return httpcom.BuildNeoVMInvokeCode(smartcodeAddress, []interface{}{methodName, argbytes})
 }
 
 //ParseNeoVMContractReturnTypeBool return bool value of smart contract execute code.
 func ParseNeoVMContractReturnTypeBool(hexStr string) (bool, error) {
-	return hexStr == "01", nil
+	return httpcom.ParseNeoVMContractReturnTypeBool(hexStr)
 }
 
 //ParseNeoVMContractReturnTypeInteger return integer value of smart contract execute code.
 func ParseNeoVMContractReturnTypeInteger(hexStr string) (int64, error) {
-	data, err := hex.DecodeString(hexStr)
-	if err != nil {
-		return 0, fmt.Errorf("hex.DecodeString error:%s", err)
-	}
-	return common.BigIntFromNeoBytes(data).Int64(), nil
+	return httpcom.ParseNeoVMContractReturnTypeInteger(hexStr)
 }
 
 //ParseNeoVMContractReturnTypeByteArray return []byte value of smart contract execute code.
 func ParseNeoVMContractReturnTypeByteArray(hexStr string) (string, error) {
-	return hexStr, nil
+	return httpcom.ParseNeoVMContractReturnTypeByteArray(hexStr)
 }
 
 //ParseNeoVMContractReturnTypeString return string value of smart contract execute code.
 func ParseNeoVMContractReturnTypeString(hexStr string) (string, error) {
-	data, err := hex.DecodeString(hexStr)
-	if err != nil {
-		return "", fmt.Errorf("hex.DecodeString:%s error:%s", hexStr, err)
-	}
-	return string(data), nil
+	return httpcom.ParseNeoVMContractReturnTypeString(hexStr)
 }
@@ -19,10 +19,9 @@
 package utils
 
 import (
-	"encoding/hex"
 	"fmt"
+	httpcom "github.com/OnyxPay/OnyxChain/http/base/common"
 	"reflect"
-	"strconv"
 	"strings"
 )
 
@@ -163,40 +162,7 @@ func parseRawParam(rawParam string) (interface{}, error) {
 	}
 	pType := strings.TrimSpace(ps[0])
 	pValue := strings.TrimSpace(ps[1])
-	return parseRawParamValue(pType, pValue)
-}
-
-func parseRawParamValue(pType string, pValue string) (interface{}, error) {
-	switch strings.ToLower(pType) {
-	case PARAM_TYPE_BYTE_ARRAY:
-		value, err := hex.DecodeString(pValue)
-		if err != nil {
-			return nil, fmt.Errorf("parse byte array param:%s error:%s", pValue, err)
-		}
-		return value, nil
-	case PARAM_TYPE_STRING:
-		return pValue, nil
-	case PARAM_TYPE_INTEGER:
-		if pValue == "" {
-			return nil, fmt.Errorf("invalid integer")
-		}
-		value, err := strconv.ParseInt(pValue, 10, 64)
-		if err != nil {
-			return nil, fmt.Errorf("parse integer param:%s error:%s", pValue, err)
-		}
-		return value, nil
-	case PARAM_TYPE_BOOLEAN:
-		switch strings.ToLower(pValue) {
-		case "true":
-			return true, nil
-		case "false":
-			return false, nil
-		default:
-			return nil, fmt.Errorf("parse boolean param:%s failed", pValue)
-		}
-	default:
-		return nil, fmt.Errorf("unspport param type:%s", pType)
-	}
+	return httpcom.ParseNeoVMParamValue(pType, pValue)
 }
 
 //ParseReturnValue return the value of rawReturnTypeStr type.
@@ -262,80 +228,10 @@ func parseReturnValueArray(rawValues []interface{}, returnTypes []interface{}) (
 	return values, nil
 }
 
-//NeoVMInvokeParam use to express the param to invoke neovm contract.
-//Type can be of array, bytearray, string, int and bool
-//If type is one of bytearray, string, int and bool, value must be a string
-//If Type is array, value must be []*NeoVMInvokeParam
-//Example:
-//[]interface{}{
-//	&NeoVMInvokeParam{
-//		Type:  "string",
-//		Value: "foo",
-//	},
-//	&NeoVMInvokeParam{
-//		Type: "array",
-//		Value: []interface{}{
-//			&NeoVMInvokeParam{
-//				Type:  "int",
-//				Value: "0",
-//			},
-//			&NeoVMInvokeParam{
-//				Type:  "bool",
-//				Value: "true",
-//			},
-//		},
-//	},
-//}
-type NeoVMInvokeParam struct {
-	Type  string
-	Value interface{} //string or []*NeoVMInvokeParam
-}
+//NeoVMInvokeParam use to express the param to invoke neovm contract, see httpcom.NeoVMInvokeParam
+type NeoVMInvokeParam = httpcom.NeoVMInvokeParam
 
 //ParseNeoVMInvokeParams parse params to []interface, rawParams is array of NeoVMInvokeParam
 func ParseNeoVMInvokeParams(rawParams []interface{}) ([]interface{}, error) {
-	if len(rawParams) == 0 {
-		return nil, nil
-	}
-	params := make([]interface{}, 0)
-	for _, rawParam := range rawParams {
-		rawParamItem, ok := rawParam.(map[string]interface{})
-		if !ok {
-			return nil, fmt.Errorf("invalid param %v", rawParam)
-		}
-		for k, v := range rawParamItem {
-			rawParamItem[strings.ToLower(k)] = v
-		}
-		pType, ok := rawParamItem["type"]
-		if !ok {
-			return nil, fmt.Errorf("invalid param %v", rawParamItem)
-		}
-		pt, ok := pType.(string)
-		if !ok {
-			return nil, fmt.Errorf("invalid param %v", rawParamItem)
-		}
-		pValue, ok := rawParamItem["value"]
-		if !ok {
-			return nil, fmt.Errorf("invalid param %v", rawParamItem)
-		}
-		switch pv := pValue.(type) {
-		case string:
-			pv = strings.TrimSpace(pv)
-			param, err := parseRawParamValue(pt, pv)
-			if err != nil {
-				return nil, fmt.Errorf("Parse Param type:%s value:%s error:%s", pType, pv, err)
-			}
-			params = append(params, param)
-		case []interface{}:
-			ps, err := ParseNeoVMInvokeParams(pv)
-			if err != nil {
-				return nil, err
-			}
-			if len(ps) > 0 {
-				params = append(params, ps)
-			}
-		default:
-			return nil, fmt.Errorf("invalid param %v", rawParamItem)
-		}
-	}
-	return params, nil
+	return httpcom.ParseNeoVMInvokeParams(rawParams)
 }
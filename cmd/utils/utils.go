@@ -20,9 +20,12 @@ package utils
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"github.com/OnyxPay/OnyxChain/common/constants"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/onx"
+	"io"
 	"io/ioutil"
 	"math"
 	"math/big"
@@ -97,6 +100,73 @@ func CheckAssetAmount(asset string, amount uint64) error {
 	return nil
 }
 
+//BatchTransferEntry is a line of the batch transfer file
+type BatchTransferEntry struct {
+	To     string
+	Amount uint64
+	Memo   string
+}
+
+//ReadBatchTransferFile read the batch transfer file in csv format.
+//Each line is to,amount[,memo], the lines start with # are ignored, and the first line can be a header starts with "to"
+func ReadBatchTransferFile(asset, filePath string) ([]*BatchTransferEntry, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseBatchTransfer(asset, file)
+}
+
+//ParseBatchTransfer parse the batch transfer csv, see ReadBatchTransferFile
+func ParseBatchTransfer(asset string, r io.Reader) ([]*BatchTransferEntry, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "to") {
+		records = records[1:]
+	}
+	entries := make([]*BatchTransferEntry, 0, len(records))
+	for i, record := range records {
+		if len(record) != 2 && len(record) != 3 {
+			return nil, fmt.Errorf("record %d: should be to,amount[,memo]", i+1)
+		}
+		amountStr := strings.TrimSpace(record[1])
+		var amount uint64
+		switch strings.ToLower(asset) {
+		case ASSET_ONX:
+			amount = ParseOnx(amountStr)
+		case ASSET_OXG:
+			amount = ParseOxg(amountStr)
+		default:
+			return nil, fmt.Errorf("unsupport asset:%s", asset)
+		}
+		if amount == 0 {
+			return nil, fmt.Errorf("record %d: invalid amount:%s", i+1, amountStr)
+		}
+		if err := CheckAssetAmount(asset, amount); err != nil {
+			return nil, fmt.Errorf("record %d: %s", i+1, err)
+		}
+		entry := &BatchTransferEntry{To: strings.TrimSpace(record[0]), Amount: amount}
+		if len(record) == 3 {
+			entry.Memo = record[2]
+		}
+		if len(entry.Memo) > onx.MAX_MEMO_LEN {
+			return nil, fmt.Errorf("record %d: memo length:%d over %d", i+1, len(entry.Memo), onx.MAX_MEMO_LEN)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no transfer record")
+	}
+	return entries, nil
+}
+
 func GetJsonObjectFromFile(filePath string, jsonObject interface{}) error {
 	data, err := ioutil.ReadFile(filePath)
 	if err != nil {
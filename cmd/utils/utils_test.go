@@ -20,6 +20,7 @@ package utils
 
 import (
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -72,3 +73,25 @@ func TestGenExportBlocksFileName(t *testing.T) {
 	fileName = GenExportBlocksFileName(name, start, end)
 	assert.Equal(t, "blocks.export_0_100.dat", fileName)
 }
+
+func TestParseBatchTransfer(t *testing.T) {
+	data := `to,amount,memo
+# payroll of May
+AMAx993nE6NEqZjwBssUfopxnnvTdob9ij,10,salary alice
+AFmseVrdL9f9oyCzZefL9tG6UbvhPbdYzM, 1.5
+"AMAx993nE6NEqZjwBssUfopxnnvTdob9ij",2,"bonus, alice"
+`
+	entries, err := ParseBatchTransfer(ASSET_OXG, strings.NewReader(data))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(entries))
+	assert.Equal(t, &BatchTransferEntry{To: "AMAx993nE6NEqZjwBssUfopxnnvTdob9ij", Amount: 10000000000, Memo: "salary alice"}, entries[0])
+	assert.Equal(t, &BatchTransferEntry{To: "AFmseVrdL9f9oyCzZefL9tG6UbvhPbdYzM", Amount: 1500000000}, entries[1])
+	assert.Equal(t, "bonus, alice", entries[2].Memo)
+
+	_, err = ParseBatchTransfer(ASSET_ONX, strings.NewReader("AMAx993nE6NEqZjwBssUfopxnnvTdob9ij,abc\n"))
+	assert.NotNil(t, err)
+	_, err = ParseBatchTransfer(ASSET_ONX, strings.NewReader("AMAx993nE6NEqZjwBssUfopxnnvTdob9ij\n"))
+	assert.NotNil(t, err)
+	_, err = ParseBatchTransfer(ASSET_ONX, strings.NewReader("to,amount\n"))
+	assert.NotNil(t, err)
+}
@@ -39,15 +39,18 @@ var Version = "1.6.0" //Set value when build project
 const (
 	DEFAULT_CONFIG_FILE_NAME = "./config.json"
 	DEFAULT_WALLET_FILE_NAME = "./wallet.dat"
+	DEFAULT_NATIVE_ABI_PATH  = "./abi"
 	MIN_GEN_BLOCK_TIME       = 2
 	DEFAULT_GEN_BLOCK_TIME   = 6
 	DBFT_MIN_NODE_NUM        = 4 //min node number of dbft consensus
 	SOLO_MIN_NODE_NUM        = 1 //min node number of solo consensus
 	VBFT_MIN_NODE_NUM        = 4 //min node number of vbft consensus
+	SBFT_MIN_NODE_NUM        = 4 //min node number of sbft consensus
 
 	CONSENSUS_TYPE_DBFT = "dbft"
 	CONSENSUS_TYPE_SOLO = "solo"
 	CONSENSUS_TYPE_VBFT = "vbft"
+	CONSENSUS_TYPE_SBFT = "sbft"
 
 	DEFAULT_LOG_LEVEL                       = log.InfoLog
 	DEFAULT_MAX_LOG_SIZE                    = 100 //MByte
@@ -70,6 +73,9 @@ const (
 	DEFUALT_CLI_RPC_ADDRESS                 = "127.0.0.1"
 	DEFAULT_GAS_LIMIT                       = 20000
 	DEFAULT_GAS_PRICE                       = 500
+	DEFAULT_MAX_TX_IN_POOL                  = 100000
+	DEFAULT_MAX_TX_PER_ACCOUNT              = 1000
+	MIN_PRUNE_BLOCKS                        = 1024 //min count of recent blocks kept in pruning mode
 
 	DEFAULT_DATA_DIR      = "./Chain"
 	DEFAULT_RESERVED_FILE = "./peers.rsv"
@@ -190,6 +196,7 @@ var TestNetConfig = &GenesisConfig{
 	},
 	DBFT: &DBFTConfig{},
 	SOLO: &SOLOConfig{},
+	SBFT: &SBFTConfig{},
 }
 
 var MainNetConfig = &GenesisConfig{
@@ -257,6 +264,7 @@ var MainNetConfig = &GenesisConfig{
 	},
 	DBFT: &DBFTConfig{},
 	SOLO: &SOLOConfig{},
+	SBFT: &SBFTConfig{},
 }
 
 var DefConfig = NewOnyxChainConfig()
@@ -267,6 +275,7 @@ type GenesisConfig struct {
 	VBFT          *VBFTConfig
 	DBFT          *DBFTConfig
 	SOLO          *SOLOConfig
+	SBFT          *SBFTConfig
 }
 
 func NewGenesisConfig() *GenesisConfig {
@@ -276,6 +285,7 @@ func NewGenesisConfig() *GenesisConfig {
 		VBFT:          &VBFTConfig{},
 		DBFT:          &DBFTConfig{},
 		SOLO:          &SOLOConfig{},
+		SBFT:          &SBFTConfig{},
 	}
 }
 
@@ -486,14 +496,25 @@ type SOLOConfig struct {
 	Bookkeepers  []string
 }
 
+type SBFTConfig struct {
+	GenBlockTime uint
+	Bookkeepers  []string
+}
+
 type CommonConfig struct {
-	LogLevel       uint
-	NodeType       string
-	EnableEventLog bool
-	SystemFee      map[string]int64
-	GasLimit       uint64
-	GasPrice       uint64
-	DataDir        string
+	LogLevel          uint
+	NodeType          string
+	EnableEventLog    bool
+	EnableEventIndex  bool
+	EnableArchiveMode bool
+	PruneBlocks       uint32
+	LightNode         bool
+	SystemFee         map[string]int64
+	GasLimit          uint64
+	GasPrice          uint64
+	MaxTxInPool       uint
+	MaxTxPerAccount   uint
+	DataDir           string
 }
 
 type ConsensusConfig struct {
@@ -524,6 +545,8 @@ type P2PNodeConfig struct {
 	MaxConnInBound            uint
 	MaxConnOutBound           uint
 	MaxConnInBoundForSingleIP uint
+	EnableDHT                 bool
+	SecureTransport           bool
 }
 
 type RpcConfig struct {
@@ -532,6 +555,7 @@ type RpcConfig struct {
 	HttpLocalPort     uint
 	HttpCertPath      string
 	HttpKeyPath       string
+	EnableTrace       bool
 }
 
 type RestfulConfig struct {
@@ -563,11 +587,13 @@ func NewOnyxChainConfig() *OnyxChainConfig {
 	return &OnyxChainConfig{
 		Genesis: MainNetConfig,
 		Common: &CommonConfig{
-			LogLevel:       DEFAULT_LOG_LEVEL,
-			EnableEventLog: DEFAULT_ENABLE_EVENT_LOG,
-			SystemFee:      make(map[string]int64),
-			GasLimit:       DEFAULT_GAS_LIMIT,
-			DataDir:        DEFAULT_DATA_DIR,
+			LogLevel:        DEFAULT_LOG_LEVEL,
+			EnableEventLog:  DEFAULT_ENABLE_EVENT_LOG,
+			SystemFee:       make(map[string]int64),
+			GasLimit:        DEFAULT_GAS_LIMIT,
+			MaxTxInPool:     DEFAULT_MAX_TX_IN_POOL,
+			MaxTxPerAccount: DEFAULT_MAX_TX_PER_ACCOUNT,
+			DataDir:         DEFAULT_DATA_DIR,
 		},
 		Consensus: &ConsensusConfig{
 			EnableConsensus: true,
@@ -625,6 +651,8 @@ func (this *OnyxChainConfig) GetBookkeepers() ([]keypair.PublicKey, error) {
 		bookKeepers = this.Genesis.DBFT.Bookkeepers
 	case CONSENSUS_TYPE_SOLO:
 		bookKeepers = this.Genesis.SOLO.Bookkeepers
+	case CONSENSUS_TYPE_SBFT:
+		bookKeepers = this.Genesis.SBFT.Bookkeepers
 	default:
 		return nil, fmt.Errorf("Does not support %s consensus", this.Genesis.ConsensusType)
 	}
@@ -672,6 +700,8 @@ func (this *OnyxChainConfig) getDefNetworkIDFromGenesisConfig(genCfg *GenesisCon
 		configData, err = json.Marshal(genCfg.VBFT)
 	case CONSENSUS_TYPE_DBFT:
 		configData, err = json.Marshal(genCfg.DBFT)
+	case CONSENSUS_TYPE_SBFT:
+		configData, err = json.Marshal(genCfg.SBFT)
 	case CONSENSUS_TYPE_SOLO:
 		return NETWORK_ID_SOLO_NET, nil
 	default:
@@ -57,3 +57,49 @@ func ComputeMerkleRoot(hashes []Uint256) Uint256 {
 
 	return hashes[0]
 }
+
+//ComputeMerkleProof return the sibling hashes from the hash at index up to the merkle root computed by ComputeMerkleRoot
+func ComputeMerkleProof(hashes []Uint256, index uint32) []Uint256 {
+	if int(index) >= len(hashes) {
+		return nil
+	}
+	level := make([]Uint256, len(hashes))
+	copy(level, hashes)
+	var proof []Uint256
+	for len(level) != 1 {
+		sibling := index ^ 1
+		if int(sibling) >= len(level) {
+			sibling = index
+		}
+		proof = append(proof, level[sibling])
+		n := (len(level) + 1) / 2
+		for i := 0; i < n; i++ {
+			if 2*i+1 < len(level) {
+				level[i] = hashMerklePair(level[2*i], level[2*i+1])
+			} else {
+				level[i] = hashMerklePair(level[2*i], level[2*i])
+			}
+		}
+		level = level[:n]
+		index /= 2
+	}
+	return proof
+}
+
+//ComputeMerkleRootWithProof return the merkle root calculated from the hash at index and its proof
+func ComputeMerkleRootWithProof(hash Uint256, index uint32, proof []Uint256) Uint256 {
+	for _, sibling := range proof {
+		if index%2 == 0 {
+			hash = hashMerklePair(hash, sibling)
+		} else {
+			hash = hashMerklePair(sibling, hash)
+		}
+		index /= 2
+	}
+	return hash
+}
+
+func hashMerklePair(left, right Uint256) Uint256 {
+	temp := sha256.Sum256(append(left[:], right[:]...))
+	return Uint256(sha256.Sum256(temp[:]))
+}
@@ -182,3 +182,21 @@ func computeMerkleRootOld(hashes []Uint256) Uint256 {
 	tree, _ := newMerkleTree(hashes)
 	return tree.Root.Hash
 }
+
+func TestComputeMerkleProof(t *testing.T) {
+	for n := 1; n < 20; n++ {
+		data := make([]Uint256, n)
+		for i := range data {
+			data[i] = Uint256(sha256.Sum256([]byte(fmt.Sprint(i))))
+		}
+		root := ComputeMerkleRoot(append([]Uint256{}, data...))
+		for i := range data {
+			proof := ComputeMerkleProof(data, uint32(i))
+			assert.Equal(t, root, ComputeMerkleRootWithProof(data[i], uint32(i), proof))
+			if n > 1 {
+				assert.NotEqual(t, root, ComputeMerkleRootWithProof(data[(i+1)%n], uint32(i), proof))
+			}
+		}
+	}
+	assert.Nil(t, ComputeMerkleProof(nil, 0))
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/OnyxPay/OnyxChain/account"
 	"github.com/OnyxPay/OnyxChain/common/log"
 	"github.com/OnyxPay/OnyxChain/consensus/dbft"
+	"github.com/OnyxPay/OnyxChain/consensus/sbft"
 	"github.com/OnyxPay/OnyxChain/consensus/solo"
 	"github.com/OnyxPay/OnyxChain/consensus/vbft"
 )
@@ -37,6 +38,7 @@ const (
 	CONSENSUS_DBFT = "dbft"
 	CONSENSUS_SOLO = "solo"
 	CONSENSUS_VBFT = "vbft"
+	CONSENSUS_SBFT = "sbft"
 )
 
 func NewConsensusService(consensusType string, account *account.Account, txpool *actor.PID, ledger *actor.PID, p2p *actor.PID) (ConsensusService, error) {
@@ -52,6 +54,8 @@ func NewConsensusService(consensusType string, account *account.Account, txpool
 		consensus, err = solo.NewSoloService(account, txpool)
 	case CONSENSUS_VBFT:
 		consensus, err = vbft.NewVbftServer(account, txpool, p2p)
+	case CONSENSUS_SBFT:
+		consensus, err = sbft.NewSbftService(account, txpool, p2p)
 	}
 	log.Infof("ConsensusType:%s", consensusType)
 	return consensus, err
@@ -0,0 +1,321 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sbft
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/types"
+)
+
+type ConsensusMsgType byte
+
+const (
+	BlockProposalMsg ConsensusMsgType = 0x01
+	BlockPrepareMsg  ConsensusMsgType = 0x02
+	BlockCommitMsg   ConsensusMsgType = 0x03
+	ViewChangeMsg    ConsensusMsgType = 0x04
+)
+
+type ConsensusMsg interface {
+	Serialization(sink *common.ZeroCopySink)
+	Deserialization(source *common.ZeroCopySource) error
+	MsgData() *ConsensusMsgData
+}
+
+// ConsensusMsgData is the common header of all sbft messages
+type ConsensusMsgData struct {
+	Type   ConsensusMsgType
+	Height uint32
+	View   uint32
+}
+
+func (this *ConsensusMsgData) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteByte(byte(this.Type))
+	sink.WriteUint32(this.Height)
+	sink.WriteUint32(this.View)
+}
+
+func (this *ConsensusMsgData) Deserialization(source *common.ZeroCopySource) error {
+	msgType, eof := source.NextByte()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	this.Type = ConsensusMsgType(msgType)
+	this.Height, eof = source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	this.View, eof = source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// PrepareSig is the signature of a bookkeeper on the prepare of a block, 2f+1 of them
+// make the prepare certificate of a lock
+type PrepareSig struct {
+	Index uint16
+	Sig   []byte
+}
+
+// PrepareDigest returns the data signed by the prepare of block hash at height and view
+func PrepareDigest(height, view uint32, hash common.Uint256) []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteByte(byte(BlockPrepareMsg))
+	sink.WriteUint32(height)
+	sink.WriteUint32(view)
+	sink.WriteHash(hash)
+	return sink.Bytes()
+}
+
+func serializePrepareSigs(sink *common.ZeroCopySink, sigs []*PrepareSig) {
+	sink.WriteVarUint(uint64(len(sigs)))
+	for _, sig := range sigs {
+		sink.WriteUint16(sig.Index)
+		sink.WriteVarBytes(sig.Sig)
+	}
+}
+
+func deserializePrepareSigs(source *common.ZeroCopySource) ([]*PrepareSig, error) {
+	n, _, irregular, eof := source.NextVarUint()
+	if irregular {
+		return nil, common.ErrIrregularData
+	}
+	if eof || n > source.Len() {
+		return nil, io.ErrUnexpectedEOF
+	}
+	var sigs []*PrepareSig
+	for i := uint64(0); i < n; i++ {
+		sig := &PrepareSig{}
+		sig.Index, eof = source.NextUint16()
+		if eof {
+			return nil, io.ErrUnexpectedEOF
+		}
+		sig.Sig, _, irregular, eof = source.NextVarBytes()
+		if irregular {
+			return nil, common.ErrIrregularData
+		}
+		if eof {
+			return nil, io.ErrUnexpectedEOF
+		}
+		sigs = append(sigs, sig)
+	}
+	return sigs, nil
+}
+
+// BlockProposal is sent by the leader of a view with the block to agree on. A block
+// proposed again after a view change carries the prepare certificate of its lock.
+type BlockProposal struct {
+	msgData    ConsensusMsgData
+	Block      *types.Block
+	LockedView uint32
+	LockedCert []*PrepareSig
+}
+
+func (this *BlockProposal) Serialization(sink *common.ZeroCopySink) {
+	this.msgData.Serialization(sink)
+	this.Block.Serialization(sink)
+	sink.WriteUint32(this.LockedView)
+	serializePrepareSigs(sink, this.LockedCert)
+}
+
+func (this *BlockProposal) Deserialization(source *common.ZeroCopySource) error {
+	if err := this.msgData.Deserialization(source); err != nil {
+		return err
+	}
+	this.Block = new(types.Block)
+	if err := this.Block.Deserialization(source); err != nil {
+		return err
+	}
+	var eof bool
+	this.LockedView, eof = source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	var err error
+	this.LockedCert, err = deserializePrepareSigs(source)
+	return err
+}
+
+func (this *BlockProposal) MsgData() *ConsensusMsgData {
+	return &this.msgData
+}
+
+// BlockPrepare is sent by a bookkeeper after accepting a proposal, PrepareSig signs the PrepareDigest
+type BlockPrepare struct {
+	msgData    ConsensusMsgData
+	BlockHash  common.Uint256
+	PrepareSig []byte
+}
+
+func (this *BlockPrepare) Serialization(sink *common.ZeroCopySink) {
+	this.msgData.Serialization(sink)
+	sink.WriteHash(this.BlockHash)
+	sink.WriteVarBytes(this.PrepareSig)
+}
+
+func (this *BlockPrepare) Deserialization(source *common.ZeroCopySource) error {
+	if err := this.msgData.Deserialization(source); err != nil {
+		return err
+	}
+	var eof, irregular bool
+	this.BlockHash, eof = source.NextHash()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	this.PrepareSig, _, irregular, eof = source.NextVarBytes()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (this *BlockPrepare) MsgData() *ConsensusMsgData {
+	return &this.msgData
+}
+
+// BlockCommit carries the bookkeeper's signature of the prepared block
+type BlockCommit struct {
+	msgData   ConsensusMsgData
+	BlockHash common.Uint256
+	BlockSig  []byte
+}
+
+func (this *BlockCommit) Serialization(sink *common.ZeroCopySink) {
+	this.msgData.Serialization(sink)
+	sink.WriteHash(this.BlockHash)
+	sink.WriteVarBytes(this.BlockSig)
+}
+
+func (this *BlockCommit) Deserialization(source *common.ZeroCopySource) error {
+	if err := this.msgData.Deserialization(source); err != nil {
+		return err
+	}
+	var eof, irregular bool
+	this.BlockHash, eof = source.NextHash()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	this.BlockSig, _, irregular, eof = source.NextVarBytes()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (this *BlockCommit) MsgData() *ConsensusMsgData {
+	return &this.msgData
+}
+
+// ViewChange asks to move to a new view of the current height. The
+// block the sender is locked on, if any, is attached with its prepare
+// certificate so that the next leader can propose it again.
+type ViewChange struct {
+	msgData     ConsensusMsgData
+	LockedView  uint32
+	LockedBlock *types.Block
+	LockedCert  []*PrepareSig
+}
+
+func (this *ViewChange) Serialization(sink *common.ZeroCopySink) {
+	this.msgData.Serialization(sink)
+	sink.WriteUint32(this.LockedView)
+	sink.WriteBool(this.LockedBlock != nil)
+	if this.LockedBlock != nil {
+		this.LockedBlock.Serialization(sink)
+		serializePrepareSigs(sink, this.LockedCert)
+	}
+}
+
+func (this *ViewChange) Deserialization(source *common.ZeroCopySource) error {
+	if err := this.msgData.Deserialization(source); err != nil {
+		return err
+	}
+	var eof, irregular, locked bool
+	this.LockedView, eof = source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	locked, irregular, eof = source.NextBool()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	if locked {
+		this.LockedBlock = new(types.Block)
+		if err := this.LockedBlock.Deserialization(source); err != nil {
+			return err
+		}
+		var err error
+		this.LockedCert, err = deserializePrepareSigs(source)
+		return err
+	}
+	return nil
+}
+
+func (this *ViewChange) MsgData() *ConsensusMsgData {
+	return &this.msgData
+}
+
+func SerializeMsg(msg ConsensusMsg) []byte {
+	sink := common.NewZeroCopySink(nil)
+	msg.Serialization(sink)
+	return sink.Bytes()
+}
+
+func DeserializeMsg(data []byte) (ConsensusMsg, error) {
+	if len(data) == 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	var msg ConsensusMsg
+	switch ConsensusMsgType(data[0]) {
+	case BlockProposalMsg:
+		msg = &BlockProposal{}
+	case BlockPrepareMsg:
+		msg = &BlockPrepare{}
+	case BlockCommitMsg:
+		msg = &BlockCommit{}
+	case ViewChangeMsg:
+		msg = &ViewChange{}
+	default:
+		return nil, fmt.Errorf("unknown sbft msg type: %d", data[0])
+	}
+	source := common.NewZeroCopySource(data)
+	if err := msg.Deserialization(source); err != nil {
+		return nil, err
+	}
+	if source.Len() != 0 {
+		return nil, errors.New("sbft msg has trailing data")
+	}
+	return msg, nil
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sbft
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func constructBlock(height uint32) *types.Block {
+	header := &types.Header{
+		PrevBlockHash:    common.Uint256{1},
+		TransactionsRoot: common.ComputeMerkleRoot(nil),
+		Timestamp:        uint32(time.Now().Unix()),
+		Height:           height,
+		ConsensusData:    uint64(123456),
+	}
+	return &types.Block{Header: header}
+}
+
+func TestBlockProposalSerialize(t *testing.T) {
+	msg := &BlockProposal{Block: constructBlock(10)}
+	msg.msgData = ConsensusMsgData{Type: BlockProposalMsg, Height: 10, View: 2}
+
+	m, err := DeserializeMsg(SerializeMsg(msg))
+	assert.Nil(t, err)
+	proposal, ok := m.(*BlockProposal)
+	assert.True(t, ok)
+	assert.Equal(t, msg.msgData, proposal.msgData)
+	assert.Equal(t, msg.Block.Hash(), proposal.Block.Hash())
+	assert.Nil(t, proposal.LockedCert)
+
+	msg.LockedView = 1
+	msg.LockedCert = []*PrepareSig{{Index: 0, Sig: []byte{1}}, {Index: 3, Sig: []byte{2, 3}}}
+	m, err = DeserializeMsg(SerializeMsg(msg))
+	assert.Nil(t, err)
+	proposal = m.(*BlockProposal)
+	assert.Equal(t, uint32(1), proposal.LockedView)
+	assert.Equal(t, msg.LockedCert, proposal.LockedCert)
+}
+
+func TestBlockPrepareSerialize(t *testing.T) {
+	msg := &BlockPrepare{BlockHash: common.Uint256{3}, PrepareSig: []byte{4, 5}}
+	msg.msgData = ConsensusMsgData{Type: BlockPrepareMsg, Height: 3, View: 1}
+
+	m, err := DeserializeMsg(SerializeMsg(msg))
+	assert.Nil(t, err)
+	assert.Equal(t, msg, m)
+}
+
+func TestBlockCommitSerialize(t *testing.T) {
+	msg := &BlockCommit{BlockHash: common.Uint256{2}, BlockSig: []byte{1, 2, 3}}
+	msg.msgData = ConsensusMsgData{Type: BlockCommitMsg, Height: 3, View: 1}
+
+	m, err := DeserializeMsg(SerializeMsg(msg))
+	assert.Nil(t, err)
+	assert.Equal(t, msg, m)
+
+	data := SerializeMsg(msg)
+	_, err = DeserializeMsg(data[:len(data)-1])
+	assert.NotNil(t, err)
+}
+
+func TestViewChangeSerialize(t *testing.T) {
+	msg := &ViewChange{}
+	msg.msgData = ConsensusMsgData{Type: ViewChangeMsg, Height: 5, View: 3}
+	m, err := DeserializeMsg(SerializeMsg(msg))
+	assert.Nil(t, err)
+	assert.Equal(t, msg, m)
+
+	msg.LockedView = 2
+	msg.LockedBlock = constructBlock(5)
+	msg.LockedCert = []*PrepareSig{{Index: 1, Sig: []byte{6}}}
+	m, err = DeserializeMsg(SerializeMsg(msg))
+	assert.Nil(t, err)
+	vc := m.(*ViewChange)
+	assert.Equal(t, uint32(2), vc.LockedView)
+	assert.Equal(t, msg.LockedBlock.Hash(), vc.LockedBlock.Hash())
+	assert.Equal(t, msg.LockedCert, vc.LockedCert)
+}
+
+func TestDeserializeUnknownMsg(t *testing.T) {
+	_, err := DeserializeMsg([]byte{0xff, 0, 0, 0, 0, 0, 0, 0, 0})
+	assert.NotNil(t, err)
+	_, err = DeserializeMsg(nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,187 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sbft
+
+import (
+	"sort"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/types"
+)
+
+// roundState keeps the consensus progress of a single block height
+type roundState struct {
+	height       uint32
+	prevHash     common.Uint256
+	view         uint32
+	expectedView uint32
+
+	proposal     *types.Block
+	proposalSent bool
+	commitSent   bool
+	sealed       bool
+
+	lockedView  uint32
+	lockedBlock *types.Block
+	lockedCert  []*PrepareSig
+
+	blocks    map[common.Uint256]*types.Block
+	proposals map[uint32]*BlockProposal
+	prepares  map[uint32]map[common.Uint256]map[int][]byte
+	commits   map[common.Uint256]map[int][]byte
+	votes     map[int]*ViewChange
+}
+
+func newRoundState(height uint32, prevHash common.Uint256) *roundState {
+	return &roundState{
+		height:    height,
+		prevHash:  prevHash,
+		blocks:    make(map[common.Uint256]*types.Block),
+		proposals: make(map[uint32]*BlockProposal),
+		prepares:  make(map[uint32]map[common.Uint256]map[int][]byte),
+		commits:   make(map[common.Uint256]map[int][]byte),
+		votes:     make(map[int]*ViewChange),
+	}
+}
+
+// changeView drops the per view state, the lock survives view changes
+func (self *roundState) changeView(view uint32) {
+	self.view = view
+	if self.expectedView < view {
+		self.expectedView = view
+	}
+	self.proposal = nil
+	self.proposalSent = false
+	self.commitSent = false
+	for v := range self.proposals {
+		if v < view {
+			delete(self.proposals, v)
+		}
+	}
+	for v := range self.prepares {
+		if v < view {
+			delete(self.prepares, v)
+		}
+	}
+}
+
+// lock keeps the block with the highest lock view, cert is its prepare certificate
+func (self *roundState) lock(view uint32, block *types.Block, cert []*PrepareSig) {
+	if self.lockedBlock == nil || self.lockedView < view {
+		self.lockedView = view
+		self.lockedBlock = block
+		self.lockedCert = cert
+	}
+}
+
+func (self *roundState) addPrepare(view uint32, hash common.Uint256, index int, sig []byte) int {
+	hashes, present := self.prepares[view]
+	if !present {
+		hashes = make(map[common.Uint256]map[int][]byte)
+		self.prepares[view] = hashes
+	}
+	sigs, present := hashes[hash]
+	if !present {
+		sigs = make(map[int][]byte)
+		hashes[hash] = sigs
+	}
+	sigs[index] = sig
+	return len(sigs)
+}
+
+func (self *roundState) prepareCount(view uint32, hash common.Uint256) int {
+	return len(self.prepares[view][hash])
+}
+
+// prepareCert returns the prepare signatures of block hash in view ordered by bookkeeper index
+func (self *roundState) prepareCert(view uint32, hash common.Uint256) []*PrepareSig {
+	sigs := self.prepares[view][hash]
+	indexes := make([]int, 0, len(sigs))
+	for index := range sigs {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	cert := make([]*PrepareSig, 0, len(indexes))
+	for _, index := range indexes {
+		cert = append(cert, &PrepareSig{Index: uint16(index), Sig: sigs[index]})
+	}
+	return cert
+}
+
+func (self *roundState) addCommit(hash common.Uint256, index int, sig []byte) int {
+	sigs, present := self.commits[hash]
+	if !present {
+		sigs = make(map[int][]byte)
+		self.commits[hash] = sigs
+	}
+	sigs[index] = sig
+	return len(sigs)
+}
+
+// commitSigs returns the commit signatures of block hash ordered by bookkeeper index
+func (self *roundState) commitSigs(hash common.Uint256) [][]byte {
+	sigs := self.commits[hash]
+	indexes := make([]int, 0, len(sigs))
+	for index := range sigs {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	result := make([][]byte, 0, len(indexes))
+	for _, index := range indexes {
+		result = append(result, sigs[index])
+	}
+	return result
+}
+
+// addVote records the latest view change of a bookkeeper, stale votes are ignored
+func (self *roundState) addVote(index int, msg *ViewChange) bool {
+	if vote, present := self.votes[index]; present && vote.msgData.View >= msg.msgData.View {
+		return false
+	}
+	self.votes[index] = msg
+	return true
+}
+
+// supportedView returns the highest view requested by at least count bookkeepers
+func (self *roundState) supportedView(count int) uint32 {
+	if count <= 0 || len(self.votes) < count {
+		return 0
+	}
+	views := make([]uint32, 0, len(self.votes))
+	for _, vote := range self.votes {
+		views = append(views, vote.msgData.View)
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i] > views[j] })
+	return views[count-1]
+}
+
+// highestLock returns the locked block with the highest lock view known for this round and its
+// prepare certificate, the locks of votes are verified before the votes are added
+func (self *roundState) highestLock() (*types.Block, uint32, []*PrepareSig) {
+	block, view, cert := self.lockedBlock, self.lockedView, self.lockedCert
+	for _, vote := range self.votes {
+		if vote.LockedBlock == nil {
+			continue
+		}
+		if block == nil || vote.LockedView > view {
+			block, view, cert = vote.LockedBlock, vote.LockedView, vote.LockedCert
+		}
+	}
+	return block, view, cert
+}
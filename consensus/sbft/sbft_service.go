@@ -18,13 +18,751 @@
 
 package sbft
 
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/OnyxPay/OnyxChain-crypto/keypair"
+	"github.com/OnyxPay/OnyxChain-eventbus/actor"
+	"github.com/OnyxPay/OnyxChain/account"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/config"
+	"github.com/OnyxPay/OnyxChain/common/log"
+	actorTypes "github.com/OnyxPay/OnyxChain/consensus/actor"
+	"github.com/OnyxPay/OnyxChain/core/ledger"
+	"github.com/OnyxPay/OnyxChain/core/signature"
+	"github.com/OnyxPay/OnyxChain/core/store"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/OnyxPay/OnyxChain/events"
+	"github.com/OnyxPay/OnyxChain/events/message"
+	p2pmsg "github.com/OnyxPay/OnyxChain/p2pserver/message/types"
+	txpool "github.com/OnyxPay/OnyxChain/txnpool/common"
+	"github.com/OnyxPay/OnyxChain/validator/increment"
+)
+
+/*
+*Simple BFT consensus for permissioned networks with a fixed bookkeeper set.
+*The leader of (height, view) is bookkeepers[(height+view)%N]. A block is sealed
+*after 2f+1 prepares and 2f+1 commits, and a view change happens on timeout.
+ */
+const ContextVersion uint32 = 0
+
+const (
+	MAX_TIMEOUT_SHIFT    = 6    // upper bound of the exponential view timeout backoff
+	MAX_PENDING_MSGS     = 1024 // max buffered messages of the next height
+	MAX_BLOCK_TIME_AHEAD = 10 * time.Minute
+)
+
+type ledgerStore interface {
+	GetCurrentBlockHeight() uint32
+	GetCurrentBlockHash() common.Uint256
+	GetHeaderByHash(blockHash common.Uint256) (*types.Header, error)
+	GetBlockRootWithNewTxRoots(startHeight uint32, txRoots []common.Uint256) common.Uint256
+	ExecuteBlock(b *types.Block) (store.ExecuteResult, error)
+	SubmitBlock(b *types.Block, exec store.ExecuteResult) error
+}
+
+type txPoolActor interface {
+	GetTxnPool(byCount bool, height uint32) []*txpool.TXEntry
+	VerifyBlock(txs []*types.Transaction, height uint32) error
+}
+
+type p2pActor interface {
+	Broadcast(msg interface{})
+}
+
 type SbftService struct {
+	Account        *account.Account
+	bookkeepers    []keypair.PublicKey
+	index          int
+	nextBookkeeper common.Address
+	genBlockTime   time.Duration
+	ledger         ledgerStore
+	poolActor      txPoolActor
+	p2p            p2pActor
+	incrValidator  *increment.IncrementValidator
+
+	round         *roundState
+	pending       []*p2pmsg.ConsensusPayload
+	lastBlockTime time.Time
+	timer         *time.Timer
+
+	msgC    chan *p2pmsg.ConsensusPayload
+	blockC  chan *types.Block
+	quitC   chan struct{}
+	started bool
+
+	pid *actor.PID
+	sub *events.ActorSubscriber
+}
+
+func NewSbftService(bkAccount *account.Account, txpool, p2p *actor.PID) (*SbftService, error) {
+	bookkeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return nil, fmt.Errorf("GetBookkeepers error:%s", err)
+	}
+	genBlockTime := time.Duration(config.DEFAULT_GEN_BLOCK_TIME) * time.Second
+	if config.DefConfig.Genesis.SBFT.GenBlockTime > config.MIN_GEN_BLOCK_TIME {
+		genBlockTime = time.Duration(config.DefConfig.Genesis.SBFT.GenBlockTime) * time.Second
+	} else {
+		log.Warnf("The Generate block time should be longer than %d seconds, so set it to be default %d seconds.",
+			config.MIN_GEN_BLOCK_TIME, config.DEFAULT_GEN_BLOCK_TIME)
+	}
+	service, err := newSbftService(bkAccount, bookkeepers, genBlockTime, ledger.DefLedger,
+		&actorTypes.TxPoolActor{Pool: txpool}, &actorTypes.P2PActor{P2P: p2p})
+	if err != nil {
+		return nil, err
+	}
+
+	props := actor.FromProducer(func() actor.Actor {
+		return service
+	})
+
+	pid, err := actor.SpawnNamed(props, "consensus_sbft")
+	service.pid = pid
+	service.sub = events.NewActorSubscriber(pid)
+	return service, err
+}
+
+func newSbftService(bkAccount *account.Account, bookkeepers []keypair.PublicKey, genBlockTime time.Duration,
+	ledger ledgerStore, poolActor txPoolActor, p2p p2pActor) (*SbftService, error) {
+	if len(bookkeepers) == 0 {
+		return nil, fmt.Errorf("sbft needs at least one bookkeeper")
+	}
+	nextBookkeeper, err := types.AddressFromBookkeepers(bookkeepers)
+	if err != nil {
+		return nil, fmt.Errorf("GetBookkeeperAddress error:%s", err)
+	}
+	index := -1
+	for i, pk := range bookkeepers {
+		if keypair.ComparePublicKey(pk, bkAccount.PublicKey) {
+			index = i
+			break
+		}
+	}
+	service := &SbftService{
+		Account:        bkAccount,
+		bookkeepers:    bookkeepers,
+		index:          index,
+		nextBookkeeper: nextBookkeeper,
+		genBlockTime:   genBlockTime,
+		ledger:         ledger,
+		poolActor:      poolActor,
+		p2p:            p2p,
+		incrValidator:  increment.NewIncrementValidator(20),
+		timer:          time.NewTimer(genBlockTime),
+		msgC:           make(chan *p2pmsg.ConsensusPayload, MAX_PENDING_MSGS),
+		blockC:         make(chan *types.Block, 16),
+	}
+	if !service.timer.Stop() {
+		<-service.timer.C
+	}
+	return service, nil
+}
+
+func (self *SbftService) Receive(context actor.Context) {
+	switch msg := context.Message().(type) {
+	case *actor.Restarting:
+		log.Info("sbft actor restarting")
+	case *actor.Stopping:
+		log.Info("sbft actor stopping")
+	case *actor.Stopped:
+		log.Info("sbft actor stopped")
+	case *actor.Started:
+		log.Info("sbft actor started")
+	case *actor.Restart:
+		log.Info("sbft actor restart")
+	case *actorTypes.StartConsensus:
+		if self.started {
+			log.Info("consensus have started")
+			return
+		}
+		self.sub.Subscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+		self.start()
+	case *actorTypes.StopConsensus:
+		if self.started {
+			self.sub.Unsubscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+			self.halt()
+		}
+	case *message.SaveBlockCompleteMsg:
+		log.Infof("sbft actor receives block complete event. block height=%d txnum=%d",
+			msg.Block.Header.Height, len(msg.Block.Transactions))
+		if self.started {
+			self.onBlockSaved(msg.Block)
+		}
+	case *p2pmsg.ConsensusPayload:
+		if self.started {
+			self.onConsensusPayload(msg)
+		}
+	default:
+		log.Info("sbft actor: Unknown msg ", msg, "type", reflect.TypeOf(msg))
+	}
+}
+
+func (self *SbftService) GetPID() *actor.PID {
+	return self.pid
+}
+
+func (self *SbftService) Start() error {
+	self.pid.Tell(&actorTypes.StartConsensus{})
+	return nil
+}
+
+func (self *SbftService) Halt() error {
+	self.pid.Tell(&actorTypes.StopConsensus{})
+	return nil
+}
+
+func (self *SbftService) start() {
+	self.started = true
+	self.quitC = make(chan struct{})
+	if self.index < 0 {
+		log.Info("You aren't bookkeeper")
+	}
+	go self.run(self.quitC)
+}
+
+func (self *SbftService) halt() {
+	log.Info("SBFT Stop")
+	self.started = false
+	close(self.quitC)
+	self.incrValidator.Clean()
+}
+
+// onBlockSaved and onConsensusPayload hand events over to the consensus loop
+func (self *SbftService) onBlockSaved(block *types.Block) {
+	select {
+	case self.blockC <- block:
+	case <-self.quitC:
+	}
+}
+
+func (self *SbftService) onConsensusPayload(payload *p2pmsg.ConsensusPayload) {
+	select {
+	case self.msgC <- payload:
+	case <-self.quitC:
+	}
+}
+
+func (self *SbftService) run(quitC chan struct{}) {
+	defer self.timer.Stop()
+
+	self.lastBlockTime = time.Now()
+	self.newRound()
+	for {
+		select {
+		case block := <-self.blockC:
+			self.incrValidator.AddBlock(block)
+			if block.Header.Height >= self.round.height {
+				self.lastBlockTime = time.Now()
+				self.newRound()
+			}
+		case payload := <-self.msgC:
+			self.processPayload(payload)
+		case <-self.timer.C:
+			self.handleTimeout()
+		case <-quitC:
+			return
+		}
+	}
+}
+
+func (self *SbftService) quorum() int {
+	return len(self.bookkeepers) - (len(self.bookkeepers)-1)/3
+}
+
+func (self *SbftService) faulty() int {
+	return (len(self.bookkeepers) - 1) / 3
+}
+
+func (self *SbftService) leader(height, view uint32) int {
+	return int((uint64(height) + uint64(view)) % uint64(len(self.bookkeepers)))
+}
+
+func (self *SbftService) isLeader() bool {
+	return self.index >= 0 && self.index == self.leader(self.round.height, self.round.view)
+}
+
+func (self *SbftService) viewTimeout(view uint32) time.Duration {
+	if view > MAX_TIMEOUT_SHIFT {
+		view = MAX_TIMEOUT_SHIFT
+	}
+	return self.genBlockTime << (view + 1)
+}
+
+func (self *SbftService) resetTimer(d time.Duration) {
+	if !self.timer.Stop() {
+		select {
+		case <-self.timer.C:
+		default:
+		}
+	}
+	self.timer.Reset(d)
 }
 
-func (ss *SbftService) Halt() error {
+func (self *SbftService) newRound() {
+	height := self.ledger.GetCurrentBlockHeight() + 1
+	self.round = newRoundState(height, self.ledger.GetCurrentBlockHash())
+	log.Infof("sbft start round: height=%d", height)
+	self.enterView(0)
+
+	pending := self.pending
+	self.pending = nil
+	for _, payload := range pending {
+		self.processPayload(payload)
+	}
+}
+
+func (self *SbftService) enterView(view uint32) {
+	self.round.changeView(view)
+	if self.index < 0 {
+		return
+	}
+	log.Infof("sbft enter view: height=%d view=%d leader=%d", self.round.height, view, self.leader(self.round.height, view))
+
+	if self.isLeader() {
+		delay := time.Duration(0)
+		if view == 0 {
+			delay = self.genBlockTime - time.Since(self.lastBlockTime)
+			if delay < 0 {
+				delay = 0
+			}
+		}
+		self.resetTimer(delay)
+	} else {
+		self.resetTimer(self.viewTimeout(view))
+	}
+
+	if proposal, present := self.round.proposals[view]; present {
+		delete(self.round.proposals, view)
+		self.handleProposal(self.leader(self.round.height, view), proposal)
+	}
+}
+
+func (self *SbftService) handleTimeout() {
+	if self.index < 0 || self.round.sealed {
+		return
+	}
+	if self.isLeader() && !self.round.proposalSent && self.round.expectedView == self.round.view {
+		self.propose()
+		self.resetTimer(self.viewTimeout(self.round.view))
+		return
+	}
+	self.requestViewChange()
+}
+
+func (self *SbftService) propose() {
+	self.round.proposalSent = true
+	block, lockedView, lockedCert := self.round.highestLock()
+	if block != nil {
+		if err := self.verifyBlock(block); err != nil {
+			log.Warnf("sbft locked block verify failed: %s", err)
+			block, lockedCert = nil, nil
+		} else {
+			self.round.lock(lockedView, block, lockedCert)
+		}
+	}
+	if block == nil {
+		var err error
+		block, err = self.makeBlock()
+		if err != nil {
+			log.Errorf("sbft makeBlock error %s", err)
+			return
+		}
+	}
+	log.Infof("sbft send proposal: height=%d view=%d tx=%d", self.round.height, self.round.view, len(block.Transactions))
+
+	proposal := &BlockProposal{Block: block}
+	if lockedCert != nil {
+		proposal.LockedView, proposal.LockedCert = lockedView, lockedCert
+	}
+	self.broadcast(proposal)
+	self.acceptProposal(self.index, block)
+}
+
+func (self *SbftService) makeBlock() (*types.Block, error) {
+	prevHeader, err := self.ledger.GetHeaderByHash(self.round.prevHash)
+	if err != nil || prevHeader == nil {
+		return nil, fmt.Errorf("GetHeaderByHash PrevHash:%x error:%v", self.round.prevHash, err)
+	}
+	timestamp := uint32(time.Now().Unix())
+	if timestamp <= prevHeader.Timestamp {
+		timestamp = prevHeader.Timestamp + 1
+	}
+
+	validHeight := self.validHeight(self.round.height - 1)
+	txs := self.poolActor.GetTxnPool(true, validHeight)
+	transactions := make([]*types.Transaction, 0, len(txs))
+	for _, txEntry := range txs {
+		if err := self.incrValidator.Verify(txEntry.Tx, validHeight); err == nil {
+			transactions = append(transactions, txEntry.Tx)
+		}
+	}
+
+	txHash := make([]common.Uint256, 0, len(transactions))
+	for _, t := range transactions {
+		txHash = append(txHash, t.Hash())
+	}
+	txRoot := common.ComputeMerkleRoot(txHash)
+	blockRoot := self.ledger.GetBlockRootWithNewTxRoots(self.round.height, []common.Uint256{txRoot})
+	header := &types.Header{
+		Version:          ContextVersion,
+		PrevBlockHash:    self.round.prevHash,
+		TransactionsRoot: txRoot,
+		BlockRoot:        blockRoot,
+		Timestamp:        timestamp,
+		Height:           self.round.height,
+		ConsensusData:    common.GetNonce(),
+		NextBookkeeper:   self.nextBookkeeper,
+	}
+	return &types.Block{
+		Header:       header,
+		Transactions: transactions,
+	}, nil
+}
+
+func (self *SbftService) validHeight(height uint32) uint32 {
+	start, end := self.incrValidator.BlockRange()
+	if height+1 == end {
+		return start
+	}
+	self.incrValidator.Clean()
+	log.Infof("increment validator block height %v != ledger block height %v", int(end)-1, height)
+	return height
+}
+
+func (self *SbftService) verifyBlock(block *types.Block) error {
+	header := block.Header
+	if header == nil {
+		return fmt.Errorf("nil block header")
+	}
+	if header.Height != self.round.height || header.PrevBlockHash != self.round.prevHash {
+		return fmt.Errorf("unmatched block height %d", header.Height)
+	}
+	if header.Version != ContextVersion {
+		return fmt.Errorf("unmatched block version %d", header.Version)
+	}
+	if header.NextBookkeeper != self.nextBookkeeper {
+		return fmt.Errorf("unmatched next bookkeeper")
+	}
+	prevHeader, err := self.ledger.GetHeaderByHash(self.round.prevHash)
+	if err != nil || prevHeader == nil {
+		return fmt.Errorf("GetHeaderByHash PrevHash:%x error:%v", self.round.prevHash, err)
+	}
+	if header.Timestamp <= prevHeader.Timestamp ||
+		header.Timestamp > uint32(time.Now().Add(MAX_BLOCK_TIME_AHEAD).Unix()) {
+		return fmt.Errorf("incorrect block timestamp %d", header.Timestamp)
+	}
+	txRoot := header.TransactionsRoot
+	if header.BlockRoot != self.ledger.GetBlockRootWithNewTxRoots(header.Height, []common.Uint256{txRoot}) {
+		return fmt.Errorf("unmatched block root")
+	}
+
+	if len(block.Transactions) > 0 {
+		validHeight := self.validHeight(self.round.height - 1)
+		if err := self.poolActor.VerifyBlock(block.Transactions, validHeight); err != nil {
+			return fmt.Errorf("transaction verification failed: %s", err)
+		}
+		for _, tx := range block.Transactions {
+			if err := self.incrValidator.Verify(tx, validHeight); err != nil {
+				return fmt.Errorf("transaction increment verification failed: %s", err)
+			}
+		}
+	}
 	return nil
 }
 
-func (ds *SbftService) Start() error {
+func (self *SbftService) processPayload(payload *p2pmsg.ConsensusPayload) {
+	index := int(payload.BookkeeperIndex)
+	if index == self.index || index >= len(self.bookkeepers) {
+		return
+	}
+	if !keypair.ComparePublicKey(self.bookkeepers[index], payload.Owner) {
+		log.Debugf("sbft payload owner mismatch bookkeeper %d", index)
+		return
+	}
+	if err := payload.Verify(); err != nil {
+		log.Warn(err.Error())
+		return
+	}
+	msg, err := DeserializeMsg(payload.Data)
+	if err != nil {
+		log.Errorf("sbft DeserializeMsg failed: %s", err)
+		return
+	}
+
+	msgData := msg.MsgData()
+	if msgData.Height == self.round.height+1 {
+		if len(self.pending) < MAX_PENDING_MSGS {
+			self.pending = append(self.pending, payload)
+		}
+		return
+	}
+	if msgData.Height != self.round.height || self.round.sealed {
+		return
+	}
+
+	switch m := msg.(type) {
+	case *BlockProposal:
+		self.handleProposal(index, m)
+	case *BlockPrepare:
+		self.handlePrepare(index, m)
+	case *BlockCommit:
+		self.handleCommit(index, m)
+	case *ViewChange:
+		self.handleViewChange(index, m)
+	}
+}
+
+func (self *SbftService) handleProposal(index int, msg *BlockProposal) {
+	view := msg.msgData.View
+	if index != self.leader(self.round.height, view) {
+		log.Debugf("sbft proposal from non-leader %d, view=%d", index, view)
+		return
+	}
+	if view > self.round.view {
+		self.round.proposals[view] = msg
+		return
+	}
+	if view < self.round.view {
+		// a proposal of an old view is still good for sealing once enough commits arrive
+		if err := self.verifyBlock(msg.Block); err == nil {
+			hash := msg.Block.Hash()
+			self.round.blocks[hash] = msg.Block
+			self.checkCommitted(hash)
+		}
+		return
+	}
+	if self.round.proposal != nil {
+		return
+	}
+	log.Infof("sbft proposal received: height=%d view=%d index=%d tx=%d",
+		self.round.height, view, index, len(msg.Block.Transactions))
+
+	if err := self.verifyBlock(msg.Block); err != nil {
+		log.Warnf("sbft proposal verify failed: %s", err)
+		return
+	}
+	hash := msg.Block.Hash()
+	if msg.LockedCert != nil {
+		if err := self.verifyPrepareCert(msg.LockedView, hash, msg.LockedCert); err != nil || msg.LockedView >= view {
+			log.Warnf("sbft proposal lock of view %d is invalid", msg.LockedView)
+			return
+		}
+		self.round.lock(msg.LockedView, msg.Block, msg.LockedCert)
+	}
+	if self.round.lockedBlock != nil && self.round.lockedBlock.Hash() != hash {
+		log.Warnf("sbft proposal %x conflicts with locked block %x", hash, self.round.lockedBlock.Hash())
+		return
+	}
+	self.acceptProposal(index, msg.Block)
+}
+
+func (self *SbftService) acceptProposal(index int, block *types.Block) {
+	hash := block.Hash()
+	self.round.proposal = block
+	self.round.blocks[hash] = block
+	sig, err := signature.Sign(self.Account, PrepareDigest(self.round.height, self.round.view, hash))
+	if err != nil {
+		log.Errorf("sbft sign prepare error %s", err)
+		return
+	}
+	self.broadcast(&BlockPrepare{BlockHash: hash, PrepareSig: sig})
+	self.round.addPrepare(self.round.view, hash, self.index, sig)
+	self.checkPrepared()
+	self.checkCommitted(hash)
+}
+
+func (self *SbftService) handlePrepare(index int, msg *BlockPrepare) {
+	if msg.msgData.View < self.round.view {
+		return
+	}
+	digest := PrepareDigest(self.round.height, msg.msgData.View, msg.BlockHash)
+	if err := signature.Verify(self.bookkeepers[index], digest, msg.PrepareSig); err != nil {
+		log.Warnf("sbft prepare from %d verify failed: %s", index, err)
+		return
+	}
+	self.round.addPrepare(msg.msgData.View, msg.BlockHash, index, msg.PrepareSig)
+	if msg.msgData.View == self.round.view {
+		self.checkPrepared()
+	}
+}
+
+func (self *SbftService) checkPrepared() {
+	proposal := self.round.proposal
+	if proposal == nil || self.round.commitSent {
+		return
+	}
+	hash := proposal.Hash()
+	if self.round.prepareCount(self.round.view, hash) < self.quorum() {
+		return
+	}
+
+	sig, err := signature.Sign(self.Account, hash[:])
+	if err != nil {
+		log.Errorf("sbft sign block error %s", err)
+		return
+	}
+	self.round.lock(self.round.view, proposal, self.round.prepareCert(self.round.view, hash))
+	self.round.commitSent = true
+	log.Infof("sbft block prepared: height=%d view=%d hash=%x", self.round.height, self.round.view, hash)
+
+	self.broadcast(&BlockCommit{BlockHash: hash, BlockSig: sig})
+	self.round.addCommit(hash, self.index, sig)
+	self.checkCommitted(hash)
+}
+
+func (self *SbftService) handleCommit(index int, msg *BlockCommit) {
+	if err := signature.Verify(self.bookkeepers[index], msg.BlockHash[:], msg.BlockSig); err != nil {
+		log.Warnf("sbft commit from %d verify failed: %s", index, err)
+		return
+	}
+	self.round.addCommit(msg.BlockHash, index, msg.BlockSig)
+	self.checkCommitted(msg.BlockHash)
+}
+
+func (self *SbftService) checkCommitted(hash common.Uint256) {
+	block, present := self.round.blocks[hash]
+	if !present || self.round.sealed {
+		return
+	}
+	if len(self.round.commits[hash]) < self.quorum() {
+		return
+	}
+
+	block.Header.Bookkeepers = self.bookkeepers
+	block.Header.SigData = self.round.commitSigs(hash)
+	result, err := self.ledger.ExecuteBlock(block)
+	if err != nil {
+		log.Errorf("sbft ExecuteBlock Height:%d error:%s", block.Header.Height, err)
+		return
+	}
+	if err = self.ledger.SubmitBlock(block, result); err != nil {
+		log.Errorf("sbft SubmitBlock Height:%d error:%s", block.Header.Height, err)
+		return
+	}
+	self.round.sealed = true
+	log.Infof("sbft block sealed: height=%d hash=%x", block.Header.Height, hash)
+}
+
+func (self *SbftService) requestViewChange() {
+	if self.round.expectedView < self.round.view {
+		self.round.expectedView = self.round.view
+	}
+	self.round.expectedView++
+	log.Infof("sbft request change view: height=%d view=%d nv=%d",
+		self.round.height, self.round.view, self.round.expectedView)
+
+	self.resetTimer(self.viewTimeout(self.round.expectedView))
+	self.sendViewChange()
+}
+
+func (self *SbftService) sendViewChange() {
+	msg := &ViewChange{
+		LockedView:  self.round.lockedView,
+		LockedBlock: self.round.lockedBlock,
+		LockedCert:  self.round.lockedCert,
+	}
+	msg.msgData.View = self.round.expectedView
+	self.broadcast(msg)
+
+	// the own vote is recorded as if it was received from the network
+	self.round.addVote(self.index, msg)
+	self.checkViewChange()
+}
+
+func (self *SbftService) handleViewChange(index int, msg *ViewChange) {
+	if msg.msgData.View <= self.round.view {
+		return
+	}
+	if msg.LockedBlock != nil {
+		if err := self.verifyLock(msg); err != nil {
+			log.Warnf("sbft view change from %d dropped: %s", index, err)
+			return
+		}
+	}
+	if self.round.addVote(index, msg) {
+		self.checkViewChange()
+	}
+}
+
+// verifyLock checks that the block a view change is locked on was prepared by a quorum
+func (self *SbftService) verifyLock(msg *ViewChange) error {
+	header := msg.LockedBlock.Header
+	if header == nil || header.Height != self.round.height || header.PrevBlockHash != self.round.prevHash {
+		return fmt.Errorf("locked block of other round")
+	}
+	if msg.LockedView >= msg.msgData.View {
+		return fmt.Errorf("lock view %d not below view %d", msg.LockedView, msg.msgData.View)
+	}
+	return self.verifyPrepareCert(msg.LockedView, msg.LockedBlock.Hash(), msg.LockedCert)
+}
+
+// verifyPrepareCert checks that cert holds valid prepares of block hash in view from a quorum of bookkeepers
+func (self *SbftService) verifyPrepareCert(view uint32, hash common.Uint256, cert []*PrepareSig) error {
+	digest := PrepareDigest(self.round.height, view, hash)
+	signers := make(map[uint16]bool)
+	for _, sig := range cert {
+		if int(sig.Index) >= len(self.bookkeepers) || signers[sig.Index] {
+			return fmt.Errorf("invalid prepare signer %d", sig.Index)
+		}
+		if err := signature.Verify(self.bookkeepers[sig.Index], digest, sig.Sig); err != nil {
+			return fmt.Errorf("prepare of %d verify failed: %s", sig.Index, err)
+		}
+		signers[sig.Index] = true
+	}
+	if len(signers) < self.quorum() {
+		return fmt.Errorf("prepare certificate of %d signers below quorum", len(signers))
+	}
 	return nil
 }
+
+func (self *SbftService) checkViewChange() {
+	// join the view change once f+1 bookkeepers ask for it, at least one of them is honest
+	if view := self.round.supportedView(self.faulty() + 1); view > self.round.expectedView && self.index >= 0 {
+		self.round.expectedView = view
+		self.resetTimer(self.viewTimeout(view))
+		self.sendViewChange()
+		return
+	}
+	if view := self.round.supportedView(self.quorum()); view > self.round.view {
+		self.enterView(view)
+	}
+}
+
+func (self *SbftService) broadcast(msg ConsensusMsg) {
+	msgData := msg.MsgData()
+	switch msg.(type) {
+	case *BlockProposal:
+		msgData.Type = BlockProposalMsg
+	case *BlockPrepare:
+		msgData.Type = BlockPrepareMsg
+	case *BlockCommit:
+		msgData.Type = BlockCommitMsg
+	case *ViewChange:
+		msgData.Type = ViewChangeMsg
+	}
+	msgData.Height = self.round.height
+	if msgData.Type != ViewChangeMsg {
+		msgData.View = self.round.view
+	}
+
+	payload := &p2pmsg.ConsensusPayload{
+		Version:         ContextVersion,
+		PrevHash:        self.round.prevHash,
+		Height:          self.round.height,
+		BookkeeperIndex: uint16(self.index),
+		Timestamp:       uint32(time.Now().Unix()),
+		Data:            SerializeMsg(msg),
+		Owner:           self.Account.PublicKey,
+	}
+	buf := new(bytes.Buffer)
+	if err := payload.SerializeUnsigned(buf); err != nil {
+		log.Errorf("sbft serialize payload error %s", err)
+		return
+	}
+	payload.Signature, _ = signature.Sign(self.Account, buf.Bytes())
+	self.p2p.Broadcast(payload)
+}
@@ -0,0 +1,334 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sbft
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OnyxPay/OnyxChain-crypto/keypair"
+	"github.com/OnyxPay/OnyxChain/account"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/signature"
+	"github.com/OnyxPay/OnyxChain/core/store"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	p2pmsg "github.com/OnyxPay/OnyxChain/p2pserver/message/types"
+	txpool "github.com/OnyxPay/OnyxChain/txnpool/common"
+	"github.com/stretchr/testify/assert"
+)
+
+const testGenBlockTime = 50 * time.Millisecond
+
+// memLedger is an in memory chain which only checks the block signatures
+type memLedger struct {
+	lock        sync.RWMutex
+	bookkeepers []keypair.PublicKey
+	blocks      []*types.Block
+	headers     map[common.Uint256]*types.Header
+	onSaved     func(block *types.Block)
+}
+
+func newMemLedger(bookkeepers []keypair.PublicKey, genesis *types.Block) *memLedger {
+	return &memLedger{
+		bookkeepers: bookkeepers,
+		blocks:      []*types.Block{genesis},
+		headers:     map[common.Uint256]*types.Header{genesis.Hash(): genesis.Header},
+	}
+}
+
+func (self *memLedger) GetCurrentBlockHeight() uint32 {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return uint32(len(self.blocks) - 1)
+}
+
+func (self *memLedger) GetCurrentBlockHash() common.Uint256 {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.blocks[len(self.blocks)-1].Hash()
+}
+
+func (self *memLedger) GetBlockHash(height uint32) common.Uint256 {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	if int(height) >= len(self.blocks) {
+		return common.UINT256_EMPTY
+	}
+	return self.blocks[height].Hash()
+}
+
+func (self *memLedger) GetHeaderByHash(hash common.Uint256) (*types.Header, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.headers[hash], nil
+}
+
+func (self *memLedger) GetBlockRootWithNewTxRoots(startHeight uint32, txRoots []common.Uint256) common.Uint256 {
+	return common.ComputeMerkleRoot(txRoots)
+}
+
+func (self *memLedger) ExecuteBlock(b *types.Block) (store.ExecuteResult, error) {
+	return store.ExecuteResult{}, nil
+}
+
+func (self *memLedger) SubmitBlock(b *types.Block, exec store.ExecuteResult) error {
+	m := len(self.bookkeepers) - (len(self.bookkeepers)-1)/3
+	hash := b.Hash()
+	if err := signature.VerifyMultiSignature(hash[:], b.Header.Bookkeepers, m, b.Header.SigData); err != nil {
+		return err
+	}
+	self.lock.Lock()
+	last := self.blocks[len(self.blocks)-1]
+	if b.Header.Height != last.Header.Height+1 || b.Header.PrevBlockHash != last.Hash() {
+		self.lock.Unlock()
+		return fmt.Errorf("block %d does not extend the chain", b.Header.Height)
+	}
+	self.blocks = append(self.blocks, b)
+	self.headers[hash] = b.Header
+	self.lock.Unlock()
+
+	// emulate the save block complete event of the ledger store
+	go self.onSaved(b)
+	return nil
+}
+
+type emptyTxPool struct{}
+
+func (self *emptyTxPool) GetTxnPool(byCount bool, height uint32) []*txpool.TXEntry {
+	return nil
+}
+
+func (self *emptyTxPool) VerifyBlock(txs []*types.Transaction, height uint32) error {
+	return nil
+}
+
+// testNetwork delivers broadcast payloads to all the other online nodes
+type testNetwork struct {
+	lock    sync.RWMutex
+	nodes   []*SbftService
+	offline map[int]bool
+}
+
+func (self *testNetwork) setOffline(index int, offline bool) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.offline[index] = offline
+}
+
+type testPeer struct {
+	net   *testNetwork
+	index int
+}
+
+func (self *testPeer) Broadcast(msg interface{}) {
+	sink := common.NewZeroCopySink(nil)
+	msg.(*p2pmsg.ConsensusPayload).Serialization(sink)
+	data := sink.Bytes()
+
+	self.net.lock.RLock()
+	defer self.net.lock.RUnlock()
+	if self.net.offline[self.index] {
+		return
+	}
+	for i, node := range self.net.nodes {
+		if i == self.index || self.net.offline[i] {
+			continue
+		}
+		payload := &p2pmsg.ConsensusPayload{}
+		if err := payload.Deserialization(common.NewZeroCopySource(data)); err != nil {
+			panic(err)
+		}
+		go node.onConsensusPayload(payload)
+	}
+}
+
+type testCluster struct {
+	net     *testNetwork
+	ledgers []*memLedger
+}
+
+func newTestCluster(t *testing.T, n int) *testCluster {
+	accounts := make([]*account.Account, 0, n)
+	for i := 0; i < n; i++ {
+		accounts = append(accounts, account.NewAccount(""))
+	}
+	// keep node i the owner of bookkeeper i
+	sort.Slice(accounts, func(i, j int) bool {
+		return bytes.Compare(keypair.SerializePublicKey(accounts[i].PublicKey),
+			keypair.SerializePublicKey(accounts[j].PublicKey)) < 0
+	})
+	bookkeepers := make([]keypair.PublicKey, 0, n)
+	for _, acc := range accounts {
+		bookkeepers = append(bookkeepers, acc.PublicKey)
+	}
+	nextBookkeeper, err := types.AddressFromBookkeepers(bookkeepers)
+	assert.Nil(t, err)
+	genesis := &types.Block{
+		Header: &types.Header{
+			Timestamp:      uint32(time.Now().Unix()) - 100,
+			NextBookkeeper: nextBookkeeper,
+		},
+	}
+
+	cluster := &testCluster{net: &testNetwork{offline: make(map[int]bool)}}
+	for i, acc := range accounts {
+		ledger := newMemLedger(bookkeepers, genesis)
+		service, err := newSbftService(acc, bookkeepers, testGenBlockTime, ledger, &emptyTxPool{},
+			&testPeer{net: cluster.net, index: i})
+		assert.Nil(t, err)
+		assert.Equal(t, i, service.index)
+		ledger.onSaved = service.onBlockSaved
+		cluster.ledgers = append(cluster.ledgers, ledger)
+		cluster.net.nodes = append(cluster.net.nodes, service)
+	}
+	return cluster
+}
+
+func (self *testCluster) start() {
+	for i, node := range self.net.nodes {
+		if !self.net.offline[i] {
+			node.start()
+		}
+	}
+}
+
+func (self *testCluster) halt() {
+	for i, node := range self.net.nodes {
+		if node.started {
+			node.halt()
+		}
+		self.net.setOffline(i, true)
+	}
+}
+
+// waitHeight waits until all the online nodes reach the height
+func (self *testCluster) waitHeight(t *testing.T, height uint32, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		reached := true
+		for i, ledger := range self.ledgers {
+			if !self.net.offline[i] && ledger.GetCurrentBlockHeight() < height {
+				reached = false
+			}
+		}
+		if reached {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("cluster did not reach height %d in %s", height, timeout)
+	return false
+}
+
+func (self *testCluster) checkSameChain(t *testing.T, height uint32) {
+	for h := uint32(1); h <= height; h++ {
+		var expected common.Uint256
+		for i, ledger := range self.ledgers {
+			if self.net.offline[i] {
+				continue
+			}
+			hash := ledger.GetBlockHash(h)
+			if expected == common.UINT256_EMPTY {
+				expected = hash
+			}
+			assert.Equal(t, expected, hash, fmt.Sprintf("fork at height %d", h))
+		}
+	}
+}
+
+func TestSbftClusterSealBlocks(t *testing.T) {
+	cluster := newTestCluster(t, 4)
+	cluster.start()
+	defer cluster.halt()
+
+	if cluster.waitHeight(t, 5, 10*time.Second) {
+		cluster.checkSameChain(t, 5)
+	}
+}
+
+func TestSbftClusterLeaderFailure(t *testing.T) {
+	cluster := newTestCluster(t, 4)
+	// the leader of height 1 view 0 never comes up
+	cluster.net.setOffline(1, true)
+	cluster.start()
+	defer cluster.halt()
+
+	if cluster.waitHeight(t, 3, 10*time.Second) {
+		cluster.checkSameChain(t, 3)
+	}
+}
+
+func TestSbftClusterNoQuorum(t *testing.T) {
+	cluster := newTestCluster(t, 4)
+	cluster.net.setOffline(2, true)
+	cluster.net.setOffline(3, true)
+	cluster.start()
+	defer cluster.halt()
+
+	time.Sleep(10 * testGenBlockTime)
+	for _, ledger := range cluster.ledgers {
+		assert.Equal(t, uint32(0), ledger.GetCurrentBlockHeight())
+	}
+}
+
+func TestLeaderRotation(t *testing.T) {
+	cluster := newTestCluster(t, 4)
+	node := cluster.net.nodes[0]
+	assert.Equal(t, 3, node.quorum())
+	assert.Equal(t, 1, node.faulty())
+	assert.Equal(t, 1, node.leader(1, 0))
+	assert.Equal(t, 2, node.leader(1, 1))
+	assert.Equal(t, 0, node.leader(3, 1))
+}
+
+func TestViewChangeLockCertificate(t *testing.T) {
+	cluster := newTestCluster(t, 4)
+	node := cluster.net.nodes[0]
+	node.round = newRoundState(1, cluster.ledgers[0].GetCurrentBlockHash())
+	block := &types.Block{Header: &types.Header{Height: 1, PrevBlockHash: node.round.prevHash}}
+	hash := block.Hash()
+
+	cert := make([]*PrepareSig, 0, 3)
+	for i := 1; i <= 3; i++ {
+		sig, err := signature.Sign(cluster.net.nodes[i].Account, PrepareDigest(1, 0, hash))
+		assert.Nil(t, err)
+		cert = append(cert, &PrepareSig{Index: uint16(i), Sig: sig})
+	}
+	vote := func(index int, cert []*PrepareSig) *ViewChange {
+		msg := &ViewChange{LockedBlock: block, LockedCert: cert}
+		msg.msgData = ConsensusMsgData{Type: ViewChangeMsg, Height: 1, View: 1}
+		node.handleViewChange(index, msg)
+		return node.round.votes[index]
+	}
+
+	// a lock without a quorum of prepares is forged
+	assert.Nil(t, vote(1, cert[:2]))
+	assert.Nil(t, vote(1, append(cert[:2:2], cert[1])))
+	forged := &PrepareSig{Index: 0, Sig: cert[0].Sig}
+	assert.Nil(t, vote(1, append(cert[:2:2], forged)))
+
+	assert.NotNil(t, vote(1, cert))
+	locked, view, _ := node.round.highestLock()
+	assert.Equal(t, hash, locked.Hash())
+	assert.Equal(t, uint32(0), view)
+}
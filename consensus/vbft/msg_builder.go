@@ -231,6 +231,12 @@ func (self *Server) constructProposalMsg(blkNum uint32, sysTxs, userTxs []*types
 	if prevBlk.Block.Header.Timestamp >= blocktimestamp {
 		blocktimestamp = prevBlk.Block.Header.Timestamp + 1
 	}
+	// the timestamp tells proposals of different rounds apart, which are not regarded as equivocation
+	if blkNum == self.lastProposalBlkNum && self.lastProposalTimestamp >= blocktimestamp {
+		blocktimestamp = self.lastProposalTimestamp + 1
+	}
+	self.lastProposalBlkNum = blkNum
+	self.lastProposalTimestamp = blocktimestamp
 
 	vrfValue, vrfProof, err := computeVrf(self.account.PrivateKey, blkNum, prevBlk.getVrfValue())
 	if err != nil {
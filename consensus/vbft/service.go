@@ -31,11 +31,13 @@ import (
 	"github.com/OnyxPay/OnyxChain-eventbus/actor"
 	"github.com/OnyxPay/OnyxChain/account"
 	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/config"
 	"github.com/OnyxPay/OnyxChain/common/log"
 	actorTypes "github.com/OnyxPay/OnyxChain/consensus/actor"
 	"github.com/OnyxPay/OnyxChain/consensus/vbft/config"
 	"github.com/OnyxPay/OnyxChain/core/ledger"
 	"github.com/OnyxPay/OnyxChain/core/payload"
+	"github.com/OnyxPay/OnyxChain/core/signature"
 	"github.com/OnyxPay/OnyxChain/core/types"
 	"github.com/OnyxPay/OnyxChain/core/utils"
 	"github.com/OnyxPay/OnyxChain/events"
@@ -44,6 +46,7 @@ import (
 	gover "github.com/OnyxPay/OnyxChain/smartcontract/service/native/governance"
 	ninit "github.com/OnyxPay/OnyxChain/smartcontract/service/native/init"
 	nutils "github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	tc "github.com/OnyxPay/OnyxChain/txnpool/common"
 	"github.com/OnyxPay/OnyxChain/validator/increment"
 )
 
@@ -111,6 +114,8 @@ type Server struct {
 	LastConfigBlockNum       uint32
 	config                   *vconfig.ChainConfig
 	currentParticipantConfig *BlockParticipantConfig
+	lastProposalBlkNum       uint32 // block num of the last proposal made by this server
+	lastProposalTimestamp    uint32 // a proposal made again for the same block uses a later timestamp
 
 	chainStore *ChainStore // block store
 	msgPool    *MsgPool    // consensus msg pool
@@ -1210,7 +1215,7 @@ func (self *Server) processMsgEvent() error {
 				// add proposal to block-pool
 				if err := self.blockPool.newBlockProposal(pMsg); err != nil {
 					if err == errDupProposal {
-						// TODO: faulty proposer detected
+						self.reportEquivocation(pMsg)
 					}
 					log.Errorf("failed to add block proposal (%d): %s", msgBlkNum, err)
 					return nil
@@ -2127,6 +2132,77 @@ func (self *Server) creategovernaceTransaction(blkNum uint32) (*types.Transactio
 	return tx, err
 }
 
+//createEquivocationTransaction invoke governance native contract slashEquivocation with two conflicting proposals
+func (self *Server) createEquivocationTransaction(peerPubkey string, proposal1, proposal2 *blockProposalMsg) (*types.Transaction, error) {
+	payload1, err := proposal1.Serialize()
+	if err != nil {
+		return nil, fmt.Errorf("serialize proposal failed: %s", err)
+	}
+	payload2, err := proposal2.Serialize()
+	if err != nil {
+		return nil, fmt.Errorf("serialize proposal failed: %s", err)
+	}
+	param := &gover.SlashEquivocationParam{
+		PeerPubkey: peerPubkey,
+		Proposal1:  payload1,
+		Proposal2:  payload2,
+	}
+	bf := new(bytes.Buffer)
+	if err := param.Serialize(bf); err != nil {
+		return nil, fmt.Errorf("serialize slashEquivocation param failed: %s", err)
+	}
+	mutable := utils.BuildNativeTransaction(nutils.GovernanceContractAddress, gover.SLASH_EQUIVOCATION, bf.Bytes())
+	mutable.GasPrice = config.DefConfig.Common.GasPrice
+	mutable.GasLimit = config.DefConfig.Common.GasLimit
+	mutable.Payer = self.account.Address
+	txHash := mutable.Hash()
+	sig, err := signature.Sign(self.account, txHash[:])
+	if err != nil {
+		return nil, fmt.Errorf("sign equivocation tx failed: %s", err)
+	}
+	mutable.Sigs = []types.Sig{{
+		PubKeys: []keypair.PublicKey{self.account.PublicKey},
+		M:       1,
+		SigData: [][]byte{sig},
+	}}
+	return mutable.IntoImmutable()
+}
+
+//reportEquivocation submit the evidence to governance if the proposer of msg has proposed another conflicting block
+func (self *Server) reportEquivocation(msg *blockProposalMsg) {
+	blkNum := msg.GetBlockNum()
+	proposer := msg.Block.getProposer()
+	for _, p := range self.blockPool.getBlockProposals(blkNum) {
+		if p.Block.getProposer() != proposer {
+			continue
+		}
+		if p.Block.EmptyBlock == nil || msg.Block.EmptyBlock == nil {
+			return
+		}
+		if !gover.IsEquivocation(
+			&gover.EquivocationProposal{Block: p.Block.Block.Header, EmptyBlock: p.Block.EmptyBlock.Header},
+			&gover.EquivocationProposal{Block: msg.Block.Block.Header, EmptyBlock: msg.Block.EmptyBlock.Header}) {
+			return
+		}
+		pubKey := self.peerPool.GetPeerPubKey(proposer)
+		if pubKey == nil {
+			log.Errorf("server %d failed to get pubkey of equivocating proposer %d", self.Index, proposer)
+			return
+		}
+		tx, err := self.createEquivocationTransaction(vconfig.PubkeyID(pubKey), p, msg)
+		if err != nil {
+			log.Errorf("server %d failed to create equivocation tx for proposer %d, blk %d: %s",
+				self.Index, proposer, blkNum, err)
+			return
+		}
+		txHash := tx.Hash()
+		log.Warnf("server %d detected equivocation of proposer %d at blk %d, submit tx %s",
+			self.Index, proposer, blkNum, txHash.ToHexString())
+		self.poolActor.Pool.Tell(&tc.TxReq{Tx: tx, Sender: tc.NilSender})
+		return
+	}
+}
+
 //checkNeedUpdateChainConfig use blockcount
 func (self *Server) checkNeedUpdateChainConfig(blockNum uint32) bool {
 	prevBlk, _ := self.blockPool.getSealedBlock(blockNum - 1)
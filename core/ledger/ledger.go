@@ -20,16 +20,20 @@ package ledger
 
 import (
 	"fmt"
+	"io"
+
 	"github.com/OnyxPay/OnyxChain-crypto/keypair"
 	"github.com/OnyxPay/OnyxChain/common"
 	"github.com/OnyxPay/OnyxChain/common/log"
 	"github.com/OnyxPay/OnyxChain/core/payload"
 	"github.com/OnyxPay/OnyxChain/core/states"
 	"github.com/OnyxPay/OnyxChain/core/store"
+	scom "github.com/OnyxPay/OnyxChain/core/store/common"
 	"github.com/OnyxPay/OnyxChain/core/store/ledgerstore"
 	"github.com/OnyxPay/OnyxChain/core/types"
 	"github.com/OnyxPay/OnyxChain/smartcontract/event"
 	cstate "github.com/OnyxPay/OnyxChain/smartcontract/states"
+	vm "github.com/OnyxPay/OnyxChain/vm/neovm"
 )
 
 var DefLedger *Ledger
@@ -164,6 +168,21 @@ func (self *Ledger) GetStorageItem(codeHash common.Address, key []byte) ([]byte,
 	return storageItem.Value, nil
 }
 
+func (self *Ledger) GetStorageItemByHeight(codeHash common.Address, key []byte, height uint32) ([]byte, error) {
+	storageKey := &states.StorageKey{
+		ContractAddress: codeHash,
+		Key:             key,
+	}
+	storageItem, err := self.ldgStore.GetStorageItemByHeight(storageKey, height)
+	if err != nil {
+		return nil, err
+	}
+	if storageItem == nil {
+		return nil, nil
+	}
+	return storageItem.Value, nil
+}
+
 func (self *Ledger) GetContractState(contractHash common.Address) (*payload.DeployCode, error) {
 	return self.ldgStore.GetContractState(contractHash)
 }
@@ -172,10 +191,26 @@ func (self *Ledger) GetMerkleProof(proofHeight, rootHeight uint32) ([]common.Uin
 	return self.ldgStore.GetMerkleProof(proofHeight, rootHeight)
 }
 
+func (self *Ledger) GetTransactionProof(txHash common.Uint256, rootHeight uint32) (*types.TxProof, error) {
+	return self.ldgStore.GetTransactionProof(txHash, rootHeight)
+}
+
+func (self *Ledger) GetStorageProof(key []byte, rootHeight uint32) (*types.StorageProof, error) {
+	return self.ldgStore.GetStorageProof(key, rootHeight)
+}
+
 func (self *Ledger) PreExecuteContract(tx *types.Transaction) (*cstate.PreExecResult, error) {
 	return self.ldgStore.PreExecuteContract(tx)
 }
 
+func (self *Ledger) PreExecuteContractWithTracer(tx *types.Transaction, tracer vm.Tracer) (*cstate.PreExecResult, error) {
+	return self.ldgStore.PreExecuteContractWithTracer(tx, tracer)
+}
+
+func (self *Ledger) TraceTransaction(txHash common.Uint256, tracer vm.Tracer) (*cstate.TraceResult, error) {
+	return self.ldgStore.TraceTransaction(txHash, tracer)
+}
+
 func (self *Ledger) GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error) {
 	return self.ldgStore.GetEventNotifyByTx(tx)
 }
@@ -184,6 +219,28 @@ func (self *Ledger) GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNotify
 	return self.ldgStore.GetEventNotifyByBlock(height)
 }
 
+func (self *Ledger) GetTxsByAddress(addr common.Address, startHeight, endHeight, offset,
+	limit uint32) ([]*scom.TxIndexItem, error) {
+	return self.ldgStore.GetTxsByAddress(addr, startHeight, endHeight, offset, limit)
+}
+
+func (self *Ledger) GetTxsByContract(contract common.Address, startHeight, endHeight, offset,
+	limit uint32) ([]*scom.TxIndexItem, error) {
+	return self.ldgStore.GetTxsByContract(contract, startHeight, endHeight, offset, limit)
+}
+
+func (self *Ledger) ExportSnapshot(w io.Writer) (*store.SnapshotCheckpoint, error) {
+	return self.ldgStore.ExportSnapshot(w)
+}
+
+func (self *Ledger) ImportSnapshot(r io.Reader, genesisBlock *types.Block, checkpoint *store.SnapshotCheckpoint) error {
+	err := self.ldgStore.ImportSnapshot(r, genesisBlock, checkpoint)
+	if err != nil {
+		return fmt.Errorf("ImportSnapshot error %s", err)
+	}
+	return nil
+}
+
 func (self *Ledger) Close() error {
 	return self.ldgStore.Close()
 }
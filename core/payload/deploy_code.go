@@ -27,10 +27,24 @@ import (
 	"github.com/OnyxPay/OnyxChain/common/serialization"
 )
 
+// VmType describe the virtual machine which executes the deployed code
+type VmType byte
+
+const (
+	NEOVM_TYPE  VmType = 0
+	WASMVM_TYPE VmType = 1
+)
+
+const (
+	needStorageFlag byte = 1 << 0
+	wasmVmFlag      byte = 1 << 1
+)
+
 // DeployCode is an implementation of transaction payload for deploy smartcontract
 type DeployCode struct {
 	Code        []byte
 	NeedStorage bool
+	VmType      VmType
 	Name        string
 	Version     string
 	Author      string
@@ -55,7 +69,7 @@ func (dc *DeployCode) Serialize(w io.Writer) error {
 		return fmt.Errorf("DeployCode Code Serialize failed: %s", err)
 	}
 
-	err = serialization.WriteBool(w, dc.NeedStorage)
+	err = serialization.WriteByte(w, dc.vmFlags())
 	if err != nil {
 		return fmt.Errorf("DeployCode NeedStorage Serialize failed: %s", err)
 	}
@@ -95,10 +109,13 @@ func (dc *DeployCode) Deserialize(r io.Reader) error {
 	}
 	dc.Code = code
 
-	dc.NeedStorage, err = serialization.ReadBool(r)
+	flags, err := serialization.ReadByte(r)
 	if err != nil {
 		return fmt.Errorf("DeployCode NeedStorage Deserialize failed: %s", err)
 	}
+	if err = dc.setVmFlags(flags); err != nil {
+		return fmt.Errorf("DeployCode NeedStorage Deserialize failed: %s", err)
+	}
 
 	dc.Name, err = serialization.ReadString(r)
 	if err != nil {
@@ -128,6 +145,31 @@ func (dc *DeployCode) Deserialize(r io.Reader) error {
 	return nil
 }
 
+// vmFlags pack NeedStorage and VmType into one byte, NeoVM contracts keep the
+// encoding of the former NeedStorage bool
+func (dc *DeployCode) vmFlags() byte {
+	var flags byte
+	if dc.NeedStorage {
+		flags |= needStorageFlag
+	}
+	if dc.VmType == WASMVM_TYPE {
+		flags |= wasmVmFlag
+	}
+	return flags
+}
+
+func (dc *DeployCode) setVmFlags(flags byte) error {
+	if flags&^(needStorageFlag|wasmVmFlag) != 0 {
+		return fmt.Errorf("invalid vm flags: %d", flags)
+	}
+	dc.NeedStorage = flags&needStorageFlag != 0
+	dc.VmType = NEOVM_TYPE
+	if flags&wasmVmFlag != 0 {
+		dc.VmType = WASMVM_TYPE
+	}
+	return nil
+}
+
 func (dc *DeployCode) ToArray() []byte {
 	b := new(bytes.Buffer)
 	dc.Serialize(b)
@@ -136,7 +178,7 @@ func (dc *DeployCode) ToArray() []byte {
 
 func (dc *DeployCode) Serialization(sink *common.ZeroCopySink) error {
 	sink.WriteVarBytes(dc.Code)
-	sink.WriteBool(dc.NeedStorage)
+	sink.WriteByte(dc.vmFlags())
 	sink.WriteString(dc.Name)
 	sink.WriteString(dc.Version)
 	sink.WriteString(dc.Author)
@@ -154,8 +196,9 @@ func (dc *DeployCode) Deserialization(source *common.ZeroCopySource) error {
 		return common.ErrIrregularData
 	}
 
-	dc.NeedStorage, irregular, eof = source.NextBool()
-	if irregular {
+	var flags byte
+	flags, eof = source.NextByte()
+	if dc.setVmFlags(flags) != nil {
 		return common.ErrIrregularData
 	}
 
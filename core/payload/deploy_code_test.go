@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/OnyxPay/OnyxChain/common"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -40,3 +41,34 @@ func TestDeployCode_Serialize(t *testing.T) {
 	err := deploy2.Deserialize(buf)
 	assert.NotNil(t, err)
 }
+
+func TestDeployCode_VmType(t *testing.T) {
+	deploy := DeployCode{
+		Code:        []byte{0x00, 0x61, 0x73, 0x6d},
+		NeedStorage: true,
+		VmType:      WASMVM_TYPE,
+		Name:        "wasm",
+	}
+
+	sink := common.NewZeroCopySink(nil)
+	deploy.Serialization(sink)
+	var deploy2 DeployCode
+	err := deploy2.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, WASMVM_TYPE, deploy2.VmType)
+	assert.True(t, deploy2.NeedStorage)
+
+	legacy := DeployCode{Code: []byte{1, 2, 3}, NeedStorage: true}
+	buf := bytes.NewBuffer(nil)
+	legacy.Serialize(buf)
+	assert.Equal(t, byte(1), buf.Bytes()[4])
+	var legacy2 DeployCode
+	err = legacy2.Deserialize(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, NEOVM_TYPE, legacy2.VmType)
+
+	bs := sink.Bytes()
+	bs[5] = 0x04
+	err = deploy2.Deserialization(common.NewZeroCopySource(bs))
+	assert.Equal(t, common.ErrIrregularData, err)
+}
@@ -23,10 +23,12 @@ type DataEntryPrefix byte
 
 const (
 	// DATA
-	DATA_BLOCK             DataEntryPrefix = 0x00 //Block height => block hash key prefix
-	DATA_HEADER                            = 0x01 //Block hash => block hash key prefix
-	DATA_TRANSACTION                       = 0x02 //Transction hash = > transaction key prefix
-	DATA_STATE_MERKLE_ROOT                 = 0x21 // block height => write set hash + state merkle root
+	DATA_BLOCK              DataEntryPrefix = 0x00 //Block height => block hash key prefix
+	DATA_HEADER                             = 0x01 //Block hash => block hash key prefix
+	DATA_TRANSACTION                        = 0x02 //Transction hash = > transaction key prefix
+	DATA_STATE_MERKLE_ROOT                  = 0x21 // block height => write set hash + state merkle root, only the root is kept for pruned block
+	DATA_STATE_HISTORY                      = 0x22 // state key + block height => state value before the block
+	DATA_STATE_HISTORY_KEYS                 = 0x25 // block height => state keys changed by the block
 
 	// Transaction
 	ST_BOOKKEEPER DataEntryPrefix = 0x03 //BookKeeper state key prefix
@@ -36,6 +38,8 @@ const (
 	ST_VOTE       DataEntryPrefix = 0x08 //Vote state key prefix
 
 	IX_HEADER_HASH_LIST DataEntryPrefix = 0x09 //Block height => block hash key prefix
+	IX_ADDRESS_TX       DataEntryPrefix = 0x15 //Address + block height + tx hash => nil, transfer index of address
+	IX_CONTRACT_EVENT   DataEntryPrefix = 0x16 //Contract address + block height + tx hash => nil, event index of contract
 
 	//SYSTEM
 	SYS_CURRENT_BLOCK      DataEntryPrefix = 0x10 //Current block key prefix
@@ -43,6 +47,8 @@ const (
 	SYS_CURRENT_STATE_ROOT DataEntryPrefix = 0x12 //no use
 	SYS_BLOCK_MERKLE_TREE  DataEntryPrefix = 0x13 // Block merkle tree root key prefix
 	SYS_STATE_MERKLE_TREE  DataEntryPrefix = 0x20 // state merkle tree root key prefix
+	SYS_ARCHIVE_HEIGHT     DataEntryPrefix = 0x23 // height since which state history is archived
+	SYS_PRUNED_HEIGHT      DataEntryPrefix = 0x24 // height of the last block whose data is pruned
 
 	EVENT_NOTIFY DataEntryPrefix = 0x14 //Event notify key prefix
 )
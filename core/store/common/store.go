@@ -26,6 +26,9 @@ import (
 )
 
 var ErrNotFound = errors.New("not found")
+var ErrNotArchived = errors.New("state of the height is not archived")
+var ErrPruned = errors.New("data of the block has been pruned")
+var ErrNotIndexed = errors.New("event index is not enabled")
 
 //Store iterator for iterate store
 type StoreIterator interface {
@@ -94,6 +97,12 @@ type EventStore interface {
 	CommitTo() error
 }
 
+//TxIndexItem is a transaction found by the event index
+type TxIndexItem struct {
+	Height uint32         //Height of the block which contains the transaction
+	TxHash common.Uint256 //Transaction hash
+}
+
 //State item type
 type ItemState byte
 
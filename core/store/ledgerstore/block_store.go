@@ -123,6 +123,9 @@ func (this *BlockStore) GetBlock(blockHash common.Uint256) (*types.Block, error)
 	txList := make([]*types.Transaction, 0, len(txHashes))
 	for _, txHash := range txHashes {
 		tx, _, err := this.GetTransaction(txHash)
+		if err == scom.ErrPruned {
+			return nil, err
+		}
 		if err != nil {
 			return nil, fmt.Errorf("GetTransaction %s error %s", txHash.ToHexString(), err)
 		}
@@ -186,6 +189,31 @@ func (this *BlockStore) SaveHeader(block *types.Block, sysFee common.Fixed64) er
 	return nil
 }
 
+//SavePrunedBlock persist the header and transaction hashes of a block whose transactions are not kept,
+//like the blocks imported from snapshot. The transactions are marked with block height as PruneBlock does.
+func (this *BlockStore) SavePrunedBlock(header *types.Header, txHashes []common.Uint256) error {
+	key := this.getHeaderKey(header.Hash())
+	sink := common.NewZeroCopySink(nil)
+	sysFee := common.Fixed64(0)
+	sysFee.Serialization(sink)
+	err := header.Serialization(sink)
+	if err != nil {
+		return err
+	}
+	sink.WriteUint32(uint32(len(txHashes)))
+	for _, txHash := range txHashes {
+		sink.WriteHash(txHash)
+	}
+	this.store.BatchPut(key, sink.Bytes())
+
+	value := bytes.NewBuffer(nil)
+	serialization.WriteUint32(value, header.Height)
+	for _, txHash := range txHashes {
+		this.store.BatchPut(this.getTransactionKey(txHash), value.Bytes())
+	}
+	return nil
+}
+
 //GetHeader return the header specified by block hash
 func (this *BlockStore) GetHeader(blockHash common.Uint256) (*types.Header, error) {
 	if this.enableCache {
@@ -382,6 +410,10 @@ func (this *BlockStore) loadTransaction(txHash common.Uint256) (*types.Transacti
 	if eof {
 		return nil, 0, io.ErrUnexpectedEOF
 	}
+	//only height is kept for pruned transaction
+	if source.Len() == 0 {
+		return nil, height, scom.ErrPruned
+	}
 	tx = new(types.Transaction)
 	err = tx.Deserialization(source)
 	if err != nil {
@@ -390,6 +422,45 @@ func (this *BlockStore) loadTransaction(txHash common.Uint256) (*types.Transacti
 	return tx, height, nil
 }
 
+//PruneBlock remove the transactions of block from store. The header and the height of transactions are kept,
+//so that the block is still in the header chain and the transactions can not be replayed.
+func (this *BlockStore) PruneBlock(blockHash common.Uint256) error {
+	header, txHashes, err := this.loadHeaderWithTx(blockHash)
+	if err != nil {
+		return err
+	}
+	if this.enableCache {
+		this.cache.RemoveBlock(blockHash, txHashes)
+	}
+	value := bytes.NewBuffer(nil)
+	serialization.WriteUint32(value, header.Height)
+	for _, txHash := range txHashes {
+		this.store.BatchPut(this.getTransactionKey(txHash), value.Bytes())
+	}
+	return nil
+}
+
+//GetPrunedHeight return the height of the last pruned block, 0 if none has been pruned
+func (this *BlockStore) GetPrunedHeight() (uint32, error) {
+	key := this.getPrunedHeightKey()
+	data, err := this.store.Get(key)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return serialization.ReadUint32(bytes.NewReader(data))
+}
+
+//SavePrunedHeight persist the height of the last pruned block to store
+func (this *BlockStore) SavePrunedHeight(height uint32) {
+	key := this.getPrunedHeightKey()
+	value := bytes.NewBuffer(nil)
+	serialization.WriteUint32(value, height)
+	this.store.BatchPut(key, value.Bytes())
+}
+
 //IsContainTransaction return whether the transaction is in store
 func (this *BlockStore) ContainTransaction(txHash common.Uint256) (bool, error) {
 	key := this.getTransactionKey(txHash)
@@ -480,6 +551,10 @@ func (this *BlockStore) getBlockMerkleTreeKey() []byte {
 	return []byte{byte(scom.SYS_BLOCK_MERKLE_TREE)}
 }
 
+func (this *BlockStore) getPrunedHeightKey() []byte {
+	return []byte{byte(scom.SYS_PRUNED_HEIGHT)}
+}
+
 func (this *BlockStore) getVersionKey() []byte {
 	return []byte{byte(scom.SYS_VERSION)}
 }
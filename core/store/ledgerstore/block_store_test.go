@@ -26,6 +26,7 @@ import (
 	"github.com/OnyxPay/OnyxChain/account"
 	"github.com/OnyxPay/OnyxChain/common"
 	"github.com/OnyxPay/OnyxChain/core/payload"
+	scom "github.com/OnyxPay/OnyxChain/core/store/common"
 	"github.com/OnyxPay/OnyxChain/core/types"
 	"github.com/OnyxPay/OnyxChain/core/utils"
 	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/onx"
@@ -354,6 +355,88 @@ func TestBlock(t *testing.T) {
 	}
 }
 
+func TestPruneBlock(t *testing.T) {
+	acc1 := account.NewAccount("")
+	acc2 := account.NewAccount("")
+	header := &types.Header{
+		Version:       123,
+		PrevBlockHash: common.Uint256{},
+		Timestamp:     uint32(uint32(time.Date(2017, time.February, 23, 0, 0, 0, 0, time.UTC).Unix())),
+		Height:        uint32(5),
+		ConsensusData: 1234567890,
+	}
+	tx1, err := transferTx(acc1.Address, acc2.Address, 10)
+	if err != nil {
+		t.Errorf("TestPruneBlock transferTx error:%s", err)
+		return
+	}
+	block := &types.Block{
+		Header:       header,
+		Transactions: []*types.Transaction{tx1},
+	}
+	blockHash := block.Hash()
+	tx1Hash := tx1.Hash()
+
+	testBlockStore.NewBatch()
+	err = testBlockStore.SaveBlock(block)
+	if err != nil {
+		t.Errorf("SaveBlock error %s", err)
+		return
+	}
+	err = testBlockStore.CommitTo()
+	if err != nil {
+		t.Errorf("CommitTo error %s", err)
+		return
+	}
+
+	testBlockStore.NewBatch()
+	err = testBlockStore.PruneBlock(blockHash)
+	if err != nil {
+		t.Errorf("PruneBlock error %s", err)
+		return
+	}
+	testBlockStore.SavePrunedHeight(header.Height)
+	err = testBlockStore.CommitTo()
+	if err != nil {
+		t.Errorf("CommitTo error %s", err)
+		return
+	}
+
+	_, err = testBlockStore.GetBlock(blockHash)
+	if err != scom.ErrPruned {
+		t.Errorf("TestPruneBlock failed GetBlock error %v != %s", err, scom.ErrPruned)
+		return
+	}
+	tx, height, err := testBlockStore.GetTransaction(tx1Hash)
+	if err != scom.ErrPruned || tx != nil || height != header.Height {
+		t.Errorf("TestPruneBlock failed GetTransaction tx:%v height:%d error:%v", tx, height, err)
+		return
+	}
+	exist, err := testBlockStore.ContainTransaction(tx1Hash)
+	if err != nil || !exist {
+		t.Errorf("TestPruneBlock failed transaction %x should exist, error:%v", tx1Hash, err)
+		return
+	}
+	h, err := testBlockStore.GetHeader(blockHash)
+	if err != nil {
+		t.Errorf("GetHeader error %s", err)
+		return
+	}
+	if h.Hash() != blockHash {
+		t.Errorf("TestPruneBlock failed header hash %x != %x", h.Hash(), blockHash)
+		return
+	}
+	prunedHeight, err := testBlockStore.GetPrunedHeight()
+	if err != nil {
+		t.Errorf("GetPrunedHeight error %s", err)
+		return
+	}
+	if prunedHeight != header.Height {
+		t.Errorf("TestPruneBlock failed pruned height %d != %d", prunedHeight, header.Height)
+		return
+	}
+}
+
 func transferTx(from, to common.Address, amount uint64) (*types.Transaction, error) {
 	buf := bytes.NewBuffer(nil)
 	var sts []onx.State
@@ -21,6 +21,7 @@ package ledgerstore
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/OnyxPay/OnyxChain/common"
@@ -134,6 +135,107 @@ func (this *EventStore) GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNo
 	return evtNotifies, nil
 }
 
+//PruneEventNotifyByBlock remove the event notifies of all transactions in block
+func (this *EventStore) PruneEventNotifyByBlock(height uint32) error {
+	key, err := this.getEventNotifyByBlockKey(height)
+	if err != nil {
+		return err
+	}
+	data, err := this.store.Get(key)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	reader := bytes.NewBuffer(data)
+	size, err := serialization.ReadUint32(reader)
+	if err != nil {
+		return fmt.Errorf("ReadUint32 error %s", err)
+	}
+	for i := uint32(0); i < size; i++ {
+		var txHash common.Uint256
+		err = txHash.Deserialize(reader)
+		if err != nil {
+			return fmt.Errorf("txHash.Deserialize error %s", err)
+		}
+		notify, err := this.GetEventNotifyByTx(txHash)
+		if err == nil {
+			for _, indexKey := range this.getEventIndexKeys(height, notify) {
+				this.store.BatchDelete(indexKey)
+			}
+		} else if err != scom.ErrNotFound {
+			return err
+		}
+		this.store.BatchDelete(this.getEventNotifyByTxKey(txHash))
+	}
+	this.store.BatchDelete(key)
+	return nil
+}
+
+//SaveEventIndex index the transaction by the contracts emitting its event notifies,
+//and by the addresses which appear in its transfer notifies
+func (this *EventStore) SaveEventIndex(height uint32, notify *event.ExecuteNotify) {
+	for _, key := range this.getEventIndexKeys(height, notify) {
+		this.store.BatchPut(key, nil)
+	}
+}
+
+//GetTxsByAddress return the transactions which transfer from or to the address, in the block height range
+//[startHeight, endHeight]. Results are sorted by block height, and paged by offset and limit
+func (this *EventStore) GetTxsByAddress(addr common.Address, startHeight, endHeight, offset,
+	limit uint32) ([]*scom.TxIndexItem, error) {
+	return this.getIndexedTxs(scom.IX_ADDRESS_TX, addr, startHeight, endHeight, offset, limit)
+}
+
+//GetTxsByContract return the transactions which have event notifies of the contract, in the block height range
+//[startHeight, endHeight]. Results are sorted by block height, and paged by offset and limit
+func (this *EventStore) GetTxsByContract(contract common.Address, startHeight, endHeight, offset,
+	limit uint32) ([]*scom.TxIndexItem, error) {
+	return this.getIndexedTxs(scom.IX_CONTRACT_EVENT, contract, startHeight, endHeight, offset, limit)
+}
+
+func (this *EventStore) getIndexedTxs(prefix scom.DataEntryPrefix, addr common.Address, startHeight, endHeight,
+	offset, limit uint32) ([]*scom.TxIndexItem, error) {
+	items := make([]*scom.TxIndexItem, 0)
+	if startHeight > endHeight || limit == 0 {
+		return items, nil
+	}
+	lastHash := common.Uint256{}
+	for i := range lastHash {
+		lastHash[i] = 0xff
+	}
+	start := this.getEventIndexKey(prefix, addr, startHeight, common.UINT256_EMPTY)
+	//append a zero byte to the last possible key of endHeight, so that endHeight is included in range
+	end := append(this.getEventIndexKey(prefix, addr, endHeight, lastHash), 0)
+
+	iter := this.store.NewRangeIterator(start, end)
+	defer iter.Release()
+	skipped := uint32(0)
+	for iter.Next() {
+		if skipped < offset {
+			skipped++
+			continue
+		}
+		key := iter.Key()
+		if len(key) != 1+common.ADDR_LEN+4+common.UINT256_SIZE {
+			return nil, fmt.Errorf("invalid event index key %x", key)
+		}
+		item := &scom.TxIndexItem{
+			Height: binary.BigEndian.Uint32(key[1+common.ADDR_LEN:]),
+		}
+		copy(item.TxHash[:], key[1+common.ADDR_LEN+4:])
+		items = append(items, item)
+		if uint32(len(items)) >= limit {
+			break
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 //CommitTo event store batch to store
 func (this *EventStore) CommitTo() error {
 	return this.store.BatchCommit()
@@ -207,3 +309,68 @@ func (this *EventStore) getEventNotifyByTxKey(txHash common.Uint256) []byte {
 	copy(key[1:], data)
 	return key
 }
+
+func (this *EventStore) getEventIndexKeys(height uint32, notify *event.ExecuteNotify) [][]byte {
+	keys := make([][]byte, 0)
+	added := make(map[string]bool)
+	add := func(key []byte) {
+		if !added[string(key)] {
+			added[string(key)] = true
+			keys = append(keys, key)
+		}
+	}
+	for _, n := range notify.Notify {
+		add(this.getEventIndexKey(scom.IX_CONTRACT_EVENT, n.ContractAddress, height, notify.TxHash))
+		for _, addr := range getTransferAddresses(n.States) {
+			add(this.getEventIndexKey(scom.IX_ADDRESS_TX, addr, height, notify.TxHash))
+		}
+	}
+	return keys
+}
+
+//getEventIndexKey use big endian height, so that the index of address is iterated by height order
+func (this *EventStore) getEventIndexKey(prefix scom.DataEntryPrefix, addr common.Address, height uint32,
+	txHash common.Uint256) []byte {
+	key := make([]byte, 1+common.ADDR_LEN+4+common.UINT256_SIZE)
+	key[0] = byte(prefix)
+	copy(key[1:], addr[:])
+	binary.BigEndian.PutUint32(key[1+common.ADDR_LEN:], height)
+	copy(key[1+common.ADDR_LEN+4:], txHash[:])
+	return key
+}
+
+//getTransferAddresses return the from and to address of transfer notify ["transfer", from, to, amount].
+//Native contracts notify with base58 address, while neovm contracts notify with hex string
+func getTransferAddresses(states interface{}) []common.Address {
+	list, ok := states.([]interface{})
+	if !ok || len(list) < 3 {
+		return nil
+	}
+	method, ok := list[0].(string)
+	if !ok || (method != "transfer" && method != hex.EncodeToString([]byte("transfer"))) {
+		return nil
+	}
+	addrs := make([]common.Address, 0, 2)
+	for _, item := range list[1:3] {
+		str, ok := item.(string)
+		if !ok {
+			continue
+		}
+		addr, err := common.AddressFromBase58(str)
+		if err != nil {
+			buf, err := hex.DecodeString(str)
+			if err != nil {
+				continue
+			}
+			addr, err = common.AddressParseFromBytes(buf)
+			if err != nil {
+				continue
+			}
+		}
+		if addr == common.ADDRESS_EMPTY {
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	scom "github.com/OnyxPay/OnyxChain/core/store/common"
+	"github.com/OnyxPay/OnyxChain/smartcontract/event"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventIndex(t *testing.T) {
+	store, err := NewEventStore("test/event")
+	assert.Nil(t, err)
+	defer store.Close()
+
+	addr1 := common.Address{1}
+	addr2 := common.Address{2}
+	contract := common.Address{3}
+	txHashes := []common.Uint256{{1}, {2}, {3}}
+	notifies := []*event.ExecuteNotify{
+		{
+			TxHash: txHashes[0],
+			Notify: []*event.NotifyEventInfo{{
+				ContractAddress: utils.OnxContractAddress,
+				States:          []interface{}{"transfer", addr1.ToBase58(), addr2.ToBase58(), uint64(1)},
+			}},
+		},
+		{
+			TxHash: txHashes[1],
+			Notify: []*event.NotifyEventInfo{{
+				ContractAddress: contract,
+				States: []interface{}{hex.EncodeToString([]byte("transfer")), hex.EncodeToString(addr1[:]),
+					hex.EncodeToString(contract[:]), "01"},
+			}},
+		},
+		{
+			TxHash: txHashes[2],
+			Notify: []*event.NotifyEventInfo{{
+				ContractAddress: contract,
+				States:          []interface{}{"approve", addr2.ToBase58()},
+			}},
+		},
+	}
+	for i, notify := range notifies {
+		height := uint32(i + 1)
+		store.NewBatch()
+		err = store.SaveEventNotifyByTx(notify.TxHash, notify)
+		assert.Nil(t, err)
+		err = store.SaveEventNotifyByBlock(height, []common.Uint256{notify.TxHash})
+		assert.Nil(t, err)
+		store.SaveEventIndex(height, notify)
+		err = store.CommitTo()
+		assert.Nil(t, err)
+	}
+
+	txs, err := store.GetTxsByAddress(addr1, 0, 10, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []*scom.TxIndexItem{{Height: 1, TxHash: txHashes[0]}, {Height: 2, TxHash: txHashes[1]}}, txs)
+	txs, err = store.GetTxsByAddress(addr2, 0, 10, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []*scom.TxIndexItem{{Height: 1, TxHash: txHashes[0]}}, txs)
+	txs, err = store.GetTxsByAddress(addr1, 2, 3, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []*scom.TxIndexItem{{Height: 2, TxHash: txHashes[1]}}, txs)
+	txs, err = store.GetTxsByAddress(addr1, 0, 1, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []*scom.TxIndexItem{{Height: 1, TxHash: txHashes[0]}}, txs)
+	txs, err = store.GetTxsByAddress(addr1, 0, 10, 1, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []*scom.TxIndexItem{{Height: 2, TxHash: txHashes[1]}}, txs)
+
+	txs, err = store.GetTxsByContract(contract, 0, 10, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []*scom.TxIndexItem{{Height: 2, TxHash: txHashes[1]}, {Height: 3, TxHash: txHashes[2]}}, txs)
+	txs, err = store.GetTxsByContract(contract, 0, 10, 0, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []*scom.TxIndexItem{{Height: 2, TxHash: txHashes[1]}}, txs)
+
+	store.NewBatch()
+	err = store.PruneEventNotifyByBlock(2)
+	assert.Nil(t, err)
+	err = store.CommitTo()
+	assert.Nil(t, err)
+
+	txs, err = store.GetTxsByAddress(addr1, 0, 10, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []*scom.TxIndexItem{{Height: 1, TxHash: txHashes[0]}}, txs)
+	txs, err = store.GetTxsByContract(contract, 0, 10, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []*scom.TxIndexItem{{Height: 3, TxHash: txHashes[2]}}, txs)
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"errors"
+
+	scom "github.com/OnyxPay/OnyxChain/core/store/common"
+)
+
+var errReadOnlyStore = errors.New("history state store is read only")
+
+//historyStore is a read only PersistStore of the state after the block of height was executed.
+//It is backed by the state history of archive mode.
+type historyStore struct {
+	state  *StateStore
+	height uint32
+}
+
+//newHistoryStore return the state store at block height
+func newHistoryStore(state *StateStore, height uint32) *historyStore {
+	return &historyStore{state: state, height: height}
+}
+
+func (self *historyStore) Get(key []byte) ([]byte, error) {
+	return self.state.getStateByHeight(key, self.height)
+}
+
+func (self *historyStore) Has(key []byte) (bool, error) {
+	_, err := self.Get(key)
+	if err == scom.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+//NewIterator iterate the keys existing currently and return their values at the height.
+//Keys deleted after the height are not visited.
+func (self *historyStore) NewIterator(prefix []byte) scom.StoreIterator {
+	iter := &historyIterator{index: -1}
+	backIter := self.state.store.NewIterator(prefix)
+	for backIter.Next() {
+		key := append([]byte{}, backIter.Key()...)
+		value, err := self.Get(key)
+		if err == scom.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			iter.err = err
+			break
+		}
+		iter.keys = append(iter.keys, key)
+		iter.values = append(iter.values, value)
+	}
+	backIter.Release()
+	if iter.err == nil {
+		iter.err = backIter.Error()
+	}
+	return iter
+}
+
+func (self *historyStore) Put(key []byte, value []byte) error {
+	return errReadOnlyStore
+}
+
+func (self *historyStore) Delete(key []byte) error {
+	return errReadOnlyStore
+}
+
+func (self *historyStore) NewBatch() {}
+
+func (self *historyStore) BatchPut(key []byte, value []byte) {}
+
+func (self *historyStore) BatchDelete(key []byte) {}
+
+func (self *historyStore) BatchCommit() error {
+	return errReadOnlyStore
+}
+
+func (self *historyStore) Close() error {
+	return nil
+}
+
+type historyIterator struct {
+	keys   [][]byte
+	values [][]byte
+	index  int
+	err    error
+}
+
+func (self *historyIterator) Next() bool {
+	if self.index+1 >= len(self.keys) {
+		self.index = len(self.keys)
+		return false
+	}
+	self.index++
+	return true
+}
+
+func (self *historyIterator) First() bool {
+	self.index = 0
+	return len(self.keys) > 0
+}
+
+func (self *historyIterator) Key() []byte {
+	if self.index < 0 || self.index >= len(self.keys) {
+		return nil
+	}
+	return self.keys[self.index]
+}
+
+func (self *historyIterator) Value() []byte {
+	if self.index < 0 || self.index >= len(self.values) {
+		return nil
+	}
+	return self.values[self.index]
+}
+
+func (self *historyIterator) Release() {
+	self.keys = nil
+	self.values = nil
+}
+
+func (self *historyIterator) Error() error {
+	return self.err
+}
@@ -55,19 +55,22 @@ import (
 	"github.com/OnyxPay/OnyxChain/smartcontract/service/neovm"
 	sstate "github.com/OnyxPay/OnyxChain/smartcontract/states"
 	"github.com/OnyxPay/OnyxChain/smartcontract/storage"
+	vm "github.com/OnyxPay/OnyxChain/vm/neovm"
 )
 
 const (
 	SYSTEM_VERSION          = byte(1)      //Version of ledger store
 	HEADER_INDEX_BATCH_SIZE = uint32(2000) //Bath size of saving header index
+	PRUNE_BATCH_SIZE        = uint32(100)  //Max count of blocks pruned when saving one block
 )
 
 var (
 	//Storage save path.
-	DBDirEvent          = "ledgerevent"
-	DBDirBlock          = "block"
-	DBDirState          = "states"
-	MerkleTreeStorePath = "merkle_tree.db"
+	DBDirEvent               = "ledgerevent"
+	DBDirBlock               = "block"
+	DBDirState               = "states"
+	MerkleTreeStorePath      = "merkle_tree.db"
+	StateMerkleTreeStorePath = "state_merkle_tree.db"
 )
 
 //LedgerStoreImp is main store struct fo ledger
@@ -85,6 +88,10 @@ type LedgerStoreImp struct {
 	vbftPeerInfoblock    map[string]uint32 //pubInfo save pubkey,peerindex
 	lock                 sync.RWMutex
 	stateHashCheckHeight uint32
+	pruneBlockNum        uint32 //Count of recent blocks whose data are kept, 0 means pruning is disabled
+	prunedHeight         uint32 //Height of the last pruned block
+	eventIndex           bool   //Whether index transactions by contract and transfer address of event notifies
+	lightNode            bool   //Whether only keep block headers, headers are saved as blocks without transactions
 }
 
 //NewLedgerStore return LedgerStoreImp instance
@@ -96,6 +103,9 @@ func NewLedgerStore(dataDir string, stateHashHeight uint32) (*LedgerStoreImp, er
 		vbftPeerInfoblock:    make(map[string]uint32),
 		savingBlockSemaphore: make(chan bool, 1),
 		stateHashCheckHeight: stateHashHeight,
+		pruneBlockNum:        config.DefConfig.Common.PruneBlocks,
+		eventIndex:           config.DefConfig.Common.EnableEventIndex && config.DefConfig.Common.EnableEventLog,
+		lightNode:            config.DefConfig.Common.LightNode,
 	}
 
 	blockStore, err := NewBlockStore(fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirBlock), true)
@@ -106,7 +116,8 @@ func NewLedgerStore(dataDir string, stateHashHeight uint32) (*LedgerStoreImp, er
 
 	dbPath := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirState)
 	merklePath := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), MerkleTreeStorePath)
-	stateStore, err := NewStateStore(dbPath, merklePath, stateHashHeight)
+	stateMerklePath := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), StateMerkleTreeStorePath)
+	stateStore, err := NewStateStore(dbPath, merklePath, stateMerklePath, stateHashHeight)
 	if err != nil {
 		return nil, fmt.Errorf("NewStateStore error %s", err)
 	}
@@ -136,6 +147,10 @@ func (this *LedgerStoreImp) InitLedgerStoreWithGenesisBlock(genesisBlock *types.
 		if err != nil {
 			return fmt.Errorf("stateStore.ClearAll error %s", err)
 		}
+		err = this.initArchive()
+		if err != nil {
+			return fmt.Errorf("initArchive error %s", err)
+		}
 		err = this.eventStore.ClearAll()
 		if err != nil {
 			return fmt.Errorf("eventStore.ClearAll error %s", err)
@@ -173,6 +188,10 @@ func (this *LedgerStoreImp) InitLedgerStoreWithGenesisBlock(genesisBlock *types.
 		if !exist {
 			return fmt.Errorf("GenesisBlock arenot init correctly")
 		}
+		err = this.initArchive()
+		if err != nil {
+			return fmt.Errorf("initArchive error %s", err)
+		}
 		err = this.init()
 		if err != nil {
 			return fmt.Errorf("init error %s", err)
@@ -232,6 +251,13 @@ func (this *LedgerStoreImp) initGenesisBlock() error {
 	return this.blockStore.SaveVersion(SYSTEM_VERSION)
 }
 
+func (this *LedgerStoreImp) initArchive() error {
+	if config.DefConfig.Common.EnableArchiveMode {
+		return this.stateStore.EnableArchive()
+	}
+	return this.stateStore.DisableArchive()
+}
+
 func (this *LedgerStoreImp) init() error {
 	err := this.loadCurrentBlock()
 	if err != nil {
@@ -241,6 +267,11 @@ func (this *LedgerStoreImp) init() error {
 	if err != nil {
 		return fmt.Errorf("loadHeaderIndexList error %s", err)
 	}
+	prunedHeight, err := this.blockStore.GetPrunedHeight()
+	if err != nil {
+		return fmt.Errorf("GetPrunedHeight error %s", err)
+	}
+	this.setPrunedHeight(prunedHeight)
 	err = this.recoverStore()
 	if err != nil {
 		return fmt.Errorf("recoverStore error %s", err)
@@ -500,11 +531,74 @@ func (this *LedgerStoreImp) AddHeader(header *types.Header) error {
 	if err != nil {
 		return fmt.Errorf("verifyHeader error %s", err)
 	}
+	if this.lightNode {
+		return this.saveHeader(header)
+	}
 	this.addHeaderCache(header)
 	this.setHeaderIndex(header.Height, header.Hash())
 	return nil
 }
 
+//saveHeader persist the header as current block in light node mode. The block is saved without transactions
+//and regarded as pruned, and the state store only keeps the block merkle tree.
+func (this *LedgerStoreImp) saveHeader(header *types.Header) error {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+	blockHash := header.Hash()
+	blockHeight := header.Height
+	blockRoot := this.GetBlockRootWithNewTxRoots(blockHeight, []common.Uint256{header.TransactionsRoot})
+	if blockRoot != header.BlockRoot {
+		return fmt.Errorf("wrong block root at height:%d, expected:%s, got:%s",
+			blockHeight, blockRoot.ToHexString(), header.BlockRoot.ToHexString())
+	}
+
+	this.blockStore.NewBatch()
+	this.stateStore.NewBatch()
+	this.eventStore.NewBatch()
+	this.setHeaderIndex(blockHeight, blockHash)
+	err := this.saveHeaderIndexList()
+	if err != nil {
+		return fmt.Errorf("saveHeaderIndexList error %s", err)
+	}
+	err = this.blockStore.SavePrunedBlock(header, nil)
+	if err != nil {
+		return fmt.Errorf("SavePrunedBlock height:%d error %s", blockHeight, err)
+	}
+	this.blockStore.SaveBlockHash(blockHeight, blockHash)
+	err = this.blockStore.SaveCurrentBlock(blockHeight, blockHash)
+	if err != nil {
+		return fmt.Errorf("SaveCurrentBlock error %s", err)
+	}
+	this.blockStore.SavePrunedHeight(blockHeight)
+	err = this.stateStore.AddBlockMerkleTreeRoot(header.TransactionsRoot)
+	if err != nil {
+		return fmt.Errorf("AddBlockMerkleTreeRoot error %s", err)
+	}
+	err = this.stateStore.SaveCurrentBlock(blockHeight, blockHash)
+	if err != nil {
+		return fmt.Errorf("SaveCurrentBlock error %s", err)
+	}
+	err = this.eventStore.SaveCurrentBlock(blockHeight, blockHash)
+	if err != nil {
+		return fmt.Errorf("SaveCurrentBlock error %s", err)
+	}
+	err = this.blockStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("blockStore.CommitTo height:%d error %s", blockHeight, err)
+	}
+	err = this.eventStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("eventStore.CommitTo height:%d error %s", blockHeight, err)
+	}
+	err = this.stateStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("stateStore.CommitTo height:%d error %s", blockHeight, err)
+	}
+	this.setPrunedHeight(blockHeight)
+	this.setCurrentBlock(blockHeight, blockHash)
+	return nil
+}
+
 //AddHeaders bath add header.
 func (this *LedgerStoreImp) AddHeaders(headers []*types.Header) error {
 	sort.Slice(headers, func(i, j int) bool {
@@ -572,6 +666,9 @@ func (this *LedgerStoreImp) SubmitBlock(block *types.Block, result store.Execute
 //AddBlock add the block to store.
 //When the block is not the next block, it will be cache. until the missing block arrived
 func (this *LedgerStoreImp) AddBlock(block *types.Block, stateMerkleRoot common.Uint256) error {
+	if this.lightNode {
+		return fmt.Errorf("light node does not save blocks")
+	}
 	currBlockHeight := this.GetCurrentBlockHeight()
 	blockHeight := block.Header.Height
 	if blockHeight <= currBlockHeight {
@@ -699,6 +796,9 @@ func (this *LedgerStoreImp) saveBlockToStateStore(block *types.Block, result sto
 
 	for _, notify := range result.Notify {
 		SaveNotify(this.eventStore, notify.TxHash, notify)
+		if this.eventIndex {
+			this.eventStore.SaveEventIndex(blockHeight, notify)
+		}
 	}
 
 	err := this.stateStore.AddStateMerkleTreeRoot(blockHeight, result.Hash)
@@ -718,6 +818,11 @@ func (this *LedgerStoreImp) saveBlockToStateStore(block *types.Block, result sto
 
 	log.Debugf("the state transition hash of block %d is:%s", blockHeight, result.Hash.ToHexString())
 
+	err = this.stateStore.AddStateHistory(blockHeight, result.WriteSet)
+	if err != nil {
+		return fmt.Errorf("AddStateHistory error %s", err)
+	}
+
 	result.WriteSet.ForEach(func(key, val []byte) {
 		if len(val) == 0 {
 			this.stateStore.BatchDeleteRawKey(key)
@@ -772,6 +877,77 @@ func (this *LedgerStoreImp) releaseSavingBlockLock() {
 	}
 }
 
+func (this *LedgerStoreImp) setPrunedHeight(height uint32) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.prunedHeight = height
+}
+
+//GetPrunedHeight return the height of the last block whose data has been pruned, 0 if no block is pruned.
+func (this *LedgerStoreImp) GetPrunedHeight() uint32 {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.prunedHeight
+}
+
+func (this *LedgerStoreImp) isPruned(height uint32) bool {
+	return height > 0 && height <= this.GetPrunedHeight()
+}
+
+//pruneBlocks put the removal of the transactions, event notifies, state history and write set hashes of blocks older
+//than the last pruneBlockNum blocks to batch, and return the pruned height after the batch is committed. At most
+//PRUNE_BATCH_SIZE blocks are pruned at a time, so that a long chain is pruned gradually. Headers, block merkle tree
+//and state merkle roots are kept, and the genesis block is never pruned.
+func (this *LedgerStoreImp) pruneBlocks(currHeight uint32) (uint32, error) {
+	prunedHeight := this.GetPrunedHeight()
+	if this.pruneBlockNum == 0 || currHeight <= this.pruneBlockNum {
+		return prunedHeight, nil
+	}
+	target := currHeight - this.pruneBlockNum
+	if target <= prunedHeight {
+		return prunedHeight, nil
+	}
+	if target-prunedHeight > PRUNE_BATCH_SIZE {
+		target = prunedHeight + PRUNE_BATCH_SIZE
+	}
+	for height := prunedHeight + 1; height <= target; height++ {
+		blockHash := this.getHeaderIndex(height)
+		header, err := this.blockStore.GetHeader(blockHash)
+		if err != nil {
+			return 0, fmt.Errorf("GetHeader height:%d error %s", height, err)
+		}
+		if !isConfigBlock(header) {
+			err = this.blockStore.PruneBlock(blockHash)
+			if err != nil {
+				return 0, fmt.Errorf("PruneBlock height:%d error %s", height, err)
+			}
+		}
+		err = this.eventStore.PruneEventNotifyByBlock(height)
+		if err != nil {
+			return 0, fmt.Errorf("PruneEventNotifyByBlock height:%d error %s", height, err)
+		}
+		err = this.stateStore.PruneStateHistory(height)
+		if err != nil {
+			return 0, fmt.Errorf("PruneStateHistory height:%d error %s", height, err)
+		}
+		err = this.stateStore.PruneStateMerkleRoot(height)
+		if err != nil {
+			return 0, fmt.Errorf("PruneStateMerkleRoot height:%d error %s", height, err)
+		}
+	}
+	this.blockStore.SavePrunedHeight(target)
+	return target, nil
+}
+
+//isConfigBlock return whether block carries new vbft chain config, which is loaded from block by consensus
+func isConfigBlock(header *types.Header) bool {
+	if strings.ToLower(config.DefConfig.Genesis.ConsensusType) != config.CONSENSUS_TYPE_VBFT {
+		return false
+	}
+	blkInfo, err := vconfig.VbftBlock(header)
+	return err == nil && blkInfo.NewChainConfig != nil
+}
+
 //saveBlock do the job of execution samrt contract and commit block to store.
 func (this *LedgerStoreImp) submitBlock(block *types.Block, result store.ExecuteResult) error {
 	blockHash := block.Hash()
@@ -797,6 +973,10 @@ func (this *LedgerStoreImp) submitBlock(block *types.Block, result store.Execute
 	if err != nil {
 		return fmt.Errorf("save to event store height:%d error:%s", blockHeight, err)
 	}
+	prunedHeight, err := this.pruneBlocks(blockHeight)
+	if err != nil {
+		return fmt.Errorf("prune blocks height:%d error:%s", blockHeight, err)
+	}
 	err = this.blockStore.CommitTo()
 	if err != nil {
 		return fmt.Errorf("blockStore.CommitTo height:%d error %s", blockHeight, err)
@@ -811,6 +991,8 @@ func (this *LedgerStoreImp) submitBlock(block *types.Block, result store.Execute
 		return fmt.Errorf("stateStore.CommitTo height:%d error %s", blockHeight, err)
 	}
 	this.setCurrentBlock(blockHeight, blockHash)
+	this.setPrunedHeight(prunedHeight)
+	this.stateStore.SetPrunedHeight(prunedHeight)
 
 	if events.DefActorPublisher != nil {
 		events.DefActorPublisher.Publish(
@@ -859,7 +1041,7 @@ func (this *LedgerStoreImp) handleTransaction(overlay *overlaydb.OverlayDB, cach
 			log.Debugf("HandleDeployTransaction tx %s error %s", txHash.ToHexString(), err)
 		}
 	case types.Invoke:
-		err := this.stateStore.HandleInvokeTransaction(this, overlay, cache, tx, block, notify)
+		err := this.stateStore.HandleInvokeTransaction(this, overlay, cache, tx, block, notify, nil)
 		if overlay.Error() != nil {
 			return nil, fmt.Errorf("HandleInvokeTransaction tx %s error %s", txHash.ToHexString(), overlay.Error())
 		}
@@ -958,7 +1140,11 @@ func (this *LedgerStoreImp) GetTransaction(txHash common.Uint256) (*types.Transa
 
 //GetBlockByHash return block by block hash. Wrap function of BlockStore.GetBlockByHash
 func (this *LedgerStoreImp) GetBlockByHash(blockHash common.Uint256) (*types.Block, error) {
-	return this.blockStore.GetBlock(blockHash)
+	block, err := this.blockStore.GetBlock(blockHash)
+	if err == nil && this.lightNode && this.isPruned(block.Header.Height) {
+		return nil, scom.ErrPruned
+	}
+	return block, err
 }
 
 //GetBlockByHeight return block by height.
@@ -991,18 +1177,68 @@ func (this *LedgerStoreImp) GetStorageItem(key *states.StorageKey) (*states.Stor
 	return this.stateStore.GetStorageState(key)
 }
 
+//GetStorageItemByHeight return the storage value of the key in smart contract at block height.
+//Heights lower than current block height are available only in archive mode. Wrap function of StateStore.GetStorageStateByHeight
+func (this *LedgerStoreImp) GetStorageItemByHeight(key *states.StorageKey, height uint32) (*states.StorageItem, error) {
+	currHeight := this.GetCurrentBlockHeight()
+	if height > currHeight {
+		return nil, fmt.Errorf("height %d is higher than current block height %d", height, currHeight)
+	}
+	if height < currHeight || this.stateStore.archive {
+		item, err := this.stateStore.GetStorageStateByHeight(key, height)
+		if err == scom.ErrNotArchived && this.isPruned(height) {
+			return nil, scom.ErrPruned
+		}
+		return item, err
+	}
+	return this.stateStore.GetStorageState(key)
+}
+
 //GetEventNotifyByTx return the events notify gen by executing of smart contract.  Wrap function of EventStore.GetEventNotifyByTx
 func (this *LedgerStoreImp) GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error) {
-	return this.eventStore.GetEventNotifyByTx(tx)
+	notify, err := this.eventStore.GetEventNotifyByTx(tx)
+	if err == scom.ErrNotFound && this.GetPrunedHeight() > 0 {
+		_, height, e := this.blockStore.GetTransaction(tx)
+		if e == scom.ErrPruned || (e == nil && this.isPruned(height)) {
+			return nil, scom.ErrPruned
+		}
+	}
+	return notify, err
 }
 
 //GetEventNotifyByBlock return the transaction hash which have event notice after execution of smart contract. Wrap function of EventStore.GetEventNotifyByBlock
 func (this *LedgerStoreImp) GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNotify, error) {
+	if this.isPruned(height) {
+		return nil, scom.ErrPruned
+	}
 	return this.eventStore.GetEventNotifyByBlock(height)
 }
 
+//GetTxsByAddress return the transactions which transfer from or to the address in the block height range. Wrap function of EventStore.GetTxsByAddress
+func (this *LedgerStoreImp) GetTxsByAddress(addr common.Address, startHeight, endHeight, offset,
+	limit uint32) ([]*scom.TxIndexItem, error) {
+	if !this.eventIndex {
+		return nil, scom.ErrNotIndexed
+	}
+	return this.eventStore.GetTxsByAddress(addr, startHeight, endHeight, offset, limit)
+}
+
+//GetTxsByContract return the transactions which have event notifies of the contract in the block height range. Wrap function of EventStore.GetTxsByContract
+func (this *LedgerStoreImp) GetTxsByContract(contract common.Address, startHeight, endHeight, offset,
+	limit uint32) ([]*scom.TxIndexItem, error) {
+	if !this.eventIndex {
+		return nil, scom.ErrNotIndexed
+	}
+	return this.eventStore.GetTxsByContract(contract, startHeight, endHeight, offset, limit)
+}
+
 //PreExecuteContract return the result of smart contract execution without commit to store
 func (this *LedgerStoreImp) PreExecuteContract(tx *types.Transaction) (*sstate.PreExecResult, error) {
+	return this.PreExecuteContractWithTracer(tx, nil)
+}
+
+//PreExecuteContractWithTracer pre-execute the transaction and record the neovm execution steps by tracer
+func (this *LedgerStoreImp) PreExecuteContractWithTracer(tx *types.Transaction, tracer vm.Tracer) (*sstate.PreExecResult, error) {
 	height := this.GetCurrentBlockHeight()
 	stf := &sstate.PreExecResult{State: event.CONTRACT_STATE_FAIL, Gas: neovm.MIN_TRANSACTION_GAS, Result: nil}
 
@@ -1029,6 +1265,7 @@ func (this *LedgerStoreImp) PreExecuteContract(tx *types.Transaction) (*sstate.P
 			CacheDB: cache,
 			Gas:     math.MaxUint64 - calcGasByCodeLen(len(invoke.Code), preGas[neovm.UINT_INVOKE_CODE_LEN_NAME]),
 			PreExec: true,
+			Tracer:  tracer,
 		}
 
 		//start the smart contract executive function
@@ -1055,6 +1292,55 @@ func (this *LedgerStoreImp) PreExecuteContract(tx *types.Transaction) (*sstate.P
 	}
 }
 
+//TraceTransaction re-execute the invoke transaction against the state before its block with tracer, the
+//transactions ahead of it in the block are executed first. Gas is priced by the current global params.
+//It is available only in archive mode.
+func (this *LedgerStoreImp) TraceTransaction(txHash common.Uint256, tracer vm.Tracer) (*sstate.TraceResult, error) {
+	tx, height, err := this.blockStore.GetTransaction(txHash)
+	if err != nil {
+		return nil, err
+	}
+	if tx.TxType != types.Invoke {
+		return nil, fmt.Errorf("transaction %s is not an invoke transaction", txHash.ToHexString())
+	}
+	if height == 0 {
+		return nil, fmt.Errorf("transaction of genesis block can not be traced")
+	}
+	if !this.stateStore.isArchived(height - 1) {
+		return nil, scom.ErrNotArchived
+	}
+	block, err := this.GetBlockByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, scom.ErrNotFound
+	}
+
+	overlay := overlaydb.NewOverlayDB(newHistoryStore(this.stateStore, height-1))
+	cache := storage.NewCacheDB(overlay)
+	for _, t := range block.Transactions {
+		cache.Reset()
+		if t.Hash() != txHash {
+			if _, err := this.handleTransaction(overlay, cache, block, t); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		notify := &event.ExecuteNotify{TxHash: txHash, State: event.CONTRACT_STATE_FAIL}
+		err := this.stateStore.HandleInvokeTransaction(this, overlay, cache, t, block, notify, tracer)
+		if overlay.Error() != nil {
+			return nil, overlay.Error()
+		}
+		result := &sstate.TraceResult{State: notify.State, Gas: notify.GasConsumed, Notify: notify.Notify}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("transaction %s not found in block %d", txHash.ToHexString(), height)
+}
+
 func (this *LedgerStoreImp) getPreGas(config *smartcontract.Config, cache *storage.CacheDB) (map[string]uint64, error) {
 	bf := new(bytes.Buffer)
 	names := []string{neovm.CONTRACT_CREATE_NAME, neovm.UINT_INVOKE_CODE_LEN_NAME, neovm.UINT_DEPLOY_CODE_LEN_NAME}
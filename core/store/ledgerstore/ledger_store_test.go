@@ -51,7 +51,8 @@ func TestMain(m *testing.M) {
 	}
 	testStateDir := "test/state"
 	merklePath := "test/" + MerkleTreeStorePath
-	testStateStore, err = NewStateStore(testStateDir, merklePath, 1000)
+	stateMerklePath := "test/" + StateMerkleTreeStorePath
+	testStateStore, err = NewStateStore(testStateDir, merklePath, stateMerklePath, 1000)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "NewStateStore error %s\n", err)
 		return
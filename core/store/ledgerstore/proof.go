@@ -0,0 +1,182 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	scom "github.com/OnyxPay/OnyxChain/core/store/common"
+	"github.com/OnyxPay/OnyxChain/core/types"
+)
+
+//GetTransactionProof return the proof of the transaction against the block root of the block of rootHeight
+func (this *LedgerStoreImp) GetTransactionProof(txHash common.Uint256, rootHeight uint32) (*types.TxProof, error) {
+	currHeight := this.GetCurrentBlockHeight()
+	if rootHeight > currHeight {
+		return nil, fmt.Errorf("root height %d is higher than current block height %d", rootHeight, currHeight)
+	}
+	_, height, err := this.blockStore.GetTransaction(txHash)
+	if err != nil {
+		return nil, err
+	}
+	if height > rootHeight {
+		return nil, fmt.Errorf("transaction height %d is higher than root height %d", height, rootHeight)
+	}
+	block, err := this.GetBlockByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, scom.ErrNotFound
+	}
+	index := -1
+	txHashes := make([]common.Uint256, 0, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		hash := tx.Hash()
+		if hash == txHash {
+			index = i
+		}
+		txHashes = append(txHashes, hash)
+	}
+	if index < 0 {
+		return nil, scom.ErrNotFound
+	}
+	blockPath, err := this.stateStore.GetMerkleProof(height, rootHeight)
+	if err != nil {
+		return nil, fmt.Errorf("GetMerkleProof error %s", err)
+	}
+	return &types.TxProof{
+		Tx:         block.Transactions[index],
+		Height:     height,
+		TxIndex:    uint32(index),
+		TxPath:     common.ComputeMerkleProof(txHashes, uint32(index)),
+		RootHeight: rootHeight,
+		BlockPath:  blockPath,
+	}, nil
+}
+
+//GetStorageProof return the proof of the raw state key against the state merkle root of the block of rootHeight.
+//It needs the state history of archive mode to find the block which changed the key last time.
+func (this *LedgerStoreImp) GetStorageProof(key []byte, rootHeight uint32) (*types.StorageProof, error) {
+	currHeight := this.GetCurrentBlockHeight()
+	if rootHeight > currHeight {
+		return nil, fmt.Errorf("root height %d is higher than current block height %d", rootHeight, currHeight)
+	}
+	return this.stateStore.GetStorageProof(key, rootHeight)
+}
+
+//GetStorageProof return the proof of the raw state key against the state merkle root of the block of rootHeight
+func (self *StateStore) GetStorageProof(key []byte, rootHeight uint32) (*types.StorageProof, error) {
+	if rootHeight <= self.stateHashCheckHeight {
+		return nil, fmt.Errorf("state proof is not available at height %d", rootHeight)
+	}
+	height, err := self.getLastChangeHeight(key, rootHeight)
+	if err != nil {
+		return nil, err
+	}
+	if height <= self.stateHashCheckHeight || !self.isArchived(height-1) {
+		return nil, scom.ErrNotArchived
+	}
+	writeSet, err := self.getWriteSet(height)
+	if err != nil {
+		return nil, fmt.Errorf("get write set of height:%d error %s", height, err)
+	}
+	proof := &types.StorageProof{
+		Key:        key,
+		Height:     height,
+		WriteSet:   writeSet,
+		RootHeight: rootHeight,
+	}
+	for _, item := range writeSet {
+		if bytes.Equal(item.Key, key) {
+			proof.Value = item.Value
+		}
+	}
+	proof.StatePath, err = self.deltaMerkleTree.InclusionProof(height-self.stateHashCheckHeight,
+		rootHeight-self.stateHashCheckHeight+1)
+	if err != nil {
+		return nil, fmt.Errorf("state merkle proof error %s", err)
+	}
+	proof.StateRoot, err = self.GetStateMerkleRoot(rootHeight)
+	if err != nil {
+		return nil, fmt.Errorf("GetStateMerkleRoot error %s", err)
+	}
+	return proof, nil
+}
+
+//getLastChangeHeight return the height of the last archived block which changed the key before or at height
+func (self *StateStore) getLastChangeHeight(key []byte, height uint32) (uint32, error) {
+	if !self.archive {
+		return 0, scom.ErrNotArchived
+	}
+	found := false
+	lastHeight := uint32(0)
+	prefix := self.genStateHistoryPrefix(key)
+	iter := self.store.NewIterator(prefix)
+	for iter.Next() {
+		k := iter.Key()
+		if len(k) != len(prefix)+4 {
+			continue
+		}
+		h := binary.BigEndian.Uint32(k[len(prefix):])
+		if h > height {
+			break
+		}
+		found = true
+		lastHeight = h
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, scom.ErrNotFound
+	}
+	return lastHeight, nil
+}
+
+//getWriteSet return the state keys changed by the block of height and their values after the block
+func (self *StateStore) getWriteSet(height uint32) ([]*types.StateItem, error) {
+	data, err := self.store.Get(self.genStateHistoryKeysKey(height))
+	if err != nil {
+		return nil, err
+	}
+	source := common.NewZeroCopySource(data)
+	count, eof := source.NextUint32()
+	if eof {
+		return nil, io.ErrUnexpectedEOF
+	}
+	writeSet := make([]*types.StateItem, 0, count)
+	for i := uint32(0); i < count; i++ {
+		key, _, _, eof := source.NextVarBytes()
+		if eof {
+			return nil, io.ErrUnexpectedEOF
+		}
+		value, err := self.getStateByHeight(key, height)
+		if err != nil && err != scom.ErrNotFound {
+			return nil, err
+		}
+		writeSet = append(writeSet, &types.StateItem{Key: key, Value: value})
+	}
+	return writeSet, nil
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"testing"
+
+	"github.com/OnyxPay/OnyxChain-crypto/keypair"
+	"github.com/OnyxPay/OnyxChain/account"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/config"
+	"github.com/OnyxPay/OnyxChain/core/payload"
+	scom "github.com/OnyxPay/OnyxChain/core/store/common"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/OnyxPay/OnyxChain/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDeployTx(t *testing.T, code []byte) *types.Transaction {
+	tx, err := utils.NewDeployTransaction(code, "test", "1", "", "", "", false).IntoImmutable()
+	assert.Nil(t, err)
+	return tx
+}
+
+func TestProof(t *testing.T) {
+	acc := account.NewAccount("")
+	genesisBlock, restore := setSoloGenesis(t, acc)
+	defer restore()
+	archiveMode := config.DefConfig.Common.EnableArchiveMode
+	config.DefConfig.Common.EnableArchiveMode = true
+	defer func() {
+		config.DefConfig.Common.EnableArchiveMode = archiveMode
+	}()
+
+	store, err := NewLedgerStore("test/proof", 0)
+	assert.Nil(t, err)
+	defer store.Close()
+	assert.Nil(t, store.InitLedgerStoreWithGenesisBlock(genesisBlock, []keypair.PublicKey{acc.PublicKey}))
+
+	txs := []*types.Transaction{newDeployTx(t, []byte{1}), newDeployTx(t, []byte{2}), newDeployTx(t, []byte{3})}
+	addTestBlock(t, store, newTestBlock(t, store, acc, txs...))
+	addTestBlock(t, store, newTestBlock(t, store, acc, newDeployTx(t, []byte{4})))
+	addTestBlock(t, store, newTestBlock(t, store, acc))
+
+	header1, err := store.GetHeaderByHeight(1)
+	assert.Nil(t, err)
+	header3, err := store.GetHeaderByHeight(3)
+	assert.Nil(t, err)
+	for i, tx := range txs {
+		proof, err := store.GetTransactionProof(tx.Hash(), 3)
+		assert.Nil(t, err)
+		assert.Equal(t, uint32(i), proof.TxIndex)
+		assert.Nil(t, proof.Verify(header1, header3))
+
+		sink := common.NewZeroCopySink(nil)
+		assert.Nil(t, proof.Serialization(sink))
+		decoded := &types.TxProof{}
+		assert.Nil(t, decoded.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+		assert.Nil(t, decoded.Verify(header1, header3))
+	}
+	proof, err := store.GetTransactionProof(txs[0].Hash(), 3)
+	assert.Nil(t, err)
+	proof.TxIndex = 1
+	assert.NotNil(t, proof.Verify(header1, header3))
+	_, err = store.GetTransactionProof(txs[0].Hash(), 4)
+	assert.NotNil(t, err)
+
+	deploy := txs[1].Payload.(*payload.DeployCode)
+	key, _ := store.stateStore.getContractStateKey(deploy.Address())
+	stProof, err := store.GetStorageProof(key, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(1), stProof.Height)
+	assert.Equal(t, 3, len(stProof.WriteSet))
+	assert.NotEqual(t, 0, len(stProof.Value))
+	stateRoot, err := store.GetStateMerkleRoot(3)
+	assert.Nil(t, err)
+	assert.Equal(t, stateRoot, stProof.StateRoot)
+	assert.Nil(t, stProof.Verify(0))
+
+	sink := common.NewZeroCopySink(nil)
+	assert.Nil(t, stProof.Serialization(sink))
+	decoded := &types.StorageProof{}
+	assert.Nil(t, decoded.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Nil(t, decoded.Verify(0))
+
+	decoded.Value = append(decoded.Value, 0)
+	assert.NotNil(t, decoded.Verify(0))
+	stProof.WriteSet[0].Value = []byte{1}
+	assert.NotNil(t, stProof.Verify(0))
+}
+
+func TestLightNode(t *testing.T) {
+	acc := account.NewAccount("")
+	genesisBlock, restore := setSoloGenesis(t, acc)
+	defer restore()
+	bookkeepers := []keypair.PublicKey{acc.PublicKey}
+
+	full, err := NewLedgerStore("test/lightFull", 0)
+	assert.Nil(t, err)
+	defer full.Close()
+	assert.Nil(t, full.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+	tx := newDeployTx(t, []byte{1})
+	addTestBlock(t, full, newTestBlock(t, full, acc))
+	addTestBlock(t, full, newTestBlock(t, full, acc, tx))
+	addTestBlock(t, full, newTestBlock(t, full, acc))
+
+	lightNode := config.DefConfig.Common.LightNode
+	config.DefConfig.Common.LightNode = true
+	defer func() {
+		config.DefConfig.Common.LightNode = lightNode
+	}()
+	light, err := NewLedgerStore("test/light", 0)
+	assert.Nil(t, err)
+	assert.Nil(t, light.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+	headers := make([]*types.Header, 0)
+	for h := uint32(1); h <= 3; h++ {
+		header, err := full.GetHeaderByHeight(h)
+		assert.Nil(t, err)
+		headers = append(headers, header)
+	}
+	assert.Nil(t, light.AddHeaders(headers))
+	assert.Equal(t, uint32(3), light.GetCurrentBlockHeight())
+	assert.Equal(t, full.GetCurrentBlockHash(), light.GetCurrentBlockHash())
+	block, err := full.GetBlockByHeight(3)
+	assert.Nil(t, err)
+	assert.NotNil(t, light.AddBlock(block, common.UINT256_EMPTY))
+
+	//headers are kept after restart
+	assert.Nil(t, light.Close())
+	light, err = NewLedgerStore("test/light", 0)
+	assert.Nil(t, err)
+	defer light.Close()
+	assert.Nil(t, light.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+	assert.Equal(t, uint32(3), light.GetCurrentBlockHeight())
+	_, err = light.GetBlockByHeight(2)
+	assert.Equal(t, scom.ErrPruned, err)
+
+	proof, err := full.GetTransactionProof(tx.Hash(), 3)
+	assert.Nil(t, err)
+	header, err := light.GetHeaderByHeight(proof.Height)
+	assert.Nil(t, err)
+	rootHeader, err := light.GetHeaderByHeight(proof.RootHeight)
+	assert.Nil(t, err)
+	assert.Nil(t, proof.Verify(header, rootHeader))
+}
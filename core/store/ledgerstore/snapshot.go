@@ -0,0 +1,536 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/config"
+	"github.com/OnyxPay/OnyxChain/common/log"
+	"github.com/OnyxPay/OnyxChain/common/serialization"
+	vconfig "github.com/OnyxPay/OnyxChain/consensus/vbft/config"
+	"github.com/OnyxPay/OnyxChain/core/store"
+	scom "github.com/OnyxPay/OnyxChain/core/store/common"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/OnyxPay/OnyxChain/merkle"
+)
+
+const (
+	SNAPSHOT_VERSION    = byte(1)      //Version of snapshot format
+	SNAPSHOT_BATCH_SIZE = uint32(2000) //Count of blocks or state entries committed in a batch when importing snapshot
+)
+
+//Prefixes of the state entries saved in snapshot
+var snapshotStatePrefixes = []scom.DataEntryPrefix{scom.ST_BOOKKEEPER, scom.ST_CONTRACT, scom.ST_STORAGE}
+
+//snapshotMeta describe the ledger saved in snapshot
+type snapshotMeta struct {
+	version       byte
+	height        uint32           //Height of the last block in snapshot
+	blockHash     common.Uint256   //Hash of the last block in snapshot
+	writeSetHash  common.Uint256   //Write set hash of the last block
+	stateRoot     common.Uint256   //State merkle root after the last block
+	stateTreeSize uint32           //Size of state merkle tree
+	stateTree     []common.Uint256 //Hashes of compact state merkle tree
+}
+
+func (this *snapshotMeta) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteByte(this.version)
+	sink.WriteUint32(this.height)
+	sink.WriteHash(this.blockHash)
+	sink.WriteHash(this.writeSetHash)
+	sink.WriteHash(this.stateRoot)
+	sink.WriteUint32(this.stateTreeSize)
+	sink.WriteUint32(uint32(len(this.stateTree)))
+	for _, hash := range this.stateTree {
+		sink.WriteHash(hash)
+	}
+}
+
+func (this *snapshotMeta) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	this.version, eof = source.NextByte()
+	this.height, eof = source.NextUint32()
+	this.blockHash, eof = source.NextHash()
+	this.writeSetHash, eof = source.NextHash()
+	this.stateRoot, eof = source.NextHash()
+	this.stateTreeSize, eof = source.NextUint32()
+	count, eof := source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	this.stateTree = make([]common.Uint256, 0, count)
+	for i := uint32(0); i < count; i++ {
+		hash, eof := source.NextHash()
+		if eof {
+			return io.ErrUnexpectedEOF
+		}
+		this.stateTree = append(this.stateTree, hash)
+	}
+	return nil
+}
+
+//ExportSnapshot write the ledger at current block to w. The snapshot consists of the headers and transaction hashes
+//of all blocks, the full genesis block, config blocks and current block which are needed by consensus, the state
+//merkle tree and all the state entries at current block. The returned checkpoint should be published through a trusted
+//channel, nodes importing the snapshot check it against the checkpoint.
+func (this *LedgerStoreImp) ExportSnapshot(w io.Writer) (*store.SnapshotCheckpoint, error) {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+
+	height, blockHash := this.GetCurrentBlock()
+	meta := &snapshotMeta{
+		version:   SNAPSHOT_VERSION,
+		height:    height,
+		blockHash: blockHash,
+	}
+	if height >= this.stateHashCheckHeight {
+		value, err := this.stateStore.store.Get(this.stateStore.genStateMerkleRootKey(height))
+		if err != nil {
+			return nil, fmt.Errorf("get state merkle root height:%d error %s", height, err)
+		}
+		source := common.NewZeroCopySource(value)
+		meta.writeSetHash, _ = source.NextHash()
+		meta.stateRoot, _ = source.NextHash()
+		meta.stateTreeSize, meta.stateTree, err = this.stateStore.GetStateMerkleTree()
+		if err != nil {
+			return nil, fmt.Errorf("GetStateMerkleTree error %s", err)
+		}
+	}
+	sink := common.NewZeroCopySink(nil)
+	meta.Serialization(sink)
+	err := serialization.WriteVarBytes(w, sink.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	for h := uint32(0); h <= height; h++ {
+		hash := this.getHeaderIndex(h)
+		header, txHashes, err := this.blockStore.loadHeaderWithTx(hash)
+		if err != nil {
+			return nil, fmt.Errorf("loadHeaderWithTx height:%d error %s", h, err)
+		}
+		sink.Reset()
+		full := h == 0 || h == height || isConfigBlock(header)
+		sink.WriteBool(full)
+		if full {
+			block, err := this.blockStore.GetBlock(hash)
+			if err != nil {
+				return nil, fmt.Errorf("GetBlock height:%d error %s", h, err)
+			}
+			err = block.Serialization(sink)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			err = header.Serialization(sink)
+			if err != nil {
+				return nil, err
+			}
+			sink.WriteUint32(uint32(len(txHashes)))
+			for _, txHash := range txHashes {
+				sink.WriteHash(txHash)
+			}
+		}
+		err = serialization.WriteVarBytes(w, sink.Bytes())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stateHash := sha256.New()
+	for _, prefix := range snapshotStatePrefixes {
+		iter := this.stateStore.store.NewIterator([]byte{byte(prefix)})
+		for iter.Next() {
+			key := iter.Key()
+			value := iter.Value()
+			stateHash.Write(key)
+			stateHash.Write(value)
+			err = serialization.WriteVarBytes(w, key)
+			if err == nil {
+				err = serialization.WriteVarBytes(w, value)
+			}
+			if err != nil {
+				iter.Release()
+				return nil, err
+			}
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return nil, err
+		}
+	}
+	//empty key marks the end of state entries
+	err = serialization.WriteVarBytes(w, nil)
+	if err != nil {
+		return nil, err
+	}
+	checkpoint := &store.SnapshotCheckpoint{
+		BlockHash: meta.blockHash,
+		StateRoot: meta.stateRoot,
+	}
+	copy(checkpoint.StateHash[:], stateHash.Sum(nil))
+	err = serialization.WriteVarBytes(w, checkpoint.StateHash[:])
+	if err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+//ImportSnapshot initialize an empty ledger store with the snapshot read from r, instead of replaying all the blocks.
+//Headers are verified from genesis block like block sync does, but nothing in the chain verifies the state entries:
+//the state merkle root of the next block only covers the write set of that block. So the last block hash, the state
+//merkle root and the hash of all state entries must match the checkpoint, which comes from a trusted source such as
+//the export of a node run by the operator.
+func (this *LedgerStoreImp) ImportSnapshot(r io.Reader, genesisBlock *types.Block, checkpoint *store.SnapshotCheckpoint) error {
+	if checkpoint == nil {
+		return fmt.Errorf("missing trusted checkpoint")
+	}
+	hasInit, err := this.hasAlreadyInitGenesisBlock()
+	if err != nil {
+		return fmt.Errorf("hasAlreadyInit error %s", err)
+	}
+	if hasInit || this.stateStore.merkleTree.TreeSize() != 0 {
+		return fmt.Errorf("ledger is not empty")
+	}
+
+	data, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return fmt.Errorf("read snapshot meta error %s", err)
+	}
+	meta := new(snapshotMeta)
+	err = meta.Deserialization(common.NewZeroCopySource(data))
+	if err != nil {
+		return fmt.Errorf("deserialize snapshot meta error %s", err)
+	}
+	if meta.version != SNAPSHOT_VERSION {
+		return fmt.Errorf("unsupported snapshot version %d", meta.version)
+	}
+	err = this.checkSnapshotStateTree(meta, checkpoint)
+	if err != nil {
+		return err
+	}
+
+	err = this.blockStore.ClearAll()
+	if err != nil {
+		return fmt.Errorf("blockStore.ClearAll error %s", err)
+	}
+	err = this.stateStore.ClearAll()
+	if err != nil {
+		return fmt.Errorf("stateStore.ClearAll error %s", err)
+	}
+	err = this.eventStore.ClearAll()
+	if err != nil {
+		return fmt.Errorf("eventStore.ClearAll error %s", err)
+	}
+
+	err = this.importSnapshotBlocks(r, meta, genesisBlock)
+	if err != nil {
+		return err
+	}
+	err = this.importSnapshotStates(r, checkpoint.StateHash)
+	if err != nil {
+		return err
+	}
+
+	this.blockStore.NewBatch()
+	this.stateStore.NewBatch()
+	this.eventStore.NewBatch()
+	if meta.height >= this.stateHashCheckHeight {
+		this.stateStore.deltaMerkleTree = merkle.NewTree(meta.stateTreeSize, meta.stateTree, nil)
+		value := common.NewZeroCopySink(nil)
+		value.WriteUint32(meta.stateTreeSize)
+		for _, hash := range meta.stateTree {
+			value.WriteHash(hash)
+		}
+		this.stateStore.BatchPutRawKeyVal(this.stateStore.genStateMerkleTreeKey(), value.Bytes())
+		value.Reset()
+		value.WriteHash(meta.writeSetHash)
+		value.WriteHash(meta.stateRoot)
+		this.stateStore.BatchPutRawKeyVal(this.stateStore.genStateMerkleRootKey(meta.height), value.Bytes())
+	}
+	err = this.stateStore.SaveCurrentBlock(meta.height, meta.blockHash)
+	if err != nil {
+		return fmt.Errorf("stateStore.SaveCurrentBlock error %s", err)
+	}
+	err = this.eventStore.SaveCurrentBlock(meta.height, meta.blockHash)
+	if err != nil {
+		return fmt.Errorf("eventStore.SaveCurrentBlock error %s", err)
+	}
+	if meta.height > 0 {
+		this.blockStore.SavePrunedHeight(meta.height - 1)
+		this.setPrunedHeight(meta.height - 1)
+	}
+	err = this.blockStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("blockStore.CommitTo error %s", err)
+	}
+	err = this.eventStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("eventStore.CommitTo error %s", err)
+	}
+	err = this.stateStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("stateStore.CommitTo error %s", err)
+	}
+	//save version at last, so that a broken import is cleared when init ledger
+	err = this.initGenesisBlock()
+	if err != nil {
+		return fmt.Errorf("init error %s", err)
+	}
+	log.Infof("ImportSnapshot success. height:%d hash:%s state root:%s", meta.height,
+		meta.blockHash.ToHexString(), meta.stateRoot.ToHexString())
+	return nil
+}
+
+func (this *LedgerStoreImp) checkSnapshotStateTree(meta *snapshotMeta, checkpoint *store.SnapshotCheckpoint) error {
+	if meta.blockHash != checkpoint.BlockHash {
+		return fmt.Errorf("last block hash mismatch with checkpoint, expected:%s, got:%s",
+			checkpoint.BlockHash.ToHexString(), meta.blockHash.ToHexString())
+	}
+	if meta.stateRoot != checkpoint.StateRoot {
+		return fmt.Errorf("state merkle root mismatch with checkpoint, expected:%s, got:%s",
+			checkpoint.StateRoot.ToHexString(), meta.stateRoot.ToHexString())
+	}
+	if meta.height < this.stateHashCheckHeight {
+		if meta.stateTreeSize != 0 || meta.stateRoot != common.UINT256_EMPTY {
+			return fmt.Errorf("unexpected state merkle tree before height %d", this.stateHashCheckHeight)
+		}
+		return nil
+	}
+	if meta.stateTreeSize != meta.height-this.stateHashCheckHeight+1 {
+		return fmt.Errorf("state merkle tree size %d is inconsistent with height %d", meta.stateTreeSize, meta.height)
+	}
+	root := merkle.NewTree(meta.stateTreeSize, meta.stateTree, nil).Root()
+	if root != meta.stateRoot {
+		return fmt.Errorf("state merkle root mismatch, expected:%s, got:%s", root.ToHexString(),
+			meta.stateRoot.ToHexString())
+	}
+	return nil
+}
+
+func (this *LedgerStoreImp) importSnapshotBlocks(r io.Reader, meta *snapshotMeta, genesisBlock *types.Block) error {
+	var vbftPeerInfo map[string]uint32
+	var prevHash common.Uint256
+	this.blockStore.NewBatch()
+	this.stateStore.NewBatch()
+	for h := uint32(0); h <= meta.height; h++ {
+		data, err := serialization.ReadVarBytes(r)
+		if err != nil {
+			return fmt.Errorf("read block height:%d error %s", h, err)
+		}
+		header, txHashes, block, err := parseSnapshotBlock(data)
+		if err != nil {
+			return fmt.Errorf("parse block height:%d error %s", h, err)
+		}
+		if header.Height != h {
+			return fmt.Errorf("block height %d not equal expected height %d", header.Height, h)
+		}
+		blockHash := header.Hash()
+		if h == 0 {
+			genesisHash := genesisBlock.Hash()
+			if blockHash != genesisHash {
+				return fmt.Errorf("genesis block mismatch, expected:%s, got:%s",
+					genesisHash.ToHexString(), blockHash.ToHexString())
+			}
+			vbftPeerInfo, err = genesisVbftPeerInfo(header)
+		} else {
+			vbftPeerInfo, err = this.verifyHeader(header, vbftPeerInfo)
+		}
+		if err != nil {
+			return fmt.Errorf("verifyHeader height:%d error %s", h, err)
+		}
+		txRoot := common.ComputeMerkleRoot(append([]common.Uint256{}, txHashes...))
+		if txRoot != header.TransactionsRoot {
+			return fmt.Errorf("wrong transactions root at height:%d", h)
+		}
+		blockRoot := this.stateStore.GetBlockRootWithNewTxRoots([]common.Uint256{header.TransactionsRoot})
+		if h != 0 && blockRoot != header.BlockRoot {
+			return fmt.Errorf("wrong block root at height:%d, expected:%s, got:%s",
+				h, blockRoot.ToHexString(), header.BlockRoot.ToHexString())
+		}
+		if h == meta.height && blockHash != meta.blockHash {
+			return fmt.Errorf("last block hash mismatch, expected:%s, got:%s",
+				meta.blockHash.ToHexString(), blockHash.ToHexString())
+		}
+		this.addHeaderCache(header)
+		this.delHeaderCache(prevHash)
+		prevHash = blockHash
+
+		if block != nil {
+			err = this.blockStore.SaveBlock(block)
+		} else {
+			err = this.blockStore.SavePrunedBlock(header, txHashes)
+		}
+		if err != nil {
+			return fmt.Errorf("save block height:%d error %s", h, err)
+		}
+		this.blockStore.SaveBlockHash(h, blockHash)
+		this.setHeaderIndex(h, blockHash)
+		this.setCurrentBlock(h, blockHash)
+		err = this.saveHeaderIndexList()
+		if err != nil {
+			return fmt.Errorf("saveHeaderIndexList error %s", err)
+		}
+		err = this.blockStore.SaveCurrentBlock(h, blockHash)
+		if err != nil {
+			return fmt.Errorf("SaveCurrentBlock error %s", err)
+		}
+		err = this.stateStore.AddBlockMerkleTreeRoot(header.TransactionsRoot)
+		if err != nil {
+			return fmt.Errorf("AddBlockMerkleTreeRoot error %s", err)
+		}
+
+		if (h+1)%SNAPSHOT_BATCH_SIZE == 0 || h == meta.height {
+			err = this.blockStore.CommitTo()
+			if err != nil {
+				return fmt.Errorf("blockStore.CommitTo height:%d error %s", h, err)
+			}
+			err = this.stateStore.CommitTo()
+			if err != nil {
+				return fmt.Errorf("stateStore.CommitTo height:%d error %s", h, err)
+			}
+			this.blockStore.NewBatch()
+			this.stateStore.NewBatch()
+		}
+	}
+	this.delHeaderCache(prevHash)
+	return nil
+}
+
+func (this *LedgerStoreImp) importSnapshotStates(r io.Reader, trusted common.Uint256) error {
+	stateHash := sha256.New()
+	count := uint32(0)
+	this.stateStore.NewBatch()
+	for {
+		key, err := serialization.ReadVarBytes(r)
+		if err != nil {
+			return fmt.Errorf("read state key error %s", err)
+		}
+		if len(key) == 0 {
+			break
+		}
+		if !isSnapshotStateKey(key) {
+			return fmt.Errorf("invalid state key %x", key)
+		}
+		value, err := serialization.ReadVarBytes(r)
+		if err != nil {
+			return fmt.Errorf("read state value error %s", err)
+		}
+		stateHash.Write(key)
+		stateHash.Write(value)
+		this.stateStore.BatchPutRawKeyVal(key, value)
+		count++
+		if count%SNAPSHOT_BATCH_SIZE == 0 {
+			err = this.stateStore.CommitTo()
+			if err != nil {
+				return fmt.Errorf("stateStore.CommitTo error %s", err)
+			}
+			this.stateStore.NewBatch()
+		}
+	}
+	expected, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return fmt.Errorf("read state hash error %s", err)
+	}
+	hash := stateHash.Sum(nil)
+	if !bytes.Equal(expected, hash) {
+		return fmt.Errorf("state hash mismatch")
+	}
+	if !bytes.Equal(trusted[:], hash) {
+		return fmt.Errorf("state hash mismatch with checkpoint, expected:%x, got:%x", trusted[:], hash)
+	}
+	return this.stateStore.CommitTo()
+}
+
+func parseSnapshotBlock(data []byte) (*types.Header, []common.Uint256, *types.Block, error) {
+	source := common.NewZeroCopySource(data)
+	full, irregular, eof := source.NextBool()
+	if irregular {
+		return nil, nil, nil, common.ErrIrregularData
+	}
+	if eof {
+		return nil, nil, nil, io.ErrUnexpectedEOF
+	}
+	if full {
+		block := new(types.Block)
+		err := block.Deserialization(source)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		txHashes := make([]common.Uint256, 0, len(block.Transactions))
+		for _, tx := range block.Transactions {
+			txHashes = append(txHashes, tx.Hash())
+		}
+		return block.Header, txHashes, block, nil
+	}
+	header := new(types.Header)
+	err := header.Deserialization(source)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	txSize, eof := source.NextUint32()
+	if eof {
+		return nil, nil, nil, io.ErrUnexpectedEOF
+	}
+	txHashes := make([]common.Uint256, 0, txSize)
+	for i := uint32(0); i < txSize; i++ {
+		txHash, eof := source.NextHash()
+		if eof {
+			return nil, nil, nil, io.ErrUnexpectedEOF
+		}
+		txHashes = append(txHashes, txHash)
+	}
+	if isConfigBlock(header) {
+		return nil, nil, nil, fmt.Errorf("transactions of config block are missing")
+	}
+	return header, txHashes, nil, nil
+}
+
+func genesisVbftPeerInfo(header *types.Header) (map[string]uint32, error) {
+	peerInfo := make(map[string]uint32)
+	if strings.ToLower(config.DefConfig.Genesis.ConsensusType) != config.CONSENSUS_TYPE_VBFT {
+		return peerInfo, nil
+	}
+	blkInfo, err := vconfig.VbftBlock(header)
+	if err != nil {
+		return nil, err
+	}
+	if blkInfo.NewChainConfig == nil {
+		return nil, fmt.Errorf("genesis block has no chain config")
+	}
+	for _, p := range blkInfo.NewChainConfig.Peers {
+		peerInfo[p.ID] = p.Index
+	}
+	return peerInfo, nil
+}
+
+func isSnapshotStateKey(key []byte) bool {
+	for _, prefix := range snapshotStatePrefixes {
+		if key[0] == byte(prefix) {
+			return true
+		}
+	}
+	return false
+}
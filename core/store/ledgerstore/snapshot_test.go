@@ -0,0 +1,181 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/OnyxPay/OnyxChain-crypto/keypair"
+	"github.com/OnyxPay/OnyxChain/account"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/config"
+	"github.com/OnyxPay/OnyxChain/common/serialization"
+	"github.com/OnyxPay/OnyxChain/core/genesis"
+	"github.com/OnyxPay/OnyxChain/core/signature"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBlock(t *testing.T, store *LedgerStoreImp, acc *account.Account, txs ...*types.Transaction) *types.Block {
+	prev, err := store.GetHeaderByHeight(store.GetCurrentBlockHeight())
+	assert.Nil(t, err)
+	height := prev.Height + 1
+	txHashes := make([]common.Uint256, 0, len(txs))
+	for _, tx := range txs {
+		txHashes = append(txHashes, tx.Hash())
+	}
+	txRoot := common.ComputeMerkleRoot(txHashes)
+	header := &types.Header{
+		PrevBlockHash:    prev.Hash(),
+		TransactionsRoot: txRoot,
+		BlockRoot:        store.GetBlockRootWithNewTxRoots(height, []common.Uint256{txRoot}),
+		Timestamp:        prev.Timestamp + 1,
+		Height:           height,
+		NextBookkeeper:   prev.NextBookkeeper,
+	}
+	hash := header.Hash()
+	sig, err := signature.Sign(acc, hash[:])
+	assert.Nil(t, err)
+	header.Bookkeepers = []keypair.PublicKey{acc.PublicKey}
+	header.SigData = [][]byte{sig}
+	return &types.Block{Header: header, Transactions: append([]*types.Transaction{}, txs...)}
+}
+
+func addTestBlock(t *testing.T, store *LedgerStoreImp, block *types.Block) common.Uint256 {
+	result, err := store.ExecuteBlock(block)
+	assert.Nil(t, err)
+	assert.Nil(t, store.SubmitBlock(block, result))
+	return result.MerkleRoot
+}
+
+//setSoloGenesis switch the genesis config to solo consensus with acc as bookkeeper, the returned func restores it
+func setSoloGenesis(t *testing.T, acc *account.Account) (*types.Block, func()) {
+	genesisConfig := *config.DefConfig.Genesis
+	config.DefConfig.Genesis.ConsensusType = config.CONSENSUS_TYPE_SOLO
+	config.DefConfig.Genesis.SOLO = &config.SOLOConfig{
+		Bookkeepers: []string{hex.EncodeToString(keypair.SerializePublicKey(acc.PublicKey))},
+	}
+	genesisBlock, err := genesis.BuildGenesisBlock([]keypair.PublicKey{acc.PublicKey}, config.DefConfig.Genesis)
+	assert.Nil(t, err)
+	return genesisBlock, func() {
+		*config.DefConfig.Genesis = genesisConfig
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	acc := account.NewAccount("")
+	genesisBlock, restore := setSoloGenesis(t, acc)
+	defer restore()
+	bookkeepers := []keypair.PublicKey{acc.PublicKey}
+
+	storeA, err := NewLedgerStore("test/snapshotA", 0)
+	assert.Nil(t, err)
+	defer storeA.Close()
+	assert.Nil(t, storeA.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+	for i := 0; i < 3; i++ {
+		addTestBlock(t, storeA, newTestBlock(t, storeA, acc))
+	}
+
+	buf := bytes.NewBuffer(nil)
+	checkpoint, err := storeA.ExportSnapshot(buf)
+	assert.Nil(t, err)
+	snapshot := buf.Bytes()
+	rootA, err := storeA.GetStateMerkleRoot(3)
+	assert.Nil(t, err)
+	assert.Equal(t, storeA.GetCurrentBlockHash(), checkpoint.BlockHash)
+	assert.Equal(t, rootA, checkpoint.StateRoot)
+
+	//snapshot not matching the trusted checkpoint is rejected before writing the ledger
+	storeC, err := NewLedgerStore("test/snapshotC", 0)
+	assert.Nil(t, err)
+	defer storeC.Close()
+	assert.NotNil(t, storeC.ImportSnapshot(bytes.NewReader(snapshot), genesisBlock, nil))
+	wrong := *checkpoint
+	wrong.BlockHash = common.UINT256_EMPTY
+	assert.NotNil(t, storeC.ImportSnapshot(bytes.NewReader(snapshot), genesisBlock, &wrong))
+	wrong = *checkpoint
+	wrong.StateRoot = common.UINT256_EMPTY
+	assert.NotNil(t, storeC.ImportSnapshot(bytes.NewReader(snapshot), genesisBlock, &wrong))
+	//an altered state entry with a matching trailing hash is rejected by the trusted state hash
+	err = storeC.ImportSnapshot(bytes.NewReader(tamperSnapshotState(t, snapshot, 3)), genesisBlock, checkpoint)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "state hash mismatch with checkpoint")
+
+	storeB, err := NewLedgerStore("test/snapshotB", 0)
+	assert.Nil(t, err)
+	defer storeB.Close()
+	//snapshot of unknown version is rejected before writing the ledger
+	broken := append([]byte{}, snapshot...)
+	broken[1] = 0xff
+	assert.NotNil(t, storeB.ImportSnapshot(bytes.NewReader(broken), genesisBlock, checkpoint))
+	assert.Nil(t, storeB.ImportSnapshot(bytes.NewReader(snapshot), genesisBlock, checkpoint))
+	assert.NotNil(t, storeB.ImportSnapshot(bytes.NewReader(snapshot), genesisBlock, checkpoint))
+	assert.Nil(t, storeB.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+
+	assert.Equal(t, uint32(3), storeB.GetCurrentBlockHeight())
+	assert.Equal(t, storeA.GetCurrentBlockHash(), storeB.GetCurrentBlockHash())
+	assert.Equal(t, uint32(2), storeB.GetPrunedHeight())
+	rootB, err := storeB.GetStateMerkleRoot(3)
+	assert.Nil(t, err)
+	assert.Equal(t, rootA, rootB)
+	header, err := storeB.GetHeaderByHeight(1)
+	assert.Nil(t, err)
+	assert.Equal(t, storeA.GetBlockHash(1), header.Hash())
+
+	//the next block has the same state merkle root on both ledgers
+	block := newTestBlock(t, storeA, acc)
+	rootA = addTestBlock(t, storeA, block)
+	rootB = addTestBlock(t, storeB, block)
+	assert.Equal(t, rootA, rootB)
+	assert.Equal(t, storeA.GetCurrentBlockHash(), storeB.GetCurrentBlockHash())
+}
+
+//tamperSnapshotState alter the value of the first state entry in snapshot and recalculate the trailing state hash
+func tamperSnapshotState(t *testing.T, snapshot []byte, height uint32) []byte {
+	r := bytes.NewReader(snapshot)
+	w := bytes.NewBuffer(nil)
+	//meta and blocks are copied as is
+	for i := uint32(0); i < height+2; i++ {
+		data, err := serialization.ReadVarBytes(r)
+		assert.Nil(t, err)
+		assert.Nil(t, serialization.WriteVarBytes(w, data))
+	}
+	stateHash := sha256.New()
+	for first := true; ; first = false {
+		key, err := serialization.ReadVarBytes(r)
+		assert.Nil(t, err)
+		assert.Nil(t, serialization.WriteVarBytes(w, key))
+		if len(key) == 0 {
+			break
+		}
+		value, err := serialization.ReadVarBytes(r)
+		assert.Nil(t, err)
+		if first {
+			value = append(value, 0xff)
+		}
+		stateHash.Write(key)
+		stateHash.Write(value)
+		assert.Nil(t, serialization.WriteVarBytes(w, value))
+	}
+	assert.Nil(t, serialization.WriteVarBytes(w, stateHash.Sum(nil)))
+	return w.Bytes()
+}
@@ -26,6 +26,7 @@ import (
 	"io"
 
 	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/config"
 	"github.com/OnyxPay/OnyxChain/common/log"
 	"github.com/OnyxPay/OnyxChain/common/serialization"
 	"github.com/OnyxPay/OnyxChain/core/payload"
@@ -50,11 +51,15 @@ type StateStore struct {
 	merkleTree           *merkle.CompactMerkleTree //Merkle tree of block root
 	deltaMerkleTree      *merkle.CompactMerkleTree //Merkle tree of delta state root
 	merkleHashStore      merkle.HashStore
+	stateMerklePath      string           //Merkle tree store path of delta state root
+	stateMerkleHashStore merkle.HashStore //Hash store of delta state merkle tree, used to generate state proof
 	stateHashCheckHeight uint32
+	archive              bool   //Whether keep the state history of every block
+	archiveHeight        uint32 //Height of the first block whose state history is kept
 }
 
 //NewStateStore return state store instance
-func NewStateStore(dbDir, merklePath string, stateHashCheckHeight uint32) (*StateStore, error) {
+func NewStateStore(dbDir, merklePath, stateMerklePath string, stateHashCheckHeight uint32) (*StateStore, error) {
 	var err error
 	store, err := leveldbstore.NewLevelDBStore(dbDir)
 	if err != nil {
@@ -64,6 +69,7 @@ func NewStateStore(dbDir, merklePath string, stateHashCheckHeight uint32) (*Stat
 		dbDir:                dbDir,
 		store:                store,
 		merklePath:           merklePath,
+		stateMerklePath:      stateMerklePath,
 		stateHashCheckHeight: stateHashCheckHeight,
 	}
 	_, height, err := stateStore.GetCurrentBlock()
@@ -117,7 +123,9 @@ func (self *StateStore) init(currBlockHeight uint32) error {
 	}
 	self.merkleTree = merkle.NewTree(treeSize, hashes, self.merkleHashStore)
 
-	if currBlockHeight >= self.stateHashCheckHeight {
+	stateTreeSize := uint32(0)
+	//light node does not execute blocks, so the state merkle tree stops at genesis block
+	if currBlockHeight >= self.stateHashCheckHeight && !config.DefConfig.Common.LightNode {
 		treeSize, hashes, err := self.GetStateMerkleTree()
 		if err != nil && err != scom.ErrNotFound {
 			return err
@@ -125,8 +133,16 @@ func (self *StateStore) init(currBlockHeight uint32) error {
 		if treeSize > 0 && treeSize != currBlockHeight-self.stateHashCheckHeight+1 {
 			return fmt.Errorf("merkle tree size is inconsistent with blockheight: %d", currBlockHeight+1)
 		}
+		stateTreeSize = treeSize
 		self.deltaMerkleTree = merkle.NewTree(treeSize, hashes, nil)
 	}
+	self.stateMerkleHashStore, err = merkle.NewFileHashStore(self.stateMerklePath, stateTreeSize)
+	if err != nil {
+		log.Warn("state merkle store is inconsistent with StateStore. state proof will be disabled")
+		self.stateMerkleHashStore = nil
+	} else if self.deltaMerkleTree != nil {
+		self.deltaMerkleTree = merkle.NewTree(stateTreeSize, self.deltaMerkleTree.Hashes(), self.stateMerkleHashStore)
+	}
 	return nil
 }
 
@@ -175,8 +191,11 @@ func (self *StateStore) GetStateMerkleRoot(height uint32) (result common.Uint256
 		return
 	}
 	source := common.NewZeroCopySource(value)
-	_, eof := source.NextHash()
-	result, eof = source.NextHash()
+	//only state merkle root is kept for pruned block
+	if source.Len() > common.UINT256_SIZE {
+		source.NextHash()
+	}
+	result, eof := source.NextHash()
 	if eof {
 		err = io.ErrUnexpectedEOF
 	}
@@ -187,7 +206,7 @@ func (self *StateStore) AddStateMerkleTreeRoot(blockHeight uint32, writeSetHash
 	if blockHeight < self.stateHashCheckHeight {
 		return nil
 	} else if blockHeight == self.stateHashCheckHeight {
-		self.deltaMerkleTree = merkle.NewTree(0, nil, nil)
+		self.deltaMerkleTree = merkle.NewTree(0, nil, self.stateMerkleHashStore)
 	}
 	key := self.genStateMerkleTreeKey()
 
@@ -315,6 +334,210 @@ func (self *StateStore) GetStorageState(key *states.StorageKey) (*states.Storage
 	return storageState, nil
 }
 
+//GetStorageStateByHeight return the storage value of the key in smart contract after the block of height was executed.
+func (self *StateStore) GetStorageStateByHeight(key *states.StorageKey, height uint32) (*states.StorageItem, error) {
+	storeKey, err := self.getStorageKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := self.getStateByHeight(storeKey, height)
+	if err != nil {
+		return nil, err
+	}
+	reader := bytes.NewReader(data)
+	storageState := new(states.StorageItem)
+	err = storageState.Deserialize(reader)
+	if err != nil {
+		return nil, err
+	}
+	return storageState, nil
+}
+
+//EnableArchive start keeping the state history of blocks. The history is kept from the next block to be saved,
+//or from the first archived block if archive mode has been enabled before.
+func (self *StateStore) EnableArchive() error {
+	key := self.getArchiveHeightKey()
+	data, err := self.store.Get(key)
+	if err == nil {
+		source := common.NewZeroCopySource(data)
+		height, eof := source.NextUint32()
+		if eof {
+			return io.ErrUnexpectedEOF
+		}
+		self.archive = true
+		self.archiveHeight = height
+		return nil
+	}
+	if err != scom.ErrNotFound {
+		return err
+	}
+
+	height := uint32(0)
+	_, currHeight, err := self.GetCurrentBlock()
+	if err == nil {
+		height = currHeight + 1
+	} else if err != scom.ErrNotFound {
+		return err
+	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(height)
+	err = self.store.Put(key, sink.Bytes())
+	if err != nil {
+		return err
+	}
+	self.archive = true
+	self.archiveHeight = height
+	return nil
+}
+
+//DisableArchive stop keeping the state history. The history saved before can not be queried any more,
+//since blocks saved from now on leave gaps in it.
+func (self *StateStore) DisableArchive() error {
+	self.archive = false
+	key := self.getArchiveHeightKey()
+	has, err := self.store.Has(key)
+	if err != nil || !has {
+		return err
+	}
+	return self.store.Delete(key)
+}
+
+//AddStateHistory save the value before the block of height of every key in write set, so that the
+//state of any archived height can be recovered. It should be called before the write set is put to batch.
+func (self *StateStore) AddStateHistory(height uint32, writeSet *overlaydb.MemDB) error {
+	if !self.archive {
+		return nil
+	}
+	var err error
+	keys := common.NewZeroCopySink(nil)
+	count := uint32(0)
+	writeSet.ForEach(func(key, val []byte) {
+		if err != nil {
+			return
+		}
+		prev, e := self.store.Get(key)
+		if e != nil && e != scom.ErrNotFound {
+			err = e
+			return
+		}
+		//empty value means the key does not exist before the block
+		self.store.BatchPut(self.genStateHistoryKey(key, height), prev)
+		keys.WriteVarBytes(key)
+		count++
+	})
+	if err != nil {
+		return err
+	}
+	value := common.NewZeroCopySink(make([]byte, 0, 4+len(keys.Bytes())))
+	value.WriteUint32(count)
+	value.WriteBytes(keys.Bytes())
+	self.store.BatchPut(self.genStateHistoryKeysKey(height), value.Bytes())
+	return nil
+}
+
+//PruneStateHistory remove the state history saved by the block of height. The state of height and lower
+//can not be queried any more after the batch is committed and SetPrunedHeight is called.
+func (self *StateStore) PruneStateHistory(height uint32) error {
+	if !self.archive || height < self.archiveHeight {
+		return nil
+	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(height + 1)
+	self.store.BatchPut(self.getArchiveHeightKey(), sink.Bytes())
+
+	key := self.genStateHistoryKeysKey(height)
+	data, err := self.store.Get(key)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	source := common.NewZeroCopySource(data)
+	count, eof := source.NextUint32()
+	for i := uint32(0); i < count && !eof; i++ {
+		var stateKey []byte
+		stateKey, _, _, eof = source.NextVarBytes()
+		if !eof {
+			self.store.BatchDelete(self.genStateHistoryKey(stateKey, height))
+		}
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	self.store.BatchDelete(key)
+	return nil
+}
+
+//SetPrunedHeight move the first archived height past the pruned blocks, it should be called after the
+//pruned state history is committed
+func (self *StateStore) SetPrunedHeight(height uint32) {
+	if self.archive && height >= self.archiveHeight {
+		self.archiveHeight = height + 1
+	}
+}
+
+//PruneStateMerkleRoot remove the write set hash saved by the block of height, only the state merkle root
+//is kept. The merkle hash stores are not pruned, since GetMerkleProof and state proofs are built from them.
+func (self *StateStore) PruneStateMerkleRoot(height uint32) error {
+	if height < self.stateHashCheckHeight {
+		return nil
+	}
+	key := self.genStateMerkleRootKey(height)
+	value, err := self.store.Get(key)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	if len(value) > common.UINT256_SIZE {
+		self.store.BatchPut(key, value[len(value)-common.UINT256_SIZE:])
+	}
+	return nil
+}
+
+//isArchived return whether the state after the block of height can be recovered from state history
+func (self *StateStore) isArchived(height uint32) bool {
+	return self.archive && uint64(height)+1 >= uint64(self.archiveHeight)
+}
+
+//getStateByHeight return the value of raw state key after the block of height was executed
+func (self *StateStore) getStateByHeight(key []byte, height uint32) ([]byte, error) {
+	if !self.isArchived(height) {
+		return nil, scom.ErrNotArchived
+	}
+	// read current value before iterating history, if a block is committed in between, its history
+	// entry holds exactly the value read here.
+	value, err := self.store.Get(key)
+	if err != nil && err != scom.ErrNotFound {
+		return nil, err
+	}
+
+	prefix := self.genStateHistoryPrefix(key)
+	iter := self.store.NewIterator(prefix)
+	for iter.Next() {
+		k := iter.Key()
+		if len(k) != len(prefix)+4 {
+			continue
+		}
+		// the first change after height holds the value at height
+		if binary.BigEndian.Uint32(k[len(prefix):]) > height {
+			value = append([]byte{}, iter.Value()...)
+			break
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	if len(value) == 0 {
+		return nil, scom.ErrNotFound
+	}
+	return value, nil
+}
+
 //GetCurrentBlock return current block height and current hash in state store
 func (self *StateStore) GetCurrentBlock() (common.Uint256, uint32, error) {
 	key := self.getCurrentBlockKey()
@@ -349,6 +572,32 @@ func (self *StateStore) getCurrentBlockKey() []byte {
 	return []byte{byte(scom.SYS_CURRENT_BLOCK)}
 }
 
+func (self *StateStore) getArchiveHeightKey() []byte {
+	return []byte{byte(scom.SYS_ARCHIVE_HEIGHT)}
+}
+
+func (self *StateStore) genStateHistoryPrefix(key []byte) []byte {
+	sink := common.NewZeroCopySink(make([]byte, 0, 1+9+len(key)+4))
+	sink.WriteByte(byte(scom.DATA_STATE_HISTORY))
+	sink.WriteVarBytes(key)
+	return sink.Bytes()
+}
+
+func (self *StateStore) genStateHistoryKeysKey(height uint32) []byte {
+	key := make([]byte, 5, 5)
+	key[0] = byte(scom.DATA_STATE_HISTORY_KEYS)
+	binary.LittleEndian.PutUint32(key[1:], height)
+	return key
+}
+
+// height is encoded in big endian so that the history of a key is iterated in the order of height
+func (self *StateStore) genStateHistoryKey(key []byte, height uint32) []byte {
+	prefix := self.genStateHistoryPrefix(key)
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], height)
+	return append(prefix, buf[:]...)
+}
+
 func (self *StateStore) getBookkeeperKey() ([]byte, error) {
 	key := make([]byte, 1+len(BOOKKEEPER))
 	key[0] = byte(scom.ST_BOOKKEEPER)
@@ -413,6 +662,9 @@ func (self *StateStore) ClearAll() error {
 //Close state store
 func (self *StateStore) Close() error {
 	self.merkleHashStore.Close()
+	if self.stateMerkleHashStore != nil {
+		self.stateMerkleHashStore.Close()
+	}
 	return self.store.Close()
 }
 
@@ -19,12 +19,14 @@
 package ledgerstore
 
 import (
+	"bytes"
 	"math/rand"
 	"testing"
 
-
 	"github.com/OnyxPay/OnyxChain/common"
-
+	"github.com/OnyxPay/OnyxChain/core/states"
+	scom "github.com/OnyxPay/OnyxChain/core/store/common"
+	"github.com/OnyxPay/OnyxChain/core/store/overlaydb"
 	"github.com/OnyxPay/OnyxChain/merkle"
 	"github.com/stretchr/testify/assert"
 )
@@ -73,3 +75,202 @@ func TestStateMerkleRoot(t *testing.T) {
 	}
 
 }
+
+// saveStorageBlock save a block which writes storage of key to value, or deletes it if value is empty
+func saveStorageBlock(t *testing.T, db *StateStore, height uint32, key *states.StorageKey, value []byte) {
+	storeKey, _ := db.getStorageKey(key)
+	writeSet := overlaydb.NewMemDB(0, 0)
+	if len(value) == 0 {
+		writeSet.Delete(storeKey)
+	} else {
+		buf := bytes.NewBuffer(nil)
+		item := &states.StorageItem{Value: value}
+		item.Serialize(buf)
+		writeSet.Put(storeKey, buf.Bytes())
+	}
+	db.NewBatch()
+	err := db.AddStateHistory(height, writeSet)
+	assert.Nil(t, err)
+	writeSet.ForEach(func(key, val []byte) {
+		if len(val) == 0 {
+			db.BatchDeleteRawKey(key)
+		} else {
+			db.BatchPutRawKeyVal(key, val)
+		}
+	})
+	db.SaveCurrentBlock(height, common.Uint256{})
+	err = db.CommitTo()
+	assert.Nil(t, err)
+}
+
+func TestStateHistory(t *testing.T) {
+	db := NewMemStateStore(0)
+	err := db.EnableArchive()
+	assert.Nil(t, err)
+
+	key := &states.StorageKey{ContractAddress: common.ADDRESS_EMPTY, Key: []byte("key")}
+	other := &states.StorageKey{ContractAddress: common.ADDRESS_EMPTY, Key: []byte("key1")}
+	saveStorageBlock(t, db, 0, key, []byte("v0"))
+	saveStorageBlock(t, db, 1, key, []byte("v1"))
+	saveStorageBlock(t, db, 2, other, []byte("o2"))
+	saveStorageBlock(t, db, 3, key, nil)
+	saveStorageBlock(t, db, 4, key, []byte("v4"))
+
+	expected := []string{"v0", "v1", "v1", "", "v4"}
+	for height, value := range expected {
+		item, err := db.GetStorageStateByHeight(key, uint32(height))
+		if value == "" {
+			assert.Equal(t, scom.ErrNotFound, err)
+			continue
+		}
+		assert.Nil(t, err)
+		assert.Equal(t, value, string(item.Value))
+	}
+	_, err = db.GetStorageStateByHeight(other, 1)
+	assert.Equal(t, scom.ErrNotFound, err)
+	item, err := db.GetStorageStateByHeight(other, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, "o2", string(item.Value))
+}
+
+func TestHistoryStore(t *testing.T) {
+	db := NewMemStateStore(0)
+	err := db.EnableArchive()
+	assert.Nil(t, err)
+
+	key := &states.StorageKey{ContractAddress: common.ADDRESS_EMPTY, Key: []byte("key")}
+	other := &states.StorageKey{ContractAddress: common.ADDRESS_EMPTY, Key: []byte("key1")}
+	saveStorageBlock(t, db, 0, key, []byte("v0"))
+	saveStorageBlock(t, db, 1, key, []byte("v1"))
+	saveStorageBlock(t, db, 2, other, []byte("o2"))
+	saveStorageBlock(t, db, 3, key, []byte("v3"))
+
+	storeKey, _ := db.getStorageKey(key)
+	otherKey, _ := db.getStorageKey(other)
+	for height, keys := range [][][]byte{{storeKey}, {storeKey}, {storeKey, otherKey}} {
+		store := newHistoryStore(db, uint32(height))
+		iter := store.NewIterator([]byte{byte(scom.ST_STORAGE)})
+		count := 0
+		for iter.Next() {
+			assert.Equal(t, keys[count], iter.Key())
+			value, err := db.getStateByHeight(iter.Key(), uint32(height))
+			assert.Nil(t, err)
+			assert.Equal(t, value, iter.Value())
+			count++
+		}
+		iter.Release()
+		assert.Nil(t, iter.Error())
+		assert.Equal(t, len(keys), count)
+	}
+
+	store := newHistoryStore(db, 1)
+	has, err := store.Has(otherKey)
+	assert.Nil(t, err)
+	assert.False(t, has)
+	value, err := store.Get(storeKey)
+	assert.Nil(t, err)
+	item := new(states.StorageItem)
+	err = item.Deserialize(bytes.NewReader(value))
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", string(item.Value))
+	assert.NotNil(t, store.Put(storeKey, value))
+}
+
+func TestStateHistoryEnabledLater(t *testing.T) {
+	db := NewMemStateStore(0)
+	key := &states.StorageKey{ContractAddress: common.ADDRESS_EMPTY, Key: []byte("key")}
+	saveStorageBlock(t, db, 0, key, []byte("v0"))
+	saveStorageBlock(t, db, 1, key, []byte("v1"))
+
+	_, err := db.GetStorageStateByHeight(key, 1)
+	assert.Equal(t, scom.ErrNotArchived, err)
+
+	err = db.EnableArchive()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(2), db.archiveHeight)
+	saveStorageBlock(t, db, 2, key, []byte("v2"))
+	saveStorageBlock(t, db, 3, key, []byte("v3"))
+
+	_, err = db.GetStorageStateByHeight(key, 0)
+	assert.Equal(t, scom.ErrNotArchived, err)
+	for height, value := range []string{"v1", "v2", "v3"} {
+		item, err := db.GetStorageStateByHeight(key, uint32(height+1))
+		assert.Nil(t, err)
+		assert.Equal(t, value, string(item.Value))
+	}
+
+	// archive height is kept when enabled again
+	err = db.EnableArchive()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(2), db.archiveHeight)
+
+	err = db.DisableArchive()
+	assert.Nil(t, err)
+	_, err = db.GetStorageStateByHeight(key, 2)
+	assert.Equal(t, scom.ErrNotArchived, err)
+	err = db.EnableArchive()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(4), db.archiveHeight)
+}
+
+func TestPruneStateHistory(t *testing.T) {
+	db := NewMemStateStore(0)
+	err := db.EnableArchive()
+	assert.Nil(t, err)
+
+	key := &states.StorageKey{ContractAddress: common.ADDRESS_EMPTY, Key: []byte("key")}
+	for height, value := range []string{"v0", "v1", "v2", "v3"} {
+		saveStorageBlock(t, db, uint32(height), key, []byte(value))
+	}
+
+	db.NewBatch()
+	for height := uint32(0); height <= 1; height++ {
+		err = db.PruneStateHistory(height)
+		assert.Nil(t, err)
+	}
+	err = db.CommitTo()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0), db.archiveHeight)
+	db.SetPrunedHeight(1)
+	assert.Equal(t, uint32(2), db.archiveHeight)
+
+	_, err = db.GetStorageStateByHeight(key, 0)
+	assert.Equal(t, scom.ErrNotArchived, err)
+	for height, value := range []string{"v1", "v2", "v3"} {
+		item, err := db.GetStorageStateByHeight(key, uint32(height+1))
+		assert.Nil(t, err)
+		assert.Equal(t, value, string(item.Value))
+	}
+	for height := uint32(0); height <= 1; height++ {
+		storeKey, _ := db.getStorageKey(key)
+		has, err := db.store.Has(db.genStateHistoryKey(storeKey, height))
+		assert.Nil(t, err)
+		assert.False(t, has)
+	}
+}
+
+func TestPruneStateMerkleRoot(t *testing.T) {
+	db := NewMemStateStore(0)
+	db.NewBatch()
+	for height := uint32(0); height < 3; height++ {
+		err := db.AddStateMerkleTreeRoot(height, common.Uint256{byte(height + 1)})
+		assert.Nil(t, err)
+	}
+	err := db.CommitTo()
+	assert.Nil(t, err)
+	root, err := db.GetStateMerkleRoot(1)
+	assert.Nil(t, err)
+
+	db.NewBatch()
+	err = db.PruneStateMerkleRoot(1)
+	assert.Nil(t, err)
+	err = db.CommitTo()
+	assert.Nil(t, err)
+
+	value, err := db.store.Get(db.genStateMerkleRootKey(1))
+	assert.Nil(t, err)
+	assert.Equal(t, common.UINT256_SIZE, len(value))
+	pruned, err := db.GetStateMerkleRoot(1)
+	assert.Nil(t, err)
+	assert.Equal(t, root, pruned)
+}
@@ -41,7 +41,9 @@ import (
 	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/onx"
 	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
 	"github.com/OnyxPay/OnyxChain/smartcontract/service/neovm"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/wasmvm"
 	"github.com/OnyxPay/OnyxChain/smartcontract/storage"
+	vm "github.com/OnyxPay/OnyxChain/vm/neovm"
 )
 
 //HandleDeployTransaction deal with smart contract deploy transaction
@@ -97,6 +99,14 @@ func (self *StateStore) HandleDeployTransaction(store store.LedgerStore, overlay
 		cache.Commit()
 	}
 
+	if deploy.VmType == payload.WASMVM_TYPE {
+		if err := wasmvm.VerifyContractCode(deploy.Code); err != nil {
+			notify.Notify = append(notify.Notify, notifies...)
+			notify.GasConsumed = gasConsumed
+			return err
+		}
+	}
+
 	address := deploy.Address()
 	log.Infof("deploy contract address:%s", address.ToHexString())
 	// store contract message
@@ -115,9 +125,9 @@ func (self *StateStore) HandleDeployTransaction(store store.LedgerStore, overlay
 	return nil
 }
 
-//HandleInvokeTransaction deal with smart contract invoke transaction
+//HandleInvokeTransaction deal with smart contract invoke transaction, tracer is optional and records the neovm execution steps
 func (self *StateStore) HandleInvokeTransaction(store store.LedgerStore, overlay *overlaydb.OverlayDB, cache *storage.CacheDB,
-	tx *types.Transaction, block *types.Block, notify *event.ExecuteNotify) error {
+	tx *types.Transaction, block *types.Block, notify *event.ExecuteNotify, tracer vm.Tracer) error {
 	invoke := tx.Payload.(*payload.InvokeCode)
 	code := invoke.Code
 	sysTransFlag := bytes.Compare(code, ninit.COMMIT_DPOS_BYTES) == 0 || block.Header.Height == 0
@@ -193,6 +203,7 @@ func (self *StateStore) HandleInvokeTransaction(store store.LedgerStore, overlay
 		CacheDB: cache,
 		Store:   store,
 		Gas:     availableGasLimit - codeLenGasLimit,
+		Tracer:  tracer,
 	}
 
 	//start the smart contract executive function
@@ -19,14 +19,18 @@
 package store
 
 import (
+	"io"
+
 	"github.com/OnyxPay/OnyxChain-crypto/keypair"
 	"github.com/OnyxPay/OnyxChain/common"
 	"github.com/OnyxPay/OnyxChain/core/payload"
 	"github.com/OnyxPay/OnyxChain/core/states"
+	scom "github.com/OnyxPay/OnyxChain/core/store/common"
 	"github.com/OnyxPay/OnyxChain/core/store/overlaydb"
 	"github.com/OnyxPay/OnyxChain/core/types"
 	"github.com/OnyxPay/OnyxChain/smartcontract/event"
 	cstates "github.com/OnyxPay/OnyxChain/smartcontract/states"
+	vm "github.com/OnyxPay/OnyxChain/vm/neovm"
 )
 
 type ExecuteResult struct {
@@ -36,6 +40,13 @@ type ExecuteResult struct {
 	Notify     []*event.ExecuteNotify
 }
 
+// SnapshotCheckpoint is the ledger state trusted by the operator, which a snapshot must match when importing
+type SnapshotCheckpoint struct {
+	BlockHash common.Uint256 //Hash of the last block in snapshot
+	StateRoot common.Uint256 //State merkle root after the last block
+	StateHash common.Uint256 //Hash of all the state entries in snapshot
+}
+
 // LedgerStore provides func with store package.
 type LedgerStore interface {
 	InitLedgerStoreWithGenesisBlock(genesisblock *types.Block, defaultBookkeeper []keypair.PublicKey) error
@@ -59,10 +70,19 @@ type LedgerStore interface {
 	IsContainTransaction(txHash common.Uint256) (bool, error)
 	GetBlockRootWithNewTxRoots(startHeight uint32, txRoots []common.Uint256) common.Uint256
 	GetMerkleProof(m, n uint32) ([]common.Uint256, error)
+	GetTransactionProof(txHash common.Uint256, rootHeight uint32) (*types.TxProof, error)
+	GetStorageProof(key []byte, rootHeight uint32) (*types.StorageProof, error)
 	GetContractState(contractHash common.Address) (*payload.DeployCode, error)
 	GetBookkeeperState() (*states.BookkeeperState, error)
 	GetStorageItem(key *states.StorageKey) (*states.StorageItem, error)
+	GetStorageItemByHeight(key *states.StorageKey, height uint32) (*states.StorageItem, error)
 	PreExecuteContract(tx *types.Transaction) (*cstates.PreExecResult, error)
+	PreExecuteContractWithTracer(tx *types.Transaction, tracer vm.Tracer) (*cstates.PreExecResult, error)
+	TraceTransaction(txHash common.Uint256, tracer vm.Tracer) (*cstates.TraceResult, error)
 	GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error)
 	GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNotify, error)
+	GetTxsByAddress(addr common.Address, startHeight, endHeight, offset, limit uint32) ([]*scom.TxIndexItem, error)
+	GetTxsByContract(contract common.Address, startHeight, endHeight, offset, limit uint32) ([]*scom.TxIndexItem, error)
+	ExportSnapshot(w io.Writer) (*SnapshotCheckpoint, error)
+	ImportSnapshot(r io.Reader, genesisBlock *types.Block, checkpoint *SnapshotCheckpoint) error
 }
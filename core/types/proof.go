@@ -0,0 +1,237 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/merkle"
+)
+
+//TxProof prove a transaction is included in the block of Height, and the block is included in the block root of
+//the block of RootHeight
+type TxProof struct {
+	Tx         *Transaction
+	Height     uint32           //Height of the block holding the transaction
+	TxIndex    uint32           //Index of the transaction in the block
+	TxPath     []common.Uint256 //Merkle path from the transaction hash to the transactions root of the block
+	RootHeight uint32           //Height of the block whose block root proves the block
+	BlockPath  []common.Uint256 //Merkle path from the transactions root of the block to the block root
+}
+
+func (this *TxProof) Serialization(sink *common.ZeroCopySink) error {
+	err := this.Tx.Serialization(sink)
+	if err != nil {
+		return err
+	}
+	sink.WriteUint32(this.Height)
+	sink.WriteUint32(this.TxIndex)
+	serializeHashes(sink, this.TxPath)
+	sink.WriteUint32(this.RootHeight)
+	serializeHashes(sink, this.BlockPath)
+	return nil
+}
+
+func (this *TxProof) Deserialization(source *common.ZeroCopySource) error {
+	this.Tx = &Transaction{}
+	err := this.Tx.Deserialization(source)
+	if err != nil {
+		return err
+	}
+	var eof bool
+	this.Height, eof = source.NextUint32()
+	this.TxIndex, eof = source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	this.TxPath, err = deserializeHashes(source)
+	if err != nil {
+		return err
+	}
+	this.RootHeight, eof = source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	this.BlockPath, err = deserializeHashes(source)
+	return err
+}
+
+//Verify check the proof against the header of the block holding the transaction and the header of RootHeight,
+//both headers should have been verified by the caller
+func (this *TxProof) Verify(header, rootHeader *Header) error {
+	if header.Height != this.Height || rootHeader.Height != this.RootHeight {
+		return fmt.Errorf("header height mismatch")
+	}
+	if this.Height > this.RootHeight {
+		return fmt.Errorf("block height %d is higher than root height %d", this.Height, this.RootHeight)
+	}
+	txRoot := common.ComputeMerkleRootWithProof(this.Tx.Hash(), this.TxIndex, this.TxPath)
+	if txRoot != header.TransactionsRoot {
+		return fmt.Errorf("transactions root mismatch, expected:%s, got:%s",
+			header.TransactionsRoot.ToHexString(), txRoot.ToHexString())
+	}
+	return merkle.NewMerkleVerifier().VerifyLeafHashInclusion(header.TransactionsRoot, this.Height, this.BlockPath,
+		rootHeader.BlockRoot, this.RootHeight+1)
+}
+
+//StateItem is a raw state key and its value, empty value means the key is deleted
+type StateItem struct {
+	Key   []byte
+	Value []byte
+}
+
+//StorageProof prove the value of a state key was written by the block of Height. The write set of the block is
+//hashed into a leaf of the state merkle tree, whose root at RootHeight is StateRoot. State roots are not committed
+//in block headers, so the caller should confirm StateRoot by other means, and the proof can not show the key is
+//not changed after Height.
+type StorageProof struct {
+	Key        []byte           //Raw state key
+	Value      []byte           //Value of the key after the block of Height, empty if the key is deleted
+	Height     uint32           //Height of the last block changing the key before or at RootHeight
+	WriteSet   []*StateItem     //Write set of the block of Height in key order
+	RootHeight uint32           //Height of the state merkle root
+	StateRoot  common.Uint256   //State merkle root after the block of RootHeight
+	StatePath  []common.Uint256 //Merkle path from the write set hash to the state merkle root
+}
+
+func (this *StorageProof) Serialization(sink *common.ZeroCopySink) error {
+	sink.WriteVarBytes(this.Key)
+	sink.WriteVarBytes(this.Value)
+	sink.WriteUint32(this.Height)
+	sink.WriteVarUint(uint64(len(this.WriteSet)))
+	for _, item := range this.WriteSet {
+		sink.WriteVarBytes(item.Key)
+		sink.WriteVarBytes(item.Value)
+	}
+	sink.WriteUint32(this.RootHeight)
+	sink.WriteHash(this.StateRoot)
+	serializeHashes(sink, this.StatePath)
+	return nil
+}
+
+func (this *StorageProof) Deserialization(source *common.ZeroCopySource) error {
+	var irregular, eof bool
+	this.Key, _, irregular, eof = source.NextVarBytes()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	this.Value, _, irregular, eof = source.NextVarBytes()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	this.Height, eof = source.NextUint32()
+	n, _, irregular, eof := source.NextVarUint()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	if irregular {
+		return common.ErrIrregularData
+	}
+	this.WriteSet = nil
+	for i := uint64(0); i < n; i++ {
+		item := &StateItem{}
+		item.Key, _, irregular, eof = source.NextVarBytes()
+		if irregular {
+			return common.ErrIrregularData
+		}
+		item.Value, _, irregular, eof = source.NextVarBytes()
+		if eof {
+			return io.ErrUnexpectedEOF
+		}
+		if irregular {
+			return common.ErrIrregularData
+		}
+		this.WriteSet = append(this.WriteSet, item)
+	}
+	this.RootHeight, eof = source.NextUint32()
+	this.StateRoot, eof = source.NextHash()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	var err error
+	this.StatePath, err = deserializeHashes(source)
+	return err
+}
+
+//WriteSetHash return the hash of write set, which is the leaf of state merkle tree
+func (this *StorageProof) WriteSetHash() common.Uint256 {
+	hasher := sha256.New()
+	for _, item := range this.WriteSet {
+		hasher.Write(item.Key)
+		hasher.Write(item.Value)
+	}
+	var hash common.Uint256
+	hasher.Sum(hash[:0])
+	return hash
+}
+
+//Verify check the value of key is in the write set, and the write set is included in StateRoot. The state merkle
+//tree starts at stateHashCheckHeight, whose leaf is the hash of total state.
+func (this *StorageProof) Verify(stateHashCheckHeight uint32) error {
+	if this.Height <= stateHashCheckHeight || this.Height > this.RootHeight {
+		return fmt.Errorf("block height %d is out of range", this.Height)
+	}
+	found := false
+	for i, item := range this.WriteSet {
+		if i > 0 && bytes.Compare(this.WriteSet[i-1].Key, item.Key) >= 0 {
+			return fmt.Errorf("write set is not in key order")
+		}
+		if bytes.Equal(item.Key, this.Key) {
+			if !bytes.Equal(item.Value, this.Value) {
+				return fmt.Errorf("value mismatch with write set")
+			}
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("key is not in write set")
+	}
+	return merkle.NewMerkleVerifier().VerifyLeafHashInclusion(this.WriteSetHash(), this.Height-stateHashCheckHeight,
+		this.StatePath, this.StateRoot, this.RootHeight-stateHashCheckHeight+1)
+}
+
+func serializeHashes(sink *common.ZeroCopySink, hashes []common.Uint256) {
+	sink.WriteVarUint(uint64(len(hashes)))
+	for _, hash := range hashes {
+		sink.WriteHash(hash)
+	}
+}
+
+func deserializeHashes(source *common.ZeroCopySource) ([]common.Uint256, error) {
+	n, _, irregular, eof := source.NextVarUint()
+	if eof {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if irregular {
+		return nil, common.ErrIrregularData
+	}
+	if n > source.Len()/common.UINT256_SIZE {
+		return nil, io.ErrUnexpectedEOF
+	}
+	hashes := make([]common.Uint256, 0, n)
+	for i := uint64(0); i < n; i++ {
+		hash, _ := source.NextHash()
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
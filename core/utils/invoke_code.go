@@ -0,0 +1,127 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"bytes"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/payload"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	neovm "github.com/OnyxPay/OnyxChain/smartcontract/service/neovm"
+	vm "github.com/OnyxPay/OnyxChain/vm/neovm"
+)
+
+// GetTxContracts returns the contracts a transaction calls directly, or the
+// contract deployed by a deploy transaction
+func GetTxContracts(tx *types.Transaction) []common.Address {
+	switch pl := tx.Payload.(type) {
+	case *payload.InvokeCode:
+		return GetInvokedContracts(pl.Code)
+	case *payload.DeployCode:
+		return []common.Address{common.AddressFromVmCode(pl.Code)}
+	}
+	return nil
+}
+
+// GetInvokedContracts scans the invoke code and returns the addresses called by
+// APPCALL, TAILCALL and native invoke syscalls, in order and without duplicates.
+// Dynamic calls resolve to the last address pushed before them.
+func GetInvokedContracts(code []byte) []common.Address {
+	var contracts []common.Address
+	var lastAddr []byte
+	seen := make(map[common.Address]bool)
+	add := func(addr []byte) {
+		if len(addr) != common.ADDR_LEN {
+			return
+		}
+		var contract common.Address
+		copy(contract[:], addr)
+		if !seen[contract] {
+			seen[contract] = true
+			contracts = append(contracts, contract)
+		}
+	}
+
+	source := common.NewZeroCopySource(code)
+	for source.Len() > 0 {
+		b, _ := source.NextByte()
+		op := vm.OpCode(b)
+		switch {
+		case op >= vm.PUSHBYTES1 && op <= vm.PUSHBYTES75:
+			data, eof := source.NextBytes(uint64(op))
+			if eof {
+				return contracts
+			}
+			if len(data) == common.ADDR_LEN {
+				lastAddr = data
+			}
+		case op == vm.PUSHDATA1 || op == vm.PUSHDATA2 || op == vm.PUSHDATA4:
+			var size uint64
+			var eof bool
+			switch op {
+			case vm.PUSHDATA1:
+				var n uint8
+				n, eof = source.NextUint8()
+				size = uint64(n)
+			case vm.PUSHDATA2:
+				var n uint16
+				n, eof = source.NextUint16()
+				size = uint64(n)
+			default:
+				var n uint32
+				n, eof = source.NextUint32()
+				size = uint64(n)
+			}
+			if eof {
+				return contracts
+			}
+			data, eof := source.NextBytes(size)
+			if eof {
+				return contracts
+			}
+			if len(data) == common.ADDR_LEN {
+				lastAddr = data
+			}
+		case op >= vm.JMP && op <= vm.CALL:
+			if _, eof := source.NextUint16(); eof {
+				return contracts
+			}
+		case op == vm.APPCALL || op == vm.TAILCALL:
+			addr, eof := source.NextBytes(common.ADDR_LEN)
+			if eof {
+				return contracts
+			}
+			if bytes.Equal(addr, common.ADDRESS_EMPTY[:]) {
+				add(lastAddr)
+			} else {
+				add(addr)
+			}
+		case op == vm.SYSCALL:
+			name, _, irregular, eof := source.NextString()
+			if irregular || eof {
+				return contracts
+			}
+			if name == neovm.NATIVE_INVOKE_NAME {
+				add(lastAddr)
+			}
+		}
+	}
+	return contracts
+}
@@ -0,0 +1,47 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	vm "github.com/OnyxPay/OnyxChain/vm/neovm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetInvokedContracts(t *testing.T) {
+	// the 100 bytes argument is pushed by PUSHDATA1
+	code, err := BuildNativeInvokeCode(utils.OnxContractAddress, 0, "transfer", []interface{}{make([]byte, 100)})
+	assert.Nil(t, err)
+	assert.Equal(t, []common.Address{utils.OnxContractAddress}, GetInvokedContracts(code))
+
+	neo := common.Address{1, 2, 3}
+	builder := vm.NewParamsBuilder(new(bytes.Buffer))
+	builder.EmitPushByteArray([]byte("method"))
+	builder.EmitPushCall(neo[:])
+	builder.EmitPushByteArray(utils.OxgContractAddress[:])
+	builder.EmitPushCall(common.ADDRESS_EMPTY[:])
+	builder.EmitPushCall(neo[:])
+	assert.Equal(t, []common.Address{neo, utils.OxgContractAddress}, GetInvokedContracts(builder.ToArray()))
+
+	assert.Equal(t, 0, len(GetInvokedContracts([]byte{byte(vm.APPCALL), 0x01})))
+}
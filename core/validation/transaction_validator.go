@@ -30,6 +30,7 @@ import (
 	"github.com/OnyxPay/OnyxChain/core/signature"
 	"github.com/OnyxPay/OnyxChain/core/types"
 	onxErrors "github.com/OnyxPay/OnyxChain/errors"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/wasmvm"
 )
 
 // VerifyTransaction verifys received single transaction
@@ -114,6 +115,9 @@ func checkTransactionPayload(tx *types.Transaction) error {
 
 	switch pld := tx.Payload.(type) {
 	case *payload.DeployCode:
+		if pld.VmType == payload.WASMVM_TYPE {
+			return wasmvm.VerifyContractCode(pld.Code)
+		}
 		return nil
 	case *payload.InvokeCode:
 		return nil
@@ -52,6 +52,8 @@ const (
 	ErrNetVerifyFail        ErrCode = 45019
 	ErrGasPrice             ErrCode = 45020
 	ErrVerifySignature      ErrCode = 45021
+	ErrUnderpriced          ErrCode = 45022
+	ErrAccountTxLimit       ErrCode = 45023
 )
 
 func (err ErrCode) Error() string {
@@ -100,6 +102,10 @@ func (err ErrCode) Error() string {
 		return "invalid gas price"
 	case ErrVerifySignature:
 		return "transaction verify signature fail"
+	case ErrUnderpriced:
+		return "replacement transaction underpriced"
+	case ErrAccountTxLimit:
+		return "too many transactions of the payer in tx pool"
 
 	}
 
@@ -22,9 +22,11 @@ import (
 	"github.com/OnyxPay/OnyxChain/common"
 	"github.com/OnyxPay/OnyxChain/core/ledger"
 	"github.com/OnyxPay/OnyxChain/core/payload"
+	scom "github.com/OnyxPay/OnyxChain/core/store/common"
 	"github.com/OnyxPay/OnyxChain/core/types"
 	"github.com/OnyxPay/OnyxChain/smartcontract/event"
 	cstate "github.com/OnyxPay/OnyxChain/smartcontract/states"
+	vm "github.com/OnyxPay/OnyxChain/vm/neovm"
 )
 
 const (
@@ -72,6 +74,11 @@ func GetStorageItem(address common.Address, key []byte) ([]byte, error) {
 	return ledger.DefLedger.GetStorageItem(address, key)
 }
 
+//GetStorageItemByHeight from ledger
+func GetStorageItemByHeight(address common.Address, key []byte, height uint32) ([]byte, error) {
+	return ledger.DefLedger.GetStorageItemByHeight(address, key, height)
+}
+
 //GetContractStateFromStore from ledger
 func GetContractStateFromStore(hash common.Address) (*payload.DeployCode, error) {
 	hash = updateNativeSCAddr(hash)
@@ -89,6 +96,16 @@ func PreExecuteContract(tx *types.Transaction) (*cstate.PreExecResult, error) {
 	return ledger.DefLedger.PreExecuteContract(tx)
 }
 
+//PreExecuteContractWithTracer from ledger
+func PreExecuteContractWithTracer(tx *types.Transaction, tracer vm.Tracer) (*cstate.PreExecResult, error) {
+	return ledger.DefLedger.PreExecuteContractWithTracer(tx, tracer)
+}
+
+//TraceTransaction from ledger
+func TraceTransaction(txHash common.Uint256, tracer vm.Tracer) (*cstate.TraceResult, error) {
+	return ledger.DefLedger.TraceTransaction(txHash, tracer)
+}
+
 //GetEventNotifyByTxHash from ledger
 func GetEventNotifyByTxHash(txHash common.Uint256) (*event.ExecuteNotify, error) {
 	return ledger.DefLedger.GetEventNotifyByTx(txHash)
@@ -99,6 +116,16 @@ func GetEventNotifyByHeight(height uint32) ([]*event.ExecuteNotify, error) {
 	return ledger.DefLedger.GetEventNotifyByBlock(height)
 }
 
+//GetTxsByAddress from ledger
+func GetTxsByAddress(addr common.Address, startHeight, endHeight, offset, limit uint32) ([]*scom.TxIndexItem, error) {
+	return ledger.DefLedger.GetTxsByAddress(addr, startHeight, endHeight, offset, limit)
+}
+
+//GetTxsByContract from ledger
+func GetTxsByContract(contract common.Address, startHeight, endHeight, offset, limit uint32) ([]*scom.TxIndexItem, error) {
+	return ledger.DefLedger.GetTxsByContract(contract, startHeight, endHeight, offset, limit)
+}
+
 //GetMerkleProof from ledger
 func GetMerkleProof(proofHeight uint32, rootHeight uint32) ([]common.Uint256, error) {
 	return ledger.DefLedger.GetMerkleProof(proofHeight, rootHeight)
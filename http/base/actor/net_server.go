@@ -23,11 +23,16 @@ import (
 	"time"
 
 	"github.com/OnyxPay/OnyxChain-eventbus/actor"
+	comm "github.com/OnyxPay/OnyxChain/common"
 	"github.com/OnyxPay/OnyxChain/common/log"
+	"github.com/OnyxPay/OnyxChain/core/types"
 	ac "github.com/OnyxPay/OnyxChain/p2pserver/actor/server"
 	"github.com/OnyxPay/OnyxChain/p2pserver/common"
 )
 
+//proof requests of light node may try several peers
+const LIGHT_REQ_TIMEOUT = 30
+
 var netServerPid *actor.PID
 
 func SetNetServerPID(actr *actor.PID) {
@@ -204,3 +209,40 @@ func GetNodeType() (uint64, error) {
 	}
 	return r.NodeType, nil
 }
+
+//GetVerifiedTxProof from netSever actor, only available in light node
+func GetVerifiedTxProof(txHash comm.Uint256) (*types.TxProof, error) {
+	if netServerPid == nil {
+		return nil, errors.New("net server is not started")
+	}
+	future := netServerPid.RequestFuture(&ac.GetTxProofReq{TxHash: txHash}, LIGHT_REQ_TIMEOUT*time.Second)
+	result, err := future.Result()
+	if err != nil {
+		log.Errorf(ERR_ACTOR_COMM, err)
+		return nil, err
+	}
+	r, ok := result.(*ac.GetTxProofRsp)
+	if !ok {
+		return nil, errors.New("fail")
+	}
+	return r.Proof, r.Error
+}
+
+//GetVerifiedStorageProof from netSever actor, only available in light node
+func GetVerifiedStorageProof(contract comm.Address, key []byte) (*types.StorageProof, error) {
+	if netServerPid == nil {
+		return nil, errors.New("net server is not started")
+	}
+	future := netServerPid.RequestFuture(&ac.GetStorageProofReq{Contract: contract, Key: key},
+		LIGHT_REQ_TIMEOUT*time.Second)
+	result, err := future.Result()
+	if err != nil {
+		log.Errorf(ERR_ACTOR_COMM, err)
+		return nil, err
+	}
+	r, ok := result.(*ac.GetStorageProofRsp)
+	if !ok {
+		return nil, errors.New("fail")
+	}
+	return r.Proof, r.Error
+}
@@ -110,10 +110,25 @@ func GetTxFromPool(hash common.Uint256) (tcomn.TXEntry, error) {
 	if !ok {
 		return tcomn.TXEntry{}, errors.New("fail")
 	}
-	txnEntry := tcomn.TXEntry{rsp.Txn, txStatus.TxStatus}
+	txnEntry := tcomn.TXEntry{Tx: rsp.Txn, Attrs: txStatus.TxStatus}
 	return txnEntry, nil
 }
 
+//GetMemPoolTxs from txpool actor, filtered by payer and contract if they are not nil
+func GetMemPoolTxs(payer, contract *common.Address) ([]*tcomn.MemPoolTx, error) {
+	future := txnPid.RequestFuture(&tcomn.GetMemPoolTxsReq{Payer: payer, Contract: contract}, REQ_TIMEOUT*time.Second)
+	result, err := future.Result()
+	if err != nil {
+		log.Errorf(ERR_ACTOR_COMM, err)
+		return nil, err
+	}
+	rsp, ok := result.(*tcomn.GetMemPoolTxsRsp)
+	if !ok {
+		return nil, errors.New("fail")
+	}
+	return rsp.Txs, nil
+}
+
 //GetTxnCount from txpool actor
 func GetTxnCount() ([]uint32, error) {
 	future := txnPid.RequestFuture(&tcomn.GetTxnCountReq{}, REQ_TIMEOUT*time.Second)
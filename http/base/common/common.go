@@ -22,6 +22,7 @@ package common
 import (
 	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"github.com/OnyxPay/OnyxChain-crypto/keypair"
 	"github.com/OnyxPay/OnyxChain/common"
@@ -30,6 +31,7 @@ import (
 	"github.com/OnyxPay/OnyxChain/common/serialization"
 	"github.com/OnyxPay/OnyxChain/core/ledger"
 	"github.com/OnyxPay/OnyxChain/core/payload"
+	scom "github.com/OnyxPay/OnyxChain/core/store/common"
 	"github.com/OnyxPay/OnyxChain/core/types"
 	cutils "github.com/OnyxPay/OnyxChain/core/utils"
 	onxErrors "github.com/OnyxPay/OnyxChain/errors"
@@ -45,11 +47,38 @@ import (
 
 const MAX_SEARCH_HEIGHT uint32 = 100
 
+const (
+	DEFAULT_INDEX_QUERY_LIMIT uint32 = 100  //Default count of items returned by event index query
+	MAX_INDEX_QUERY_LIMIT     uint32 = 1000 //Max count of items returned by event index query
+)
+
+//Percentage of gas consumed by pre-execution added to the suggested gas limit,
+//since state may change before the transaction is executed
+const PRE_EXEC_GAS_LIMIT_MARGIN uint64 = 10
+
 type BalanceOfRsp struct {
 	Onx string `json:"onyx"`
 	Oxg string `json:"oxg"`
 }
 
+type VestingInfo struct {
+	Locked    string // the onx not claimed yet
+	Claimable string // the vested onx not claimed yet
+	Schedules []VestingScheduleInfo
+}
+
+type VestingScheduleInfo struct {
+	Id          uint32
+	Creator     string
+	HeightBased bool
+	Start       uint32
+	Cliff       uint32
+	Duration    uint32
+	Amount      string
+	Claimed     string
+	Vested      string
+}
+
 type MerkleProof struct {
 	Type             string
 	TransactionsRoot string
@@ -72,6 +101,19 @@ type ExecuteNotify struct {
 	Notify      []NotifyEventInfo
 }
 
+type IndexedTx struct {
+	TxHash string
+	Height uint32
+}
+
+type ContractEvent struct {
+	TxHash      string
+	Height      uint32
+	State       byte
+	GasConsumed uint64
+	Notify      []NotifyEventInfo
+}
+
 type PreExecuteResult struct {
 	State  byte
 	Gas    uint64
@@ -79,6 +121,29 @@ type PreExecuteResult struct {
 	Notify []NotifyEventInfo
 }
 
+type PreExecuteCallResult struct {
+	State    byte
+	Gas      uint64
+	GasLimit uint64
+	Result   interface{}
+	Notify   []NotifyEventInfo
+}
+
+type GasEstimate struct {
+	Gas      uint64
+	GasLimit uint64
+}
+
+type TraceResult struct {
+	State     byte
+	Gas       uint64
+	Error     string
+	Result    interface{}
+	Notify    []NotifyEventInfo
+	Steps     []*neovm.StepLog
+	Truncated bool
+}
+
 type NotifyEventInfo struct {
 	ContractAddress string
 	States          interface{}
@@ -169,6 +234,18 @@ type TXNEntryInfo struct {
 	State []TXNAttrInfo // the result from each validator
 }
 
+type MemPoolTxInfo struct {
+	TxHash    string
+	Payer     string
+	GasPrice  uint64
+	GasLimit  uint64
+	Nonce     uint32
+	Age       int64 // seconds since the tx is received, or enters the pool if verified
+	Verified  bool
+	State     []TXNAttrInfo // the result from each validator
+	Contracts []string      // the contracts called by the tx
+}
+
 func GetLogEvent(obj *event.LogEventArgs) (map[string]bool, LogEventArgs) {
 	hash := obj.TxHash
 	addr := obj.ContractAddress.ToHexString()
@@ -187,6 +264,77 @@ func GetExecuteNotify(obj *event.ExecuteNotify) (map[string]bool, ExecuteNotify)
 	return contractAddrs, ExecuteNotify{txhash, obj.State, obj.GasConsumed, evts}
 }
 
+//GetTxsByAddress return the transactions which transfer from or to the address in the block height range
+func GetTxsByAddress(addr common.Address, startHeight, endHeight, offset, limit uint32) ([]IndexedTx, error) {
+	items, err := bactor.GetTxsByAddress(addr, startHeight, endHeight, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]IndexedTx, 0, len(items))
+	for _, item := range items {
+		txs = append(txs, IndexedTx{item.TxHash.ToHexString(), item.Height})
+	}
+	return txs, nil
+}
+
+//GetContractEvents return the event notifies of the contract in the block height range,
+//notifies of other contracts in the same transaction are left out
+func GetContractEvents(contract common.Address, startHeight, endHeight, offset, limit uint32) ([]ContractEvent, error) {
+	items, err := bactor.GetTxsByContract(contract, startHeight, endHeight, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	evts := make([]ContractEvent, 0, len(items))
+	for _, item := range items {
+		notify, err := bactor.GetEventNotifyByTxHash(item.TxHash)
+		if err != nil {
+			return nil, err
+		}
+		notifies := []NotifyEventInfo{}
+		for _, v := range notify.Notify {
+			if v.ContractAddress == contract {
+				notifies = append(notifies, NotifyEventInfo{v.ContractAddress.ToHexString(), v.States})
+			}
+		}
+		evts = append(evts, ContractEvent{item.TxHash.ToHexString(), item.Height, notify.State,
+			notify.GasConsumed, notifies})
+	}
+	return evts, nil
+}
+
+//GetMemPoolTxs return the transactions in the pool and the pending list, oldest first.
+//The list is filtered by the payer and the called contract if they are not nil
+func GetMemPoolTxs(payer, contract *common.Address) ([]*MemPoolTxInfo, error) {
+	items, err := bactor.GetMemPoolTxs(payer, contract)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	txs := make([]*MemPoolTxInfo, 0, len(items))
+	for _, item := range items {
+		hash := item.Tx.Hash()
+		info := &MemPoolTxInfo{
+			TxHash:    hash.ToHexString(),
+			Payer:     item.Tx.Payer.ToBase58(),
+			GasPrice:  item.Tx.GasPrice,
+			GasLimit:  item.Tx.GasLimit,
+			Nonce:     item.Tx.Nonce,
+			Age:       int64(now.Sub(item.Time) / time.Second),
+			Verified:  item.Verified,
+			State:     []TXNAttrInfo{},
+			Contracts: []string{},
+		}
+		for _, t := range item.Attrs {
+			info.State = append(info.State, TXNAttrInfo{t.Height, int(t.Type), int(t.ErrCode)})
+		}
+		for _, addr := range cutils.GetTxContracts(item.Tx) {
+			info.Contracts = append(info.Contracts, addr.ToHexString())
+		}
+		txs = append(txs, info)
+	}
+	return txs, nil
+}
+
 func ConvertPreExecuteResult(obj *cstate.PreExecResult) PreExecuteResult {
 	evts := []NotifyEventInfo{}
 	for _, v := range obj.Notify {
@@ -195,6 +343,29 @@ func ConvertPreExecuteResult(obj *cstate.PreExecResult) PreExecuteResult {
 	return PreExecuteResult{obj.State, obj.Gas, obj.Result, evts}
 }
 
+//ConvertPreExecuteCallResult convert pre-execute result with the typed result value and the suggested gas limit
+func ConvertPreExecuteCallResult(obj *cstate.PreExecResult, result interface{}) PreExecuteCallResult {
+	evts := []NotifyEventInfo{}
+	for _, v := range obj.Notify {
+		evts = append(evts, NotifyEventInfo{v.ContractAddress.ToHexString(), v.States})
+	}
+	return PreExecuteCallResult{obj.State, obj.Gas, SuggestGasLimit(obj.Gas), result, evts}
+}
+
+//ConvertTraceResult convert the execution result with the steps recorded by logger
+func ConvertTraceResult(obj *cstate.TraceResult, result interface{}, logger *neovm.StructLogger) TraceResult {
+	evts := []NotifyEventInfo{}
+	for _, v := range obj.Notify {
+		evts = append(evts, NotifyEventInfo{v.ContractAddress.ToHexString(), v.States})
+	}
+	return TraceResult{obj.State, obj.Gas, obj.Error, result, evts, logger.Steps, logger.Truncated}
+}
+
+//SuggestGasLimit return the gas limit suggested for the gas consumed by pre-execution
+func SuggestGasLimit(gas uint64) uint64 {
+	return gas + gas*PRE_EXEC_GAS_LIMIT_MARGIN/100
+}
+
 func TransArryByteToHexString(ptx *types.Transaction) *Transactions {
 	trans := new(Transactions)
 	trans.TxType = ptx.TxType
@@ -290,6 +461,22 @@ func GetBalance(address common.Address) (*BalanceOfRsp, error) {
 	}, nil
 }
 
+//GetBalanceByHeight return balance of address at block height, which requires archive mode if height is not current block height
+func GetBalanceByHeight(address common.Address, height uint32) (*BalanceOfRsp, error) {
+	onx, err := GetContractBalanceByHeight(utils.OnxContractAddress, address, height)
+	if err != nil {
+		return nil, err
+	}
+	oxg, err := GetContractBalanceByHeight(utils.OxgContractAddress, address, height)
+	if err != nil {
+		return nil, err
+	}
+	return &BalanceOfRsp{
+		Onx: fmt.Sprintf("%d", onx),
+		Oxg: fmt.Sprintf("%d", oxg),
+	}, nil
+}
+
 func GetGrantOxg(addr common.Address) (string, error) {
 	key := append([]byte(onx.UNBOUND_TIME_OFFSET), addr[:]...)
 	value, err := ledger.DefLedger.GetStorageItem(utils.OnxContractAddress, key)
@@ -304,10 +491,87 @@ func GetGrantOxg(addr common.Address) (string, error) {
 	if err != nil {
 		return fmt.Sprintf("%v", 0), err
 	}
+	schedules, err := getVestingSchedules(addr)
+	if err != nil {
+		return fmt.Sprintf("%v", 0), err
+	}
+	onx += schedules.Locked()
 	boundoxg := utils.CalcUnbindOxg(onx, v, uint32(time.Now().Unix())-constants.GENESIS_BLOCK_TIMESTAMP)
 	return fmt.Sprintf("%v", boundoxg), nil
 }
 
+func getVestingSchedules(addr common.Address) (*onx.VestingSchedules, error) {
+	key := append([]byte(onx.VESTING), addr[:]...)
+	value, err := bactor.GetStorageItem(utils.OnxContractAddress, key)
+	if err != nil && err != scom.ErrNotFound {
+		return nil, err
+	}
+	schedules := new(onx.VestingSchedules)
+	if len(value) == 0 {
+		return schedules, nil
+	}
+	if err := schedules.Deserialization(common.NewZeroCopySource(value)); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+//GetVesting return the onx vesting schedules of the beneficiary, the vested amount is calculated at current block height and time
+func GetVesting(addr common.Address) (*VestingInfo, error) {
+	schedules, err := getVestingSchedules(addr)
+	if err != nil {
+		return nil, err
+	}
+	height := bactor.GetCurrentBlockHeight()
+	now := uint32(time.Now().Unix())
+	claimable := uint64(0)
+	info := &VestingInfo{
+		Locked:    fmt.Sprintf("%d", schedules.Locked()),
+		Schedules: make([]VestingScheduleInfo, 0, len(schedules.Schedules)),
+	}
+	for _, v := range schedules.Schedules {
+		vested := v.Vested(height, now)
+		claimable += vested - v.Claimed
+		info.Schedules = append(info.Schedules, VestingScheduleInfo{
+			Id:          v.Id,
+			Creator:     v.Creator.ToBase58(),
+			HeightBased: v.HeightBased,
+			Start:       v.Start,
+			Cliff:       v.Cliff,
+			Duration:    v.Duration,
+			Amount:      fmt.Sprintf("%d", v.Amount),
+			Claimed:     fmt.Sprintf("%d", v.Claimed),
+			Vested:      fmt.Sprintf("%d", vested),
+		})
+	}
+	info.Claimable = fmt.Sprintf("%d", claimable)
+	return info, nil
+}
+
+//GetDIDDocument return the W3C DID document of the ONX ID by pre-executing getDocument, nil if the ID is not registered
+func GetDIDDocument(id string) (json.RawMessage, error) {
+	mutable, err := NewNativeInvokeTransaction(0, 0, utils.OnxIDContractAddress, 0, "getDocument", []interface{}{[]byte(id)})
+	if err != nil {
+		return nil, fmt.Errorf("NewNativeInvokeTransaction error:%s", err)
+	}
+	tx, err := mutable.IntoImmutable()
+	if err != nil {
+		return nil, err
+	}
+	result, err := bactor.PreExecuteContract(tx)
+	if err != nil {
+		return nil, fmt.Errorf("PrepareInvokeContract error:%s", err)
+	}
+	if result.State == 0 {
+		return nil, nil
+	}
+	data, err := hex.DecodeString(result.Result.(string))
+	if err != nil {
+		return nil, fmt.Errorf("hex.DecodeString error:%s", err)
+	}
+	return json.RawMessage(data), nil
+}
+
 func GetAllowance(asset string, from, to common.Address) (string, error) {
 	var contractAddr common.Address
 	switch strings.ToLower(asset) {
@@ -350,6 +614,18 @@ func GetContractBalance(cVersion byte, contractAddr, accAddr common.Address) (ui
 	return balance.Uint64(), nil
 }
 
+//GetContractBalanceByHeight read balance of native token contract from storage at block height
+func GetContractBalanceByHeight(contractAddr, accAddr common.Address, height uint32) (uint64, error) {
+	value, err := bactor.GetStorageItemByHeight(contractAddr, accAddr[:], height)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return serialization.ReadUint64(bytes.NewBuffer(value))
+}
+
 func GetContractAllowance(cVersion byte, contractAddr, fromAddr, toAddr common.Address) (uint64, error) {
 	type allowanceStruct struct {
 		From common.Address
@@ -0,0 +1,174 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/OnyxPay/OnyxChain/common"
+	"strconv"
+	"strings"
+)
+
+const (
+	PARAM_TYPE_BYTE_ARRAY = "bytearray"
+	PARAM_TYPE_STRING     = "string"
+	PARAM_TYPE_INTEGER    = "int"
+	PARAM_TYPE_BOOLEAN    = "bool"
+)
+
+//NeoVMInvokeParam use to express the param to invoke neovm contract.
+//Type can be of array, bytearray, string, int and bool
+//If type is one of bytearray, string, int and bool, value must be a string
+//If Type is array, value must be []*NeoVMInvokeParam
+//Example:
+//[]interface{}{
+//	&NeoVMInvokeParam{
+//		Type:  "string",
+//		Value: "foo",
+//	},
+//	&NeoVMInvokeParam{
+//		Type: "array",
+//		Value: []interface{}{
+//			&NeoVMInvokeParam{
+//				Type:  "int",
+//				Value: "0",
+//			},
+//			&NeoVMInvokeParam{
+//				Type:  "bool",
+//				Value: "true",
+//			},
+//		},
+//	},
+//}
+type NeoVMInvokeParam struct {
+	Type  string
+	Value interface{} //string or []*NeoVMInvokeParam
+}
+
+//ParseNeoVMInvokeParams parse params to []interface, rawParams is array of NeoVMInvokeParam
+func ParseNeoVMInvokeParams(rawParams []interface{}) ([]interface{}, error) {
+	if len(rawParams) == 0 {
+		return nil, nil
+	}
+	params := make([]interface{}, 0)
+	for _, rawParam := range rawParams {
+		rawParamItem, ok := rawParam.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid param %v", rawParam)
+		}
+		for k, v := range rawParamItem {
+			rawParamItem[strings.ToLower(k)] = v
+		}
+		pType, ok := rawParamItem["type"]
+		if !ok {
+			return nil, fmt.Errorf("invalid param %v", rawParamItem)
+		}
+		pt, ok := pType.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid param %v", rawParamItem)
+		}
+		pValue, ok := rawParamItem["value"]
+		if !ok {
+			return nil, fmt.Errorf("invalid param %v", rawParamItem)
+		}
+		switch pv := pValue.(type) {
+		case string:
+			pv = strings.TrimSpace(pv)
+			param, err := ParseNeoVMParamValue(pt, pv)
+			if err != nil {
+				return nil, fmt.Errorf("Parse Param type:%s value:%s error:%s", pType, pv, err)
+			}
+			params = append(params, param)
+		case []interface{}:
+			ps, err := ParseNeoVMInvokeParams(pv)
+			if err != nil {
+				return nil, err
+			}
+			if len(ps) > 0 {
+				params = append(params, ps)
+			}
+		default:
+			return nil, fmt.Errorf("invalid param %v", rawParamItem)
+		}
+	}
+	return params, nil
+}
+
+//ParseNeoVMParamValue return the value of neovm param in type of bytearray, string, int or bool
+func ParseNeoVMParamValue(pType string, pValue string) (interface{}, error) {
+	switch strings.ToLower(pType) {
+	case PARAM_TYPE_BYTE_ARRAY:
+		value, err := hex.DecodeString(pValue)
+		if err != nil {
+			return nil, fmt.Errorf("parse byte array param:%s error:%s", pValue, err)
+		}
+		return value, nil
+	case PARAM_TYPE_STRING:
+		return pValue, nil
+	case PARAM_TYPE_INTEGER:
+		if pValue == "" {
+			return nil, fmt.Errorf("invalid integer")
+		}
+		value, err := strconv.ParseInt(pValue, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse integer param:%s error:%s", pValue, err)
+		}
+		return value, nil
+	case PARAM_TYPE_BOOLEAN:
+		switch strings.ToLower(pValue) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		default:
+			return nil, fmt.Errorf("parse boolean param:%s failed", pValue)
+		}
+	default:
+		return nil, fmt.Errorf("unspport param type:%s", pType)
+	}
+}
+
+//ParseNeoVMContractReturnTypeBool return bool value of smart contract execute code.
+func ParseNeoVMContractReturnTypeBool(hexStr string) (bool, error) {
+	return hexStr == "01", nil
+}
+
+//ParseNeoVMContractReturnTypeInteger return integer value of smart contract execute code.
+func ParseNeoVMContractReturnTypeInteger(hexStr string) (int64, error) {
+	data, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return 0, fmt.Errorf("hex.DecodeString error:%s", err)
+	}
+	return common.BigIntFromNeoBytes(data).Int64(), nil
+}
+
+//ParseNeoVMContractReturnTypeByteArray return []byte value of smart contract execute code.
+func ParseNeoVMContractReturnTypeByteArray(hexStr string) (string, error) {
+	return hexStr, nil
+}
+
+//ParseNeoVMContractReturnTypeString return string value of smart contract execute code.
+func ParseNeoVMContractReturnTypeString(hexStr string) (string, error) {
+	data, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return "", fmt.Errorf("hex.DecodeString:%s error:%s", hexStr, err)
+	}
+	return string(data), nil
+}
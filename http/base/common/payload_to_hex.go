@@ -37,6 +37,7 @@ type InvokeCodeInfo struct {
 type DeployCodeInfo struct {
 	Code        string
 	NeedStorage bool
+	VmType      byte
 	Name        string
 	CodeVersion string
 	Author      string
@@ -101,6 +102,7 @@ func TransPayloadToHex(p types.Payload) PayloadInfo {
 		obj := new(DeployCodeInfo)
 		obj.Code = common.ToHexString(object.Code)
 		obj.NeedStorage = object.NeedStorage
+		obj.VmType = byte(object.VmType)
 		obj.Name = object.Name
 		obj.CodeVersion = object.Version
 		obj.Author = object.Author
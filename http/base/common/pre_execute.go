@@ -0,0 +1,142 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/OnyxPay/OnyxChain/cmd/abi"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"strings"
+)
+
+//PreExecuteCall is a contract method call to pre-execute, which needn't to be signed.
+//Params of native contract are typed by native abi. Params of neovm contract are typed by Abi if given,
+//otherwise params should be in form of NeoVMInvokeParam
+type PreExecuteCall struct {
+	Contract string          `json:"contract"`
+	Method   string          `json:"method"`
+	Params   []interface{}   `json:"params"`
+	Payer    string          `json:"payer"`
+	Abi      json.RawMessage `json:"abi"`
+}
+
+//NewPreExecuteCallTransaction return the unsigned transaction of call, and the return type of method by abi.
+//Return type is empty if there is no abi of the contract
+func NewPreExecuteCallTransaction(call *PreExecuteCall) (*types.Transaction, string, error) {
+	contractAddr, err := common.AddressFromHexString(call.Contract)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid contract address:%s", call.Contract)
+	}
+	if call.Method == "" {
+		return nil, "", fmt.Errorf("method cannot be empty")
+	}
+	var mutable *types.MutableTransaction
+	returnType := ""
+	if nativeAbi := abi.DefAbiMgr.GetNativeAbi(contractAddr.ToHexString()); nativeAbi != nil {
+		funcAbi := nativeAbi.GetFunc(call.Method)
+		if funcAbi == nil {
+			return nil, "", fmt.Errorf("method:%s not found in native abi", call.Method)
+		}
+		mutable, err = NewNativeAbiInvokeTransaction(0, 0, contractAddr, 0, call.Params, funcAbi)
+		if err != nil {
+			return nil, "", err
+		}
+		returnType = funcAbi.ReturnType
+	} else if len(call.Abi) > 0 {
+		contractAbi, err := NewNeovmContractAbi(call.Abi)
+		if err != nil {
+			return nil, "", err
+		}
+		funcAbi := contractAbi.GetFunc(call.Method)
+		if funcAbi == nil {
+			return nil, "", fmt.Errorf("method:%s not found in abi", call.Method)
+		}
+		rawParams := make([]string, 0, len(call.Params))
+		for _, param := range call.Params {
+			rawParam, ok := param.(string)
+			if !ok {
+				return nil, "", fmt.Errorf("param:%v assert to string failed", param)
+			}
+			rawParams = append(rawParams, rawParam)
+		}
+		invokeParams, err := ParseNeovmFunc(rawParams, funcAbi)
+		if err != nil {
+			return nil, "", err
+		}
+		mutable, err = NewNeovmInvokeTransaction(0, 0, contractAddr, invokeParams)
+		if err != nil {
+			return nil, "", err
+		}
+		returnType = funcAbi.ReturnType
+	} else {
+		params, err := ParseNeoVMInvokeParams(call.Params)
+		if err != nil {
+			return nil, "", err
+		}
+		mutable, err = NewNeovmInvokeTransaction(0, 0, contractAddr, []interface{}{call.Method, params})
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	if call.Payer != "" {
+		mutable.Payer, err = common.AddressFromBase58(call.Payer)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid payer address:%s", call.Payer)
+		}
+	}
+	tx, err := mutable.IntoImmutable()
+	if err != nil {
+		return nil, "", err
+	}
+	return tx, returnType, nil
+}
+
+//ParseAbiReturnValue return the value of pre-execute result in the abi return type.
+//Result is returned as it is if return type is empty or unknown, or result is an array
+func ParseAbiReturnValue(result interface{}, returnType string) (interface{}, error) {
+	hexStr, ok := result.(string)
+	if !ok {
+		return result, nil
+	}
+	switch strings.ToLower(returnType) {
+	case abi.NATIVE_PARAM_TYPE_BOOL, abi.NEOVM_PARAM_TYPE_BOOL:
+		return ParseNeoVMContractReturnTypeBool(hexStr)
+	case abi.NATIVE_PARAM_TYPE_INTEGER, abi.NEOVM_PARAM_TYPE_INTEGER:
+		return ParseNeoVMContractReturnTypeInteger(hexStr)
+	case abi.NATIVE_PARAM_TYPE_STRING:
+		return ParseNeoVMContractReturnTypeString(hexStr)
+	case abi.NATIVE_PARAM_TYPE_BYTEARRAY:
+		return ParseNeoVMContractReturnTypeByteArray(hexStr)
+	case abi.NATIVE_PARAM_TYPE_ADDRESS:
+		data, err := hex.DecodeString(hexStr)
+		if err != nil {
+			return nil, fmt.Errorf("hex.DecodeString:%s error:%s", hexStr, err)
+		}
+		addr, err := common.AddressParseFromBytes(data)
+		if err != nil {
+			return nil, err
+		}
+		return addr.ToBase58(), nil
+	default:
+		return result, nil
+	}
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"fmt"
+	"github.com/OnyxPay/OnyxChain/cmd/abi"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	"testing"
+)
+
+func TestNewPreExecuteCallTransaction(t *testing.T) {
+	abi.DefAbiMgr.Init("../../../cmd/abi/native_abi_script")
+	addr := common.Address([20]byte{1})
+	call := &PreExecuteCall{
+		Contract: utils.OnxContractAddress.ToHexString(),
+		Method:   "balanceOf",
+		Params:   []interface{}{addr.ToBase58()},
+		Payer:    addr.ToBase58(),
+	}
+	tx, returnType, err := NewPreExecuteCallTransaction(call)
+	if err != nil {
+		t.Errorf("NewPreExecuteCallTransaction error:%s", err)
+		return
+	}
+	if tx.TxType != types.Invoke || tx.Payer != addr {
+		t.Errorf("NewPreExecuteCallTransaction invalid transaction")
+		return
+	}
+	if returnType != "Int" {
+		t.Errorf("return type %s != Int", returnType)
+		return
+	}
+
+	call.Method = "unknown"
+	_, _, err = NewPreExecuteCallTransaction(call)
+	if err == nil {
+		t.Errorf("NewPreExecuteCallTransaction should fail for unknown method")
+		return
+	}
+
+	contract := common.Address([20]byte{2})
+	call = &PreExecuteCall{
+		Contract: contract.ToHexString(),
+		Method:   "name",
+		Abi:      []byte(`{"hash":"","entrypoint":"Main","functions":[{"name":"Name","parameters":[],"returntype":"String"}]}`),
+	}
+	_, returnType, err = NewPreExecuteCallTransaction(call)
+	if err != nil {
+		t.Errorf("NewPreExecuteCallTransaction error:%s", err)
+		return
+	}
+	if returnType != "String" {
+		t.Errorf("return type %s != String", returnType)
+		return
+	}
+}
+
+func TestParseAbiReturnValue(t *testing.T) {
+	addr := common.Address([20]byte{1})
+	testCases := []struct {
+		result     interface{}
+		returnType string
+		value      interface{}
+	}{
+		{"01", "Bool", true},
+		{"00", "Boolean", false},
+		{"e803", "Int", int64(1000)},
+		{"666f6f", "String", "foo"},
+		{"666f6f", "ByteArray", "666f6f"},
+		{common.ToHexString(addr[:]), "Address", addr.ToBase58()},
+		{"666f6f", "", "666f6f"},
+		{[]interface{}{"01"}, "Array", []interface{}{"01"}},
+	}
+	for _, testCase := range testCases {
+		value, err := ParseAbiReturnValue(testCase.result, testCase.returnType)
+		if err != nil {
+			t.Errorf("ParseAbiReturnValue %v type:%s error:%s", testCase.result, testCase.returnType, err)
+			return
+		}
+		if fmt.Sprint(value) != fmt.Sprint(testCase.value) {
+			t.Errorf("ParseAbiReturnValue %v type:%s value %v != %v", testCase.result, testCase.returnType,
+				value, testCase.value)
+		}
+	}
+}
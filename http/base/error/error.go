@@ -39,6 +39,10 @@ const (
 	UNKNOWN_ASSET       int64 = 44002
 	UNKNOWN_BLOCK       int64 = 44003
 	UNKNOWN_CONTRACT    int64 = 44004
+	UNKNOWN_STATE       int64 = 44005
+	PRUNED_DATA         int64 = 44006
+	UNINDEXED_DATA      int64 = 44007
+	UNKNOWN_ID          int64 = 44008
 
 	INTERNAL_ERROR  int64 = 45001
 	SMARTCODE_ERROR int64 = 47001
@@ -63,6 +67,10 @@ var ErrMap = map[int64]string{
 	UNKNOWN_ASSET:       "UNKNOWN ASSET",
 	UNKNOWN_BLOCK:       "UNKNOWN BLOCK",
 	UNKNOWN_CONTRACT:    "UNKNOWN CONTRACT",
+	UNKNOWN_STATE:       "UNKNOWN STATE, NOT ARCHIVED",
+	PRUNED_DATA:         "DATA OF THE BLOCK IS PRUNED",
+	UNINDEXED_DATA:      "EVENT INDEX IS NOT ENABLED",
+	UNKNOWN_ID:          "UNKNOWN ONX ID",
 
 	INTERNAL_ERROR:                           "INTERNAL ERROR",
 	SMARTCODE_ERROR:                          "SMARTCODE EXEC ERROR",
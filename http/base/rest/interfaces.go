@@ -20,6 +20,9 @@ package rest
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/OnyxPay/OnyxChain/account"
 	"github.com/OnyxPay/OnyxChain/common"
 	"github.com/OnyxPay/OnyxChain/common/config"
 	"github.com/OnyxPay/OnyxChain/common/log"
@@ -95,6 +98,9 @@ func GetBlockHash(cmd map[string]interface{}) map[string]interface{} {
 func getBlock(hash common.Uint256, getTxBytes bool) (interface{}, int64) {
 	block, err := bactor.GetBlockFromStore(hash)
 	if err != nil {
+		if err == scom.ErrPruned {
+			return nil, berr.PRUNED_DATA
+		}
 		return nil, berr.UNKNOWN_BLOCK
 	}
 	if block == nil {
@@ -140,11 +146,12 @@ func GetBlockHeightByTxHash(cmd map[string]interface{}) map[string]interface{} {
 	if err != nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
+	// height of pruned transaction is still kept
 	height, tx, err := bactor.GetTxnWithHeightByTxHash(hash)
-	if err != nil {
+	if err != nil && err != scom.ErrPruned {
 		return ResponsePack(berr.INTERNAL_ERROR)
 	}
-	if tx == nil {
+	if err == nil && tx == nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
 	resp["Result"] = height
@@ -170,6 +177,9 @@ func GetBlockTxsByHeight(cmd map[string]interface{}) map[string]interface{} {
 	}
 	block, err := bactor.GetBlockFromStore(hash)
 	if err != nil {
+		if err == scom.ErrPruned {
+			return ResponsePack(berr.PRUNED_DATA)
+		}
 		return ResponsePack(berr.UNKNOWN_BLOCK)
 	}
 	resp["Result"] = bcomn.GetBlockTransactions(block)
@@ -197,6 +207,9 @@ func GetBlockByHeight(cmd map[string]interface{}) map[string]interface{} {
 	}
 	index := uint32(height)
 	block, err := bactor.GetBlockByHeight(index)
+	if err == scom.ErrPruned {
+		return ResponsePack(berr.PRUNED_DATA)
+	}
 	if err != nil || block == nil {
 		return ResponsePack(berr.UNKNOWN_BLOCK)
 	}
@@ -221,6 +234,9 @@ func GetTransactionByHash(cmd map[string]interface{}) map[string]interface{} {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
 	height, tx, err := bactor.GetTxnWithHeightByTxHash(hash)
+	if err == scom.ErrPruned {
+		return ResponsePack(berr.PRUNED_DATA)
+	}
 	if tx == nil {
 		return ResponsePack(berr.UNKNOWN_TRANSACTION)
 	}
@@ -308,6 +324,9 @@ func GetSmartCodeEventTxsByHeight(cmd map[string]interface{}) map[string]interfa
 		if scom.ErrNotFound == err {
 			return ResponsePack(berr.SUCCESS)
 		}
+		if scom.ErrPruned == err {
+			return ResponsePack(berr.PRUNED_DATA)
+		}
 		return ResponsePack(berr.INTERNAL_ERROR)
 	}
 	eInfos := make([]*bcomn.ExecuteNotify, 0, len(eventInfos))
@@ -340,6 +359,9 @@ func GetSmartCodeEventByTxHash(cmd map[string]interface{}) map[string]interface{
 		if scom.ErrNotFound == err {
 			return ResponsePack(berr.SUCCESS)
 		}
+		if scom.ErrPruned == err {
+			return ResponsePack(berr.PRUNED_DATA)
+		}
 		return ResponsePack(berr.INTERNAL_ERROR)
 	}
 	if eventInfo == nil {
@@ -394,11 +416,26 @@ func GetStorage(cmd map[string]interface{}) map[string]interface{} {
 	if err != nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
-	value, err := bactor.GetStorageItem(address, item)
+	var value []byte
+	if param, ok := cmd["Height"].(string); ok && len(param) > 0 {
+		height, e := strconv.ParseUint(param, 10, 32)
+		if e != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		value, err = bactor.GetStorageItemByHeight(address, item, uint32(height))
+	} else {
+		value, err = bactor.GetStorageItem(address, item)
+	}
 	if err != nil {
 		if err == scom.ErrNotFound {
 			return ResponsePack(berr.SUCCESS)
 		}
+		if err == scom.ErrNotArchived {
+			return ResponsePack(berr.UNKNOWN_STATE)
+		}
+		if err == scom.ErrPruned {
+			return ResponsePack(berr.PRUNED_DATA)
+		}
 		return ResponsePack(berr.INTERNAL_ERROR)
 	}
 	resp["Result"] = common.ToHexString(value)
@@ -416,7 +453,22 @@ func GetBalance(cmd map[string]interface{}) map[string]interface{} {
 	if err != nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
-	balance, err := bcomn.GetBalance(address)
+	var balance *bcomn.BalanceOfRsp
+	if param, ok := cmd["Height"].(string); ok && len(param) > 0 {
+		height, e := strconv.ParseUint(param, 10, 32)
+		if e != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		balance, err = bcomn.GetBalanceByHeight(address, uint32(height))
+		if err == scom.ErrNotArchived {
+			return ResponsePack(berr.UNKNOWN_STATE)
+		}
+		if err == scom.ErrPruned {
+			return ResponsePack(berr.PRUNED_DATA)
+		}
+	} else {
+		balance, err = bcomn.GetBalance(address)
+	}
 	if err != nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
@@ -435,11 +487,12 @@ func GetMerkleProof(cmd map[string]interface{}) map[string]interface{} {
 	if err != nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
+	// merkle proof only needs the height of transaction, which is kept after pruning
 	height, tx, err := bactor.GetTxnWithHeightByTxHash(hash)
-	if err != nil {
+	if err != nil && err != scom.ErrPruned {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
-	if tx == nil {
+	if err == nil && tx == nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
 	header, err := bactor.GetHeaderByHeight(height)
@@ -528,6 +581,43 @@ func GetUnboundOxg(cmd map[string]interface{}) map[string]interface{} {
 	return resp
 }
 
+//get onx vesting schedules
+func GetVesting(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	addrStr, ok := cmd["Addr"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	addr, err := bcomn.GetAddress(addrStr)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	rsp, err := bcomn.GetVesting(addr)
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = rsp
+	return resp
+}
+
+//get the DID document of onx id
+func GetDIDDocument(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	id, ok := cmd["Id"].(string)
+	if !ok || !account.VerifyID(id) {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	rsp, err := bcomn.GetDIDDocument(id)
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	if rsp == nil {
+		return ResponsePack(berr.UNKNOWN_ID)
+	}
+	resp["Result"] = rsp
+	return resp
+}
+
 //get grant oxg
 func GetGrantOxg(cmd map[string]interface{}) map[string]interface{} {
 	resp := ResponsePack(berr.SUCCESS)
@@ -547,7 +637,8 @@ func GetGrantOxg(cmd map[string]interface{}) map[string]interface{} {
 	return resp
 }
 
-//get memory pool transaction count
+//get memory pool transaction count of verified and pending, followed by the metrics of
+//tx pool: payer count, replaced, evicted and rejected transaction count
 func GetMemPoolTxCount(cmd map[string]interface{}) map[string]interface{} {
 	resp := ResponsePack(berr.SUCCESS)
 	count, err := bactor.GetTxnCount()
@@ -580,3 +671,177 @@ func GetMemPoolTxState(cmd map[string]interface{}) map[string]interface{} {
 	resp["Result"] = bcomn.TXNEntryInfo{attrs}
 	return resp
 }
+
+//get transactions in memory pool, filtered by the optional payer and contract address
+func GetMemPoolTxs(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	var filters [2]*common.Address
+	for i, name := range []string{"Payer", "Contract"} {
+		str, ok := cmd[name].(string)
+		if !ok || len(str) == 0 {
+			continue
+		}
+		address, err := bcomn.GetAddress(str)
+		if err != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		filters[i] = &address
+	}
+	txs, err := bcomn.GetMemPoolTxs(filters[0], filters[1])
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = txs
+	return resp
+}
+
+//get transactions which transfer from or to the address
+func GetAddressTxs(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	str, ok := cmd["Addr"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	address, err := bcomn.GetAddress(str)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	startHeight, endHeight, offset, limit, ok := getIndexQueryParams(cmd)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	rsp, err := bcomn.GetTxsByAddress(address, startHeight, endHeight, offset, limit)
+	if err != nil {
+		return responseIndexQueryError(err)
+	}
+	resp["Result"] = rsp
+	return resp
+}
+
+//get event notifies of the contract
+func GetContractEvents(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	str, ok := cmd["Hash"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	contract, err := bcomn.GetAddress(str)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	startHeight, endHeight, offset, limit, ok := getIndexQueryParams(cmd)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	rsp, err := bcomn.GetContractEvents(contract, startHeight, endHeight, offset, limit)
+	if err != nil {
+		return responseIndexQueryError(err)
+	}
+	resp["Result"] = rsp
+	return resp
+}
+
+//getIndexQueryParams parse the optional start, end, offset and limit of event index query.
+//The range defaults to the whole chain, and the limit defaults to DEFAULT_INDEX_QUERY_LIMIT
+func getIndexQueryParams(cmd map[string]interface{}) (uint32, uint32, uint32, uint32, bool) {
+	args := []uint32{0, bactor.GetCurrentBlockHeight(), 0, bcomn.DEFAULT_INDEX_QUERY_LIMIT}
+	for i, name := range []string{"Start", "End", "Offset", "Limit"} {
+		param, ok := cmd[name].(string)
+		if !ok || len(param) == 0 {
+			continue
+		}
+		v, err := strconv.ParseUint(param, 10, 32)
+		if err != nil {
+			return 0, 0, 0, 0, false
+		}
+		args[i] = uint32(v)
+	}
+	if args[3] > bcomn.MAX_INDEX_QUERY_LIMIT {
+		return 0, 0, 0, 0, false
+	}
+	return args[0], args[1], args[2], args[3], true
+}
+
+func responseIndexQueryError(err error) map[string]interface{} {
+	switch err {
+	case scom.ErrNotIndexed:
+		return ResponsePack(berr.UNINDEXED_DATA)
+	case scom.ErrPruned:
+		return ResponsePack(berr.PRUNED_DATA)
+	default:
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+}
+
+//pre-execute an unsigned raw transaction in Data, or a contract call
+func PreExecute(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	txn, returnType, err := parsePreExecuteParam(cmd)
+	if err != nil {
+		resp = ResponsePack(berr.INVALID_PARAMS)
+		resp["Result"] = err.Error()
+		return resp
+	}
+	result, err := bactor.PreExecuteContract(txn)
+	if err != nil {
+		log.Infof("PreExecute: %s", err)
+		resp = ResponsePack(berr.SMARTCODE_ERROR)
+		resp["Result"] = err.Error()
+		return resp
+	}
+	value, err := bcomn.ParseAbiReturnValue(result.Result, returnType)
+	if err != nil {
+		resp = ResponsePack(berr.SMARTCODE_ERROR)
+		resp["Result"] = err.Error()
+		return resp
+	}
+	resp["Result"] = bcomn.ConvertPreExecuteCallResult(result, value)
+	return resp
+}
+
+//estimate gas of an unsigned raw transaction in Data, or a contract call
+func EstimateGas(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	txn, _, err := parsePreExecuteParam(cmd)
+	if err != nil {
+		resp = ResponsePack(berr.INVALID_PARAMS)
+		resp["Result"] = err.Error()
+		return resp
+	}
+	result, err := bactor.PreExecuteContract(txn)
+	if err != nil {
+		log.Infof("EstimateGas: %s", err)
+		resp = ResponsePack(berr.SMARTCODE_ERROR)
+		resp["Result"] = err.Error()
+		return resp
+	}
+	resp["Result"] = bcomn.GasEstimate{Gas: result.Gas, GasLimit: bcomn.SuggestGasLimit(result.Gas)}
+	return resp
+}
+
+//parsePreExecuteParam return the transaction to pre-execute, and the return type of the contract method
+func parsePreExecuteParam(cmd map[string]interface{}) (*types.Transaction, string, error) {
+	if str, ok := cmd["Data"].(string); ok {
+		raw, err := common.HexToBytes(str)
+		if err != nil {
+			return nil, "", err
+		}
+		txn, err := types.TransactionFromRawBytes(raw)
+		if err != nil {
+			return nil, "", err
+		}
+		if txn.TxType != types.Invoke && txn.TxType != types.Deploy {
+			return nil, "", fmt.Errorf("transaction type error")
+		}
+		return txn, "", nil
+	}
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, "", err
+	}
+	call := &bcomn.PreExecuteCall{}
+	if err = json.Unmarshal(data, call); err != nil {
+		return nil, "", err
+	}
+	return bcomn.NewPreExecuteCallTransaction(call)
+}
@@ -21,6 +21,8 @@ package rpc
 import (
 	"bytes"
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"github.com/OnyxPay/OnyxChain/common"
 	"github.com/OnyxPay/OnyxChain/common/config"
 	"github.com/OnyxPay/OnyxChain/common/log"
@@ -32,6 +34,8 @@ import (
 	bcomn "github.com/OnyxPay/OnyxChain/http/base/common"
 	berr "github.com/OnyxPay/OnyxChain/http/base/error"
 	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	cstate "github.com/OnyxPay/OnyxChain/smartcontract/states"
+	"github.com/OnyxPay/OnyxChain/vm/neovm"
 )
 
 //get best block hash
@@ -70,6 +74,9 @@ func GetBlock(params []interface{}) map[string]interface{} {
 	}
 	block, err := bactor.GetBlockFromStore(hash)
 	if err != nil {
+		if err == scom.ErrPruned {
+			return responsePack(berr.PRUNED_DATA, "pruned block")
+		}
 		return responsePack(berr.UNKNOWN_BLOCK, "unknown block")
 	}
 	if len(params) >= 2 {
@@ -122,19 +129,35 @@ func GetConnectionCount(params []interface{}) map[string]interface{} {
 	return responseSuccess(count)
 }
 
+//get transactions in memory pool with their payer, gas price, nonce, age and verification state.
+//The optional params are the payer and the called contract address to filter the transactions, empty for any
+//A JSON example for getrawmempool method as following:
+//  {"jsonrpc": "2.0", "method": "getrawmempool", "params": ["payer address", "contract address"], "id": 0}
 func GetRawMemPool(params []interface{}) map[string]interface{} {
-	txs := []*bcomn.Transactions{}
-	txpool := bactor.GetTxsFromPool(false)
-	for _, t := range txpool {
-		txs = append(txs, bcomn.TransArryByteToHexString(t))
+	var filters [2]*common.Address
+	for i := 0; i < len(params) && i < len(filters); i++ {
+		str, ok := params[i].(string)
+		if !ok {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		if str == "" {
+			continue
+		}
+		address, err := bcomn.GetAddress(str)
+		if err != nil {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		filters[i] = &address
 	}
-	if len(txs) == 0 {
-		return responsePack(berr.INVALID_PARAMS, nil)
+	txs, err := bcomn.GetMemPoolTxs(filters[0], filters[1])
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, nil)
 	}
 	return responseSuccess(txs)
 }
 
-//get memory pool transaction count
+//get memory pool transaction count of verified and pending, followed by the metrics of
+//tx pool: payer count, replaced, evicted and rejected transaction count
 func GetMemPoolTxCount(params []interface{}) map[string]interface{} {
 	count, err := bactor.GetTxnCount()
 	if err != nil {
@@ -188,6 +211,9 @@ func GetRawTransaction(params []interface{}) map[string]interface{} {
 		}
 		h, t, err := bactor.GetTxnWithHeightByTxHash(hash)
 		if err != nil {
+			if err == scom.ErrPruned {
+				return responsePack(berr.PRUNED_DATA, "pruned transaction")
+			}
 			return responsePack(berr.UNKNOWN_TRANSACTION, "unknown transaction")
 		}
 		height = h
@@ -216,6 +242,8 @@ func GetRawTransaction(params []interface{}) map[string]interface{} {
 
 //get storage from contract
 //   {"jsonrpc": "2.0", "method": "getstorage", "params": ["code hash", "key"], "id": 0}
+// an optional block height can be appended to params to query the storage at that height, which requires archive mode:
+//   {"jsonrpc": "2.0", "method": "getstorage", "params": ["code hash", "key", 100], "id": 0}
 func GetStorage(params []interface{}) map[string]interface{} {
 	if len(params) < 2 {
 		return responsePack(berr.INVALID_PARAMS, nil)
@@ -246,11 +274,27 @@ func GetStorage(params []interface{}) map[string]interface{} {
 	default:
 		return responsePack(berr.INVALID_PARAMS, "")
 	}
-	value, err := bactor.GetStorageItem(address, key)
+	var value []byte
+	var err error
+	if len(params) >= 3 {
+		height, ok := params[2].(float64)
+		if !ok || height < 0 {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		value, err = bactor.GetStorageItemByHeight(address, key, uint32(height))
+	} else {
+		value, err = bactor.GetStorageItem(address, key)
+	}
 	if err != nil {
 		if err == scom.ErrNotFound {
 			return responseSuccess(nil)
 		}
+		if err == scom.ErrNotArchived {
+			return responsePack(berr.UNKNOWN_STATE, "")
+		}
+		if err == scom.ErrPruned {
+			return responsePack(berr.PRUNED_DATA, "")
+		}
 		return responsePack(berr.INVALID_PARAMS, "")
 	}
 	return responseSuccess(common.ToHexString(value))
@@ -368,6 +412,9 @@ func GetSmartCodeEvent(params []interface{}) map[string]interface{} {
 			if err == scom.ErrNotFound {
 				return responseSuccess(nil)
 			}
+			if err == scom.ErrPruned {
+				return responsePack(berr.PRUNED_DATA, "")
+			}
 			return responsePack(berr.INTERNAL_ERROR, "")
 		}
 		eInfos := make([]*bcomn.ExecuteNotify, 0, len(eventInfos))
@@ -388,6 +435,9 @@ func GetSmartCodeEvent(params []interface{}) map[string]interface{} {
 			if scom.ErrNotFound == err {
 				return responseSuccess(nil)
 			}
+			if scom.ErrPruned == err {
+				return responsePack(berr.PRUNED_DATA, "")
+			}
 			return responsePack(berr.INTERNAL_ERROR, "")
 		}
 		_, notify := bcomn.GetExecuteNotify(eventInfo)
@@ -412,8 +462,9 @@ func GetBlockHeightByTxHash(params []interface{}) map[string]interface{} {
 		if err != nil {
 			return responsePack(berr.INVALID_PARAMS, "")
 		}
+		// height of pruned transaction is still kept
 		height, _, err := bactor.GetTxnWithHeightByTxHash(hash)
-		if err != nil {
+		if err != nil && err != scom.ErrPruned {
 			return responsePack(berr.INVALID_PARAMS, "")
 		}
 		return responseSuccess(height)
@@ -423,7 +474,8 @@ func GetBlockHeightByTxHash(params []interface{}) map[string]interface{} {
 	return responsePack(berr.INVALID_PARAMS, "")
 }
 
-//get balance of address
+//get balance of address, at the block height if given
+//   {"jsonrpc": "2.0", "method": "getbalance", "params": ["address", 100], "id": 0}
 func GetBalance(params []interface{}) map[string]interface{} {
 	if len(params) < 1 {
 		return responsePack(berr.INVALID_PARAMS, "")
@@ -436,6 +488,23 @@ func GetBalance(params []interface{}) map[string]interface{} {
 	if err != nil {
 		return responsePack(berr.INVALID_PARAMS, "")
 	}
+	if len(params) >= 2 {
+		height, ok := params[1].(float64)
+		if !ok || height < 0 {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		rsp, err := bcomn.GetBalanceByHeight(address, uint32(height))
+		if err != nil {
+			if err == scom.ErrNotArchived {
+				return responsePack(berr.UNKNOWN_STATE, "")
+			}
+			if err == scom.ErrPruned {
+				return responsePack(berr.PRUNED_DATA, "")
+			}
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		return responseSuccess(rsp)
+	}
 	rsp, err := bcomn.GetBalance(address)
 	if err != nil {
 		return responsePack(berr.INVALID_PARAMS, "")
@@ -488,8 +557,9 @@ func GetMerkleProof(params []interface{}) map[string]interface{} {
 	if err != nil {
 		return responsePack(berr.INVALID_PARAMS, "")
 	}
+	// merkle proof only needs the height of transaction, which is kept after pruning
 	height, _, err := bactor.GetTxnWithHeightByTxHash(hash)
-	if err != nil {
+	if err != nil && err != scom.ErrPruned {
 		return responsePack(berr.INVALID_PARAMS, "")
 	}
 	header, err := bactor.GetHeaderByHeight(height)
@@ -528,6 +598,9 @@ func GetBlockTxsByHeight(params []interface{}) map[string]interface{} {
 		}
 		block, err := bactor.GetBlockFromStore(hash)
 		if err != nil {
+			if err == scom.ErrPruned {
+				return responsePack(berr.PRUNED_DATA, "")
+			}
 			return responsePack(berr.UNKNOWN_BLOCK, "")
 		}
 		return responseSuccess(bcomn.GetBlockTransactions(block))
@@ -566,6 +639,26 @@ func GetUnboundOxg(params []interface{}) map[string]interface{} {
 	return responseSuccess(rsp)
 }
 
+// get onx vesting schedules of address
+func GetVesting(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	addr, err := bcomn.GetAddress(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	rsp, err := bcomn.GetVesting(addr)
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, "")
+	}
+	return responseSuccess(rsp)
+}
+
 // get grant oxg of address
 func GetGrantOxg(params []interface{}) map[string]interface{} {
 	if len(params) < 1 {
@@ -585,3 +678,278 @@ func GetGrantOxg(params []interface{}) map[string]interface{} {
 	}
 	return responseSuccess(rsp)
 }
+
+//get transactions which transfer from or to the address, in the optional height range and page
+//   {"jsonrpc": "2.0", "method": "getaddresstxs", "params": ["address", startHeight, endHeight, offset, limit], "id": 0}
+func GetAddressTxs(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	address, err := bcomn.GetAddress(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	startHeight, endHeight, offset, limit, ok := getIndexQueryParams(params[1:])
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	rsp, err := bcomn.GetTxsByAddress(address, startHeight, endHeight, offset, limit)
+	if err != nil {
+		return responseIndexQueryError(err)
+	}
+	return responseSuccess(rsp)
+}
+
+//get event notifies of the contract, in the optional height range and page
+//   {"jsonrpc": "2.0", "method": "getcontractevents", "params": ["contract", startHeight, endHeight, offset, limit], "id": 0}
+func GetContractEvents(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	contract, err := bcomn.GetAddress(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	startHeight, endHeight, offset, limit, ok := getIndexQueryParams(params[1:])
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	rsp, err := bcomn.GetContractEvents(contract, startHeight, endHeight, offset, limit)
+	if err != nil {
+		return responseIndexQueryError(err)
+	}
+	return responseSuccess(rsp)
+}
+
+//getIndexQueryParams parse the optional [startHeight, endHeight, offset, limit] of event index query.
+//The range defaults to the whole chain, and the limit defaults to DEFAULT_INDEX_QUERY_LIMIT
+func getIndexQueryParams(params []interface{}) (uint32, uint32, uint32, uint32, bool) {
+	args := []uint32{0, bactor.GetCurrentBlockHeight(), 0, bcomn.DEFAULT_INDEX_QUERY_LIMIT}
+	if len(params) > len(args) {
+		return 0, 0, 0, 0, false
+	}
+	for i, param := range params {
+		v, ok := param.(float64)
+		if !ok || v < 0 {
+			return 0, 0, 0, 0, false
+		}
+		args[i] = uint32(v)
+	}
+	if args[3] > bcomn.MAX_INDEX_QUERY_LIMIT {
+		return 0, 0, 0, 0, false
+	}
+	return args[0], args[1], args[2], args[3], true
+}
+
+func responseIndexQueryError(err error) map[string]interface{} {
+	switch err {
+	case scom.ErrNotIndexed:
+		return responsePack(berr.UNINDEXED_DATA, "")
+	case scom.ErrPruned:
+		return responsePack(berr.PRUNED_DATA, "")
+	default:
+		return responsePack(berr.INTERNAL_ERROR, "")
+	}
+}
+
+//pre-execute an unsigned raw transaction, or a contract call in form of
+//{"contract": "contract address", "method": "method name", "params": [...], "payer": "address", "abi": {...}}
+//the optional second param 1 records the neovm execution steps, if tracing is enabled
+//   {"jsonrpc": "2.0", "method": "preexecute", "params": ["raw transaction", 1], "id": 0}
+func PreExecute(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	txn, returnType, err := parsePreExecuteParam(params[0])
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, err.Error())
+	}
+	if len(params) > 1 {
+		trace, ok := params[1].(float64)
+		if !ok {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		if trace == 1 {
+			if !config.DefConfig.Rpc.EnableTrace {
+				return responsePack(berr.INVALID_METHOD, "rpc trace is disabled")
+			}
+			return tracePreExecute(txn, returnType)
+		}
+	}
+	result, err := bactor.PreExecuteContract(txn)
+	if err != nil {
+		log.Infof("PreExecute: %s", err)
+		return responsePack(berr.SMARTCODE_ERROR, err.Error())
+	}
+	value, err := bcomn.ParseAbiReturnValue(result.Result, returnType)
+	if err != nil {
+		return responsePack(berr.SMARTCODE_ERROR, err.Error())
+	}
+	return responseSuccess(bcomn.ConvertPreExecuteCallResult(result, value))
+}
+
+//tracePreExecute pre-execute the transaction with a tracer, the steps are returned even if the execution failed
+func tracePreExecute(txn *types.Transaction, returnType string) map[string]interface{} {
+	logger := neovm.NewStructLogger(0)
+	result, err := bactor.PreExecuteContractWithTracer(txn, logger)
+	trace := &cstate.TraceResult{State: result.State, Gas: result.Gas, Notify: result.Notify}
+	var value interface{}
+	if err != nil {
+		trace.Error = err.Error()
+	} else if value, err = bcomn.ParseAbiReturnValue(result.Result, returnType); err != nil {
+		return responsePack(berr.SMARTCODE_ERROR, err.Error())
+	}
+	return responseSuccess(bcomn.ConvertTraceResult(trace, value, logger))
+}
+
+//re-execute a historical invoke transaction against the state at its height and record the neovm execution steps.
+//it is available only in archive mode with tracing enabled
+//   {"jsonrpc": "2.0", "method": "tracetransaction", "params": ["transaction hash"], "id": 0}
+func TraceTransaction(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	hash, err := common.Uint256FromHexString(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	logger := neovm.NewStructLogger(0)
+	result, err := bactor.TraceTransaction(hash, logger)
+	if err != nil {
+		switch err {
+		case scom.ErrNotFound:
+			return responsePack(berr.UNKNOWN_TRANSACTION, "")
+		case scom.ErrNotArchived:
+			return responsePack(berr.UNKNOWN_STATE, "")
+		case scom.ErrPruned:
+			return responsePack(berr.PRUNED_DATA, "")
+		default:
+			log.Infof("TraceTransaction: %s", err)
+			return responsePack(berr.SMARTCODE_ERROR, err.Error())
+		}
+	}
+	return responseSuccess(bcomn.ConvertTraceResult(result, nil, logger))
+}
+
+//get the transaction by hash from full nodes with merkle proof verified against local block headers.
+//it is available only in light node
+//   {"jsonrpc": "2.0", "method": "getverifiedtransaction", "params": ["transaction hash"], "id": 0}
+func GetVerifiedTransaction(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	hash, err := common.Uint256FromHexString(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	proof, err := bactor.GetVerifiedTxProof(hash)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return responsePack(berr.UNKNOWN_TRANSACTION, "")
+		}
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	txinfo := bcomn.TransArryByteToHexString(proof.Tx)
+	txinfo.Height = proof.Height
+	return responseSuccess(txinfo)
+}
+
+//get storage from full nodes with state proof verified. it is available only in light node
+//   {"jsonrpc": "2.0", "method": "getverifiedstorage", "params": ["code hash", "key"], "id": 0}
+func GetVerifiedStorage(params []interface{}) map[string]interface{} {
+	if len(params) < 2 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	address, err := bcomn.GetAddress(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok = params[1].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	key, err := hex.DecodeString(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	proof, err := bactor.GetVerifiedStorageProof(address, key)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return responseSuccess(nil)
+		}
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	if len(proof.Value) == 0 {
+		return responseSuccess(nil)
+	}
+	return responseSuccess(common.ToHexString(proof.Value))
+}
+
+//estimate gas of an unsigned raw transaction, or a contract call in the same form of preexecute
+//   {"jsonrpc": "2.0", "method": "estimategas", "params": ["raw transaction"], "id": 0}
+func EstimateGas(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	txn, _, err := parsePreExecuteParam(params[0])
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, err.Error())
+	}
+	result, err := bactor.PreExecuteContract(txn)
+	if err != nil {
+		log.Infof("EstimateGas: %s", err)
+		return responsePack(berr.SMARTCODE_ERROR, err.Error())
+	}
+	return responseSuccess(bcomn.GasEstimate{Gas: result.Gas, GasLimit: bcomn.SuggestGasLimit(result.Gas)})
+}
+
+//parsePreExecuteParam return the transaction to pre-execute, and the return type of the contract method
+func parsePreExecuteParam(param interface{}) (*types.Transaction, string, error) {
+	switch p := param.(type) {
+	case string:
+		raw, err := common.HexToBytes(p)
+		if err != nil {
+			return nil, "", err
+		}
+		txn, err := types.TransactionFromRawBytes(raw)
+		if err != nil {
+			return nil, "", err
+		}
+		if txn.TxType != types.Invoke && txn.TxType != types.Deploy {
+			return nil, "", fmt.Errorf("transaction type error")
+		}
+		return txn, "", nil
+	case map[string]interface{}:
+		data, err := json.Marshal(p)
+		if err != nil {
+			return nil, "", err
+		}
+		call := &bcomn.PreExecuteCall{}
+		if err = json.Unmarshal(data, call); err != nil {
+			return nil, "", err
+		}
+		return bcomn.NewPreExecuteCallTransaction(call)
+	default:
+		return nil, "", fmt.Errorf("invalid param")
+	}
+}
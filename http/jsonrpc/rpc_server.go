@@ -39,7 +39,7 @@ func StartRPCServer() error {
 	rpc.HandleFunc("getblockcount", rpc.GetBlockCount)
 	rpc.HandleFunc("getblockhash", rpc.GetBlockHash)
 	rpc.HandleFunc("getconnectioncount", rpc.GetConnectionCount)
-	//HandleFunc("getrawmempool", GetRawMemPool)
+	rpc.HandleFunc("getrawmempool", rpc.GetRawMemPool)
 
 	rpc.HandleFunc("getrawtransaction", rpc.GetRawTransaction)
 	rpc.HandleFunc("sendrawtransaction", rpc.SendRawTransaction)
@@ -60,6 +60,16 @@ func StartRPCServer() error {
 	rpc.HandleFunc("getgasprice", rpc.GetGasPrice)
 	rpc.HandleFunc("getunboundoxg", rpc.GetUnboundOxg)
 	rpc.HandleFunc("getgrantoxg", rpc.GetGrantOxg)
+	rpc.HandleFunc("getvesting", rpc.GetVesting)
+	rpc.HandleFunc("getaddresstxs", rpc.GetAddressTxs)
+	rpc.HandleFunc("getcontractevents", rpc.GetContractEvents)
+	rpc.HandleFunc("preexecute", rpc.PreExecute)
+	rpc.HandleFunc("estimategas", rpc.EstimateGas)
+	if cfg.DefConfig.Rpc.EnableTrace {
+		rpc.HandleFunc("tracetransaction", rpc.TraceTransaction)
+	}
+	rpc.HandleFunc("getverifiedtransaction", rpc.GetVerifiedTransaction)
+	rpc.HandleFunc("getverifiedstorage", rpc.GetVerifiedStorage)
 
 	port := int(cfg.DefConfig.Rpc.HttpJsonPort)
 	certPath := cfg.DefConfig.Rpc.HttpCertPath
@@ -36,11 +36,20 @@ type Route struct {
 	Handler http.HandlerFunc
 }
 type Router struct {
-	routes []*Route
+	routes   []*Route
+	patterns map[string]map[string]string //param patterns other than \w+, keyed by route path and param name
 }
 
 func NewRouter() *Router {
-	return &Router{}
+	return &Router{patterns: make(map[string]map[string]string)}
+}
+
+//ParamPattern set the regexp pattern of the param in route path, it should be called before the route is added
+func (this *Router) ParamPattern(path string, param string, pattern string) {
+	if this.patterns[path] == nil {
+		this.patterns[path] = make(map[string]string)
+	}
+	this.patterns[path][param] = pattern
 }
 
 func (this *Router) Try(path string, method string) (http.HandlerFunc, paramsMap, error) {
@@ -66,6 +75,7 @@ func (this *Router) Try(path string, method string) (http.HandlerFunc, paramsMap
 func (this *Router) add(method string, path string, handler http.HandlerFunc) {
 	route := &Route{}
 	route.Method = method
+	patterns := this.patterns[path]
 	path = "^" + path + "$"
 	route.Handler = handler
 
@@ -74,7 +84,11 @@ func (this *Router) add(method string, path string, handler http.HandlerFunc) {
 		if matches != nil {
 			for _, v := range matches {
 				route.Params = append(route.Params, v[1])
-				path = strings.Replace(path, v[0], `(\w+)`, 1)
+				pattern, ok := patterns[v[1]]
+				if !ok {
+					pattern = `\w+`
+				}
+				path = strings.Replace(path, v[0], "("+pattern+")", 1)
 			}
 		}
 	}
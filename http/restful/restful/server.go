@@ -71,12 +71,19 @@ const (
 	GET_ALLOWANCE         = "/api/v1/allowance/:asset/:from/:to"
 	GET_UNBOUNDOXG        = "/api/v1/unboundoxg/:addr"
 	GET_GRANTOXG          = "/api/v1/grantong/:addr"
+	GET_VESTING           = "/api/v1/vesting/:addr"
+	GET_DID_DOCUMENT      = "/api/v1/did/:id"
 	GET_MEMPOOL_TXCOUNT   = "/api/v1/mempool/txcount"
 	GET_MEMPOOL_TXSTATE   = "/api/v1/mempool/txstate/:hash"
+	GET_MEMPOOL_TXS       = "/api/v1/mempool/txs"
 	GET_VERSION           = "/api/v1/version"
 	GET_NETWORKID         = "/api/v1/networkid"
+	GET_ADDRESS_TXS       = "/api/v1/address/transactions/:addr"
+	GET_CONTRACT_EVTS     = "/api/v1/smartcode/event/contract/:hash"
 
-	POST_RAW_TX = "/api/v1/transaction"
+	POST_RAW_TX       = "/api/v1/transaction"
+	POST_PRE_EXECUTE  = "/api/v1/preexecute"
+	POST_ESTIMATE_GAS = "/api/v1/estimategas"
 )
 
 //init restful server
@@ -84,6 +91,7 @@ func InitRestServer() rest.ApiServer {
 	rt := &restServer{}
 
 	rt.router = NewRouter()
+	rt.router.ParamPattern(GET_DID_DOCUMENT, "id", `did:[0-9a-z]+:[0-9A-Za-z]+`)
 	rt.registryMethod()
 	rt.initGetHandler()
 	rt.initPostHandler()
@@ -152,14 +160,21 @@ func (this *restServer) registryMethod() {
 		GET_GAS_PRICE:         {name: "getgasprice", handler: rest.GetGasPrice},
 		GET_UNBOUNDOXG:        {name: "getunboundoxg", handler: rest.GetUnboundOxg},
 		GET_GRANTOXG:          {name: "getgrantoxg", handler: rest.GetGrantOxg},
+		GET_VESTING:           {name: "getvesting", handler: rest.GetVesting},
+		GET_DID_DOCUMENT:      {name: "getdiddocument", handler: rest.GetDIDDocument},
 		GET_MEMPOOL_TXCOUNT:   {name: "getmempooltxcount", handler: rest.GetMemPoolTxCount},
 		GET_MEMPOOL_TXSTATE:   {name: "getmempooltxstate", handler: rest.GetMemPoolTxState},
+		GET_MEMPOOL_TXS:       {name: "getrawmempool", handler: rest.GetMemPoolTxs},
 		GET_VERSION:           {name: "getversion", handler: rest.GetNodeVersion},
 		GET_NETWORKID:         {name: "getnetworkid", handler: rest.GetNetworkId},
+		GET_ADDRESS_TXS:       {name: "getaddresstxs", handler: rest.GetAddressTxs},
+		GET_CONTRACT_EVTS:     {name: "getcontractevents", handler: rest.GetContractEvents},
 	}
 
 	postMethodMap := map[string]Action{
-		POST_RAW_TX: {name: "sendrawtransaction", handler: rest.SendRawTransaction},
+		POST_RAW_TX:       {name: "sendrawtransaction", handler: rest.SendRawTransaction},
+		POST_PRE_EXECUTE:  {name: "preexecute", handler: rest.PreExecute},
+		POST_ESTIMATE_GAS: {name: "estimategas", handler: rest.EstimateGas},
 	}
 	this.postMap = postMethodMap
 	this.getMap = getMethodMap
@@ -196,8 +211,16 @@ func (this *restServer) getPath(url string) string {
 		return GET_UNBOUNDOXG
 	} else if strings.Contains(url, strings.TrimRight(GET_GRANTOXG, ":addr")) {
 		return GET_GRANTOXG
+	} else if strings.Contains(url, strings.TrimRight(GET_VESTING, ":addr")) {
+		return GET_VESTING
+	} else if strings.Contains(url, strings.TrimRight(GET_DID_DOCUMENT, ":id")) {
+		return GET_DID_DOCUMENT
 	} else if strings.Contains(url, strings.TrimRight(GET_MEMPOOL_TXSTATE, ":hash")) {
 		return GET_MEMPOOL_TXSTATE
+	} else if strings.Contains(url, strings.TrimRight(GET_ADDRESS_TXS, ":addr")) {
+		return GET_ADDRESS_TXS
+	} else if strings.Contains(url, strings.TrimRight(GET_CONTRACT_EVTS, ":hash")) {
+		return GET_CONTRACT_EVTS
 	}
 	return url
 }
@@ -223,6 +246,7 @@ func (this *restServer) getParams(r *http.Request, url string, req map[string]in
 		req["PreExec"] = r.FormValue("preExec")
 	case GET_STORAGE:
 		req["Hash"], req["Key"] = getParam(r, "hash"), getParam(r, "key")
+		req["Height"] = r.FormValue("height")
 	case GET_SMTCOCE_EVT_TXS:
 		req["Height"] = getParam(r, "height")
 	case GET_SMTCOCE_EVTS:
@@ -230,7 +254,7 @@ func (this *restServer) getParams(r *http.Request, url string, req map[string]in
 	case GET_BLK_HGT_BY_TXHASH:
 		req["Hash"] = getParam(r, "hash")
 	case GET_BALANCE:
-		req["Addr"] = getParam(r, "addr")
+		req["Addr"], req["Height"] = getParam(r, "addr"), r.FormValue("height")
 	case GET_MERKLE_PROOF:
 		req["Hash"] = getParam(r, "hash")
 	case GET_ALLOWANCE:
@@ -240,8 +264,22 @@ func (this *restServer) getParams(r *http.Request, url string, req map[string]in
 		req["Addr"] = getParam(r, "addr")
 	case GET_GRANTOXG:
 		req["Addr"] = getParam(r, "addr")
+	case GET_VESTING:
+		req["Addr"] = getParam(r, "addr")
+	case GET_DID_DOCUMENT:
+		req["Id"] = getParam(r, "id")
 	case GET_MEMPOOL_TXSTATE:
 		req["Hash"] = getParam(r, "hash")
+	case GET_MEMPOOL_TXS:
+		req["Payer"], req["Contract"] = r.FormValue("payer"), r.FormValue("contract")
+	case GET_ADDRESS_TXS:
+		req["Addr"] = getParam(r, "addr")
+		req["Start"], req["End"] = r.FormValue("start"), r.FormValue("end")
+		req["Offset"], req["Limit"] = r.FormValue("offset"), r.FormValue("limit")
+	case GET_CONTRACT_EVTS:
+		req["Hash"] = getParam(r, "hash")
+		req["Start"], req["End"] = r.FormValue("start"), r.FormValue("end")
+		req["Offset"], req["Limit"] = r.FormValue("offset"), r.FormValue("limit")
 	default:
 	}
 	return req
@@ -38,6 +38,7 @@ var ws *websocket.WsServer
 func StartServer() {
 	bactor.SubscribeEvent(message.TOPIC_SAVE_BLOCK_COMPLETE, sendBlock2WSclient)
 	bactor.SubscribeEvent(message.TOPIC_SMART_CODE_EVENT, pushSmartCodeEvent)
+	bactor.SubscribeEvent(message.TOPIC_PENDING_TX, pushPendingTx)
 	go func() {
 		ws = websocket.InitWsServer()
 		ws.Start()
@@ -80,9 +81,11 @@ func pushSmartCodeEvent(v interface{}) {
 		case *event.LogEventArgs:
 			contractAddrs, evts := bcomn.GetLogEvent(object)
 			pushEvent(contractAddrs, rs.TxHash.ToHexString(), rs.Error, rs.Action, evts)
+			ws.PushLogToSubscriptions(rs.Action, evts)
 		case *event.ExecuteNotify:
 			contractAddrs, notify := bcomn.GetExecuteNotify(object)
 			pushEvent(contractAddrs, rs.TxHash.ToHexString(), rs.Error, rs.Action, notify)
+			ws.PushNotifyToSubscriptions(rs.Action, notify)
 		default:
 		}
 	}()
@@ -111,8 +114,20 @@ func pushBlock(v interface{}) {
 		ws.BroadcastToSubscribers(nil, websocket.WSTOPIC_RAW_BLOCK, resp)
 
 		resp["Action"] = "sendjsonblock"
-		resp["Result"] = bcomn.GetBlockInfo(&block)
+		blockInfo := bcomn.GetBlockInfo(&block)
+		resp["Result"] = blockInfo
 		ws.BroadcastToSubscribers(nil, websocket.WSTOPIC_JSON_BLOCK, resp)
+
+		ws.PushHeader(blockInfo.Header)
+	}
+}
+
+func pushPendingTx(v interface{}) {
+	if ws == nil || cfg.DefConfig.Ws.HttpWsPort == 0 {
+		return
+	}
+	if tx, ok := v.(*types.Transaction); ok {
+		go ws.PushPendingTx(tx)
 	}
 }
 func pushBlockTransactions(v interface{}) {
@@ -43,6 +43,8 @@ const (
 	WSTOPIC_JSON_BLOCK = 2
 	WSTOPIC_RAW_BLOCK  = 3
 	WSTOPIC_TXHASHS    = 4
+	WSTOPIC_PENDING_TX = 5
+	WSTOPIC_HEADER     = 6
 )
 
 type handler func(map[string]interface{}) map[string]interface{}
@@ -68,6 +70,8 @@ type WsServer struct {
 	ActionMap    map[string]Handler   //handler functions
 	TxHashMap    map[string]string    //key: txHash   value:sessionid
 	SubscribeMap map[string]subscribe //key: sessionId   value:subscribeInfo
+	//key: sessionId   value:subscriptions of the session by subscription id
+	Subscriptions map[string]map[string]*subscription
 }
 
 //init websocket server
@@ -77,6 +81,8 @@ func InitWsServer() *WsServer {
 		SessionList:  session.NewSessionList(),
 		TxHashMap:    make(map[string]string),
 		SubscribeMap: make(map[string]subscribe),
+
+		Subscriptions: make(map[string]map[string]*subscription),
 	}
 	return ws
 }
@@ -172,6 +178,31 @@ func (self *WsServer) registryMethod() {
 		resp["Result"] = sub
 		return resp
 	}
+	addsubscription := func(cmd map[string]interface{}) map[string]interface{} {
+		sub, err := newSubscription(cmd)
+		if err != nil {
+			return rest.ResponsePack(Err.INVALID_PARAMS)
+		}
+		sessionId, _ := cmd["SessionId"].(string)
+		if !self.addSubscription(sessionId, sub) {
+			return rest.ResponsePack(Err.INVALID_PARAMS)
+		}
+		resp := rest.ResponsePack(Err.SUCCESS)
+		resp["Action"] = "addsubscription"
+		resp["Result"] = sub
+		return resp
+	}
+	removesubscription := func(cmd map[string]interface{}) map[string]interface{} {
+		sessionId, _ := cmd["SessionId"].(string)
+		id, _ := cmd["SubscriptionId"].(string)
+		if !self.removeSubscription(sessionId, id) {
+			return rest.ResponsePack(Err.INVALID_PARAMS)
+		}
+		resp := rest.ResponsePack(Err.SUCCESS)
+		resp["Action"] = "removesubscription"
+		resp["Result"] = id
+		return resp
+	}
 	getsessioncount := func(cmd map[string]interface{}) map[string]interface{} {
 		resp := rest.ResponsePack(Err.SUCCESS)
 		resp["Action"] = "getsessioncount"
@@ -193,6 +224,8 @@ func (self *WsServer) registryMethod() {
 		"sendrawtransaction":        {handler: rest.SendRawTransaction, pushFlag: true},
 		"heartbeat":                 {handler: heartbeat},
 		"subscribe":                 {handler: subscribe},
+		"addsubscription":           {handler: addsubscription},
+		"removesubscription":        {handler: removesubscription},
 		"getstorage":                {handler: rest.GetStorage},
 		"getallowance":              {handler: rest.GetAllowance},
 		"getmerkleproof":            {handler: rest.GetMerkleProof},
@@ -200,6 +233,7 @@ func (self *WsServer) registryMethod() {
 		"getgasprice":               {handler: rest.GetGasPrice},
 		"getunboundoxg":             {handler: rest.GetUnboundOxg},
 		"getgrantoxg":               {handler: rest.GetGrantOxg},
+		"getvesting":                {handler: rest.GetVesting},
 		"getmempooltxcount":         {handler: rest.GetMemPoolTxCount},
 		"getmempooltxstate":         {handler: rest.GetMemPoolTxState},
 		"getversion":                {handler: rest.GetNodeVersion},
@@ -376,6 +410,7 @@ func (self *WsServer) deleteSubscribe(sessionId string) {
 	self.Lock()
 	defer self.Unlock()
 	delete(self.SubscribeMap, sessionId)
+	delete(self.Subscriptions, sessionId)
 }
 
 func marshalResp(resp map[string]interface{}) []byte {
@@ -0,0 +1,274 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package websocket
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	cutils "github.com/OnyxPay/OnyxChain/core/utils"
+	bcomn "github.com/OnyxPay/OnyxChain/http/base/common"
+	Err "github.com/OnyxPay/OnyxChain/http/base/error"
+	"github.com/OnyxPay/OnyxChain/http/base/rest"
+	"github.com/pborman/uuid"
+)
+
+const MAX_SUBSCRIPTIONS_PER_SESSION = 64
+
+//topics of the subscriptions created by addsubscription
+var subscriptionTopics = map[string]int{
+	"event":     WSTOPIC_EVENT,
+	"pendingtx": WSTOPIC_PENDING_TX,
+	"header":    WSTOPIC_HEADER,
+}
+
+//subscription is a topic subscribed by a session with an optional filter.
+//Filters are ANDed, an address filter matches if any of the addresses is involved
+type subscription struct {
+	Id        string   `json:"SubscriptionId"`
+	Topic     string   `json:"Topic"`
+	Contract  string   `json:"Contract,omitempty"`
+	EventName string   `json:"EventName,omitempty"`
+	Addresses []string `json:"Addresses,omitempty"`
+	topic     int
+	addresses []common.Address
+}
+
+//newSubscription parse the subscription from the request of addsubscription
+func newSubscription(cmd map[string]interface{}) (*subscription, error) {
+	name, _ := cmd["Topic"].(string)
+	topic, ok := subscriptionTopics[name]
+	if !ok {
+		return nil, errors.New("unknown topic")
+	}
+	sub := &subscription{Id: uuid.NewUUID().String(), Topic: name, topic: topic}
+	if str, ok := cmd["Contract"].(string); ok && str != "" {
+		contract, err := bcomn.GetAddress(str)
+		if err != nil {
+			return nil, err
+		}
+		sub.Contract = contract.ToHexString()
+	}
+	if str, ok := cmd["EventName"].(string); ok {
+		sub.EventName = str
+	}
+	if addrs, ok := cmd["Addresses"].([]interface{}); ok {
+		for _, v := range addrs {
+			str, ok := v.(string)
+			if !ok {
+				return nil, errors.New("invalid address")
+			}
+			addr, err := bcomn.GetAddress(str)
+			if err != nil {
+				return nil, err
+			}
+			sub.Addresses = append(sub.Addresses, addr.ToBase58())
+			sub.addresses = append(sub.addresses, addr)
+		}
+	}
+	switch topic {
+	case WSTOPIC_PENDING_TX:
+		if sub.EventName != "" {
+			return nil, errors.New("event name filter is only supported by event topic")
+		}
+	case WSTOPIC_HEADER:
+		if sub.Contract != "" || sub.EventName != "" || len(sub.addresses) != 0 {
+			return nil, errors.New("header topic does not support filters")
+		}
+	}
+	return sub, nil
+}
+
+//matchNotify check whether the notify of a contract matches the filter
+func (self *subscription) matchNotify(notify *bcomn.NotifyEventInfo) bool {
+	if self.Contract != "" && self.Contract != notify.ContractAddress {
+		return false
+	}
+	if self.EventName != "" {
+		states, ok := notify.States.([]interface{})
+		if !ok || len(states) == 0 {
+			return false
+		}
+		name, ok := states[0].(string)
+		if !ok || (name != self.EventName && name != hex.EncodeToString([]byte(self.EventName))) {
+			return false
+		}
+	}
+	if len(self.addresses) == 0 {
+		return true
+	}
+	for _, addr := range self.addresses {
+		if containsAddress(notify.States, addr) {
+			return true
+		}
+	}
+	return false
+}
+
+//matchTx check whether the transaction matches the filter, the addresses are matched against the payer
+func (self *subscription) matchTx(tx *types.Transaction) bool {
+	if len(self.addresses) != 0 {
+		found := false
+		for _, addr := range self.addresses {
+			if addr == tx.Payer {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if self.Contract == "" {
+		return true
+	}
+	for _, addr := range cutils.GetTxContracts(tx) {
+		if addr.ToHexString() == self.Contract {
+			return true
+		}
+	}
+	return false
+}
+
+//containsAddress check whether the address is in the states in base58 or hex
+func containsAddress(states interface{}, addr common.Address) bool {
+	switch v := states.(type) {
+	case string:
+		return v == addr.ToBase58() || v == addr.ToHexString() || v == hex.EncodeToString(addr[:])
+	case []interface{}:
+		for _, item := range v {
+			if containsAddress(item, addr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//addSubscription add a subscription of the session
+func (self *WsServer) addSubscription(sessionId string, sub *subscription) bool {
+	self.Lock()
+	defer self.Unlock()
+	subs, ok := self.Subscriptions[sessionId]
+	if !ok {
+		subs = make(map[string]*subscription)
+		self.Subscriptions[sessionId] = subs
+	}
+	if len(subs) >= MAX_SUBSCRIPTIONS_PER_SESSION {
+		return false
+	}
+	subs[sub.Id] = sub
+	return true
+}
+
+//removeSubscription remove a subscription of the session
+func (self *WsServer) removeSubscription(sessionId string, id string) bool {
+	self.Lock()
+	defer self.Unlock()
+	subs := self.Subscriptions[sessionId]
+	if _, ok := subs[id]; !ok {
+		return false
+	}
+	delete(subs, id)
+	if len(subs) == 0 {
+		delete(self.Subscriptions, sessionId)
+	}
+	return true
+}
+
+//pushToSubscriptions send the result returned by getResult to every subscription of the topic,
+//nothing is sent to a subscription if getResult returns nil
+func (self *WsServer) pushToSubscriptions(topic int, action string, getResult func(sub *subscription) interface{}) {
+	self.RLock()
+	defer self.RUnlock()
+	for sid, subs := range self.Subscriptions {
+		s := self.SessionList.GetSessionById(sid)
+		if s == nil {
+			continue
+		}
+		for _, sub := range subs {
+			if sub.topic != topic {
+				continue
+			}
+			result := getResult(sub)
+			if result == nil {
+				continue
+			}
+			resp := rest.ResponsePack(Err.SUCCESS)
+			resp["Action"] = action
+			resp["Result"] = result
+			resp["SubscriptionId"] = sub.Id
+			s.Send(marshalResp(resp))
+		}
+	}
+}
+
+//PushNotifyToSubscriptions send the notifies of a transaction matching the filter of event subscriptions
+func (self *WsServer) PushNotifyToSubscriptions(action string, notify bcomn.ExecuteNotify) {
+	self.pushToSubscriptions(WSTOPIC_EVENT, action, func(sub *subscription) interface{} {
+		notifies := []bcomn.NotifyEventInfo{}
+		for i := range notify.Notify {
+			if sub.matchNotify(&notify.Notify[i]) {
+				notifies = append(notifies, notify.Notify[i])
+			}
+		}
+		if len(notifies) == 0 {
+			return nil
+		}
+		result := notify
+		result.Notify = notifies
+		return result
+	})
+}
+
+//PushLogToSubscriptions send the log event to the event subscriptions filtered by contract only
+func (self *WsServer) PushLogToSubscriptions(action string, evt bcomn.LogEventArgs) {
+	self.pushToSubscriptions(WSTOPIC_EVENT, action, func(sub *subscription) interface{} {
+		if sub.EventName != "" || len(sub.addresses) != 0 {
+			return nil
+		}
+		if sub.Contract != "" && sub.Contract != evt.ContractAddress {
+			return nil
+		}
+		return evt
+	})
+}
+
+//PushPendingTx send the transaction entered the tx pool to the pendingtx subscriptions
+func (self *WsServer) PushPendingTx(tx *types.Transaction) {
+	var result *bcomn.Transactions
+	self.pushToSubscriptions(WSTOPIC_PENDING_TX, "sendpendingtx", func(sub *subscription) interface{} {
+		if !sub.matchTx(tx) {
+			return nil
+		}
+		if result == nil {
+			result = bcomn.TransArryByteToHexString(tx)
+		}
+		return result
+	})
+}
+
+//PushHeader send the header of the new block to the header subscriptions
+func (self *WsServer) PushHeader(header *bcomn.BlockHead) {
+	self.pushToSubscriptions(WSTOPIC_HEADER, "sendheader", func(sub *subscription) interface{} {
+		return header
+	})
+}
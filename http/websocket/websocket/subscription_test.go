@@ -0,0 +1,78 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package websocket
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	bcomn "github.com/OnyxPay/OnyxChain/http/base/common"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionFilter(t *testing.T) {
+	from := common.Address{1}
+	to := common.Address{2}
+	sub, err := newSubscription(map[string]interface{}{
+		"Topic":     "event",
+		"Contract":  utils.OnxContractAddress.ToHexString(),
+		"EventName": "transfer",
+		"Addresses": []interface{}{to.ToBase58()},
+	})
+	assert.Nil(t, err)
+
+	notify := &bcomn.NotifyEventInfo{
+		ContractAddress: utils.OnxContractAddress.ToHexString(),
+		States:          []interface{}{"transfer", from.ToBase58(), to.ToBase58(), uint64(1)},
+	}
+	assert.True(t, sub.matchNotify(notify))
+
+	notify.States = []interface{}{hex.EncodeToString([]byte("transfer")), hex.EncodeToString(from[:]),
+		hex.EncodeToString(to[:]), "01"}
+	assert.True(t, sub.matchNotify(notify))
+
+	notify.States = []interface{}{"approve", from.ToBase58(), to.ToBase58(), uint64(1)}
+	assert.False(t, sub.matchNotify(notify))
+
+	notify.States = []interface{}{"transfer", from.ToBase58(), from.ToBase58(), uint64(1)}
+	assert.False(t, sub.matchNotify(notify))
+
+	notify.ContractAddress = utils.OxgContractAddress.ToHexString()
+	notify.States = []interface{}{"transfer", from.ToBase58(), to.ToBase58(), uint64(1)}
+	assert.False(t, sub.matchNotify(notify))
+}
+
+func TestNewSubscription(t *testing.T) {
+	_, err := newSubscription(map[string]interface{}{"Topic": "unknown"})
+	assert.NotNil(t, err)
+	_, err = newSubscription(map[string]interface{}{"Topic": "header", "Contract": utils.OnxContractAddress.ToHexString()})
+	assert.NotNil(t, err)
+	_, err = newSubscription(map[string]interface{}{"Topic": "pendingtx", "EventName": "transfer"})
+	assert.NotNil(t, err)
+	_, err = newSubscription(map[string]interface{}{"Topic": "event", "Addresses": []interface{}{"invalid"}})
+	assert.NotNil(t, err)
+
+	a, err := newSubscription(map[string]interface{}{"Topic": "pendingtx"})
+	assert.Nil(t, err)
+	b, err := newSubscription(map[string]interface{}{"Topic": "pendingtx"})
+	assert.Nil(t, err)
+	assert.NotEqual(t, a.Id, b.Id)
+}
@@ -34,6 +34,7 @@ import (
 	alog "github.com/OnyxPay/OnyxChain-eventbus/log"
 	"github.com/OnyxPay/OnyxChain/account"
 	"github.com/OnyxPay/OnyxChain/cmd"
+	"github.com/OnyxPay/OnyxChain/cmd/abi"
 	cmdcom "github.com/OnyxPay/OnyxChain/cmd/common"
 	"github.com/OnyxPay/OnyxChain/cmd/utils"
 	"github.com/OnyxPay/OnyxChain/common"
@@ -74,10 +75,13 @@ func setupAPP() *cli.App {
 		cmd.ContractCommand,
 		cmd.ImportCommand,
 		cmd.ExportCommand,
+		cmd.SnapshotCommand,
 		cmd.TxCommond,
 		cmd.SigTxCommand,
 		cmd.MultiSigAddrCommand,
 		cmd.MultiSigTxCommand,
+		cmd.MultisigCommand,
+		cmd.CredentialCommand,
 		cmd.SendTxCommand,
 		cmd.ShowTxCommand,
 	}
@@ -86,6 +90,10 @@ func setupAPP() *cli.App {
 		utils.ConfigFlag,
 		utils.LogLevelFlag,
 		utils.DisableEventLogFlag,
+		utils.EventIndexFlag,
+		utils.ArchiveModeFlag,
+		utils.PruneBlocksFlag,
+		utils.LightNodeFlag,
 		utils.DataDirFlag,
 		utils.CertFileFlag,
 		utils.KeyFileFlag,
@@ -99,6 +107,8 @@ func setupAPP() *cli.App {
 		//txpool setting
 		utils.GasPriceFlag,
 		utils.GasLimitFlag,
+		utils.MaxTxInPoolFlag,
+		utils.MaxTxPerAccountFlag,
 		utils.TxpoolPreExecDisableFlag,
 		utils.DisableSyncVerifyTxFlag,
 		utils.DisableBroadcastNetTxFlag,
@@ -113,14 +123,18 @@ func setupAPP() *cli.App {
 		utils.MaxConnInBoundFlag,
 		utils.MaxConnOutBoundFlag,
 		utils.MaxConnInBoundForSingleIPFlag,
+		utils.DHTFlag,
+		utils.SecureTransportFlag,
 		//test mode setting
 		utils.EnableTestModeFlag,
 		utils.TestModeGenBlockTimeFlag,
 		//rpc setting
 		utils.RPCDisabledFlag,
 		utils.RPCPortFlag,
+		utils.RPCTraceEnableFlag,
 		utils.RPCLocalEnableFlag,
 		utils.RPCLocalProtFlag,
+		utils.NativeAbiPathFlag,
 		//rest setting
 		utils.RestfulEnableFlag,
 		utils.RestfulPortFlag,
@@ -172,7 +186,7 @@ func startOnyxChain(ctx *cli.Context) {
 		log.Errorf("initTxPool error:%s", err)
 		return
 	}
-	p2pSvr, p2pPid, err := initP2PNode(ctx, txpool)
+	p2pSvr, p2pPid, err := initP2PNode(ctx, txpool, acc)
 	if err != nil {
 		log.Errorf("initP2PNode error:%s", err)
 		return
@@ -182,6 +196,7 @@ func startOnyxChain(ctx *cli.Context) {
 		log.Errorf("initConsensus error:%s", err)
 		return
 	}
+	initNativeAbi(ctx)
 	err = initRpc(ctx)
 	if err != nil {
 		log.Errorf("initRpc error:%s", err)
@@ -218,7 +233,7 @@ func initConfig(ctx *cli.Context) (*config.OnyxChainConfig, error) {
 }
 
 func initAccount(ctx *cli.Context) (*account.Account, error) {
-	if !config.DefConfig.Consensus.EnableConsensus {
+	if !config.DefConfig.Consensus.EnableConsensus && !config.DefConfig.P2PNode.SecureTransport {
 		return nil, nil
 	}
 	walletFile := ctx.GlobalString(utils.GetFlagName(utils.WalletFileFlag))
@@ -293,7 +308,7 @@ func initTxPool(ctx *cli.Context) (*proc.TXPoolServer, error) {
 	return txPoolServer, nil
 }
 
-func initP2PNode(ctx *cli.Context, txpoolSvr *proc.TXPoolServer) (*p2pserver.P2PServer, *actor.PID, error) {
+func initP2PNode(ctx *cli.Context, txpoolSvr *proc.TXPoolServer, acc *account.Account) (*p2pserver.P2PServer, *actor.PID, error) {
 	if config.DefConfig.Genesis.ConsensusType == config.CONSENSUS_TYPE_SOLO {
 		return nil, nil, nil
 	}
@@ -305,6 +320,7 @@ func initP2PNode(ctx *cli.Context, txpoolSvr *proc.TXPoolServer) (*p2pserver.P2P
 		return nil, nil, fmt.Errorf("p2pActor init error %s", err)
 	}
 	p2p.SetPID(p2pPID)
+	p2p.SetNodeKey(acc)
 	err = p2p.Start()
 	if err != nil {
 		return nil, nil, fmt.Errorf("p2p service start error %s", err)
@@ -337,6 +353,13 @@ func initConsensus(ctx *cli.Context, p2pPid *actor.PID, txpoolSvr *proc.TXPoolSe
 	return consensusService, nil
 }
 
+func initNativeAbi(ctx *cli.Context) {
+	if !config.DefConfig.Rpc.EnableHttpJsonRpc && !config.DefConfig.Restful.EnableHttpRestful {
+		return
+	}
+	abi.DefAbiMgr.Init(ctx.String(utils.GetFlagName(utils.NativeAbiPathFlag)))
+}
+
 func initRpc(ctx *cli.Context) error {
 	if !config.DefConfig.Rpc.EnableHttpJsonRpc {
 		return nil
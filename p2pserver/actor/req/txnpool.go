@@ -66,3 +66,26 @@ func GetTransaction(hash common.Uint256) (*types.Transaction, error) {
 	}
 	return result.(tc.GetTxnRsp).Txn, nil
 }
+
+//get all txns in txnpool, including the ones under verifying
+func GetMemPoolTxs() ([]*types.Transaction, error) {
+	if txnPoolPid == nil {
+		log.Warn("[p2p]net_server tx pool pid is nil")
+		return nil, errors.NewErr("[p2p]net_server tx pool pid is nil")
+	}
+	future := txnPoolPid.RequestFuture(&tc.GetMemPoolTxsReq{}, txnPoolReqTimeout)
+	result, err := future.Result()
+	if err != nil {
+		log.Warnf("[p2p]net_server GetMemPoolTxs error: %v\n", err)
+		return nil, err
+	}
+	rsp, ok := result.(*tc.GetMemPoolTxsRsp)
+	if !ok {
+		return nil, errors.NewErr("[p2p]net_server GetMemPoolTxs unexpected response")
+	}
+	txs := make([]*types.Transaction, 0, len(rsp.Txs))
+	for _, memTx := range rsp.Txs {
+		txs = append(txs, memTx.Tx)
+	}
+	return txs, nil
+}
@@ -94,6 +94,16 @@ func (this *P2PActor) Receive(ctx actor.Context) {
 		this.server.OnHeaderReceive(msg.FromID, msg.Headers)
 	case *common.AppendBlock:
 		this.server.OnBlockReceive(msg.FromID, msg.BlockSize, msg.Block, msg.MerkleRoot)
+	case *common.AppendTxProof:
+		this.server.OnTxProofReceive(msg.FromID, msg.Proof)
+	case *common.AppendStorageProof:
+		this.server.OnStorageProofReceive(msg.FromID, msg.Proof)
+	case *common.NotFoundHash:
+		this.server.OnNotFound(msg.FromID, msg.Hash)
+	case *GetTxProofReq:
+		this.handleGetTxProofReq(ctx, msg)
+	case *GetStorageProofReq:
+		this.handleGetStorageProofReq(ctx, msg)
 	default:
 		err := this.server.Xmit(ctx.Message())
 		if nil != err {
@@ -243,3 +253,36 @@ func (this *P2PActor) handleTransmitConsensusMsgReq(ctx actor.Context, req *Tran
 		log.Warnf("[p2p]can`t transmit consensus msg:no valid neighbor peer: %d\n", req.Target)
 	}
 }
+
+//transaction proof handler, the proof is requested from peers in another goroutine,
+//since the responses are passed through this actor
+func (this *P2PActor) handleGetTxProofReq(ctx actor.Context, req *GetTxProofReq) {
+	sender, self := ctx.Sender(), ctx.Self()
+	if sender == nil {
+		return
+	}
+	go func() {
+		proof, err := this.server.GetTransactionProof(req.TxHash)
+		resp := &GetTxProofRsp{
+			Proof: proof,
+			Error: err,
+		}
+		sender.Request(resp, self)
+	}()
+}
+
+//storage proof handler
+func (this *P2PActor) handleGetStorageProofReq(ctx actor.Context, req *GetStorageProofReq) {
+	sender, self := ctx.Sender(), ctx.Self()
+	if sender == nil {
+		return
+	}
+	go func() {
+		proof, err := this.server.GetStorageProof(req.Contract, req.Key)
+		resp := &GetStorageProofRsp{
+			Proof: proof,
+			Error: err,
+		}
+		sender.Request(resp, self)
+	}()
+}
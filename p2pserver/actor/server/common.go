@@ -19,6 +19,8 @@
 package server
 
 import (
+	"github.com/OnyxPay/OnyxChain/common"
+	ctypes "github.com/OnyxPay/OnyxChain/core/types"
 	types "github.com/OnyxPay/OnyxChain/p2pserver/common"
 	ptypes "github.com/OnyxPay/OnyxChain/p2pserver/message/types"
 )
@@ -132,3 +134,26 @@ type TransmitConsensusMsgReq struct {
 	Target uint64
 	Msg    ptypes.Message
 }
+
+//verified transaction proof request of light node
+type GetTxProofReq struct {
+	TxHash common.Uint256
+}
+
+//response of verified transaction proof request
+type GetTxProofRsp struct {
+	Proof *ctypes.TxProof
+	Error error
+}
+
+//verified storage proof request of light node
+type GetStorageProofReq struct {
+	Contract common.Address
+	Key      []byte
+}
+
+//response of verified storage proof request
+type GetStorageProofRsp struct {
+	Proof *ctypes.StorageProof
+	Error error
+}
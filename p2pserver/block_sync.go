@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/config"
 	"github.com/OnyxPay/OnyxChain/common/log"
 	"github.com/OnyxPay/OnyxChain/core/ledger"
 	"github.com/OnyxPay/OnyxChain/core/types"
@@ -501,6 +502,15 @@ func (this *BlockSyncMgr) OnBlockReceive(fromID uint64, blockSize uint32, block
 	if height > nextHeader {
 		return
 	}
+	//light node only keeps the header of new block
+	if config.DefConfig.Common.LightNode {
+		if height == nextHeader {
+			if err := this.ledger.AddHeaders([]*types.Header{block.Header}); err != nil {
+				log.Warnf("[p2p]OnBlockReceive AddHeaders error:%s", err)
+			}
+		}
+		return
+	}
 	curBlockHeight := this.ledger.GetCurrentBlockHeight()
 	if height <= curBlockHeight {
 		return
@@ -802,6 +812,10 @@ func (this *BlockSyncMgr) getNextNode(nextBlockHeight uint32) *peer.Peer {
 		if n.GetSyncState() != p2pComm.ESTABLISH {
 			continue
 		}
+		//light node only keeps headers
+		if n.GetServices() == uint64(p2pComm.LIGHT_NODE) {
+			continue
+		}
 		nodeBlockHeight := n.GetHeight()
 		if nextBlockHeight <= uint32(nodeBlockHeight) {
 			return n
@@ -19,10 +19,13 @@
 package common
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"strconv"
 	"strings"
 
+	"github.com/OnyxPay/OnyxChain-crypto/keypair"
 	com "github.com/OnyxPay/OnyxChain/common"
 	"github.com/OnyxPay/OnyxChain/core/types"
 )
@@ -31,6 +34,7 @@ import (
 const (
 	VERIFY_NODE  = 1 //peer involved in consensus
 	SERVICE_NODE = 2 //peer only sync with consensus peer
+	LIGHT_NODE   = 3 //peer only keep block headers
 )
 
 //link and concurrent const
@@ -41,6 +45,10 @@ const (
 	REQ_INTERVAL        = 3          //single request max interval in second
 	MAX_REQ_RECORD_SIZE = 1000       //the maximum request record size
 	MAX_RESP_CACHE_SIZE = 50         //the maximum response cache
+	MAX_KNOWN_TX_SIZE   = 10000      //the maximum relayed tx hash cache, used to predict the txs peer lacks
+	MAX_CMPCT_BLK_SIZE  = 16         //the maximum compact blocks waiting for missing txs
+	HANDSHAKE_TIMEOUT   = 10         //secure handshake timeout in second
+	MAX_FRAME_LEN       = 1024 * 64  //the maximum plaintext length of an encrypted frame
 )
 
 //msg cmd const
@@ -64,7 +72,8 @@ const (
 
 //info update const
 const (
-	PROTOCOL_VERSION      = 0     //protocol version
+	PROTOCOL_VERSION      = 1     //protocol version
+	COMPACT_BLOCK_VERSION = 1     //the minimum protocol version supports compact block relay
 	UPDATE_RATE_PER_BLOCK = 2     //info update rate in one generate block period
 	KEEPALIVE_TIMEOUT     = 15    //contact timeout in sec
 	DIAL_TIMEOUT          = 6     //connect timeout in sec
@@ -102,6 +111,12 @@ const (
 	RECENT_LIMIT     = 10 //recent contact list limit
 )
 
+//dht const
+const (
+	DHT_BOOK_FILE_NAME = "peers.dht" //file of dht address book
+	DHT_CONNECT_NUM    = 4           //max nodes to connect from dht each time
+)
+
 //PeerAddr represent peer`s net information
 type PeerAddr struct {
 	Time          int64    //latest timestamp
@@ -114,22 +129,29 @@ type PeerAddr struct {
 
 //const channel msg id and type
 const (
-	VERSION_TYPE     = "version"    //peer`s information
-	VERACK_TYPE      = "verack"     //ack msg after version recv
-	GetADDR_TYPE     = "getaddr"    //req nbr address from peer
-	ADDR_TYPE        = "addr"       //nbr address
-	PING_TYPE        = "ping"       //ping  sync height
-	PONG_TYPE        = "pong"       //pong  recv nbr height
-	GET_HEADERS_TYPE = "getheaders" //req blk hdr
-	HEADERS_TYPE     = "headers"    //blk hdr
-	INV_TYPE         = "inv"        //inv payload
-	GET_DATA_TYPE    = "getdata"    //req data from peer
-	BLOCK_TYPE       = "block"      //blk payload
-	TX_TYPE          = "tx"         //transaction
-	CONSENSUS_TYPE   = "consensus"  //consensus payload
-	GET_BLOCKS_TYPE  = "getblocks"  //req blks from peer
-	NOT_FOUND_TYPE   = "notfound"   //peer can`t find blk according to the hash
-	DISCONNECT_TYPE  = "disconnect" //peer disconnect info raise by link
+	VERSION_TYPE      = "version"    //peer`s information
+	VERACK_TYPE       = "verack"     //ack msg after version recv
+	GetADDR_TYPE      = "getaddr"    //req nbr address from peer
+	ADDR_TYPE         = "addr"       //nbr address
+	PING_TYPE         = "ping"       //ping  sync height
+	PONG_TYPE         = "pong"       //pong  recv nbr height
+	GET_HEADERS_TYPE  = "getheaders" //req blk hdr
+	HEADERS_TYPE      = "headers"    //blk hdr
+	INV_TYPE          = "inv"        //inv payload
+	GET_DATA_TYPE     = "getdata"    //req data from peer
+	BLOCK_TYPE        = "block"      //blk payload
+	TX_TYPE           = "tx"         //transaction
+	CONSENSUS_TYPE    = "consensus"  //consensus payload
+	GET_BLOCKS_TYPE   = "getblocks"  //req blks from peer
+	NOT_FOUND_TYPE    = "notfound"   //peer can`t find blk according to the hash
+	DISCONNECT_TYPE   = "disconnect" //peer disconnect info raise by link
+	GET_TX_PROOF_TYPE = "gettxproof" //req transaction proof from peer
+	TX_PROOF_TYPE     = "txproof"    //transaction proof
+	GET_ST_PROOF_TYPE = "getstproof" //req storage proof from peer
+	ST_PROOF_TYPE     = "stproof"    //storage proof
+	CMPCT_BLOCK_TYPE  = "cmpctblock" //compact block payload
+	GET_BLK_TXN_TYPE  = "getblktxn"  //req missing txs of compact block
+	BLK_TXN_TYPE      = "blktxn"     //missing txs of compact block
 )
 
 type AppendPeerID struct {
@@ -152,6 +174,21 @@ type AppendBlock struct {
 	MerkleRoot com.Uint256  // MerkleRoot
 }
 
+type AppendTxProof struct {
+	FromID uint64         // The peer id
+	Proof  *types.TxProof // Transaction proof
+}
+
+type AppendStorageProof struct {
+	FromID uint64              // The peer id
+	Proof  *types.StorageProof // Storage proof
+}
+
+type NotFoundHash struct {
+	FromID uint64      // The peer id
+	Hash   com.Uint256 // Hash of the data not found
+}
+
 //ParseIPAddr return ip address
 func ParseIPAddr(s string) (string, error) {
 	i := strings.Index(s, ":")
@@ -176,3 +213,9 @@ func ParseIPPort(s string) (string, error) {
 	}
 	return s[i:], nil
 }
+
+//PubKeyToPeerID return the peer id derived from the node public key, which is the first 8 bytes of the dht node id
+func PubKeyToPeerID(pubKey keypair.PublicKey) uint64 {
+	hash := sha256.Sum256(keypair.SerializePublicKey(pubKey))
+	return binary.BigEndian.Uint64(hash[:8])
+}
@@ -0,0 +1,231 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dht
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/log"
+)
+
+const (
+	BOOK_MAX_SIZE  = 1000 //the maximum nodes in address book
+	BOOK_MIN_SCORE = -5   //node is removed from address book when its score drops below
+	BOOK_MAX_SCORE = 100  //the maximum score of a node
+	BOOK_IP_LIMIT  = 10   //the maximum nodes of a subnet in address book
+)
+
+//bookEntry is the persisted record of a node
+type bookEntry struct {
+	ID       string
+	IP       string
+	UDPPort  uint16
+	TCPPort  uint16
+	Score    int   //increased on successful contact, decreased on failure
+	LastSeen int64 //unix time of last successful contact
+	node     *Node
+}
+
+//AddrBook record the known nodes with scores, and persist them to file
+type AddrBook struct {
+	lock    sync.Mutex
+	path    string
+	entries map[NodeID]*bookEntry
+}
+
+//NewAddrBook return the address book loaded from path, empty path means no persistence
+func NewAddrBook(path string) *AddrBook {
+	book := &AddrBook{
+		path:    path,
+		entries: make(map[NodeID]*bookEntry),
+	}
+	if path == "" || !common.FileExisted(path) {
+		return book
+	}
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Warnf("[dht]read address book %s error: %s", path, err)
+		return book
+	}
+	entries := make([]*bookEntry, 0)
+	if err = json.Unmarshal(buf, &entries); err != nil {
+		log.Warnf("[dht]parse address book %s error: %s", path, err)
+		return book
+	}
+	for _, e := range entries {
+		raw, err := hex.DecodeString(e.ID)
+		ip := net.ParseIP(e.IP)
+		if err != nil || len(raw) != ID_LEN || ip == nil {
+			continue
+		}
+		e.node = &Node{IP: ip, UDPPort: e.UDPPort, TCPPort: e.TCPPort}
+		copy(e.node.ID[:], raw)
+		if sub := subnet(ip); sub != "" && book.subnetCount(sub, e.node.ID) >= BOOK_IP_LIMIT {
+			continue
+		}
+		book.entries[e.node.ID] = e
+	}
+	return book
+}
+
+//Add record the node which is contacted successfully. The node is dropped if its subnet already has BOOK_IP_LIMIT
+//nodes in address book
+func (this *AddrBook) Add(n *Node) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if sub := subnet(n.IP); sub != "" && this.subnetCount(sub, n.ID) >= BOOK_IP_LIMIT {
+		return
+	}
+	e, ok := this.entries[n.ID]
+	if !ok {
+		if len(this.entries) >= BOOK_MAX_SIZE {
+			this.evict()
+		}
+		e = &bookEntry{ID: n.ID.String()}
+		this.entries[n.ID] = e
+	}
+	e.node = n
+	e.IP = n.IP.String()
+	e.UDPPort = n.UDPPort
+	e.TCPPort = n.TCPPort
+	e.LastSeen = time.Now().Unix()
+}
+
+//MarkGood increase the score of node
+func (this *AddrBook) MarkGood(id NodeID) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if e, ok := this.entries[id]; ok && e.Score < BOOK_MAX_SCORE {
+		e.Score++
+	}
+}
+
+//MarkBad decrease the score of node, and remove it if the score is too low
+func (this *AddrBook) MarkBad(id NodeID) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if e, ok := this.entries[id]; ok {
+		e.Score--
+		if e.Score < BOOK_MIN_SCORE {
+			delete(this.entries, id)
+		}
+	}
+}
+
+//Best return at most count nodes with the highest score, the recently seen first for same score
+func (this *AddrBook) Best(count int, exclude func(n *Node) bool) []*Node {
+	this.lock.Lock()
+	entries := make([]*bookEntry, 0, len(this.entries))
+	for _, e := range this.entries {
+		if exclude == nil || !exclude(e.node) {
+			entries = append(entries, e)
+		}
+	}
+	this.lock.Unlock()
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		return entries[i].LastSeen > entries[j].LastSeen
+	})
+	nodes := make([]*Node, 0, count)
+	for i := 0; i < len(entries) && i < count; i++ {
+		nodes = append(nodes, entries[i].node)
+	}
+	return nodes
+}
+
+//Len return the node count of address book
+func (this *AddrBook) Len() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return len(this.entries)
+}
+
+//Save persist the address book
+func (this *AddrBook) Save() error {
+	if this.path == "" {
+		return nil
+	}
+	this.lock.Lock()
+	entries := make([]*bookEntry, 0, len(this.entries))
+	for _, e := range this.entries {
+		entries = append(entries, e)
+	}
+	buf, err := json.Marshal(entries)
+	this.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(this.path, buf, os.ModePerm)
+}
+
+//evict remove the node with lowest score in the most crowded subnet, so that nodes from many addresses of a few
+//subnets can't push out the others. Caller should hold the lock
+func (this *AddrBook) evict() {
+	counts := make(map[string]int)
+	for _, e := range this.entries {
+		if sub := subnet(e.node.IP); sub != "" {
+			counts[sub]++
+		}
+	}
+	crowd := func(e *bookEntry) int {
+		if sub := subnet(e.node.IP); sub != "" {
+			return counts[sub]
+		}
+		return 1
+	}
+	worse := func(a, b *bookEntry) bool {
+		if crowd(a) != crowd(b) {
+			return crowd(a) > crowd(b)
+		}
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		return a.LastSeen < b.LastSeen
+	}
+	var worst *bookEntry
+	for _, e := range this.entries {
+		if worst == nil || worse(e, worst) {
+			worst = e
+		}
+	}
+	if worst != nil {
+		delete(this.entries, worst.node.ID)
+	}
+}
+
+//subnetCount return the count of nodes in the subnet, except the node of id. Caller should hold the lock
+func (this *AddrBook) subnetCount(sub string, id NodeID) int {
+	count := 0
+	for _, e := range this.entries {
+		if e.node.ID != id && subnet(e.node.IP) == sub {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,405 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package dht provides the kademlia peer discovery of p2p network
+package dht
+
+import (
+	"crypto/rand"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OnyxPay/OnyxChain/common/log"
+	"github.com/OnyxPay/OnyxChain/core/signature"
+)
+
+const (
+	ALPHA            = 3  //concurrency of node lookup
+	REQ_TIMEOUT      = 2  //s, request timeout time, the node is marked failed after timeout
+	REFRESH_INTERVAL = 30 //s, time interval to refresh the table and save the address book
+)
+
+//Config is the config of dht
+type Config struct {
+	Key        signature.Signer //Node key, node id is the hash of its public key
+	ListenAddr string           //UDP address to listen on
+	TCPPort    uint16           //P2P sync port announced to other nodes
+	BootNodes  []string         //UDP addresses to join the network
+	BookPath   string           //File to persist the address book, empty means no persistence
+}
+
+//reply is the response packet of a request
+type reply struct {
+	node   *Node
+	packet *packet
+}
+
+type pendingKey struct {
+	addr  string
+	reqId uint64
+}
+
+//DHT maintain the kademlia routing table through udp, and provide nodes to connect
+type DHT struct {
+	key       signature.Signer
+	self      *Node
+	conn      *net.UDPConn
+	table     *Table
+	book      *AddrBook
+	bootNodes []*net.UDPAddr
+	lock      sync.Mutex
+	pending   map[pendingKey]chan *reply //Requests waiting for reply
+	reqId     uint64
+	quit      chan struct{}
+	closeOnce sync.Once
+}
+
+//NewDHT listen on the udp address and return a DHT instance
+func NewDHT(cfg *Config) (*DHT, error) {
+	if cfg.Key == nil {
+		return nil, errors.New("[dht]node key is nil")
+	}
+	addr, err := net.ResolveUDPAddr("udp", cfg.ListenAddr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	local := conn.LocalAddr().(*net.UDPAddr)
+	self := &Node{
+		ID:      PubKeyToNodeID(cfg.Key.PubKey()),
+		IP:      local.IP,
+		UDPPort: uint16(local.Port),
+		TCPPort: cfg.TCPPort,
+	}
+	bootNodes := make([]*net.UDPAddr, 0, len(cfg.BootNodes))
+	for _, n := range cfg.BootNodes {
+		bootAddr, err := net.ResolveUDPAddr("udp", n)
+		if err != nil {
+			log.Warnf("[dht]resolve boot node %s error: %s", n, err)
+			continue
+		}
+		bootNodes = append(bootNodes, bootAddr)
+	}
+	log.Infof("[dht]init node ID to %s", self.ID.String())
+	return &DHT{
+		key:       cfg.Key,
+		self:      self,
+		conn:      conn,
+		table:     newTable(self.ID),
+		book:      NewAddrBook(cfg.BookPath),
+		bootNodes: bootNodes,
+		pending:   make(map[pendingKey]chan *reply),
+		quit:      make(chan struct{}),
+	}, nil
+}
+
+//Start receive packets and refresh the table
+func (this *DHT) Start() {
+	go this.readLoop()
+	go this.refreshLoop()
+}
+
+//Stop close the udp connection and save the address book
+func (this *DHT) Stop() {
+	this.closeOnce.Do(func() {
+		close(this.quit)
+		this.conn.Close()
+		if err := this.book.Save(); err != nil {
+			log.Warnf("[dht]save address book error: %s", err)
+		}
+	})
+}
+
+//Self return the local node
+func (this *DHT) Self() *Node {
+	return this.self
+}
+
+//Nodes return the nodes in routing table
+func (this *DHT) Nodes() []*Node {
+	return this.table.nodes()
+}
+
+//Candidates return at most count nodes to connect from address book, ordered by score
+func (this *DHT) Candidates(count int, exclude func(n *Node) bool) []*Node {
+	return this.book.Best(count, exclude)
+}
+
+//MarkConnected increase the score of node after p2p connection established
+func (this *DHT) MarkConnected(id NodeID) {
+	this.book.MarkGood(id)
+}
+
+//MarkConnectFailed decrease the score of node after p2p connection failed
+func (this *DHT) MarkConnectFailed(id NodeID) {
+	this.book.MarkBad(id)
+}
+
+//Lookup find the nodes closest to target iteratively
+func (this *DHT) Lookup(target NodeID) []*Node {
+	type result struct {
+		node  *Node
+		nodes []*Node
+		err   error
+	}
+	closest := this.table.closest(target, BUCKET_SIZE)
+	asked := map[NodeID]bool{this.self.ID: true}
+	seen := map[NodeID]bool{this.self.ID: true}
+	for _, n := range closest {
+		seen[n.ID] = true
+	}
+	ch := make(chan *result, ALPHA)
+	pending := 0
+	for {
+		for i := 0; i < len(closest) && pending < ALPHA; i++ {
+			n := closest[i]
+			if asked[n.ID] {
+				continue
+			}
+			asked[n.ID] = true
+			pending++
+			go func() {
+				nodes, err := this.findNode(n, target)
+				ch <- &result{node: n, nodes: nodes, err: err}
+			}()
+		}
+		if pending == 0 {
+			break
+		}
+		r := <-ch
+		pending--
+		if r.err != nil {
+			closest = remove(closest, r.node.ID)
+			continue
+		}
+		for _, n := range r.nodes {
+			if !seen[n.ID] {
+				seen[n.ID] = true
+				closest = append(closest, n)
+			}
+		}
+		sortByDistance(target, closest)
+		if len(closest) > BUCKET_SIZE {
+			closest = closest[:BUCKET_SIZE]
+		}
+	}
+	return closest
+}
+
+//bootstrap ping the boot nodes and the best nodes of address book, then lookup self to fill the table
+func (this *DHT) bootstrap() {
+	wg := &sync.WaitGroup{}
+	for _, n := range this.book.Best(BUCKET_SIZE, nil) {
+		wg.Add(1)
+		go func(n *Node) {
+			defer wg.Done()
+			this.ping(n)
+		}(n)
+	}
+	for _, addr := range this.bootNodes {
+		wg.Add(1)
+		go func(addr *net.UDPAddr) {
+			defer wg.Done()
+			r, err := this.request(addr, &packet{Type: PING_PACKET}, NodeID{})
+			if err != nil {
+				log.Debugf("[dht]ping boot node %s error: %s", addr.String(), err)
+				return
+			}
+			this.onAlive(r.node)
+		}(addr)
+	}
+	wg.Wait()
+	this.Lookup(this.self.ID)
+}
+
+func (this *DHT) refreshLoop() {
+	this.bootstrap()
+	ticker := time.NewTicker(REFRESH_INTERVAL * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if this.table.len() == 0 {
+				this.bootstrap()
+			} else {
+				this.Lookup(this.self.ID)
+				var target NodeID
+				rand.Read(target[:])
+				this.Lookup(target)
+			}
+			if err := this.book.Save(); err != nil {
+				log.Warnf("[dht]save address book error: %s", err)
+			}
+		case <-this.quit:
+			return
+		}
+	}
+}
+
+func (this *DHT) readLoop() {
+	buf := make([]byte, MAX_PACKET_SIZE)
+	for {
+		n, addr, err := this.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-this.quit:
+				return
+			default:
+			}
+			log.Debugf("[dht]read udp error: %s", err)
+			continue
+		}
+		p, from, err := decodePacket(buf[:n])
+		if err != nil {
+			log.Debugf("[dht]invalid packet from %s: %s", addr.String(), err)
+			continue
+		}
+		if from == this.self.ID {
+			continue
+		}
+		this.handle(from, addr, p)
+	}
+}
+
+//handle process the packet from node
+func (this *DHT) handle(from NodeID, addr *net.UDPAddr, p *packet) {
+	n := &Node{ID: from, IP: addr.IP, UDPPort: uint16(addr.Port), TCPPort: p.TCPPort}
+	switch p.Type {
+	case PING_PACKET, FIND_NODE_PACKET:
+		if p.Type == PING_PACKET {
+			this.send(addr, &packet{Type: PONG_PACKET, ReqId: p.ReqId})
+		} else {
+			nodes := this.table.closest(p.Target, BUCKET_SIZE)
+			this.send(addr, &packet{Type: NEIGHBORS_PACKET, ReqId: p.ReqId, Nodes: nodes})
+		}
+		//the unknown node is added after it answers ping
+		if !this.table.contains(from) {
+			go this.ping(n)
+		}
+	case PONG_PACKET, NEIGHBORS_PACKET:
+		key := pendingKey{addr: addr.String(), reqId: p.ReqId}
+		this.lock.Lock()
+		ch, ok := this.pending[key]
+		delete(this.pending, key)
+		this.lock.Unlock()
+		if ok {
+			ch <- &reply{node: n, packet: p}
+		}
+	}
+}
+
+//ping check whether the node is alive, and add it to the table
+func (this *DHT) ping(n *Node) error {
+	r, err := this.request(n.UDPAddr(), &packet{Type: PING_PACKET}, n.ID)
+	if err != nil {
+		this.onFailed(n)
+		return err
+	}
+	this.onAlive(r.node)
+	return nil
+}
+
+//findNode ask the node for the nodes closest to target
+func (this *DHT) findNode(n *Node, target NodeID) ([]*Node, error) {
+	r, err := this.request(n.UDPAddr(), &packet{Type: FIND_NODE_PACKET, Target: target}, n.ID)
+	if err != nil {
+		this.onFailed(n)
+		return nil, err
+	}
+	this.onAlive(r.node)
+	nodes := make([]*Node, 0, len(r.packet.Nodes))
+	for _, node := range r.packet.Nodes {
+		if node.ID == this.self.ID || node.IP.IsUnspecified() || node.UDPPort == 0 {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+//onAlive add the node to table and address book. If the bucket is full, the least recently seen node is pinged
+//and replaced if it's dead
+func (this *DHT) onAlive(n *Node) {
+	this.book.Add(n)
+	old := this.table.add(n)
+	if old == nil {
+		return
+	}
+	go func() {
+		r, err := this.request(old.UDPAddr(), &packet{Type: PING_PACKET}, old.ID)
+		if err != nil {
+			this.onFailed(old)
+			return
+		}
+		this.book.Add(r.node)
+		this.table.add(r.node)
+	}()
+}
+
+//onFailed remove the node from table and decrease its score
+func (this *DHT) onFailed(n *Node) {
+	this.table.remove(n.ID)
+	this.book.MarkBad(n.ID)
+}
+
+//request send the packet and wait for the reply. If id is not empty, the reply should come from the node of id
+func (this *DHT) request(addr *net.UDPAddr, p *packet, id NodeID) (*reply, error) {
+	p.ReqId = atomic.AddUint64(&this.reqId, 1)
+	key := pendingKey{addr: addr.String(), reqId: p.ReqId}
+	ch := make(chan *reply, 1)
+	this.lock.Lock()
+	this.pending[key] = ch
+	this.lock.Unlock()
+	defer func() {
+		this.lock.Lock()
+		delete(this.pending, key)
+		this.lock.Unlock()
+	}()
+
+	if err := this.send(addr, p); err != nil {
+		return nil, err
+	}
+	select {
+	case r := <-ch:
+		if id != (NodeID{}) && r.node.ID != id {
+			return nil, errors.New("[dht]node id mismatch")
+		}
+		return r, nil
+	case <-time.After(REQ_TIMEOUT * time.Second):
+		return nil, errors.New("[dht]request timeout")
+	case <-this.quit:
+		return nil, errors.New("[dht]dht stopped")
+	}
+}
+
+func (this *DHT) send(addr *net.UDPAddr, p *packet) error {
+	p.Expiration = uint64(time.Now().Unix() + PACKET_EXPIRATION)
+	p.TCPPort = this.self.TCPPort
+	data, err := encodePacket(this.key, p)
+	if err != nil {
+		return err
+	}
+	_, err = this.conn.WriteToUDP(data, addr)
+	return err
+}
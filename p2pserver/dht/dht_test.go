@@ -0,0 +1,179 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dht
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/OnyxPay/OnyxChain/account"
+	"github.com/OnyxPay/OnyxChain/common/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const TEST_NODE_NUM = 30
+
+func init() {
+	log.Init(log.Stdout)
+}
+
+func newTestDHT(t *testing.T, bootNodes []string, bookPath string) *DHT {
+	dht, err := NewDHT(&Config{
+		Key:        account.NewAccount(""),
+		ListenAddr: "127.0.0.1:0",
+		TCPPort:    20338,
+		BootNodes:  bootNodes,
+		BookPath:   bookPath,
+	})
+	assert.Nil(t, err)
+	return dht
+}
+
+func TestPacket(t *testing.T) {
+	acc := account.NewAccount("")
+	p := &packet{
+		Type:       NEIGHBORS_PACKET,
+		Expiration: uint64(time.Now().Unix() + PACKET_EXPIRATION),
+		ReqId:      10,
+		TCPPort:    20338,
+		Nodes:      []*Node{newTestNode(1, 2)},
+	}
+	p.Nodes[0].IP = []byte{127, 0, 0, 1}
+	data, err := encodePacket(acc, p)
+	assert.Nil(t, err)
+	decoded, from, err := decodePacket(data)
+	assert.Nil(t, err)
+	assert.Equal(t, PubKeyToNodeID(acc.PublicKey), from)
+	assert.Equal(t, p.ReqId, decoded.ReqId)
+	assert.Equal(t, 1, len(decoded.Nodes))
+	assert.Equal(t, p.Nodes[0].TCPAddr(), decoded.Nodes[0].TCPAddr())
+
+	data[len(data)-1] ^= 1
+	_, _, err = decodePacket(data)
+	assert.NotNil(t, err)
+
+	p.Expiration = uint64(time.Now().Unix() - 1)
+	data, err = encodePacket(acc, p)
+	assert.Nil(t, err)
+	_, _, err = decodePacket(data)
+	assert.NotNil(t, err)
+}
+
+func TestAddrBook(t *testing.T) {
+	path := "test.dht"
+	defer os.Remove(path)
+	book := NewAddrBook(path)
+	a := newTestNode(1, 0)
+	b := newTestNode(2, 0)
+	a.IP = []byte{127, 0, 0, 1}
+	b.IP = []byte{127, 0, 0, 2}
+	book.Add(a)
+	book.Add(b)
+	book.MarkGood(b.ID)
+	nodes := book.Best(2, nil)
+	assert.Equal(t, 2, len(nodes))
+	assert.Equal(t, b.ID, nodes[0].ID)
+	nodes = book.Best(2, func(n *Node) bool { return n.ID == b.ID })
+	assert.Equal(t, 1, len(nodes))
+	assert.Equal(t, a.ID, nodes[0].ID)
+
+	assert.Nil(t, book.Save())
+	book = NewAddrBook(path)
+	assert.Equal(t, 2, book.Len())
+	nodes = book.Best(1, nil)
+	assert.Equal(t, b.ID, nodes[0].ID)
+	assert.Equal(t, b.TCPAddr(), nodes[0].TCPAddr())
+
+	for i := 0; i <= -BOOK_MIN_SCORE; i++ {
+		book.MarkBad(a.ID)
+	}
+	assert.Equal(t, 1, book.Len())
+}
+
+func TestAddrBookIPLimit(t *testing.T) {
+	book := NewAddrBook("")
+	for i := 0; i < BOOK_IP_LIMIT; i++ {
+		n := newTestNode(0xf0, byte(i))
+		n.IP = []byte{3, 3, 3, byte(i)}
+		book.Add(n)
+		book.MarkGood(n.ID)
+	}
+	n := newTestNode(0xf0, 0xff)
+	n.IP = []byte{3, 3, 3, 0xff}
+	book.Add(n)
+	assert.Equal(t, BOOK_IP_LIMIT, book.Len())
+
+	//the most crowded subnet is evicted first, even with higher score
+	for i := 0; book.Len() < BOOK_MAX_SIZE; i++ {
+		n = newTestNode(byte(i>>8), byte(i))
+		n.IP = []byte{2, byte(i >> 8), byte(i), 1}
+		book.Add(n)
+	}
+	n = newTestNode(0xf1, 0)
+	n.IP = []byte{4, 4, 4, 4}
+	book.Add(n)
+	assert.Equal(t, BOOK_MAX_SIZE, book.Len())
+	assert.Equal(t, BOOK_IP_LIMIT-1, book.subnetCount("3.3.3.0", NodeID{}))
+	assert.Equal(t, 1, book.subnetCount("4.4.4.0", NodeID{}))
+}
+
+func TestLookup(t *testing.T) {
+	dhts := make([]*DHT, 0, TEST_NODE_NUM)
+	boot := newTestDHT(t, nil, "")
+	boot.Start()
+	defer boot.Stop()
+	dhts = append(dhts, boot)
+	for i := 1; i < TEST_NODE_NUM; i++ {
+		dht := newTestDHT(t, []string{boot.Self().UDPAddr().String()}, "")
+		dht.Start()
+		defer dht.Stop()
+		dhts = append(dhts, dht)
+	}
+
+	//wait for bootstrap
+	for i := 0; i < 50; i++ {
+		ready := true
+		for _, dht := range dhts {
+			if len(dht.Nodes()) == 0 {
+				ready = false
+			}
+		}
+		if ready && boot.book.Len() == TEST_NODE_NUM-1 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Equal(t, TEST_NODE_NUM-1, boot.book.Len())
+	//refresh once as the nodes joined later are unknown to the earlier ones
+	for _, dht := range dhts {
+		dht.Lookup(dht.Self().ID)
+	}
+
+	for i, dht := range dhts {
+		target := dhts[(i*7+3)%TEST_NODE_NUM]
+		if target == dht {
+			continue
+		}
+		nodes := dht.Lookup(target.Self().ID)
+		assert.NotEqual(t, 0, len(nodes))
+		assert.Equal(t, target.Self().ID, nodes[0].ID)
+		assert.Equal(t, target.Self().TCPAddr(), nodes[0].TCPAddr())
+	}
+}
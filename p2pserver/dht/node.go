@@ -0,0 +1,145 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dht
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/bits"
+	"net"
+	"strconv"
+
+	"github.com/OnyxPay/OnyxChain-crypto/keypair"
+	"github.com/OnyxPay/OnyxChain/common"
+)
+
+const ID_LEN = 32 //node id length in byte
+
+//lanNets are the private networks, whose nodes are not limited by subnet
+var lanNets = []*net.IPNet{
+	{IP: net.IP{10, 0, 0, 0}, Mask: net.CIDRMask(8, 32)},
+	{IP: net.IP{172, 16, 0, 0}, Mask: net.CIDRMask(12, 32)},
+	{IP: net.IP{192, 168, 0, 0}, Mask: net.CIDRMask(16, 32)},
+	{IP: net.IP{0xfc, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, Mask: net.CIDRMask(7, 128)},
+}
+
+//NodeID is the sha256 hash of the node public key
+type NodeID [ID_LEN]byte
+
+//PubKeyToNodeID return the node id of the public key
+func PubKeyToNodeID(pubKey keypair.PublicKey) NodeID {
+	return sha256.Sum256(keypair.SerializePublicKey(pubKey))
+}
+
+func (this NodeID) String() string {
+	return hex.EncodeToString(this[:])
+}
+
+//logDistance return the bit length of a xor b, 0 means a equals b
+func logDistance(a, b NodeID) int {
+	for i := 0; i < ID_LEN; i++ {
+		x := a[i] ^ b[i]
+		if x != 0 {
+			return (ID_LEN-i)*8 - bits.LeadingZeros8(x)
+		}
+	}
+	return 0
+}
+
+//distCmp compare the xor distances a->target and b->target
+func distCmp(target, a, b NodeID) int {
+	for i := 0; i < ID_LEN; i++ {
+		da := a[i] ^ target[i]
+		db := b[i] ^ target[i]
+		if da > db {
+			return 1
+		} else if da < db {
+			return -1
+		}
+	}
+	return 0
+}
+
+//Node is a dht node, TCPPort is the p2p sync port of the node
+type Node struct {
+	ID      NodeID
+	IP      net.IP
+	UDPPort uint16
+	TCPPort uint16
+}
+
+//UDPAddr return the dht address of the node
+func (this *Node) UDPAddr() *net.UDPAddr {
+	return &net.UDPAddr{IP: this.IP, Port: int(this.UDPPort)}
+}
+
+//TCPAddr return the p2p sync address of the node
+func (this *Node) TCPAddr() string {
+	return net.JoinHostPort(this.IP.String(), strconv.Itoa(int(this.TCPPort)))
+}
+
+func (this *Node) String() string {
+	return fmt.Sprintf("%x@%s", this.ID[:8], this.UDPAddr().String())
+}
+
+func (this *Node) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteBytes(this.ID[:])
+	sink.WriteBytes(this.IP.To16())
+	sink.WriteUint16(this.UDPPort)
+	sink.WriteUint16(this.TCPPort)
+}
+
+func (this *Node) Deserialization(source *common.ZeroCopySource) error {
+	id, eof := source.NextBytes(ID_LEN)
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	copy(this.ID[:], id)
+	ip, eof := source.NextBytes(net.IPv6len)
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	this.IP = make(net.IP, net.IPv6len)
+	copy(this.IP, ip)
+	this.UDPPort, eof = source.NextUint16()
+	this.TCPPort, eof = source.NextUint16()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+//subnet return the /24 network of ipv4 address or the /64 network of ipv6 address, which limits the nodes an attacker
+//can place from addresses it controls. Empty string is returned for loopback and lan addresses, which are not limited
+func subnet(ip net.IP) string {
+	if ip == nil || ip.IsUnspecified() || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+		return ""
+	}
+	for _, lan := range lanNets {
+		if lan.Contains(ip) {
+			return ""
+		}
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(64, 128)).String()
+}
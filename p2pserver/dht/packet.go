@@ -0,0 +1,155 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dht
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/OnyxPay/OnyxChain-crypto/keypair"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/signature"
+)
+
+//packet type
+const (
+	PING_PACKET      = 1 //check whether node is alive
+	PONG_PACKET      = 2 //reply of ping
+	FIND_NODE_PACKET = 3 //ask for the nodes closest to target
+	NEIGHBORS_PACKET = 4 //reply of find node
+)
+
+const (
+	MAX_PACKET_SIZE   = 1280 //the maximum udp packet size
+	PACKET_EXPIRATION = 20   //s, packet is dropped after expiration
+)
+
+//packet is the dht message, every packet is signed by the node key of sender
+type packet struct {
+	Type       byte
+	Expiration uint64
+	ReqId      uint64  //Id to match the reply with request
+	TCPPort    uint16  //P2P sync port of the sender
+	Target     NodeID  //Target of find node
+	Nodes      []*Node //Nodes of neighbors
+}
+
+func (this *packet) serializeBody(sink *common.ZeroCopySink) {
+	sink.WriteByte(this.Type)
+	sink.WriteUint64(this.Expiration)
+	sink.WriteUint64(this.ReqId)
+	sink.WriteUint16(this.TCPPort)
+	switch this.Type {
+	case FIND_NODE_PACKET:
+		sink.WriteBytes(this.Target[:])
+	case NEIGHBORS_PACKET:
+		sink.WriteVarUint(uint64(len(this.Nodes)))
+		for _, n := range this.Nodes {
+			n.Serialization(sink)
+		}
+	}
+}
+
+func (this *packet) deserializeBody(source *common.ZeroCopySource) error {
+	var eof bool
+	this.Type, eof = source.NextByte()
+	this.Expiration, eof = source.NextUint64()
+	this.ReqId, eof = source.NextUint64()
+	this.TCPPort, eof = source.NextUint16()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	switch this.Type {
+	case PING_PACKET, PONG_PACKET:
+	case FIND_NODE_PACKET:
+		target, eof := source.NextBytes(ID_LEN)
+		if eof {
+			return io.ErrUnexpectedEOF
+		}
+		copy(this.Target[:], target)
+	case NEIGHBORS_PACKET:
+		n, _, irregular, eof := source.NextVarUint()
+		if eof {
+			return io.ErrUnexpectedEOF
+		}
+		if irregular || n > BUCKET_SIZE {
+			return common.ErrIrregularData
+		}
+		for i := uint64(0); i < n; i++ {
+			node := &Node{}
+			if err := node.Deserialization(source); err != nil {
+				return err
+			}
+			this.Nodes = append(this.Nodes, node)
+		}
+	default:
+		return fmt.Errorf("unknown packet type %d", this.Type)
+	}
+	return nil
+}
+
+//encodePacket serialize the packet as: signature, public key, body. The signature covers public key and body
+func encodePacket(key signature.Signer, p *packet) ([]byte, error) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarBytes(keypair.SerializePublicKey(key.PubKey()))
+	p.serializeBody(sink)
+	data := sink.Bytes()
+	sig, err := signature.Sign(key, data)
+	if err != nil {
+		return nil, err
+	}
+	sink = common.NewZeroCopySink(nil)
+	sink.WriteVarBytes(sig)
+	sink.WriteBytes(data)
+	if sink.Size() > MAX_PACKET_SIZE {
+		return nil, errors.New("packet is too large")
+	}
+	return sink.Bytes(), nil
+}
+
+//decodePacket verify the signature and return the packet and the node id of sender
+func decodePacket(data []byte) (*packet, NodeID, error) {
+	source := common.NewZeroCopySource(data)
+	sig, _, irregular, eof := source.NextVarBytes()
+	if irregular || eof {
+		return nil, NodeID{}, errors.New("invalid signature data")
+	}
+	signed := data[source.Pos():]
+	rawKey, _, irregular, eof := source.NextVarBytes()
+	if irregular || eof {
+		return nil, NodeID{}, errors.New("invalid public key data")
+	}
+	pubKey, err := keypair.DeserializePublicKey(rawKey)
+	if err != nil {
+		return nil, NodeID{}, err
+	}
+	if err = signature.Verify(pubKey, signed, sig); err != nil {
+		return nil, NodeID{}, err
+	}
+	p := &packet{}
+	if err = p.deserializeBody(source); err != nil {
+		return nil, NodeID{}, err
+	}
+	if int64(p.Expiration) < time.Now().Unix() {
+		return nil, NodeID{}, errors.New("packet expired")
+	}
+	return p, PubKeyToNodeID(pubKey), nil
+}
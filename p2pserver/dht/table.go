@@ -0,0 +1,192 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dht
+
+import (
+	"sort"
+	"sync"
+)
+
+const (
+	BUCKET_SIZE      = 16          //k, the maximum nodes in a bucket
+	BUCKET_NUM       = ID_LEN * 8  //one bucket for each log distance
+	MAX_REPLACEMENTS = BUCKET_SIZE //the maximum replacement nodes of a bucket
+	BUCKET_IP_LIMIT  = 2           //the maximum nodes of a subnet in a bucket, including replacements
+	TABLE_IP_LIMIT   = 10          //the maximum nodes of a subnet in the table, including replacements
+)
+
+//bucket keep the nodes of same log distance, the least recently seen node is at the front
+type bucket struct {
+	entries      []*Node
+	replacements []*Node //nodes waiting for a slot when bucket is full
+}
+
+//Table is the kademlia routing table
+type Table struct {
+	lock    sync.Mutex
+	self    NodeID
+	buckets [BUCKET_NUM]*bucket
+}
+
+func newTable(self NodeID) *Table {
+	t := &Table{self: self}
+	for i := range t.buckets {
+		t.buckets[i] = &bucket{}
+	}
+	return t
+}
+
+func (this *Table) bucket(id NodeID) *bucket {
+	return this.buckets[logDistance(this.self, id)-1]
+}
+
+//add move the node to the tail of its bucket. If the bucket is full, the node is kept as replacement, and the
+//least recently seen node is returned, which should be pinged and removed if it's dead. The node is dropped if its
+//subnet already reaches BUCKET_IP_LIMIT in the bucket or TABLE_IP_LIMIT in the table
+func (this *Table) add(n *Node) *Node {
+	if n.ID == this.self {
+		return nil
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	b := this.bucket(n.ID)
+	if sub := subnet(n.IP); sub != "" {
+		if b.subnetCount(sub, n.ID) >= BUCKET_IP_LIMIT {
+			return nil
+		}
+		count := 0
+		for _, other := range this.buckets {
+			count += other.subnetCount(sub, n.ID)
+		}
+		if count >= TABLE_IP_LIMIT {
+			return nil
+		}
+	}
+	if index := indexOf(b.entries, n.ID); index >= 0 {
+		b.entries = append(append(b.entries[:index], b.entries[index+1:]...), n)
+		return nil
+	}
+	if len(b.entries) < BUCKET_SIZE {
+		b.entries = append(b.entries, n)
+		b.replacements = remove(b.replacements, n.ID)
+		return nil
+	}
+	b.replacements = append(remove(b.replacements, n.ID), n)
+	if len(b.replacements) > MAX_REPLACEMENTS {
+		b.replacements = b.replacements[1:]
+	}
+	return b.entries[0]
+}
+
+//remove delete the node and fill the bucket with the latest replacement
+func (this *Table) remove(id NodeID) {
+	if id == this.self {
+		return
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	b := this.bucket(id)
+	if indexOf(b.entries, id) < 0 {
+		b.replacements = remove(b.replacements, id)
+		return
+	}
+	b.entries = remove(b.entries, id)
+	if len(b.replacements) > 0 {
+		last := len(b.replacements) - 1
+		b.entries = append(b.entries, b.replacements[last])
+		b.replacements = b.replacements[:last]
+	}
+}
+
+//contains return whether the node is in the table
+func (this *Table) contains(id NodeID) bool {
+	if id == this.self {
+		return false
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return indexOf(this.bucket(id).entries, id) >= 0
+}
+
+//closest return at most count nodes closest to target
+func (this *Table) closest(target NodeID, count int) []*Node {
+	nodes := this.nodes()
+	sortByDistance(target, nodes)
+	if len(nodes) > count {
+		nodes = nodes[:count]
+	}
+	return nodes
+}
+
+//nodes return all nodes in the table
+func (this *Table) nodes() []*Node {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	nodes := make([]*Node, 0)
+	for _, b := range this.buckets {
+		nodes = append(nodes, b.entries...)
+	}
+	return nodes
+}
+
+//len return the node count of the table
+func (this *Table) len() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	count := 0
+	for _, b := range this.buckets {
+		count += len(b.entries)
+	}
+	return count
+}
+
+//subnetCount return the count of entries and replacements in the subnet, except the node of id
+func (this *bucket) subnetCount(sub string, id NodeID) int {
+	count := 0
+	for _, nodes := range [][]*Node{this.entries, this.replacements} {
+		for _, n := range nodes {
+			if n.ID != id && subnet(n.IP) == sub {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func sortByDistance(target NodeID, nodes []*Node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return distCmp(target, nodes[i].ID, nodes[j].ID) < 0
+	})
+}
+
+func indexOf(nodes []*Node, id NodeID) int {
+	for i, n := range nodes {
+		if n.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func remove(nodes []*Node, id NodeID) []*Node {
+	if index := indexOf(nodes, id); index >= 0 {
+		return append(nodes[:index], nodes[index+1:]...)
+	}
+	return nodes
+}
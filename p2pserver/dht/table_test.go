@@ -0,0 +1,114 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dht
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestNode(first, last byte) *Node {
+	n := &Node{UDPPort: 20338, TCPPort: 20338}
+	n.ID[0] = first
+	n.ID[ID_LEN-1] = last
+	return n
+}
+
+func TestTableAddRemove(t *testing.T) {
+	table := newTable(NodeID{})
+	assert.Nil(t, table.add(&Node{}))
+	assert.Equal(t, 0, table.len())
+
+	for i := 0; i < BUCKET_SIZE; i++ {
+		assert.Nil(t, table.add(newTestNode(0x80, byte(i))))
+	}
+	assert.Equal(t, BUCKET_SIZE, table.len())
+	//re-added node is moved to the tail
+	assert.Nil(t, table.add(newTestNode(0x80, 0)))
+	oldest := table.add(newTestNode(0x80, 0xff))
+	assert.NotNil(t, oldest)
+	assert.Equal(t, newTestNode(0x80, 1).ID, oldest.ID)
+	assert.False(t, table.contains(newTestNode(0x80, 0xff).ID))
+
+	//replacement fills the slot of removed node
+	table.remove(oldest.ID)
+	assert.False(t, table.contains(oldest.ID))
+	assert.True(t, table.contains(newTestNode(0x80, 0xff).ID))
+	assert.Equal(t, BUCKET_SIZE, table.len())
+
+	//other buckets are not affected
+	assert.Nil(t, table.add(newTestNode(0x01, 0)))
+	assert.Equal(t, BUCKET_SIZE+1, table.len())
+}
+
+func TestTableClosest(t *testing.T) {
+	table := newTable(NodeID{})
+	for i := 1; i <= 8; i++ {
+		table.add(newTestNode(byte(i), 0))
+	}
+	target := newTestNode(0x03, 1).ID
+	nodes := table.closest(target, 3)
+	assert.Equal(t, 3, len(nodes))
+	assert.Equal(t, newTestNode(0x03, 0).ID, nodes[0].ID)
+	assert.Equal(t, newTestNode(0x02, 0).ID, nodes[1].ID)
+	assert.Equal(t, newTestNode(0x01, 0).ID, nodes[2].ID)
+}
+
+func TestTableIPLimit(t *testing.T) {
+	table := newTable(NodeID{})
+	for i := 0; i < BUCKET_IP_LIMIT; i++ {
+		n := newTestNode(0x80, byte(i))
+		n.IP = []byte{1, 2, 3, byte(i)}
+		assert.Nil(t, table.add(n))
+	}
+	//bucket is limited by subnet, re-added node is not counted twice
+	n := newTestNode(0x80, 0xff)
+	n.IP = []byte{1, 2, 3, 0xff}
+	assert.Nil(t, table.add(n))
+	assert.False(t, table.contains(n.ID))
+	n = newTestNode(0x80, 0)
+	n.IP = []byte{1, 2, 3, 0}
+	assert.Nil(t, table.add(n))
+	assert.True(t, table.contains(n.ID))
+	n = newTestNode(0x80, 0xfe)
+	n.IP = []byte{1, 2, 4, 0}
+	assert.Nil(t, table.add(n))
+	assert.True(t, table.contains(n.ID))
+	//lan addresses are not limited
+	for i := 0; i < BUCKET_IP_LIMIT+1; i++ {
+		n = newTestNode(0x80, byte(0x10+i))
+		n.IP = []byte{192, 168, 0, byte(i)}
+		assert.Nil(t, table.add(n))
+		assert.True(t, table.contains(n.ID))
+	}
+
+	//table is limited by subnet across buckets
+	table = newTable(NodeID{})
+	for i := 0; i < TABLE_IP_LIMIT; i++ {
+		n = newTestNode(0x80>>uint(i/BUCKET_IP_LIMIT), byte(i))
+		n.IP = []byte{5, 6, 7, byte(i)}
+		assert.Nil(t, table.add(n))
+	}
+	assert.Equal(t, TABLE_IP_LIMIT, table.len())
+	n = newTestNode(0x01, 0)
+	n.IP = []byte{5, 6, 7, 0xff}
+	assert.Nil(t, table.add(n))
+	assert.False(t, table.contains(n.ID))
+}
@@ -0,0 +1,221 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2pserver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/config"
+	"github.com/OnyxPay/OnyxChain/common/log"
+	"github.com/OnyxPay/OnyxChain/core/ledger"
+	scom "github.com/OnyxPay/OnyxChain/core/store/common"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	p2pComm "github.com/OnyxPay/OnyxChain/p2pserver/common"
+	"github.com/OnyxPay/OnyxChain/p2pserver/message/msg_pack"
+	msgtypes "github.com/OnyxPay/OnyxChain/p2pserver/message/types"
+	"github.com/OnyxPay/OnyxChain/p2pserver/peer"
+)
+
+const (
+	LIGHT_PROOF_REQUEST_TIMEOUT = 10 //s, Request proof timeout time, try next peer after timeout
+	LIGHT_STATE_ROOT_CONFIRMS   = 2  //Number of peers which should agree on a storage proof
+)
+
+//proofKey identify a proof request to a peer
+type proofKey struct {
+	peerId uint64
+	hash   common.Uint256
+}
+
+//LightNodeMgr request transaction and storage proofs from full nodes, and verify them against local block headers
+type LightNodeMgr struct {
+	server  *P2PServer
+	ledger  *ledger.Ledger
+	lock    sync.Mutex
+	pending map[proofKey]chan interface{} //Proof requests waiting for response, nil response means not found
+}
+
+//NewLightNodeMgr return a LightNodeMgr instance
+func NewLightNodeMgr(server *P2PServer) *LightNodeMgr {
+	return &LightNodeMgr{
+		server:  server,
+		ledger:  server.ledger,
+		pending: make(map[proofKey]chan interface{}),
+	}
+}
+
+//GetTransactionProof request the proof of the transaction from full nodes, and return the first verified one
+func (this *LightNodeMgr) GetTransactionProof(txHash common.Uint256) (*types.TxProof, error) {
+	rootHeight := this.ledger.GetCurrentBlockHeight()
+	rootHeader, err := this.ledger.GetHeaderByHeight(rootHeight)
+	if err != nil {
+		return nil, fmt.Errorf("GetHeaderByHeight:%d error %s", rootHeight, err)
+	}
+	for _, p := range this.getProofNodes(rootHeight) {
+		resp, err := this.request(p, txHash, msgpack.NewTxProofReq(txHash, rootHeight))
+		if err != nil {
+			log.Debugf("[p2p]GetTransactionProof from peer:%d error:%s", p.GetID(), err)
+			continue
+		}
+		proof := resp.(*types.TxProof)
+		if proof.Tx.Hash() != txHash || proof.RootHeight != rootHeight {
+			log.Warnf("[p2p]GetTransactionProof peer:%d responses mismatched proof", p.GetID())
+			continue
+		}
+		header, err := this.ledger.GetHeaderByHeight(proof.Height)
+		if err != nil {
+			log.Warnf("[p2p]GetTransactionProof GetHeaderByHeight:%d error:%s", proof.Height, err)
+			continue
+		}
+		if err = proof.Verify(header, rootHeader); err != nil {
+			log.Warnf("[p2p]GetTransactionProof verify proof from peer:%d error:%s", p.GetID(), err)
+			continue
+		}
+		return proof, nil
+	}
+	return nil, scom.ErrNotFound
+}
+
+//GetStorageProof request the proof of the storage item from full nodes. Since the state root is not committed in
+//block headers, the proof is returned only if LIGHT_STATE_ROOT_CONFIRMS peers give the same state root, height and
+//value. The proof does not prove freshness: a proof of an older write set is valid against the state root as well, so
+//colluding peers can still return a stale value which was overwritten after the proved Height.
+func (this *LightNodeMgr) GetStorageProof(contract common.Address, key []byte) (*types.StorageProof, error) {
+	storeKey := make([]byte, 0, 1+common.ADDR_LEN+len(key))
+	storeKey = append(storeKey, byte(scom.ST_STORAGE))
+	storeKey = append(storeKey, contract[:]...)
+	storeKey = append(storeKey, key...)
+	hash := msgtypes.StorageKeyHash(storeKey)
+	stateHashCheckHeight := config.GetStateHashCheckHeight(config.DefConfig.P2PNode.NetworkId)
+	rootHeight := this.ledger.GetCurrentBlockHeight()
+
+	var result *types.StorageProof
+	confirms := 0
+	for _, p := range this.getProofNodes(rootHeight) {
+		resp, err := this.request(p, hash, msgpack.NewStorageProofReq(storeKey, rootHeight))
+		if err != nil {
+			log.Debugf("[p2p]GetStorageProof from peer:%d error:%s", p.GetID(), err)
+			continue
+		}
+		proof := resp.(*types.StorageProof)
+		if !bytes.Equal(proof.Key, storeKey) || proof.RootHeight != rootHeight {
+			log.Warnf("[p2p]GetStorageProof peer:%d responses mismatched proof", p.GetID())
+			continue
+		}
+		if err = proof.Verify(stateHashCheckHeight); err != nil {
+			log.Warnf("[p2p]GetStorageProof verify proof from peer:%d error:%s", p.GetID(), err)
+			continue
+		}
+		if result == nil {
+			result = proof
+		} else if result.StateRoot != proof.StateRoot {
+			return nil, fmt.Errorf("state root of height:%d mismatch between peers", rootHeight)
+		} else if result.Height != proof.Height || !bytes.Equal(result.Value, proof.Value) {
+			return nil, fmt.Errorf("storage value at height:%d mismatch between peers", rootHeight)
+		}
+		confirms++
+		if confirms >= LIGHT_STATE_ROOT_CONFIRMS {
+			return result, nil
+		}
+	}
+	if result == nil {
+		return nil, scom.ErrNotFound
+	}
+	return nil, errors.New("not enough peers to confirm the storage proof")
+}
+
+//OnTxProofReceive receive transaction proof from net
+func (this *LightNodeMgr) OnTxProofReceive(fromID uint64, proof *types.TxProof) {
+	if proof.Tx == nil {
+		return
+	}
+	this.onResponse(proofKey{peerId: fromID, hash: proof.Tx.Hash()}, proof)
+}
+
+//OnStorageProofReceive receive storage proof from net
+func (this *LightNodeMgr) OnStorageProofReceive(fromID uint64, proof *types.StorageProof) {
+	this.onResponse(proofKey{peerId: fromID, hash: msgtypes.StorageKeyHash(proof.Key)}, proof)
+}
+
+//OnNotFound receive not found message from net, which may be the response of a proof request
+func (this *LightNodeMgr) OnNotFound(fromID uint64, hash common.Uint256) {
+	this.onResponse(proofKey{peerId: fromID, hash: hash}, nil)
+}
+
+func (this *LightNodeMgr) onResponse(key proofKey, resp interface{}) {
+	this.lock.Lock()
+	ch, ok := this.pending[key]
+	delete(this.pending, key)
+	this.lock.Unlock()
+	if ok {
+		ch <- resp
+	}
+}
+
+//request send the proof request to peer and wait for the response
+func (this *LightNodeMgr) request(p *peer.Peer, hash common.Uint256, msg msgtypes.Message) (interface{}, error) {
+	key := proofKey{peerId: p.GetID(), hash: hash}
+	ch := make(chan interface{}, 1)
+	this.lock.Lock()
+	if _, ok := this.pending[key]; ok {
+		this.lock.Unlock()
+		return nil, errors.New("proof request is on flight")
+	}
+	this.pending[key] = ch
+	this.lock.Unlock()
+	defer func() {
+		this.lock.Lock()
+		if this.pending[key] == ch {
+			delete(this.pending, key)
+		}
+		this.lock.Unlock()
+	}()
+
+	if err := this.server.Send(p, msg, false); err != nil {
+		return nil, err
+	}
+	select {
+	case resp := <-ch:
+		if resp == nil {
+			return nil, scom.ErrNotFound
+		}
+		return resp, nil
+	case <-time.After(LIGHT_PROOF_REQUEST_TIMEOUT * time.Second):
+		return nil, errors.New("proof request timeout")
+	}
+}
+
+//getProofNodes return the full node peers which have reached the height
+func (this *LightNodeMgr) getProofNodes(height uint32) []*peer.Peer {
+	nodes := make([]*peer.Peer, 0)
+	for _, p := range this.server.network.GetNeighbors() {
+		if p.GetServices() == uint64(p2pComm.LIGHT_NODE) {
+			continue
+		}
+		if uint32(p.GetHeight()) >= height {
+			nodes = append(nodes, p)
+		}
+	}
+	return nodes
+}
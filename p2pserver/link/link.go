@@ -25,6 +25,7 @@ import (
 	"net"
 	"time"
 
+	"github.com/OnyxPay/OnyxChain-crypto/keypair"
 	comm "github.com/OnyxPay/OnyxChain/common"
 	"github.com/OnyxPay/OnyxChain/common/log"
 	"github.com/OnyxPay/OnyxChain/p2pserver/common"
@@ -40,6 +41,7 @@ type Link struct {
 	time      time.Time              // The latest time the node activity
 	recvChan  chan *types.MsgPayload //msgpayload channel
 	reqRecord map[string]int64       //Map RequestId to Timestamp, using for rejecting duplicate request in specific time
+	pubKey    keypair.PublicKey      //Node public key authenticated by secure handshake
 }
 
 func NewLink() *Link {
@@ -97,6 +99,14 @@ func (this *Link) GetConn() net.Conn {
 //set connection
 func (this *Link) SetConn(conn net.Conn) {
 	this.conn = conn
+	if sconn, ok := conn.(*SecureConn); ok {
+		this.pubKey = sconn.RemotePubKey()
+	}
+}
+
+//GetPubKey return the node public key of remote peer, nil if the connection is not secure
+func (this *Link) GetPubKey() keypair.PublicKey {
+	return this.pubKey
 }
 
 //record latest message time
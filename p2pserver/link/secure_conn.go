@@ -0,0 +1,265 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package link
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/OnyxPay/OnyxChain-crypto/keypair"
+	comm "github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/signature"
+	"github.com/OnyxPay/OnyxChain/p2pserver/common"
+	"golang.org/x/crypto/curve25519"
+)
+
+const (
+	HANDSHAKE_PROLOGUE = "onyxchain-p2p-secure-v1" //mixed into the handshake hash to separate from other protocols
+	FRAME_HDR_LEN      = 4                         //length of encrypted frame in byte
+	INITIATOR_ROLE     = 0x01
+	RESPONDER_ROLE     = 0x02
+)
+
+//SecureConn is a connection encrypted and authenticated by the secure handshake. The handshake follows the noise
+//pattern: both sides exchange x25519 ephemeral keys, derive the session keys from the shared secret, then prove
+//their node keys by signing the handshake hash in encrypted frames.
+type SecureConn struct {
+	net.Conn
+	remotePubKey keypair.PublicKey
+	readCipher   cipher.AEAD
+	writeCipher  cipher.AEAD
+	readNonce    uint64
+	writeNonce   uint64
+	readBuf      []byte //decrypted data not read yet
+	writeLock    sync.Mutex
+}
+
+//SecureHandshake run the handshake on conn with the node key, and return the secure connection
+func SecureHandshake(conn net.Conn, key signature.Signer, initiator bool) (*SecureConn, error) {
+	ephemeral := make([]byte, curve25519.ScalarSize)
+	if _, err := io.ReadFull(rand.Reader, ephemeral); err != nil {
+		return nil, err
+	}
+	localEph, err := curve25519.X25519(ephemeral, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	remoteEph := make([]byte, curve25519.PointSize)
+	if initiator {
+		if _, err = conn.Write(localEph); err != nil {
+			return nil, err
+		}
+		if _, err = io.ReadFull(conn, remoteEph); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err = io.ReadFull(conn, remoteEph); err != nil {
+			return nil, err
+		}
+		if _, err = conn.Write(localEph); err != nil {
+			return nil, err
+		}
+	}
+	shared, err := curve25519.X25519(ephemeral, remoteEph)
+	if err != nil {
+		return nil, err
+	}
+
+	hasher := sha256.New()
+	hasher.Write([]byte(HANDSHAKE_PROLOGUE))
+	if initiator {
+		hasher.Write(localEph)
+		hasher.Write(remoteEph)
+	} else {
+		hasher.Write(remoteEph)
+		hasher.Write(localEph)
+	}
+	hash := hasher.Sum(nil)
+	initCipher, err := newSessionCipher(shared, hash, INITIATOR_ROLE)
+	if err != nil {
+		return nil, err
+	}
+	respCipher, err := newSessionCipher(shared, hash, RESPONDER_ROLE)
+	if err != nil {
+		return nil, err
+	}
+	sconn := &SecureConn{Conn: conn}
+	localRole, remoteRole := byte(INITIATOR_ROLE), byte(RESPONDER_ROLE)
+	if initiator {
+		sconn.readCipher, sconn.writeCipher = respCipher, initCipher
+	} else {
+		sconn.readCipher, sconn.writeCipher = initCipher, respCipher
+		localRole, remoteRole = remoteRole, localRole
+	}
+
+	if initiator {
+		if err = sconn.writeAuth(key, hash, localRole); err != nil {
+			return nil, err
+		}
+		if err = sconn.readAuth(hash, remoteRole); err != nil {
+			return nil, err
+		}
+	} else {
+		if err = sconn.readAuth(hash, remoteRole); err != nil {
+			return nil, err
+		}
+		if err = sconn.writeAuth(key, hash, localRole); err != nil {
+			return nil, err
+		}
+	}
+	return sconn, nil
+}
+
+//newSessionCipher derive the aes-gcm cipher of role from the shared secret and handshake hash
+func newSessionCipher(shared, hash []byte, role byte) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, shared)
+	mac.Write(hash)
+	mac.Write([]byte{role})
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+//writeAuth send the node public key and its signature of the handshake hash
+func (this *SecureConn) writeAuth(key signature.Signer, hash []byte, role byte) error {
+	sig, err := signature.Sign(key, append(append([]byte{}, hash...), role))
+	if err != nil {
+		return err
+	}
+	sink := comm.NewZeroCopySink(nil)
+	sink.WriteVarBytes(keypair.SerializePublicKey(key.PubKey()))
+	sink.WriteVarBytes(sig)
+	return this.writeFrame(sink.Bytes())
+}
+
+//readAuth receive the remote public key and verify its signature of the handshake hash
+func (this *SecureConn) readAuth(hash []byte, role byte) error {
+	data, err := this.readFrame()
+	if err != nil {
+		return err
+	}
+	source := comm.NewZeroCopySource(data)
+	pubKeyData, _, irregular, eof := source.NextVarBytes()
+	if irregular {
+		return comm.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	sig, _, irregular, eof := source.NextVarBytes()
+	if irregular {
+		return comm.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	pubKey, err := keypair.DeserializePublicKey(pubKeyData)
+	if err != nil {
+		return fmt.Errorf("[p2p]invalid remote public key: %s", err)
+	}
+	err = signature.Verify(pubKey, append(append([]byte{}, hash...), role), sig)
+	if err != nil {
+		return fmt.Errorf("[p2p]verify handshake signature error: %s", err)
+	}
+	this.remotePubKey = pubKey
+	return nil
+}
+
+//RemotePubKey return the node public key of remote peer
+func (this *SecureConn) RemotePubKey() keypair.PublicKey {
+	return this.remotePubKey
+}
+
+func (this *SecureConn) Read(b []byte) (int, error) {
+	for len(this.readBuf) == 0 {
+		data, err := this.readFrame()
+		if err != nil {
+			return 0, err
+		}
+		this.readBuf = data
+	}
+	n := copy(b, this.readBuf)
+	this.readBuf = this.readBuf[n:]
+	return n, nil
+}
+
+func (this *SecureConn) Write(b []byte) (int, error) {
+	this.writeLock.Lock()
+	defer this.writeLock.Unlock()
+	written := 0
+	for written < len(b) {
+		end := written + common.MAX_FRAME_LEN
+		if end > len(b) {
+			end = len(b)
+		}
+		if err := this.writeFrame(b[written:end]); err != nil {
+			return written, err
+		}
+		written = end
+	}
+	return written, nil
+}
+
+func (this *SecureConn) readFrame() ([]byte, error) {
+	var hdr [FRAME_HDR_LEN]byte
+	if _, err := io.ReadFull(this.Conn, hdr[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(hdr[:])
+	if length > uint32(common.MAX_FRAME_LEN+this.readCipher.Overhead()) {
+		return nil, errors.New("[p2p]encrypted frame too large")
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(this.Conn, data); err != nil {
+		return nil, err
+	}
+	data, err := this.readCipher.Open(data[:0], makeNonce(this.readNonce), data, nil)
+	if err != nil {
+		return nil, err
+	}
+	this.readNonce++
+	return data, nil
+}
+
+func (this *SecureConn) writeFrame(data []byte) error {
+	buf := make([]byte, FRAME_HDR_LEN, FRAME_HDR_LEN+len(data)+this.writeCipher.Overhead())
+	buf = this.writeCipher.Seal(buf, makeNonce(this.writeNonce), data, nil)
+	this.writeNonce++
+	binary.BigEndian.PutUint32(buf, uint32(len(buf)-FRAME_HDR_LEN))
+	_, err := this.Conn.Write(buf)
+	return err
+}
+
+//makeNonce return the aes-gcm nonce of counter
+func makeNonce(counter uint64) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[4:], counter)
+	return nonce
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package link
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net"
+	"testing"
+
+	"github.com/OnyxPay/OnyxChain-crypto/keypair"
+	"github.com/OnyxPay/OnyxChain/account"
+	comm "github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/p2pserver/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func secureHandshakePair(cliKey, serKey *account.Account) (*SecureConn, *SecureConn, error) {
+	cli, ser := net.Pipe()
+	type result struct {
+		conn *SecureConn
+		err  error
+	}
+	ch := make(chan *result, 1)
+	go func() {
+		conn, err := SecureHandshake(ser, serKey, false)
+		if err != nil {
+			ser.Close()
+		}
+		ch <- &result{conn: conn, err: err}
+	}()
+	cliConn, err := SecureHandshake(cli, cliKey, true)
+	if err != nil {
+		cli.Close()
+	}
+	r := <-ch
+	if err != nil {
+		return nil, nil, err
+	}
+	return cliConn, r.conn, r.err
+}
+
+func TestSecureHandshake(t *testing.T) {
+	cliKey := account.NewAccount("")
+	serKey := account.NewAccount("")
+	cliConn, serConn, err := secureHandshakePair(cliKey, serKey)
+	assert.Nil(t, err)
+	defer cliConn.Close()
+	defer serConn.Close()
+	assert.Equal(t, serKey.PublicKey, cliConn.RemotePubKey())
+	assert.Equal(t, cliKey.PublicKey, serConn.RemotePubKey())
+
+	link := NewLink()
+	link.SetConn(serConn)
+	assert.Equal(t, cliKey.PublicKey, link.GetPubKey())
+	assert.Equal(t, common.PubKeyToPeerID(cliKey.PublicKey), common.PubKeyToPeerID(link.GetPubKey()))
+
+	data := make([]byte, common.MAX_FRAME_LEN*2+100)
+	rand.Read(data)
+	go func() {
+		cliConn.Write(data)
+	}()
+	received := make([]byte, len(data))
+	_, err = io.ReadFull(serConn, received)
+	assert.Nil(t, err)
+	assert.True(t, bytes.Equal(data, received))
+}
+
+func TestSecureConnTampered(t *testing.T) {
+	cliKey := account.NewAccount("")
+	serKey := account.NewAccount("")
+	cli, ser := net.Pipe()
+	defer cli.Close()
+	defer ser.Close()
+	go func() {
+		cliConn, err := SecureHandshake(cli, cliKey, true)
+		if err != nil {
+			return
+		}
+		cliConn.Write([]byte("hello"))
+	}()
+
+	//flip a bit of the first data frame after handshake
+	serConn, err := SecureHandshake(ser, serKey, false)
+	assert.Nil(t, err)
+	frame := make([]byte, FRAME_HDR_LEN+5+serConn.readCipher.Overhead())
+	_, err = io.ReadFull(ser, frame)
+	assert.Nil(t, err)
+	frame[len(frame)-1] ^= 1
+	r, w := net.Pipe()
+	defer r.Close()
+	go w.Write(frame)
+	serConn.Conn = r
+	_, err = serConn.Read(make([]byte, 5))
+	assert.NotNil(t, err)
+}
+
+func TestSecureAuthTruncated(t *testing.T) {
+	key := account.NewAccount("")
+	hash := make([]byte, 32)
+	aead, err := newSessionCipher(hash, hash, INITIATOR_ROLE)
+	assert.Nil(t, err)
+	cli, ser := net.Pipe()
+	defer cli.Close()
+	defer ser.Close()
+	cliConn := &SecureConn{Conn: cli, writeCipher: aead}
+	serConn := &SecureConn{Conn: ser, readCipher: aead}
+
+	//the auth frame is cut in the middle of the public key
+	go func() {
+		sink := comm.NewZeroCopySink(nil)
+		sink.WriteVarBytes(keypair.SerializePublicKey(key.PublicKey))
+		sink.WriteVarBytes([]byte("signature"))
+		cliConn.writeFrame(sink.Bytes()[:10])
+	}()
+	assert.Equal(t, io.ErrUnexpectedEOF, serConn.readAuth(hash, INITIATOR_ROLE))
+}
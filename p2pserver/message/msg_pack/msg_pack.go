@@ -19,6 +19,7 @@
 package msgpack
 
 import (
+	"math/rand"
 	"time"
 
 	"github.com/OnyxPay/OnyxChain/common"
@@ -207,3 +208,77 @@ func NewConsensusDataReq(hash common.Uint256) mt.Message {
 
 	return &dataReq
 }
+
+//transaction proof request package
+func NewTxProofReq(txHash common.Uint256, rootHeight uint32) mt.Message {
+	log.Trace()
+	var req mt.TxProofReq
+	req.TxHash = txHash
+	req.RootHeight = rootHeight
+
+	return &req
+}
+
+//transaction proof package
+func NewTxProof(proof *ct.TxProof) mt.Message {
+	log.Trace()
+	var txProof mt.TxProof
+	txProof.Proof = proof
+
+	return &txProof
+}
+
+//storage proof request package
+func NewStorageProofReq(key []byte, rootHeight uint32) mt.Message {
+	log.Trace()
+	var req mt.StorageProofReq
+	req.Key = key
+	req.RootHeight = rootHeight
+
+	return &req
+}
+
+//storage proof package
+func NewStorageProof(proof *ct.StorageProof) mt.Message {
+	log.Trace()
+	var storageProof mt.StorageProof
+	storageProof.Proof = proof
+
+	return &storageProof
+}
+
+//compact block request package
+func NewCmpctBlkDataReq(hash common.Uint256) mt.Message {
+	log.Trace()
+	var dataReq mt.DataReq
+	dataReq.DataType = common.COMPACT_BLOCK
+	dataReq.Hash = hash
+
+	return &dataReq
+}
+
+//compact block package, prefill decides the transactions sent along with the block
+func NewCompactBlock(bk *ct.Block, merkleRoot common.Uint256, prefill func(tx *ct.Transaction) bool) mt.Message {
+	log.Trace()
+	return mt.NewCompactBlock(bk, merkleRoot, rand.Uint64(), prefill)
+}
+
+//missing transactions of compact block request package
+func NewBlkTxnReq(hash common.Uint256, indexes []uint32) mt.Message {
+	log.Trace()
+	var req mt.BlkTxnReq
+	req.BlockHash = hash
+	req.Indexes = indexes
+
+	return &req
+}
+
+//missing transactions of compact block package
+func NewBlkTxn(hash common.Uint256, txs []*ct.Transaction) mt.Message {
+	log.Trace()
+	var blkTxn mt.BlkTxn
+	blkTxn.BlockHash = hash
+	blkTxn.Txs = txs
+
+	return &blkTxn
+}
@@ -0,0 +1,274 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	ct "github.com/OnyxPay/OnyxChain/core/types"
+	comm "github.com/OnyxPay/OnyxChain/p2pserver/common"
+)
+
+//PrefilledTx is a transaction sent along with compact block, which the sender predicts the peer lacks
+type PrefilledTx struct {
+	Index uint32 //Index of the transaction in the block
+	Tx    *ct.Transaction
+}
+
+//CompactBlock relay a block with short tx ids, the peer rebuilds the block from its txnpool
+type CompactBlock struct {
+	Header       *ct.Header
+	MerkleRoot   common.Uint256
+	Nonce        uint64         //Salt of short tx ids
+	ShortIds     []uint64       //Short ids of the transactions not prefilled, in block order
+	PrefilledTxs []*PrefilledTx //Prefilled transactions in block order
+}
+
+//NewCompactBlock build the compact block, the transactions which prefill returns true are sent with it
+func NewCompactBlock(blk *ct.Block, merkleRoot common.Uint256, nonce uint64,
+	prefill func(tx *ct.Transaction) bool) *CompactBlock {
+	blkHash := blk.Hash()
+	cmpct := &CompactBlock{
+		Header:     blk.Header,
+		MerkleRoot: merkleRoot,
+		Nonce:      nonce,
+	}
+	for i, tx := range blk.Transactions {
+		if prefill(tx) {
+			cmpct.PrefilledTxs = append(cmpct.PrefilledTxs, &PrefilledTx{Index: uint32(i), Tx: tx})
+		} else {
+			cmpct.ShortIds = append(cmpct.ShortIds, ShortTxId(blkHash, nonce, tx.Hash()))
+		}
+	}
+	return cmpct
+}
+
+//ShortTxId return the short id of a transaction in the compact block
+func ShortTxId(blkHash common.Uint256, nonce uint64, txHash common.Uint256) uint64 {
+	var buf [common.UINT256_SIZE*2 + 8]byte
+	copy(buf[:], blkHash[:])
+	binary.LittleEndian.PutUint64(buf[common.UINT256_SIZE:], nonce)
+	copy(buf[common.UINT256_SIZE+8:], txHash[:])
+	hash := sha256.Sum256(buf[:])
+	return binary.LittleEndian.Uint64(hash[:8])
+}
+
+//TxCount return the transaction count of the block
+func (this *CompactBlock) TxCount() int {
+	return len(this.ShortIds) + len(this.PrefilledTxs)
+}
+
+//Rebuild fill the transactions of the block from pool, which is keyed by short id. It returns the transactions
+//in block order, and the indexes of the missing ones
+func (this *CompactBlock) Rebuild(pool map[uint64]*ct.Transaction) ([]*ct.Transaction, []uint32) {
+	txs := make([]*ct.Transaction, this.TxCount())
+	for _, prefilled := range this.PrefilledTxs {
+		txs[prefilled.Index] = prefilled.Tx
+	}
+	missing := make([]uint32, 0)
+	next := 0
+	for i := range txs {
+		if txs[i] != nil {
+			continue
+		}
+		txs[i] = pool[this.ShortIds[next]]
+		if txs[i] == nil {
+			missing = append(missing, uint32(i))
+		}
+		next++
+	}
+	return txs, missing
+}
+
+//Serialize message payload
+func (this *CompactBlock) Serialization(sink *common.ZeroCopySink) error {
+	err := this.Header.Serialization(sink)
+	if err != nil {
+		return err
+	}
+	sink.WriteHash(this.MerkleRoot)
+	sink.WriteUint64(this.Nonce)
+	sink.WriteVarUint(uint64(len(this.ShortIds)))
+	for _, id := range this.ShortIds {
+		sink.WriteUint64(id)
+	}
+	sink.WriteVarUint(uint64(len(this.PrefilledTxs)))
+	for _, prefilled := range this.PrefilledTxs {
+		sink.WriteUint32(prefilled.Index)
+		err = prefilled.Tx.Serialization(sink)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *CompactBlock) CmdType() string {
+	return comm.CMPCT_BLOCK_TYPE
+}
+
+//Deserialize message payload
+func (this *CompactBlock) Deserialization(source *common.ZeroCopySource) error {
+	this.Header = &ct.Header{}
+	err := this.Header.Deserialization(source)
+	if err != nil {
+		return err
+	}
+	var eof bool
+	this.MerkleRoot, eof = source.NextHash()
+	this.Nonce, eof = source.NextUint64()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	n, err := nextCount(source, 8)
+	if err != nil {
+		return err
+	}
+	this.ShortIds = make([]uint64, 0, n)
+	for i := uint64(0); i < n; i++ {
+		id, _ := source.NextUint64()
+		this.ShortIds = append(this.ShortIds, id)
+	}
+	n, err = nextCount(source, 4)
+	if err != nil {
+		return err
+	}
+	this.PrefilledTxs = make([]*PrefilledTx, 0, n)
+	total := uint64(len(this.ShortIds)) + n
+	for i := uint64(0); i < n; i++ {
+		prefilled := &PrefilledTx{Tx: &ct.Transaction{}}
+		prefilled.Index, eof = source.NextUint32()
+		if eof {
+			return io.ErrUnexpectedEOF
+		}
+		if uint64(prefilled.Index) >= total ||
+			(i > 0 && prefilled.Index <= this.PrefilledTxs[i-1].Index) {
+			return common.ErrIrregularData
+		}
+		err = prefilled.Tx.Deserialization(source)
+		if err != nil {
+			return err
+		}
+		this.PrefilledTxs = append(this.PrefilledTxs, prefilled)
+	}
+	return nil
+}
+
+//BlkTxnReq request the missing transactions of compact block
+type BlkTxnReq struct {
+	BlockHash common.Uint256
+	Indexes   []uint32 //Indexes of the missing transactions in the block
+}
+
+//Serialize message payload
+func (this *BlkTxnReq) Serialization(sink *common.ZeroCopySink) error {
+	sink.WriteHash(this.BlockHash)
+	sink.WriteVarUint(uint64(len(this.Indexes)))
+	for _, index := range this.Indexes {
+		sink.WriteUint32(index)
+	}
+	return nil
+}
+
+func (this *BlkTxnReq) CmdType() string {
+	return comm.GET_BLK_TXN_TYPE
+}
+
+//Deserialize message payload
+func (this *BlkTxnReq) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	this.BlockHash, eof = source.NextHash()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	n, err := nextCount(source, 4)
+	if err != nil {
+		return err
+	}
+	this.Indexes = make([]uint32, 0, n)
+	for i := uint64(0); i < n; i++ {
+		index, _ := source.NextUint32()
+		this.Indexes = append(this.Indexes, index)
+	}
+	return nil
+}
+
+//BlkTxn is the response of BlkTxnReq
+type BlkTxn struct {
+	BlockHash common.Uint256
+	Txs       []*ct.Transaction //Transactions in the order of BlkTxnReq indexes
+}
+
+//Serialize message payload
+func (this *BlkTxn) Serialization(sink *common.ZeroCopySink) error {
+	sink.WriteHash(this.BlockHash)
+	sink.WriteVarUint(uint64(len(this.Txs)))
+	for _, tx := range this.Txs {
+		err := tx.Serialization(sink)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *BlkTxn) CmdType() string {
+	return comm.BLK_TXN_TYPE
+}
+
+//Deserialize message payload
+func (this *BlkTxn) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	this.BlockHash, eof = source.NextHash()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	n, err := nextCount(source, 1)
+	if err != nil {
+		return err
+	}
+	this.Txs = make([]*ct.Transaction, 0, n)
+	for i := uint64(0); i < n; i++ {
+		tx := &ct.Transaction{}
+		err = tx.Deserialization(source)
+		if err != nil {
+			return err
+		}
+		this.Txs = append(this.Txs, tx)
+	}
+	return nil
+}
+
+//nextCount read the item count, which is bounded by the remaining data and the min item size
+func nextCount(source *common.ZeroCopySource, itemSize uint64) (uint64, error) {
+	n, _, irregular, eof := source.NextVarUint()
+	if eof {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if irregular {
+		return 0, common.ErrIrregularData
+	}
+	if n > source.Len()/itemSize {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return n, nil
+}
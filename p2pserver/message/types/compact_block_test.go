@@ -0,0 +1,81 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	cm "github.com/OnyxPay/OnyxChain/common"
+	ct "github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/OnyxPay/OnyxChain/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCompactBlock(t *testing.T) (*ct.Block, *CompactBlock) {
+	blk := &ct.Block{Header: &ct.Header{Height: 10, Timestamp: 12345678}}
+	for i := byte(0); i < 4; i++ {
+		tx, err := utils.NewInvokeTransaction([]byte{i}).IntoImmutable()
+		assert.Nil(t, err)
+		blk.Transactions = append(blk.Transactions, tx)
+	}
+	cmpct := NewCompactBlock(blk, cm.UINT256_EMPTY, 42, func(tx *ct.Transaction) bool {
+		return tx == blk.Transactions[1]
+	})
+	return blk, cmpct
+}
+
+func TestCompactBlockSerializationDeserialization(t *testing.T) {
+	blk, msg := newTestCompactBlock(t)
+	assert.Equal(t, 3, len(msg.ShortIds))
+	assert.Equal(t, 1, len(msg.PrefilledTxs))
+
+	sink := cm.NewZeroCopySink(nil)
+	assert.Nil(t, WriteMessage(sink, msg))
+	demsg, _, err := ReadMessage(bytes.NewBuffer(sink.Bytes()))
+	assert.Nil(t, err)
+	cmpct := demsg.(*CompactBlock)
+	assert.Equal(t, blk.Hash(), cmpct.Header.Hash())
+	assert.Equal(t, msg.Nonce, cmpct.Nonce)
+	assert.Equal(t, msg.ShortIds, cmpct.ShortIds)
+	assert.Equal(t, uint32(1), cmpct.PrefilledTxs[0].Index)
+	assert.Equal(t, blk.Transactions[1].Hash(), cmpct.PrefilledTxs[0].Tx.Hash())
+}
+
+func TestCompactBlockRebuild(t *testing.T) {
+	blk, cmpct := newTestCompactBlock(t)
+	pool := make(map[uint64]*ct.Transaction)
+	for _, i := range []int{0, 3} {
+		pool[ShortTxId(blk.Hash(), cmpct.Nonce, blk.Transactions[i].Hash())] = blk.Transactions[i]
+	}
+	txs, missing := cmpct.Rebuild(pool)
+	assert.Equal(t, []uint32{2}, missing)
+	for _, i := range []int{0, 1, 3} {
+		assert.Equal(t, blk.Transactions[i], txs[i])
+	}
+	assert.Nil(t, txs[2])
+}
+
+func TestBlkTxnReqSerializationDeserialization(t *testing.T) {
+	var msg BlkTxnReq
+	msg.BlockHash, _ = cm.Uint256FromHexString("8932da73f52b1e22f30c609988ed1f693b6144f74fed9a2a20869afa7abfdf5e")
+	msg.Indexes = []uint32{1, 5, 8}
+
+	MessageTest(t, &msg)
+}
@@ -184,6 +184,20 @@ func MakeEmptyMessage(cmdType string) (Message, error) {
 		return &Disconnected{}, nil
 	case common.GET_BLOCKS_TYPE:
 		return &BlocksReq{}, nil
+	case common.GET_TX_PROOF_TYPE:
+		return &TxProofReq{}, nil
+	case common.TX_PROOF_TYPE:
+		return &TxProof{}, nil
+	case common.GET_ST_PROOF_TYPE:
+		return &StorageProofReq{}, nil
+	case common.ST_PROOF_TYPE:
+		return &StorageProof{}, nil
+	case common.CMPCT_BLOCK_TYPE:
+		return &CompactBlock{}, nil
+	case common.GET_BLK_TXN_TYPE:
+		return &BlkTxnReq{}, nil
+	case common.BLK_TXN_TYPE:
+		return &BlkTxn{}, nil
 	default:
 		return nil, errors.New("unsupported cmd type:" + cmdType)
 	}
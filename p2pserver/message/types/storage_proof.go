@@ -0,0 +1,89 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"crypto/sha256"
+	"io"
+
+	comm "github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/OnyxPay/OnyxChain/p2pserver/common"
+)
+
+//StorageProofReq request the proof of a raw state key against the state merkle root of the block of RootHeight
+type StorageProofReq struct {
+	Key        []byte
+	RootHeight uint32
+}
+
+//Serialize message payload
+func (this *StorageProofReq) Serialization(sink *comm.ZeroCopySink) error {
+	sink.WriteVarBytes(this.Key)
+	sink.WriteUint32(this.RootHeight)
+	return nil
+}
+
+func (this *StorageProofReq) CmdType() string {
+	return common.GET_ST_PROOF_TYPE
+}
+
+//Deserialize message payload
+func (this *StorageProofReq) Deserialization(source *comm.ZeroCopySource) error {
+	var irregular, eof bool
+	this.Key, _, irregular, eof = source.NextVarBytes()
+	if irregular {
+		return comm.ErrIrregularData
+	}
+	this.RootHeight, eof = source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+//StorageProof is the response of StorageProofReq
+type StorageProof struct {
+	Proof *types.StorageProof
+}
+
+//Serialize message payload
+func (this *StorageProof) Serialization(sink *comm.ZeroCopySink) error {
+	return this.Proof.Serialization(sink)
+}
+
+func (this *StorageProof) CmdType() string {
+	return common.ST_PROOF_TYPE
+}
+
+//Deserialize message payload
+func (this *StorageProof) Deserialization(source *comm.ZeroCopySource) error {
+	proof := &types.StorageProof{}
+	err := proof.Deserialization(source)
+	if err != nil {
+		return err
+	}
+	this.Proof = proof
+	return nil
+}
+
+//StorageKeyHash return the hash identifying a storage key in NotFound message
+func StorageKeyHash(key []byte) comm.Uint256 {
+	return sha256.Sum256(key)
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"testing"
+
+	cm "github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/types"
+)
+
+func TestStorageProofReqSerializationDeserialization(t *testing.T) {
+	var msg StorageProofReq
+	msg.Key = []byte("storage key")
+	msg.RootHeight = 100
+
+	MessageTest(t, &msg)
+}
+
+func TestStorageProofSerializationDeserialization(t *testing.T) {
+	root, _ := cm.Uint256FromHexString("8932da73f52b1e22f30c609988ed1f693b6144f74fed9a2a20869afa7abfdf5e")
+	var msg StorageProof
+	msg.Proof = &types.StorageProof{
+		Key:    []byte("key1"),
+		Value:  []byte("value1"),
+		Height: 10,
+		WriteSet: []*types.StateItem{
+			{Key: []byte("key1"), Value: []byte("value1")},
+			{Key: []byte("key2"), Value: []byte("value2")},
+		},
+		RootHeight: 20,
+		StateRoot:  root,
+		StatePath:  []cm.Uint256{root, StorageKeyHash([]byte("key2"))},
+	}
+
+	MessageTest(t, &msg)
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"io"
+
+	comm "github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/OnyxPay/OnyxChain/p2pserver/common"
+)
+
+//TxProofReq request the proof of a transaction against the block root of the block of RootHeight
+type TxProofReq struct {
+	TxHash     comm.Uint256
+	RootHeight uint32
+}
+
+//Serialize message payload
+func (this *TxProofReq) Serialization(sink *comm.ZeroCopySink) error {
+	sink.WriteHash(this.TxHash)
+	sink.WriteUint32(this.RootHeight)
+	return nil
+}
+
+func (this *TxProofReq) CmdType() string {
+	return common.GET_TX_PROOF_TYPE
+}
+
+//Deserialize message payload
+func (this *TxProofReq) Deserialization(source *comm.ZeroCopySource) error {
+	var eof bool
+	this.TxHash, eof = source.NextHash()
+	this.RootHeight, eof = source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+//TxProof is the response of TxProofReq
+type TxProof struct {
+	Proof *types.TxProof
+}
+
+//Serialize message payload
+func (this *TxProof) Serialization(sink *comm.ZeroCopySink) error {
+	return this.Proof.Serialization(sink)
+}
+
+func (this *TxProof) CmdType() string {
+	return common.TX_PROOF_TYPE
+}
+
+//Deserialize message payload
+func (this *TxProof) Deserialization(source *comm.ZeroCopySource) error {
+	proof := &types.TxProof{}
+	err := proof.Deserialization(source)
+	if err != nil {
+		return err
+	}
+	this.Proof = proof
+	return nil
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	evtActor "github.com/OnyxPay/OnyxChain-eventbus/actor"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/log"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	actor "github.com/OnyxPay/OnyxChain/p2pserver/actor/req"
+	msgCommon "github.com/OnyxPay/OnyxChain/p2pserver/common"
+	"github.com/OnyxPay/OnyxChain/p2pserver/message/msg_pack"
+	msgTypes "github.com/OnyxPay/OnyxChain/p2pserver/message/types"
+	"github.com/OnyxPay/OnyxChain/p2pserver/net/protocol"
+	"github.com/OnyxPay/OnyxChain/p2pserver/peer"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+//knownTxs cache the hash of txs relayed through the network, which the peers are supposed to have
+var knownTxs, _ = lru.New(msgCommon.MAX_KNOWN_TX_SIZE)
+
+//cmpctBlocks cache the compact blocks waiting for missing txs, keyed by block hash
+var cmpctBlocks, _ = lru.New(msgCommon.MAX_CMPCT_BLK_SIZE)
+
+//partialBlock is a compact block being rebuilt
+type partialBlock struct {
+	peerId     uint64
+	blockSize  uint32
+	header     *types.Header
+	merkleRoot common.Uint256
+	txs        []*types.Transaction
+	missing    []uint32
+}
+
+//AddKnownTx record the tx relayed through the network
+func AddKnownTx(hash common.Uint256) {
+	knownTxs.Add(hash, struct{}{})
+}
+
+//isUnknownTx return whether the peers may lack the tx, it should be prefilled in compact block
+func isUnknownTx(tx *types.Transaction) bool {
+	return !knownTxs.Contains(tx.Hash())
+}
+
+//getPoolShortIds return the txs in txnpool keyed by short id, ids conflicted are set nil
+func getPoolShortIds(blkHash common.Uint256, nonce uint64) (map[uint64]*types.Transaction, error) {
+	txs, err := actor.GetMemPoolTxs()
+	if err != nil {
+		return nil, err
+	}
+	pool := make(map[uint64]*types.Transaction, len(txs))
+	for _, tx := range txs {
+		id := msgTypes.ShortTxId(blkHash, nonce, tx.Hash())
+		if _, ok := pool[id]; ok {
+			pool[id] = nil
+		} else {
+			pool[id] = tx
+		}
+	}
+	return pool, nil
+}
+
+//finishCompactBlock pass the rebuilt block to p2p actor, or request the full block if the txs mismatch with header
+func finishCompactBlock(p2p p2p.P2P, remotePeer *peer.Peer, pid *evtActor.PID, partial *partialBlock) {
+	blkHash := partial.header.Hash()
+	hashes := make([]common.Uint256, 0, len(partial.txs))
+	for _, tx := range partial.txs {
+		hashes = append(hashes, tx.Hash())
+	}
+	if common.ComputeMerkleRoot(hashes) != partial.header.TransactionsRoot {
+		log.Debugf("[p2p]rebuild compact block %s failed, request full block", blkHash.ToHexString())
+		requestFullBlock(p2p, remotePeer, blkHash)
+		return
+	}
+	if pid != nil {
+		input := &msgCommon.AppendBlock{
+			FromID:     partial.peerId,
+			BlockSize:  partial.blockSize,
+			Block:      &types.Block{Header: partial.header, Transactions: partial.txs},
+			MerkleRoot: partial.merkleRoot,
+		}
+		pid.Tell(input)
+	}
+}
+
+//requestFullBlock fallback to full block relay
+func requestFullBlock(p2p p2p.P2P, remotePeer *peer.Peer, blkHash common.Uint256) {
+	msg := msgpack.NewBlkDataReq(blkHash)
+	err := p2p.Send(remotePeer, msg, false)
+	if err != nil {
+		log.Warn(err)
+	}
+}
@@ -190,6 +190,13 @@ func ConsensusHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID,
 func NotFoundHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
 	var notFound = data.Payload.(*msgTypes.NotFound)
 	log.Debug("[p2p]receive notFound message, hash is ", notFound.Hash)
+	if pid != nil {
+		input := &msgCommon.NotFoundHash{
+			FromID: data.Id,
+			Hash:   notFound.Hash,
+		}
+		pid.Tell(input)
+	}
 }
 
 // TransactionHandle handles the transaction message from peer
@@ -197,6 +204,7 @@ func TransactionHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID
 	log.Trace("[p2p]receive transaction message", data.Addr, data.Id)
 
 	var trn = data.Payload.(*msgTypes.Trn)
+	AddKnownTx(trn.Txn.Hash())
 	actor.AddTransaction(trn.Txn)
 	log.Trace("[p2p]receive Transaction message hash", trn.Txn.Hash())
 
@@ -240,6 +248,22 @@ func VersionHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 
 	}
 
+	if config.DefConfig.P2PNode.SecureTransport {
+		link := remotePeer.SyncLink
+		if version.P.IsConsensus {
+			link = remotePeer.ConsLink
+		}
+		if err := checkPeerKey(link.GetPubKey(), &version.P); err != nil {
+			log.Warnf("[p2p]peer %s authentication failed: %s, close", data.Addr, err)
+			if version.P.IsConsensus {
+				remotePeer.CloseCons()
+			} else {
+				remotePeer.CloseSync()
+			}
+			return
+		}
+	}
+
 	if version.P.IsConsensus == true {
 		if config.DefConfig.P2PNode.DualPortSupport == false {
 			log.Warn("[p2p]consensus port not surpport", data.Addr)
@@ -548,6 +572,50 @@ func DataReqHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 			return
 		}
 
+	case common.COMPACT_BLOCK:
+		reqID := fmt.Sprintf("%x%s", reqType, hash.ToHexString())
+		data := getRespCacheValue(reqID)
+		var msg msgTypes.Message
+		if data != nil {
+			switch data.(type) {
+			case *msgTypes.CompactBlock:
+				msg = data.(*msgTypes.CompactBlock)
+			}
+		}
+		if msg == nil {
+			block, err := ledger.DefLedger.GetBlockByHash(hash)
+			if err != nil || block == nil || block.Header == nil {
+				log.Debug("[p2p]can't get compact block by hash: ", hash,
+					" ,send not found message")
+				msg := msgpack.NewNotFound(hash)
+				err := p2p.Send(remotePeer, msg, false)
+				if err != nil {
+					log.Warn(err)
+					return
+				}
+				return
+			}
+			merkleRoot, err := ledger.DefLedger.GetStateMerkleRoot(block.Header.Height)
+			if err != nil {
+				log.Debugf("[p2p]failed to get state merkel root at height %v, err %v",
+					block.Header.Height, err)
+				msg := msgpack.NewNotFound(hash)
+				err := p2p.Send(remotePeer, msg, false)
+				if err != nil {
+					log.Warn(err)
+					return
+				}
+				return
+			}
+			msg = msgpack.NewCompactBlock(block, merkleRoot, isUnknownTx)
+			saveRespCache(reqID, msg)
+		}
+		err := p2p.Send(remotePeer, msg, false)
+		if err != nil {
+			log.Warn(err)
+			return
+		}
+
 	case common.TRANSACTION:
 		txn, err := ledger.DefLedger.GetTransaction(hash)
 		if err != nil {
@@ -569,6 +637,199 @@ func DataReqHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, ar
 	}
 }
 
+// TxProofReqHandle handles the transaction proof req from light node
+func TxProofReqHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
+	log.Trace("[p2p]receive tx proof req message", data.Addr, data.Id)
+
+	var req = data.Payload.(*msgTypes.TxProofReq)
+
+	remotePeer := p2p.GetPeer(data.Id)
+	if remotePeer == nil {
+		log.Debug("[p2p]remotePeer invalid in TxProofReqHandle")
+		return
+	}
+	var msg msgTypes.Message
+	proof, err := ledger.DefLedger.GetTransactionProof(req.TxHash, req.RootHeight)
+	if err != nil {
+		log.Debug("[p2p]can't get transaction proof by hash: ", req.TxHash,
+			" ,send not found message")
+		msg = msgpack.NewNotFound(req.TxHash)
+	} else {
+		msg = msgpack.NewTxProof(proof)
+	}
+	err = p2p.Send(remotePeer, msg, false)
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+}
+
+// TxProofHandle handles the transaction proof from peer
+func TxProofHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
+	log.Trace("[p2p]receive tx proof message", data.Addr, data.Id)
+
+	if pid != nil {
+		var txProof = data.Payload.(*msgTypes.TxProof)
+		input := &msgCommon.AppendTxProof{
+			FromID: data.Id,
+			Proof:  txProof.Proof,
+		}
+		pid.Tell(input)
+	}
+}
+
+// StorageProofReqHandle handles the storage proof req from light node
+func StorageProofReqHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
+	log.Trace("[p2p]receive storage proof req message", data.Addr, data.Id)
+
+	var req = data.Payload.(*msgTypes.StorageProofReq)
+
+	remotePeer := p2p.GetPeer(data.Id)
+	if remotePeer == nil {
+		log.Debug("[p2p]remotePeer invalid in StorageProofReqHandle")
+		return
+	}
+	var msg msgTypes.Message
+	proof, err := ledger.DefLedger.GetStorageProof(req.Key, req.RootHeight)
+	if err != nil {
+		log.Debugf("[p2p]can't get storage proof of key: %x, err %v, send not found message", req.Key, err)
+		msg = msgpack.NewNotFound(msgTypes.StorageKeyHash(req.Key))
+	} else {
+		msg = msgpack.NewStorageProof(proof)
+	}
+	err = p2p.Send(remotePeer, msg, false)
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+}
+
+// StorageProofHandle handles the storage proof from peer
+func StorageProofHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
+	log.Trace("[p2p]receive storage proof message", data.Addr, data.Id)
+
+	if pid != nil {
+		var storageProof = data.Payload.(*msgTypes.StorageProof)
+		input := &msgCommon.AppendStorageProof{
+			FromID: data.Id,
+			Proof:  storageProof.Proof,
+		}
+		pid.Tell(input)
+	}
+}
+
+// CompactBlockHandle rebuilds the compact block from txnpool, and requests the missing txs from peer
+func CompactBlockHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
+	log.Trace("[p2p]receive compact block message", data.Addr, data.Id)
+
+	var cmpct = data.Payload.(*msgTypes.CompactBlock)
+
+	remotePeer := p2p.GetPeer(data.Id)
+	if remotePeer == nil {
+		log.Debug("[p2p]remotePeer invalid in CompactBlockHandle")
+		return
+	}
+	blkHash := cmpct.Header.Hash()
+	if cmpct.Header.Height <= ledger.DefLedger.GetCurrentBlockHeight() {
+		return
+	}
+	pool, err := getPoolShortIds(blkHash, cmpct.Nonce)
+	if err != nil {
+		log.Debugf("[p2p]get txnpool for compact block error %s, request full block", err)
+		requestFullBlock(p2p, remotePeer, blkHash)
+		return
+	}
+	txs, missing := cmpct.Rebuild(pool)
+	partial := &partialBlock{
+		peerId:     data.Id,
+		blockSize:  data.PayloadSize,
+		header:     cmpct.Header,
+		merkleRoot: cmpct.MerkleRoot,
+		txs:        txs,
+		missing:    missing,
+	}
+	if len(missing) == 0 {
+		finishCompactBlock(p2p, remotePeer, pid, partial)
+		return
+	}
+	log.Debugf("[p2p]compact block %s missing %d txs", blkHash.ToHexString(), len(missing))
+	cmpctBlocks.Add(blkHash, partial)
+	msg := msgpack.NewBlkTxnReq(blkHash, missing)
+	err = p2p.Send(remotePeer, msg, false)
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+}
+
+// BlkTxnReqHandle handles the missing txs req of compact block from peer
+func BlkTxnReqHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
+	log.Trace("[p2p]receive block txn req message", data.Addr, data.Id)
+
+	var req = data.Payload.(*msgTypes.BlkTxnReq)
+
+	remotePeer := p2p.GetPeer(data.Id)
+	if remotePeer == nil {
+		log.Debug("[p2p]remotePeer invalid in BlkTxnReqHandle")
+		return
+	}
+	var msg msgTypes.Message
+	block, err := ledger.DefLedger.GetBlockByHash(req.BlockHash)
+	if err == nil && block != nil {
+		txs := make([]*types.Transaction, 0, len(req.Indexes))
+		for _, index := range req.Indexes {
+			if int(index) >= len(block.Transactions) {
+				break
+			}
+			txs = append(txs, block.Transactions[index])
+		}
+		if len(txs) == len(req.Indexes) {
+			msg = msgpack.NewBlkTxn(req.BlockHash, txs)
+		}
+	}
+	if msg == nil {
+		log.Debug("[p2p]can't get block txn by hash: ", req.BlockHash,
+			" ,send not found message")
+		msg = msgpack.NewNotFound(req.BlockHash)
+	}
+	err = p2p.Send(remotePeer, msg, false)
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+}
+
+// BlkTxnHandle handles the missing txs of compact block from peer
+func BlkTxnHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
+	log.Trace("[p2p]receive block txn message", data.Addr, data.Id)
+
+	var blkTxn = data.Payload.(*msgTypes.BlkTxn)
+
+	remotePeer := p2p.GetPeer(data.Id)
+	if remotePeer == nil {
+		log.Debug("[p2p]remotePeer invalid in BlkTxnHandle")
+		return
+	}
+	value, ok := cmpctBlocks.Get(blkTxn.BlockHash)
+	if !ok {
+		return
+	}
+	partial := value.(*partialBlock)
+	if partial.peerId != data.Id {
+		return
+	}
+	cmpctBlocks.Remove(blkTxn.BlockHash)
+	if len(blkTxn.Txs) != len(partial.missing) {
+		requestFullBlock(p2p, remotePeer, blkTxn.BlockHash)
+		return
+	}
+	for i, index := range partial.missing {
+		partial.txs[index] = blkTxn.Txs[i]
+	}
+	partial.blockSize += data.PayloadSize
+	finishCompactBlock(p2p, remotePeer, pid, partial)
+}
+
 // InvHandle handles the inventory message(block,
 // transaction and consensus) from peer.
 func InvHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args ...interface{}) {
@@ -616,9 +877,14 @@ func InvHandle(data *msgTypes.MsgPayload, p2p p2p.P2P, pid *evtActor.PID, args .
 			}
 			if !isContainBlock && msgTypes.LastInvHash != id {
 				msgTypes.LastInvHash = id
-				// send the block request
+				// send the block request, use compact block if peer supports
 				log.Infof("[p2p]inv request block hash: %x", id)
-				msg := msgpack.NewBlkDataReq(id)
+				var msg msgTypes.Message
+				if remotePeer.GetVersion() >= msgCommon.COMPACT_BLOCK_VERSION {
+					msg = msgpack.NewCmpctBlkDataReq(id)
+				} else {
+					msg = msgpack.NewBlkDataReq(id)
+				}
 				err = p2p.Send(remotePeer, msg, false)
 				if err != nil {
 					log.Warn(err)
@@ -566,3 +566,24 @@ func TestDisconnectHandle(t *testing.T) {
 
 	network.DelNbrNode(testID)
 }
+
+func TestCheckPeerKey(t *testing.T) {
+	keyData, _ := hex.DecodeString(config.DefConfig.Genesis.VBFT.Peers[0].PeerPubkey)
+	peerKey, err := keypair.DeserializePublicKey(keyData)
+	assert.Nil(t, err)
+	_, otherKey, _ := keypair.GenerateKeyPair(keypair.PK_ECDSA, keypair.P256)
+
+	version := &types.VersionPayload{
+		Services: msgCommon.VERIFY_NODE,
+		Nonce:    msgCommon.PubKeyToPeerID(peerKey),
+	}
+	assert.NotNil(t, checkPeerKey(nil, version))
+	assert.Nil(t, checkPeerKey(peerKey, version))
+	assert.NotNil(t, checkPeerKey(otherKey, version))
+
+	//a consensus peer should be in governance peer pool
+	version.Nonce = msgCommon.PubKeyToPeerID(otherKey)
+	assert.NotNil(t, checkPeerKey(otherKey, version))
+	version.Services = msgCommon.SERVICE_NODE
+	assert.Nil(t, checkPeerKey(otherKey, version))
+}
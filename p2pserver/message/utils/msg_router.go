@@ -73,6 +73,13 @@ func (this *MessageRouter) init(p2p p2p.P2P) {
 	this.RegisterMsgHandler(msgCommon.NOT_FOUND_TYPE, NotFoundHandle)
 	this.RegisterMsgHandler(msgCommon.TX_TYPE, TransactionHandle)
 	this.RegisterMsgHandler(msgCommon.DISCONNECT_TYPE, DisconnectHandle)
+	this.RegisterMsgHandler(msgCommon.GET_TX_PROOF_TYPE, TxProofReqHandle)
+	this.RegisterMsgHandler(msgCommon.TX_PROOF_TYPE, TxProofHandle)
+	this.RegisterMsgHandler(msgCommon.GET_ST_PROOF_TYPE, StorageProofReqHandle)
+	this.RegisterMsgHandler(msgCommon.ST_PROOF_TYPE, StorageProofHandle)
+	this.RegisterMsgHandler(msgCommon.CMPCT_BLOCK_TYPE, CompactBlockHandle)
+	this.RegisterMsgHandler(msgCommon.GET_BLK_TXN_TYPE, BlkTxnReqHandle)
+	this.RegisterMsgHandler(msgCommon.BLK_TXN_TYPE, BlkTxnHandle)
 }
 
 // RegisterMsgHandler registers msg handler with the msg type
@@ -0,0 +1,92 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/OnyxPay/OnyxChain-crypto/keypair"
+	"github.com/OnyxPay/OnyxChain/common/config"
+	"github.com/OnyxPay/OnyxChain/core/ledger"
+	msgCommon "github.com/OnyxPay/OnyxChain/p2pserver/common"
+	msgTypes "github.com/OnyxPay/OnyxChain/p2pserver/message/types"
+	gov "github.com/OnyxPay/OnyxChain/smartcontract/service/native/governance"
+	nutils "github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+)
+
+//checkPeerKey check the peer id is derived from the public key authenticated by secure handshake, and the
+//consensus peer of vbft is in the governance peer pool
+func checkPeerKey(pubKey keypair.PublicKey, version *msgTypes.VersionPayload) error {
+	if pubKey == nil {
+		return errors.New("peer is not authenticated")
+	}
+	if msgCommon.PubKeyToPeerID(pubKey) != version.Nonce {
+		return fmt.Errorf("peer id %d mismatch with public key", version.Nonce)
+	}
+	consensusType := strings.ToLower(config.DefConfig.Genesis.ConsensusType)
+	if version.Services != msgCommon.VERIFY_NODE || consensusType != config.CONSENSUS_TYPE_VBFT {
+		return nil
+	}
+	peers, err := getGovernancePeers()
+	if err != nil {
+		return fmt.Errorf("get governance peers error: %s", err)
+	}
+	if !peers[hex.EncodeToString(keypair.SerializePublicKey(pubKey))] {
+		return errors.New("consensus peer is not in governance peer pool")
+	}
+	return nil
+}
+
+//getGovernancePeers return the public keys of candidate and consensus peers in the peer pool of current view
+func getGovernancePeers() (map[string]bool, error) {
+	data, err := ledger.DefLedger.GetStorageItem(nutils.GovernanceContractAddress, []byte(gov.GOVERNANCE_VIEW))
+	if err != nil {
+		return nil, err
+	}
+	view := &gov.GovernanceView{}
+	if err := view.Deserialize(bytes.NewBuffer(data)); err != nil {
+		return nil, err
+	}
+	viewBytes, err := gov.GetUint32Bytes(view.View)
+	if err != nil {
+		return nil, err
+	}
+	data, err = ledger.DefLedger.GetStorageItem(nutils.GovernanceContractAddress,
+		append([]byte(gov.PEER_POOL), viewBytes...))
+	if err != nil {
+		return nil, err
+	}
+	peerMap := &gov.PeerPoolMap{
+		PeerPoolMap: make(map[string]*gov.PeerPoolItem),
+	}
+	if err := peerMap.Deserialize(bytes.NewBuffer(data)); err != nil {
+		return nil, err
+	}
+	peers := make(map[string]bool)
+	for _, item := range peerMap.PeerPoolMap {
+		if item.Status == gov.CandidateStatus || item.Status == gov.ConsensusStatus {
+			peers[item.PeerPubkey] = true
+		}
+	}
+	return peers, nil
+}
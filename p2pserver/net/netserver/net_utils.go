@@ -30,6 +30,7 @@ import (
 	"github.com/OnyxPay/OnyxChain/common/config"
 	"github.com/OnyxPay/OnyxChain/common/log"
 	"github.com/OnyxPay/OnyxChain/p2pserver/common"
+	"github.com/OnyxPay/OnyxChain/p2pserver/link"
 )
 
 // createListener creates a net listener on the port
@@ -151,3 +152,22 @@ func initTlsListen(port uint16) (net.Listener, error) {
 	}
 	return listener, nil
 }
+
+//secureHandshake run the secure handshake on conn if secure transport is enabled, conn is closed if handshake failed
+func (this *NetServer) secureHandshake(conn net.Conn, initiator bool) (net.Conn, error) {
+	if !config.DefConfig.P2PNode.SecureTransport {
+		return conn, nil
+	}
+	if this.key == nil {
+		conn.Close()
+		return nil, errors.New("[p2p]node key is not set")
+	}
+	conn.SetDeadline(time.Now().Add(time.Second * common.HANDSHAKE_TIMEOUT))
+	sconn, err := link.SecureHandshake(conn, this.key, initiator)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return sconn, nil
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/OnyxPay/OnyxChain/common/config"
 	"github.com/OnyxPay/OnyxChain/common/log"
 	"github.com/OnyxPay/OnyxChain/core/ledger"
+	"github.com/OnyxPay/OnyxChain/core/signature"
 	"github.com/OnyxPay/OnyxChain/p2pserver/common"
 	"github.com/OnyxPay/OnyxChain/p2pserver/message/msg_pack"
 	"github.com/OnyxPay/OnyxChain/p2pserver/message/types"
@@ -64,6 +65,7 @@ type NetServer struct {
 	inConnRecord  InConnectionRecord
 	outConnRecord OutConnectionRecord
 	OwnAddress    string //network`s own address(ip : sync port),which get from version check
+	key           signature.Signer
 }
 
 //InConnectionRecord include all addr connected
@@ -97,6 +99,8 @@ func (this *NetServer) init() error {
 
 	if config.DefConfig.Consensus.EnableConsensus {
 		this.base.SetServices(uint64(common.VERIFY_NODE))
+	} else if config.DefConfig.Common.LightNode {
+		this.base.SetServices(uint64(common.LIGHT_NODE))
 	} else {
 		this.base.SetServices(uint64(common.SERVICE_NODE))
 	}
@@ -133,6 +137,13 @@ func (this *NetServer) init() error {
 	return nil
 }
 
+//SetNodeKey set the node key used by secure handshake, and derive the peer id from its public key
+func (this *NetServer) SetNodeKey(key signature.Signer) {
+	this.key = key
+	this.base.SetID(common.PubKeyToPeerID(key.PubKey()))
+	log.Infof("[p2p]init peer ID to %d", this.base.GetID())
+}
+
 //InitListen start listening on the config port
 func (this *NetServer) Start() {
 	this.startListening()
@@ -314,6 +325,12 @@ func (this *NetServer) Connect(addr string, isConsensus bool) error {
 			return err
 		}
 	}
+	conn, err = this.secureHandshake(conn, true)
+	if err != nil {
+		this.RemoveFromConnectingList(addr)
+		log.Debugf("[p2p]secure handshake with %s failed:%s", addr, err.Error())
+		return err
+	}
 
 	addr = conn.RemoteAddr().String()
 	log.Debugf("[p2p]peer %s connect with %s with %s",
@@ -474,16 +491,24 @@ func (this *NetServer) startSyncAccept(listener net.Listener) {
 			continue
 		}
 
-		remotePeer := peer.NewPeer()
 		addr := conn.RemoteAddr().String()
 		this.AddInConnRecord(addr)
 
-		this.AddPeerSyncAddress(addr, remotePeer)
+		go func(conn net.Conn) {
+			conn, err := this.secureHandshake(conn, false)
+			if err != nil {
+				log.Debugf("[p2p]secure handshake with %s failed:%s", addr, err.Error())
+				this.RemoveFromInConnRecord(addr)
+				return
+			}
+			remotePeer := peer.NewPeer()
+			this.AddPeerSyncAddress(addr, remotePeer)
 
-		remotePeer.SyncLink.SetAddr(addr)
-		remotePeer.SyncLink.SetConn(conn)
-		remotePeer.AttachSyncChan(this.SyncChan)
-		go remotePeer.SyncLink.Rx()
+			remotePeer.SyncLink.SetAddr(addr)
+			remotePeer.SyncLink.SetConn(conn)
+			remotePeer.AttachSyncChan(this.SyncChan)
+			remotePeer.SyncLink.Rx()
+		}(conn)
 	}
 }
 
@@ -514,14 +539,21 @@ func (this *NetServer) startConsAccept(listener net.Listener) {
 			continue
 		}
 
-		remotePeer := peer.NewPeer()
 		addr := conn.RemoteAddr().String()
-		this.AddPeerConsAddress(addr, remotePeer)
-
-		remotePeer.ConsLink.SetAddr(addr)
-		remotePeer.ConsLink.SetConn(conn)
-		remotePeer.AttachConsChan(this.ConsChan)
-		go remotePeer.ConsLink.Rx()
+		go func(conn net.Conn) {
+			conn, err := this.secureHandshake(conn, false)
+			if err != nil {
+				log.Debugf("[p2p]secure handshake with %s failed:%s", addr, err.Error())
+				return
+			}
+			remotePeer := peer.NewPeer()
+			this.AddPeerConsAddress(addr, remotePeer)
+
+			remotePeer.ConsLink.SetAddr(addr)
+			remotePeer.ConsLink.SetConn(conn)
+			remotePeer.AttachConsChan(this.ConsChan)
+			remotePeer.ConsLink.Rx()
+		}(conn)
 	}
 }
 
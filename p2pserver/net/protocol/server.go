@@ -20,6 +20,7 @@
 package p2p
 
 import (
+	"github.com/OnyxPay/OnyxChain/core/signature"
 	"github.com/OnyxPay/OnyxChain/p2pserver/common"
 	"github.com/OnyxPay/OnyxChain/p2pserver/message/types"
 	"github.com/OnyxPay/OnyxChain/p2pserver/peer"
@@ -66,4 +67,5 @@ type P2P interface {
 	SetOwnAddress(addr string)
 	IsOwnAddress(addr string) bool
 	IsAddrFromConnecting(addr string) bool
+	SetNodeKey(key signature.Signer)
 }
@@ -21,6 +21,7 @@ package p2pserver
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"math/rand"
 	"net"
@@ -32,12 +33,14 @@ import (
 	"time"
 
 	evtActor "github.com/OnyxPay/OnyxChain-eventbus/actor"
+	"github.com/OnyxPay/OnyxChain/account"
 	comm "github.com/OnyxPay/OnyxChain/common"
 	"github.com/OnyxPay/OnyxChain/common/config"
 	"github.com/OnyxPay/OnyxChain/common/log"
 	"github.com/OnyxPay/OnyxChain/core/ledger"
 	"github.com/OnyxPay/OnyxChain/core/types"
 	"github.com/OnyxPay/OnyxChain/p2pserver/common"
+	"github.com/OnyxPay/OnyxChain/p2pserver/dht"
 	"github.com/OnyxPay/OnyxChain/p2pserver/message/msg_pack"
 	msgtypes "github.com/OnyxPay/OnyxChain/p2pserver/message/types"
 	"github.com/OnyxPay/OnyxChain/p2pserver/message/utils"
@@ -52,6 +55,9 @@ type P2PServer struct {
 	msgRouter *utils.MessageRouter
 	pid       *evtActor.PID
 	blockSync *BlockSyncMgr
+	lightNode *LightNodeMgr
+	dht       *dht.DHT
+	nodeKey   *account.Account
 	ledger    *ledger.Ledger
 	ReconnectAddrs
 	recentPeers    map[uint32][]string
@@ -77,6 +83,9 @@ func NewServer() *P2PServer {
 
 	p.msgRouter = utils.NewMsgRouter(p.network)
 	p.blockSync = NewBlockSyncMgr(p)
+	if config.DefConfig.Common.LightNode {
+		p.lightNode = NewLightNodeMgr(p)
+	}
 	p.recentPeers = make(map[uint32][]string)
 	p.quitSyncRecent = make(chan bool)
 	p.quitOnline = make(chan bool)
@@ -92,6 +101,12 @@ func (this *P2PServer) GetConnectionCnt() uint32 {
 //Start create all services
 func (this *P2PServer) Start() error {
 	if this.network != nil {
+		if config.DefConfig.P2PNode.SecureTransport {
+			if this.nodeKey == nil {
+				return errors.New("[p2p]secure transport requires the wallet account as node key")
+			}
+			this.network.SetNodeKey(this.nodeKey)
+		}
 		this.network.Start()
 	} else {
 		return errors.New("[p2p]network invalid")
@@ -102,7 +117,15 @@ func (this *P2PServer) Start() error {
 		return errors.New("[p2p]msg router invalid")
 	}
 	this.tryRecentPeers()
-	go this.connectSeedService()
+	if config.DefConfig.P2PNode.EnableDHT {
+		err := this.startDHT()
+		if err != nil {
+			return err
+		}
+		go this.dhtService()
+	} else {
+		go this.connectSeedService()
+	}
 	go this.syncUpRecentPeers()
 	go this.keepOnlineService()
 	go this.heartBeatService()
@@ -116,6 +139,9 @@ func (this *P2PServer) Stop() {
 	this.quitSyncRecent <- true
 	this.quitOnline <- true
 	this.quitHeartBeat <- true
+	if this.dht != nil {
+		this.dht.Stop()
+	}
 	this.msgRouter.Stop()
 	this.blockSync.Close()
 }
@@ -149,6 +175,7 @@ func (this *P2PServer) Xmit(message interface{}) error {
 	case *types.Transaction:
 		log.Debug("[p2p]TX transaction message")
 		txn := message.(*types.Transaction)
+		utils.AddKnownTx(txn.Hash())
 		msg = msgpack.NewTxn(txn)
 	case *msgtypes.ConsensusPayload:
 		log.Debug("[p2p]TX consensus message")
@@ -207,6 +234,43 @@ func (this *P2PServer) OnBlockReceive(fromID uint64, blockSize uint32,
 	this.blockSync.OnBlockReceive(fromID, blockSize, block, merkleRoot)
 }
 
+// OnTxProofReceive passes the transaction proof from network to the light node mgr
+func (this *P2PServer) OnTxProofReceive(fromID uint64, proof *types.TxProof) {
+	if this.lightNode != nil {
+		this.lightNode.OnTxProofReceive(fromID, proof)
+	}
+}
+
+// OnStorageProofReceive passes the storage proof from network to the light node mgr
+func (this *P2PServer) OnStorageProofReceive(fromID uint64, proof *types.StorageProof) {
+	if this.lightNode != nil {
+		this.lightNode.OnStorageProofReceive(fromID, proof)
+	}
+}
+
+// OnNotFound passes the not found hash from network to the light node mgr
+func (this *P2PServer) OnNotFound(fromID uint64, hash comm.Uint256) {
+	if this.lightNode != nil {
+		this.lightNode.OnNotFound(fromID, hash)
+	}
+}
+
+// GetTransactionProof returns the verified transaction proof from full nodes, only available in light node
+func (this *P2PServer) GetTransactionProof(txHash comm.Uint256) (*types.TxProof, error) {
+	if this.lightNode == nil {
+		return nil, errors.New("[p2p]not a light node")
+	}
+	return this.lightNode.GetTransactionProof(txHash)
+}
+
+// GetStorageProof returns the verified storage proof from full nodes, only available in light node
+func (this *P2PServer) GetStorageProof(contract comm.Address, key []byte) (*types.StorageProof, error) {
+	if this.lightNode == nil {
+		return nil, errors.New("[p2p]not a light node")
+	}
+	return this.lightNode.GetStorageProof(contract, key)
+}
+
 // Todo: remove it if no use
 func (this *P2PServer) GetConnectionState() uint32 {
 	return common.INIT
@@ -223,6 +287,11 @@ func (this *P2PServer) SetPID(pid *evtActor.PID) {
 	this.msgRouter.SetPID(pid)
 }
 
+// SetNodeKey sets the key to identify the node in dht and secure transport, a random key is used by dht if acc is nil
+func (this *P2PServer) SetNodeKey(acc *account.Account) {
+	this.nodeKey = acc
+}
+
 // GetPID returns p2p actor
 func (this *P2PServer) GetPID() *evtActor.PID {
 	return this.pid
@@ -361,6 +430,8 @@ func (this *P2PServer) reachMinConnection() bool {
 		minCount = config.SOLO_MIN_NODE_NUM
 	case "vbft":
 		minCount = config.VBFT_MIN_NODE_NUM
+	case "sbft":
+		minCount = config.SBFT_MIN_NODE_NUM
 
 	}
 	return int(this.GetConnectionCnt())+1 >= minCount
@@ -466,6 +537,79 @@ func (this *P2PServer) connectSeedService() {
 	}
 }
 
+//startDHT start the dht on the udp port of sync port, seed nodes are used as boot nodes
+func (this *P2PServer) startDHT() error {
+	if this.nodeKey == nil {
+		this.nodeKey = account.NewAccount("")
+	}
+	d, err := dht.NewDHT(&dht.Config{
+		Key:        this.nodeKey,
+		ListenAddr: ":" + strconv.Itoa(int(this.network.GetSyncPort())),
+		TCPPort:    this.network.GetSyncPort(),
+		BootNodes:  config.DefConfig.Genesis.SeedList,
+		BookPath:   common.DHT_BOOK_FILE_NAME,
+	})
+	if err != nil {
+		return fmt.Errorf("[p2p]start dht error: %s", err)
+	}
+	this.dht = d
+	this.dht.Start()
+	return nil
+}
+
+//dhtService connect the nodes discovered by dht
+func (this *P2PServer) dhtService() {
+	t := time.NewTimer(time.Second * common.CONN_MONITOR)
+	for {
+		select {
+		case <-t.C:
+			this.connectDHTNodes()
+			t.Stop()
+			t.Reset(time.Second * common.CONN_MONITOR)
+		case <-this.quitOnline:
+			t.Stop()
+			return
+		}
+	}
+}
+
+//connectDHTNodes connect the best nodes of dht address book which are not connected
+func (this *P2PServer) connectDHTNodes() {
+	connCount := uint(this.network.GetOutConnRecordLen())
+	if connCount >= config.DefConfig.P2PNode.MaxConnOutBound {
+		return
+	}
+	connPeers := make(map[string]bool)
+	np := this.network.GetNp()
+	np.Lock()
+	for _, tn := range np.List {
+		ipAddr, _ := tn.GetAddr16()
+		ip := net.IP(ipAddr[:])
+		connPeers[ip.To16().String()+":"+strconv.Itoa(int(tn.GetSyncPort()))] = true
+	}
+	np.Unlock()
+
+	count := int(config.DefConfig.P2PNode.MaxConnOutBound - connCount)
+	if count > common.DHT_CONNECT_NUM {
+		count = common.DHT_CONNECT_NUM
+	}
+	nodes := this.dht.Candidates(count, func(n *dht.Node) bool {
+		addr := n.TCPAddr()
+		return connPeers[addr] || this.network.IsOwnAddress(addr) || this.network.IsAddrFromConnecting(addr)
+	})
+	for _, n := range nodes {
+		go func(n *dht.Node) {
+			err := this.network.Connect(n.TCPAddr(), false)
+			if err != nil {
+				log.Debugf("[p2p]connect dht node %s error: %s", n.String(), err)
+				this.dht.MarkConnectFailed(n.ID)
+				return
+			}
+			this.dht.MarkConnected(n.ID)
+		}(n)
+	}
+}
+
 //keepOnline try connect lost peer
 func (this *P2PServer) keepOnlineService() {
 	t := time.NewTimer(time.Second * common.CONN_MONITOR)
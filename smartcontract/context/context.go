@@ -21,6 +21,7 @@ package context
 import (
 	"github.com/OnyxPay/OnyxChain/common"
 	"github.com/OnyxPay/OnyxChain/smartcontract/event"
+	"github.com/OnyxPay/OnyxChain/smartcontract/states"
 )
 
 // ContextRef is a interface of smart context
@@ -38,6 +39,7 @@ type ContextRef interface {
 	CheckWitness(address common.Address) bool
 	PushNotifications(notifications []*event.NotifyEventInfo)
 	NewExecuteEngine(code []byte) (Engine, error)
+	NewWasmExecuteEngine(code []byte, param states.ContractInvokeParam) (Engine, error)
 	CheckUseGas(gas uint64) bool
 	CheckExecStep() bool
 }
@@ -202,40 +202,45 @@ func AssignFuncsToRole(native *native.NativeService) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("[assignFuncsToRole] putRoleFunc failed: %v", err)
 	}
+	err = refreshRole(native, param.ContractAddr, param.Role)
+	if err != nil {
+		return nil, fmt.Errorf("[assignFuncsToRole] refreshRole failed: %v", err)
+	}
 
 	pushEvent(native, sucState)
 	return utils.BYTE_TRUE, nil
 }
 
-func assignToRole(native *native.NativeService, param *OnxIDsToRoleParam) (bool, error) {
-	//check admin's permission
-	admin, err := getContractAdmin(native, param.ContractAddr)
+func verifyAdmin(native *native.NativeService, contractAddr common.Address, adminOnxID []byte, keyNo uint64) (bool, error) {
+	admin, err := getContractAdmin(native, contractAddr)
 	if err != nil {
 		return false, fmt.Errorf("getContractAdmin failed: %v", err)
 	}
 	if admin == nil {
-		return false, fmt.Errorf("admin of contract %s is not set", param.ContractAddr.ToHexString())
+		return false, fmt.Errorf("admin of contract %s is not set", contractAddr.ToHexString())
 	}
-	if bytes.Compare(admin, param.AdminOnxID) != 0 {
-		log.Debugf("param's adminOnxID doesn't match: %s != %s", string(param.AdminOnxID),
+	if bytes.Compare(admin, adminOnxID) != 0 {
+		log.Debugf("param's adminOnxID doesn't match: %s != %s", string(adminOnxID),
 			string(admin))
 		return false, nil
 	}
-	valid, err := verifySig(native, param.AdminOnxID, param.KeyNo)
+	valid, err := verifySig(native, adminOnxID, keyNo)
 	if err != nil {
 		return false, fmt.Errorf("verify admin's signature failed: %v", err)
 	}
 	if !valid {
-		log.Debugf("[assignOnxIDsToRole] verifySig return false: adminOnxID=%s, keyNo=%d",
-			string(admin), param.KeyNo)
+		log.Debugf("verifySig return false: adminOnxID=%s, keyNo=%d", string(admin), keyNo)
 		return false, nil
 	}
+	return true, nil
+}
 
-	//init a permanent auth token
-	token := new(AuthToken)
-	token.expireTime = uint32(future.Unix())
-	token.level = 2
-	token.role = param.Role
+func assignToRole(native *native.NativeService, param *OnxIDsToRoleParam, expireTime uint32) (bool, error) {
+	//check admin's permission
+	valid, err := verifyAdmin(native, param.ContractAddr, param.AdminOnxID, param.KeyNo)
+	if err != nil || !valid {
+		return false, err
+	}
 
 	for _, p := range param.Persons {
 		if p == nil {
@@ -247,24 +252,34 @@ func assignToRole(native *native.NativeService, param *OnxIDsToRoleParam) (bool,
 		}
 		if tokens == nil {
 			tokens = new(roleTokens)
-			tokens.tokens = make([]*AuthToken, 1)
-			tokens.tokens[0] = token
-		} else {
-			ret, err := hasRole(native, param.ContractAddr, p, param.Role)
-			if err != nil {
-				return false, fmt.Errorf("check if %s has role %s failed: %v", string(p),
-					string(param.Role), err)
-			}
-			if !ret {
-				tokens.tokens = append(tokens.tokens, token)
-			} else {
-				continue
-			}
 		}
+		//a new grant of the role overrides the expire time of the old one
+		token := findToken(tokens, param.Role)
+		if token == nil {
+			token = new(AuthToken)
+			token.level = 2
+			token.role = param.Role
+			tokens.tokens = append(tokens.tokens, token)
+		} else if token.expireTime == expireTime {
+			continue
+		}
+		token.expireTime = expireTime
 		err = putOnxIDToken(native, param.ContractAddr, p, tokens)
 		if err != nil {
 			return false, err
 		}
+		err = refreshRoleMember(native, param.ContractAddr, param.Role, p)
+		if err != nil {
+			return false, err
+		}
+		err = revokeDelegatedFrom(native, param.ContractAddr, param.Role, p)
+		if err != nil {
+			return false, err
+		}
+	}
+	err = refreshRole(native, param.ContractAddr, param.Role)
+	if err != nil {
+		return false, err
 	}
 	return true, nil
 }
@@ -287,7 +302,8 @@ func AssignOnxIDsToRole(native *native.NativeService) ([]byte, error) {
 		}
 	}
 
-	ret, err := assignToRole(native, param)
+	//init a permanent auth token
+	ret, err := assignToRole(native, param, uint32(future.Unix()))
 	if err != nil {
 		return nil, fmt.Errorf("[assignOnxIDsToRole] failed: %v", err)
 	}
@@ -304,6 +320,313 @@ func AssignOnxIDsToRole(native *native.NativeService) ([]byte, error) {
 	}
 }
 
+func AssignOnxIDsToRoleWithExpiry(native *native.NativeService) ([]byte, error) {
+	//deserialize param
+	param := new(OnxIDsToRoleWithExpiryParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[assignOnxIDsToRoleWithExpiry] deserialize param failed: %v", err)
+	}
+
+	if param.Role == nil {
+		return nil, fmt.Errorf("[assignOnxIDsToRoleWithExpiry] invalid param: role is nil")
+	}
+	if param.ExpireTime <= uint64(native.Time) {
+		return nil, fmt.Errorf("[assignOnxIDsToRoleWithExpiry] invalid param: expireTime %d is not later than %d",
+			param.ExpireTime, native.Time)
+	}
+	for i, onxID := range param.Persons {
+		if !account.VerifyID(string(onxID)) {
+			return nil, fmt.Errorf("[assignOnxIDsToRoleWithExpiry] invalid param: param.Persons[%d]=%s",
+				i, string(onxID))
+		}
+	}
+
+	ret, err := assignToRole(native, &param.OnxIDsToRoleParam, uint32(param.ExpireTime))
+	if err != nil {
+		return nil, fmt.Errorf("[assignOnxIDsToRoleWithExpiry] failed: %v", err)
+	}
+
+	contract := param.ContractAddr.ToHexString()
+	failState := []interface{}{"assignOnxIDsToRoleWithExpiry", contract, param.ExpireTime, false}
+	sucState := []interface{}{"assignOnxIDsToRoleWithExpiry", contract, param.ExpireTime, true}
+	if ret {
+		pushEvent(native, sucState)
+		return utils.BYTE_TRUE, nil
+	}
+	pushEvent(native, failState)
+	return utils.BYTE_FALSE, nil
+}
+
+/*
+ * remove both the direct auth token and the delegated one of role from persons,
+ * and the tokens delegated by persons to others
+ */
+func revokeFromRole(native *native.NativeService, param *OnxIDsToRoleParam) (bool, error) {
+	//check admin's permission
+	valid, err := verifyAdmin(native, param.ContractAddr, param.AdminOnxID, param.KeyNo)
+	if err != nil || !valid {
+		return false, err
+	}
+
+	for _, p := range param.Persons {
+		if p == nil {
+			continue
+		}
+		tokens, err := getOnxIDToken(native, param.ContractAddr, p)
+		if err != nil {
+			return false, fmt.Errorf("getOnxIDToken failed: %v", err)
+		}
+		if tokens != nil {
+			for i, token := range tokens.tokens {
+				if bytes.Compare(token.role, param.Role) == 0 {
+					tokens.tokens = append(tokens.tokens[:i], tokens.tokens[i+1:]...)
+					err = putOnxIDToken(native, param.ContractAddr, p, tokens)
+					if err != nil {
+						return false, err
+					}
+					break
+				}
+			}
+		}
+		status, err := getDelegateStatus(native, param.ContractAddr, p)
+		if err != nil {
+			return false, fmt.Errorf("getDelegateStatus failed: %v", err)
+		}
+		if status != nil {
+			for i, s := range status.status {
+				if bytes.Compare(s.role, param.Role) == 0 {
+					status.status = append(status.status[:i], status.status[i+1:]...)
+					err = putDelegateStatus(native, param.ContractAddr, p, status)
+					if err != nil {
+						return false, err
+					}
+					break
+				}
+			}
+		}
+		err = refreshRoleMember(native, param.ContractAddr, param.Role, p)
+		if err != nil {
+			return false, err
+		}
+		err = revokeDelegatedFrom(native, param.ContractAddr, param.Role, p)
+		if err != nil {
+			return false, err
+		}
+	}
+	err = refreshRole(native, param.ContractAddr, param.Role)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+//revokeDelegatedFrom remove the tokens of role delegated by root from the members of role,
+//a delegated token can not be delegated again so the members cover all of them
+func revokeDelegatedFrom(native *native.NativeService, contractAddr common.Address, role, root []byte) error {
+	members, err := getRoleMembers(native, contractAddr, role)
+	if err != nil {
+		return fmt.Errorf("getRoleMembers failed: %v", err)
+	}
+	for _, m := range members.items {
+		status, err := getDelegateStatus(native, contractAddr, m)
+		if err != nil {
+			return fmt.Errorf("getDelegateStatus failed: %v", err)
+		}
+		if status == nil {
+			continue
+		}
+		for i, s := range status.status {
+			if bytes.Compare(s.role, role) == 0 && bytes.Compare(s.root, root) == 0 {
+				status.status = append(status.status[:i], status.status[i+1:]...)
+				err = putDelegateStatus(native, contractAddr, m, status)
+				if err != nil {
+					return err
+				}
+				err = refreshRoleMember(native, contractAddr, role, m)
+				if err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func RevokeOnxIDsFromRole(native *native.NativeService) ([]byte, error) {
+	//deserialize param
+	param := new(OnxIDsToRoleParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[revokeOnxIDsFromRole] deserialize param failed: %v", err)
+	}
+
+	if param.Role == nil {
+		return nil, fmt.Errorf("[revokeOnxIDsFromRole] invalid param: role is nil")
+	}
+
+	ret, err := revokeFromRole(native, param)
+	if err != nil {
+		return nil, fmt.Errorf("[revokeOnxIDsFromRole] failed: %v", err)
+	}
+
+	contract := param.ContractAddr.ToHexString()
+	failState := []interface{}{"revokeOnxIDsFromRole", contract, false}
+	sucState := []interface{}{"revokeOnxIDsFromRole", contract, true}
+	if ret {
+		pushEvent(native, sucState)
+		return utils.BYTE_TRUE, nil
+	}
+	pushEvent(native, failState)
+	return utils.BYTE_FALSE, nil
+}
+
+func RemoveFuncsFromRole(native *native.NativeService) ([]byte, error) {
+	//deserialize input param
+	param := new(FuncsToRoleParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[removeFuncsFromRole] deserialize param failed: %v", err)
+	}
+
+	//prepare event msg
+	contract := param.ContractAddr.ToHexString()
+	failState := []interface{}{"removeFuncsFromRole", contract, false}
+	sucState := []interface{}{"removeFuncsFromRole", contract, true}
+
+	if param.Role == nil {
+		return nil, fmt.Errorf("[removeFuncsFromRole] invalid param: role is nil")
+	}
+
+	//check the caller's permission
+	valid, err := verifyAdmin(native, param.ContractAddr, param.AdminOnxID, param.KeyNo)
+	if err != nil {
+		return nil, fmt.Errorf("[removeFuncsFromRole] verifyAdmin failed: %v", err)
+	}
+	if !valid {
+		pushEvent(native, failState)
+		return utils.BYTE_FALSE, nil
+	}
+
+	funcs, err := getRoleFunc(native, param.ContractAddr, param.Role)
+	if err != nil {
+		return nil, fmt.Errorf("[removeFuncsFromRole] getRoleFunc failed: %v", err)
+	}
+	if funcs == nil {
+		log.Debugf("[removeFuncsFromRole] role %s has no function", string(param.Role))
+		pushEvent(native, failState)
+		return utils.BYTE_FALSE, nil
+	}
+	funcNames := make([]string, 0, len(funcs.funcNames))
+	for _, fn := range funcs.funcNames {
+		if !stringSliceContains(param.FuncNames, fn) {
+			funcNames = append(funcNames, fn)
+		}
+	}
+	funcs.funcNames = funcNames
+	err = putRoleFunc(native, param.ContractAddr, param.Role, funcs)
+	if err != nil {
+		return nil, fmt.Errorf("[removeFuncsFromRole] putRoleFunc failed: %v", err)
+	}
+	err = refreshRole(native, param.ContractAddr, param.Role)
+	if err != nil {
+		return nil, fmt.Errorf("[removeFuncsFromRole] refreshRole failed: %v", err)
+	}
+
+	pushEvent(native, sucState)
+	return utils.BYTE_TRUE, nil
+}
+
+func findToken(tokens *roleTokens, role []byte) *AuthToken {
+	for _, token := range tokens.tokens {
+		if bytes.Compare(token.role, role) == 0 {
+			return token
+		}
+	}
+	return nil
+}
+
+func findDelegateStatus(status *Status, role []byte) *DelegateStatus {
+	for _, s := range status.status {
+		if bytes.Compare(s.role, role) == 0 {
+			return s
+		}
+	}
+	return nil
+}
+
+//getRoleMember return the grant of role to onxID, expired ones included
+func getRoleMember(native *native.NativeService, contractAddr common.Address, role, onxID []byte) (*RoleMember, error) {
+	tokens, err := getOnxIDToken(native, contractAddr, onxID)
+	if err != nil {
+		return nil, fmt.Errorf("getOnxIDToken failed: %v", err)
+	}
+	if tokens != nil {
+		if token := findToken(tokens, role); token != nil {
+			return &RoleMember{OnxID: onxID, ExpireTime: token.expireTime, Level: token.level}, nil
+		}
+	}
+	status, err := getDelegateStatus(native, contractAddr, onxID)
+	if err != nil {
+		return nil, fmt.Errorf("getDelegateStatus failed: %v", err)
+	}
+	if status != nil {
+		if s := findDelegateStatus(status, role); s != nil {
+			return &RoleMember{OnxID: onxID, ExpireTime: s.expireTime, Level: s.level, Delegated: true}, nil
+		}
+	}
+	return nil, nil
+}
+
+//refreshRoleMember keeps onxID in the member index of role as long as it has a grant of the role
+func refreshRoleMember(native *native.NativeService, contractAddr common.Address, role, onxID []byte) error {
+	member, err := getRoleMember(native, contractAddr, role, onxID)
+	if err != nil {
+		return err
+	}
+	members, err := getRoleMembers(native, contractAddr, role)
+	if err != nil {
+		return fmt.Errorf("getRoleMembers failed: %v", err)
+	}
+	var changed bool
+	if member != nil {
+		changed = members.add(onxID)
+	} else {
+		changed = members.remove(onxID)
+	}
+	if !changed {
+		return nil
+	}
+	return putRoleMembers(native, contractAddr, role, members)
+}
+
+//refreshRole keeps role in the role index as long as it has functions or members
+func refreshRole(native *native.NativeService, contractAddr common.Address, role []byte) error {
+	funcs, err := getRoleFunc(native, contractAddr, role)
+	if err != nil {
+		return fmt.Errorf("getRoleFunc failed: %v", err)
+	}
+	members, err := getRoleMembers(native, contractAddr, role)
+	if err != nil {
+		return fmt.Errorf("getRoleMembers failed: %v", err)
+	}
+	roles, err := getRoles(native, contractAddr)
+	if err != nil {
+		return fmt.Errorf("getRoles failed: %v", err)
+	}
+	var changed bool
+	if (funcs != nil && len(funcs.funcNames) > 0) || len(members.items) > 0 {
+		changed = roles.add(role)
+	} else {
+		changed = roles.remove(role)
+	}
+	if !changed {
+		return nil
+	}
+	return putRoles(native, contractAddr, roles)
+}
+
 func getAuthToken(native *native.NativeService, contractAddr common.Address, onxID, role []byte) (*AuthToken, error) {
 	tokens, err := getOnxIDToken(native, contractAddr, onxID)
 	if err != nil {
@@ -311,7 +634,7 @@ func getAuthToken(native *native.NativeService, contractAddr common.Address, onx
 	}
 	if tokens != nil {
 		for _, token := range tokens.tokens {
-			if bytes.Compare(token.role, role) == 0 { //permanent token
+			if bytes.Compare(token.role, role) == 0 && native.Time < token.expireTime { //direct token
 				return token, nil
 			}
 		}
@@ -452,6 +775,10 @@ func delegate(native *native.NativeService, contractAddr common.Address, from []
 			if err != nil {
 				return false, fmt.Errorf("putDelegateStatus failed: %v", err)
 			}
+			err = refreshRoleMember(native, contractAddr, role, to)
+			if err != nil {
+				return false, fmt.Errorf("refreshRoleMember failed: %v", err)
+			}
 			return true, nil
 		}
 	}
@@ -530,6 +857,10 @@ func withdraw(native *native.NativeService, contractAddr common.Address, initiat
 			if err != nil {
 				return false, err
 			}
+			err = refreshRoleMember(native, contractAddr, role, delegate)
+			if err != nil {
+				return false, err
+			}
 			return true, nil
 		}
 	}
@@ -645,6 +976,82 @@ func VerifyToken(native *native.NativeService) ([]byte, error) {
 	return utils.BYTE_FALSE, nil
 }
 
+func GetRoles(native *native.NativeService) ([]byte, error) {
+	param := new(GetRolesParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[getRoles] deserialize param failed: %v", err)
+	}
+	roles, err := getRoles(native, param.ContractAddr)
+	if err != nil {
+		return nil, fmt.Errorf("[getRoles] getRoles failed: %v", err)
+	}
+	bf := new(bytes.Buffer)
+	if err := utils.WriteVarUint(bf, uint64(len(roles.items))); err != nil {
+		return nil, fmt.Errorf("[getRoles] serialize failed: %v", err)
+	}
+	for _, role := range roles.items {
+		if err := serialization.WriteVarBytes(bf, role); err != nil {
+			return nil, fmt.Errorf("[getRoles] serialize failed: %v", err)
+		}
+	}
+	return bf.Bytes(), nil
+}
+
+func GetRoleMembers(native *native.NativeService) ([]byte, error) {
+	param := new(RoleParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[getRoleMembers] deserialize param failed: %v", err)
+	}
+	members, err := getRoleMembers(native, param.ContractAddr, param.Role)
+	if err != nil {
+		return nil, fmt.Errorf("[getRoleMembers] getRoleMembers failed: %v", err)
+	}
+	bf := new(bytes.Buffer)
+	if err := utils.WriteVarUint(bf, uint64(len(members.items))); err != nil {
+		return nil, fmt.Errorf("[getRoleMembers] serialize failed: %v", err)
+	}
+	for _, onxID := range members.items {
+		member, err := getRoleMember(native, param.ContractAddr, param.Role, onxID)
+		if err != nil {
+			return nil, fmt.Errorf("[getRoleMembers] getRoleMember failed: %v", err)
+		}
+		if member == nil {
+			return nil, fmt.Errorf("[getRoleMembers] grant of %s not found", string(onxID))
+		}
+		if err := member.Serialize(bf); err != nil {
+			return nil, fmt.Errorf("[getRoleMembers] serialize failed: %v", err)
+		}
+	}
+	return bf.Bytes(), nil
+}
+
+func GetFuncsOfRole(native *native.NativeService) ([]byte, error) {
+	param := new(RoleParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[getFuncsOfRole] deserialize param failed: %v", err)
+	}
+	funcs, err := getRoleFunc(native, param.ContractAddr, param.Role)
+	if err != nil {
+		return nil, fmt.Errorf("[getFuncsOfRole] getRoleFunc failed: %v", err)
+	}
+	if funcs == nil {
+		funcs = new(roleFuncs)
+	}
+	bf := new(bytes.Buffer)
+	if err := utils.WriteVarUint(bf, uint64(len(funcs.funcNames))); err != nil {
+		return nil, fmt.Errorf("[getFuncsOfRole] serialize failed: %v", err)
+	}
+	for _, fn := range funcs.funcNames {
+		if err := serialization.WriteString(bf, fn); err != nil {
+			return nil, fmt.Errorf("[getFuncsOfRole] serialize failed: %v", err)
+		}
+	}
+	return bf.Bytes(), nil
+}
+
 func verifySig(native *native.NativeService, onxID []byte, keyNo uint64) (bool, error) {
 	bf := new(bytes.Buffer)
 	if err := serialization.WriteVarBytes(bf, onxID); err != nil {
@@ -677,4 +1084,10 @@ func RegisterAuthContract(native *native.NativeService) {
 	native.Register("assignOnxIDsToRole", AssignOnxIDsToRole)
 	native.Register("verifyToken", VerifyToken)
 	native.Register("transfer", Transfer)
+	native.Register("assignOnxIDsToRoleWithExpiry", AssignOnxIDsToRoleWithExpiry)
+	native.Register("revokeOnxIDsFromRole", RevokeOnxIDsFromRole)
+	native.Register("removeFuncsFromRole", RemoveFuncsFromRole)
+	native.Register("getRoles", GetRoles)
+	native.Register("getRoleMembers", GetRoleMembers)
+	native.Register("getFuncsOfRole", GetFuncsOfRole)
 }
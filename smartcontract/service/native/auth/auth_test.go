@@ -0,0 +1,269 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package auth
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"testing"
+
+	"github.com/OnyxPay/OnyxChain/account"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/serialization"
+	"github.com/OnyxPay/OnyxChain/core/store/overlaydb"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/onxid"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/testsuite"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type param interface {
+	Serialize(w io.Writer) error
+}
+
+func init() {
+	onxid.Init()
+	Init()
+}
+
+//invoke call the native contract from the caller contract in a transaction signed by the signer at the time,
+//the changes are committed only if the call succeeds
+func invoke(db *overlaydb.OverlayDB, caller, signer common.Address, time uint32, contract common.Address,
+	method string, p param) ([]byte, error) {
+	bf := new(bytes.Buffer)
+	if err := p.Serialize(bf); err != nil {
+		return nil, err
+	}
+	tx := &testsuite.Tx{Signer: signer, Caller: caller, Time: time}
+	return testsuite.Invoke(db, tx, contract, method, testsuite.RawParam(bf.Bytes()))
+}
+
+func verify(t *testing.T, db *overlaydb.OverlayDB, acc *account.Account, time uint32, caller []byte, fn string) bool {
+	p := &VerifyTokenParam{ContractAddr: OnxContractAddr, Caller: caller, Fn: fn, KeyNo: 1}
+	result, err := invoke(db, common.Address{}, acc.Address, time, utils.AuthContractAddress, "verifyToken", p)
+	assert.Nil(t, err)
+	return bytes.Equal(result, utils.BYTE_TRUE)
+}
+
+func queryRoles(t *testing.T, db *overlaydb.OverlayDB) []string {
+	result, err := invoke(db, common.Address{}, common.Address{}, 0, utils.AuthContractAddress, "getRoles",
+		&GetRolesParam{ContractAddr: OnxContractAddr})
+	assert.Nil(t, err)
+	rd := bytes.NewReader(result)
+	n, err := utils.ReadVarUint(rd)
+	assert.Nil(t, err)
+	roles := make([]string, 0)
+	for i := uint64(0); i < n; i++ {
+		r, err := serialization.ReadVarBytes(rd)
+		assert.Nil(t, err)
+		roles = append(roles, string(r))
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+func queryFuncs(t *testing.T, db *overlaydb.OverlayDB, role string) []string {
+	result, err := invoke(db, common.Address{}, common.Address{}, 0, utils.AuthContractAddress, "getFuncsOfRole",
+		&RoleParam{ContractAddr: OnxContractAddr, Role: []byte(role)})
+	assert.Nil(t, err)
+	rd := bytes.NewReader(result)
+	n, err := utils.ReadVarUint(rd)
+	assert.Nil(t, err)
+	funcs := make([]string, 0)
+	for i := uint64(0); i < n; i++ {
+		fn, err := serialization.ReadString(rd)
+		assert.Nil(t, err)
+		funcs = append(funcs, fn)
+	}
+	sort.Strings(funcs)
+	return funcs
+}
+
+func queryMembers(t *testing.T, db *overlaydb.OverlayDB, role string) []*RoleMember {
+	result, err := invoke(db, common.Address{}, common.Address{}, 0, utils.AuthContractAddress, "getRoleMembers",
+		&RoleParam{ContractAddr: OnxContractAddr, Role: []byte(role)})
+	assert.Nil(t, err)
+	rd := bytes.NewReader(result)
+	n, err := utils.ReadVarUint(rd)
+	assert.Nil(t, err)
+	members := make([]*RoleMember, 0)
+	for i := uint64(0); i < n; i++ {
+		m := new(RoleMember)
+		assert.Nil(t, m.Deserialize(rd))
+		members = append(members, m)
+	}
+	return members
+}
+
+func TestRoleManagement(t *testing.T) {
+	db := testsuite.NewDB()
+	adminAcc, acc1, acc2 := account.NewAccount(""), account.NewAccount(""), account.NewAccount("")
+	adminID := testsuite.RegisterID(t, db, adminAcc)
+	id1 := testsuite.RegisterID(t, db, acc1)
+	id2 := testsuite.RegisterID(t, db, acc2)
+
+	_, err := invoke(db, OnxContractAddr, adminAcc.Address, 0, utils.AuthContractAddress, "initContractAdmin",
+		&InitContractAdminParam{AdminOnxID: adminID})
+	assert.Nil(t, err)
+
+	assign := &FuncsToRoleParam{ContractAddr: OnxContractAddr, AdminOnxID: adminID, Role: []byte(role),
+		FuncNames: funcs, KeyNo: 1}
+	_, err = invoke(db, common.Address{}, adminAcc.Address, 100, utils.AuthContractAddress, "assignFuncsToRole", assign)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{role}, queryRoles(t, db))
+	assert.Equal(t, funcs, queryFuncs(t, db, role))
+
+	// id1 is granted permanently, id2 until 1000
+	grant := OnxIDsToRoleParam{ContractAddr: OnxContractAddr, AdminOnxID: adminID, Role: []byte(role),
+		Persons: [][]byte{id1}, KeyNo: 1}
+	_, err = invoke(db, common.Address{}, adminAcc.Address, 100, utils.AuthContractAddress, "assignOnxIDsToRole", &grant)
+	assert.Nil(t, err)
+	timed := &OnxIDsToRoleWithExpiryParam{OnxIDsToRoleParam: grant, ExpireTime: 100}
+	timed.Persons = [][]byte{id2}
+	_, err = invoke(db, common.Address{}, adminAcc.Address, 100, utils.AuthContractAddress,
+		"assignOnxIDsToRoleWithExpiry", timed)
+	assert.NotNil(t, err)
+	timed.ExpireTime = 1000
+	_, err = invoke(db, common.Address{}, acc1.Address, 100, utils.AuthContractAddress,
+		"assignOnxIDsToRoleWithExpiry", timed)
+	assert.NotNil(t, err)
+	_, err = invoke(db, common.Address{}, adminAcc.Address, 100, utils.AuthContractAddress,
+		"assignOnxIDsToRoleWithExpiry", timed)
+	assert.Nil(t, err)
+
+	members := queryMembers(t, db, role)
+	assert.Equal(t, 2, len(members))
+	assert.Equal(t, id1, members[0].OnxID)
+	assert.Equal(t, uint32(future.Unix()), members[0].ExpireTime)
+	assert.Equal(t, id2, members[1].OnxID)
+	assert.Equal(t, uint32(1000), members[1].ExpireTime)
+	assert.False(t, members[1].Delegated)
+
+	assert.True(t, verify(t, db, acc2, 999, id2, "foo1"))
+	assert.False(t, verify(t, db, acc2, 1001, id2, "foo1"))
+	assert.True(t, verify(t, db, acc1, 1001, id1, "foo1"))
+
+	// an expired grant can not be delegated
+	delegate := &DelegateParam{ContractAddr: OnxContractAddr, From: id2, To: id1, Role: []byte(role),
+		Period: 100, Level: 1, KeyNo: 1}
+	result, err := invoke(db, common.Address{}, acc2.Address, 1001, utils.AuthContractAddress, "delegate", delegate)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_FALSE, result)
+
+	// remove a function from the role
+	assign.FuncNames = []string{"foo1"}
+	_, err = invoke(db, common.Address{}, adminAcc.Address, 100, utils.AuthContractAddress, "removeFuncsFromRole", assign)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"foo2"}, queryFuncs(t, db, role))
+	assert.False(t, verify(t, db, acc1, 100, id1, "foo1"))
+	assert.True(t, verify(t, db, acc1, 100, id1, "foo2"))
+
+	// revoke the members, the role is dropped once it has neither functions nor members
+	grant.Persons = [][]byte{id1, id2}
+	_, err = invoke(db, common.Address{}, adminAcc.Address, 100, utils.AuthContractAddress, "revokeOnxIDsFromRole", &grant)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(queryMembers(t, db, role)))
+	assert.False(t, verify(t, db, acc1, 100, id1, "foo2"))
+	assert.Equal(t, []string{role}, queryRoles(t, db))
+	assign.FuncNames = []string{"foo2"}
+	_, err = invoke(db, common.Address{}, adminAcc.Address, 100, utils.AuthContractAddress, "removeFuncsFromRole", assign)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(queryRoles(t, db)))
+}
+
+func TestRevokeDelegated(t *testing.T) {
+	db := testsuite.NewDB()
+	adminAcc, acc1, acc2 := account.NewAccount(""), account.NewAccount(""), account.NewAccount("")
+	adminID := testsuite.RegisterID(t, db, adminAcc)
+	id1 := testsuite.RegisterID(t, db, acc1)
+	id2 := testsuite.RegisterID(t, db, acc2)
+
+	_, err := invoke(db, OnxContractAddr, adminAcc.Address, 0, utils.AuthContractAddress, "initContractAdmin",
+		&InitContractAdminParam{AdminOnxID: adminID})
+	assert.Nil(t, err)
+	assign := &FuncsToRoleParam{ContractAddr: OnxContractAddr, AdminOnxID: adminID, Role: []byte(role),
+		FuncNames: funcs, KeyNo: 1}
+	_, err = invoke(db, common.Address{}, adminAcc.Address, 100, utils.AuthContractAddress, "assignFuncsToRole", assign)
+	assert.Nil(t, err)
+	grant := &OnxIDsToRoleParam{ContractAddr: OnxContractAddr, AdminOnxID: adminID, Role: []byte(role),
+		Persons: [][]byte{id1}, KeyNo: 1}
+	_, err = invoke(db, common.Address{}, adminAcc.Address, 100, utils.AuthContractAddress, "assignOnxIDsToRole", grant)
+	assert.Nil(t, err)
+	delegate := &DelegateParam{ContractAddr: OnxContractAddr, From: id1, To: id2, Role: []byte(role),
+		Period: 100, Level: 1, KeyNo: 1}
+	result, err := invoke(db, common.Address{}, acc1.Address, 100, utils.AuthContractAddress, "delegate", delegate)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, result)
+
+	members := queryMembers(t, db, role)
+	assert.Equal(t, 2, len(members))
+	assert.Equal(t, id2, members[1].OnxID)
+	assert.Equal(t, uint32(200), members[1].ExpireTime)
+	assert.True(t, members[1].Delegated)
+	assert.True(t, verify(t, db, acc2, 150, id2, "foo1"))
+
+	// only the admin can revoke
+	grant.Persons = [][]byte{id2}
+	grant.AdminOnxID = id1
+	result, err = invoke(db, common.Address{}, acc1.Address, 150, utils.AuthContractAddress, "revokeOnxIDsFromRole", grant)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_FALSE, result)
+	grant.AdminOnxID = adminID
+	result, err = invoke(db, common.Address{}, adminAcc.Address, 150, utils.AuthContractAddress, "revokeOnxIDsFromRole", grant)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, result)
+	assert.False(t, verify(t, db, acc2, 150, id2, "foo1"))
+	members = queryMembers(t, db, role)
+	assert.Equal(t, 1, len(members))
+	assert.Equal(t, id1, members[0].OnxID)
+}
+
+func TestRevokeDelegator(t *testing.T) {
+	db := testsuite.NewDB()
+	adminAcc, acc1, acc2 := account.NewAccount(""), account.NewAccount(""), account.NewAccount("")
+	adminID := testsuite.RegisterID(t, db, adminAcc)
+	id1 := testsuite.RegisterID(t, db, acc1)
+	id2 := testsuite.RegisterID(t, db, acc2)
+
+	_, err := invoke(db, OnxContractAddr, adminAcc.Address, 0, utils.AuthContractAddress, "initContractAdmin",
+		&InitContractAdminParam{AdminOnxID: adminID})
+	assert.Nil(t, err)
+	assign := &FuncsToRoleParam{ContractAddr: OnxContractAddr, AdminOnxID: adminID, Role: []byte(role),
+		FuncNames: funcs, KeyNo: 1}
+	_, err = invoke(db, common.Address{}, adminAcc.Address, 100, utils.AuthContractAddress, "assignFuncsToRole", assign)
+	assert.Nil(t, err)
+	grant := &OnxIDsToRoleParam{ContractAddr: OnxContractAddr, AdminOnxID: adminID, Role: []byte(role),
+		Persons: [][]byte{id1}, KeyNo: 1}
+	_, err = invoke(db, common.Address{}, adminAcc.Address, 100, utils.AuthContractAddress, "assignOnxIDsToRole", grant)
+	assert.Nil(t, err)
+	delegate := &DelegateParam{ContractAddr: OnxContractAddr, From: id1, To: id2, Role: []byte(role),
+		Period: 100, Level: 1, KeyNo: 1}
+	result, err := invoke(db, common.Address{}, acc1.Address, 100, utils.AuthContractAddress, "delegate", delegate)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, result)
+	assert.True(t, verify(t, db, acc2, 150, id2, "foo1"))
+
+	// the token delegated by the revoked member is revoked too
+	result, err = invoke(db, common.Address{}, adminAcc.Address, 150, utils.AuthContractAddress, "revokeOnxIDsFromRole", grant)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, result)
+	assert.False(t, verify(t, db, acc1, 150, id1, "foo1"))
+	assert.False(t, verify(t, db, acc2, 150, id2, "foo1"))
+	assert.Equal(t, 0, len(queryMembers(t, db, role)))
+}
@@ -207,6 +207,35 @@ func (this *OnxIDsToRoleParam) Deserialize(rd io.Reader) error {
 	return nil
 }
 
+type OnxIDsToRoleWithExpiryParam struct {
+	OnxIDsToRoleParam
+	ExpireTime uint64
+}
+
+func (this *OnxIDsToRoleWithExpiryParam) Serialize(w io.Writer) error {
+	if err := this.OnxIDsToRoleParam.Serialize(w); err != nil {
+		return err
+	}
+	if err := utils.WriteVarUint(w, this.ExpireTime); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *OnxIDsToRoleWithExpiryParam) Deserialize(rd io.Reader) error {
+	var err error
+	if err = this.OnxIDsToRoleParam.Deserialize(rd); err != nil {
+		return err
+	}
+	if this.ExpireTime, err = utils.ReadVarUint(rd); err != nil {
+		return err
+	}
+	if this.ExpireTime > math.MaxUint32 {
+		return fmt.Errorf("expire time too large: %d", this.ExpireTime)
+	}
+	return nil
+}
+
 type DelegateParam struct {
 	ContractAddr common.Address
 	From         []byte
@@ -358,3 +387,89 @@ func (this *VerifyTokenParam) Deserialize(rd io.Reader) error {
 	}
 	return nil
 }
+
+type GetRolesParam struct {
+	ContractAddr common.Address
+}
+
+func (this *GetRolesParam) Serialize(w io.Writer) error {
+	if err := serializeAddress(w, this.ContractAddr); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *GetRolesParam) Deserialize(rd io.Reader) error {
+	var err error
+	if this.ContractAddr, err = utils.ReadAddress(rd); err != nil {
+		return err
+	}
+	return nil
+}
+
+type RoleParam struct {
+	ContractAddr common.Address
+	Role         []byte
+}
+
+func (this *RoleParam) Serialize(w io.Writer) error {
+	if err := serializeAddress(w, this.ContractAddr); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.Role); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *RoleParam) Deserialize(rd io.Reader) error {
+	var err error
+	if this.ContractAddr, err = utils.ReadAddress(rd); err != nil {
+		return err
+	}
+	if this.Role, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	return nil
+}
+
+//RoleMember is an item of the result of getRoleMembers
+type RoleMember struct {
+	OnxID      []byte
+	ExpireTime uint32
+	Level      uint8
+	Delegated  bool
+}
+
+func (this *RoleMember) Serialize(w io.Writer) error {
+	if err := serialization.WriteVarBytes(w, this.OnxID); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint32(w, this.ExpireTime); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint8(w, this.Level); err != nil {
+		return err
+	}
+	if err := serialization.WriteBool(w, this.Delegated); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *RoleMember) Deserialize(rd io.Reader) error {
+	var err error
+	if this.OnxID, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.ExpireTime, err = serialization.ReadUint32(rd); err != nil {
+		return err
+	}
+	if this.Level, err = serialization.ReadUint8(rd); err != nil {
+		return err
+	}
+	if this.Delegated, err = serialization.ReadBool(rd); err != nil {
+		return err
+	}
+	return nil
+}
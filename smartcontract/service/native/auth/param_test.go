@@ -129,6 +129,28 @@ func TestSerialization_AssignOnxIDs(t *testing.T) {
 	assert.Equal(t, param, param2)
 }
 
+func TestSerialization_AssignOnxIDsWithExpiry(t *testing.T) {
+	param := &OnxIDsToRoleWithExpiryParam{
+		OnxIDsToRoleParam: OnxIDsToRoleParam{
+			ContractAddr: OnxContractAddr,
+			AdminOnxID:   admin,
+			Role:         []byte(role),
+			Persons:      [][]byte{p1, p2},
+		},
+		ExpireTime: 60 * 60 * 24,
+	}
+	bf := new(bytes.Buffer)
+	if err := param.Serialize(bf); err != nil {
+		t.Fatal(err)
+	}
+	rd := bytes.NewReader(bf.Bytes())
+	param2 := new(OnxIDsToRoleWithExpiryParam)
+	if err := param2.Deserialize(rd); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, param, param2)
+}
+
 func TestSerialization_Delegate(t *testing.T) {
 	param := &DelegateParam{
 		ContractAddr: OnxContractAddr,
@@ -19,6 +19,7 @@
 package auth
 
 import (
+	"bytes"
 	"io"
 
 	"github.com/OnyxPay/OnyxChain/common/serialization"
@@ -187,3 +188,66 @@ func (this *roleTokens) Deserialize(rd io.Reader) error {
 	}
 	return nil
 }
+
+/*
+ * index of the roles of a contract or the members of a role
+ */
+type byteSliceList struct {
+	items [][]byte
+}
+
+func (this *byteSliceList) Serialize(w io.Writer) error {
+	if err := serialization.WriteUint32(w, uint32(len(this.items))); err != nil {
+		return err
+	}
+	for _, item := range this.items {
+		if err := serialization.WriteVarBytes(w, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *byteSliceList) Deserialize(rd io.Reader) error {
+	iLen, err := serialization.ReadUint32(rd)
+	if err != nil {
+		return err
+	}
+	this.items = make([][]byte, 0)
+	for i := uint32(0); i < iLen; i++ {
+		item, err := serialization.ReadVarBytes(rd)
+		if err != nil {
+			return err
+		}
+		this.items = append(this.items, item)
+	}
+	return nil
+}
+
+func (this *byteSliceList) index(item []byte) int {
+	for i, it := range this.items {
+		if bytes.Equal(it, item) {
+			return i
+		}
+	}
+	return -1
+}
+
+//add appends the item if it is not in the list, return true if the list is changed
+func (this *byteSliceList) add(item []byte) bool {
+	if this.index(item) >= 0 {
+		return false
+	}
+	this.items = append(this.items, item)
+	return true
+}
+
+//remove deletes the item from the list, return true if the list is changed
+func (this *byteSliceList) remove(item []byte) bool {
+	i := this.index(item)
+	if i < 0 {
+		return false
+	}
+	this.items = append(this.items[:i], this.items[i+1:]...)
+	return true
+}
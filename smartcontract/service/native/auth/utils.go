@@ -35,6 +35,8 @@ var (
 	PreRoleFunc       = []byte{0x02}
 	PreRoleToken      = []byte{0x03}
 	PreDelegateStatus = []byte{0x04}
+	PreRoles          = []byte{0x05}
+	PreRoleMember     = []byte{0x06}
 )
 
 //type(this.contractAddr.Admin) = []byte
@@ -181,6 +183,72 @@ func putDelegateStatus(native *native.NativeService, contractAddr common.Address
 	return nil
 }
 
+func getByteSliceList(native *native.NativeService, key []byte) (*byteSliceList, error) {
+	item, err := utils.GetStorageItem(native, key)
+	if err != nil {
+		return nil, err
+	}
+	list := new(byteSliceList)
+	if item == nil {
+		return list, nil
+	}
+	rd := bytes.NewReader(item.Value)
+	err = list.Deserialize(rd)
+	if err != nil {
+		return nil, fmt.Errorf("deserialize byteSliceList object failed. data: %x", item.Value)
+	}
+	return list, nil
+}
+
+func putByteSliceList(native *native.NativeService, key []byte, list *byteSliceList) error {
+	if len(list.items) == 0 {
+		native.CacheDB.Delete(key)
+		return nil
+	}
+	bf := new(bytes.Buffer)
+	err := list.Serialize(bf)
+	if err != nil {
+		return fmt.Errorf("serialize byteSliceList failed, caused by %v", err)
+	}
+	utils.PutBytes(native, key, bf.Bytes())
+	return nil
+}
+
+//type(this.contractAddr.Roles) = byteSliceList
+func concatRolesKey(native *native.NativeService, contractAddr common.Address) []byte {
+	this := native.ContextRef.CurrentContext().ContractAddress
+	key := append(this[:], contractAddr[:]...)
+	key = append(key, PreRoles...)
+
+	return key
+}
+
+func getRoles(native *native.NativeService, contractAddr common.Address) (*byteSliceList, error) {
+	return getByteSliceList(native, concatRolesKey(native, contractAddr))
+}
+
+func putRoles(native *native.NativeService, contractAddr common.Address, roles *byteSliceList) error {
+	return putByteSliceList(native, concatRolesKey(native, contractAddr), roles)
+}
+
+//type(this.contractAddr.RoleMember.role) = byteSliceList
+func concatRoleMemberKey(native *native.NativeService, contractAddr common.Address, role []byte) []byte {
+	this := native.ContextRef.CurrentContext().ContractAddress
+	key := append(this[:], contractAddr[:]...)
+	key = append(key, PreRoleMember...)
+	key = append(key, role...)
+
+	return key
+}
+
+func getRoleMembers(native *native.NativeService, contractAddr common.Address, role []byte) (*byteSliceList, error) {
+	return getByteSliceList(native, concatRoleMemberKey(native, contractAddr, role))
+}
+
+func putRoleMembers(native *native.NativeService, contractAddr common.Address, role []byte, members *byteSliceList) error {
+	return putByteSliceList(native, concatRoleMemberKey(native, contractAddr, role), members)
+}
+
 //remote duplicates in the slice of string
 func stringSliceUniq(s []string) []string {
 	smap := make(map[string]int)
@@ -199,6 +267,15 @@ func stringSliceUniq(s []string) []string {
 	return ret
 }
 
+func stringSliceContains(s []string, str string) bool {
+	for _, v := range s {
+		if v == str {
+			return true
+		}
+	}
+	return false
+}
+
 func pushEvent(native *native.NativeService, s interface{}) {
 	event := new(event.NotifyEventInfo)
 	event.ContractAddress = native.ContextRef.CurrentContext().ContractAddress
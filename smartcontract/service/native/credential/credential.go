@@ -0,0 +1,147 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package credential implements the registry of verifiable credentials issued by onx ids. The issuer commits
+// the hash of a credential about the subject with an expiry, the issuer or the subject can revoke it later,
+// and anyone can query the status of the credential by its hash and issuer.
+package credential
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/OnyxPay/OnyxChain/account"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/errors"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+)
+
+func InitCredential() {
+	native.Contracts[utils.CredentialContractAddress] = RegisterCredentialContract
+}
+
+func RegisterCredentialContract(native *native.NativeService) {
+	native.Register(COMMIT_NAME, CredentialCommit)
+	native.Register(REVOKE_NAME, CredentialRevoke)
+	native.Register(GET_CREDENTIAL_NAME, CredentialGetCredential)
+	native.Register(GET_STATUS_NAME, CredentialGetStatus)
+}
+
+//CredentialCommit anchor the hash of a credential signed by the key of the issuer
+func CredentialCommit(native *native.NativeService) ([]byte, error) {
+	var param CommitParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[Commit] param deserialize error!")
+	}
+	if err := checkHash(param.Hash); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Commit] %v", err)
+	}
+	if !account.VerifyID(string(param.Subject)) {
+		return utils.BYTE_FALSE, errors.NewErr("[Commit] invalid subject ID")
+	}
+	if param.Expiry != 0 && param.Expiry <= uint64(native.Time) {
+		return utils.BYTE_FALSE, fmt.Errorf("[Commit] expiry %d should be greater than current time %d", param.Expiry, native.Time)
+	}
+	if err := verifySignature(native, param.Issuer, param.KeyIndex); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Commit] %v", err)
+	}
+	credential, err := getCredential(native, param.Issuer, param.Hash)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	if credential != nil {
+		return utils.BYTE_FALSE, errors.NewErr("[Commit] credential has been committed by the issuer")
+	}
+
+	credential = &Credential{
+		Hash:    param.Hash,
+		Issuer:  param.Issuer,
+		Subject: param.Subject,
+		Expiry:  param.Expiry,
+		Height:  native.Height,
+		Status:  STATUS_VALID,
+	}
+	putCredential(native, credential)
+	addNotifications(native, COMMIT_NAME, credential, credential.Expiry)
+	return utils.BYTE_TRUE, nil
+}
+
+//CredentialRevoke revoke a valid credential by the issuer or the subject
+func CredentialRevoke(native *native.NativeService) ([]byte, error) {
+	var param RevokeParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[Revoke] param deserialize error!")
+	}
+	credential, err := getCredential(native, param.Issuer, param.Hash)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	if credential == nil {
+		return utils.BYTE_FALSE, errors.NewErr("[Revoke] credential not found")
+	}
+	if credential.Status != STATUS_VALID {
+		return utils.BYTE_FALSE, errors.NewErr("[Revoke] credential has been revoked")
+	}
+	if !bytes.Equal(param.Revoker, credential.Issuer) && !bytes.Equal(param.Revoker, credential.Subject) {
+		return utils.BYTE_FALSE, errors.NewErr("[Revoke] revoker should be the issuer or the subject")
+	}
+	if err := verifySignature(native, param.Revoker, param.KeyIndex); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Revoke] %v", err)
+	}
+
+	credential.Status = STATUS_REVOKED
+	putCredential(native, credential)
+	addNotifications(native, REVOKE_NAME, credential, string(param.Revoker))
+	return utils.BYTE_TRUE, nil
+}
+
+//CredentialGetCredential return the serialized credential of the hash issued by the issuer
+func CredentialGetCredential(native *native.NativeService) ([]byte, error) {
+	var param CredentialIdParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[GetCredential] param deserialize error!")
+	}
+	credential, err := getCredential(native, param.Issuer, param.Hash)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	if credential == nil {
+		return utils.BYTE_FALSE, errors.NewErr("[GetCredential] credential not found")
+	}
+	sink := common.NewZeroCopySink(nil)
+	credential.Serialization(sink)
+	return sink.Bytes(), nil
+}
+
+//CredentialGetStatus return the status of the credential of the hash issued by the issuer at the time of current block,
+//STATUS_UNKNOWN if not committed by the issuer
+func CredentialGetStatus(native *native.NativeService) ([]byte, error) {
+	var param CredentialIdParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[GetStatus] param deserialize error!")
+	}
+	credential, err := getCredential(native, param.Issuer, param.Hash)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	if credential == nil {
+		return []byte{STATUS_UNKNOWN}, nil
+	}
+	return []byte{credential.StatusAt(uint64(native.Time))}, nil
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package credential
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/OnyxPay/OnyxChain/account"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/store/overlaydb"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/onxid"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/testsuite"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	onxid.Init()
+	InitCredential()
+}
+
+//invoke call the contract in a transaction signed by the signer at the time, the changes are committed only if the call succeeds
+func invoke(db *overlaydb.OverlayDB, signer common.Address, time uint32, contract common.Address, method string, p testsuite.Param) ([]byte, error) {
+	return testsuite.Invoke(db, &testsuite.Tx{Signer: signer, Time: time}, contract, method, p)
+}
+
+func status(t *testing.T, db *overlaydb.OverlayDB, time uint32, issuer, hash []byte) byte {
+	result, err := invoke(db, common.Address{}, time, utils.CredentialContractAddress, GET_STATUS_NAME,
+		&CredentialIdParam{Hash: hash, Issuer: issuer})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(result))
+	return result[0]
+}
+
+func TestCredential(t *testing.T) {
+	db := testsuite.NewDB()
+	issuerAcc, subjectAcc, other := account.NewAccount(""), account.NewAccount(""), account.NewAccount("")
+	issuer := testsuite.RegisterID(t, db, issuerAcc)
+	subject := testsuite.RegisterID(t, db, subjectAcc)
+	hash := sha256.Sum256([]byte("credential"))
+
+	commit := &CommitParam{Hash: hash[:], Issuer: issuer, Subject: subject, Expiry: 2000, KeyIndex: 1}
+	_, err := invoke(db, other.Address, 1000, utils.CredentialContractAddress, COMMIT_NAME, commit)
+	assert.NotNil(t, err)
+	_, err = invoke(db, issuerAcc.Address, 2000, utils.CredentialContractAddress, COMMIT_NAME, commit)
+	assert.NotNil(t, err)
+	assert.Equal(t, STATUS_UNKNOWN, status(t, db, 1000, issuer, hash[:]))
+
+	// the hash committed by another issuer does not occupy or shadow the credential of the issuer
+	otherID := testsuite.RegisterID(t, db, other)
+	front := &CommitParam{Hash: hash[:], Issuer: otherID, Subject: subject, KeyIndex: 1}
+	_, err = invoke(db, other.Address, 1000, utils.CredentialContractAddress, COMMIT_NAME, front)
+	assert.Nil(t, err)
+	assert.Equal(t, STATUS_UNKNOWN, status(t, db, 1000, issuer, hash[:]))
+	_, err = invoke(db, issuerAcc.Address, 1000, utils.CredentialContractAddress, COMMIT_NAME, commit)
+	assert.Nil(t, err)
+	_, err = invoke(db, issuerAcc.Address, 1000, utils.CredentialContractAddress, COMMIT_NAME, commit)
+	assert.NotNil(t, err)
+
+	assert.Equal(t, STATUS_VALID, status(t, db, 1999, issuer, hash[:]))
+	assert.Equal(t, STATUS_EXPIRED, status(t, db, 2000, issuer, hash[:]))
+	result, err := invoke(db, other.Address, 0, utils.CredentialContractAddress, GET_CREDENTIAL_NAME,
+		&CredentialIdParam{Hash: hash[:], Issuer: issuer})
+	assert.Nil(t, err)
+	credential := new(Credential)
+	assert.Nil(t, credential.Deserialization(common.NewZeroCopySource(result)))
+	assert.Equal(t, issuer, credential.Issuer)
+	assert.Equal(t, subject, credential.Subject)
+	assert.Equal(t, uint64(2000), credential.Expiry)
+
+	// only the issuer or the subject can revoke
+	revoke := &RevokeParam{Hash: hash[:], Issuer: issuer, Revoker: subject, KeyIndex: 1}
+	_, err = invoke(db, issuerAcc.Address, 1000, utils.CredentialContractAddress, REVOKE_NAME, revoke)
+	assert.NotNil(t, err)
+	_, err = invoke(db, subjectAcc.Address, 1000, utils.CredentialContractAddress, REVOKE_NAME, revoke)
+	assert.Nil(t, err)
+	_, err = invoke(db, subjectAcc.Address, 1000, utils.CredentialContractAddress, REVOKE_NAME, revoke)
+	assert.NotNil(t, err)
+	assert.Equal(t, STATUS_REVOKED, status(t, db, 1000, issuer, hash[:]))
+	assert.Equal(t, STATUS_VALID, status(t, db, 1000, otherID, hash[:]))
+}
@@ -0,0 +1,186 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package credential
+
+import (
+	"io"
+	"math"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+)
+
+// CommitParam anchors the hash of a credential issued by Issuer about Subject, the issuer signs the
+// transaction with the public key of KeyIndex. Expiry is a unix timestamp, 0 means never expire.
+type CommitParam struct {
+	Hash     []byte
+	Issuer   []byte
+	Subject  []byte
+	Expiry   uint64
+	KeyIndex uint64
+}
+
+func (this *CommitParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.Hash)
+	sink.WriteVarBytes(this.Issuer)
+	sink.WriteVarBytes(this.Subject)
+	utils.EncodeVarUint(sink, this.Expiry)
+	utils.EncodeVarUint(sink, this.KeyIndex)
+}
+
+func (this *CommitParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Hash, err = decodeBytes(source); err != nil {
+		return err
+	}
+	if this.Issuer, err = decodeBytes(source); err != nil {
+		return err
+	}
+	if this.Subject, err = decodeBytes(source); err != nil {
+		return err
+	}
+	if this.Expiry, err = utils.DecodeVarUint(source); err != nil {
+		return err
+	}
+	this.KeyIndex, err = utils.DecodeVarUint(source)
+	return err
+}
+
+// RevokeParam revokes the credential of Hash issued by Issuer, the revoker is the issuer or the subject,
+// and signs the transaction with the public key of KeyIndex.
+type RevokeParam struct {
+	Hash     []byte
+	Issuer   []byte
+	Revoker  []byte
+	KeyIndex uint64
+}
+
+func (this *RevokeParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.Hash)
+	sink.WriteVarBytes(this.Issuer)
+	sink.WriteVarBytes(this.Revoker)
+	utils.EncodeVarUint(sink, this.KeyIndex)
+}
+
+func (this *RevokeParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Hash, err = decodeBytes(source); err != nil {
+		return err
+	}
+	if this.Issuer, err = decodeBytes(source); err != nil {
+		return err
+	}
+	if this.Revoker, err = decodeBytes(source); err != nil {
+		return err
+	}
+	this.KeyIndex, err = utils.DecodeVarUint(source)
+	return err
+}
+
+// CredentialIdParam identifies a credential by the hash and the issuer, the same hash committed by
+// another issuer is a different credential.
+type CredentialIdParam struct {
+	Hash   []byte
+	Issuer []byte
+}
+
+func (this *CredentialIdParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.Hash)
+	sink.WriteVarBytes(this.Issuer)
+}
+
+func (this *CredentialIdParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Hash, err = decodeBytes(source); err != nil {
+		return err
+	}
+	this.Issuer, err = decodeBytes(source)
+	return err
+}
+
+// Credential is the on-chain record of a committed credential
+type Credential struct {
+	Hash    []byte
+	Issuer  []byte
+	Subject []byte
+	Expiry  uint64
+	Height  uint32 // the height of the commitment
+	Status  byte
+}
+
+func (this *Credential) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.Hash)
+	sink.WriteVarBytes(this.Issuer)
+	sink.WriteVarBytes(this.Subject)
+	utils.EncodeVarUint(sink, this.Expiry)
+	utils.EncodeVarUint(sink, uint64(this.Height))
+	utils.EncodeVarUint(sink, uint64(this.Status))
+}
+
+func (this *Credential) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Hash, err = decodeBytes(source); err != nil {
+		return err
+	}
+	if this.Issuer, err = decodeBytes(source); err != nil {
+		return err
+	}
+	if this.Subject, err = decodeBytes(source); err != nil {
+		return err
+	}
+	if this.Expiry, err = utils.DecodeVarUint(source); err != nil {
+		return err
+	}
+	height, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return err
+	}
+	if height > math.MaxUint32 {
+		return common.ErrIrregularData
+	}
+	this.Height = uint32(height)
+	status, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return err
+	}
+	if status > math.MaxUint8 {
+		return common.ErrIrregularData
+	}
+	this.Status = byte(status)
+	return nil
+}
+
+//StatusAt return the status of the credential at the time, STATUS_EXPIRED if a valid credential has expired
+func (this *Credential) StatusAt(time uint64) byte {
+	if this.Status == STATUS_VALID && this.Expiry != 0 && this.Expiry <= time {
+		return STATUS_EXPIRED
+	}
+	return this.Status
+}
+
+func decodeBytes(source *common.ZeroCopySource) ([]byte, error) {
+	data, _, irregular, eof := source.NextVarBytes()
+	if eof {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if irregular {
+		return nil, common.ErrIrregularData
+	}
+	return data, nil
+}
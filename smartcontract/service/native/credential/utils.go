@@ -0,0 +1,120 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package credential
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/config"
+	"github.com/OnyxPay/OnyxChain/smartcontract/event"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+)
+
+const (
+	//function name
+	COMMIT_NAME         = "commit"
+	REVOKE_NAME         = "revoke"
+	GET_CREDENTIAL_NAME = "getCredential"
+	GET_STATUS_NAME     = "getStatus"
+
+	//key prefix
+	CREDENTIAL = "credential"
+
+	//status of the credential, STATUS_UNKNOWN and STATUS_EXPIRED are only returned by getStatus
+	STATUS_UNKNOWN byte = 0
+	STATUS_VALID   byte = 1
+	STATUS_REVOKED byte = 2
+	STATUS_EXPIRED byte = 3
+
+	MAX_HASH_LEN = 64
+)
+
+//genCredentialKey return the key of the credential of hash issued by the issuer, so an issuer can not
+//occupy or shadow the hash of credentials issued by others
+func genCredentialKey(issuer, hash []byte) []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteBytes(utils.CredentialContractAddress[:])
+	sink.WriteBytes([]byte(CREDENTIAL))
+	sink.WriteVarBytes(issuer)
+	sink.WriteBytes(hash)
+	return sink.Bytes()
+}
+
+func getCredential(native *native.NativeService, issuer, hash []byte) (*Credential, error) {
+	item, err := utils.GetStorageItem(native, genCredentialKey(issuer, hash))
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, nil
+	}
+	credential := new(Credential)
+	if err := credential.Deserialization(common.NewZeroCopySource(item.Value)); err != nil {
+		return nil, fmt.Errorf("deserialize credential error:%v", err)
+	}
+	if !bytes.Equal(credential.Issuer, issuer) {
+		return nil, fmt.Errorf("credential issuer %s mismatch", credential.Issuer)
+	}
+	return credential, nil
+}
+
+func putCredential(native *native.NativeService, credential *Credential) {
+	sink := common.NewZeroCopySink(nil)
+	credential.Serialization(sink)
+	utils.PutBytes(native, genCredentialKey(credential.Issuer, credential.Hash), sink.Bytes())
+}
+
+func checkHash(hash []byte) error {
+	if len(hash) == 0 || len(hash) > MAX_HASH_LEN {
+		return fmt.Errorf("hash should be 1 to %d bytes", MAX_HASH_LEN)
+	}
+	return nil
+}
+
+//verifySignature check the transaction is signed by the key of the onx id by onxid.verifySignature
+func verifySignature(native *native.NativeService, id []byte, keyIndex uint64) error {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarBytes(id)
+	utils.EncodeVarUint(sink, keyIndex)
+	ret, err := native.NativeCall(utils.OnxIDContractAddress, "verifySignature", sink.Bytes())
+	if err != nil {
+		return fmt.Errorf("verify signature of %s error: %v", id, err)
+	}
+	valid, ok := ret.([]byte)
+	if !ok || !bytes.Equal(valid, utils.BYTE_TRUE) {
+		return fmt.Errorf("verify signature of %s failed", id)
+	}
+	return nil
+}
+
+func addNotifications(native *native.NativeService, method string, credential *Credential, states ...interface{}) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.Notifications = append(native.Notifications,
+		&event.NotifyEventInfo{
+			ContractAddress: utils.CredentialContractAddress,
+			States: append([]interface{}{method, hex.EncodeToString(credential.Hash), string(credential.Issuer),
+				string(credential.Subject)}, states...),
+		})
+}
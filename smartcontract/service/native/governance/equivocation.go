@@ -0,0 +1,260 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package governance
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/OnyxPay/OnyxChain-crypto/keypair"
+	"github.com/OnyxPay/OnyxChain/common"
+	vbftconfig "github.com/OnyxPay/OnyxChain/consensus/vbft/config"
+	"github.com/OnyxPay/OnyxChain/core/signature"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/global_params"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+)
+
+const (
+	//name of the global param which holds the equivocation penalty in percent of init pos
+	EQUIVOCATION_PENALTY_PARAM = "equivocationPenalty"
+	//penalty used when the global param is not set
+	DEFAULT_EQUIVOCATION_PENALTY = 10
+)
+
+//Slash a node which proposed two different blocks in the same round. Anyone can submit the evidence, which
+//consists of the two block proposals signed by the node, a part of the node's init pos is moved into penalty stake.
+func SlashEquivocation(native *native.NativeService) ([]byte, error) {
+	params := new(SlashEquivocationParam)
+	if err := params.Deserialize(bytes.NewBuffer(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("deserialize, deserialize slashEquivocationParam error: %v", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+
+	peerPubkeyPrefix, err := hex.DecodeString(params.PeerPubkey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
+	}
+	pubKey, err := keypair.DeserializePublicKey(peerPubkeyPrefix)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("keypair.DeserializePublicKey, peerPubkey format error: %v", err)
+	}
+
+	//get current view
+	view, err := GetView(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getView, get view error: %v", err)
+	}
+	//get peerPoolMap
+	peerPoolMap, err := GetPeerPoolMap(native, contract, view)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getPeerPoolMap, get peerPoolMap error: %v", err)
+	}
+	peerPoolItem, ok := peerPoolMap.PeerPoolMap[params.PeerPubkey]
+	if !ok {
+		return utils.BYTE_FALSE, fmt.Errorf("slashEquivocation, peerPubkey is not in peerPoolMap")
+	}
+	if peerPoolItem.Status != CandidateStatus && peerPoolItem.Status != ConsensusStatus {
+		return utils.BYTE_FALSE, fmt.Errorf("slashEquivocation, peer status is not candidate or consensus")
+	}
+
+	proposal1, err := verifyEquivocationProposal(params.Proposal1, pubKey, peerPoolItem.Index)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("verifyEquivocationProposal, proposal1 error: %v", err)
+	}
+	proposal2, err := verifyEquivocationProposal(params.Proposal2, pubKey, peerPoolItem.Index)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("verifyEquivocationProposal, proposal2 error: %v", err)
+	}
+	if !IsEquivocation(proposal1, proposal2) {
+		return utils.BYTE_FALSE, fmt.Errorf("slashEquivocation, proposals are not conflicting")
+	}
+	height := proposal1.Block.Height
+
+	slashed, err := isEquivocationSlashed(native, contract, peerPubkeyPrefix, height)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("isEquivocationSlashed, get equivocation error: %v", err)
+	}
+	if slashed {
+		return utils.BYTE_FALSE, fmt.Errorf("slashEquivocation, peer is already slashed at height %d", height)
+	}
+
+	rate, err := getEquivocationPenalty(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getEquivocationPenalty, get equivocation penalty error: %v", err)
+	}
+	penalty := (rate*peerPoolItem.InitPos + 99) / 100
+	if penalty > peerPoolItem.InitPos {
+		penalty = peerPoolItem.InitPos
+	}
+	if penalty > 0 {
+		// onx transfer to trigger unboundoxg
+		err = appCallTransferOnx(native, utils.GovernanceContractAddress, utils.GovernanceContractAddress, penalty)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("appCallTransferOnx, onx transfer error: %v", err)
+		}
+
+		//update total stake
+		err = withdrawTotalStake(native, contract, peerPoolItem.Address, penalty)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("withdrawTotalStake, withdrawTotalStake error: %v", err)
+		}
+
+		//update penalty stake
+		err = depositPenaltyStake(native, contract, params.PeerPubkey, penalty, 0)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("depositPenaltyStake, deposit penaltyStake error: %v", err)
+		}
+
+		peerPoolItem.InitPos = peerPoolItem.InitPos - penalty
+		peerPoolMap.PeerPoolMap[params.PeerPubkey] = peerPoolItem
+		err = putPeerPoolMap(native, contract, view, peerPoolMap)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("putPeerPoolMap, put peerPoolMap error: %v", err)
+		}
+	}
+
+	err = putEquivocationSlashed(native, contract, peerPubkeyPrefix, height, penalty)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("putEquivocationSlashed, put equivocation error: %v", err)
+	}
+
+	notifyEvent(native, contract, SLASH_EQUIVOCATION, params.PeerPubkey, height, penalty)
+	return utils.BYTE_TRUE, nil
+}
+
+//EquivocationProposal is a vbft block proposal, the proposer signs a block and an empty block for every proposal
+type EquivocationProposal struct {
+	Block      *types.Header
+	EmptyBlock *types.Header
+}
+
+//IsEquivocation checks if two proposals of a proposer conflict. Proposals of the same round are built on the same
+//block with the same timestamp and chain config, they conflict if they carry different blocks. A proposer which
+//proposes again after a timeout uses a later timestamp, so its proposals of different rounds do not conflict.
+func IsEquivocation(proposal1, proposal2 *EquivocationProposal) bool {
+	header1, header2 := proposal1.Block, proposal2.Block
+	if header1.Height != header2.Height || header1.PrevBlockHash != header2.PrevBlockHash ||
+		header1.Timestamp != header2.Timestamp {
+		return false
+	}
+	info1, err := getVbftBlockInfo(header1)
+	if err != nil {
+		return false
+	}
+	info2, err := getVbftBlockInfo(header2)
+	if err != nil {
+		return false
+	}
+	if info1.Proposer != info2.Proposer || info1.LastConfigBlockNum != info2.LastConfigBlockNum {
+		return false
+	}
+	block1, empty1 := proposal1.Block.Hash(), proposal1.EmptyBlock.Hash()
+	block2, empty2 := proposal2.Block.Hash(), proposal2.EmptyBlock.Hash()
+	return !(block1 == block2 && empty1 == empty2) && !(block1 == empty2 && empty1 == block2)
+}
+
+//deserialize a vbft block proposal and verify that both of its blocks are signed by the proposer of the index
+func verifyEquivocationProposal(raw []byte, pubKey keypair.PublicKey, index uint32) (*EquivocationProposal, error) {
+	source := common.NewZeroCopySource(raw)
+	blockBytes, _, irregular, eof := source.NextVarBytes()
+	if irregular || eof {
+		return nil, fmt.Errorf("read block error")
+	}
+	emptyBlockBytes, _, irregular, eof := source.NextVarBytes()
+	if irregular || eof {
+		return nil, fmt.Errorf("read empty block error")
+	}
+	block, err := verifyEquivocationBlock(blockBytes, pubKey)
+	if err != nil {
+		return nil, fmt.Errorf("verify block error: %v", err)
+	}
+	emptyBlock, err := verifyEquivocationBlock(emptyBlockBytes, pubKey)
+	if err != nil {
+		return nil, fmt.Errorf("verify empty block error: %v", err)
+	}
+	if block.Hash() == emptyBlock.Hash() || block.Height != emptyBlock.Height ||
+		block.PrevBlockHash != emptyBlock.PrevBlockHash || block.Timestamp != emptyBlock.Timestamp ||
+		!bytes.Equal(block.ConsensusPayload, emptyBlock.ConsensusPayload) {
+		return nil, fmt.Errorf("empty block does not match block")
+	}
+	info, err := getVbftBlockInfo(block)
+	if err != nil {
+		return nil, err
+	}
+	if info.Proposer != index {
+		return nil, fmt.Errorf("block is proposed by %d, not by %d", info.Proposer, index)
+	}
+	return &EquivocationProposal{Block: block, EmptyBlock: emptyBlock}, nil
+}
+
+func verifyEquivocationBlock(raw []byte, pubKey keypair.PublicKey) (*types.Header, error) {
+	block, err := types.BlockFromRawBytes(raw)
+	if err != nil {
+		return nil, fmt.Errorf("types.BlockFromRawBytes, deserialize block error: %v", err)
+	}
+	header := block.Header
+	if len(header.SigData) == 0 {
+		return nil, fmt.Errorf("block is not signed")
+	}
+	hash := header.Hash()
+	if err := signature.Verify(pubKey, hash[:], header.SigData[0]); err != nil {
+		return nil, fmt.Errorf("signature.Verify, verify block signature error: %v", err)
+	}
+	return header, nil
+}
+
+func getVbftBlockInfo(header *types.Header) (*vbftconfig.VbftBlockInfo, error) {
+	info := new(vbftconfig.VbftBlockInfo)
+	if err := json.Unmarshal(header.ConsensusPayload, info); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal, unmarshal vbft block info error: %v", err)
+	}
+	return info, nil
+}
+
+func getEquivocationPenalty(native *native.NativeService) (uint64, error) {
+	nameList := global_params.ParamNameList{EQUIVOCATION_PENALTY_PARAM}
+	bf := new(bytes.Buffer)
+	if err := nameList.Serialize(bf); err != nil {
+		return 0, fmt.Errorf("serialize, serialize paramNameList error: %v", err)
+	}
+	result, err := native.NativeCall(utils.ParamContractAddress, global_params.GET_GLOBAL_PARAM_NAME, bf.Bytes())
+	if err != nil {
+		return 0, fmt.Errorf("appCall, get global param error: %v", err)
+	}
+	params := new(global_params.Params)
+	if err := params.Deserialize(bytes.NewBuffer(result.([]byte))); err != nil {
+		return 0, fmt.Errorf("deserialize, deserialize global params error: %v", err)
+	}
+	index, param := params.GetParam(EQUIVOCATION_PENALTY_PARAM)
+	if index < 0 || param.Value == "" {
+		return DEFAULT_EQUIVOCATION_PENALTY, nil
+	}
+	rate, err := strconv.ParseUint(param.Value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("strconv.ParseUint, parse %s error: %v", EQUIVOCATION_PENALTY_PARAM, err)
+	}
+	if rate > 100 {
+		return 0, fmt.Errorf("%s is larger than 100", EQUIVOCATION_PENALTY_PARAM)
+	}
+	return rate, nil
+}
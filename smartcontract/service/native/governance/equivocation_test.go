@@ -0,0 +1,141 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package governance
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/OnyxPay/OnyxChain-crypto/keypair"
+	"github.com/OnyxPay/OnyxChain/account"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/constants"
+	"github.com/OnyxPay/OnyxChain/common/serialization"
+	vbftconfig "github.com/OnyxPay/OnyxChain/consensus/vbft/config"
+	"github.com/OnyxPay/OnyxChain/core/signature"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/global_params"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/onx"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/testsuite"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func signedBlock(t *testing.T, acc *account.Account, timestamp uint32, nonce uint64, payload []byte) []byte {
+	header := &types.Header{
+		PrevBlockHash:    common.Uint256{1},
+		Timestamp:        timestamp,
+		Height:           100,
+		ConsensusData:    nonce,
+		ConsensusPayload: payload,
+	}
+	hash := header.Hash()
+	sig, err := signature.Sign(acc, hash[:])
+	assert.Nil(t, err)
+	header.Bookkeepers = []keypair.PublicKey{acc.PublicKey}
+	header.SigData = [][]byte{sig}
+	block := &types.Block{Header: header}
+	return block.ToArray()
+}
+
+func signedProposal(t *testing.T, acc *account.Account, proposer uint32, timestamp uint32, nonce uint64) []byte {
+	payload, err := json.Marshal(&vbftconfig.VbftBlockInfo{Proposer: proposer})
+	assert.Nil(t, err)
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarBytes(signedBlock(t, acc, timestamp, nonce, payload))
+	sink.WriteVarBytes(signedBlock(t, acc, timestamp, 0, payload))
+	sink.WriteHash(common.Uint256{})
+	return sink.Bytes()
+}
+
+func TestSlashEquivocation(t *testing.T) {
+	db := testsuite.NewDB()
+	admin, owner := common.Address{1}, common.Address{2}
+	peer, other := account.NewAccount(""), account.NewAccount("")
+	peerPubkey := hex.EncodeToString(keypair.SerializePublicKey(peer.PublicKey))
+	time := constants.GENESIS_BLOCK_TIMESTAMP + 1000
+	contract := utils.GovernanceContractAddress
+
+	bf := new(bytes.Buffer)
+	params := global_params.Params{{Key: EQUIVOCATION_PENALTY_PARAM, Value: "20"}}
+	assert.Nil(t, params.Serialize(bf))
+	assert.Nil(t, utils.WriteAddress(bf, admin))
+	args := new(bytes.Buffer)
+	assert.Nil(t, serialization.WriteVarBytes(args, bf.Bytes()))
+	_, err := invoke(db, admin, 0, utils.ParamContractAddress, global_params.INIT_NAME, testsuite.RawParam(args.Bytes()))
+	assert.Nil(t, err)
+
+	service, cache := newNative(db, admin, time)
+	assert.Nil(t, putGovernanceView(service, contract, &GovernanceView{View: 1}))
+	peerPoolMap := &PeerPoolMap{PeerPoolMap: map[string]*PeerPoolItem{
+		peerPubkey: {Index: 3, PeerPubkey: peerPubkey, Address: owner, Status: ConsensusStatus, InitPos: 1000},
+	}}
+	assert.Nil(t, putPeerPoolMap(service, contract, 1, peerPoolMap))
+	assert.Nil(t, putTotalStake(service, contract, &TotalStake{Address: owner, Stake: 1000,
+		TimeOffset: time - constants.GENESIS_BLOCK_TIMESTAMP}))
+	service.CacheDB.Put(onx.GenBalanceKey(utils.OnxContractAddress, contract), utils.GenUInt64StorageItem(1000).ToArray())
+	cache.Commit()
+
+	//the same proposal is not conflicting with itself
+	evidence := &SlashEquivocationParam{
+		PeerPubkey: peerPubkey,
+		Proposal1:  signedProposal(t, peer, 3, 500, 1),
+	}
+	evidence.Proposal2 = evidence.Proposal1
+	_, err = invoke(db, admin, time, contract, SLASH_EQUIVOCATION, testsuite.Serialize(t, evidence))
+	assert.NotNil(t, err)
+
+	//proposals of different rounds are not conflicting
+	evidence.Proposal2 = signedProposal(t, peer, 3, 510, 2)
+	_, err = invoke(db, admin, time, contract, SLASH_EQUIVOCATION, testsuite.Serialize(t, evidence))
+	assert.NotNil(t, err)
+
+	//proposals must be signed by the peer
+	evidence.Proposal2 = signedProposal(t, other, 3, 500, 2)
+	_, err = invoke(db, admin, time, contract, SLASH_EQUIVOCATION, testsuite.Serialize(t, evidence))
+	assert.NotNil(t, err)
+
+	//blocks of other proposers signed by the peer as endorser are not its proposals
+	evidence.Proposal1 = signedProposal(t, peer, 4, 500, 1)
+	evidence.Proposal2 = signedProposal(t, peer, 4, 500, 2)
+	_, err = invoke(db, admin, time, contract, SLASH_EQUIVOCATION, testsuite.Serialize(t, evidence))
+	assert.NotNil(t, err)
+
+	evidence.Proposal1 = signedProposal(t, peer, 3, 500, 1)
+	evidence.Proposal2 = signedProposal(t, peer, 3, 500, 2)
+	_, err = invoke(db, admin, time, contract, SLASH_EQUIVOCATION, testsuite.Serialize(t, evidence))
+	assert.Nil(t, err)
+
+	service, _ = newNative(db, admin, time)
+	peerPoolMap, err = GetPeerPoolMap(service, contract, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(800), peerPoolMap.PeerPoolMap[peerPubkey].InitPos)
+	totalStake, err := getTotalStake(service, contract, owner)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(800), totalStake.Stake)
+	penaltyStake, err := getPenaltyStake(service, contract, peerPubkey)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(200), penaltyStake.InitPos)
+
+	//the same height can not be slashed twice
+	evidence.Proposal2 = signedProposal(t, peer, 3, 500, 3)
+	_, err = invoke(db, admin, time, contract, SLASH_EQUIVOCATION, testsuite.Serialize(t, evidence))
+	assert.NotNil(t, err)
+}
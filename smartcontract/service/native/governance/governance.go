@@ -76,6 +76,11 @@ const (
 	REDUCE_INIT_POS                  = "reduceInitPos"
 	SET_PROMISE_POS                  = "setPromisePos"
 	SET_GAS_ADDRESS                  = "setGasAddress"
+	CREATE_PROPOSAL                  = "createProposal"
+	VOTE_PROPOSAL                    = "voteProposal"
+	EXECUTE_PROPOSAL                 = "executeProposal"
+	GET_PROPOSAL                     = "getProposal"
+	SLASH_EQUIVOCATION               = "slashEquivocation"
 
 	//key prefix
 	GLOBAL_PARAM      = "globalParam"
@@ -95,6 +100,11 @@ const (
 	PROMISE_POS       = "promisePos"
 	PRE_CONFIG        = "preConfig"
 	GAS_ADDRESS       = "gasAddress"
+	PROPOSAL          = "proposal"
+	PROPOSAL_INDEX    = "proposalIndex"
+	PROPOSAL_VOTE     = "proposalVote"
+	VOTE_LOCK         = "voteLock"
+	EQUIVOCATION      = "equivocation"
 
 	//global
 	PRECISE           = 1000000
@@ -137,6 +147,11 @@ func RegisterGovernanceContract(native *native.NativeService) {
 	native.Register(WITHDRAW_FEE, WithdrawFee)
 	native.Register(ADD_INIT_POS, AddInitPos)
 	native.Register(REDUCE_INIT_POS, ReduceInitPos)
+	native.Register(CREATE_PROPOSAL, CreateProposal)
+	native.Register(VOTE_PROPOSAL, VoteProposal)
+	native.Register(EXECUTE_PROPOSAL, ExecuteProposal)
+	native.Register(GET_PROPOSAL, GetProposal)
+	native.Register(SLASH_EQUIVOCATION, SlashEquivocation)
 
 	native.Register(INIT_CONFIG, InitConfig)
 	native.Register(APPROVE_CANDIDATE, ApproveCandidate)
@@ -955,6 +970,15 @@ func Withdraw(native *native.NativeService) ([]byte, error) {
 		}
 	}
 
+	//the stake voted for a proposal can not be withdrawn to vote again before the voting ends
+	voteLock, err := getVoteLock(native, contract, address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getVoteLock, get voteLock error: %v", err)
+	}
+	if native.Time < voteLock {
+		return utils.BYTE_FALSE, fmt.Errorf("withdraw, stake is locked by proposal votes until %d", voteLock)
+	}
+
 	//onx transfer
 	err = appCallTransferOnx(native, utils.GovernanceContractAddress, address, total)
 	if err != nil {
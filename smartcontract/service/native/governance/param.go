@@ -1020,3 +1020,155 @@ func (this *GasAddress) Deserialization(source *common.ZeroCopySource) error {
 	this.Address = address
 	return nil
 }
+
+type CreateProposalParam struct {
+	Proposer    common.Address
+	Type        uint8
+	Content     []byte
+	Description string
+}
+
+func (this *CreateProposalParam) Serialize(w io.Writer) error {
+	if err := serialization.WriteVarBytes(w, this.Proposer[:]); err != nil {
+		return fmt.Errorf("serialization.WriteVarBytes, serialize proposer error: %v", err)
+	}
+	if err := utils.WriteVarUint(w, uint64(this.Type)); err != nil {
+		return fmt.Errorf("utils.WriteVarUint, serialize type error: %v", err)
+	}
+	if err := serialization.WriteVarBytes(w, this.Content); err != nil {
+		return fmt.Errorf("serialization.WriteVarBytes, serialize content error: %v", err)
+	}
+	if err := serialization.WriteString(w, this.Description); err != nil {
+		return fmt.Errorf("serialization.WriteString, serialize description error: %v", err)
+	}
+	return nil
+}
+
+func (this *CreateProposalParam) Deserialize(r io.Reader) error {
+	proposer, err := utils.ReadAddress(r)
+	if err != nil {
+		return fmt.Errorf("utils.ReadAddress, deserialize proposer error: %v", err)
+	}
+	proposalType, err := utils.ReadVarUint(r)
+	if err != nil {
+		return fmt.Errorf("utils.ReadVarUint, deserialize type error: %v", err)
+	}
+	if proposalType > math.MaxUint8 {
+		return fmt.Errorf("type larger than max of uint8")
+	}
+	content, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadVarBytes, deserialize content error: %v", err)
+	}
+	description, err := serialization.ReadString(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadString, deserialize description error: %v", err)
+	}
+	this.Proposer = proposer
+	this.Type = uint8(proposalType)
+	this.Content = content
+	this.Description = description
+	return nil
+}
+
+type VoteProposalParam struct {
+	Index   uint32
+	Voter   common.Address
+	Approve bool
+}
+
+func (this *VoteProposalParam) Serialize(w io.Writer) error {
+	if err := utils.WriteVarUint(w, uint64(this.Index)); err != nil {
+		return fmt.Errorf("utils.WriteVarUint, serialize index error: %v", err)
+	}
+	if err := serialization.WriteVarBytes(w, this.Voter[:]); err != nil {
+		return fmt.Errorf("serialization.WriteVarBytes, serialize voter error: %v", err)
+	}
+	if err := serialization.WriteBool(w, this.Approve); err != nil {
+		return fmt.Errorf("serialization.WriteBool, serialize approve error: %v", err)
+	}
+	return nil
+}
+
+func (this *VoteProposalParam) Deserialize(r io.Reader) error {
+	index, err := utils.ReadVarUint(r)
+	if err != nil {
+		return fmt.Errorf("utils.ReadVarUint, deserialize index error: %v", err)
+	}
+	if index > math.MaxUint32 {
+		return fmt.Errorf("index larger than max of uint32")
+	}
+	voter, err := utils.ReadAddress(r)
+	if err != nil {
+		return fmt.Errorf("utils.ReadAddress, deserialize voter error: %v", err)
+	}
+	approve, err := serialization.ReadBool(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadBool, deserialize approve error: %v", err)
+	}
+	this.Index = uint32(index)
+	this.Voter = voter
+	this.Approve = approve
+	return nil
+}
+
+type ProposalIndexParam struct {
+	Index uint32
+}
+
+func (this *ProposalIndexParam) Serialize(w io.Writer) error {
+	if err := utils.WriteVarUint(w, uint64(this.Index)); err != nil {
+		return fmt.Errorf("utils.WriteVarUint, serialize index error: %v", err)
+	}
+	return nil
+}
+
+func (this *ProposalIndexParam) Deserialize(r io.Reader) error {
+	index, err := utils.ReadVarUint(r)
+	if err != nil {
+		return fmt.Errorf("utils.ReadVarUint, deserialize index error: %v", err)
+	}
+	if index > math.MaxUint32 {
+		return fmt.Errorf("index larger than max of uint32")
+	}
+	this.Index = uint32(index)
+	return nil
+}
+
+type SlashEquivocationParam struct {
+	PeerPubkey string
+	Proposal1  []byte
+	Proposal2  []byte
+}
+
+func (this *SlashEquivocationParam) Serialize(w io.Writer) error {
+	if err := serialization.WriteString(w, this.PeerPubkey); err != nil {
+		return fmt.Errorf("serialization.WriteString, serialize peerPubkey error: %v", err)
+	}
+	if err := serialization.WriteVarBytes(w, this.Proposal1); err != nil {
+		return fmt.Errorf("serialization.WriteVarBytes, serialize proposal1 error: %v", err)
+	}
+	if err := serialization.WriteVarBytes(w, this.Proposal2); err != nil {
+		return fmt.Errorf("serialization.WriteVarBytes, serialize proposal2 error: %v", err)
+	}
+	return nil
+}
+
+func (this *SlashEquivocationParam) Deserialize(r io.Reader) error {
+	peerPubkey, err := serialization.ReadString(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadString, deserialize peerPubkey error: %v", err)
+	}
+	proposal1, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadVarBytes, deserialize proposal1 error: %v", err)
+	}
+	proposal2, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadVarBytes, deserialize proposal2 error: %v", err)
+	}
+	this.PeerPubkey = peerPubkey
+	this.Proposal1 = proposal1
+	this.Proposal2 = proposal2
+	return nil
+}
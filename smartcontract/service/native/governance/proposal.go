@@ -0,0 +1,355 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package governance
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/config"
+	"github.com/OnyxPay/OnyxChain/common/log"
+	"github.com/OnyxPay/OnyxChain/smartcontract/event"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/global_params"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+)
+
+//Proposals are executed by calling setGlobalParam and createSnapshot of the param contract, updateConfig or
+//updateGlobalParam of this contract, so the operator of the param contract must be set to this contract address
+//before any proposal can be executed, otherwise the execution of a passed proposal fails and the proposal is closed.
+const (
+	//proposal type
+	PROPOSAL_TYPE_GLOBAL_PARAM     uint8 = 0
+	PROPOSAL_TYPE_CONFIG           uint8 = 1
+	PROPOSAL_TYPE_GOVERNANCE_PARAM uint8 = 2
+
+	//proposal status
+	PROPOSAL_STATUS_VOTING   uint8 = 0
+	PROPOSAL_STATUS_EXECUTED uint8 = 1
+	PROPOSAL_STATUS_REJECTED uint8 = 2
+	PROPOSAL_STATUS_FAILED   uint8 = 3
+
+	//voting window of a proposal in seconds
+	PROPOSAL_VOTING_PERIOD = 7 * 24 * 60 * 60
+	//percentage of total stake that must vote for a proposal to be valid
+	PROPOSAL_QUORUM = 33
+	//max length of proposal description
+	MAX_PROPOSAL_DESCRIPTION = 1024
+)
+
+//Create a proposal to change global params or vbft config, the proposer must have stake in this contract
+func CreateProposal(native *native.NativeService) ([]byte, error) {
+	params := new(CreateProposalParam)
+	if err := params.Deserialize(bytes.NewBuffer(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("deserialize, deserialize createProposalParam error: %v", err)
+	}
+
+	//check witness
+	err := utils.ValidateOwner(native, params.Proposer)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("validateOwner, checkWitness error: %v", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+
+	totalStake, err := getTotalStake(native, contract, params.Proposer)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getTotalStake, get totalStake error: %v", err)
+	}
+	if totalStake.Stake == 0 {
+		return utils.BYTE_FALSE, fmt.Errorf("createProposal, proposer has no stake")
+	}
+	if len(params.Description) > MAX_PROPOSAL_DESCRIPTION {
+		return utils.BYTE_FALSE, fmt.Errorf("createProposal, description is longer than %d", MAX_PROPOSAL_DESCRIPTION)
+	}
+	if err := checkProposalContent(params.Type, params.Content); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("checkProposalContent, %v", err)
+	}
+
+	index, err := getProposalIndex(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getProposalIndex, get proposalIndex error: %v", err)
+	}
+	index = index + 1
+	proposal := &Proposal{
+		Index:       index,
+		Proposer:    params.Proposer,
+		Type:        params.Type,
+		Content:     params.Content,
+		Description: params.Description,
+		StartTime:   native.Time,
+		EndTime:     native.Time + PROPOSAL_VOTING_PERIOD,
+		Status:      PROPOSAL_STATUS_VOTING,
+	}
+	if proposal.EndTime < proposal.StartTime {
+		return utils.BYTE_FALSE, fmt.Errorf("createProposal, endTime overflow")
+	}
+	err = putProposal(native, contract, proposal)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("putProposal, put proposal error: %v", err)
+	}
+	err = putProposalIndex(native, contract, index)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("putProposalIndex, put proposalIndex error: %v", err)
+	}
+
+	notifyEvent(native, contract, CREATE_PROPOSAL, index, params.Proposer.ToBase58(), proposal.Type,
+		proposal.EndTime)
+	return utils.BYTE_TRUE, nil
+}
+
+//Vote for or against a proposal, the vote is weighted by the voter's stake in this contract.
+//Voting again replaces the previous vote with the current stake. The stake of the voter can not be withdrawn
+//until the end of voting, so the same onx can not vote again from another address.
+func VoteProposal(native *native.NativeService) ([]byte, error) {
+	params := new(VoteProposalParam)
+	if err := params.Deserialize(bytes.NewBuffer(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("deserialize, deserialize voteProposalParam error: %v", err)
+	}
+
+	//check witness
+	err := utils.ValidateOwner(native, params.Voter)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("validateOwner, checkWitness error: %v", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+
+	proposal, err := getProposal(native, contract, params.Index)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getProposal, get proposal error: %v", err)
+	}
+	if proposal.Status != PROPOSAL_STATUS_VOTING || native.Time >= proposal.EndTime {
+		return utils.BYTE_FALSE, fmt.Errorf("voteProposal, voting of proposal %d is closed", params.Index)
+	}
+
+	totalStake, err := getTotalStake(native, contract, params.Voter)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getTotalStake, get totalStake error: %v", err)
+	}
+	if totalStake.Stake == 0 {
+		return utils.BYTE_FALSE, fmt.Errorf("voteProposal, voter has no stake")
+	}
+
+	//withdraw previous vote
+	vote, err := getProposalVote(native, contract, params.Index, params.Voter)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getProposalVote, get proposalVote error: %v", err)
+	}
+	if vote != nil {
+		if vote.Approve {
+			proposal.ForStake = proposal.ForStake - vote.Stake
+		} else {
+			proposal.AgainstStake = proposal.AgainstStake - vote.Stake
+		}
+	}
+	vote = &ProposalVote{
+		Approve: params.Approve,
+		Stake:   totalStake.Stake,
+	}
+	if vote.Approve {
+		proposal.ForStake = proposal.ForStake + vote.Stake
+	} else {
+		proposal.AgainstStake = proposal.AgainstStake + vote.Stake
+	}
+	err = putProposalVote(native, contract, params.Index, params.Voter, vote)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("putProposalVote, put proposalVote error: %v", err)
+	}
+	voteLock, err := getVoteLock(native, contract, params.Voter)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getVoteLock, get voteLock error: %v", err)
+	}
+	if voteLock < proposal.EndTime {
+		err = putVoteLock(native, contract, params.Voter, proposal.EndTime)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("putVoteLock, put voteLock error: %v", err)
+		}
+	}
+	err = putProposal(native, contract, proposal)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("putProposal, put proposal error: %v", err)
+	}
+
+	notifyEvent(native, contract, VOTE_PROPOSAL, params.Index, params.Voter.ToBase58(), params.Approve,
+		vote.Stake)
+	return utils.BYTE_TRUE, nil
+}
+
+//Close the voting of a proposal after its voting window, a passed proposal is executed through the existing handlers.
+//A proposal passes when the voted stake reaches the quorum of total stake and more stake votes for it than against it.
+//A passed proposal whose execution fails is closed as failed, so it does not stay in voting forever.
+func ExecuteProposal(native *native.NativeService) ([]byte, error) {
+	params := new(ProposalIndexParam)
+	if err := params.Deserialize(bytes.NewBuffer(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("deserialize, deserialize proposalIndexParam error: %v", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+
+	proposal, err := getProposal(native, contract, params.Index)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getProposal, get proposal error: %v", err)
+	}
+	if proposal.Status != PROPOSAL_STATUS_VOTING {
+		return utils.BYTE_FALSE, fmt.Errorf("executeProposal, proposal %d is already closed", params.Index)
+	}
+	if native.Time < proposal.EndTime {
+		return utils.BYTE_FALSE, fmt.Errorf("executeProposal, voting of proposal %d is not finished", params.Index)
+	}
+
+	stake, err := getTotalPos(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getTotalPos, get total pos error: %v", err)
+	}
+	voted := proposal.ForStake + proposal.AgainstStake
+	if voted >= stake/100*PROPOSAL_QUORUM && proposal.ForStake > proposal.AgainstStake {
+		if err := executeProposal(native, contract, proposal); err != nil {
+			log.Warnf("executeProposal, execute proposal %d error: %v", params.Index, err)
+			proposal.Status = PROPOSAL_STATUS_FAILED
+		} else {
+			proposal.Status = PROPOSAL_STATUS_EXECUTED
+		}
+	} else {
+		proposal.Status = PROPOSAL_STATUS_REJECTED
+	}
+	err = putProposal(native, contract, proposal)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("putProposal, put proposal error: %v", err)
+	}
+
+	notifyEvent(native, contract, EXECUTE_PROPOSAL, params.Index, proposal.Status, proposal.ForStake,
+		proposal.AgainstStake)
+	return utils.BYTE_TRUE, nil
+}
+
+//Get a proposal by index
+func GetProposal(native *native.NativeService) ([]byte, error) {
+	params := new(ProposalIndexParam)
+	if err := params.Deserialize(bytes.NewBuffer(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("deserialize, deserialize proposalIndexParam error: %v", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+
+	proposal, err := getProposal(native, contract, params.Index)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getProposal, get proposal error: %v", err)
+	}
+	bf := new(bytes.Buffer)
+	if err := proposal.Serialize(bf); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("serialize, serialize proposal error: %v", err)
+	}
+	return bf.Bytes(), nil
+}
+
+func checkProposalContent(proposalType uint8, content []byte) error {
+	switch proposalType {
+	case PROPOSAL_TYPE_GLOBAL_PARAM:
+		params := global_params.Params{}
+		if err := params.Deserialize(bytes.NewBuffer(content)); err != nil {
+			return fmt.Errorf("deserialize global params error: %v", err)
+		}
+		if len(params) == 0 {
+			return fmt.Errorf("global params is empty")
+		}
+	case PROPOSAL_TYPE_CONFIG:
+		configuration := new(Configuration)
+		if err := configuration.Deserialize(bytes.NewBuffer(content)); err != nil {
+			return fmt.Errorf("deserialize configuration error: %v", err)
+		}
+	case PROPOSAL_TYPE_GOVERNANCE_PARAM:
+		globalParam := new(GlobalParam)
+		if err := globalParam.Deserialize(bytes.NewBuffer(content)); err != nil {
+			return fmt.Errorf("deserialize globalParam error: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown proposal type %d", proposalType)
+	}
+	return nil
+}
+
+func executeProposal(native *native.NativeService, contract common.Address, proposal *Proposal) error {
+	switch proposal.Type {
+	case PROPOSAL_TYPE_GLOBAL_PARAM:
+		if err := proposalCall(native, utils.ParamContractAddress, global_params.SET_GLOBAL_PARAM_NAME,
+			proposal.Content); err != nil {
+			return fmt.Errorf("appCall setGlobalParam error: %v", err)
+		}
+		if err := proposalCall(native, utils.ParamContractAddress, global_params.CREATE_SNAPSHOT_NAME,
+			[]byte{}); err != nil {
+			return fmt.Errorf("appCall createSnapshot error: %v", err)
+		}
+	case PROPOSAL_TYPE_CONFIG:
+		if err := proposalCall(native, contract, UPDATE_CONFIG, proposal.Content); err != nil {
+			return fmt.Errorf("appCall updateConfig error: %v", err)
+		}
+	case PROPOSAL_TYPE_GOVERNANCE_PARAM:
+		if err := proposalCall(native, contract, UPDATE_GLOBAL_PARAM, proposal.Content); err != nil {
+			return fmt.Errorf("appCall updateGlobalParam error: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown proposal type %d", proposal.Type)
+	}
+	return nil
+}
+
+//call the handler of a proposal, the context and notifications of this contract are restored if the handler fails.
+//The handlers check the witness and the params before writing storage, so a failed handler leaves no state behind.
+func proposalCall(native *native.NativeService, address common.Address, method string, args []byte) error {
+	ctx := native.ContextRef.CurrentContext()
+	notifications := native.Notifications
+	input := native.Input
+	if _, err := native.NativeCall(address, method, args); err != nil {
+		for native.ContextRef.CurrentContext() != ctx {
+			native.ContextRef.PopContext()
+		}
+		native.Notifications = notifications
+		native.Input = input
+		return err
+	}
+	return nil
+}
+
+//get total pos of candidate and consensus peers in current view
+func getTotalPos(native *native.NativeService, contract common.Address) (uint64, error) {
+	view, err := GetView(native, contract)
+	if err != nil {
+		return 0, fmt.Errorf("getView, get view error: %v", err)
+	}
+	peerPoolMap, err := GetPeerPoolMap(native, contract, view)
+	if err != nil {
+		return 0, fmt.Errorf("getPeerPoolMap, get peerPoolMap error: %v", err)
+	}
+	var totalPos uint64
+	for _, peerPoolItem := range peerPoolMap.PeerPoolMap {
+		if peerPoolItem.Status == CandidateStatus || peerPoolItem.Status == ConsensusStatus {
+			totalPos = totalPos + peerPoolItem.InitPos + peerPoolItem.TotalPos
+		}
+	}
+	return totalPos, nil
+}
+
+func notifyEvent(native *native.NativeService, contract common.Address, functionName string,
+	states ...interface{}) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.Notifications = append(native.Notifications,
+		&event.NotifyEventInfo{
+			ContractAddress: contract,
+			States:          append([]interface{}{functionName}, states...),
+		})
+}
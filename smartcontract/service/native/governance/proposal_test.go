@@ -0,0 +1,204 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package governance
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/serialization"
+	"github.com/OnyxPay/OnyxChain/core/store/overlaydb"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/OnyxPay/OnyxChain/smartcontract"
+	"github.com/OnyxPay/OnyxChain/smartcontract/context"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/global_params"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/onx"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/oxg"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/testsuite"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	"github.com/OnyxPay/OnyxChain/smartcontract/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	InitGovernance()
+	global_params.InitGlobalParams()
+	onx.InitOnx()
+	oxg.InitOxg()
+}
+
+//newNative build a native service in a transaction signed by the signer at the time
+func newNative(db *overlaydb.OverlayDB, signer common.Address, time uint32) (*native.NativeService, *storage.CacheDB) {
+	cache := storage.NewCacheDB(db)
+	sc := &smartcontract.SmartContract{
+		Config:  &smartcontract.Config{Tx: &types.Transaction{SignedAddr: []common.Address{signer}}, Height: 10, Time: time},
+		CacheDB: cache,
+		Gas:     math.MaxUint64,
+	}
+	sc.PushContext(&context.Context{ContractAddress: utils.GovernanceContractAddress})
+	service, _ := sc.NewNativeService()
+	return service, cache
+}
+
+//invoke call the contract from governance contract, the changes are committed only if the call succeeds
+func invoke(db *overlaydb.OverlayDB, signer common.Address, time uint32, contract common.Address, method string,
+	p testsuite.Param) ([]byte, error) {
+	tx := &testsuite.Tx{Signer: signer, Caller: utils.GovernanceContractAddress, Time: time}
+	return testsuite.Invoke(db, tx, contract, method, p)
+}
+
+func getGasPrice(t *testing.T, db *overlaydb.OverlayDB) string {
+	names := global_params.ParamNameList{"gasPrice"}
+	result, err := invoke(db, common.Address{}, 0, utils.ParamContractAddress, global_params.GET_GLOBAL_PARAM_NAME,
+		testsuite.Serialize(t, &names))
+	assert.Nil(t, err)
+	params := global_params.Params{}
+	assert.Nil(t, params.Deserialize(bytes.NewBuffer(result)))
+	return params[0].Value
+}
+
+func getProposalStatus(t *testing.T, db *overlaydb.OverlayDB, index uint32) *Proposal {
+	result, err := invoke(db, common.Address{}, 0, utils.GovernanceContractAddress, GET_PROPOSAL,
+		testsuite.Serialize(t, &ProposalIndexParam{Index: index}))
+	assert.Nil(t, err)
+	proposal := new(Proposal)
+	assert.Nil(t, proposal.Deserialize(bytes.NewBuffer(result)))
+	return proposal
+}
+
+func TestProposal(t *testing.T) {
+	db := testsuite.NewDB()
+	admin, voter1, voter2, voter3 := common.Address{1}, common.Address{2}, common.Address{3}, common.Address{4}
+
+	//init global params and hand the operator to governance contract
+	bf := new(bytes.Buffer)
+	params := global_params.Params{{Key: "gasPrice", Value: "500"}}
+	assert.Nil(t, params.Serialize(bf))
+	assert.Nil(t, utils.WriteAddress(bf, admin))
+	args := new(bytes.Buffer)
+	assert.Nil(t, serialization.WriteVarBytes(args, bf.Bytes()))
+	_, err := invoke(db, admin, 0, utils.ParamContractAddress, global_params.INIT_NAME, testsuite.RawParam(args.Bytes()))
+	assert.Nil(t, err)
+	bf = new(bytes.Buffer)
+	assert.Nil(t, utils.WriteAddress(bf, utils.GovernanceContractAddress))
+	_, err = invoke(db, admin, 0, utils.ParamContractAddress, global_params.SET_OPERATOR, testsuite.RawParam(bf.Bytes()))
+	assert.Nil(t, err)
+
+	//total pos of peers is 300, the quorum is 99
+	service, cache := newNative(db, admin, 0)
+	contract := utils.GovernanceContractAddress
+	assert.Nil(t, putGovernanceView(service, contract, &GovernanceView{View: 1}))
+	peerPoolMap := &PeerPoolMap{PeerPoolMap: map[string]*PeerPoolItem{
+		"peer": {PeerPubkey: "peer", Status: ConsensusStatus, InitPos: 100, TotalPos: 200},
+	}}
+	assert.Nil(t, putPeerPoolMap(service, contract, 1, peerPoolMap))
+	assert.Nil(t, putTotalStake(service, contract, &TotalStake{Address: voter1, Stake: 100}))
+	assert.Nil(t, putTotalStake(service, contract, &TotalStake{Address: voter2, Stake: 50}))
+	assert.Nil(t, putTotalStake(service, contract, &TotalStake{Address: voter3, Stake: 20}))
+	assert.Nil(t, putAuthorizeInfo(service, contract, &AuthorizeInfo{PeerPubkey: "0102", Address: voter1,
+		WithdrawUnfreezePos: 100}))
+	cache.Commit()
+
+	bf = new(bytes.Buffer)
+	params = global_params.Params{{Key: "gasPrice", Value: "10"}}
+	assert.Nil(t, params.Serialize(bf))
+	create := &CreateProposalParam{Proposer: admin, Type: PROPOSAL_TYPE_GLOBAL_PARAM, Content: bf.Bytes(),
+		Description: "lower gas price"}
+	_, err = invoke(db, admin, 1000, contract, CREATE_PROPOSAL, testsuite.Serialize(t, create))
+	assert.NotNil(t, err)
+	create.Proposer = voter1
+	create.Type = 3
+	_, err = invoke(db, voter1, 1000, contract, CREATE_PROPOSAL, testsuite.Serialize(t, create))
+	assert.NotNil(t, err)
+	create.Type = PROPOSAL_TYPE_GLOBAL_PARAM
+	_, err = invoke(db, voter1, 1000, contract, CREATE_PROPOSAL, testsuite.Serialize(t, create))
+	assert.Nil(t, err)
+	create.Proposer = voter3
+	_, err = invoke(db, voter3, 1000, contract, CREATE_PROPOSAL, testsuite.Serialize(t, create))
+	assert.Nil(t, err)
+
+	//voter2 changes its vote
+	vote := &VoteProposalParam{Index: 1, Voter: voter1, Approve: true}
+	_, err = invoke(db, voter1, 1001, contract, VOTE_PROPOSAL, testsuite.Serialize(t, vote))
+	assert.Nil(t, err)
+	vote.Voter = voter2
+	vote.Approve = false
+	_, err = invoke(db, voter1, 1001, contract, VOTE_PROPOSAL, testsuite.Serialize(t, vote))
+	assert.NotNil(t, err)
+	_, err = invoke(db, voter2, 1001, contract, VOTE_PROPOSAL, testsuite.Serialize(t, vote))
+	assert.Nil(t, err)
+	proposal := getProposalStatus(t, db, 1)
+	assert.Equal(t, uint64(100), proposal.ForStake)
+	assert.Equal(t, uint64(50), proposal.AgainstStake)
+	vote.Approve = true
+	_, err = invoke(db, voter2, 1002, contract, VOTE_PROPOSAL, testsuite.Serialize(t, vote))
+	assert.Nil(t, err)
+	proposal = getProposalStatus(t, db, 1)
+	assert.Equal(t, uint64(150), proposal.ForStake)
+	assert.Equal(t, uint64(0), proposal.AgainstStake)
+
+	//the voted stake is locked until the voting ends
+	service, _ = newNative(db, voter1, 0)
+	voteLock, err := getVoteLock(service, contract, voter1)
+	assert.Nil(t, err)
+	assert.Equal(t, proposal.EndTime, voteLock)
+	withdraw := &WithdrawParam{Address: voter1, PeerPubkeyList: []string{"0102"}, WithdrawList: []uint32{100}}
+	_, err = invoke(db, voter1, proposal.EndTime-1, contract, WITHDRAW, testsuite.Serialize(t, withdraw))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "locked")
+
+	//the passed proposal is executed after voting
+	execute := &ProposalIndexParam{Index: 1}
+	_, err = invoke(db, voter3, proposal.EndTime-1, contract, EXECUTE_PROPOSAL, testsuite.Serialize(t, execute))
+	assert.NotNil(t, err)
+	_, err = invoke(db, voter3, proposal.EndTime, contract, VOTE_PROPOSAL, testsuite.Serialize(t, vote))
+	assert.NotNil(t, err)
+	_, err = invoke(db, voter3, proposal.EndTime, contract, EXECUTE_PROPOSAL, testsuite.Serialize(t, execute))
+	assert.Nil(t, err)
+	assert.Equal(t, PROPOSAL_STATUS_EXECUTED, getProposalStatus(t, db, 1).Status)
+	assert.Equal(t, "10", getGasPrice(t, db))
+	_, err = invoke(db, voter3, proposal.EndTime, contract, EXECUTE_PROPOSAL, testsuite.Serialize(t, execute))
+	assert.NotNil(t, err)
+
+	//the proposal without quorum is rejected
+	vote = &VoteProposalParam{Index: 2, Voter: voter3, Approve: true}
+	_, err = invoke(db, voter3, 1001, contract, VOTE_PROPOSAL, testsuite.Serialize(t, vote))
+	assert.Nil(t, err)
+	execute.Index = 2
+	_, err = invoke(db, voter3, proposal.EndTime, contract, EXECUTE_PROPOSAL, testsuite.Serialize(t, execute))
+	assert.Nil(t, err)
+	assert.Equal(t, PROPOSAL_STATUS_REJECTED, getProposalStatus(t, db, 2).Status)
+
+	//the passed proposal which fails to execute is closed as failed
+	create = &CreateProposalParam{Proposer: voter1, Type: PROPOSAL_TYPE_CONFIG,
+		Content: testsuite.Serialize(t, &Configuration{}), Description: "invalid config"}
+	_, err = invoke(db, voter1, 1000, contract, CREATE_PROPOSAL, testsuite.Serialize(t, create))
+	assert.Nil(t, err)
+	vote = &VoteProposalParam{Index: 3, Voter: voter1, Approve: true}
+	_, err = invoke(db, voter1, 1001, contract, VOTE_PROPOSAL, testsuite.Serialize(t, vote))
+	assert.Nil(t, err)
+	execute.Index = 3
+	_, err = invoke(db, voter3, proposal.EndTime, contract, EXECUTE_PROPOSAL, testsuite.Serialize(t, execute))
+	assert.Nil(t, err)
+	assert.Equal(t, PROPOSAL_STATUS_FAILED, getProposalStatus(t, db, 3).Status)
+	_, err = invoke(db, voter3, proposal.EndTime, contract, EXECUTE_PROPOSAL, testsuite.Serialize(t, execute))
+	assert.NotNil(t, err)
+}
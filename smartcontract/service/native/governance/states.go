@@ -547,3 +547,133 @@ func (this *SplitFeeAddress) Deserialize(r io.Reader) error {
 	this.Amount = amount
 	return nil
 }
+
+type Proposal struct { //table record a proposal to change global params or vbft config
+	Index        uint32
+	Proposer     common.Address
+	Type         uint8
+	Content      []byte
+	Description  string
+	StartTime    uint32
+	EndTime      uint32
+	ForStake     uint64
+	AgainstStake uint64
+	Status       uint8
+}
+
+func (this *Proposal) Serialize(w io.Writer) error {
+	if err := serialization.WriteUint32(w, this.Index); err != nil {
+		return fmt.Errorf("serialization.WriteUint32, serialize index error: %v", err)
+	}
+	if err := this.Proposer.Serialize(w); err != nil {
+		return fmt.Errorf("address.Serialize, serialize proposer error: %v", err)
+	}
+	if err := serialization.WriteUint8(w, this.Type); err != nil {
+		return fmt.Errorf("serialization.WriteUint8, serialize type error: %v", err)
+	}
+	if err := serialization.WriteVarBytes(w, this.Content); err != nil {
+		return fmt.Errorf("serialization.WriteVarBytes, serialize content error: %v", err)
+	}
+	if err := serialization.WriteString(w, this.Description); err != nil {
+		return fmt.Errorf("serialization.WriteString, serialize description error: %v", err)
+	}
+	if err := serialization.WriteUint32(w, this.StartTime); err != nil {
+		return fmt.Errorf("serialization.WriteUint32, serialize startTime error: %v", err)
+	}
+	if err := serialization.WriteUint32(w, this.EndTime); err != nil {
+		return fmt.Errorf("serialization.WriteUint32, serialize endTime error: %v", err)
+	}
+	if err := serialization.WriteUint64(w, this.ForStake); err != nil {
+		return fmt.Errorf("serialization.WriteUint64, serialize forStake error: %v", err)
+	}
+	if err := serialization.WriteUint64(w, this.AgainstStake); err != nil {
+		return fmt.Errorf("serialization.WriteUint64, serialize againstStake error: %v", err)
+	}
+	if err := serialization.WriteUint8(w, this.Status); err != nil {
+		return fmt.Errorf("serialization.WriteUint8, serialize status error: %v", err)
+	}
+	return nil
+}
+
+func (this *Proposal) Deserialize(r io.Reader) error {
+	index, err := serialization.ReadUint32(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadUint32, deserialize index error: %v", err)
+	}
+	proposer := new(common.Address)
+	if err := proposer.Deserialize(r); err != nil {
+		return fmt.Errorf("address.Deserialize, deserialize proposer error: %v", err)
+	}
+	proposalType, err := serialization.ReadUint8(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadUint8, deserialize type error: %v", err)
+	}
+	content, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadVarBytes, deserialize content error: %v", err)
+	}
+	description, err := serialization.ReadString(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadString, deserialize description error: %v", err)
+	}
+	startTime, err := serialization.ReadUint32(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadUint32, deserialize startTime error: %v", err)
+	}
+	endTime, err := serialization.ReadUint32(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadUint32, deserialize endTime error: %v", err)
+	}
+	forStake, err := serialization.ReadUint64(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadUint64, deserialize forStake error: %v", err)
+	}
+	againstStake, err := serialization.ReadUint64(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadUint64, deserialize againstStake error: %v", err)
+	}
+	status, err := serialization.ReadUint8(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadUint8, deserialize status error: %v", err)
+	}
+	this.Index = index
+	this.Proposer = *proposer
+	this.Type = proposalType
+	this.Content = content
+	this.Description = description
+	this.StartTime = startTime
+	this.EndTime = endTime
+	this.ForStake = forStake
+	this.AgainstStake = againstStake
+	this.Status = status
+	return nil
+}
+
+type ProposalVote struct { //table record the vote of an address on a proposal
+	Approve bool
+	Stake   uint64
+}
+
+func (this *ProposalVote) Serialize(w io.Writer) error {
+	if err := serialization.WriteBool(w, this.Approve); err != nil {
+		return fmt.Errorf("serialization.WriteBool, serialize approve error: %v", err)
+	}
+	if err := serialization.WriteUint64(w, this.Stake); err != nil {
+		return fmt.Errorf("serialization.WriteUint64, serialize stake error: %v", err)
+	}
+	return nil
+}
+
+func (this *ProposalVote) Deserialize(r io.Reader) error {
+	approve, err := serialization.ReadBool(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadBool, deserialize approve error: %v", err)
+	}
+	stake, err := serialization.ReadUint64(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadUint64, deserialize stake error: %v", err)
+	}
+	this.Approve = approve
+	this.Stake = stake
+	return nil
+}
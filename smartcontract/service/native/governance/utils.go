@@ -819,3 +819,162 @@ func putGasAddress(native *native.NativeService, contract common.Address, gasAdd
 		cstates.GenRawStorageItem(sink.Bytes()))
 	return nil
 }
+
+func getProposalIndex(native *native.NativeService, contract common.Address) (uint32, error) {
+	proposalIndexBytes, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(PROPOSAL_INDEX)))
+	if err != nil {
+		return 0, fmt.Errorf("native.CacheDB.Get, get proposalIndex error: %v", err)
+	}
+	if proposalIndexBytes == nil {
+		return 0, nil
+	}
+	proposalIndexStore, err := cstates.GetValueFromRawStorageItem(proposalIndexBytes)
+	if err != nil {
+		return 0, fmt.Errorf("getProposalIndex, deserialize from raw storage item err:%v", err)
+	}
+	proposalIndex, err := GetBytesUint32(proposalIndexStore)
+	if err != nil {
+		return 0, fmt.Errorf("GetBytesUint32, get proposalIndex error: %v", err)
+	}
+	return proposalIndex, nil
+}
+
+func putProposalIndex(native *native.NativeService, contract common.Address, proposalIndex uint32) error {
+	proposalIndexBytes, err := GetUint32Bytes(proposalIndex)
+	if err != nil {
+		return fmt.Errorf("GetUint32Bytes, get proposalIndexBytes error: %v", err)
+	}
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(PROPOSAL_INDEX)), cstates.GenRawStorageItem(proposalIndexBytes))
+	return nil
+}
+
+func getProposal(native *native.NativeService, contract common.Address, index uint32) (*Proposal, error) {
+	indexBytes, err := GetUint32Bytes(index)
+	if err != nil {
+		return nil, fmt.Errorf("GetUint32Bytes, get indexBytes error: %v", err)
+	}
+	proposalBytes, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(PROPOSAL), indexBytes))
+	if err != nil {
+		return nil, fmt.Errorf("native.CacheDB.Get, get proposal error: %v", err)
+	}
+	if proposalBytes == nil {
+		return nil, fmt.Errorf("getProposal, proposal %d is not found", index)
+	}
+	proposalStore, err := cstates.GetValueFromRawStorageItem(proposalBytes)
+	if err != nil {
+		return nil, fmt.Errorf("getProposal, deserialize from raw storage item err:%v", err)
+	}
+	proposal := new(Proposal)
+	if err := proposal.Deserialize(bytes.NewBuffer(proposalStore)); err != nil {
+		return nil, fmt.Errorf("deserialize, deserialize proposal error: %v", err)
+	}
+	return proposal, nil
+}
+
+func putProposal(native *native.NativeService, contract common.Address, proposal *Proposal) error {
+	indexBytes, err := GetUint32Bytes(proposal.Index)
+	if err != nil {
+		return fmt.Errorf("GetUint32Bytes, get indexBytes error: %v", err)
+	}
+	bf := new(bytes.Buffer)
+	if err := proposal.Serialize(bf); err != nil {
+		return fmt.Errorf("serialize, serialize proposal error: %v", err)
+	}
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(PROPOSAL), indexBytes), cstates.GenRawStorageItem(bf.Bytes()))
+	return nil
+}
+
+func getProposalVote(native *native.NativeService, contract common.Address, index uint32,
+	address common.Address) (*ProposalVote, error) {
+	indexBytes, err := GetUint32Bytes(index)
+	if err != nil {
+		return nil, fmt.Errorf("GetUint32Bytes, get indexBytes error: %v", err)
+	}
+	voteBytes, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(PROPOSAL_VOTE), indexBytes, address[:]))
+	if err != nil {
+		return nil, fmt.Errorf("native.CacheDB.Get, get proposalVote error: %v", err)
+	}
+	if voteBytes == nil {
+		return nil, nil
+	}
+	voteStore, err := cstates.GetValueFromRawStorageItem(voteBytes)
+	if err != nil {
+		return nil, fmt.Errorf("getProposalVote, deserialize from raw storage item err:%v", err)
+	}
+	vote := new(ProposalVote)
+	if err := vote.Deserialize(bytes.NewBuffer(voteStore)); err != nil {
+		return nil, fmt.Errorf("deserialize, deserialize proposalVote error: %v", err)
+	}
+	return vote, nil
+}
+
+func putProposalVote(native *native.NativeService, contract common.Address, index uint32, address common.Address,
+	vote *ProposalVote) error {
+	indexBytes, err := GetUint32Bytes(index)
+	if err != nil {
+		return fmt.Errorf("GetUint32Bytes, get indexBytes error: %v", err)
+	}
+	bf := new(bytes.Buffer)
+	if err := vote.Serialize(bf); err != nil {
+		return fmt.Errorf("serialize, serialize proposalVote error: %v", err)
+	}
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(PROPOSAL_VOTE), indexBytes, address[:]),
+		cstates.GenRawStorageItem(bf.Bytes()))
+	return nil
+}
+
+func getVoteLock(native *native.NativeService, contract common.Address, address common.Address) (uint32, error) {
+	voteLockBytes, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(VOTE_LOCK), address[:]))
+	if err != nil {
+		return 0, fmt.Errorf("native.CacheDB.Get, get voteLock error: %v", err)
+	}
+	if voteLockBytes == nil {
+		return 0, nil
+	}
+	voteLockStore, err := cstates.GetValueFromRawStorageItem(voteLockBytes)
+	if err != nil {
+		return 0, fmt.Errorf("getVoteLock, deserialize from raw storage item err:%v", err)
+	}
+	voteLock, err := GetBytesUint32(voteLockStore)
+	if err != nil {
+		return 0, fmt.Errorf("GetBytesUint32, get voteLock error: %v", err)
+	}
+	return voteLock, nil
+}
+
+func putVoteLock(native *native.NativeService, contract common.Address, address common.Address, voteLock uint32) error {
+	voteLockBytes, err := GetUint32Bytes(voteLock)
+	if err != nil {
+		return fmt.Errorf("GetUint32Bytes, get voteLockBytes error: %v", err)
+	}
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(VOTE_LOCK), address[:]), cstates.GenRawStorageItem(voteLockBytes))
+	return nil
+}
+
+func isEquivocationSlashed(native *native.NativeService, contract common.Address, peerPubkeyPrefix []byte,
+	height uint32) (bool, error) {
+	heightBytes, err := GetUint32Bytes(height)
+	if err != nil {
+		return false, fmt.Errorf("GetUint32Bytes, get heightBytes error: %v", err)
+	}
+	slashed, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(EQUIVOCATION), peerPubkeyPrefix, heightBytes))
+	if err != nil {
+		return false, fmt.Errorf("native.CacheDB.Get, get equivocation error: %v", err)
+	}
+	return slashed != nil, nil
+}
+
+func putEquivocationSlashed(native *native.NativeService, contract common.Address, peerPubkeyPrefix []byte,
+	height uint32, penalty uint64) error {
+	heightBytes, err := GetUint32Bytes(height)
+	if err != nil {
+		return fmt.Errorf("GetUint32Bytes, get heightBytes error: %v", err)
+	}
+	penaltyBytes, err := GetUint64Bytes(penalty)
+	if err != nil {
+		return fmt.Errorf("GetUint64Bytes, get penaltyBytes error: %v", err)
+	}
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(EQUIVOCATION), peerPubkeyPrefix, heightBytes),
+		cstates.GenRawStorageItem(penaltyBytes))
+	return nil
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package htlc implements the hashed timelock contract, which locks onx, oxg or the assets of
+// the token registry until the receiver claims with the preimage or the sender refunds after the timeout.
+package htlc
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/errors"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+)
+
+func InitHtlc() {
+	native.Contracts[utils.HtlcContractAddress] = RegisterHtlcContract
+}
+
+func RegisterHtlcContract(native *native.NativeService) {
+	native.Register(LOCK_NAME, HtlcLock)
+	native.Register(CLAIM_NAME, HtlcClaim)
+	native.Register(REFUND_NAME, HtlcRefund)
+	native.Register(GET_LOCK_NAME, HtlcGetLock)
+}
+
+//HtlcLock move the asset of the sender to the contract under the hash lock
+func HtlcLock(native *native.NativeService) ([]byte, error) {
+	var param LockParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[Lock] param deserialize error!")
+	}
+	if err := checkAsset(param.Asset, param.Symbol); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Lock] %v", err)
+	}
+	if err := checkHashLock(param.HashType, param.HashLock); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Lock] %v", err)
+	}
+	if param.Amount == 0 {
+		return utils.BYTE_FALSE, errors.NewErr("[Lock] amount should be greater than 0")
+	}
+	if param.Timeout <= native.Height {
+		return utils.BYTE_FALSE, fmt.Errorf("[Lock] timeout %d should be greater than current height %d", param.Timeout, native.Height)
+	}
+	if !native.ContextRef.CheckWitness(param.Sender) {
+		return utils.BYTE_FALSE, errors.NewErr("[Lock] authentication failed!")
+	}
+	lock, err := getLock(native, param.Sender, param.HashLock)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	if lock != nil {
+		return utils.BYTE_FALSE, errors.NewErr("[Lock] hash lock has been used by the sender")
+	}
+
+	if err := appCallTransfer(native, param.Asset, param.Symbol, param.Sender, utils.HtlcContractAddress, param.Amount); err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	lock = &Lock{LockParam: param, Height: native.Height, Status: STATUS_LOCKED}
+	putLock(native, lock)
+	addNotifications(native, LOCK_NAME, lock, param.Sender.ToBase58(), param.Receiver.ToBase58(),
+		param.Asset.ToHexString(), param.Symbol, param.Amount, param.Timeout)
+	return utils.BYTE_TRUE, nil
+}
+
+//HtlcClaim move the locked asset to the receiver with the preimage before the timeout
+func HtlcClaim(native *native.NativeService) ([]byte, error) {
+	var param ClaimParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[Claim] param deserialize error!")
+	}
+	if len(param.Preimage) > MAX_PREIMAGE_LEN {
+		return utils.BYTE_FALSE, fmt.Errorf("[Claim] preimage should not be longer than %d", MAX_PREIMAGE_LEN)
+	}
+	lock, err := getLock(native, param.Sender, param.HashLock)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	if lock == nil || lock.Status != STATUS_LOCKED {
+		return utils.BYTE_FALSE, errors.NewErr("[Claim] no active lock of the sender and hash lock")
+	}
+	if native.Height >= lock.Timeout {
+		return utils.BYTE_FALSE, fmt.Errorf("[Claim] lock is timeout at height %d", lock.Timeout)
+	}
+	if !matchPreimage(lock, param.Preimage) {
+		return utils.BYTE_FALSE, errors.NewErr("[Claim] preimage mismatch")
+	}
+	if !native.ContextRef.CheckWitness(lock.Receiver) {
+		return utils.BYTE_FALSE, errors.NewErr("[Claim] authentication failed!")
+	}
+
+	if err := appCallTransfer(native, lock.Asset, lock.Symbol, utils.HtlcContractAddress, lock.Receiver, lock.Amount); err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	lock.Status = STATUS_CLAIMED
+	lock.Preimage = param.Preimage
+	putLock(native, lock)
+	addNotifications(native, CLAIM_NAME, lock, lock.Receiver.ToBase58(), hex.EncodeToString(param.Preimage))
+	return utils.BYTE_TRUE, nil
+}
+
+//HtlcRefund return the locked asset to the sender after the timeout
+func HtlcRefund(native *native.NativeService) ([]byte, error) {
+	var param LockIdParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[Refund] param deserialize error!")
+	}
+	lock, err := getLock(native, param.Sender, param.HashLock)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	if lock == nil || lock.Status != STATUS_LOCKED {
+		return utils.BYTE_FALSE, errors.NewErr("[Refund] no active lock of the sender and hash lock")
+	}
+	if native.Height < lock.Timeout {
+		return utils.BYTE_FALSE, fmt.Errorf("[Refund] lock can not be refunded before height %d", lock.Timeout)
+	}
+	if !native.ContextRef.CheckWitness(lock.Sender) {
+		return utils.BYTE_FALSE, errors.NewErr("[Refund] authentication failed!")
+	}
+
+	if err := appCallTransfer(native, lock.Asset, lock.Symbol, utils.HtlcContractAddress, lock.Sender, lock.Amount); err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	lock.Status = STATUS_REFUNDED
+	putLock(native, lock)
+	addNotifications(native, REFUND_NAME, lock, lock.Sender.ToBase58())
+	return utils.BYTE_TRUE, nil
+}
+
+//HtlcGetLock return the serialized lock of the sender and hash lock
+func HtlcGetLock(native *native.NativeService) ([]byte, error) {
+	var param LockIdParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[GetLock] param deserialize error!")
+	}
+	lock, err := getLock(native, param.Sender, param.HashLock)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	if lock == nil {
+		return utils.BYTE_FALSE, errors.NewErr("[GetLock] lock not exist")
+	}
+	sink := common.NewZeroCopySink(nil)
+	lock.Serialization(sink)
+	return sink.Bytes(), nil
+}
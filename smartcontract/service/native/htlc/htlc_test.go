@@ -0,0 +1,148 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package htlc
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/store/overlaydb"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/onx"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/testsuite"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/token"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+//invoke call the contract at the height in a transaction signed by the signer
+func invoke(db *overlaydb.OverlayDB, height uint32, signer common.Address, contract common.Address, method string, p testsuite.Param) ([]byte, error) {
+	return testsuite.Invoke(db, &testsuite.Tx{Signer: signer, Height: height}, contract, method, p)
+}
+
+func onxBalance(t *testing.T, db *overlaydb.OverlayDB, addr common.Address) uint64 {
+	return testsuite.BalanceOf(t, db, utils.OnxContractAddress, addr)
+}
+
+func TestHtlc(t *testing.T) {
+	onx.InitOnx()
+	token.InitToken()
+	InitHtlc()
+	db := testsuite.NewDB()
+
+	sender := common.Address{1}
+	receiver := common.Address{2}
+	testsuite.Put(db, onx.GenBalanceKey(utils.OnxContractAddress, sender), utils.GenUInt64StorageItem(100).ToArray())
+
+	preimage := []byte("secret of the swap")
+	hash := sha256.Sum256(preimage)
+	lock := &LockParam{
+		Sender:   sender,
+		Receiver: receiver,
+		Asset:    utils.OnxContractAddress,
+		Amount:   60,
+		HashType: HASH_SHA256,
+		HashLock: hash[:],
+		Timeout:  100,
+	}
+	_, err := invoke(db, 10, receiver, utils.HtlcContractAddress, LOCK_NAME, lock)
+	assert.NotNil(t, err)
+	// the hash lock seen in the mempool can not be occupied by others
+	attacker := common.Address{3}
+	testsuite.Put(db, onx.GenBalanceKey(utils.OnxContractAddress, attacker), utils.GenUInt64StorageItem(1).ToArray())
+	attack := *lock
+	attack.Sender, attack.Amount = attacker, 1
+	_, err = invoke(db, 10, attacker, utils.HtlcContractAddress, LOCK_NAME, &attack)
+	assert.Nil(t, err)
+	_, err = invoke(db, 10, sender, utils.HtlcContractAddress, LOCK_NAME, lock)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(40), onxBalance(t, db, sender))
+	assert.Equal(t, uint64(61), onxBalance(t, db, utils.HtlcContractAddress))
+	_, err = invoke(db, 10, sender, utils.HtlcContractAddress, LOCK_NAME, lock)
+	assert.NotNil(t, err)
+
+	_, err = invoke(db, 20, receiver, utils.HtlcContractAddress, CLAIM_NAME, &ClaimParam{Sender: sender, HashLock: hash[:], Preimage: []byte("wrong")})
+	assert.NotNil(t, err)
+	_, err = invoke(db, 20, receiver, utils.HtlcContractAddress, REFUND_NAME, &LockIdParam{Sender: sender, HashLock: hash[:]})
+	assert.NotNil(t, err)
+	_, err = invoke(db, 100, receiver, utils.HtlcContractAddress, CLAIM_NAME, &ClaimParam{Sender: sender, HashLock: hash[:], Preimage: preimage})
+	assert.NotNil(t, err)
+	_, err = invoke(db, 20, receiver, utils.HtlcContractAddress, CLAIM_NAME, &ClaimParam{Sender: sender, HashLock: hash[:], Preimage: preimage})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(60), onxBalance(t, db, receiver))
+	assert.Equal(t, uint64(1), onxBalance(t, db, utils.HtlcContractAddress))
+
+	result, err := invoke(db, 20, receiver, utils.HtlcContractAddress, GET_LOCK_NAME, &LockIdParam{Sender: sender, HashLock: hash[:]})
+	assert.Nil(t, err)
+	state := new(Lock)
+	assert.Nil(t, state.Deserialization(common.NewZeroCopySource(result)))
+	assert.Equal(t, STATUS_CLAIMED, state.Status)
+	assert.Equal(t, preimage, state.Preimage)
+	_, err = invoke(db, 200, sender, utils.HtlcContractAddress, REFUND_NAME, &LockIdParam{Sender: sender, HashLock: hash[:]})
+	assert.NotNil(t, err)
+}
+
+func TestHtlcRefundToken(t *testing.T) {
+	onx.InitOnx()
+	token.InitToken()
+	InitHtlc()
+	db := testsuite.NewDB()
+
+	sender := common.Address{1}
+	receiver := common.Address{2}
+	create := &token.CreateAssetParam{
+		Asset:         token.AssetInfo{Symbol: "USDX", Name: "USD Stable", Issuer: sender},
+		InitialSupply: 100,
+	}
+	_, err := invoke(db, 0, sender, utils.TokenContractAddress, token.CREATE_ASSET_NAME, create)
+	assert.Nil(t, err)
+
+	hashLock := common.AddressFromVmCode([]byte("secret"))
+	lock := &LockParam{
+		Sender:   sender,
+		Receiver: receiver,
+		Asset:    utils.TokenContractAddress,
+		Amount:   30,
+		HashType: HASH_HASH160,
+		HashLock: hashLock[:],
+		Timeout:  100,
+	}
+	_, err = invoke(db, 10, sender, utils.HtlcContractAddress, LOCK_NAME, lock)
+	assert.NotNil(t, err)
+	lock.Symbol = "USDX"
+	_, err = invoke(db, 10, sender, utils.HtlcContractAddress, LOCK_NAME, lock)
+	assert.Nil(t, err)
+
+	_, err = invoke(db, 50, sender, utils.HtlcContractAddress, REFUND_NAME, &LockIdParam{Sender: sender, HashLock: hashLock[:]})
+	assert.NotNil(t, err)
+	_, err = invoke(db, 100, receiver, utils.HtlcContractAddress, REFUND_NAME, &LockIdParam{Sender: sender, HashLock: hashLock[:]})
+	assert.NotNil(t, err)
+	_, err = invoke(db, 100, sender, utils.HtlcContractAddress, REFUND_NAME, &LockIdParam{Sender: sender, HashLock: hashLock[:]})
+	assert.Nil(t, err)
+	_, err = invoke(db, 50, receiver, utils.HtlcContractAddress, CLAIM_NAME, &ClaimParam{Sender: sender, HashLock: hashLock[:], Preimage: []byte("secret")})
+	assert.NotNil(t, err)
+
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString("USDX")
+	utils.EncodeAddress(sink, sender)
+	result, err := invoke(db, 0, sender, utils.TokenContractAddress, token.BALANCEOF_NAME, testsuite.RawParam(sink.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(100), common.BigIntFromNeoBytes(result))
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package htlc
+
+import (
+	"io"
+	"math"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+)
+
+// LockParam locks the asset of Sender for Receiver under the hash lock until the timeout height.
+// Asset is the contract of ONX, OXG or the token registry, Symbol is only used by the token registry.
+type LockParam struct {
+	Sender   common.Address
+	Receiver common.Address
+	Asset    common.Address
+	Symbol   string
+	Amount   uint64
+	HashType byte
+	HashLock []byte
+	Timeout  uint32
+}
+
+func (this *LockParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.Sender)
+	utils.EncodeAddress(sink, this.Receiver)
+	utils.EncodeAddress(sink, this.Asset)
+	sink.WriteString(this.Symbol)
+	utils.EncodeVarUint(sink, this.Amount)
+	utils.EncodeVarUint(sink, uint64(this.HashType))
+	sink.WriteVarBytes(this.HashLock)
+	utils.EncodeVarUint(sink, uint64(this.Timeout))
+}
+
+func (this *LockParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Sender, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	if this.Receiver, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	if this.Asset, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	if this.Symbol, err = decodeString(source); err != nil {
+		return err
+	}
+	if this.Amount, err = utils.DecodeVarUint(source); err != nil {
+		return err
+	}
+	hashType, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return err
+	}
+	if hashType > math.MaxUint8 {
+		return common.ErrIrregularData
+	}
+	this.HashType = byte(hashType)
+	if this.HashLock, err = decodeBytes(source); err != nil {
+		return err
+	}
+	timeout, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return err
+	}
+	if timeout > math.MaxUint32 {
+		return common.ErrIrregularData
+	}
+	this.Timeout = uint32(timeout)
+	return nil
+}
+
+// Lock is the state of a hash lock, the preimage is kept after claimed for the counterparty of a swap
+type Lock struct {
+	LockParam
+	Height   uint32 // the height of the lock
+	Status   byte
+	Preimage []byte
+}
+
+func (this *Lock) Serialization(sink *common.ZeroCopySink) {
+	this.LockParam.Serialization(sink)
+	utils.EncodeVarUint(sink, uint64(this.Height))
+	utils.EncodeVarUint(sink, uint64(this.Status))
+	sink.WriteVarBytes(this.Preimage)
+}
+
+func (this *Lock) Deserialization(source *common.ZeroCopySource) error {
+	if err := this.LockParam.Deserialization(source); err != nil {
+		return err
+	}
+	height, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return err
+	}
+	if height > math.MaxUint32 {
+		return common.ErrIrregularData
+	}
+	this.Height = uint32(height)
+	status, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return err
+	}
+	if status > math.MaxUint8 {
+		return common.ErrIrregularData
+	}
+	this.Status = byte(status)
+	this.Preimage, err = decodeBytes(source)
+	return err
+}
+
+// LockIdParam identifies a lock by the sender and the hash lock, the same hash lock can be used by different senders
+type LockIdParam struct {
+	Sender   common.Address
+	HashLock []byte
+}
+
+func (this *LockIdParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.Sender)
+	sink.WriteVarBytes(this.HashLock)
+}
+
+func (this *LockIdParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Sender, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	this.HashLock, err = decodeBytes(source)
+	return err
+}
+
+// ClaimParam claims the lock of the sender with the preimage of the hash lock
+type ClaimParam struct {
+	Sender   common.Address
+	HashLock []byte
+	Preimage []byte
+}
+
+func (this *ClaimParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.Sender)
+	sink.WriteVarBytes(this.HashLock)
+	sink.WriteVarBytes(this.Preimage)
+}
+
+func (this *ClaimParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Sender, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	if this.HashLock, err = decodeBytes(source); err != nil {
+		return err
+	}
+	this.Preimage, err = decodeBytes(source)
+	return err
+}
+
+func decodeBytes(source *common.ZeroCopySource) ([]byte, error) {
+	data, _, irregular, eof := source.NextVarBytes()
+	if eof {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if irregular {
+		return nil, common.ErrIrregularData
+	}
+	return data, nil
+}
+
+func decodeString(source *common.ZeroCopySource) (string, error) {
+	data, err := decodeBytes(source)
+	return string(data), err
+}
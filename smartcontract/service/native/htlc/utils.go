@@ -0,0 +1,169 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package htlc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/config"
+	"github.com/OnyxPay/OnyxChain/smartcontract/event"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/onx"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/token"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	"golang.org/x/crypto/ripemd160"
+)
+
+const (
+	//function name
+	LOCK_NAME     = "lock"
+	CLAIM_NAME    = "claim"
+	REFUND_NAME   = "refund"
+	GET_LOCK_NAME = "getLock"
+
+	//key prefix
+	LOCK = "lock"
+
+	//hash type of the hash lock, the same as the neovm opcodes
+	HASH_SHA256  byte = 0
+	HASH_HASH160 byte = 1
+
+	//status of the lock
+	STATUS_LOCKED   byte = 1
+	STATUS_CLAIMED  byte = 2
+	STATUS_REFUNDED byte = 3
+
+	MAX_PREIMAGE_LEN = 256
+)
+
+//genLockKey return the key of the lock of the sender, so a sender can not occupy the hash lock of others
+func genLockKey(sender common.Address, hashLock []byte) []byte {
+	key := append(utils.HtlcContractAddress[:], LOCK...)
+	key = append(key, sender[:]...)
+	return append(key, hashLock...)
+}
+
+//hashOf return the hash of the preimage by the hash type, and the size of the hash
+func hashOf(hashType byte, preimage []byte) ([]byte, error) {
+	switch hashType {
+	case HASH_SHA256:
+		hash := sha256.Sum256(preimage)
+		return hash[:], nil
+	case HASH_HASH160:
+		temp := sha256.Sum256(preimage)
+		md := ripemd160.New()
+		md.Write(temp[:])
+		return md.Sum(nil), nil
+	}
+	return nil, fmt.Errorf("unknown hash type %d", hashType)
+}
+
+//checkHashLock check the hash lock has the size of the hash type
+func checkHashLock(hashType byte, hashLock []byte) error {
+	size := 0
+	switch hashType {
+	case HASH_SHA256:
+		size = sha256.Size
+	case HASH_HASH160:
+		size = ripemd160.Size
+	default:
+		return fmt.Errorf("unknown hash type %d", hashType)
+	}
+	if len(hashLock) != size {
+		return fmt.Errorf("hash lock should be %d bytes", size)
+	}
+	return nil
+}
+
+func matchPreimage(lock *Lock, preimage []byte) bool {
+	hash, err := hashOf(lock.HashType, preimage)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(hash, lock.HashLock)
+}
+
+func getLock(native *native.NativeService, sender common.Address, hashLock []byte) (*Lock, error) {
+	item, err := utils.GetStorageItem(native, genLockKey(sender, hashLock))
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, nil
+	}
+	lock := new(Lock)
+	if err := lock.Deserialization(common.NewZeroCopySource(item.Value)); err != nil {
+		return nil, fmt.Errorf("deserialize lock error:%v", err)
+	}
+	return lock, nil
+}
+
+func putLock(native *native.NativeService, lock *Lock) {
+	sink := common.NewZeroCopySink(nil)
+	lock.Serialization(sink)
+	utils.PutBytes(native, genLockKey(lock.Sender, lock.HashLock), sink.Bytes())
+}
+
+//checkAsset check the asset is onx, oxg or an asset of the token registry
+func checkAsset(asset common.Address, symbol string) error {
+	switch asset {
+	case utils.OnxContractAddress, utils.OxgContractAddress:
+		if symbol != "" {
+			return fmt.Errorf("symbol is only used by the token registry")
+		}
+	case utils.TokenContractAddress:
+		if symbol == "" {
+			return fmt.Errorf("symbol of the token registry should not be empty")
+		}
+	default:
+		return fmt.Errorf("unsupported asset %s", asset.ToHexString())
+	}
+	return nil
+}
+
+//appCallTransfer transfer the asset by the transfer method of the asset contract
+func appCallTransfer(native *native.NativeService, asset common.Address, symbol string, from, to common.Address, amount uint64) error {
+	transfers := onx.Transfers{States: []onx.State{{From: from, To: to, Value: amount}}}
+	sink := common.NewZeroCopySink(nil)
+	if asset == utils.TokenContractAddress {
+		param := &token.TransferParam{Symbol: symbol, Transfers: transfers}
+		param.Serialization(sink)
+	} else {
+		transfers.Serialization(sink)
+	}
+	if _, err := native.NativeCall(asset, onx.TRANSFER_NAME, sink.Bytes()); err != nil {
+		return fmt.Errorf("appCallTransfer, appCall error: %v", err)
+	}
+	return nil
+}
+
+func addNotifications(native *native.NativeService, method string, lock *Lock, states ...interface{}) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.Notifications = append(native.Notifications,
+		&event.NotifyEventInfo{
+			ContractAddress: utils.HtlcContractAddress,
+			States:          append([]interface{}{method, hex.EncodeToString(lock.HashLock)}, states...),
+		})
+}
@@ -24,11 +24,15 @@ import (
 
 	"github.com/OnyxPay/OnyxChain/common"
 	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/auth"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/credential"
 	params "github.com/OnyxPay/OnyxChain/smartcontract/service/native/global_params"
 	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/governance"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/htlc"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/multisig"
 	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/oxg"
 	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/onx"
 	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/onxid"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/token"
 	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
 	"github.com/OnyxPay/OnyxChain/smartcontract/service/neovm"
 	vm "github.com/OnyxPay/OnyxChain/vm/neovm"
@@ -45,6 +49,10 @@ func init() {
 	onxid.Init()
 	auth.Init()
 	governance.InitGovernance()
+	token.InitToken()
+	htlc.InitHtlc()
+	multisig.InitMultisig()
+	credential.InitCredential()
 }
 
 func InitBytes(addr common.Address, method string) []byte {
@@ -0,0 +1,238 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package multisig implements the on-chain multisig wallet. The owners submit, confirm and revoke proposals
+// invoking native or neovm contracts, a proposal is executed with the wallet as the caller once it is confirmed
+// by the threshold of owners. The owners of a wallet are changed by a proposal invoking changeOwners.
+package multisig
+
+import (
+	"fmt"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/errors"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+)
+
+func InitMultisig() {
+	native.Contracts[utils.MultisigContractAddress] = RegisterMultisigContract
+}
+
+func RegisterMultisigContract(native *native.NativeService) {
+	native.Register(CREATE_WALLET_NAME, MultisigCreateWallet)
+	native.Register(SUBMIT_NAME, MultisigSubmit)
+	native.Register(CONFIRM_NAME, MultisigConfirm)
+	native.Register(REVOKE_NAME, MultisigRevoke)
+	native.Register(CHANGE_OWNERS_NAME, MultisigChangeOwners)
+	native.Register(GET_WALLET_NAME, MultisigGetWallet)
+	native.Register(GET_PROPOSAL_NAME, MultisigGetProposal)
+}
+
+//MultisigCreateWallet create a wallet of the owners and return the address of the wallet
+func MultisigCreateWallet(native *native.NativeService) ([]byte, error) {
+	var param CreateWalletParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[CreateWallet] param deserialize error!")
+	}
+	if !native.ContextRef.CheckWitness(param.Creator) {
+		return utils.BYTE_FALSE, errors.NewErr("[CreateWallet] authentication failed!")
+	}
+	countKey := utils.ConcatKey(utils.MultisigContractAddress, []byte(WALLET_COUNT))
+	count, err := utils.GetStorageUInt64(native, countKey)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	wallet := &Wallet{
+		Address:   walletAddress(count),
+		Owners:    param.Owners,
+		Threshold: param.Threshold,
+	}
+	if err := checkOwners(wallet.Address, wallet.Owners, wallet.Threshold); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[CreateWallet] %v", err)
+	}
+	native.CacheDB.Put(countKey, utils.GenUInt64StorageItem(count+1).ToArray())
+	putWallet(native, wallet)
+
+	addNotifications(native, CREATE_WALLET_NAME, wallet.Address.ToBase58(), param.Creator.ToBase58(), ownersOf(wallet), wallet.Threshold)
+	return wallet.Address[:], nil
+}
+
+//MultisigSubmit submit a proposal confirmed by the proposer, the proposal is executed at once if the threshold is 1
+func MultisigSubmit(native *native.NativeService) ([]byte, error) {
+	var param SubmitParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[Submit] param deserialize error!")
+	}
+	if len(param.Method) > MAX_METHOD_LEN {
+		return utils.BYTE_FALSE, fmt.Errorf("[Submit] method should not be longer than %d", MAX_METHOD_LEN)
+	}
+	wallet, err := getWallet(native, param.Wallet)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Submit] %v", err)
+	}
+	if !wallet.IsOwner(param.Proposer) {
+		return utils.BYTE_FALSE, fmt.Errorf("[Submit] %s is not owner of the wallet", param.Proposer.ToBase58())
+	}
+	if !native.ContextRef.CheckWitness(param.Proposer) {
+		return utils.BYTE_FALSE, errors.NewErr("[Submit] authentication failed!")
+	}
+
+	proposal := &Proposal{
+		Id:            wallet.NextId,
+		SubmitParam:   param,
+		Height:        native.Height,
+		Status:        STATUS_PENDING,
+		Confirmations: []common.Address{param.Proposer},
+	}
+	wallet.NextId++
+	putWallet(native, wallet)
+	putProposal(native, proposal)
+	addProposalNotifications(native, SUBMIT_NAME, proposal, param.Proposer.ToBase58(), param.Target.ToHexString(), param.Method)
+	if err := tryExecute(native, wallet, proposal); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Submit] %v", err)
+	}
+	sink := common.NewZeroCopySink(nil)
+	utils.EncodeVarUint(sink, proposal.Id)
+	return sink.Bytes(), nil
+}
+
+//MultisigConfirm confirm a pending proposal by an owner, the proposal is executed once reached the threshold
+func MultisigConfirm(native *native.NativeService) ([]byte, error) {
+	var param ConfirmParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[Confirm] param deserialize error!")
+	}
+	wallet, proposal, err := getPendingProposal(native, &param)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Confirm] %v", err)
+	}
+	if proposal.IsConfirmedBy(param.Owner) {
+		return utils.BYTE_FALSE, fmt.Errorf("[Confirm] proposal has been confirmed by %s", param.Owner.ToBase58())
+	}
+
+	proposal.Confirmations = append(proposal.Confirmations, param.Owner)
+	putProposal(native, proposal)
+	addProposalNotifications(native, CONFIRM_NAME, proposal, param.Owner.ToBase58())
+	if err := tryExecute(native, wallet, proposal); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Confirm] %v", err)
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+//MultisigRevoke revoke the confirmation of a pending proposal by an owner
+func MultisigRevoke(native *native.NativeService) ([]byte, error) {
+	var param ConfirmParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[Revoke] param deserialize error!")
+	}
+	_, proposal, err := getPendingProposal(native, &param)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Revoke] %v", err)
+	}
+	if !proposal.IsConfirmedBy(param.Owner) {
+		return utils.BYTE_FALSE, fmt.Errorf("[Revoke] proposal has not been confirmed by %s", param.Owner.ToBase58())
+	}
+
+	confirmations := make([]common.Address, 0, len(proposal.Confirmations))
+	for _, addr := range proposal.Confirmations {
+		if addr != param.Owner {
+			confirmations = append(confirmations, addr)
+		}
+	}
+	proposal.Confirmations = confirmations
+	putProposal(native, proposal)
+	addProposalNotifications(native, REVOKE_NAME, proposal, param.Owner.ToBase58())
+	return utils.BYTE_TRUE, nil
+}
+
+//MultisigChangeOwners replace the owners and threshold of the wallet, it can only be invoked by a proposal of the wallet
+func MultisigChangeOwners(native *native.NativeService) ([]byte, error) {
+	var param ChangeOwnersParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[ChangeOwners] param deserialize error!")
+	}
+	wallet, err := getWallet(native, param.Wallet)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[ChangeOwners] %v", err)
+	}
+	if !native.ContextRef.CheckWitness(wallet.Address) {
+		return utils.BYTE_FALSE, errors.NewErr("[ChangeOwners] authentication failed!")
+	}
+	if err := checkOwners(wallet.Address, param.Owners, param.Threshold); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[ChangeOwners] %v", err)
+	}
+	wallet.Owners = param.Owners
+	wallet.Threshold = param.Threshold
+	putWallet(native, wallet)
+
+	addNotifications(native, CHANGE_OWNERS_NAME, wallet.Address.ToBase58(), ownersOf(wallet), wallet.Threshold)
+	return utils.BYTE_TRUE, nil
+}
+
+//MultisigGetWallet return the serialized wallet of the address
+func MultisigGetWallet(native *native.NativeService) ([]byte, error) {
+	addr, err := utils.DecodeAddress(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[GetWallet] param deserialize error!")
+	}
+	wallet, err := getWallet(native, addr)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetWallet] %v", err)
+	}
+	sink := common.NewZeroCopySink(nil)
+	wallet.Serialization(sink)
+	return sink.Bytes(), nil
+}
+
+//MultisigGetProposal return the serialized proposal of the wallet
+func MultisigGetProposal(native *native.NativeService) ([]byte, error) {
+	var param ProposalParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[GetProposal] param deserialize error!")
+	}
+	proposal, err := getProposal(native, param.Wallet, param.Id)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetProposal] %v", err)
+	}
+	sink := common.NewZeroCopySink(nil)
+	proposal.Serialization(sink)
+	return sink.Bytes(), nil
+}
+
+//getPendingProposal return the wallet and the pending proposal after checking the witness of the owner
+func getPendingProposal(native *native.NativeService, param *ConfirmParam) (*Wallet, *Proposal, error) {
+	wallet, err := getWallet(native, param.Wallet)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !wallet.IsOwner(param.Owner) {
+		return nil, nil, fmt.Errorf("%s is not owner of the wallet", param.Owner.ToBase58())
+	}
+	if !native.ContextRef.CheckWitness(param.Owner) {
+		return nil, nil, errors.NewErr("authentication failed!")
+	}
+	proposal, err := getProposal(native, param.Wallet, param.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if proposal.Status != STATUS_PENDING {
+		return nil, nil, fmt.Errorf("proposal %d is not pending", param.Id)
+	}
+	return wallet, proposal, nil
+}
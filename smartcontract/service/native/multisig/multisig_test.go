@@ -0,0 +1,202 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package multisig
+
+import (
+	"testing"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/payload"
+	"github.com/OnyxPay/OnyxChain/core/store/overlaydb"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/onx"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/testsuite"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/neovm"
+	"github.com/OnyxPay/OnyxChain/smartcontract/storage"
+	vm "github.com/OnyxPay/OnyxChain/vm/neovm"
+	vmtypes "github.com/OnyxPay/OnyxChain/vm/neovm/types"
+	"github.com/stretchr/testify/assert"
+)
+
+//invoke call the contract in a transaction signed by the signer, the changes are committed only if the call succeeds
+func invoke(db *overlaydb.OverlayDB, signer common.Address, contract common.Address, method string, p testsuite.Param) ([]byte, error) {
+	return testsuite.Invoke(db, &testsuite.Tx{Signer: signer}, contract, method, p)
+}
+
+func serialize(p testsuite.Param) []byte {
+	sink := common.NewZeroCopySink(nil)
+	p.Serialization(sink)
+	return sink.Bytes()
+}
+
+func getWalletOf(t *testing.T, db *overlaydb.OverlayDB, addr common.Address) *Wallet {
+	result, err := invoke(db, addr, utils.MultisigContractAddress, GET_WALLET_NAME, testsuite.AddressParam(addr))
+	assert.Nil(t, err)
+	wallet := new(Wallet)
+	assert.Nil(t, wallet.Deserialization(common.NewZeroCopySource(result)))
+	return wallet
+}
+
+func getProposalOf(t *testing.T, db *overlaydb.OverlayDB, wallet common.Address, id uint64) *Proposal {
+	result, err := invoke(db, wallet, utils.MultisigContractAddress, GET_PROPOSAL_NAME, &ProposalParam{Wallet: wallet, Id: id})
+	assert.Nil(t, err)
+	proposal := new(Proposal)
+	assert.Nil(t, proposal.Deserialization(common.NewZeroCopySource(result)))
+	return proposal
+}
+
+func newWallet(t *testing.T, db *overlaydb.OverlayDB, owners []common.Address, threshold uint32) common.Address {
+	param := &CreateWalletParam{Creator: owners[0], Owners: owners, Threshold: threshold}
+	_, err := invoke(db, common.Address{9}, utils.MultisigContractAddress, CREATE_WALLET_NAME, param)
+	assert.NotNil(t, err)
+	result, err := invoke(db, owners[0], utils.MultisigContractAddress, CREATE_WALLET_NAME, param)
+	assert.Nil(t, err)
+	addr, err := common.AddressParseFromBytes(result)
+	assert.Nil(t, err)
+	return addr
+}
+
+func TestMultisigTransfer(t *testing.T) {
+	onx.InitOnx()
+	InitMultisig()
+	db := testsuite.NewDB()
+
+	a, b, c, d := common.Address{1}, common.Address{2}, common.Address{3}, common.Address{4}
+	_, err := invoke(db, a, utils.MultisigContractAddress, CREATE_WALLET_NAME,
+		&CreateWalletParam{Creator: a, Owners: []common.Address{a, b}, Threshold: 3})
+	assert.NotNil(t, err)
+	_, err = invoke(db, a, utils.MultisigContractAddress, CREATE_WALLET_NAME,
+		&CreateWalletParam{Creator: a, Owners: []common.Address{a, a}, Threshold: 1})
+	assert.NotNil(t, err)
+	wallet := newWallet(t, db, []common.Address{a, b, c}, 2)
+	assert.NotEqual(t, wallet, newWallet(t, db, []common.Address{a, b, c}, 2))
+	testsuite.Put(db, onx.GenBalanceKey(utils.OnxContractAddress, wallet), utils.GenUInt64StorageItem(100).ToArray())
+
+	transfers := &onx.Transfers{States: []onx.State{{From: wallet, To: d, Value: 40}}}
+	submit := &SubmitParam{Wallet: wallet, Proposer: a, Target: utils.OnxContractAddress, Method: onx.TRANSFER_NAME, Args: serialize(transfers)}
+	_, err = invoke(db, d, utils.MultisigContractAddress, SUBMIT_NAME, &SubmitParam{Wallet: wallet, Proposer: d,
+		Target: utils.OnxContractAddress, Method: onx.TRANSFER_NAME, Args: serialize(transfers)})
+	assert.NotNil(t, err)
+	_, err = invoke(db, b, utils.MultisigContractAddress, SUBMIT_NAME, submit)
+	assert.NotNil(t, err)
+	result, err := invoke(db, a, utils.MultisigContractAddress, SUBMIT_NAME, submit)
+	assert.Nil(t, err)
+	id, err := utils.DecodeVarUint(common.NewZeroCopySource(result))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), id)
+	assert.Equal(t, uint64(100), testsuite.BalanceOf(t, db, utils.OnxContractAddress, wallet))
+
+	// revoke and confirm again
+	_, err = invoke(db, b, utils.MultisigContractAddress, REVOKE_NAME, &ConfirmParam{Wallet: wallet, Id: id, Owner: b})
+	assert.NotNil(t, err)
+	_, err = invoke(db, a, utils.MultisigContractAddress, REVOKE_NAME, &ConfirmParam{Wallet: wallet, Id: id, Owner: a})
+	assert.Nil(t, err)
+	_, err = invoke(db, b, utils.MultisigContractAddress, CONFIRM_NAME, &ConfirmParam{Wallet: wallet, Id: id, Owner: b})
+	assert.Nil(t, err)
+	assert.Equal(t, STATUS_PENDING, getProposalOf(t, db, wallet, id).Status)
+	_, err = invoke(db, b, utils.MultisigContractAddress, CONFIRM_NAME, &ConfirmParam{Wallet: wallet, Id: id, Owner: b})
+	assert.NotNil(t, err)
+	_, err = invoke(db, b, utils.MultisigContractAddress, CONFIRM_NAME, &ConfirmParam{Wallet: wallet, Id: id, Owner: c})
+	assert.NotNil(t, err)
+
+	_, err = invoke(db, c, utils.MultisigContractAddress, CONFIRM_NAME, &ConfirmParam{Wallet: wallet, Id: id, Owner: c})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(60), testsuite.BalanceOf(t, db, utils.OnxContractAddress, wallet))
+	assert.Equal(t, uint64(40), testsuite.BalanceOf(t, db, utils.OnxContractAddress, d))
+	proposal := getProposalOf(t, db, wallet, id)
+	assert.Equal(t, STATUS_EXECUTED, proposal.Status)
+	assert.Equal(t, []common.Address{b, c}, proposal.Confirmations)
+	_, err = invoke(db, a, utils.MultisigContractAddress, CONFIRM_NAME, &ConfirmParam{Wallet: wallet, Id: id, Owner: a})
+	assert.NotNil(t, err)
+
+	// the failed execution keeps the proposal pending
+	transfers.States[0].Value = 1000
+	submit.Args = serialize(transfers)
+	result, err = invoke(db, a, utils.MultisigContractAddress, SUBMIT_NAME, submit)
+	assert.Nil(t, err)
+	id, _ = utils.DecodeVarUint(common.NewZeroCopySource(result))
+	assert.Equal(t, uint64(1), id)
+	_, err = invoke(db, b, utils.MultisigContractAddress, CONFIRM_NAME, &ConfirmParam{Wallet: wallet, Id: id, Owner: b})
+	assert.NotNil(t, err)
+	assert.Equal(t, STATUS_PENDING, getProposalOf(t, db, wallet, id).Status)
+}
+
+func TestMultisigChangeOwners(t *testing.T) {
+	InitMultisig()
+	db := testsuite.NewDB()
+
+	a, b, c := common.Address{1}, common.Address{2}, common.Address{3}
+	wallet := newWallet(t, db, []common.Address{a, b, c}, 2)
+	change := &ChangeOwnersParam{Wallet: wallet, Owners: []common.Address{a, b}, Threshold: 1}
+	_, err := invoke(db, a, utils.MultisigContractAddress, CHANGE_OWNERS_NAME, change)
+	assert.NotNil(t, err)
+
+	// c confirms a pending proposal before removed
+	submit := &SubmitParam{Wallet: wallet, Proposer: c, Target: utils.MultisigContractAddress, Method: CHANGE_OWNERS_NAME,
+		Args: serialize(&ChangeOwnersParam{Wallet: wallet, Owners: []common.Address{c}, Threshold: 1})}
+	_, err = invoke(db, c, utils.MultisigContractAddress, SUBMIT_NAME, submit)
+	assert.Nil(t, err)
+
+	submit = &SubmitParam{Wallet: wallet, Proposer: a, Target: utils.MultisigContractAddress, Method: CHANGE_OWNERS_NAME,
+		Args: serialize(change)}
+	_, err = invoke(db, a, utils.MultisigContractAddress, SUBMIT_NAME, submit)
+	assert.Nil(t, err)
+	_, err = invoke(db, b, utils.MultisigContractAddress, CONFIRM_NAME, &ConfirmParam{Wallet: wallet, Id: 1, Owner: b})
+	assert.Nil(t, err)
+	w := getWalletOf(t, db, wallet)
+	assert.Equal(t, []common.Address{a, b}, w.Owners)
+	assert.Equal(t, uint32(1), w.Threshold)
+	assert.Equal(t, uint64(2), w.NextId)
+
+	_, err = invoke(db, c, utils.MultisigContractAddress, CONFIRM_NAME, &ConfirmParam{Wallet: wallet, Id: 0, Owner: c})
+	assert.NotNil(t, err)
+	assert.Equal(t, STATUS_PENDING, getProposalOf(t, db, wallet, 0).Status)
+}
+
+func TestMultisigNeoVm(t *testing.T) {
+	InitMultisig()
+	db := testsuite.NewDB()
+
+	// the contract throws if the param is not witnessed
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteByte(byte(vm.SYSCALL))
+	sink.WriteString(neovm.RUNTIME_CHECKWITNESS_NAME)
+	sink.WriteByte(byte(vm.THROWIFNOT))
+	contract := &payload.DeployCode{Code: sink.Bytes()}
+	cache := storage.NewCacheDB(db)
+	assert.Nil(t, cache.PutContract(contract))
+	cache.Commit()
+
+	a := common.Address{1}
+	wallet := newWallet(t, db, []common.Address{a}, 1)
+	for _, addr := range []common.Address{{5}, wallet} {
+		args, err := neovm.SerializeStackItem(vmtypes.NewArray([]vmtypes.StackItems{vmtypes.NewByteArray(addr[:])}))
+		assert.Nil(t, err)
+		submit := &SubmitParam{Wallet: wallet, Proposer: a, Target: contract.Address(), Args: args}
+		_, err = invoke(db, common.Address{9}, utils.MultisigContractAddress, SUBMIT_NAME, submit)
+		assert.NotNil(t, err)
+		_, err = invoke(db, a, utils.MultisigContractAddress, SUBMIT_NAME, submit)
+		if addr == wallet {
+			assert.Nil(t, err)
+		} else {
+			assert.NotNil(t, err)
+		}
+	}
+	assert.Equal(t, uint64(1), getWalletOf(t, db, wallet).NextId)
+}
@@ -0,0 +1,302 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package multisig
+
+import (
+	"io"
+	"math"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+)
+
+// Wallet is a multisig wallet, the address has no private key and is only witnessed by the executed proposals
+type Wallet struct {
+	Address   common.Address
+	Owners    []common.Address
+	Threshold uint32
+	NextId    uint64 // the id of the next proposal
+}
+
+func (this *Wallet) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.Address)
+	encodeAddresses(sink, this.Owners)
+	utils.EncodeVarUint(sink, uint64(this.Threshold))
+	utils.EncodeVarUint(sink, this.NextId)
+}
+
+func (this *Wallet) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Address, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	if this.Owners, err = decodeAddresses(source); err != nil {
+		return err
+	}
+	if this.Threshold, err = decodeUint32(source); err != nil {
+		return err
+	}
+	this.NextId, err = utils.DecodeVarUint(source)
+	return err
+}
+
+// IsOwner check whether the address is an owner of the wallet
+func (this *Wallet) IsOwner(addr common.Address) bool {
+	for _, owner := range this.Owners {
+		if owner == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateWalletParam creates a wallet of the owners, Creator pays for nothing but should sign the transaction
+type CreateWalletParam struct {
+	Creator   common.Address
+	Owners    []common.Address
+	Threshold uint32
+}
+
+func (this *CreateWalletParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.Creator)
+	encodeAddresses(sink, this.Owners)
+	utils.EncodeVarUint(sink, uint64(this.Threshold))
+}
+
+func (this *CreateWalletParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Creator, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	if this.Owners, err = decodeAddresses(source); err != nil {
+		return err
+	}
+	this.Threshold, err = decodeUint32(source)
+	return err
+}
+
+// ChangeOwnersParam replaces the owners and threshold of the wallet, it is only invoked by a proposal of the wallet
+type ChangeOwnersParam struct {
+	Wallet    common.Address
+	Owners    []common.Address
+	Threshold uint32
+}
+
+func (this *ChangeOwnersParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.Wallet)
+	encodeAddresses(sink, this.Owners)
+	utils.EncodeVarUint(sink, uint64(this.Threshold))
+}
+
+func (this *ChangeOwnersParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Wallet, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	if this.Owners, err = decodeAddresses(source); err != nil {
+		return err
+	}
+	this.Threshold, err = decodeUint32(source)
+	return err
+}
+
+// SubmitParam submits a proposal invoking Target with the wallet as the caller.
+// For a native contract Args is the input of Method, for a neovm contract Method is empty
+// and Args is the serialized stack item array of the params.
+type SubmitParam struct {
+	Wallet   common.Address
+	Proposer common.Address
+	Target   common.Address
+	Method   string
+	Args     []byte
+}
+
+func (this *SubmitParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.Wallet)
+	utils.EncodeAddress(sink, this.Proposer)
+	utils.EncodeAddress(sink, this.Target)
+	sink.WriteString(this.Method)
+	sink.WriteVarBytes(this.Args)
+}
+
+func (this *SubmitParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Wallet, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	if this.Proposer, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	if this.Target, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	if this.Method, err = decodeString(source); err != nil {
+		return err
+	}
+	this.Args, err = decodeBytes(source)
+	return err
+}
+
+// ConfirmParam is the param of confirm and revoke
+type ConfirmParam struct {
+	Wallet common.Address
+	Id     uint64
+	Owner  common.Address
+}
+
+func (this *ConfirmParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.Wallet)
+	utils.EncodeVarUint(sink, this.Id)
+	utils.EncodeAddress(sink, this.Owner)
+}
+
+func (this *ConfirmParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Wallet, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	if this.Id, err = utils.DecodeVarUint(source); err != nil {
+		return err
+	}
+	this.Owner, err = utils.DecodeAddress(source)
+	return err
+}
+
+// ProposalParam identifies a proposal of the wallet
+type ProposalParam struct {
+	Wallet common.Address
+	Id     uint64
+}
+
+func (this *ProposalParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.Wallet)
+	utils.EncodeVarUint(sink, this.Id)
+}
+
+func (this *ProposalParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Wallet, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	this.Id, err = utils.DecodeVarUint(source)
+	return err
+}
+
+// Proposal is a submitted invocation of the wallet, it is executed once confirmed by the threshold of owners
+type Proposal struct {
+	SubmitParam
+	Id            uint64
+	Height        uint32 // the height of the submission
+	Status        byte
+	Confirmations []common.Address
+}
+
+func (this *Proposal) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarUint(sink, this.Id)
+	this.SubmitParam.Serialization(sink)
+	utils.EncodeVarUint(sink, uint64(this.Height))
+	utils.EncodeVarUint(sink, uint64(this.Status))
+	encodeAddresses(sink, this.Confirmations)
+}
+
+func (this *Proposal) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Id, err = utils.DecodeVarUint(source); err != nil {
+		return err
+	}
+	if err := this.SubmitParam.Deserialization(source); err != nil {
+		return err
+	}
+	if this.Height, err = decodeUint32(source); err != nil {
+		return err
+	}
+	status, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return err
+	}
+	if status > math.MaxUint8 {
+		return common.ErrIrregularData
+	}
+	this.Status = byte(status)
+	this.Confirmations, err = decodeAddresses(source)
+	return err
+}
+
+// IsConfirmedBy check whether the address has confirmed the proposal
+func (this *Proposal) IsConfirmedBy(addr common.Address) bool {
+	for _, v := range this.Confirmations {
+		if v == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeAddresses(sink *common.ZeroCopySink, addrs []common.Address) {
+	utils.EncodeVarUint(sink, uint64(len(addrs)))
+	for _, addr := range addrs {
+		utils.EncodeAddress(sink, addr)
+	}
+}
+
+func decodeAddresses(source *common.ZeroCopySource) ([]common.Address, error) {
+	n, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(source.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	addrs := make([]common.Address, 0, n)
+	for i := uint64(0); i < n; i++ {
+		addr, err := utils.DecodeAddress(source)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+func decodeUint32(source *common.ZeroCopySource) (uint32, error) {
+	value, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return 0, err
+	}
+	if value > math.MaxUint32 {
+		return 0, common.ErrIrregularData
+	}
+	return uint32(value), nil
+}
+
+func decodeBytes(source *common.ZeroCopySource) ([]byte, error) {
+	data, _, irregular, eof := source.NextVarBytes()
+	if eof {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if irregular {
+		return nil, common.ErrIrregularData
+	}
+	return data, nil
+}
+
+func decodeString(source *common.ZeroCopySource) (string, error) {
+	data, err := decodeBytes(source)
+	return string(data), err
+}
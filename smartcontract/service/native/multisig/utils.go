@@ -0,0 +1,253 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package multisig
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/config"
+	"github.com/OnyxPay/OnyxChain/core/payload"
+	"github.com/OnyxPay/OnyxChain/smartcontract/context"
+	"github.com/OnyxPay/OnyxChain/smartcontract/event"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/neovm"
+)
+
+const (
+	//function name
+	CREATE_WALLET_NAME = "createWallet"
+	SUBMIT_NAME        = "submit"
+	CONFIRM_NAME       = "confirm"
+	REVOKE_NAME        = "revoke"
+	CHANGE_OWNERS_NAME = "changeOwners"
+	GET_WALLET_NAME    = "getWallet"
+	GET_PROPOSAL_NAME  = "getProposal"
+
+	//event name
+	EXECUTE_NAME = "execute"
+
+	//key prefix
+	WALLET_COUNT = "walletCount"
+	WALLET       = "wallet"
+	PROPOSAL     = "proposal"
+
+	//status of the proposal
+	STATUS_PENDING  byte = 1
+	STATUS_EXECUTED byte = 2
+
+	MAX_OWNERS     = 32
+	MAX_METHOD_LEN = 1024
+)
+
+//walletAddress derive the address of the wallet from the wallet count, the address is hashed in a different
+//way from the address of vm code, so that no contract can be deployed to the address of a wallet
+func walletAddress(count uint64) common.Address {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteBytes(utils.MultisigContractAddress[:])
+	sink.WriteString(WALLET)
+	sink.WriteUint64(count)
+	temp := sha256.Sum256(sink.Bytes())
+	hash := sha256.Sum256(temp[:])
+	var addr common.Address
+	copy(addr[:], hash[:common.ADDR_LEN])
+	return addr
+}
+
+func genWalletKey(wallet common.Address) []byte {
+	return utils.ConcatKey(utils.MultisigContractAddress, []byte(WALLET), wallet[:])
+}
+
+func genProposalKey(wallet common.Address, id uint64) []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint64(id)
+	return utils.ConcatKey(utils.MultisigContractAddress, []byte(PROPOSAL), wallet[:], sink.Bytes())
+}
+
+//checkOwners check the owners are unique and the threshold is between 1 and the number of owners
+func checkOwners(wallet common.Address, owners []common.Address, threshold uint32) error {
+	if len(owners) == 0 || len(owners) > MAX_OWNERS {
+		return fmt.Errorf("number of owners should be between 1 and %d", MAX_OWNERS)
+	}
+	if threshold == 0 || int(threshold) > len(owners) {
+		return fmt.Errorf("threshold should be between 1 and the number of owners %d", len(owners))
+	}
+	seen := make(map[common.Address]bool, len(owners))
+	for _, owner := range owners {
+		if owner == common.ADDRESS_EMPTY || owner == wallet {
+			return fmt.Errorf("invalid owner %s", owner.ToBase58())
+		}
+		if seen[owner] {
+			return fmt.Errorf("duplicated owner %s", owner.ToBase58())
+		}
+		seen[owner] = true
+	}
+	return nil
+}
+
+func getWallet(native *native.NativeService, addr common.Address) (*Wallet, error) {
+	item, err := utils.GetStorageItem(native, genWalletKey(addr))
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, fmt.Errorf("wallet %s not exist", addr.ToBase58())
+	}
+	wallet := new(Wallet)
+	if err := wallet.Deserialization(common.NewZeroCopySource(item.Value)); err != nil {
+		return nil, fmt.Errorf("deserialize wallet error:%v", err)
+	}
+	return wallet, nil
+}
+
+func putWallet(native *native.NativeService, wallet *Wallet) {
+	sink := common.NewZeroCopySink(nil)
+	wallet.Serialization(sink)
+	utils.PutBytes(native, genWalletKey(wallet.Address), sink.Bytes())
+}
+
+func getProposal(native *native.NativeService, wallet common.Address, id uint64) (*Proposal, error) {
+	item, err := utils.GetStorageItem(native, genProposalKey(wallet, id))
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, fmt.Errorf("proposal %d of wallet %s not exist", id, wallet.ToBase58())
+	}
+	proposal := new(Proposal)
+	if err := proposal.Deserialization(common.NewZeroCopySource(item.Value)); err != nil {
+		return nil, fmt.Errorf("deserialize proposal error:%v", err)
+	}
+	return proposal, nil
+}
+
+func putProposal(native *native.NativeService, proposal *Proposal) {
+	sink := common.NewZeroCopySink(nil)
+	proposal.Serialization(sink)
+	utils.PutBytes(native, genProposalKey(proposal.Wallet, proposal.Id), sink.Bytes())
+}
+
+//confirmations count the confirmations of the current owners, the confirmations of removed owners are ignored
+func confirmations(wallet *Wallet, proposal *Proposal) uint32 {
+	var count uint32
+	for _, addr := range proposal.Confirmations {
+		if wallet.IsOwner(addr) {
+			count++
+		}
+	}
+	return count
+}
+
+//tryExecute execute the proposal if it is confirmed by the threshold of owners. The proposal is stored as executed
+//before the invocation, a failed invocation fails the transaction so the proposal can be executed by a later confirmation
+func tryExecute(native *native.NativeService, wallet *Wallet, proposal *Proposal) error {
+	if confirmations(wallet, proposal) < wallet.Threshold {
+		return nil
+	}
+	proposal.Status = STATUS_EXECUTED
+	putProposal(native, proposal)
+	if err := execute(native, wallet.Address, proposal); err != nil {
+		return fmt.Errorf("execute proposal %d error:%v", proposal.Id, err)
+	}
+	addProposalNotifications(native, EXECUTE_NAME, proposal)
+	return nil
+}
+
+//execute call the target of the proposal with the wallet as the calling context, so the target can check the
+//witness of the wallet
+func execute(native *native.NativeService, wallet common.Address, proposal *Proposal) error {
+	native.ContextRef.PushContext(&context.Context{ContractAddress: wallet})
+	if isNativeContract(proposal.Target) {
+		if _, err := native.NativeCall(proposal.Target, proposal.Method, proposal.Args); err != nil {
+			return err
+		}
+	} else if err := invokeNeoVm(native, proposal); err != nil {
+		return err
+	}
+	native.ContextRef.PopContext()
+	return nil
+}
+
+func isNativeContract(addr common.Address) bool {
+	_, ok := native.Contracts[addr]
+	return ok
+}
+
+//invokeNeoVm call the deployed neovm contract like APPCALL, the params are pushed in reverse order
+func invokeNeoVm(native *native.NativeService, proposal *Proposal) error {
+	if proposal.Method != "" {
+		return fmt.Errorf("method should be empty for neovm contract, the params are in args")
+	}
+	dep, err := native.CacheDB.GetContract(proposal.Target)
+	if err != nil {
+		return fmt.Errorf("get contract %s error:%v", proposal.Target.ToHexString(), err)
+	}
+	if dep == nil {
+		return fmt.Errorf("contract %s not exist", proposal.Target.ToHexString())
+	}
+	if dep.VmType != payload.NEOVM_TYPE {
+		return fmt.Errorf("contract %s is not neovm contract", proposal.Target.ToHexString())
+	}
+	item, err := neovm.DeserializeStackItem(bytes.NewReader(proposal.Args))
+	if err != nil {
+		return fmt.Errorf("deserialize args error:%v", err)
+	}
+	params, err := item.GetArray()
+	if err != nil {
+		return fmt.Errorf("args should be array:%v", err)
+	}
+	engine, err := native.ContextRef.NewExecuteEngine(dep.Code)
+	if err != nil {
+		return err
+	}
+	service := engine.(*neovm.NeoVmService)
+	for i := len(params) - 1; i >= 0; i-- {
+		service.Engine.EvaluationStack.Push(params[i])
+	}
+	_, err = service.Invoke()
+	return err
+}
+
+func addNotifications(native *native.NativeService, states ...interface{}) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.Notifications = append(native.Notifications,
+		&event.NotifyEventInfo{
+			ContractAddress: utils.MultisigContractAddress,
+			States:          states,
+		})
+}
+
+//addProposalNotifications notify the method with the wallet and id of the proposal followed by the states
+func addProposalNotifications(native *native.NativeService, method string, proposal *Proposal, states ...interface{}) {
+	addNotifications(native, append([]interface{}{method, proposal.Wallet.ToBase58(), proposal.Id}, states...)...)
+}
+
+//ownersOf return the owners in base58 for notifications
+func ownersOf(wallet *Wallet) []interface{} {
+	owners := make([]interface{}, 0, len(wallet.Owners))
+	for _, owner := range wallet.Owners {
+		owners = append(owners, owner.ToBase58())
+	}
+	return owners
+}
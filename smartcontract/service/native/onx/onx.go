@@ -44,12 +44,16 @@ func RegisterOnxContract(native *native.NativeService) {
 	native.Register(TRANSFER_NAME, OnxTransfer)
 	native.Register(APPROVE_NAME, OnxApprove)
 	native.Register(TRANSFERFROM_NAME, OnxTransferFrom)
+	native.Register(TRANSFERMULTI_NAME, OnxTransferMulti)
 	native.Register(NAME_NAME, OnxName)
 	native.Register(SYMBOL_NAME, OnxSymbol)
 	native.Register(DECIMALS_NAME, OnxDecimals)
 	native.Register(TOTALSUPPLY_NAME, OnxTotalSupply)
 	native.Register(BALANCEOF_NAME, OnxBalanceOf)
 	native.Register(ALLOWANCE_NAME, OnxAllowance)
+	native.Register(CREATE_VESTING_NAME, OnxCreateVesting)
+	native.Register(CLAIM_VESTING_NAME, OnxClaimVesting)
+	native.Register(GET_VESTING_NAME, OnxGetVesting)
 }
 
 func OnxInit(native *native.NativeService) ([]byte, error) {
@@ -141,6 +145,39 @@ func OnxTransfer(native *native.NativeService) ([]byte, error) {
 	return utils.BYTE_TRUE, nil
 }
 
+func OnxTransferMulti(native *native.NativeService) ([]byte, error) {
+	var param TransferMulti
+	source := common.NewZeroCopySource(native.Input)
+	if err := param.Deserialization(source); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[OnxTransferMulti] TransferMulti deserialize error!")
+	}
+	if err := CheckTransferMulti(native, &param, constants.ONX_TOTAL_SUPPLY); err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	for i := range param.Entries {
+		entry := &param.Entries[i]
+		if entry.Value == 0 {
+			continue
+		}
+		fromBalance, toBalance, err := TransferLeg(native, contract, param.From, entry)
+		if err != nil {
+			return utils.BYTE_FALSE, err
+		}
+
+		if err := grantOxg(native, contract, param.From, fromBalance); err != nil {
+			return utils.BYTE_FALSE, err
+		}
+
+		if err := grantOxg(native, contract, entry.To, toBalance); err != nil {
+			return utils.BYTE_FALSE, err
+		}
+
+		AddMemoNotifications(native, contract, param.From, entry)
+	}
+	return utils.BYTE_TRUE, nil
+}
+
 func OnxTransferFrom(native *native.NativeService) ([]byte, error) {
 	var state TransferFrom
 	source := common.NewZeroCopySource(native.Input)
@@ -255,6 +292,12 @@ func grantOxg(native *native.NativeService, contract, address common.Address, ba
 		return nil
 	}
 
+	//the onx locked in vesting schedules is still bound to the address
+	locked, err := getLockedBalance(native, contract, address)
+	if err != nil {
+		return err
+	}
+	balance += locked
 	if balance != 0 {
 		value := utils.CalcUnbindOxg(balance, startOffset, endOffset)
 
@@ -0,0 +1,150 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package onx
+
+import (
+	"testing"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/store/overlaydb"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/testsuite"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+//invoke call the onx contract in a transaction signed by the signer
+func invoke(db *overlaydb.OverlayDB, signer common.Address, method string, p testsuite.Param) ([]byte, error) {
+	return invokeAt(db, 0, signer, method, p)
+}
+
+//invokeAt call the onx contract at the block height
+func invokeAt(db *overlaydb.OverlayDB, height uint32, signer common.Address, method string, p testsuite.Param) ([]byte, error) {
+	return testsuite.Invoke(db, &testsuite.Tx{Signer: signer, Height: height}, utils.OnxContractAddress, method, p)
+}
+
+func balanceOf(t *testing.T, db *overlaydb.OverlayDB, addr common.Address) uint64 {
+	return testsuite.BalanceOf(t, db, utils.OnxContractAddress, addr)
+}
+
+func TestOnxTransferMulti(t *testing.T) {
+	InitOnx()
+	db := testsuite.NewDB()
+
+	payer := common.Address{1}
+	alice := common.Address{2}
+	bob := common.Address{3}
+	testsuite.Put(db, GenBalanceKey(utils.OnxContractAddress, payer), utils.GenUInt64StorageItem(100).ToArray())
+
+	param := &TransferMulti{
+		From: payer,
+		Entries: []TransferEntry{
+			{To: alice, Value: 30, Memo: "salary alice"},
+			{To: bob, Value: 50, Memo: "salary bob"},
+			{To: alice, Value: 5, Memo: "bonus alice"},
+		},
+	}
+
+	_, err := invoke(db, alice, TRANSFERMULTI_NAME, param)
+	assert.NotNil(t, err)
+	_, err = invoke(db, payer, TRANSFERMULTI_NAME, param)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(15), balanceOf(t, db, payer))
+	assert.Equal(t, uint64(35), balanceOf(t, db, alice))
+	assert.Equal(t, uint64(50), balanceOf(t, db, bob))
+
+	_, err = invoke(db, payer, TRANSFERMULTI_NAME, param)
+	assert.NotNil(t, err)
+
+	_, err = invoke(db, payer, TRANSFERMULTI_NAME, &TransferMulti{From: payer})
+	assert.NotNil(t, err)
+}
+
+func TestVestingSchedule_Vested(t *testing.T) {
+	schedule := &VestingSchedule{Start: 1000, Cliff: 100, Duration: 400, Amount: 1000}
+	assert.Equal(t, uint64(0), schedule.Vested(0, 999))
+	assert.Equal(t, uint64(0), schedule.Vested(0, 1099))
+	assert.Equal(t, uint64(250), schedule.Vested(0, 1100))
+	assert.Equal(t, uint64(500), schedule.Vested(0, 1200))
+	assert.Equal(t, uint64(1000), schedule.Vested(0, 1400))
+	assert.Equal(t, uint64(1000), schedule.Vested(0, 5000))
+
+	lock := &VestingSchedule{HeightBased: true, Start: 50, Amount: 10}
+	assert.Equal(t, uint64(0), lock.Vested(49, 5000))
+	assert.Equal(t, uint64(10), lock.Vested(50, 0))
+}
+
+func TestOnxVesting(t *testing.T) {
+	InitOnx()
+	db := testsuite.NewDB()
+
+	investor := common.Address{1}
+	beneficiary := common.Address{2}
+	testsuite.Put(db, GenBalanceKey(utils.OnxContractAddress, investor), utils.GenUInt64StorageItem(1000).ToArray())
+
+	create := &CreateVestingParam{
+		From:        investor,
+		Beneficiary: beneficiary,
+		HeightBased: true,
+		Start:       100,
+		Cliff:       10,
+		Duration:    100,
+		Amount:      600,
+	}
+	_, err := invoke(db, beneficiary, CREATE_VESTING_NAME, create)
+	assert.NotNil(t, err)
+	_, err = invoke(db, investor, CREATE_VESTING_NAME, create)
+	assert.NotNil(t, err)
+	both := &testsuite.Tx{Signer: investor, Cosigners: []common.Address{beneficiary}}
+	_, err = testsuite.Invoke(db, both, utils.OnxContractAddress, CREATE_VESTING_NAME, create)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(400), balanceOf(t, db, investor))
+	assert.Equal(t, uint64(0), balanceOf(t, db, beneficiary))
+
+	addr := testsuite.AddressParam(beneficiary)
+	result, err := invoke(db, beneficiary, GET_VESTING_NAME, addr)
+	assert.Nil(t, err)
+	schedules := new(VestingSchedules)
+	assert.Nil(t, schedules.Deserialization(common.NewZeroCopySource(result)))
+	assert.Equal(t, 1, len(schedules.Schedules))
+	assert.Equal(t, uint64(600), schedules.Locked())
+
+	_, err = invokeAt(db, 105, beneficiary, CLAIM_VESTING_NAME, addr)
+	assert.NotNil(t, err)
+	_, err = invokeAt(db, 150, investor, CLAIM_VESTING_NAME, addr)
+	assert.NotNil(t, err)
+	_, err = invokeAt(db, 150, beneficiary, CLAIM_VESTING_NAME, addr)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(300), balanceOf(t, db, beneficiary))
+	_, err = invokeAt(db, 150, beneficiary, CLAIM_VESTING_NAME, addr)
+	assert.NotNil(t, err)
+	_, err = invokeAt(db, 300, beneficiary, CLAIM_VESTING_NAME, addr)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(600), balanceOf(t, db, beneficiary))
+
+	result, err = invoke(db, beneficiary, GET_VESTING_NAME, addr)
+	assert.Nil(t, err)
+	schedules = new(VestingSchedules)
+	assert.Nil(t, schedules.Deserialization(common.NewZeroCopySource(result)))
+	assert.Equal(t, 0, len(schedules.Schedules))
+	assert.Equal(t, uint32(1), schedules.NextId)
+
+	create.Cliff = 200
+	_, err = testsuite.Invoke(db, both, utils.OnxContractAddress, CREATE_VESTING_NAME, create)
+	assert.NotNil(t, err)
+}
@@ -213,3 +213,71 @@ func (this *TransferFrom) Deserialization(source *common.ZeroCopySource) error {
 
 	return err
 }
+
+// TransferMulti pays many receivers from one sender, the sender signs once
+type TransferMulti struct {
+	From    common.Address
+	Entries []TransferEntry
+}
+
+// TransferEntry is one leg of TransferMulti with a memo of the payment
+type TransferEntry struct {
+	To    common.Address
+	Value uint64
+	Memo  string
+}
+
+func (this *TransferMulti) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.From)
+	utils.EncodeVarUint(sink, uint64(len(this.Entries)))
+	for _, v := range this.Entries {
+		v.Serialization(sink)
+	}
+}
+
+func (this *TransferMulti) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	this.From, err = utils.DecodeAddress(source)
+	if err != nil {
+		return err
+	}
+	n, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return err
+	}
+	for i := 0; uint64(i) < n; i++ {
+		var entry TransferEntry
+		if err := entry.Deserialization(source); err != nil {
+			return err
+		}
+		this.Entries = append(this.Entries, entry)
+	}
+	return nil
+}
+
+func (this *TransferEntry) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.To)
+	utils.EncodeVarUint(sink, this.Value)
+	sink.WriteString(this.Memo)
+}
+
+func (this *TransferEntry) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	this.To, err = utils.DecodeAddress(source)
+	if err != nil {
+		return err
+	}
+	this.Value, err = utils.DecodeVarUint(source)
+	if err != nil {
+		return err
+	}
+	memo, _, irregular, eof := source.NextString()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	if irregular {
+		return common.ErrIrregularData
+	}
+	this.Memo = memo
+	return nil
+}
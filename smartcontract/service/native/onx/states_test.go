@@ -44,3 +44,25 @@ func TestState_Serialize(t *testing.T) {
 
 	assert.Equal(t, state, state2)
 }
+
+func TestTransferMulti_Serialization(t *testing.T) {
+	param := TransferMulti{
+		From: common.AddressFromVmCode([]byte{1, 2, 3}),
+		Entries: []TransferEntry{
+			{To: common.AddressFromVmCode([]byte{4, 5, 6}), Value: 1, Memo: "salary 2019-05"},
+			{To: common.AddressFromVmCode([]byte{7, 8, 9}), Value: 1000000000},
+		},
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+
+	param2 := TransferMulti{}
+	if err := param2.Deserialization(common.NewZeroCopySource(sink.Bytes())); err != nil {
+		t.Fatal("transfer multi deserialize fail!")
+	}
+	assert.Equal(t, param, param2)
+
+	truncated := sink.Bytes()[:sink.Size()-10]
+	param3 := TransferMulti{}
+	assert.NotNil(t, param3.Deserialization(common.NewZeroCopySource(truncated)))
+}
@@ -45,6 +45,9 @@ const (
 	TOTALSUPPLY_NAME    = "totalSupply"
 	BALANCEOF_NAME      = "balanceOf"
 	ALLOWANCE_NAME      = "allowance"
+	TRANSFERMULTI_NAME  = "transferMulti"
+
+	MAX_MEMO_LEN = 256
 )
 
 func AddNotifications(native *native.NativeService, contract common.Address, state *State) {
@@ -58,6 +61,18 @@ func AddNotifications(native *native.NativeService, contract common.Address, sta
 		})
 }
 
+//AddMemoNotifications notify a leg of transferMulti in the format of transfer followed by the memo
+func AddMemoNotifications(native *native.NativeService, contract, from common.Address, entry *TransferEntry) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.Notifications = append(native.Notifications,
+		&event.NotifyEventInfo{
+			ContractAddress: contract,
+			States:          []interface{}{TRANSFER_NAME, from.ToBase58(), entry.To.ToBase58(), entry.Value, entry.Memo},
+		})
+}
+
 func GetToUInt64StorageItem(toBalance, value uint64) *cstates.StorageItem {
 	bf := new(bytes.Buffer)
 	serialization.WriteUint64(bf, toBalance+value)
@@ -93,6 +108,40 @@ func Transfer(native *native.NativeService, contract common.Address, state *Stat
 	return fromBalance, toBalance, nil
 }
 
+//CheckTransferMulti check the witness of the sender and the entries of transferMulti,
+//every entry should not exceed the total supply of the asset
+func CheckTransferMulti(native *native.NativeService, param *TransferMulti, totalSupply uint64) error {
+	if len(param.Entries) == 0 {
+		return errors.NewErr("[TransferMulti] no transfer entry")
+	}
+	for _, v := range param.Entries {
+		if v.Value > totalSupply {
+			return fmt.Errorf("[TransferMulti] transfer amount:%d over totalSupply:%d", v.Value, totalSupply)
+		}
+		if len(v.Memo) > MAX_MEMO_LEN {
+			return fmt.Errorf("[TransferMulti] memo length:%d over %d", len(v.Memo), MAX_MEMO_LEN)
+		}
+	}
+	if !native.ContextRef.CheckWitness(param.From) {
+		return errors.NewErr("authentication failed!")
+	}
+	return nil
+}
+
+//TransferLeg move the value of one leg of transferMulti, the witness of the sender is checked by CheckTransferMulti
+func TransferLeg(native *native.NativeService, contract, from common.Address, entry *TransferEntry) (uint64, uint64, error) {
+	fromBalance, err := fromTransfer(native, GenBalanceKey(contract, from), entry.Value)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	toBalance, err := toTransfer(native, GenBalanceKey(contract, entry.To), entry.Value)
+	if err != nil {
+		return 0, 0, err
+	}
+	return fromBalance, toBalance, nil
+}
+
 func GenApproveKey(contract, from, to common.Address) []byte {
 	temp := append(contract[:], from[:]...)
 	return append(temp, to[:]...)
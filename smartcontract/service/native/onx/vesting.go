@@ -0,0 +1,398 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package onx
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/config"
+	"github.com/OnyxPay/OnyxChain/common/constants"
+	"github.com/OnyxPay/OnyxChain/errors"
+	"github.com/OnyxPay/OnyxChain/smartcontract/event"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+)
+
+const (
+	//function name
+	CREATE_VESTING_NAME = "createVesting"
+	CLAIM_VESTING_NAME  = "claimVesting"
+	GET_VESTING_NAME    = "getVesting"
+
+	//key prefix
+	VESTING = "vesting"
+
+	MAX_VESTING_SCHEDULES = 16
+)
+
+// VestingSchedule locks onx of the beneficiary, which is released linearly from Start to Start+Duration.
+// Nothing is released before Start+Cliff. Start, Cliff and Duration are block heights if HeightBased,
+// otherwise they are unix timestamp and seconds.
+type VestingSchedule struct {
+	Id          uint32
+	Creator     common.Address
+	HeightBased bool
+	Start       uint32
+	Cliff       uint32
+	Duration    uint32
+	Amount      uint64
+	Claimed     uint64
+}
+
+//Vested return the amount released at the height and timestamp, including the claimed part
+func (this *VestingSchedule) Vested(height, timestamp uint32) uint64 {
+	now := timestamp
+	if this.HeightBased {
+		now = height
+	}
+	if now < this.Start || now-this.Start < this.Cliff {
+		return 0
+	}
+	elapsed := now - this.Start
+	if elapsed >= this.Duration {
+		return this.Amount
+	}
+	vested := new(big.Int).Mul(new(big.Int).SetUint64(this.Amount), big.NewInt(int64(elapsed)))
+	return vested.Div(vested, big.NewInt(int64(this.Duration))).Uint64()
+}
+
+func (this *VestingSchedule) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarUint(sink, uint64(this.Id))
+	utils.EncodeAddress(sink, this.Creator)
+	sink.WriteBool(this.HeightBased)
+	utils.EncodeVarUint(sink, uint64(this.Start))
+	utils.EncodeVarUint(sink, uint64(this.Cliff))
+	utils.EncodeVarUint(sink, uint64(this.Duration))
+	utils.EncodeVarUint(sink, this.Amount)
+	utils.EncodeVarUint(sink, this.Claimed)
+}
+
+func (this *VestingSchedule) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Id, err = decodeUint32(source); err != nil {
+		return err
+	}
+	if this.Creator, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	if this.HeightBased, err = decodeBool(source); err != nil {
+		return err
+	}
+	if this.Start, err = decodeUint32(source); err != nil {
+		return err
+	}
+	if this.Cliff, err = decodeUint32(source); err != nil {
+		return err
+	}
+	if this.Duration, err = decodeUint32(source); err != nil {
+		return err
+	}
+	if this.Amount, err = utils.DecodeVarUint(source); err != nil {
+		return err
+	}
+	this.Claimed, err = utils.DecodeVarUint(source)
+	return err
+}
+
+// VestingSchedules is the schedules of a beneficiary
+type VestingSchedules struct {
+	NextId    uint32
+	Schedules []VestingSchedule
+}
+
+//Locked return the amount not claimed yet
+func (this *VestingSchedules) Locked() uint64 {
+	locked := uint64(0)
+	for _, v := range this.Schedules {
+		locked += v.Amount - v.Claimed
+	}
+	return locked
+}
+
+func (this *VestingSchedules) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarUint(sink, uint64(this.NextId))
+	utils.EncodeVarUint(sink, uint64(len(this.Schedules)))
+	for _, v := range this.Schedules {
+		v.Serialization(sink)
+	}
+}
+
+func (this *VestingSchedules) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.NextId, err = decodeUint32(source); err != nil {
+		return err
+	}
+	n, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return err
+	}
+	for i := 0; uint64(i) < n; i++ {
+		var schedule VestingSchedule
+		if err := schedule.Deserialization(source); err != nil {
+			return err
+		}
+		this.Schedules = append(this.Schedules, schedule)
+	}
+	return nil
+}
+
+// CreateVestingParam locks Amount of From for the Beneficiary. Both From and the Beneficiary should witness it,
+// otherwise anyone could fill the MAX_VESTING_SCHEDULES of the beneficiary.
+type CreateVestingParam struct {
+	From        common.Address
+	Beneficiary common.Address
+	HeightBased bool
+	Start       uint32
+	Cliff       uint32
+	Duration    uint32
+	Amount      uint64
+}
+
+func (this *CreateVestingParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.From)
+	utils.EncodeAddress(sink, this.Beneficiary)
+	sink.WriteBool(this.HeightBased)
+	utils.EncodeVarUint(sink, uint64(this.Start))
+	utils.EncodeVarUint(sink, uint64(this.Cliff))
+	utils.EncodeVarUint(sink, uint64(this.Duration))
+	utils.EncodeVarUint(sink, this.Amount)
+}
+
+func (this *CreateVestingParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.From, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	if this.Beneficiary, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	if this.HeightBased, err = decodeBool(source); err != nil {
+		return err
+	}
+	if this.Start, err = decodeUint32(source); err != nil {
+		return err
+	}
+	if this.Cliff, err = decodeUint32(source); err != nil {
+		return err
+	}
+	if this.Duration, err = decodeUint32(source); err != nil {
+		return err
+	}
+	this.Amount, err = utils.DecodeVarUint(source)
+	return err
+}
+
+func decodeUint32(source *common.ZeroCopySource) (uint32, error) {
+	value, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return 0, err
+	}
+	if value > math.MaxUint32 {
+		return 0, common.ErrIrregularData
+	}
+	return uint32(value), nil
+}
+
+func decodeBool(source *common.ZeroCopySource) (bool, error) {
+	value, irregular, eof := source.NextBool()
+	if eof {
+		return false, io.ErrUnexpectedEOF
+	}
+	if irregular {
+		return false, common.ErrIrregularData
+	}
+	return value, nil
+}
+
+func GenVestingKey(contract, beneficiary common.Address) []byte {
+	temp := append(contract[:], VESTING...)
+	return append(temp, beneficiary[:]...)
+}
+
+//GetVestingSchedules return the vesting schedules of the beneficiary
+func GetVestingSchedules(native *native.NativeService, contract, beneficiary common.Address) (*VestingSchedules, error) {
+	schedules := new(VestingSchedules)
+	value, err := utils.GetStorageItem(native, GenVestingKey(contract, beneficiary))
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return schedules, nil
+	}
+	if err := schedules.Deserialization(common.NewZeroCopySource(value.Value)); err != nil {
+		return nil, fmt.Errorf("deserialize vesting schedules error:%v", err)
+	}
+	return schedules, nil
+}
+
+func putVestingSchedules(native *native.NativeService, contract, beneficiary common.Address, schedules *VestingSchedules) {
+	key := GenVestingKey(contract, beneficiary)
+	if len(schedules.Schedules) == 0 && schedules.NextId == 0 {
+		native.CacheDB.Delete(key)
+		return
+	}
+	sink := common.NewZeroCopySink(nil)
+	schedules.Serialization(sink)
+	utils.PutBytes(native, key, sink.Bytes())
+}
+
+//getLockedBalance return the onx locked in the vesting schedules, which is counted when granting oxg
+func getLockedBalance(native *native.NativeService, contract, address common.Address) (uint64, error) {
+	schedules, err := GetVestingSchedules(native, contract, address)
+	if err != nil {
+		return 0, err
+	}
+	return schedules.Locked(), nil
+}
+
+func addVestingNotifications(native *native.NativeService, contract common.Address, states ...interface{}) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.Notifications = append(native.Notifications,
+		&event.NotifyEventInfo{
+			ContractAddress: contract,
+			States:          states,
+		})
+}
+
+func OnxCreateVesting(native *native.NativeService) ([]byte, error) {
+	var param CreateVestingParam
+	source := common.NewZeroCopySource(native.Input)
+	if err := param.Deserialization(source); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[OnxCreateVesting] param deserialize error!")
+	}
+	if param.Amount == 0 || param.Amount > constants.ONX_TOTAL_SUPPLY {
+		return utils.BYTE_FALSE, fmt.Errorf("[OnxCreateVesting] invalid amount:%d", param.Amount)
+	}
+	if param.Beneficiary == common.ADDRESS_EMPTY {
+		return utils.BYTE_FALSE, errors.NewErr("[OnxCreateVesting] beneficiary should not be empty")
+	}
+	if param.Cliff > param.Duration {
+		return utils.BYTE_FALSE, fmt.Errorf("[OnxCreateVesting] cliff:%d over duration:%d", param.Cliff, param.Duration)
+	}
+	if !native.ContextRef.CheckWitness(param.From) {
+		return utils.BYTE_FALSE, errors.NewErr("authentication failed!")
+	}
+	if !native.ContextRef.CheckWitness(param.Beneficiary) {
+		return utils.BYTE_FALSE, errors.NewErr("[OnxCreateVesting] beneficiary authentication failed!")
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	schedules, err := GetVestingSchedules(native, contract, param.Beneficiary)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	if len(schedules.Schedules) >= MAX_VESTING_SCHEDULES {
+		return utils.BYTE_FALSE, fmt.Errorf("[OnxCreateVesting] beneficiary has %d schedules at most", MAX_VESTING_SCHEDULES)
+	}
+
+	fromBalance, err := fromTransfer(native, GenBalanceKey(contract, param.From), param.Amount)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	if err := grantOxg(native, contract, param.From, fromBalance); err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	//grant the oxg of the beneficiary before the locked balance changes
+	balance, err := utils.GetStorageUInt64(native, GenBalanceKey(contract, param.Beneficiary))
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	if err := grantOxg(native, contract, param.Beneficiary, balance); err != nil {
+		return utils.BYTE_FALSE, err
+	}
+
+	schedule := VestingSchedule{
+		Id:          schedules.NextId,
+		Creator:     param.From,
+		HeightBased: param.HeightBased,
+		Start:       param.Start,
+		Cliff:       param.Cliff,
+		Duration:    param.Duration,
+		Amount:      param.Amount,
+	}
+	schedules.NextId++
+	schedules.Schedules = append(schedules.Schedules, schedule)
+	putVestingSchedules(native, contract, param.Beneficiary, schedules)
+	addVestingNotifications(native, contract, CREATE_VESTING_NAME, param.From.ToBase58(), param.Beneficiary.ToBase58(),
+		param.Amount, schedule.Id)
+	return utils.BYTE_TRUE, nil
+}
+
+func OnxClaimVesting(native *native.NativeService) ([]byte, error) {
+	source := common.NewZeroCopySource(native.Input)
+	beneficiary, err := utils.DecodeAddress(source)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[OnxClaimVesting] beneficiary deserialize error!")
+	}
+	if !native.ContextRef.CheckWitness(beneficiary) {
+		return utils.BYTE_FALSE, errors.NewErr("authentication failed!")
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	schedules, err := GetVestingSchedules(native, contract, beneficiary)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	claimed := uint64(0)
+	remain := make([]VestingSchedule, 0, len(schedules.Schedules))
+	for _, v := range schedules.Schedules {
+		vested := v.Vested(native.Height, native.Time)
+		claimed += vested - v.Claimed
+		v.Claimed = vested
+		if v.Claimed < v.Amount {
+			remain = append(remain, v)
+		}
+	}
+	if claimed == 0 {
+		return utils.BYTE_FALSE, errors.NewErr("[OnxClaimVesting] no vested onx to claim")
+	}
+
+	//grant the oxg of the beneficiary with the locked balance before claiming
+	toBalance, err := toTransfer(native, GenBalanceKey(contract, beneficiary), claimed)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	if err := grantOxg(native, contract, beneficiary, toBalance); err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	schedules.Schedules = remain
+	putVestingSchedules(native, contract, beneficiary, schedules)
+	addVestingNotifications(native, contract, CLAIM_VESTING_NAME, beneficiary.ToBase58(), claimed)
+	return utils.BYTE_TRUE, nil
+}
+
+func OnxGetVesting(native *native.NativeService) ([]byte, error) {
+	source := common.NewZeroCopySource(native.Input)
+	beneficiary, err := utils.DecodeAddress(source)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[OnxGetVesting] beneficiary deserialize error!")
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	schedules, err := GetVestingSchedules(native, contract, beneficiary)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	sink := common.NewZeroCopySink(nil)
+	schedules.Serialization(sink)
+	return sink.Bytes(), nil
+}
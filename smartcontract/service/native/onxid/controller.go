@@ -0,0 +1,368 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package onxid
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/OnyxPay/OnyxChain/common/serialization"
+	"github.com/OnyxPay/OnyxChain/core/states"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+)
+
+func getControllers(srvc *native.NativeService, encID []byte) ([][]byte, error) {
+	key := append(encID, FIELD_CONTROLLER)
+	item, err := utils.GetStorageItem(srvc, key)
+	if err != nil {
+		return nil, fmt.Errorf("get storage error, %s", err)
+	} else if item == nil {
+		return nil, nil
+	}
+	buf := bytes.NewBuffer(item.Value)
+	controllers := make([][]byte, 0)
+	for buf.Len() > 0 {
+		controller, err := serialization.ReadVarBytes(buf)
+		if err != nil {
+			return nil, fmt.Errorf("deserialize controllers error, %s", err)
+		}
+		controllers = append(controllers, controller)
+	}
+	return controllers, nil
+}
+
+func putControllers(srvc *native.NativeService, encID []byte, controllers [][]byte) error {
+	key := append(encID, FIELD_CONTROLLER)
+	if len(controllers) == 0 {
+		srvc.CacheDB.Delete(key)
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, controller := range controllers {
+		if err := serialization.WriteVarBytes(&buf, controller); err != nil {
+			return fmt.Errorf("serialize controller error, %s", err)
+		}
+	}
+	srvc.CacheDB.Put(key, states.GenRawStorageItem(buf.Bytes()))
+	return nil
+}
+
+func findController(controllers [][]byte, controller []byte) int {
+	for i, v := range controllers {
+		if bytes.Equal(v, controller) {
+			return i
+		}
+	}
+	return -1
+}
+
+//isController check whether the key is an authentication key of one of the controllers of the ID
+func isController(srvc *native.NativeService, encID, pub []byte) bool {
+	controllers, err := getControllers(srvc, encID)
+	if err != nil {
+		return false
+	}
+	for _, controller := range controllers {
+		key, err := encodeID(controller)
+		if err != nil {
+			continue
+		}
+		if isAuthenticationKey(srvc, key, pub) {
+			return true
+		}
+	}
+	return false
+}
+
+//isAuthorized check whether the key is allowed to manage the keys and attributes of the ID,
+//that is the key is an authentication key of the ID or of its controllers
+func isAuthorized(srvc *native.NativeService, encID, pub []byte) bool {
+	return isAuthenticationKey(srvc, encID, pub) || isController(srvc, encID, pub)
+}
+
+//isAuthenticationKey check whether the key is a key of the ID with the authentication purpose
+func isAuthenticationKey(srvc *native.NativeService, encID, pub []byte) bool {
+	kID, err := findPk(srvc, encID, pub)
+	if err != nil || kID == 0 {
+		return false
+	}
+	purpose, err := getKeyPurpose(srvc, encID, kID)
+	if err != nil {
+		return false
+	}
+	return purpose&KEY_PURPOSE_AUTHENTICATION != 0
+}
+
+//checkAuthenticationKeyChange check the purpose of key pub can be changed to purpose, 0 means the key is removed.
+//Only the owner can change the authentication keys, and at least one authentication key should be left
+func checkAuthenticationKeyChange(srvc *native.NativeService, encID, pub []byte, purpose byte, byOwner bool) error {
+	owners, err := getAllPk(srvc, append(encID, FIELD_PK))
+	if err != nil {
+		return err
+	}
+	left := 0
+	for i, v := range owners {
+		index := uint32(i + 1)
+		p, err := getKeyPurpose(srvc, encID, index)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(v.key, pub) {
+			if !v.revoked && p&KEY_PURPOSE_AUTHENTICATION != 0 {
+				left++
+			}
+			continue
+		}
+		if !byOwner && (p|purpose)&KEY_PURPOSE_AUTHENTICATION != 0 {
+			return errors.New("controller can not change authentication keys")
+		}
+		if !v.revoked && purpose&KEY_PURPOSE_AUTHENTICATION != 0 {
+			left++
+		}
+	}
+	if left == 0 {
+		return errors.New("no authentication key left")
+	}
+	return nil
+}
+
+func genPurposeKey(encID []byte, index uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], index)
+	return append(append(encID, FIELD_PURPOSE), buf[:]...)
+}
+
+//getKeyPurpose return the purpose flags of the key, KEY_PURPOSE_ALL if not set
+func getKeyPurpose(srvc *native.NativeService, encID []byte, index uint32) (byte, error) {
+	item, err := utils.GetStorageItem(srvc, genPurposeKey(encID, index))
+	if err != nil {
+		return 0, fmt.Errorf("get key purpose error, %s", err)
+	} else if item == nil || len(item.Value) == 0 {
+		return KEY_PURPOSE_ALL, nil
+	}
+	return item.Value[0], nil
+}
+
+func putKeyPurpose(srvc *native.NativeService, encID []byte, index uint32, purpose byte) {
+	key := genPurposeKey(encID, index)
+	if purpose == KEY_PURPOSE_ALL {
+		srvc.CacheDB.Delete(key)
+		return
+	}
+	srvc.CacheDB.Put(key, states.GenRawStorageItem([]byte{purpose}))
+}
+
+func addController(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add controller failed: argument 0 error, %s", err)
+	}
+	// arg1: controller's ID
+	arg1, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add controller failed: argument 1 error, %s", err)
+	}
+	// arg2: operator's public key
+	arg2, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add controller failed: argument 2 error, %s", err)
+	}
+
+	if err = checkWitness(srvc, arg2); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add controller failed: %s", err)
+	}
+	key, err := encodeID(arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add controller failed: %s", err)
+	}
+	if !checkIDExistence(srvc, key) {
+		return utils.BYTE_FALSE, errors.New("add controller failed: ID not registered")
+	}
+	if !isAuthenticationKey(srvc, key, arg2) {
+		return utils.BYTE_FALSE, errors.New("add controller failed: operator has no authorization")
+	}
+	if bytes.Equal(arg0, arg1) {
+		return utils.BYTE_FALSE, errors.New("add controller failed: cannot be controlled by itself")
+	}
+	controllerKey, err := encodeID(arg1)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add controller failed: %s", err)
+	}
+	if !checkIDExistence(srvc, controllerKey) {
+		return utils.BYTE_FALSE, errors.New("add controller failed: controller not registered")
+	}
+
+	controllers, err := getControllers(srvc, key)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add controller failed: %s", err)
+	}
+	if findController(controllers, arg1) >= 0 {
+		return utils.BYTE_FALSE, errors.New("add controller failed: already exists")
+	}
+	if len(controllers) >= MAX_CONTROLLERS {
+		return utils.BYTE_FALSE, fmt.Errorf("add controller failed: no more than %d controllers", MAX_CONTROLLERS)
+	}
+	controllers = append(controllers, arg1)
+	if err = putControllers(srvc, key, controllers); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("add controller failed: %s", err)
+	}
+
+	triggerControllerEvent(srvc, "add", arg0, arg1)
+	return utils.BYTE_TRUE, nil
+}
+
+func removeController(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("remove controller failed: argument 0 error, %s", err)
+	}
+	// arg1: controller's ID
+	arg1, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("remove controller failed: argument 1 error, %s", err)
+	}
+	// arg2: operator's public key, which is a key of the ID or of the removed controller
+	arg2, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("remove controller failed: argument 2 error, %s", err)
+	}
+
+	if err = checkWitness(srvc, arg2); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("remove controller failed: %s", err)
+	}
+	key, err := encodeID(arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("remove controller failed: %s", err)
+	}
+	if !checkIDExistence(srvc, key) {
+		return utils.BYTE_FALSE, errors.New("remove controller failed: ID not registered")
+	}
+	controllers, err := getControllers(srvc, key)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("remove controller failed: %s", err)
+	}
+	index := findController(controllers, arg1)
+	if index < 0 {
+		return utils.BYTE_FALSE, errors.New("remove controller failed: controller not found")
+	}
+	if !isAuthenticationKey(srvc, key, arg2) {
+		controllerKey, err := encodeID(arg1)
+		if err != nil || !isAuthenticationKey(srvc, controllerKey, arg2) {
+			return utils.BYTE_FALSE, errors.New("remove controller failed: operator has no authorization")
+		}
+	}
+
+	controllers = append(controllers[:index], controllers[index+1:]...)
+	if err = putControllers(srvc, key, controllers); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("remove controller failed: %s", err)
+	}
+
+	triggerControllerEvent(srvc, "remove", arg0, arg1)
+	return utils.BYTE_TRUE, nil
+}
+
+func setKeyPurpose(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set key purpose failed: argument 0 error, %s", err)
+	}
+	// arg1: public key
+	arg1, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set key purpose failed: argument 1 error, %s", err)
+	}
+	// arg2: purpose flags
+	arg2, err := utils.ReadVarUint(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set key purpose failed: argument 2 error, %s", err)
+	}
+	// arg3: operator's public key
+	arg3, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set key purpose failed: argument 3 error, %s", err)
+	}
+	if arg2 == 0 || arg2&^uint64(KEY_PURPOSE_ALL) != 0 {
+		return utils.BYTE_FALSE, fmt.Errorf("set key purpose failed: invalid purpose %d", arg2)
+	}
+
+	if err = checkWitness(srvc, arg3); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set key purpose failed: %s", err)
+	}
+	key, err := encodeID(arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set key purpose failed: %s", err)
+	}
+	if !checkIDExistence(srvc, key) {
+		return utils.BYTE_FALSE, errors.New("set key purpose failed: ID not registered")
+	}
+	if !isAuthorized(srvc, key, arg3) {
+		return utils.BYTE_FALSE, errors.New("set key purpose failed: operator has no authorization")
+	}
+	keyID, err := findPk(srvc, key, arg1)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set key purpose failed: %s", err)
+	} else if keyID == 0 {
+		return utils.BYTE_FALSE, errors.New("set key purpose failed: public key not found")
+	}
+	pk, err := getPk(srvc, key, keyID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set key purpose failed: %s", err)
+	} else if pk.revoked {
+		return utils.BYTE_FALSE, errors.New("set key purpose failed: public key revoked")
+	}
+
+	err = checkAuthenticationKeyChange(srvc, key, arg1, byte(arg2), isAuthenticationKey(srvc, key, arg3))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set key purpose failed: %s", err)
+	}
+	putKeyPurpose(srvc, key, keyID, byte(arg2))
+	triggerKeyPurposeEvent(srvc, arg0, arg1, keyID, byte(arg2))
+	return utils.BYTE_TRUE, nil
+}
+
+func GetControllers(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	did, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return nil, fmt.Errorf("get controllers error: invalid argument, %s", err)
+	}
+	key, err := encodeID(did)
+	if err != nil {
+		return nil, fmt.Errorf("get controllers error: %s", err)
+	}
+	controllers, err := getControllers(srvc, key)
+	if err != nil {
+		return nil, fmt.Errorf("get controllers error: %s", err)
+	}
+	var res bytes.Buffer
+	for _, controller := range controllers {
+		if err := serialization.WriteVarBytes(&res, controller); err != nil {
+			return nil, fmt.Errorf("get controllers error: %s", err)
+		}
+	}
+	return res.Bytes(), nil
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package onxid
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/OnyxPay/OnyxChain-crypto/keypair"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/serialization"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native"
+)
+
+const DID_CONTEXT = "https://www.w3.org/ns/did/v1"
+
+//Document is the W3C DID Core document of an ONX ID, the attributes and recovery are
+//the extension properties of ONX ID
+type Document struct {
+	Context            []string              `json:"@context"`
+	Id                 string                `json:"id"`
+	Controller         []string              `json:"controller,omitempty"`
+	VerificationMethod []*VerificationMethod `json:"verificationMethod"`
+	Authentication     []string              `json:"authentication"`
+	AssertionMethod    []string              `json:"assertionMethod"`
+	Attribute          []*DocumentAttribute  `json:"attribute,omitempty"`
+	Recovery           string                `json:"recovery,omitempty"`
+}
+
+type VerificationMethod struct {
+	Id           string `json:"id"`
+	Type         string `json:"type"`
+	Controller   string `json:"controller"`
+	PublicKeyHex string `json:"publicKeyHex"`
+}
+
+type DocumentAttribute struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+//verificationMethodType return the verification method type of the serialized public key
+func verificationMethodType(pub []byte) string {
+	if len(pub) == 0 {
+		return ""
+	}
+	switch keypair.KeyType(pub[0]) {
+	case keypair.PK_P256_E, keypair.PK_P256_O, keypair.PK_P256_NC:
+		return "EcdsaSecp256r1VerificationKey2019"
+	case keypair.PK_ECDSA:
+		if len(pub) > 1 && pub[1] == keypair.P256 {
+			return "EcdsaSecp256r1VerificationKey2019"
+		}
+		return "EcdsaVerificationKey2019"
+	case keypair.PK_SM2:
+		return "SM2VerificationKey2019"
+	case keypair.PK_EDDSA:
+		return "Ed25519VerificationKey2018"
+	}
+	return ""
+}
+
+//GetDocument return the json of the DID document built from the DDO, the controllers and the key purposes
+func GetDocument(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	did, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return nil, fmt.Errorf("get document error: invalid argument, %s", err)
+	}
+	key, err := encodeID(did)
+	if err != nil {
+		return nil, fmt.Errorf("get document error: %s", err)
+	}
+	if !checkIDExistence(srvc, key) {
+		return nil, errors.New("get document error: ID not registered")
+	}
+	ddo, err := GetDDO(srvc)
+	if err != nil {
+		return nil, fmt.Errorf("get document error: %s", err)
+	}
+	doc, err := buildDocument(srvc, did, ddo)
+	if err != nil {
+		return nil, fmt.Errorf("get document error: %s", err)
+	}
+	return json.Marshal(doc)
+}
+
+func buildDocument(srvc *native.NativeService, did, ddo []byte) (*Document, error) {
+	id := string(did)
+	doc := &Document{
+		Context:            []string{DID_CONTEXT},
+		Id:                 id,
+		VerificationMethod: make([]*VerificationMethod, 0),
+		Authentication:     make([]string, 0),
+		AssertionMethod:    make([]string, 0),
+	}
+	key, err := encodeID(did)
+	if err != nil {
+		return nil, err
+	}
+	controllers, err := getControllers(srvc, key)
+	if err != nil {
+		return nil, err
+	}
+	for _, controller := range controllers {
+		doc.Controller = append(doc.Controller, string(controller))
+	}
+	if len(ddo) == 0 {
+		return doc, nil
+	}
+
+	buf := bytes.NewBuffer(ddo)
+	pubKeys, err := serialization.ReadVarBytes(buf)
+	if err != nil {
+		return nil, fmt.Errorf("parse public keys error, %s", err)
+	}
+	attrs, err := serialization.ReadVarBytes(buf)
+	if err != nil {
+		return nil, fmt.Errorf("parse attributes error, %s", err)
+	}
+	recovery, err := serialization.ReadVarBytes(buf)
+	if err != nil {
+		return nil, fmt.Errorf("parse recovery error, %s", err)
+	}
+
+	keys := bytes.NewBuffer(pubKeys)
+	for keys.Len() > 0 {
+		index, err := serialization.ReadUint32(keys)
+		if err != nil {
+			return nil, fmt.Errorf("parse public key index error, %s", err)
+		}
+		pub, err := serialization.ReadVarBytes(keys)
+		if err != nil {
+			return nil, fmt.Errorf("parse public key error, %s", err)
+		}
+		purpose, err := getKeyPurpose(srvc, key, index)
+		if err != nil {
+			return nil, err
+		}
+		keyId := id + "#keys-" + strconv.FormatUint(uint64(index), 10)
+		doc.VerificationMethod = append(doc.VerificationMethod, &VerificationMethod{
+			Id:           keyId,
+			Type:         verificationMethodType(pub),
+			Controller:   id,
+			PublicKeyHex: hex.EncodeToString(pub),
+		})
+		if purpose&KEY_PURPOSE_AUTHENTICATION != 0 {
+			doc.Authentication = append(doc.Authentication, keyId)
+		}
+		if purpose&KEY_PURPOSE_ASSERTION != 0 {
+			doc.AssertionMethod = append(doc.AssertionMethod, keyId)
+		}
+	}
+
+	attrBuf := bytes.NewBuffer(attrs)
+	for attrBuf.Len() > 0 {
+		var attr attribute
+		if err := attr.Deserialize(attrBuf); err != nil {
+			return nil, fmt.Errorf("parse attribute error, %s", err)
+		}
+		doc.Attribute = append(doc.Attribute, &DocumentAttribute{
+			Key:   string(attr.key),
+			Type:  string(attr.valueType),
+			Value: string(attr.value),
+		})
+	}
+
+	if len(recovery) > 0 {
+		addr, err := common.AddressParseFromBytes(recovery)
+		if err != nil {
+			return nil, fmt.Errorf("parse recovery error, %s", err)
+		}
+		doc.Recovery = addr.ToBase58()
+	}
+	return doc, nil
+}
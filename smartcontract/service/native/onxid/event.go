@@ -61,3 +61,13 @@ func triggerRecoveryEvent(srvc *native.NativeService, op string, id []byte, addr
 	st := []string{"Recovery", op, string(id), addr.ToHexString()}
 	newEvent(srvc, st)
 }
+
+func triggerControllerEvent(srvc *native.NativeService, op string, id, controller []byte) {
+	st := []string{"Controller", op, string(id), string(controller)}
+	newEvent(srvc, st)
+}
+
+func triggerKeyPurposeEvent(srvc *native.NativeService, id, pub []byte, keyID uint32, purpose byte) {
+	st := []interface{}{"PublicKey", "purpose", string(id), keyID, hex.EncodeToString(pub), purpose}
+	newEvent(srvc, st)
+}
@@ -40,5 +40,10 @@ func RegisterIDContract(srvc *native.NativeService) {
 	srvc.Register("getKeyState", GetKeyState)
 	srvc.Register("getAttributes", GetAttributes)
 	srvc.Register("getDDO", GetDDO)
+	srvc.Register("addController", addController)
+	srvc.Register("removeController", removeController)
+	srvc.Register("setKeyPurpose", setKeyPurpose)
+	srvc.Register("getControllers", GetControllers)
+	srvc.Register("getDocument", GetDocument)
 	return
 }
@@ -203,7 +203,7 @@ func addKey(srvc *native.NativeService) ([]byte, error) {
 		auth = bytes.Equal(rec, arg2)
 	}
 	if !auth {
-		if !isOwner(srvc, key, arg2) {
+		if !isAuthorized(srvc, key, arg2) {
 			return utils.BYTE_FALSE, errors.New("add key failed: operator has no authorization")
 		}
 	}
@@ -217,6 +217,10 @@ func addKey(srvc *native.NativeService) ([]byte, error) {
 	if err != nil {
 		return utils.BYTE_FALSE, errors.New("add key failed: insert public key error, " + err.Error())
 	}
+	if !auth && !isAuthenticationKey(srvc, key, arg2) {
+		// a key added by controller can not manage the ID
+		putKeyPurpose(srvc, key, keyID, KEY_PURPOSE_ASSERTION)
+	}
 
 	triggerPublicEvent(srvc, "add", arg0, arg1, keyID)
 
@@ -259,11 +263,14 @@ func removeKey(srvc *native.NativeService) ([]byte, error) {
 		auth = bytes.Equal(rec, arg2)
 	}
 	if !auth {
-		if !isOwner(srvc, key, arg2) {
+		if !isAuthorized(srvc, key, arg2) {
 			return utils.BYTE_FALSE, errors.New("remove key failed: operator has no authorization")
 		}
 	}
 
+	if err = checkAuthenticationKeyChange(srvc, key, arg1, 0, auth || isAuthenticationKey(srvc, key, arg2)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("remove key failed: %s", err)
+	}
 	keyID, err := revokePk(srvc, key, arg1)
 	if err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("remove key failed: %s", err)
@@ -404,7 +411,7 @@ func addAttributes(srvc *native.NativeService) ([]byte, error) {
 	if !checkIDExistence(srvc, key) {
 		return utils.BYTE_FALSE, errors.New("add attributes failed, ID not registered")
 	}
-	if !isOwner(srvc, key, arg2) {
+	if !isAuthorized(srvc, key, arg2) {
 		return utils.BYTE_FALSE, errors.New("add attributes failed, no authorization")
 	}
 	err = checkWitness(srvc, arg2)
@@ -454,7 +461,7 @@ func removeAttribute(srvc *native.NativeService) ([]byte, error) {
 	if !checkIDExistence(srvc, key) {
 		return utils.BYTE_FALSE, errors.New("remove attribute failed: ID not registered")
 	}
-	if !isOwner(srvc, key, arg2) {
+	if !isAuthorized(srvc, key, arg2) {
 		return utils.BYTE_FALSE, errors.New("remove attribute failed: no authorization")
 	}
 
@@ -0,0 +1,150 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package onxid
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/OnyxPay/OnyxChain-crypto/keypair"
+	"github.com/OnyxPay/OnyxChain/account"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/store/overlaydb"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/testsuite"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	Init()
+}
+
+//invoke call the onxid contract in a transaction signed by the signer, the args are []byte or uint64
+func invoke(db *overlaydb.OverlayDB, signer *account.Account, method string, args ...interface{}) ([]byte, error) {
+	sink := common.NewZeroCopySink(nil)
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case []byte:
+			sink.WriteVarBytes(v)
+		case uint64:
+			utils.EncodeVarUint(sink, v)
+		}
+	}
+	tx := &testsuite.Tx{Signer: signer.Address}
+	return testsuite.Invoke(db, tx, utils.OnxIDContractAddress, method, testsuite.RawParam(sink.Bytes()))
+}
+
+func getDocument(t *testing.T, db *overlaydb.OverlayDB, id []byte) *Document {
+	data, err := invoke(db, account.NewAccount(""), "getDocument", id)
+	assert.Nil(t, err)
+	doc := new(Document)
+	assert.Nil(t, json.Unmarshal(data, doc))
+	return doc
+}
+
+func TestController(t *testing.T) {
+	db := testsuite.NewDB()
+	owner, controllerAcc, other := account.NewAccount(""), account.NewAccount(""), account.NewAccount("")
+	ownerPk := keypair.SerializePublicKey(owner.PublicKey)
+	controllerPk := keypair.SerializePublicKey(controllerAcc.PublicKey)
+	otherPk := keypair.SerializePublicKey(other.PublicKey)
+	id := testsuite.RegisterID(t, db, owner)
+	controller := testsuite.RegisterID(t, db, controllerAcc)
+
+	// a key of the controller can not manage the ID before added
+	_, err := invoke(db, controllerAcc, "addKey", id, otherPk, controllerPk)
+	assert.NotNil(t, err)
+	_, err = invoke(db, controllerAcc, "addController", id, controller, controllerPk)
+	assert.NotNil(t, err)
+	_, err = invoke(db, owner, "addController", id, id, ownerPk)
+	assert.NotNil(t, err)
+	_, err = invoke(db, owner, "addController", id, []byte("did:onx:TSS6S4Xhzt5wtvRBTm4y3QCTRqB4BnU7vT"), ownerPk)
+	assert.NotNil(t, err)
+	_, err = invoke(db, owner, "addController", id, controller, ownerPk)
+	assert.Nil(t, err)
+	_, err = invoke(db, owner, "addController", id, controller, ownerPk)
+	assert.NotNil(t, err)
+
+	// the controller adds assertion keys, but can not change the authentication keys of the owner
+	_, err = invoke(db, controllerAcc, "addKey", id, otherPk, controllerPk)
+	assert.Nil(t, err)
+	_, err = invoke(db, controllerAcc, "removeKey", id, ownerPk, controllerPk)
+	assert.NotNil(t, err)
+	_, err = invoke(db, controllerAcc, "setKeyPurpose", id, ownerPk, uint64(KEY_PURPOSE_ASSERTION), controllerPk)
+	assert.NotNil(t, err)
+	_, err = invoke(db, controllerAcc, "setKeyPurpose", id, otherPk, uint64(KEY_PURPOSE_ALL), controllerPk)
+	assert.NotNil(t, err)
+	_, err = invoke(db, other, "addController", id, controller, otherPk)
+	assert.NotNil(t, err)
+	doc := getDocument(t, db, id)
+	assert.Equal(t, []string{string(controller)}, doc.Controller)
+	assert.Equal(t, 2, len(doc.VerificationMethod))
+	assert.Equal(t, hex.EncodeToString(otherPk), doc.VerificationMethod[1].PublicKeyHex)
+	assert.Equal(t, []string{string(id) + "#keys-1"}, doc.Authentication)
+	_, err = invoke(db, controllerAcc, "removeKey", id, otherPk, controllerPk)
+	assert.Nil(t, err)
+
+	// the controller can not add controllers but can resign
+	_, err = invoke(db, controllerAcc, "addController", id, controller, controllerPk)
+	assert.NotNil(t, err)
+	_, err = invoke(db, controllerAcc, "removeController", id, controller, controllerPk)
+	assert.Nil(t, err)
+	_, err = invoke(db, controllerAcc, "addKey", id, ownerPk, controllerPk)
+	assert.NotNil(t, err)
+	data, err := invoke(db, other, "getControllers", id)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(data))
+}
+
+func TestKeyPurpose(t *testing.T) {
+	db := testsuite.NewDB()
+	owner, assertion := account.NewAccount(""), account.NewAccount("")
+	ownerPk := keypair.SerializePublicKey(owner.PublicKey)
+	assertionPk := keypair.SerializePublicKey(assertion.PublicKey)
+	id := testsuite.RegisterID(t, db, owner)
+	_, err := invoke(db, owner, "addKey", id, assertionPk, ownerPk)
+	assert.Nil(t, err)
+
+	_, err = invoke(db, owner, "setKeyPurpose", id, assertionPk, uint64(4), ownerPk)
+	assert.NotNil(t, err)
+	_, err = invoke(db, owner, "setKeyPurpose", id, assertionPk, uint64(KEY_PURPOSE_ASSERTION), ownerPk)
+	assert.Nil(t, err)
+
+	// an assertion key still signs for the ID, but can not manage it
+	_, err = invoke(db, assertion, "verifySignature", id, uint64(2))
+	assert.Nil(t, err)
+	_, err = invoke(db, assertion, "removeKey", id, ownerPk, assertionPk)
+	assert.NotNil(t, err)
+	_, err = invoke(db, owner, "verifySignature", id, uint64(1))
+	assert.Nil(t, err)
+
+	// the last authentication key can neither be removed nor lose the purpose
+	_, err = invoke(db, owner, "setKeyPurpose", id, ownerPk, uint64(KEY_PURPOSE_ASSERTION), ownerPk)
+	assert.NotNil(t, err)
+	_, err = invoke(db, owner, "removeKey", id, ownerPk, ownerPk)
+	assert.NotNil(t, err)
+
+	doc := getDocument(t, db, id)
+	assert.Equal(t, []string{DID_CONTEXT}, doc.Context)
+	assert.Equal(t, string(id), doc.Id)
+	assert.Equal(t, 2, len(doc.VerificationMethod))
+	assert.Equal(t, "EcdsaSecp256r1VerificationKey2019", doc.VerificationMethod[0].Type)
+	assert.Equal(t, []string{string(id) + "#keys-1"}, doc.Authentication)
+	assert.Equal(t, []string{string(id) + "#keys-1", string(id) + "#keys-2"}, doc.AssertionMethod)
+}
@@ -48,9 +48,18 @@ const (
 	FIELD_VERSION byte = 0
 	FLAG_VERSION  byte = 0x01
 
-	FIELD_PK       byte = 1
-	FIELD_ATTR     byte = 2
-	FIELD_RECOVERY byte = 3
+	FIELD_PK         byte = 1
+	FIELD_ATTR       byte = 2
+	FIELD_RECOVERY   byte = 3
+	FIELD_CONTROLLER byte = 4
+	FIELD_PURPOSE    byte = 5
+
+	//key purposes, a key without purpose set is used for both
+	KEY_PURPOSE_AUTHENTICATION byte = 0x01
+	KEY_PURPOSE_ASSERTION      byte = 0x02
+	KEY_PURPOSE_ALL                 = KEY_PURPOSE_AUTHENTICATION | KEY_PURPOSE_ASSERTION
+
+	MAX_CONTROLLERS = 16
 )
 
 func encodeID(id []byte) ([]byte, error) {
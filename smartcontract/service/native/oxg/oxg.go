@@ -40,6 +40,7 @@ func RegisterOxgContract(native *native.NativeService) {
 	native.Register(onx.TRANSFER_NAME, OxgTransfer)
 	native.Register(onx.APPROVE_NAME, OxgApprove)
 	native.Register(onx.TRANSFERFROM_NAME, OxgTransferFrom)
+	native.Register(onx.TRANSFERMULTI_NAME, OxgTransferMulti)
 	native.Register(onx.NAME_NAME, OxgName)
 	native.Register(onx.SYMBOL_NAME, OxgSymbol)
 	native.Register(onx.DECIMALS_NAME, OxgDecimals)
@@ -88,6 +89,29 @@ func OxgTransfer(native *native.NativeService) ([]byte, error) {
 	return utils.BYTE_TRUE, nil
 }
 
+func OxgTransferMulti(native *native.NativeService) ([]byte, error) {
+	var param onx.TransferMulti
+	source := common.NewZeroCopySource(native.Input)
+	if err := param.Deserialization(source); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[OxgTransferMulti] TransferMulti deserialize error!")
+	}
+	if err := onx.CheckTransferMulti(native, &param, constants.OXG_TOTAL_SUPPLY); err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+	for i := range param.Entries {
+		entry := &param.Entries[i]
+		if entry.Value == 0 {
+			continue
+		}
+		if _, _, err := onx.TransferLeg(native, contract, param.From, entry); err != nil {
+			return utils.BYTE_FALSE, err
+		}
+		onx.AddMemoNotifications(native, contract, param.From, entry)
+	}
+	return utils.BYTE_TRUE, nil
+}
+
 func OxgApprove(native *native.NativeService) ([]byte, error) {
 	var state onx.State
 	source := common.NewZeroCopySource(native.Input)
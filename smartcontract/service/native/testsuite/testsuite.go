@@ -0,0 +1,158 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+//Package testsuite provides the fixture shared by the tests of native contracts
+package testsuite
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/OnyxPay/OnyxChain-crypto/keypair"
+	"github.com/OnyxPay/OnyxChain/account"
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/store/leveldbstore"
+	"github.com/OnyxPay/OnyxChain/core/store/overlaydb"
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/OnyxPay/OnyxChain/smartcontract"
+	"github.com/OnyxPay/OnyxChain/smartcontract/context"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	"github.com/OnyxPay/OnyxChain/smartcontract/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+const DEFAULT_HEIGHT = 10 // block height of a Tx without height
+
+//Param is the input of a native contract method
+type Param interface {
+	Serialization(sink *common.ZeroCopySink)
+}
+
+//BytesParam is serialized as var bytes
+type BytesParam []byte
+
+func (this BytesParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this)
+}
+
+//AddressParam is serialized as an address of native contract
+type AddressParam common.Address
+
+func (this AddressParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, common.Address(this))
+}
+
+//RawParam is the serialized input
+type RawParam []byte
+
+func (this RawParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteBytes(this)
+}
+
+//Serialize return the input serialized to io.Writer
+func Serialize(t *testing.T, p interface {
+	Serialize(w io.Writer) error
+}) RawParam {
+	bf := new(bytes.Buffer)
+	assert.Nil(t, p.Serialize(bf))
+	return bf.Bytes()
+}
+
+//Tx is the transaction in which a native contract is called
+type Tx struct {
+	Signer    common.Address   //address which signs the transaction
+	Cosigners []common.Address //other addresses which sign the transaction
+	Caller    common.Address   //address of the calling contract, empty if the contract is called by the transaction
+	Height    uint32           //block height, DEFAULT_HEIGHT if zero
+	Time      uint32           //block time
+}
+
+//NewDB return an overlay db on an empty memory store
+func NewDB() *overlaydb.OverlayDB {
+	store, _ := leveldbstore.NewMemLevelDBStore()
+	return overlaydb.NewOverlayDB(store)
+}
+
+//Put write the storage item to db as a native contract does
+func Put(db *overlaydb.OverlayDB, key, value []byte) {
+	cache := storage.NewCacheDB(db)
+	cache.Put(key, value)
+	cache.Commit()
+}
+
+//Invoke call the method of native contract in tx, the changes are committed to db only if the call succeeds
+func Invoke(db *overlaydb.OverlayDB, tx *Tx, contract common.Address, method string, p Param) ([]byte, error) {
+	height := tx.Height
+	if height == 0 {
+		height = DEFAULT_HEIGHT
+	}
+	cache := storage.NewCacheDB(db)
+	sc := &smartcontract.SmartContract{
+		Config: &smartcontract.Config{
+			Tx:     &types.Transaction{SignedAddr: append([]common.Address{tx.Signer}, tx.Cosigners...)},
+			Height: height,
+			Time:   tx.Time,
+		},
+		CacheDB: cache,
+		Gas:     math.MaxUint64,
+	}
+	if tx.Caller != common.ADDRESS_EMPTY {
+		sc.PushContext(&context.Context{ContractAddress: tx.Caller})
+	}
+	service, err := sc.NewNativeService()
+	if err != nil {
+		return nil, err
+	}
+	sink := common.NewZeroCopySink(nil)
+	p.Serialization(sink)
+	result, err := service.NativeCall(contract, method, sink.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	cache.Commit()
+	return result.([]byte), nil
+}
+
+//BalanceOf return the balance of addr in the asset contract
+func BalanceOf(t *testing.T, db *overlaydb.OverlayDB, asset, addr common.Address) uint64 {
+	result, err := Invoke(db, &Tx{Signer: addr}, asset, "balanceOf", AddressParam(addr))
+	assert.Nil(t, err)
+	return common.BigIntFromNeoBytes(result).Uint64()
+}
+
+type registerParam struct {
+	id     []byte
+	pubKey []byte
+}
+
+func (this *registerParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.id)
+	sink.WriteVarBytes(this.pubKey)
+}
+
+//RegisterID register a new ONX ID with the public key of acc, the onxid contract should be initialized
+func RegisterID(t *testing.T, db *overlaydb.OverlayDB, acc *account.Account) []byte {
+	id, err := account.GenerateID()
+	assert.Nil(t, err)
+	p := &registerParam{id: []byte(id), pubKey: keypair.SerializePublicKey(acc.PublicKey)}
+	_, err = Invoke(db, &Tx{Signer: acc.Address}, utils.OnxIDContractAddress, "regIDWithPublicKey", p)
+	assert.Nil(t, err)
+	return []byte(id)
+}
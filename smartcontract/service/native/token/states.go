@@ -0,0 +1,191 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package token
+
+import (
+	"io"
+	"math"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/onx"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+)
+
+// AssetInfo is the registered asset, identified by its symbol
+type AssetInfo struct {
+	Symbol        string
+	Name          string
+	Decimals      uint32
+	Cap           uint64         // max total supply, 0 means no limit
+	Issuer        common.Address // the creator of the asset
+	MintAuthority common.Address // empty address means the asset can not be minted
+	BurnAuthority common.Address // empty address means the asset can not be burned
+}
+
+func (this *AssetInfo) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteString(this.Symbol)
+	sink.WriteString(this.Name)
+	utils.EncodeVarUint(sink, uint64(this.Decimals))
+	utils.EncodeVarUint(sink, this.Cap)
+	utils.EncodeAddress(sink, this.Issuer)
+	utils.EncodeAddress(sink, this.MintAuthority)
+	utils.EncodeAddress(sink, this.BurnAuthority)
+}
+
+func (this *AssetInfo) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Symbol, err = decodeString(source); err != nil {
+		return err
+	}
+	if this.Name, err = decodeString(source); err != nil {
+		return err
+	}
+	decimals, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return err
+	}
+	if decimals > math.MaxUint32 {
+		return common.ErrIrregularData
+	}
+	this.Decimals = uint32(decimals)
+	if this.Cap, err = utils.DecodeVarUint(source); err != nil {
+		return err
+	}
+	if this.Issuer, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	if this.MintAuthority, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	this.BurnAuthority, err = utils.DecodeAddress(source)
+	return err
+}
+
+// CreateAssetParam registers the asset and issues the initial supply to the issuer
+type CreateAssetParam struct {
+	Asset         AssetInfo
+	InitialSupply uint64
+}
+
+func (this *CreateAssetParam) Serialization(sink *common.ZeroCopySink) {
+	this.Asset.Serialization(sink)
+	utils.EncodeVarUint(sink, this.InitialSupply)
+}
+
+func (this *CreateAssetParam) Deserialization(source *common.ZeroCopySource) error {
+	if err := this.Asset.Deserialization(source); err != nil {
+		return err
+	}
+	var err error
+	this.InitialSupply, err = utils.DecodeVarUint(source)
+	return err
+}
+
+// SupplyParam is the param of mint and burn, Account is the receiver of mint or the holder to burn from
+type SupplyParam struct {
+	Symbol  string
+	Account common.Address
+	Value   uint64
+}
+
+func (this *SupplyParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteString(this.Symbol)
+	utils.EncodeAddress(sink, this.Account)
+	utils.EncodeVarUint(sink, this.Value)
+}
+
+func (this *SupplyParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Symbol, err = decodeString(source); err != nil {
+		return err
+	}
+	if this.Account, err = utils.DecodeAddress(source); err != nil {
+		return err
+	}
+	this.Value, err = utils.DecodeVarUint(source)
+	return err
+}
+
+// TransferParam is the transfers of an asset
+type TransferParam struct {
+	Symbol    string
+	Transfers onx.Transfers
+}
+
+func (this *TransferParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteString(this.Symbol)
+	this.Transfers.Serialization(sink)
+}
+
+func (this *TransferParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Symbol, err = decodeString(source); err != nil {
+		return err
+	}
+	return this.Transfers.Deserialization(source)
+}
+
+// ApproveParam approves the allowance of an asset
+type ApproveParam struct {
+	Symbol string
+	State  onx.State
+}
+
+func (this *ApproveParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteString(this.Symbol)
+	this.State.Serialization(sink)
+}
+
+func (this *ApproveParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Symbol, err = decodeString(source); err != nil {
+		return err
+	}
+	return this.State.Deserialization(source)
+}
+
+// TransferFromParam transfers an asset from the allowance
+type TransferFromParam struct {
+	Symbol       string
+	TransferFrom onx.TransferFrom
+}
+
+func (this *TransferFromParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteString(this.Symbol)
+	this.TransferFrom.Serialization(sink)
+}
+
+func (this *TransferFromParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Symbol, err = decodeString(source); err != nil {
+		return err
+	}
+	return this.TransferFrom.Deserialization(source)
+}
+
+func decodeString(source *common.ZeroCopySource) (string, error) {
+	str, _, irregular, eof := source.NextString()
+	if eof {
+		return "", io.ErrUnexpectedEOF
+	}
+	if irregular {
+		return "", common.ErrIrregularData
+	}
+	return str, nil
+}
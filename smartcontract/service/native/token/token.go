@@ -0,0 +1,302 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package token is the registry of fungible assets issued by users, each asset
+// is identified by its symbol and shares the transfer semantics of onx
+package token
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/errors"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/onx"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+)
+
+func InitToken() {
+	native.Contracts[utils.TokenContractAddress] = RegisterTokenContract
+}
+
+func RegisterTokenContract(native *native.NativeService) {
+	native.Register(CREATE_ASSET_NAME, CreateAsset)
+	native.Register(MINT_NAME, Mint)
+	native.Register(BURN_NAME, Burn)
+	native.Register(TRANSFER_NAME, Transfer)
+	native.Register(APPROVE_NAME, Approve)
+	native.Register(TRANSFERFROM_NAME, TransferFrom)
+	native.Register(GET_ASSET_NAME, GetAsset)
+	native.Register(DECIMALS_NAME, Decimals)
+	native.Register(TOTALSUPPLY_NAME, TotalSupply)
+	native.Register(BALANCEOF_NAME, BalanceOf)
+	native.Register(ALLOWANCE_NAME, Allowance)
+}
+
+//CreateAsset register a new asset and issue the initial supply to the issuer
+func CreateAsset(native *native.NativeService) ([]byte, error) {
+	var param CreateAssetParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[CreateAsset] param deserialize error!")
+	}
+	asset := &param.Asset
+	if err := checkSymbol(asset.Symbol); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[CreateAsset] %v", err)
+	}
+	if len(asset.Name) == 0 || len(asset.Name) > MAX_NAME_LEN {
+		return utils.BYTE_FALSE, fmt.Errorf("[CreateAsset] name length should be between 1 and %d", MAX_NAME_LEN)
+	}
+	if asset.Decimals > MAX_DECIMALS {
+		return utils.BYTE_FALSE, fmt.Errorf("[CreateAsset] decimals should not be greater than %d", MAX_DECIMALS)
+	}
+	if asset.Cap != 0 && param.InitialSupply > asset.Cap {
+		return utils.BYTE_FALSE, fmt.Errorf("[CreateAsset] initial supply %d over cap %d", param.InitialSupply, asset.Cap)
+	}
+	if !native.ContextRef.CheckWitness(asset.Issuer) {
+		return utils.BYTE_FALSE, errors.NewErr("[CreateAsset] authentication failed!")
+	}
+	item, err := utils.GetStorageItem(native, genAssetKey(asset.Symbol))
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	if item != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[CreateAsset] asset %s already exists", asset.Symbol)
+	}
+
+	putAsset(native, asset)
+	if param.InitialSupply > 0 {
+		putUInt64(native, genTotalSupplyKey(asset.Symbol), param.InitialSupply)
+		putUInt64(native, genBalanceKey(asset.Symbol, asset.Issuer), param.InitialSupply)
+		addNotifications(native, asset.Symbol, &onx.State{To: asset.Issuer, Value: param.InitialSupply})
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+//Mint issue more supply to the account, only allowed by the mint authority
+func Mint(native *native.NativeService) ([]byte, error) {
+	var param SupplyParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[Mint] param deserialize error!")
+	}
+	asset, err := getAsset(native, param.Symbol)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Mint] %v", err)
+	}
+	if asset.MintAuthority == common.ADDRESS_EMPTY {
+		return utils.BYTE_FALSE, fmt.Errorf("[Mint] asset %s is not mintable", param.Symbol)
+	}
+	if !native.ContextRef.CheckWitness(asset.MintAuthority) {
+		return utils.BYTE_FALSE, errors.NewErr("[Mint] authentication failed!")
+	}
+	if param.Value == 0 {
+		return utils.BYTE_TRUE, nil
+	}
+	supply, err := utils.GetStorageUInt64(native, genTotalSupplyKey(param.Symbol))
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	supply, overflow := common.SafeAdd(supply, param.Value)
+	if overflow || (asset.Cap != 0 && supply > asset.Cap) {
+		return utils.BYTE_FALSE, fmt.Errorf("[Mint] total supply over cap %d", asset.Cap)
+	}
+	if err := addBalance(native, param.Symbol, param.Account, param.Value); err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	putUInt64(native, genTotalSupplyKey(param.Symbol), supply)
+	addNotifications(native, param.Symbol, &onx.State{To: param.Account, Value: param.Value})
+	return utils.BYTE_TRUE, nil
+}
+
+//Burn destroy the supply of the account, both the burn authority and the holder should sign
+func Burn(native *native.NativeService) ([]byte, error) {
+	var param SupplyParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[Burn] param deserialize error!")
+	}
+	asset, err := getAsset(native, param.Symbol)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Burn] %v", err)
+	}
+	if asset.BurnAuthority == common.ADDRESS_EMPTY {
+		return utils.BYTE_FALSE, fmt.Errorf("[Burn] asset %s is not burnable", param.Symbol)
+	}
+	if !native.ContextRef.CheckWitness(asset.BurnAuthority) || !native.ContextRef.CheckWitness(param.Account) {
+		return utils.BYTE_FALSE, errors.NewErr("[Burn] authentication failed!")
+	}
+	if param.Value == 0 {
+		return utils.BYTE_TRUE, nil
+	}
+	if err := subBalance(native, param.Symbol, param.Account, param.Value); err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	supply, err := utils.GetStorageUInt64(native, genTotalSupplyKey(param.Symbol))
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	putUInt64(native, genTotalSupplyKey(param.Symbol), supply-param.Value)
+	addNotifications(native, param.Symbol, &onx.State{From: param.Account, Value: param.Value})
+	return utils.BYTE_TRUE, nil
+}
+
+func Transfer(native *native.NativeService) ([]byte, error) {
+	var param TransferParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[Transfer] Transfers deserialize error!")
+	}
+	if _, err := getAsset(native, param.Symbol); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Transfer] %v", err)
+	}
+	for _, v := range param.Transfers.States {
+		if v.Value == 0 {
+			continue
+		}
+		if !native.ContextRef.CheckWitness(v.From) {
+			return utils.BYTE_FALSE, errors.NewErr("authentication failed!")
+		}
+		if err := transfer(native, param.Symbol, &v); err != nil {
+			return utils.BYTE_FALSE, err
+		}
+		addNotifications(native, param.Symbol, &v)
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+func TransferFrom(native *native.NativeService) ([]byte, error) {
+	var param TransferFromParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[TransferFrom] State deserialize error!")
+	}
+	state := &param.TransferFrom
+	if state.Value == 0 {
+		return utils.BYTE_FALSE, nil
+	}
+	if _, err := getAsset(native, param.Symbol); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[TransferFrom] %v", err)
+	}
+	if !native.ContextRef.CheckWitness(state.Sender) {
+		return utils.BYTE_FALSE, errors.NewErr("authentication failed!")
+	}
+	key := genAllowanceKey(param.Symbol, state.From, state.Sender)
+	allowance, err := utils.GetStorageUInt64(native, key)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	if allowance < state.Value {
+		return utils.BYTE_FALSE, fmt.Errorf("[TransferFrom] approve balance insufficient! have %d, got %d", allowance, state.Value)
+	}
+	putUInt64(native, key, allowance-state.Value)
+	transferState := &onx.State{From: state.From, To: state.To, Value: state.Value}
+	if err := transfer(native, param.Symbol, transferState); err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	addNotifications(native, param.Symbol, transferState)
+	return utils.BYTE_TRUE, nil
+}
+
+func Approve(native *native.NativeService) ([]byte, error) {
+	var param ApproveParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[Approve] state deserialize error!")
+	}
+	if _, err := getAsset(native, param.Symbol); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Approve] %v", err)
+	}
+	if !native.ContextRef.CheckWitness(param.State.From) {
+		return utils.BYTE_FALSE, errors.NewErr("authentication failed!")
+	}
+	putUInt64(native, genAllowanceKey(param.Symbol, param.State.From, param.State.To), param.State.Value)
+	return utils.BYTE_TRUE, nil
+}
+
+//GetAsset return the serialized AssetInfo of the symbol
+func GetAsset(native *native.NativeService) ([]byte, error) {
+	symbol, err := decodeString(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[GetAsset] symbol deserialize error!")
+	}
+	asset, err := getAsset(native, symbol)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetAsset] %v", err)
+	}
+	sink := common.NewZeroCopySink(nil)
+	asset.Serialization(sink)
+	return sink.Bytes(), nil
+}
+
+func Decimals(native *native.NativeService) ([]byte, error) {
+	symbol, err := decodeString(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[Decimals] symbol deserialize error!")
+	}
+	asset, err := getAsset(native, symbol)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Decimals] %v", err)
+	}
+	return common.BigIntToNeoBytes(big.NewInt(int64(asset.Decimals))), nil
+}
+
+func TotalSupply(native *native.NativeService) ([]byte, error) {
+	symbol, err := decodeString(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[TotalSupply] symbol deserialize error!")
+	}
+	amount, err := utils.GetStorageUInt64(native, genTotalSupplyKey(symbol))
+	if err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[TotalSupply] get totalSupply error!")
+	}
+	return common.BigIntToNeoBytes(new(big.Int).SetUint64(amount)), nil
+}
+
+func BalanceOf(native *native.NativeService) ([]byte, error) {
+	source := common.NewZeroCopySource(native.Input)
+	symbol, err := decodeString(source)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[BalanceOf] symbol deserialize error!")
+	}
+	addr, err := utils.DecodeAddress(source)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[BalanceOf] get address error!")
+	}
+	amount, err := utils.GetStorageUInt64(native, genBalanceKey(symbol, addr))
+	if err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[BalanceOf] get balance error!")
+	}
+	return common.BigIntToNeoBytes(new(big.Int).SetUint64(amount)), nil
+}
+
+func Allowance(native *native.NativeService) ([]byte, error) {
+	source := common.NewZeroCopySource(native.Input)
+	symbol, err := decodeString(source)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[Allowance] symbol deserialize error!")
+	}
+	from, err := utils.DecodeAddress(source)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[Allowance] get from address error!")
+	}
+	to, err := utils.DecodeAddress(source)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[Allowance] get to address error!")
+	}
+	amount, err := utils.GetStorageUInt64(native, genAllowanceKey(symbol, from, to))
+	if err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[Allowance] get allowance error!")
+	}
+	return common.BigIntToNeoBytes(new(big.Int).SetUint64(amount)), nil
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package token
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/core/store/overlaydb"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/onx"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/testsuite"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type symbolParam string
+
+func (this symbolParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteString(string(this))
+}
+
+type balanceParam struct {
+	symbol string
+	addr   common.Address
+}
+
+func (this *balanceParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteString(this.symbol)
+	utils.EncodeAddress(sink, this.addr)
+}
+
+//invoke call the token contract in a transaction signed by the signer
+func invoke(db *overlaydb.OverlayDB, signer common.Address, method string, p testsuite.Param) ([]byte, error) {
+	return testsuite.Invoke(db, &testsuite.Tx{Signer: signer}, utils.TokenContractAddress, method, p)
+}
+
+func balanceOf(t *testing.T, db *overlaydb.OverlayDB, symbol string, addr common.Address) uint64 {
+	result, err := invoke(db, addr, BALANCEOF_NAME, &balanceParam{symbol, addr})
+	assert.Nil(t, err)
+	return common.BigIntFromNeoBytes(result).Uint64()
+}
+
+func TestToken(t *testing.T) {
+	InitToken()
+	db := testsuite.NewDB()
+
+	issuer := common.Address{1}
+	minter := common.Address{2}
+	alice := common.Address{3}
+	create := &CreateAssetParam{
+		Asset: AssetInfo{
+			Symbol:        "USDX",
+			Name:          "USD Stable",
+			Decimals:      6,
+			Cap:           1000,
+			Issuer:        issuer,
+			MintAuthority: minter,
+		},
+		InitialSupply: 100,
+	}
+	_, err := invoke(db, alice, CREATE_ASSET_NAME, create)
+	assert.NotNil(t, err)
+	_, err = invoke(db, issuer, CREATE_ASSET_NAME, create)
+	assert.Nil(t, err)
+	_, err = invoke(db, issuer, CREATE_ASSET_NAME, create)
+	assert.NotNil(t, err)
+	assert.Equal(t, uint64(100), balanceOf(t, db, "USDX", issuer))
+
+	result, err := invoke(db, issuer, GET_ASSET_NAME, symbolParam("USDX"))
+	assert.Nil(t, err)
+	var asset AssetInfo
+	assert.Nil(t, asset.Deserialization(common.NewZeroCopySource(result)))
+	assert.Equal(t, create.Asset, asset)
+
+	transfer := &TransferParam{
+		Symbol:    "USDX",
+		Transfers: onx.Transfers{States: []onx.State{{From: issuer, To: alice, Value: 30}}},
+	}
+	_, err = invoke(db, alice, TRANSFER_NAME, transfer)
+	assert.NotNil(t, err)
+	_, err = invoke(db, issuer, TRANSFER_NAME, transfer)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(70), balanceOf(t, db, "USDX", issuer))
+	assert.Equal(t, uint64(30), balanceOf(t, db, "USDX", alice))
+
+	_, err = invoke(db, issuer, APPROVE_NAME, &ApproveParam{"USDX", onx.State{From: issuer, To: alice, Value: 10}})
+	assert.Nil(t, err)
+	transferFrom := &TransferFromParam{"USDX", onx.TransferFrom{Sender: alice, From: issuer, To: alice, Value: 11}}
+	_, err = invoke(db, alice, TRANSFERFROM_NAME, transferFrom)
+	assert.NotNil(t, err)
+	transferFrom.TransferFrom.Value = 10
+	_, err = invoke(db, alice, TRANSFERFROM_NAME, transferFrom)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(40), balanceOf(t, db, "USDX", alice))
+
+	_, err = invoke(db, issuer, MINT_NAME, &SupplyParam{"USDX", alice, 10})
+	assert.NotNil(t, err)
+	_, err = invoke(db, minter, MINT_NAME, &SupplyParam{"USDX", alice, 901})
+	assert.NotNil(t, err)
+	_, err = invoke(db, minter, MINT_NAME, &SupplyParam{"USDX", alice, 900})
+	assert.Nil(t, err)
+	result, err = invoke(db, issuer, TOTALSUPPLY_NAME, symbolParam("USDX"))
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(1000), common.BigIntFromNeoBytes(result))
+
+	_, err = invoke(db, alice, BURN_NAME, &SupplyParam{"USDX", alice, 1})
+	assert.NotNil(t, err)
+}
+
+func TestCheckSymbol(t *testing.T) {
+	assert.Nil(t, checkSymbol("USD1"))
+	assert.NotNil(t, checkSymbol("U"))
+	assert.NotNil(t, checkSymbol("usd"))
+	assert.NotNil(t, checkSymbol("USD-1"))
+	assert.NotNil(t, checkSymbol("ABCDEFGHIJKLMNOPQ"))
+}
@@ -0,0 +1,170 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package token
+
+import (
+	"fmt"
+
+	"github.com/OnyxPay/OnyxChain/common"
+	"github.com/OnyxPay/OnyxChain/common/config"
+	"github.com/OnyxPay/OnyxChain/errors"
+	"github.com/OnyxPay/OnyxChain/smartcontract/event"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/onx"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
+)
+
+const (
+	//function name
+	CREATE_ASSET_NAME = "createAsset"
+	MINT_NAME         = "mint"
+	BURN_NAME         = "burn"
+	TRANSFER_NAME     = onx.TRANSFER_NAME
+	APPROVE_NAME      = onx.APPROVE_NAME
+	TRANSFERFROM_NAME = onx.TRANSFERFROM_NAME
+	GET_ASSET_NAME    = "getAsset"
+	DECIMALS_NAME     = onx.DECIMALS_NAME
+	TOTALSUPPLY_NAME  = onx.TOTALSUPPLY_NAME
+	BALANCEOF_NAME    = onx.BALANCEOF_NAME
+	ALLOWANCE_NAME    = onx.ALLOWANCE_NAME
+
+	//key prefix
+	ASSET        = "asset"
+	TOTAL_SUPPLY = "totalSupply"
+	BALANCE      = "balance"
+	ALLOWANCE    = "allowance"
+
+	MIN_SYMBOL_LEN = 2
+	MAX_SYMBOL_LEN = 16
+	MAX_NAME_LEN   = 64
+	MAX_DECIMALS   = 18
+)
+
+//checkSymbol check the symbol is made up of upper case letters and digits
+func checkSymbol(symbol string) error {
+	if len(symbol) < MIN_SYMBOL_LEN || len(symbol) > MAX_SYMBOL_LEN {
+		return fmt.Errorf("symbol length should be between %d and %d", MIN_SYMBOL_LEN, MAX_SYMBOL_LEN)
+	}
+	for _, c := range symbol {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return fmt.Errorf("symbol %s should only contain upper case letters and digits", symbol)
+		}
+	}
+	return nil
+}
+
+func genSymbolKey(prefix string, symbol string) []byte {
+	key := append(utils.TokenContractAddress[:], prefix...)
+	key = append(key, byte(len(symbol)))
+	return append(key, symbol...)
+}
+
+func genAssetKey(symbol string) []byte {
+	return genSymbolKey(ASSET, symbol)
+}
+
+func genTotalSupplyKey(symbol string) []byte {
+	return genSymbolKey(TOTAL_SUPPLY, symbol)
+}
+
+func genBalanceKey(symbol string, addr common.Address) []byte {
+	return append(genSymbolKey(BALANCE, symbol), addr[:]...)
+}
+
+func genAllowanceKey(symbol string, from, to common.Address) []byte {
+	key := append(genSymbolKey(ALLOWANCE, symbol), from[:]...)
+	return append(key, to[:]...)
+}
+
+func getAsset(native *native.NativeService, symbol string) (*AssetInfo, error) {
+	item, err := utils.GetStorageItem(native, genAssetKey(symbol))
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, fmt.Errorf("asset %s not exist", symbol)
+	}
+	asset := new(AssetInfo)
+	if err := asset.Deserialization(common.NewZeroCopySource(item.Value)); err != nil {
+		return nil, fmt.Errorf("deserialize asset %s error:%v", symbol, err)
+	}
+	return asset, nil
+}
+
+func putAsset(native *native.NativeService, asset *AssetInfo) {
+	sink := common.NewZeroCopySink(nil)
+	asset.Serialization(sink)
+	utils.PutBytes(native, genAssetKey(asset.Symbol), sink.Bytes())
+}
+
+func putUInt64(native *native.NativeService, key []byte, value uint64) {
+	if value == 0 {
+		native.CacheDB.Delete(key)
+		return
+	}
+	native.CacheDB.Put(key, utils.GenUInt64StorageItem(value).ToArray())
+}
+
+func subBalance(native *native.NativeService, symbol string, from common.Address, value uint64) error {
+	key := genBalanceKey(symbol, from)
+	balance, err := utils.GetStorageUInt64(native, key)
+	if err != nil {
+		return err
+	}
+	if balance < value {
+		return fmt.Errorf("[Transfer] balance insufficient. asset:%s, account:%s, balance:%d, transfer amount:%d",
+			symbol, from.ToBase58(), balance, value)
+	}
+	putUInt64(native, key, balance-value)
+	return nil
+}
+
+func addBalance(native *native.NativeService, symbol string, to common.Address, value uint64) error {
+	key := genBalanceKey(symbol, to)
+	balance, err := utils.GetStorageUInt64(native, key)
+	if err != nil {
+		return err
+	}
+	balance, overflow := common.SafeAdd(balance, value)
+	if overflow {
+		return errors.NewErr("[Transfer] balance overflow")
+	}
+	putUInt64(native, key, balance)
+	return nil
+}
+
+//transfer move the asset between accounts, the witness is checked by the caller
+func transfer(native *native.NativeService, symbol string, state *onx.State) error {
+	if err := subBalance(native, symbol, state.From, state.Value); err != nil {
+		return err
+	}
+	return addBalance(native, symbol, state.To, state.Value)
+}
+
+//addNotifications notify in the format of onx transfer followed by the asset symbol
+func addNotifications(native *native.NativeService, symbol string, state *onx.State) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.Notifications = append(native.Notifications,
+		&event.NotifyEventInfo{
+			ContractAddress: utils.TokenContractAddress,
+			States:          []interface{}{TRANSFER_NAME, state.From.ToBase58(), state.To.ToBase58(), state.Value, symbol},
+		})
+}
@@ -25,12 +25,14 @@ import (
 	"github.com/OnyxPay/OnyxChain-crypto/keypair"
 	scommon "github.com/OnyxPay/OnyxChain/common"
 	"github.com/OnyxPay/OnyxChain/common/log"
+	"github.com/OnyxPay/OnyxChain/core/payload"
 	"github.com/OnyxPay/OnyxChain/core/signature"
 	"github.com/OnyxPay/OnyxChain/core/store"
 	"github.com/OnyxPay/OnyxChain/core/types"
 	"github.com/OnyxPay/OnyxChain/errors"
 	"github.com/OnyxPay/OnyxChain/smartcontract/context"
 	"github.com/OnyxPay/OnyxChain/smartcontract/event"
+	"github.com/OnyxPay/OnyxChain/smartcontract/states"
 	"github.com/OnyxPay/OnyxChain/smartcontract/storage"
 	vm "github.com/OnyxPay/OnyxChain/vm/neovm"
 	ntypes "github.com/OnyxPay/OnyxChain/vm/neovm/types"
@@ -156,15 +158,18 @@ func (this *NeoVmService) Invoke() (interface{}, error) {
 				return nil, ERR_CHECK_STACK_SIZE
 			}
 		}
+		var price uint64
 		if this.Engine.OpCode >= vm.PUSHBYTES1 && this.Engine.OpCode <= vm.PUSHBYTES75 {
-			if !this.ContextRef.CheckUseGas(OPCODE_GAS) {
+			price = OPCODE_GAS
+			if !this.ContextRef.CheckUseGas(price) {
 				return nil, ERR_GAS_INSUFFICIENT
 			}
 		} else {
 			if err := this.Engine.ValidateOp(); err != nil {
 				return nil, err
 			}
-			price, err := GasPrice(this.Engine, this.Engine.OpExec.Name)
+			var err error
+			price, err = GasPrice(this.Engine, this.Engine.OpExec.Name)
 			if err != nil {
 				return nil, err
 			}
@@ -172,6 +177,9 @@ func (this *NeoVmService) Invoke() (interface{}, error) {
 				return nil, ERR_GAS_INSUFFICIENT
 			}
 		}
+		if this.Engine.Tracer != nil {
+			this.Engine.Tracer.CaptureStep(this.Engine, this.ContextRef.CurrentContext().ContractAddress.ToHexString(), price)
+		}
 		switch this.Engine.OpCode {
 		case vm.VERIFY:
 			if vm.EvaluationStackCount(this.Engine) < 3 {
@@ -223,15 +231,23 @@ func (this *NeoVmService) Invoke() (interface{}, error) {
 			if err != nil {
 				return nil, err
 			}
-			code, err := this.getContract(addr)
+			dep, err := this.getContract(addr)
 			if err != nil {
 				return nil, err
 			}
-			service, err := this.ContextRef.NewExecuteEngine(code)
-			if err != nil {
-				return nil, err
+			var service context.Engine
+			if dep.VmType == payload.WASMVM_TYPE {
+				service, err = this.newWasmEngine(addr, dep.Code)
+				if err != nil {
+					return nil, err
+				}
+			} else {
+				service, err = this.ContextRef.NewExecuteEngine(dep.Code)
+				if err != nil {
+					return nil, err
+				}
+				this.Engine.EvaluationStack.CopyTo(service.(*NeoVmService).Engine.EvaluationStack)
 			}
-			this.Engine.EvaluationStack.CopyTo(service.(*NeoVmService).Engine.EvaluationStack)
 			result, err := service.Invoke()
 			if err != nil {
 				return nil, err
@@ -281,13 +297,16 @@ func (this *NeoVmService) SystemCall(engine *vm.ExecutionEngine) error {
 	if !this.ContextRef.CheckUseGas(price) {
 		return ERR_GAS_INSUFFICIENT
 	}
+	if engine.Tracer != nil {
+		engine.Tracer.CaptureSyscall(engine, serviceName, price)
+	}
 	if err := service.Execute(this, engine); err != nil {
 		return errors.NewDetailErr(err, errors.ErrNoCode, "[SystemCall] service execute error!")
 	}
 	return nil
 }
 
-func (this *NeoVmService) getContract(address scommon.Address) ([]byte, error) {
+func (this *NeoVmService) getContract(address scommon.Address) (*payload.DeployCode, error) {
 	dep, err := this.CacheDB.GetContract(address)
 	if err != nil {
 		return nil, errors.NewErr("[getContract] Get contract context error!")
@@ -296,7 +315,27 @@ func (this *NeoVmService) getContract(address scommon.Address) ([]byte, error) {
 	if dep == nil {
 		return nil, CONTRACT_NOT_EXIST
 	}
-	return dep.Code, nil
+	return dep, nil
+}
+
+// newWasmEngine pop the method and args of wasm contract from evaluation stack
+func (this *NeoVmService) newWasmEngine(address scommon.Address, code []byte) (context.Engine, error) {
+	if vm.EvaluationStackCount(this.Engine) < 2 {
+		return nil, fmt.Errorf("[Appcall] Too few input parameters for wasm contract:%d", vm.EvaluationStackCount(this.Engine))
+	}
+	method, err := vm.PopByteArray(this.Engine)
+	if err != nil {
+		return nil, fmt.Errorf("[Appcall] pop wasm contract method error:%v", err)
+	}
+	args, err := vm.PopByteArray(this.Engine)
+	if err != nil {
+		return nil, fmt.Errorf("[Appcall] pop wasm contract args error:%v", err)
+	}
+	return this.ContextRef.NewWasmExecuteEngine(code, states.ContractInvokeParam{
+		Address: address,
+		Method:  string(method),
+		Args:    args,
+	})
 }
 
 func checkStackSize(engine *vm.ExecutionEngine) bool {
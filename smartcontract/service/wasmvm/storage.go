@@ -18,10 +18,10 @@
 package wasmvm
 
 import (
-	"bytes"
 	"github.com/OnyxPay/OnyxChain/common"
 	"github.com/OnyxPay/OnyxChain/core/states"
 	"github.com/OnyxPay/OnyxChain/errors"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/neovm"
 	"github.com/OnyxPay/OnyxChain/vm/wasmvm/exec"
 	"github.com/OnyxPay/OnyxChain/vm/wasmvm/memory"
 	"github.com/OnyxPay/OnyxChain/vm/wasmvm/util"
@@ -48,6 +48,11 @@ func (this *WasmVmService) putstore(engine *exec.ExecutionEngine) (bool, error)
 	if err != nil {
 		return false, err
 	}
+	price, ok := neovm.GAS_TABLE.Load(neovm.STORAGE_PUT_NAME)
+	if !ok {
+		return false, errors.NewErr("[putstore] get STORAGE_PUT_NAME gas failed")
+	}
+	engine.UseGas(uint64((len(key)+len(value)-1)/1024+1) * price.(uint64))
 	k, err := serializeStorageKey(vm.ContractAddress, []byte(util.TrimBuffToString(key)))
 	if err != nil {
 		return false, err
@@ -72,6 +77,9 @@ func (this *WasmVmService) getstore(engine *exec.ExecutionEngine) (bool, error)
 	if err != nil {
 		return false, err
 	}
+	if err := useServiceGas(engine, neovm.STORAGE_GET_NAME); err != nil {
+		return false, err
+	}
 	k, err := serializeStorageKey(vm.ContractAddress, []byte(util.TrimBuffToString(key)))
 	if err != nil {
 		return false, err
@@ -81,7 +89,7 @@ func (this *WasmVmService) getstore(engine *exec.ExecutionEngine) (bool, error)
 		return false, err
 	}
 
-	if item == nil {
+	if len(item) == 0 {
 		vm.RestoreCtx()
 		if envCall.GetReturns() {
 			vm.PushResult(uint64(memory.VM_NIL_POINTER))
@@ -118,6 +126,9 @@ func (this *WasmVmService) deletestore(engine *exec.ExecutionEngine) (bool, erro
 	if err != nil {
 		return false, err
 	}
+	if err := useServiceGas(engine, neovm.STORAGE_DELETE_NAME); err != nil {
+		return false, err
+	}
 
 	k, err := serializeStorageKey(vm.ContractAddress, []byte(util.TrimBuffToString(key)))
 	if err != nil {
@@ -130,11 +141,19 @@ func (this *WasmVmService) deletestore(engine *exec.ExecutionEngine) (bool, erro
 	return true, nil
 }
 
-func serializeStorageKey(contractAddress common.Address, key []byte) ([]byte, error) {
-	bf := new(bytes.Buffer)
-	storageKey := &states.StorageKey{ContractAddress: contractAddress, Key: key}
-	if _, err := storageKey.Serialize(bf); err != nil {
-		return []byte{}, errors.NewErr("[serializeStorageKey] StorageKey serialize error!")
+// useServiceGas charge the service price of the neovm gas table
+func useServiceGas(engine *exec.ExecutionEngine, name string) error {
+	price, ok := neovm.GAS_TABLE.Load(name)
+	if !ok {
+		return errors.NewErr("[useServiceGas] get " + name + " gas failed")
 	}
-	return bf.Bytes(), nil
+	engine.UseGas(price.(uint64))
+	return nil
+}
+
+func serializeStorageKey(contractAddress common.Address, key []byte) ([]byte, error) {
+	res := make([]byte, 0, len(contractAddress[:])+len(key))
+	res = append(res, contractAddress[:]...)
+	res = append(res, key...)
+	return res, nil
 }
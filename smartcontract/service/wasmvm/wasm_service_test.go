@@ -0,0 +1,46 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package wasmvm
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestVerifyContractCode(t *testing.T) {
+	code, err := ioutil.ReadFile("../../../vm/wasmvm/exec/test_data2/contract.wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyContractCode(code); err != nil {
+		t.Errorf("VerifyContractCode should accept contract with invoke entry: %s", err)
+	}
+
+	code, err = ioutil.ReadFile("../../../vm/wasmvm/exec/test_data2/add.wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyContractCode(code); err == nil {
+		t.Error("VerifyContractCode should reject contract without invoke entry")
+	}
+
+	if err := VerifyContractCode([]byte{0x00, 0x61, 0x73}); err == nil {
+		t.Error("VerifyContractCode should reject invalid module")
+	}
+}
@@ -28,6 +28,8 @@ import (
 	"github.com/OnyxPay/OnyxChain/smartcontract/event"
 	"github.com/OnyxPay/OnyxChain/smartcontract/service/native"
 	"github.com/OnyxPay/OnyxChain/smartcontract/service/neovm"
+	"github.com/OnyxPay/OnyxChain/smartcontract/service/wasmvm"
+	"github.com/OnyxPay/OnyxChain/smartcontract/states"
 	"github.com/OnyxPay/OnyxChain/smartcontract/storage"
 	vm "github.com/OnyxPay/OnyxChain/vm/neovm"
 )
@@ -46,6 +48,7 @@ type SmartContract struct {
 	Gas           uint64
 	ExecStep      int
 	PreExec       bool
+	Tracer        vm.Tracer // neovm execution tracer, nil if not tracing
 }
 
 // Config describe smart contract need parameters configuration
@@ -138,6 +141,28 @@ func (this *SmartContract) NewExecuteEngine(code []byte) (context.Engine, error)
 		Engine:     vm.NewExecutionEngine(),
 		PreExec:    this.PreExec,
 	}
+	service.Engine.Tracer = this.Tracer
+	return service, nil
+}
+
+// NewWasmExecuteEngine return the engine of a deployed wasm contract
+// The contract "invoke" entry receive the method and args of param
+func (this *SmartContract) NewWasmExecuteEngine(code []byte, param states.ContractInvokeParam) (context.Engine, error) {
+	if !this.checkContexts() {
+		return nil, fmt.Errorf("%s", "engine over max limit!")
+	}
+	service := &wasmvm.WasmVmService{
+		Store:       this.Store,
+		CacheDB:     this.CacheDB,
+		ContextRef:  this,
+		Code:        code,
+		InvokeParam: param,
+		Tx:          this.Config.Tx,
+		Time:        this.Config.Time,
+		Height:      this.Config.Height,
+		BlockHash:   this.Config.BlockHash,
+		PreExec:     this.PreExec,
+	}
 	return service, nil
 }
 
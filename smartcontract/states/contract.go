@@ -116,3 +116,11 @@ type PreExecResult struct {
 	Result interface{}
 	Notify []*event.NotifyEventInfo
 }
+
+//TraceResult is the result of re-executing a transaction with a tracer
+type TraceResult struct {
+	State  byte
+	Gas    uint64
+	Error  string
+	Notify []*event.NotifyEventInfo
+}
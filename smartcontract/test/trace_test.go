@@ -0,0 +1,110 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package test
+
+import (
+	"testing"
+
+	"github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/OnyxPay/OnyxChain/smartcontract"
+	"github.com/OnyxPay/OnyxChain/vm/neovm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceExecution(t *testing.T) {
+	byteCode := []byte{
+		byte(neovm.PUSH1),
+		byte(neovm.PUSH2),
+		byte(neovm.ADD),
+		0x02, 0xab, 0xcd, // PUSHBYTES2
+		byte(neovm.DROP),
+	}
+
+	logger := neovm.NewStructLogger(0)
+	sc := smartcontract.SmartContract{
+		Config: &smartcontract.Config{
+			Time:   10,
+			Height: 10,
+			Tx:     &types.Transaction{},
+		},
+		Gas:    100,
+		Tracer: logger,
+	}
+	engine, err := sc.NewExecuteEngine(byteCode)
+	assert.Nil(t, err)
+	_, err = engine.Invoke()
+	assert.Nil(t, err)
+
+	ops := []string{"PUSH1", "PUSH2", "ADD", "PUSHBYTES2", "DROP"}
+	assert.Equal(t, len(ops), len(logger.Steps))
+	for i, step := range logger.Steps {
+		assert.Equal(t, ops[i], step.Op)
+	}
+	assert.Equal(t, []string{"2", "1"}, logger.Steps[2].Stack)
+	assert.Equal(t, 3, logger.Steps[3].Pc)
+	assert.Equal(t, []string{"abcd", "3"}, logger.Steps[4].Stack)
+	assert.Equal(t, 100-sc.Gas, logger.GasUsed)
+	assert.Equal(t, logger.GasUsed, logger.Steps[4].GasUsed)
+	assert.False(t, logger.Truncated)
+}
+
+func TestTraceStepLimit(t *testing.T) {
+	logger := neovm.NewStructLogger(2)
+	sc := smartcontract.SmartContract{
+		Config: &smartcontract.Config{Tx: &types.Transaction{}},
+		Gas:    100,
+		Tracer: logger,
+	}
+	engine, err := sc.NewExecuteEngine([]byte{byte(neovm.PUSH1), byte(neovm.PUSH2), byte(neovm.ADD)})
+	assert.Nil(t, err)
+	_, err = engine.Invoke()
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, len(logger.Steps))
+	assert.True(t, logger.Truncated)
+	assert.Equal(t, 100-sc.Gas, logger.GasUsed)
+}
+
+func TestTraceStackLimit(t *testing.T) {
+	byteCode := make([]byte, 0)
+	for i := 0; i < 20; i++ {
+		byteCode = append(byteCode, byte(neovm.PUSH1))
+	}
+	data := make([]byte, 100)
+	byteCode = append(byteCode, byte(neovm.PUSHDATA1), byte(len(data)))
+	byteCode = append(byteCode, data...)
+	byteCode = append(byteCode, byte(neovm.DROP))
+
+	logger := neovm.NewStructLogger(0)
+	sc := smartcontract.SmartContract{
+		Config: &smartcontract.Config{Tx: &types.Transaction{}},
+		Gas:    1000,
+		Tracer: logger,
+	}
+	engine, err := sc.NewExecuteEngine(byteCode)
+	assert.Nil(t, err)
+	_, err = engine.Invoke()
+	assert.Nil(t, err)
+
+	step := logger.Steps[len(logger.Steps)-1]
+	assert.Equal(t, "DROP", step.Op)
+	assert.Equal(t, 21, step.StackLen)
+	assert.Equal(t, neovm.MAX_TRACE_STACK_DEPTH, len(step.Stack))
+	assert.Equal(t, 2*neovm.MAX_TRACE_ITEM_BYTES+len("...(100 bytes)"), len(step.Stack[0]))
+}
@@ -20,8 +20,10 @@
 package common
 
 import (
+	"container/heap"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/OnyxPay/OnyxChain/common"
 	"github.com/OnyxPay/OnyxChain/common/config"
@@ -40,15 +42,24 @@ type TXAttr struct {
 type TXEntry struct {
 	Tx    *types.Transaction // transaction which has been verified
 	Attrs []*TXAttr          // the result from each validator
+	Time  time.Time          // the time when the transaction enters the pool
 }
 
 // TXPool contains all currently valid transactions. Transactions
 // enter the pool when they are valid from the network,
 // consensus or submitted. They exit the pool when they are included
-// in the ledger.
+// in the ledger. Transactions are queued by payer and ordered by nonce
+// in each queue, a pending transaction can be replaced by one with the
+// same payer and nonce but higher gas price, and the transaction with
+// the lowest gas price is evicted when the pool is full.
 type TXPool struct {
 	sync.RWMutex
-	txList map[common.Uint256]*TXEntry // Transactions which have been verified
+	txList       map[common.Uint256]*TXEntry            // Transactions which have been verified
+	accountTxs   map[common.Address]map[uint32]*TXEntry // Transactions of each payer indexed by nonce
+	priceHeap    txPriceHeap                            // Transactions ordered by gas price for eviction
+	maxTxCount   int                                    // Max transaction count in the pool, 0 means no limit
+	maxAccountTx int                                    // Max transaction count of a payer, 0 means no limit
+	stats        TxPoolStats                            // The metrics of the pool
 }
 
 // Init creates a new transaction pool to gather.
@@ -56,26 +67,142 @@ func (tp *TXPool) Init() {
 	tp.Lock()
 	defer tp.Unlock()
 	tp.txList = make(map[common.Uint256]*TXEntry)
+	tp.accountTxs = make(map[common.Address]map[uint32]*TXEntry)
+	tp.priceHeap = make(txPriceHeap, 0)
+	tp.maxTxCount = int(config.DefConfig.Common.MaxTxInPool)
+	tp.maxAccountTx = int(config.DefConfig.Common.MaxTxPerAccount)
+	tp.stats = TxPoolStats{}
 }
 
 // AddTxList adds a valid transaction to the transaction pool. If the
-// transaction is already in the pool, just return false. Parameter
-// txEntry includes transaction, fee, and verified information(height,
-// validator, error code).
-func (tp *TXPool) AddTxList(txEntry *TXEntry) bool {
+// transaction is already in the pool, return ErrDuplicateInput. If the
+// transaction has the same payer and nonce with a pending one, it replaces
+// the pending one only with a higher gas price. When the pool is full, the
+// transaction with the lowest gas price is evicted if the new one pays more.
+// Parameter txEntry includes transaction, fee, and verified information
+// (height, validator, error code).
+func (tp *TXPool) AddTxList(txEntry *TXEntry) errors.ErrCode {
 	tp.Lock()
 	defer tp.Unlock()
 	txHash := txEntry.Tx.Hash()
 	if _, ok := tp.txList[txHash]; ok {
 		log.Infof("AddTxList: transaction %x is already in the pool",
 			txHash)
-		return false
+		return errors.ErrDuplicateInput
+	}
+
+	errCode, removed := tp.checkTxLimit(txEntry.Tx)
+	if errCode != errors.ErrNoError {
+		log.Debugf("AddTxList: transaction %x is rejected, %s", txHash, errCode.Error())
+		return errCode
+	}
+	if removed != nil {
+		tp.removeTx(removed.Tx.Hash())
+		if removed.Tx.Payer == txEntry.Tx.Payer && removed.Tx.Nonce == txEntry.Tx.Nonce {
+			log.Debugf("AddTxList: transaction %x is replaced by %x", removed.Tx.Hash(), txHash)
+			tp.stats.Replaced++
+		} else {
+			log.Debugf("AddTxList: transaction %x is evicted by %x", removed.Tx.Hash(), txHash)
+			tp.stats.Evicted++
+		}
+	}
+	tp.addTx(txEntry)
+	return errors.ErrNoError
+}
+
+// CheckTxLimit checks whether a transaction can be accepted by the limits
+// of the pool, so that a transaction to be rejected is not verified.
+func (tp *TXPool) CheckTxLimit(tx *types.Transaction) errors.ErrCode {
+	tp.Lock()
+	defer tp.Unlock()
+	errCode, _ := tp.checkTxLimit(tx)
+	return errCode
+}
+
+// checkTxLimit returns the error code if the transaction exceeds the limits
+// of the pool, or the entry to be replaced or evicted by the transaction.
+func (tp *TXPool) checkTxLimit(tx *types.Transaction) (errors.ErrCode, *TXEntry) {
+	if old := tp.accountTxs[tx.Payer][tx.Nonce]; old != nil {
+		if tx.GasPrice <= old.Tx.GasPrice {
+			tp.stats.Rejected++
+			return errors.ErrUnderpriced, nil
+		}
+		return errors.ErrNoError, old
+	}
+	if tp.maxAccountTx > 0 && len(tp.accountTxs[tx.Payer]) >= tp.maxAccountTx {
+		tp.stats.Rejected++
+		return errors.ErrAccountTxLimit, nil
 	}
+	if tp.maxTxCount > 0 && len(tp.txList) >= tp.maxTxCount {
+		lowest := tp.lowestTx()
+		if lowest == nil || tx.GasPrice <= lowest.Tx.GasPrice {
+			tp.stats.Rejected++
+			return errors.ErrTxPoolFull, nil
+		}
+		return errors.ErrNoError, lowest
+	}
+	return errors.ErrNoError, nil
+}
 
-	tp.txList[txHash] = txEntry
+// addTx puts the transaction to the pool, the caller should hold the lock.
+func (tp *TXPool) addTx(txEntry *TXEntry) {
+	tx := txEntry.Tx
+	if txEntry.Time.IsZero() {
+		txEntry.Time = time.Now()
+	}
+	tp.txList[tx.Hash()] = txEntry
+	txs, ok := tp.accountTxs[tx.Payer]
+	if !ok {
+		txs = make(map[uint32]*TXEntry)
+		tp.accountTxs[tx.Payer] = txs
+	}
+	txs[tx.Nonce] = txEntry
+
+	// removed entries are dropped from the heap lazily, rebuild it when
+	// there are too many of them
+	if len(tp.priceHeap) > 2*len(tp.txList)+MIN_PRICE_HEAP_SIZE {
+		tp.priceHeap = make(txPriceHeap, 0, len(tp.txList))
+		for _, v := range tp.txList {
+			tp.priceHeap = append(tp.priceHeap, v)
+		}
+		heap.Init(&tp.priceHeap)
+		return
+	}
+	heap.Push(&tp.priceHeap, txEntry)
+}
+
+// removeTx removes the transaction from the pool, the caller should hold the lock.
+func (tp *TXPool) removeTx(hash common.Uint256) bool {
+	txEntry, ok := tp.txList[hash]
+	if !ok {
+		return false
+	}
+	delete(tp.txList, hash)
+	tx := txEntry.Tx
+	if txs, ok := tp.accountTxs[tx.Payer]; ok {
+		if txs[tx.Nonce] == txEntry {
+			delete(txs, tx.Nonce)
+		}
+		if len(txs) == 0 {
+			delete(tp.accountTxs, tx.Payer)
+		}
+	}
 	return true
 }
 
+// lowestTx returns the transaction with the lowest gas price in the pool,
+// the caller should hold the lock.
+func (tp *TXPool) lowestTx() *TXEntry {
+	for len(tp.priceHeap) > 0 {
+		txEntry := tp.priceHeap[0]
+		if tp.txList[txEntry.Tx.Hash()] == txEntry {
+			return txEntry
+		}
+		heap.Pop(&tp.priceHeap)
+	}
+	return nil
+}
+
 // CleanTransactionList cleans the transaction list included in the ledger.
 func (tp *TXPool) CleanTransactionList(txs []*types.Transaction) error {
 	cleaned := 0
@@ -83,8 +210,7 @@ func (tp *TXPool) CleanTransactionList(txs []*types.Transaction) error {
 	tp.Lock()
 	defer tp.Unlock()
 	for _, tx := range txs {
-		if _, ok := tp.txList[tx.Hash()]; ok {
-			delete(tp.txList, tx.Hash())
+		if tp.removeTx(tx.Hash()) {
 			cleaned++
 		}
 	}
@@ -98,12 +224,7 @@ func (tp *TXPool) CleanTransactionList(txs []*types.Transaction) error {
 func (tp *TXPool) DelTxList(tx *types.Transaction) bool {
 	tp.Lock()
 	defer tp.Unlock()
-	txHash := tx.Hash()
-	if _, ok := tp.txList[txHash]; !ok {
-		return false
-	}
-	delete(tp.txList, txHash)
-	return true
+	return tp.removeTx(tx.Hash())
 }
 
 // compareTxHeight compares a verifed transaction's height with the next
@@ -122,16 +243,23 @@ func (tp *TXPool) compareTxHeight(txEntry *TXEntry, height uint32) bool {
 // GetTxPool gets the transaction lists from the pool for the consensus,
 // if the byCount is marked, return the configured number at most; if the
 // the byCount is not marked, return all of the current transaction pool.
+// Transactions are ordered by gas price, while the transactions of the same
+// payer are kept in nonce order.
 func (tp *TXPool) GetTxPool(byCount bool, height uint32) ([]*TXEntry,
 	[]*types.Transaction) {
 	tp.RLock()
 	defer tp.RUnlock()
 
-	orderByFee := make([]*TXEntry, 0, len(tp.txList))
-	for _, txEntry := range tp.txList {
-		orderByFee = append(orderByFee, txEntry)
+	queues := make(txQueueHeap, 0, len(tp.accountTxs))
+	for _, txs := range tp.accountTxs {
+		queue := make([]*TXEntry, 0, len(txs))
+		for _, txEntry := range txs {
+			queue = append(queue, txEntry)
+		}
+		sort.Sort(OrderByNonce(queue))
+		queues = append(queues, queue)
 	}
-	sort.Sort(OrderByNetWorkFee(orderByFee))
+	heap.Init(&queues)
 
 	count := int(config.DefConfig.Consensus.MaxTxInBlock)
 	if count <= 0 {
@@ -141,19 +269,22 @@ func (tp *TXPool) GetTxPool(byCount bool, height uint32) ([]*TXEntry,
 		count = len(tp.txList)
 	}
 
-	var num int
 	txList := make([]*TXEntry, 0, count)
 	oldTxList := make([]*types.Transaction, 0)
-	for _, txEntry := range orderByFee {
+	for len(queues) > 0 && len(txList) < count {
+		queue := queues[0]
+		txEntry := queue[0]
+		if len(queue) == 1 {
+			heap.Pop(&queues)
+		} else {
+			queues[0] = queue[1:]
+			heap.Fix(&queues, 0)
+		}
 		if !tp.compareTxHeight(txEntry, height) {
 			oldTxList = append(oldTxList, txEntry.Tx)
 			continue
 		}
 		txList = append(txList, txEntry)
-		num++
-		if num >= count {
-			break
-		}
 	}
 
 	return txList, oldTxList
@@ -186,6 +317,17 @@ func (tp *TXPool) GetTxStatus(hash common.Uint256) *TxStatus {
 	return ret
 }
 
+// GetTxList returns a snapshot of the transactions in the pool.
+func (tp *TXPool) GetTxList() []*TXEntry {
+	tp.RLock()
+	defer tp.RUnlock()
+	ret := make([]*TXEntry, 0, len(tp.txList))
+	for _, txEntry := range tp.txList {
+		ret = append(ret, txEntry)
+	}
+	return ret
+}
+
 // GetTransactionCount returns the tx number of the pool.
 func (tp *TXPool) GetTransactionCount() int {
 	tp.RLock()
@@ -193,6 +335,15 @@ func (tp *TXPool) GetTransactionCount() int {
 	return len(tp.txList)
 }
 
+// GetStats returns the metrics of the pool.
+func (tp *TXPool) GetStats() TxPoolStats {
+	tp.RLock()
+	defer tp.RUnlock()
+	stats := tp.stats
+	stats.Accounts = uint32(len(tp.accountTxs))
+	return stats
+}
+
 // GetUnverifiedTxs checks the tx list in the block from consensus,
 // and returns verified tx list, unverified tx list, and
 // the tx list to be re-verified
@@ -214,7 +365,7 @@ func (tp *TXPool) GetUnverifiedTxs(txs []*types.Transaction,
 		}
 
 		if !tp.compareTxHeight(txEntry, height) {
-			delete(tp.txList, tx.Hash())
+			tp.removeTx(tx.Hash())
 			res.OldTxs = append(res.OldTxs, txEntry.Tx)
 			continue
 		}
@@ -242,7 +393,7 @@ func (tp *TXPool) RemoveTxsBelowGasPrice(gasPrice uint64) {
 	defer tp.Unlock()
 	for _, txEntry := range tp.txList {
 		if txEntry.Tx.GasPrice < gasPrice {
-			delete(tp.txList, txEntry.Tx.Hash())
+			tp.removeTx(txEntry.Tx.Hash())
 		}
 	}
 }
@@ -255,8 +406,49 @@ func (tp *TXPool) Remain() []*types.Transaction {
 	txList := make([]*types.Transaction, 0, len(tp.txList))
 	for _, txEntry := range tp.txList {
 		txList = append(txList, txEntry.Tx)
-		delete(tp.txList, txEntry.Tx.Hash())
 	}
+	tp.txList = make(map[common.Uint256]*TXEntry)
+	tp.accountTxs = make(map[common.Address]map[uint32]*TXEntry)
+	tp.priceHeap = make(txPriceHeap, 0)
 
 	return txList
 }
+
+// txPriceHeap is a min heap of transactions by gas price
+type txPriceHeap []*TXEntry
+
+func (h txPriceHeap) Len() int { return len(h) }
+
+func (h txPriceHeap) Less(i, j int) bool { return h[i].Tx.GasPrice < h[j].Tx.GasPrice }
+
+func (h txPriceHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *txPriceHeap) Push(x interface{}) { *h = append(*h, x.(*TXEntry)) }
+
+func (h *txPriceHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return x
+}
+
+// txQueueHeap is a max heap of payer queues by the gas price of the head transaction
+type txQueueHeap [][]*TXEntry
+
+func (h txQueueHeap) Len() int { return len(h) }
+
+func (h txQueueHeap) Less(i, j int) bool { return h[i][0].Tx.GasPrice > h[j][0].Tx.GasPrice }
+
+func (h txQueueHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *txQueueHeap) Push(x interface{}) { *h = append(*h, x.([]*TXEntry)) }
+
+func (h *txQueueHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
@@ -19,9 +19,11 @@
 package common
 
 import (
+	"github.com/OnyxPay/OnyxChain/common"
 	"github.com/OnyxPay/OnyxChain/common/log"
 	"github.com/OnyxPay/OnyxChain/core/payload"
 	"github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/OnyxPay/OnyxChain/errors"
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"time"
@@ -53,13 +55,13 @@ func TestTxPool(t *testing.T) {
 	}
 
 	ret := txPool.AddTxList(txEntry)
-	if ret == false {
+	if ret != errors.ErrNoError {
 		t.Error("Failed to add tx to the pool")
 		return
 	}
 
 	ret = txPool.AddTxList(txEntry)
-	if ret == true {
+	if ret != errors.ErrDuplicateInput {
 		t.Error("Failed to add tx to the pool")
 		return
 	}
@@ -98,3 +100,92 @@ func TestTxPool(t *testing.T) {
 		return
 	}
 }
+
+func newTestTxEntry(payer common.Address, nonce uint32, gasPrice uint64) *TXEntry {
+	mutable := &types.MutableTransaction{
+		TxType:   types.Invoke,
+		Nonce:    nonce,
+		GasPrice: gasPrice,
+		Payer:    payer,
+		Payload:  &payload.InvokeCode{Code: []byte{}},
+	}
+	tx, _ := mutable.IntoImmutable()
+	return &TXEntry{Tx: tx, Attrs: []*TXAttr{}}
+}
+
+func TestTxPoolReplace(t *testing.T) {
+	txPool := &TXPool{}
+	txPool.Init()
+	payer := common.Address{1}
+
+	entry := newTestTxEntry(payer, 1, 500)
+	assert.Equal(t, errors.ErrNoError, txPool.AddTxList(entry))
+	assert.Equal(t, errors.ErrUnderpriced, txPool.AddTxList(newTestTxEntry(payer, 1, 400)))
+	assert.Equal(t, errors.ErrUnderpriced, txPool.CheckTxLimit(newTestTxEntry(payer, 1, 500).Tx))
+
+	replace := newTestTxEntry(payer, 1, 600)
+	assert.Equal(t, errors.ErrNoError, txPool.AddTxList(replace))
+	assert.Nil(t, txPool.GetTransaction(entry.Tx.Hash()))
+	assert.NotNil(t, txPool.GetTransaction(replace.Tx.Hash()))
+	assert.Equal(t, 1, txPool.GetTransactionCount())
+
+	stats := txPool.GetStats()
+	assert.Equal(t, uint32(1), stats.Accounts)
+	assert.Equal(t, uint32(1), stats.Replaced)
+	assert.Equal(t, uint32(2), stats.Rejected)
+}
+
+func TestTxPoolLimit(t *testing.T) {
+	txPool := &TXPool{}
+	txPool.Init()
+	txPool.maxTxCount = 3
+	txPool.maxAccountTx = 2
+
+	payer := common.Address{1}
+	assert.Equal(t, errors.ErrNoError, txPool.AddTxList(newTestTxEntry(payer, 1, 500)))
+	assert.Equal(t, errors.ErrNoError, txPool.AddTxList(newTestTxEntry(payer, 2, 700)))
+	assert.Equal(t, errors.ErrAccountTxLimit, txPool.AddTxList(newTestTxEntry(payer, 3, 900)))
+
+	other := common.Address{2}
+	assert.Equal(t, errors.ErrNoError, txPool.AddTxList(newTestTxEntry(other, 1, 600)))
+	assert.Equal(t, errors.ErrTxPoolFull, txPool.AddTxList(newTestTxEntry(other, 2, 500)))
+
+	// the lowest gas price one is evicted
+	high := newTestTxEntry(other, 2, 800)
+	assert.Equal(t, errors.ErrNoError, txPool.AddTxList(high))
+	assert.Equal(t, 3, txPool.GetTransactionCount())
+	assert.Nil(t, txPool.GetTransaction(newTestTxEntry(payer, 1, 500).Tx.Hash()))
+	assert.NotNil(t, txPool.GetTransaction(high.Tx.Hash()))
+	assert.Equal(t, uint32(1), txPool.GetStats().Evicted)
+}
+
+func TestTxPoolOrder(t *testing.T) {
+	txPool := &TXPool{}
+	txPool.Init()
+
+	payer := common.Address{1}
+	other := common.Address{2}
+	entries := []*TXEntry{
+		newTestTxEntry(payer, 1, 500),
+		newTestTxEntry(payer, 2, 900),
+		newTestTxEntry(other, 5, 700),
+		newTestTxEntry(other, 3, 600),
+	}
+	for _, entry := range entries {
+		assert.Equal(t, errors.ErrNoError, txPool.AddTxList(entry))
+	}
+
+	// payer's nonce 2 can not go before nonce 1 in spite of higher gas price
+	txList, _ := txPool.GetTxPool(false, 0)
+	expected := []*TXEntry{entries[3], entries[2], entries[0], entries[1]}
+	assert.Equal(t, len(expected), len(txList))
+	for i, entry := range expected {
+		assert.Equal(t, entry.Tx.Hash(), txList[i].Tx.Hash())
+	}
+
+	txPool.RemoveTxsBelowGasPrice(700)
+	assert.Equal(t, 2, txPool.GetTransactionCount())
+	assert.Equal(t, uint32(2), txPool.GetStats().Accounts)
+	assert.Equal(t, 2, len(txPool.Remain()))
+	assert.Equal(t, uint32(0), txPool.GetStats().Accounts)
+}
@@ -19,13 +19,14 @@
 package common
 
 import (
+	"time"
+
 	"github.com/OnyxPay/OnyxChain/common"
 	"github.com/OnyxPay/OnyxChain/core/types"
 	"github.com/OnyxPay/OnyxChain/errors"
 )
 
 const (
-	MAX_CAPACITY     = 100140                           // The tx pool's capacity that holds the verified txs
 	MAX_PENDING_TXN  = 4096 * 10                        // The max length of pending txs
 	MAX_WORKER_NUM   = 2                                // The max concurrent workers
 	MAX_RCV_TXN_LEN  = MAX_WORKER_NUM * MAX_PENDING_TXN // The max length of the queue that server can hold
@@ -37,6 +38,8 @@ const (
 	MAX_LIMITATION   = 10000                            // The length of pending tx from net and http
 	UPDATE_FREQUENCY = 100                              // The frequency to update gas price from global params
 	MAX_TX_SIZE      = 1024 * 1024                      // The max size of a transaction to prevent DOS attacks
+
+	MIN_PRICE_HEAP_SIZE = 64 // The stale entries allowed in the price heap before it is rebuilt
 )
 
 // ActorType enumerates the kind of actor
@@ -97,6 +100,14 @@ type CheckBlkResult struct {
 	OldTxs        []*types.Transaction
 }
 
+// TxPoolStats contains the metrics of the tx pool
+type TxPoolStats struct {
+	Accounts uint32 // The count of payers which have transactions in the pool
+	Replaced uint32 // The count of transactions replaced by a higher gas price
+	Evicted  uint32 // The count of transactions evicted when the pool is full
+	Rejected uint32 // The count of transactions rejected by the limits of the pool
+}
+
 // TxStatus contains the attributes of a transaction
 type TxStatus struct {
 	Hash  common.Uint256 // transaction hash
@@ -176,7 +187,8 @@ type GetTxnStatsRsp struct {
 type GetTxnCountReq struct {
 }
 
-// GetTxnCountRsp returns current tx count, including pending, and verified
+// GetTxnCountRsp returns current tx count, including verified and pending,
+// followed by the payer count, replaced, evicted and rejected tx count
 type GetTxnCountRsp struct {
 	Count []uint32
 }
@@ -192,6 +204,27 @@ type GetPendingTxnRsp struct {
 	Txs []*types.Transaction
 }
 
+// MemPoolTx contains a transaction in the pool or in the verifying process
+type MemPoolTx struct {
+	Tx       *types.Transaction
+	Attrs    []*TXAttr // the result from each validator
+	Verified bool      // whether the transaction is verified and in the pool
+	Time     time.Time // the time when the transaction is received or enters the pool
+}
+
+// GetMemPoolTxsReq specifies the api that how to list the transactions in
+// the pool and the pending list.
+// Input: an optional payer and contract address to filter the list
+type GetMemPoolTxsReq struct {
+	Payer    *common.Address
+	Contract *common.Address
+}
+
+// GetMemPoolTxsRsp returns a transaction list for GetMemPoolTxsReq.
+type GetMemPoolTxsRsp struct {
+	Txs []*MemPoolTx
+}
+
 // consensus messages
 // GetTxnPoolReq specifies the api that how to get the valid transaction list.
 type GetTxnPoolReq struct {
@@ -252,3 +285,11 @@ func (n OrderByNetWorkFee) Len() int { return len(n) }
 func (n OrderByNetWorkFee) Swap(i, j int) { n[i], n[j] = n[j], n[i] }
 
 func (n OrderByNetWorkFee) Less(i, j int) bool { return n[j].Tx.GasPrice < n[i].Tx.GasPrice }
+
+type OrderByNonce []*TXEntry
+
+func (n OrderByNonce) Len() int { return len(n) }
+
+func (n OrderByNonce) Swap(i, j int) { n[i], n[j] = n[j], n[i] }
+
+func (n OrderByNonce) Less(i, j int) bool { return n[i].Tx.Nonce < n[j].Tx.Nonce }
@@ -140,14 +140,13 @@ func (ta *TxActor) handleTransaction(sender tc.SenderType, self *actor.PID,
 			replyTxResult(txResultCh, txn.Hash(), errors.ErrDuplicateInput,
 				fmt.Sprintf("transaction %x is already in the tx pool", txn.Hash()))
 		}
-	} else if ta.server.getTransactionCount() >= tc.MAX_CAPACITY {
-		log.Debugf("handleTransaction: transaction pool is full for tx %x",
-			txn.Hash())
+	} else if errCode := ta.server.checkTxLimit(txn); errCode != errors.ErrNoError {
+		log.Debugf("handleTransaction: transaction %x rejected by tx pool: %s",
+			txn.Hash(), errCode.Error())
 
 		ta.server.increaseStats(tc.FailureStats)
 		if sender == tc.HttpSender && txResultCh != nil {
-			replyTxResult(txResultCh, txn.Hash(), errors.ErrTxPoolFull,
-				"transaction pool is full")
+			replyTxResult(txResultCh, txn.Hash(), errCode, errCode.Error())
 		}
 	} else {
 		if _, overflow := common.SafeMul(txn.GasLimit, txn.GasPrice); overflow {
@@ -279,6 +278,16 @@ func (ta *TxActor) Receive(context actor.Context) {
 				context.Self())
 		}
 
+	case *tc.GetMemPoolTxsReq:
+		sender := context.Sender()
+
+		log.Debugf("txpool-tx actor receives getting mempool txs req from %v", sender)
+
+		res := ta.server.getMemPoolTxs(msg.Payer, msg.Contract)
+		if sender != nil {
+			sender.Request(&tc.GetMemPoolTxsRsp{Txs: res}, context.Self())
+		}
+
 	default:
 		log.Debugf("txpool-tx actor: unknown msg %v type %v", msg, reflect.TypeOf(msg))
 	}
@@ -30,6 +30,7 @@ import (
 	"github.com/OnyxPay/OnyxChain/common/log"
 	"github.com/OnyxPay/OnyxChain/core/ledger"
 	tx "github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/OnyxPay/OnyxChain/core/utils"
 	"github.com/OnyxPay/OnyxChain/errors"
 	httpcom "github.com/OnyxPay/OnyxChain/http/base/common"
 	params "github.com/OnyxPay/OnyxChain/smartcontract/service/native/global_params"
@@ -39,6 +40,7 @@ import (
 	"sort"
 	"strconv"
 	"sync"
+	"time"
 )
 
 type txStats struct {
@@ -50,6 +52,7 @@ type serverPendingTx struct {
 	tx     *tx.Transaction   // Pending tx
 	sender tc.SenderType     // Indicate which sender tx is from
 	ch     chan *tc.TxResult // channel to send tx result
+	time   time.Time         // The time when server receives the tx
 }
 
 type pendingBlock struct {
@@ -312,6 +315,7 @@ func (s *TXPoolServer) setPendingTx(tx *tx.Transaction,
 		tx:     tx,
 		sender: sender,
 		ch:     txResultCh,
+		time:   time.Now(),
 	}
 
 	s.allPendingTxs[tx.Hash()] = pt
@@ -506,11 +510,15 @@ func (s *TXPoolServer) getTxPool(byCount bool, height uint32) []*tc.TXEntry {
 	return avlTxList
 }
 
-// getTxCount returns current tx count, including pending and verified
+// getTxCount returns current tx count, including verified and pending,
+// followed by the metrics of the tx pool: payer count, replaced, evicted
+// and rejected tx count
 func (s *TXPoolServer) getTxCount() []uint32 {
+	stats := s.txPool.GetStats()
 	ret := make([]uint32, 0)
 	ret = append(ret, uint32(s.txPool.GetTransactionCount()))
 	ret = append(ret, uint32(s.getPendingListSize()))
+	ret = append(ret, stats.Accounts, stats.Replaced, stats.Evicted, stats.Rejected)
 	return ret
 }
 
@@ -525,6 +533,63 @@ func (s *TXPoolServer) getPendingTxs(byCount bool) []*tx.Transaction {
 	return ret
 }
 
+// getMemPoolTxs returns the transactions in the pool and the pending list,
+// filtered by the payer and the called contract if they are not nil
+func (s *TXPoolServer) getMemPoolTxs(payer, contract *common.Address) []*tc.MemPoolTx {
+	match := func(t *tx.Transaction) bool {
+		if payer != nil && t.Payer != *payer {
+			return false
+		}
+		if contract == nil {
+			return true
+		}
+		for _, addr := range utils.GetTxContracts(t) {
+			if addr == *contract {
+				return true
+			}
+		}
+		return false
+	}
+
+	ret := make([]*tc.MemPoolTx, 0)
+	for _, txEntry := range s.txPool.GetTxList() {
+		if !match(txEntry.Tx) {
+			continue
+		}
+		ret = append(ret, &tc.MemPoolTx{
+			Tx:       txEntry.Tx,
+			Attrs:    txEntry.Attrs,
+			Verified: true,
+			Time:     txEntry.Time,
+		})
+	}
+
+	s.mu.RLock()
+	pending := make([]*serverPendingTx, 0, len(s.allPendingTxs))
+	for _, pt := range s.allPendingTxs {
+		if match(pt.tx) {
+			pending = append(pending, pt)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, pt := range pending {
+		memTx := &tc.MemPoolTx{
+			Tx:   pt.tx,
+			Time: pt.time,
+		}
+		if status := s.getTxStatusReq(pt.tx.Hash()); status != nil {
+			memTx.Attrs = status.Attrs
+		}
+		ret = append(ret, memTx)
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Time.Before(ret[j].Time)
+	})
+	return ret
+}
+
 // cleanTransactionList cleans the txs in the block from the ledger
 func (s *TXPoolServer) cleanTransactionList(txs []*tx.Transaction, height uint32) {
 	s.txPool.CleanTransactionList(txs)
@@ -565,14 +630,19 @@ func (s *TXPoolServer) delTransaction(t *tx.Transaction) {
 }
 
 // addTxList adds a valid transaction to the tx pool.
-func (s *TXPoolServer) addTxList(txEntry *tc.TXEntry) bool {
+func (s *TXPoolServer) addTxList(txEntry *tc.TXEntry) errors.ErrCode {
 	ret := s.txPool.AddTxList(txEntry)
-	if !ret {
+	if ret == errors.ErrDuplicateInput {
 		s.increaseStats(tc.DuplicateStats)
 	}
 	return ret
 }
 
+// checkTxLimit checks whether a transaction can be accepted by the limits of the tx pool.
+func (s *TXPoolServer) checkTxLimit(t *tx.Transaction) errors.ErrCode {
+	return s.txPool.CheckTxLimit(t)
+}
+
 // increaseStats increases the count with the stats type
 func (s *TXPoolServer) increaseStats(v tc.TxnStatsType) {
 	s.stats.Lock()
@@ -23,10 +23,13 @@ import (
 	"time"
 
 	"github.com/OnyxPay/OnyxChain-eventbus/actor"
+	"github.com/OnyxPay/OnyxChain/common"
 	"github.com/OnyxPay/OnyxChain/common/log"
 	"github.com/OnyxPay/OnyxChain/core/payload"
 	"github.com/OnyxPay/OnyxChain/core/types"
+	"github.com/OnyxPay/OnyxChain/core/utils"
 	"github.com/OnyxPay/OnyxChain/errors"
+	nutils "github.com/OnyxPay/OnyxChain/smartcontract/service/native/utils"
 	tc "github.com/OnyxPay/OnyxChain/txnpool/common"
 	"github.com/OnyxPay/OnyxChain/validator/stateless"
 	vt "github.com/OnyxPay/OnyxChain/validator/types"
@@ -287,3 +290,71 @@ func TestValidator(t *testing.T) {
 
 	t.Log("Ending validator testing")
 }
+
+func TestGetMemPoolTxs(t *testing.T) {
+	s := NewTxPoolServer(tc.MAX_WORKER_NUM, true, false)
+	defer s.Stop()
+
+	payer := common.Address{1}
+	code, err := utils.BuildNativeInvokeCode(nutils.OnxContractAddress, 0, "transfer", []interface{}{})
+	assert.Nil(t, err)
+	mutable := &types.MutableTransaction{
+		TxType:  types.Invoke,
+		Nonce:   1,
+		Payer:   payer,
+		Payload: &payload.InvokeCode{Code: code},
+	}
+	verified, _ := mutable.IntoImmutable()
+	s.addTxList(&tc.TXEntry{Tx: verified, Attrs: []*tc.TXAttr{}})
+
+	mutable.Nonce = 2
+	mutable.Payer = common.Address{2}
+	pending, _ := mutable.IntoImmutable()
+	s.setPendingTx(pending, tc.NilSender, nil)
+
+	txs := s.getMemPoolTxs(nil, nil)
+	assert.Equal(t, 2, len(txs))
+	assert.Equal(t, verified.Hash(), txs[0].Tx.Hash())
+	assert.True(t, txs[0].Verified)
+	assert.Equal(t, pending.Hash(), txs[1].Tx.Hash())
+	assert.False(t, txs[1].Verified)
+
+	txs = s.getMemPoolTxs(&payer, nil)
+	assert.Equal(t, 1, len(txs))
+	assert.Equal(t, verified.Hash(), txs[0].Tx.Hash())
+
+	txs = s.getMemPoolTxs(nil, &nutils.OnxContractAddress)
+	assert.Equal(t, 2, len(txs))
+	txs = s.getMemPoolTxs(&payer, &nutils.OxgContractAddress)
+	assert.Equal(t, 0, len(txs))
+}
+
+func TestPutTxPoolRejected(t *testing.T) {
+	s := NewTxPoolServer(tc.MAX_WORKER_NUM, true, false)
+	defer s.Stop()
+
+	code, err := utils.BuildNativeInvokeCode(nutils.OnxContractAddress, 0, "transfer", []interface{}{})
+	assert.Nil(t, err)
+	mutable := &types.MutableTransaction{
+		TxType:   types.Invoke,
+		Nonce:    1,
+		GasPrice: 10,
+		Payer:    common.Address{1},
+		Payload:  &payload.InvokeCode{Code: code},
+	}
+	pooled, _ := mutable.IntoImmutable()
+	assert.Equal(t, errors.ErrNoError, s.addTxList(&tc.TXEntry{Tx: pooled, Attrs: []*tc.TXAttr{}}))
+
+	// the same nonce of the payer at a lower gas price can not replace the pooled one
+	mutable.GasPrice = 5
+	underpriced, _ := mutable.IntoImmutable()
+	ch := make(chan *tc.TxResult, 1)
+	assert.True(t, s.setPendingTx(underpriced, tc.HttpSender, ch))
+	ok := s.workers[0].putTxPool(&pendingTx{tx: underpriced, ret: []*tc.TXAttr{}})
+	assert.False(t, ok)
+
+	result := <-ch
+	assert.Equal(t, errors.ErrUnderpriced, result.Err)
+	assert.Equal(t, 0, s.getPendingListSize())
+	assert.Nil(t, s.getTransaction(underpriced.Hash()))
+}
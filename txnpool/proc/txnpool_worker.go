@@ -26,6 +26,8 @@ import (
 	"github.com/OnyxPay/OnyxChain/common/log"
 	tx "github.com/OnyxPay/OnyxChain/core/types"
 	"github.com/OnyxPay/OnyxChain/errors"
+	"github.com/OnyxPay/OnyxChain/events"
+	"github.com/OnyxPay/OnyxChain/events/message"
 	tc "github.com/OnyxPay/OnyxChain/txnpool/common"
 	"github.com/OnyxPay/OnyxChain/validator/types"
 )
@@ -167,9 +169,13 @@ func (worker *txPoolWorker) putTxPool(pt *pendingTx) bool {
 		Tx:    pt.tx,
 		Attrs: pt.ret,
 	}
-	worker.server.addTxList(txEntry)
-	worker.server.removePendingTx(pt.tx.Hash(), errors.ErrNoError)
-	return true
+	ret := worker.server.addTxList(txEntry)
+	if ret == errors.ErrNoError && events.DefActorPublisher != nil {
+		events.DefActorPublisher.Publish(message.TOPIC_PENDING_TX,
+			&message.PendingTxMsg{Tx: pt.tx})
+	}
+	worker.server.removePendingTx(pt.tx.Hash(), ret)
+	return ret == errors.ErrNoError
 }
 
 // verifyTx prepares a check request and sends it to the validators.
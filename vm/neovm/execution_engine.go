@@ -39,6 +39,7 @@ type ExecutionEngine struct {
 	Context         *ExecutionContext
 	OpCode          OpCode
 	OpExec          OpExec
+	Tracer          Tracer
 }
 
 func (this *ExecutionEngine) CurrentContext() *ExecutionContext {
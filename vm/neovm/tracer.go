@@ -0,0 +1,143 @@
+/*
+ * Copyright (C) 2019 The onyxchain Authors
+ * This file is part of The onyxchain library.
+ *
+ * The onyxchain is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The onyxchain is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The onyxchain.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package neovm
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/OnyxPay/OnyxChain/vm/neovm/types"
+)
+
+const (
+	DEFAULT_TRACE_STEP_LIMIT = 10000 // max steps recorded by a StructLogger
+	MAX_TRACE_STACK_DEPTH    = 16    // max stack items recorded by a step, counted from the top
+	MAX_TRACE_ITEM_BYTES     = 64    // max bytes of a byte array recorded by a stack item
+)
+
+//Tracer is notified by the vm service before every instruction is executed
+type Tracer interface {
+	//CaptureStep is called once the gas of the current instruction is charged
+	CaptureStep(engine *ExecutionEngine, contract string, gasCost uint64)
+	//CaptureSyscall is called when the current instruction invokes a system service
+	CaptureSyscall(engine *ExecutionEngine, name string, gasCost uint64)
+}
+
+//StepLog is a single executed instruction
+type StepLog struct {
+	Contract string
+	Pc       int
+	Op       string
+	GasCost  uint64
+	GasUsed  uint64
+	Syscall  string
+	Stack    []string
+	StackLen int
+}
+
+//StructLogger records every executed instruction with a snapshot of the top of evaluation stack
+type StructLogger struct {
+	Steps     []*StepLog
+	GasUsed   uint64
+	Truncated bool
+	limit     int
+}
+
+//NewStructLogger return a StructLogger recording at most limit steps
+func NewStructLogger(limit int) *StructLogger {
+	if limit <= 0 {
+		limit = DEFAULT_TRACE_STEP_LIMIT
+	}
+	return &StructLogger{limit: limit}
+}
+
+func (this *StructLogger) CaptureStep(engine *ExecutionEngine, contract string, gasCost uint64) {
+	this.GasUsed += gasCost
+	if len(this.Steps) >= this.limit {
+		this.Truncated = true
+		return
+	}
+	step := &StepLog{
+		Contract: contract,
+		Pc:       engine.Context.GetInstructionPointer() - 1,
+		Op:       opName(engine.OpCode),
+		GasCost:  gasCost,
+		GasUsed:  this.GasUsed,
+	}
+	step.StackLen = engine.EvaluationStack.Count()
+	count := step.StackLen
+	if count > MAX_TRACE_STACK_DEPTH {
+		count = MAX_TRACE_STACK_DEPTH
+	}
+	step.Stack = make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		step.Stack = append(step.Stack, stackItemString(engine.EvaluationStack.Peek(i)))
+	}
+	this.Steps = append(this.Steps, step)
+}
+
+func (this *StructLogger) CaptureSyscall(engine *ExecutionEngine, name string, gasCost uint64) {
+	this.GasUsed += gasCost
+	if this.Truncated || len(this.Steps) == 0 {
+		return
+	}
+	step := this.Steps[len(this.Steps)-1]
+	step.Syscall = name
+	step.GasCost += gasCost
+	step.GasUsed = this.GasUsed
+}
+
+func opName(op OpCode) string {
+	if name := OpExecList[op].Name; name != "" {
+		return name
+	}
+	if op >= PUSHBYTES1 && op <= PUSHBYTES75 {
+		return fmt.Sprintf("PUSHBYTES%d", op)
+	}
+	return fmt.Sprintf("0x%02x", byte(op))
+}
+
+func stackItemString(item types.StackItems) string {
+	switch v := item.(type) {
+	case *types.ByteArray:
+		b, _ := v.GetByteArray()
+		if len(b) > MAX_TRACE_ITEM_BYTES {
+			return fmt.Sprintf("%s...(%d bytes)", hex.EncodeToString(b[:MAX_TRACE_ITEM_BYTES]), len(b))
+		}
+		return hex.EncodeToString(b)
+	case *types.Integer:
+		i, _ := v.GetBigInteger()
+		return i.String()
+	case *types.Boolean:
+		b, _ := v.GetBoolean()
+		return fmt.Sprintf("%t", b)
+	case *types.Array:
+		a, _ := v.GetArray()
+		return fmt.Sprintf("Array[%d]", len(a))
+	case *types.Struct:
+		s, _ := v.GetStruct()
+		return fmt.Sprintf("Struct[%d]", len(s))
+	case *types.Map:
+		m, _ := v.GetMap()
+		return fmt.Sprintf("Map[%d]", len(m))
+	case *types.Interop:
+		return "Interop"
+	}
+	return fmt.Sprintf("%v", item)
+}
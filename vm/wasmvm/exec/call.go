@@ -65,6 +65,7 @@ func (vm *VM) doCall(compiled compiledFunction, index int64) {
 			rtn, err := v(vm.Engine)
 			if err != nil || !rtn {
 				log.Errorf("call method :%s failed\n", compiled.name)
+				panic(&HostCallError{Name: compiled.name, Err: err})
 			}
 		} else {
 			vm.ctx = prevCtxt
@@ -97,6 +98,20 @@ var (
 	ErrUndefinedElementIndex = errors.New("exec: undefined element index")
 )
 
+// HostCallError is the error value used while trapping the VM when an
+// imported service function failed
+type HostCallError struct {
+	Name string
+	Err  error
+}
+
+func (e *HostCallError) Error() string {
+	if e.Err == nil {
+		return "exec: call service " + e.Name + " failed"
+	}
+	return "exec: call service " + e.Name + " failed: " + e.Err.Error()
+}
+
 func (vm *VM) call() {
 	index := vm.fetchUint32()
 	vm.doCall(vm.compiledFuncs[index], int64(index))
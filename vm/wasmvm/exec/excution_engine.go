@@ -39,8 +39,14 @@ const (
 	CONTRACT_METHOD_NAME = "invoke"
 	CONTRACT_INIT_METHOD = "init"
 	VM_STACK_DEPTH       = 10
+	OPCODE_GAS           = uint64(1)
 )
 
+var ERR_GAS_INSUFFICIENT = errors.NewErr("[wasmvm] gas insufficient")
+
+// GasMeter charge the gas of executed instructions, return false when gas is insufficient
+type GasMeter func(gas uint64) bool
+
 // backup vm while call other contracts
 type vmstack struct {
 	top   int
@@ -92,6 +98,19 @@ type ExecutionEngine struct {
 	CodeContainer interfaces.CodeContainer
 	vm            *VM
 	backupVM      *vmstack
+	gasMeter      GasMeter
+}
+
+// SetGasMeter set the gas meter used to charge every executed instruction
+func (e *ExecutionEngine) SetGasMeter(meter GasMeter) {
+	e.gasMeter = meter
+}
+
+// UseGas charge gas from the gas meter, abort execution when gas is insufficient
+func (e *ExecutionEngine) UseGas(gas uint64) {
+	if e.gasMeter != nil && !e.gasMeter(gas) {
+		panic(ERR_GAS_INSUFFICIENT)
+	}
 }
 
 //GetVM return vm pointer
@@ -320,7 +339,7 @@ func (e *ExecutionEngine) Call(caller common.Address,
 	defer func() {
 		if err := recover(); err != nil {
 			returnbytes = nil
-			er = errors.NewErr("[Call] error happened while call wasmvm")
+			er = recoverError(err)
 		}
 	}()
 
@@ -343,7 +362,7 @@ func (e *ExecutionEngine) InitCall(caller common.Address,
 	defer func() {
 		if err := recover(); err != nil {
 			returnbytes = nil
-			er = errors.NewErr("[Call] error happened while call wasmvm")
+			er = recoverError(err)
 		}
 	}()
 
@@ -351,6 +370,17 @@ func (e *ExecutionEngine) InitCall(caller common.Address,
 
 }
 
+// recoverError convert the recovered panic to the error returned by Call
+func recoverError(err interface{}) error {
+	if err == ERR_GAS_INSUFFICIENT {
+		return ERR_GAS_INSUFFICIENT
+	}
+	if e, ok := err.(*HostCallError); ok {
+		return errors.NewErr("[Call] error happened while call wasmvm:" + e.Error())
+	}
+	return errors.NewErr("[Call] error happened while call wasmvm")
+}
+
 // call to execute wasm vm
 func (e *ExecutionEngine) call(caller common.Address,
 	code []byte,
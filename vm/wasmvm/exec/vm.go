@@ -387,6 +387,9 @@ outer:
 	for int(vm.ctx.pc) < len(vm.ctx.code) {
 		op := vm.ctx.code[vm.ctx.pc]
 		vm.ctx.pc++
+		if vm.Engine != nil {
+			vm.Engine.UseGas(OPCODE_GAS)
+		}
 
 		switch op {
 		case ops.Return: